@@ -13,8 +13,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/distribution/reference"
 	"github.com/dustin/go-humanize"
@@ -22,16 +26,33 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/chunkdict/generator"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/committer"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/config"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/copier"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/doctor"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/gc"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/inspector"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/operator"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/optimizer"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/packer"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/preheat"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/progress"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/reverter"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/sbom"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/server"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/viewer"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/watcher"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
 )
 
 var (
@@ -44,6 +65,11 @@ var maxCacheMaxRecords uint = 200
 
 const defaultLogLevel = logrus.InfoLevel
 
+const (
+	outputTypeRegistry  = "registry"
+	outputTypeOCILayout = "oci-layout"
+)
+
 func isPossibleValue(excepted []string, value string) bool {
 	for _, v := range excepted {
 		if value == v {
@@ -79,7 +105,7 @@ func getBackendConfig(c *cli.Context, prefix string, required bool) (string, str
 		return "", "", nil
 	}
 
-	possibleBackendTypes := []string{"oss", "s3", "localfs"}
+	possibleBackendTypes := []string{"oss", "s3", "azblob", "gcs", "localfs", "external", "http", "ipfs", "hdfs"}
 	if !isPossibleValue(possibleBackendTypes, backendType) {
 		return "", "", fmt.Errorf("--%sbackend-type should be one of %v", prefix, possibleBackendTypes)
 	}
@@ -89,13 +115,158 @@ func getBackendConfig(c *cli.Context, prefix string, required bool) (string, str
 	)
 	if err != nil {
 		return "", "", err
-	} else if (backendType == "oss" || backendType == "s3" || backendType == "localfs") && strings.TrimSpace(backendConfig) == "" {
+	} else if strings.TrimSpace(backendConfig) == "" {
 		return "", "", errors.Errorf("backend configuration is empty, please specify option '--%sbackend-config'", prefix)
 	}
 
+	if backendType == "external" {
+		backendConfig, err = getExternalBackendConfig(c, prefix, backendConfig)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	return backendType, backendConfig, nil
 }
 
+var (
+	// convertBackendTypes are the backend types supported by the
+	// nydus-snapshotter backend, which pushes the blobs in convert.
+	convertBackendTypes = []string{"oss", "s3", "localfs"}
+	// nydusdBackendTypes are the backend types supported by nydusd,
+	// which reads the blobs in check and mount.
+	nydusdBackendTypes = []string{"oss", "s3", "localfs", "ipfs"}
+)
+
+// checkBackendType checks the backend type against the ones supported by
+// the command, the backend types accepted by getBackendConfig are supported
+// by copy, gc and chunkdict generate, but not all of them can be used by
+// the others.
+func checkBackendType(prefix, backendType string, supported []string) error {
+	if backendType != "" && !isPossibleValue(supported, backendType) {
+		return fmt.Errorf("--%sbackend-type %s is not supported by this command, should be one of %v", prefix, backendType, supported)
+	}
+	return nil
+}
+
+// getExternalBackendConfig wraps the backend configuration passed to the
+// backend plugin of external backend.
+func getExternalBackendConfig(c *cli.Context, prefix, backendConfig string) (string, error) {
+	supported := false
+	if c.Command != nil {
+		for _, flag := range c.Command.Flags {
+			if flag.Names()[0] == prefix+"backend-plugin" {
+				supported = true
+			}
+		}
+	}
+	if !supported {
+		return "", errors.Errorf("external backend is not supported by this command for option '--%sbackend-type'", prefix)
+	}
+	plugin := c.String(prefix + "backend-plugin")
+	if plugin == "" {
+		return "", errors.Errorf("backend plugin is required by external backend, please specify option '--%sbackend-plugin'", prefix)
+	}
+
+	config, err := json.Marshal(backend.ExternalConfig{
+		Plugin: plugin,
+		Config: json.RawMessage(backendConfig),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "invalid backend configuration")
+	}
+	return string(config), nil
+}
+
+// getCacheBackendConfig returns the storage backend configuration to access
+// the build cache in object storage.
+func getCacheBackendConfig(c *cli.Context, cacheRef string) (string, error) {
+	backendConfig := c.String("build-cache-backend-config")
+	backendConfigFile := c.String("build-cache-backend-config-file")
+	if backendConfig != "" && backendConfigFile != "" {
+		return "", fmt.Errorf("--build-cache-backend-config conflicts with --build-cache-backend-config-file")
+	}
+	if (backendConfig != "" || backendConfigFile != "") && !cache.IsObject(cacheRef) {
+		return "", fmt.Errorf("--build-cache-backend-config requires a build cache in object storage")
+	}
+	return parseBackendConfig(backendConfig, backendConfigFile)
+}
+
+// openImage opens the Nydus image of positional arguments `<image reference>
+// <path>` for ls and cat commands.
+func openImage(c *cli.Context) (*inspector.Image, string, error) {
+	if c.NArg() != 2 {
+		return nil, "", errors.Errorf("expected arguments <image reference> <path>, but got %d arguments", c.NArg())
+	}
+
+	_, arch, err := provider.ExtractOsArch(c.String("platform"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	image, err := inspector.Open(context.Background(), inspector.Opt{
+		WorkDir:        c.String("work-dir"),
+		Target:         c.Args().Get(0),
+		TargetInsecure: c.Bool("target-insecure"),
+		ExpectedArch:   arch,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return image, c.Args().Get(1), nil
+}
+
+// getRegistryBackendConfig gets the registry backend configuration to
+// access the blobs of image reference by nydusd.
+func getRegistryBackendConfig(ref string, insecure bool) (string, error) {
+	parsed, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+
+	backendConfigStruct, err := utils.NewRegistryBackendConfig(parsed, insecure)
+	if err != nil {
+		return "", errors.Wrap(err, "parse registry backend configuration")
+	}
+
+	bytes, err := json.Marshal(backendConfigStruct)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal registry backend configuration")
+	}
+	return string(bytes), nil
+}
+
+// getTransportOption gets the CA bundle, client certificate and proxy to
+// access the source or target registry by prefix.
+func getTransportOption(c *cli.Context, prefix string) (remote.TransportOption, error) {
+	opt := remote.TransportOption{
+		CACert:     c.String(prefix + "ca-cert"),
+		ClientCert: c.String(prefix + "cert"),
+		ClientKey:  c.String(prefix + "key"),
+		Proxy:      c.String("proxy"),
+	}
+	if err := opt.Validate(); err != nil {
+		return opt, errors.Wrapf(err, "invalid options --%sca-cert, --%scert, --%skey or --proxy", prefix, prefix, prefix)
+	}
+	return opt, nil
+}
+
+// getCredentialOption gets the credential to access the source or target
+// registry by prefix.
+func getCredentialOption(c *cli.Context, prefix string) (provider.CredentialOption, error) {
+	opt := provider.CredentialOption{
+		Username:      c.String(prefix + "username"),
+		Password:      c.String(prefix + "password"),
+		IdentityToken: c.String(prefix + "token"),
+		Helper:        c.String(prefix + "credential-helper"),
+	}
+	if err := opt.Validate(); err != nil {
+		return opt, errors.Wrapf(err, "invalid options --%susername, --%spassword, --%stoken or --%scredential-helper", prefix, prefix, prefix, prefix)
+	}
+	return opt, nil
+}
+
 // Add suffix to source image reference as the target
 // image reference, like this:
 // Source: localhost:5000/nginx:latest
@@ -132,6 +303,363 @@ func getTargetReference(c *cli.Context) (string, error) {
 	return target, nil
 }
 
+func getOutputLayout(c *cli.Context) (string, error) {
+	outputType := c.String("output-type")
+	outputDir := c.String("output-dir")
+	switch outputType {
+	case outputTypeRegistry:
+		if outputDir != "" {
+			return "", fmt.Errorf("--output-dir is only supported by '--output-type %s'", outputTypeOCILayout)
+		}
+		return "", nil
+	case outputTypeOCILayout:
+		if outputDir == "" {
+			return "", fmt.Errorf("--output-dir is required by '--output-type %s'", outputTypeOCILayout)
+		}
+		return outputDir, nil
+	default:
+		return "", fmt.Errorf("--output-type should be one of %v", []string{outputTypeRegistry, outputTypeOCILayout})
+	}
+}
+
+func getBatchImages(manifestPath, targetSuffix string) ([]converter.BatchImage, error) {
+	manifest, err := converter.ParseBatchManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range manifest.Images {
+		image := &manifest.Images[idx]
+		if image.Target != "" {
+			continue
+		}
+		if targetSuffix == "" {
+			return nil, fmt.Errorf("target of image %s is not specified in batch manifest, --target-suffix is required", image.Source)
+		}
+		image.Target, err = addReferenceSuffix(image.Source, targetSuffix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest.Images, nil
+}
+
+// workDirQuotaFlag is the flag to limit the usage of work directory.
+func workDirQuotaFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:    "work-dir-quota",
+		Value:   "0",
+		Usage:   "Maximum disk usage of working directory, e.g. 20GB, the command fails fast if the space is estimated to be insufficient, 0 means unlimited",
+		EnvVars: []string{"WORK_DIR_QUOTA"},
+	}
+}
+
+func getWorkDirQuota(c *cli.Context) (int64, error) {
+	quota, err := humanize.ParseBytes(c.String("work-dir-quota"))
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid --work-dir-quota")
+	}
+	return int64(quota), nil
+}
+
+// existenceCacheFlag is the flag to cache the existence of blobs in storage
+// backend across the copy and gc commands.
+func existenceCacheFlag() cli.Flag {
+	return &cli.PathFlag{
+		Name:      "backend-existence-cache",
+		TakesFile: true,
+		Usage:     "File to cache the blobs known to exist in storage backend, so that the repeated commands skip checking them in backend, e.g. ~/.nydusify/existence-cache.json",
+		EnvVars:   []string{"BACKEND_EXISTENCE_CACHE"},
+	}
+}
+
+func existenceCacheTTLFlag() cli.Flag {
+	return &cli.DurationFlag{
+		Name:    "backend-existence-cache-ttl",
+		Value:   24 * time.Hour,
+		Usage:   "Check the blobs in storage backend again if they are cached by --backend-existence-cache longer than the duration",
+		EnvVars: []string{"BACKEND_EXISTENCE_CACHE_TTL"},
+	}
+}
+
+// openExistenceCache opens the existence cache specified by flags, nil is
+// returned if it's not specified.
+func openExistenceCache(c *cli.Context) (*backend.ExistenceCache, error) {
+	path := c.Path("backend-existence-cache")
+	if path == "" {
+		return nil, nil
+	}
+	cache, err := backend.OpenExistenceCache(path, c.Duration("backend-existence-cache-ttl"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid --backend-existence-cache")
+	}
+	return cache, nil
+}
+
+// saveExistenceCache saves the existence cache, which is best effort as the
+// cache can be rebuilt from backend.
+func saveExistenceCache(cache *backend.ExistenceCache) {
+	if cache == nil {
+		return
+	}
+	hits, misses := cache.Stats()
+	logrus.Infof("backend existence cache: %d hits, %d misses", hits, misses)
+	if err := cache.Save(); err != nil {
+		logrus.WithError(err).Warn("failed to save backend existence cache")
+	}
+}
+
+// applyConfig applies the configuration file to the defaults of the flags
+// of app, the flags specified on command line or by environment variables
+// take precedence.
+func applyConfig(c *cli.Context) error {
+	path := c.Path("config")
+	optional := path == ""
+	if optional {
+		path = config.DefaultPath()
+	}
+	cfg, err := config.Load(path, optional)
+	if err != nil {
+		return err
+	}
+
+	// The global flags are parsed already, so they are set instead.
+	known := map[string]bool{"config": true}
+	for _, flag := range c.App.Flags {
+		name := flag.Names()[0]
+		known[name] = true
+		value, ok := cfg.Flags[name]
+		if !ok || c.IsSet(name) {
+			continue
+		}
+		values, _ := config.Strings(value)
+		for _, value := range values {
+			if err := c.Set(name, value); err != nil {
+				return errors.Wrapf(err, "invalid value of %s in config file", name)
+			}
+		}
+	}
+
+	commands := map[string]bool{}
+	var apply func(parents []string, cmds []*cli.Command) error
+	apply = func(parents []string, cmds []*cli.Command) error {
+		for _, cmd := range cmds {
+			names := append(append([]string{}, parents...), cmd.Name)
+			path := config.CommandPath(names...)
+			commands[path] = true
+			flags := map[string]bool{}
+			defaults := cfg.Defaults(path)
+			for _, flag := range cmd.Flags {
+				name := flag.Names()[0]
+				known[name] = true
+				flags[name] = true
+				value, ok := defaults[name]
+				if !ok {
+					continue
+				}
+				if err := setFlagDefault(flag, value); err != nil {
+					return errors.Wrapf(err, "invalid value of %s of command %s in config file", name, path)
+				}
+			}
+			for name := range cfg.Commands[path] {
+				if !flags[name] {
+					return errors.Errorf("unknown flag %s of command %s in config file", name, path)
+				}
+			}
+			if err := apply(names, cmd.Subcommands); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := apply(nil, c.App.Commands); err != nil {
+		return err
+	}
+
+	for path := range cfg.Commands {
+		if !commands[path] {
+			return errors.Errorf("unknown command %s in config file", path)
+		}
+	}
+	for name := range cfg.Flags {
+		if !known[name] {
+			return errors.Errorf("unknown flag %s in config file", name)
+		}
+	}
+	return nil
+}
+
+// setFlagDefault sets the default of flag to value in configuration file,
+// the flag isn't required anymore as it has a default.
+func setFlagDefault(flag cli.Flag, value interface{}) error {
+	values, err := config.Strings(value)
+	if err != nil {
+		return err
+	}
+	if _, ok := flag.(*cli.StringSliceFlag); !ok && len(values) != 1 {
+		return errors.New("expect a single value")
+	}
+
+	switch flag := flag.(type) {
+	case *cli.StringFlag:
+		flag.Value = values[0]
+		flag.Required = false
+	case *cli.PathFlag:
+		flag.Value = values[0]
+		flag.Required = false
+	case *cli.StringSliceFlag:
+		flag.Value = cli.NewStringSlice(values...)
+		flag.Required = false
+	case *cli.BoolFlag:
+		v, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return err
+		}
+		flag.Value = v
+		flag.Required = false
+	case *cli.IntFlag:
+		v, err := strconv.ParseInt(values[0], 0, 0)
+		if err != nil {
+			return err
+		}
+		flag.Value = int(v)
+		flag.Required = false
+	case *cli.UintFlag:
+		v, err := strconv.ParseUint(values[0], 0, 0)
+		if err != nil {
+			return err
+		}
+		flag.Value = uint(v)
+		flag.Required = false
+	case *cli.DurationFlag:
+		v, err := time.ParseDuration(values[0])
+		if err != nil {
+			return err
+		}
+		flag.Value = v
+		flag.Required = false
+	default:
+		return errors.Errorf("unsupported flag type %T", flag)
+	}
+	return nil
+}
+
+// bashCompletion and zshCompletion complete the command line by the
+// completions generated by the hidden --generate-bash-completion flag.
+const bashCompletion = `# bash completion for {{.}}
+_{{.}}_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" "${cur}" --generate-bash-completion 2>/dev/null)
+  else
+    opts=$("${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null)
+  fi
+  COMPREPLY=($(compgen -W "${opts}" -- "${cur}"))
+  return 0
+}
+complete -o bashdefault -o default -F _{{.}}_complete {{.}}
+`
+
+const zshCompletion = `#compdef {{.}}
+_{{.}}() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+if [ "$funcstack[1]" = "_{{.}}" ]; then
+  _{{.}} "$@"
+else
+  compdef _{{.}} {{.}}
+fi
+`
+
+// completionScript generates the completion script of shell for app.
+func completionScript(app *cli.App, shell string) (string, error) {
+	name := app.HelpName
+	if name == "" {
+		name = "nydusify"
+	}
+	switch shell {
+	case "bash":
+		return strings.ReplaceAll(bashCompletion, "{{.}}", name), nil
+	case "zsh":
+		return strings.ReplaceAll(zshCompletion, "{{.}}", name), nil
+	case "fish":
+		// The fish completion is generated for the name of app.
+		appName := app.Name
+		app.Name = name
+		defer func() {
+			app.Name = appName
+		}()
+		return app.ToFishCompletion()
+	}
+	return "", errors.Errorf("unsupported shell %q, possible values: bash, zsh, fish", shell)
+}
+
+// keepWorkDirFlag is the flag to keep the temp directory in work directory,
+// value is the default policy of command.
+func keepWorkDirFlag(value string) cli.Flag {
+	return &cli.StringFlag{
+		Name:    "keep-work-dir",
+		Value:   value,
+		Usage:   "When to keep the temp directory in working directory after the command for debugging, possible values: 'never', 'on-failure', 'always'",
+		EnvVars: []string{"KEEP_WORK_DIR"},
+	}
+}
+
+func getKeepWorkDir(c *cli.Context) (string, error) {
+	policy := c.String("keep-work-dir")
+	if err := workdir.ValidatePolicy(policy); err != nil {
+		return "", errors.Wrap(err, "invalid --keep-work-dir")
+	}
+	return policy, nil
+}
+
+// cacheFlags are the flags to access the content-addressed build cache.
+func cacheFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "build-cache",
+			Required: true,
+			Usage:    "Repository of content-addressed build cache, a local directory like dir:///var/cache/nydusify, or a location in object storage like s3://bucket/prefix",
+			EnvVars:  []string{"BUILD_CACHE"},
+		},
+		&cli.BoolFlag{
+			Name:    "build-cache-insecure",
+			Usage:   "Skip verifying server certs for HTTPS cache registry",
+			EnvVars: []string{"BUILD_CACHE_INSECURE"},
+		},
+		&cli.StringFlag{
+			Name:    "build-cache-backend-config",
+			Usage:   "Storage backend configuration in JSON to access the build cache in object storage, e.g. endpoint and credentials",
+			EnvVars: []string{"BUILD_CACHE_BACKEND_CONFIG"},
+		},
+		&cli.PathFlag{
+			Name:      "build-cache-backend-config-file",
+			Usage:     "Storage backend configuration file to access the build cache in object storage",
+			TakesFile: true,
+			EnvVars:   []string{"BUILD_CACHE_BACKEND_CONFIG_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "work-dir",
+			Value:   "./tmp",
+			Usage:   "Working directory to access the build cache, will be cleaned up after accessing",
+			EnvVars: []string{"WORK_DIR"},
+		},
+	}
+}
+
 func getCacheReference(c *cli.Context, target string) (string, error) {
 	cache := c.String("build-cache")
 	cacheTag := c.String("build-cache-tag")
@@ -151,13 +679,26 @@ func getCacheReference(c *cli.Context, target string) (string, error) {
 func getPrefetchPatterns(c *cli.Context) (string, error) {
 	prefetchedDir := c.String("prefetch-dir")
 	prefetchPatterns := c.Bool("prefetch-patterns")
+	prefetchTraces := c.StringSlice("prefetch-trace")
 
 	if len(prefetchedDir) > 0 && prefetchPatterns {
 		return "", fmt.Errorf("--prefetch-dir conflicts with --prefetch-patterns")
 	}
+	if len(prefetchTraces) > 0 && (len(prefetchedDir) > 0 || prefetchPatterns) {
+		return "", fmt.Errorf("--prefetch-trace conflicts with --prefetch-dir and --prefetch-patterns")
+	}
 
 	var patterns string
 
+	if len(prefetchTraces) > 0 {
+		files, err := optimizer.LoadPrefetchTraces(prefetchTraces)
+		if err != nil {
+			return "", errors.Wrap(err, "load prefetch traces")
+		}
+		logrus.Infof("use %d files accessed in prefetch traces as prefetch patterns", len(files))
+		patterns = strings.Join(files, "\n")
+	}
+
 	if prefetchPatterns {
 		bytes, err := io.ReadAll(os.Stdin)
 		if err != nil {
@@ -192,6 +733,29 @@ func main() {
 
 	// global options
 	app.Flags = getGlobalFlags()
+	app.EnableBashCompletion = true
+
+	// The spans are flushed after the command, even if it failed.
+	shutdownTracing := func(context.Context) error { return nil }
+	app.Before = func(c *cli.Context) error {
+		if err := applyConfig(c); err != nil {
+			return err
+		}
+		shutdown, err := tracing.Setup(context.Background(), c.String("otlp-endpoint"), gitVersion)
+		if err != nil {
+			return err
+		}
+		shutdownTracing = shutdown
+		return nil
+	}
+	app.After = func(*cli.Context) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logrus.WithError(err).Warn("failed to export traces")
+		}
+		return nil
+	}
 
 	app.Commands = []*cli.Command{
 		{
@@ -200,10 +764,29 @@ func main() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     "source",
-					Required: true,
-					Usage:    "Source OCI image reference",
+					Required: false,
+					Usage:    "Source OCI image reference, or a local image like 'oci:/path/to/layout[:ref]', 'docker-archive:/path/to/image.tar[:ref]' and 'containerd://<namespace>/<image>', conflicts with --batch",
 					EnvVars:  []string{"SOURCE"},
 				},
+				&cli.StringFlag{
+					Name:    "containerd-address",
+					Value:   "/run/containerd/containerd.sock",
+					Usage:   "Containerd address to read the source image of 'containerd://<namespace>/<image>'",
+					EnvVars: []string{"CONTAINERD_ADDR"},
+				},
+				&cli.PathFlag{
+					Name:      "batch",
+					Value:     "",
+					TakesFile: true,
+					Usage:     "YAML or JSON manifest listing source/target image pairs to be converted in batch, conflicts with --source and --target",
+					EnvVars:   []string{"BATCH"},
+				},
+				&cli.UintFlag{
+					Name:    "batch-workers",
+					Value:   converter.DefaultBatchWorkers,
+					Usage:   "Maximum number of images to be converted concurrently in batch mode",
+					EnvVars: []string{"BATCH_WORKERS"},
+				},
 				&cli.StringFlag{
 					Name:     "target",
 					Required: false,
@@ -213,13 +796,13 @@ func main() {
 				&cli.StringFlag{
 					Name:    "source-backend-type",
 					Value:   "",
-					Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:   "Type of source backend to convert a model instead of image, possible values: 'modelfile', 'model-artifact'. The Nydus image with blobs in storage backend is supported as the source of 'revert' and 'copy' only",
 					EnvVars: []string{"BACKEND_TYPE"},
 				},
 				&cli.StringFlag{
 					Name:    "source-backend-config",
 					Value:   "",
-					Usage:   "Json configuration string for storage backend",
+					Usage:   "Json configuration string for source backend",
 					EnvVars: []string{"BACKEND_CONFIG"},
 				},
 				&cli.StringFlag{
@@ -240,11 +823,106 @@ func main() {
 					Usage:    "Skip verifying server certs for HTTPS target registry",
 					EnvVars:  []string{"TARGET_INSECURE"},
 				},
+				&cli.StringSliceFlag{
+					Name:    "source-mirror",
+					Usage:   "Mirror of source registry tried in order before the source registry, fails over to the next one on failure, e.g. 'https://mirror.example.com', can be specified multiple times",
+					EnvVars: []string{"SOURCE_MIRROR"},
+				},
+				&cli.PathFlag{
+					Name:      "source-ca-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify server certs of source registry, appended to system CAs",
+					EnvVars:   []string{"SOURCE_CA_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "source-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded client certificate for mutual TLS with source registry, requires --source-key",
+					EnvVars:   []string{"SOURCE_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "source-key",
+					TakesFile: true,
+					Usage:     "PEM encoded client key for mutual TLS with source registry, requires --source-cert",
+					EnvVars:   []string{"SOURCE_KEY"},
+				},
+				&cli.PathFlag{
+					Name:      "target-ca-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify server certs of target registry, appended to system CAs",
+					EnvVars:   []string{"TARGET_CA_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "target-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded client certificate for mutual TLS with target registry, requires --target-key",
+					EnvVars:   []string{"TARGET_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "target-key",
+					TakesFile: true,
+					Usage:     "PEM encoded client key for mutual TLS with target registry, requires --target-cert",
+					EnvVars:   []string{"TARGET_KEY"},
+				},
+				&cli.StringFlag{
+					Name:    "source-username",
+					Value:   "",
+					Usage:   "Username to access source registry, overrides the credential in docker config",
+					EnvVars: []string{"SOURCE_USERNAME"},
+				},
+				&cli.StringFlag{
+					Name:    "source-password",
+					Value:   "",
+					Usage:   "Password to access source registry, requires --source-username",
+					EnvVars: []string{"SOURCE_PASSWORD"},
+				},
+				&cli.StringFlag{
+					Name:    "source-token",
+					Value:   "",
+					Usage:   "Identity token (OAuth2 refresh token) to access source registry",
+					EnvVars: []string{"SOURCE_TOKEN"},
+				},
+				&cli.StringFlag{
+					Name:    "source-credential-helper",
+					Value:   "",
+					Usage:   "Docker credential helper to get credential of source registry, e.g. 'ecr-login' for docker-credential-ecr-login",
+					EnvVars: []string{"SOURCE_CREDENTIAL_HELPER"},
+				},
+				&cli.StringFlag{
+					Name:    "target-username",
+					Value:   "",
+					Usage:   "Username to access target registry, overrides the credential in docker config",
+					EnvVars: []string{"TARGET_USERNAME"},
+				},
+				&cli.StringFlag{
+					Name:    "target-password",
+					Value:   "",
+					Usage:   "Password to access target registry, requires --target-username",
+					EnvVars: []string{"TARGET_PASSWORD"},
+				},
+				&cli.StringFlag{
+					Name:    "target-token",
+					Value:   "",
+					Usage:   "Identity token (OAuth2 refresh token) to access target registry",
+					EnvVars: []string{"TARGET_TOKEN"},
+				},
+				&cli.StringFlag{
+					Name:    "target-credential-helper",
+					Value:   "",
+					Usage:   "Docker credential helper to get credential of target registry, e.g. 'gcloud' for docker-credential-gcloud",
+					EnvVars: []string{"TARGET_CREDENTIAL_HELPER"},
+				},
+				&cli.StringFlag{
+					Name:    "proxy",
+					Value:   "",
+					Usage:   "HTTP/HTTPS/SOCKS5 proxy URL to access registries, overrides HTTP_PROXY and HTTPS_PROXY, e.g. 'http://proxy.example.com:3128'",
+					EnvVars: []string{"REGISTRY_PROXY"},
+				},
 
 				&cli.StringFlag{
 					Name:    "backend-type",
 					Value:   "",
-					Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:   "Type of storage backend, possible values: 'oss', 's3', 'localfs'",
 					EnvVars: []string{"BACKEND_TYPE"},
 				},
 				&cli.StringFlag{
@@ -269,7 +947,7 @@ func main() {
 				&cli.StringFlag{
 					Name:    "build-cache",
 					Value:   "",
-					Usage:   "Specify a cache image to accelerate nydus image conversion",
+					Usage:   "Specify a cache image, a local directory like dir:///var/cache/nydusify, or a location in object storage like s3://bucket/prefix or oss://bucket/prefix, to accelerate nydus image conversion",
 					EnvVars: []string{"BUILD_CACHE"},
 				},
 				&cli.StringFlag{
@@ -297,14 +975,46 @@ func main() {
 				&cli.UintFlag{
 					Name:    "build-cache-max-records",
 					Value:   maxCacheMaxRecords,
-					Usage:   "Maximum cache records in a cache image",
+					Usage:   "Maximum cache records in a cache image, or in content-addressed build cache",
 					EnvVars: []string{"BUILD_CACHE_MAX_RECORDS"},
 				},
+				&cli.StringFlag{
+					Name:  "build-cache-mode",
+					Value: cache.ModeImage,
+					Usage: "Mode of build cache: 'image' records the converted layers in the cache image, " +
+						"'cas' stores them in the repository of --build-cache keyed by source layer digest and build parameters, " +
+						"so that they are shared across images",
+					EnvVars: []string{"BUILD_CACHE_MODE"},
+				},
+				&cli.StringFlag{
+					Name:    "build-cache-backend-config",
+					Usage:   "Storage backend configuration in JSON to access the build cache in object storage, e.g. endpoint and credentials",
+					EnvVars: []string{"BUILD_CACHE_BACKEND_CONFIG"},
+				},
+				&cli.PathFlag{
+					Name:      "build-cache-backend-config-file",
+					Usage:     "Storage backend configuration file to access the build cache in object storage",
+					TakesFile: true,
+					EnvVars:   []string{"BUILD_CACHE_BACKEND_CONFIG_FILE"},
+				},
+				&cli.StringFlag{
+					Name:    "build-cache-policy",
+					Value:   cache.PolicyLRU,
+					Usage:   "Eviction policy of content-addressed build cache, 'lru' (least recently used) or 'lfu' (least frequently used)",
+					EnvVars: []string{"BUILD_CACHE_POLICY"},
+				},
+				&cli.StringFlag{
+					Name:    "build-cache-max-size",
+					Value:   "0",
+					Usage:   "Maximum total size of converted layers in content-addressed build cache, e.g. 100GB, 0 means unlimited",
+					EnvVars: []string{"BUILD_CACHE_MAX_SIZE"},
+				},
 				&cli.StringFlag{
 					Name:     "chunk-dict",
 					Required: false,
 					Usage: "Specify a chunk dict expression for chunk deduplication, " +
-						"for examples: bootstrap:registry:localhost:5000/namespace/app:chunk_dict, bootstrap:local:/path/to/chunk_dict.boot",
+						"for examples: bootstrap:registry:localhost:5000/namespace/app:chunk_dict, bootstrap:local:/path/to/chunk_dict.boot, " +
+						"multiple chunk dicts separated by comma are merged, the former ones take precedence",
 					EnvVars: []string{"CHUNK_DICT"},
 				},
 				&cli.BoolFlag{
@@ -314,11 +1024,22 @@ func main() {
 					Usage:    "Skip verifying server certs for HTTPS dict registry",
 					EnvVars:  []string{"CHUNK_DICT_INSECURE"},
 				},
+				&cli.PathFlag{
+					Name:    "chunk-dict-catalog",
+					Usage:   "YAML or JSON file of the chunk dicts for base images, the chunk dict of the base image of source image is selected if --chunk-dict is not specified",
+					EnvVars: []string{"CHUNK_DICT_CATALOG"},
+				},
+				&cli.BoolFlag{
+					Name:    "chunk-dict-from-target",
+					Value:   false,
+					Usage:   "Deduplicate chunks against the Nydus image already pushed to the target reference, so that only new chunks are pushed",
+					EnvVars: []string{"CHUNK_DICT_FROM_TARGET"},
+				},
 
 				&cli.BoolFlag{
 					Name:    "merge-platform",
 					Value:   false,
-					Usage:   "Generate an OCI image index with both OCI and Nydus manifests for the image",
+					Usage:   "Generate an OCI image index with both OCI and Nydus manifests for the image, the manifests of non-linux platforms are copied as is",
 					EnvVars: []string{"MERGE_PLATFORM"},
 					Aliases: []string{"multi-platform"},
 				},
@@ -338,11 +1059,76 @@ func main() {
 					Usage:   "Convert to OCI-referenced nydus zran image",
 					EnvVars: []string{"OCI_REF"},
 				},
+				&cli.IntFlag{
+					Name:    "oci-tail-layers",
+					Value:   0,
+					Usage:   "Keep at most the top N gzip layers as OCI layers referenced by the nydus image in OCI ref mode, only the lower layers are converted to nydus",
+					EnvVars: []string{"OCI_TAIL_LAYERS"},
+				},
+				&cli.StringFlag{
+					Name:    "oci-tail-max-size",
+					Value:   "0",
+					Usage:   "Maximum size of the layers kept by --oci-tail-layers, the layers from the first larger one downwards are converted, e.g. 10MB",
+					EnvVars: []string{"OCI_TAIL_MAX_SIZE"},
+				},
 				&cli.BoolFlag{
-					Name:    "with-referrer",
+					Name:    "flatten",
 					Value:   false,
-					Usage:   "Associate a reference to the source image, see https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers",
-					EnvVars: []string{"WITH_REFERRER"},
+					Usage:   "Squash all layers of the source image into a single Nydus layer, the layer history is dropped",
+					EnvVars: []string{"FLATTEN"},
+				},
+				&cli.StringSliceFlag{
+					Name:    "annotation",
+					Usage:   "Annotation `key=value` set on the manifests and index of the target image, overriding the one carried over from the source image, can be specified multiple times",
+					EnvVars: []string{"ANNOTATION"},
+				},
+				&cli.BoolFlag{
+					Name:    "allow-nondistributable-artifacts",
+					Value:   false,
+					Usage:   "Pull, convert and push the non-distributable layers (e.g. foreign layers of Windows base images), otherwise the manifests having them are not converted, and copied as is with --merge-platform",
+					EnvVars: []string{"ALLOW_NONDISTRIBUTABLE_ARTIFACTS"},
+				},
+				&cli.StringFlag{
+					Name:    "hook-script",
+					Value:   "",
+					Usage:   "Executable run at the pre-layer, post-layer, pre-push and post-push stages of conversion with the stage as argument and the event in NYDUS_HOOK_EVENT environment variable, see docs for details",
+					EnvVars: []string{"HOOK_SCRIPT"},
+				},
+				&cli.BoolFlag{
+					Name:    "with-referrer",
+					Value:   false,
+					Usage:   "Associate a reference to the source image, see https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers",
+					EnvVars: []string{"WITH_REFERRER"},
+				},
+				&cli.BoolFlag{
+					Name:    "copy-referrers",
+					Value:   false,
+					Usage:   "Copy the referrers (e.g. SBOM, provenance attestation) of source image to the target image",
+					EnvVars: []string{"COPY_REFERRERS"},
+				},
+				&cli.StringFlag{
+					Name:    "sbom",
+					Value:   "",
+					Usage:   "Generate the SBOM of target image in the format, and attach it to the target image as a referrer, possible values: `spdx`, `cyclonedx`",
+					EnvVars: []string{"SBOM"},
+				},
+				&cli.BoolFlag{
+					Name:    "file-digests",
+					Value:   false,
+					Usage:   "Attach the digests of regular files to the target image as a referrer, for runtimes to deduplicate identical files across images in a local content-addressed cache",
+					EnvVars: []string{"FILE_DIGESTS"},
+				},
+				&cli.StringFlag{
+					Name:    "file-digest-min-size",
+					Value:   "0",
+					Usage:   "Minimum size of regular files recorded by --file-digests, e.g. 64KB",
+					EnvVars: []string{"FILE_DIGEST_MIN_SIZE"},
+				},
+				&cli.StringFlag{
+					Name:    "progress",
+					Value:   progress.ModeAuto,
+					Usage:   "Report the per-layer pull/build/push progress, possible values: `auto` (progress bars if stderr is a terminal), `bar`, `json` (progress events on stdout), `none`",
+					EnvVars: []string{"PROGRESS"},
 				},
 				&cli.BoolFlag{
 					Name:    "oci",
@@ -387,12 +1173,30 @@ func main() {
 					Usage:   "Read prefetch list from STDIN, please input absolute paths line by line",
 					EnvVars: []string{"PREFETCH_PATTERNS"},
 				},
+				&cli.StringSliceFlag{
+					Name:      "prefetch-trace",
+					TakesFile: true,
+					Usage:     "Read prefetch list from the file access trace in the format of nydusd access pattern JSON, stargz-snapshotter record JSON or fanotify CSV, multiple traces are merged",
+					EnvVars:   []string{"PREFETCH_TRACE"},
+				},
+				&cli.BoolFlag{
+					Name:    "prefetch-auto",
+					Value:   false,
+					Usage:   "Generate prefetch list from the entrypoint, cmd and env of source image config and the common runtime files, if no prefetch list is specified",
+					EnvVars: []string{"PREFETCH_AUTO"},
+				},
 				&cli.StringFlag{
 					Name:    "compressor",
 					Value:   "zstd",
 					Usage:   "Algorithm to compress image data blob, possible values: none, lz4_block, zstd",
 					EnvVars: []string{"COMPRESSOR"},
 				},
+				&cli.StringFlag{
+					Name:    "compressor-policy",
+					Value:   "",
+					Usage:   "Override --compressor for the layers by size, for example 'small=none,large=zstd,threshold=10MB', or 'auto' to pick the compressor by sampling the content of each layer",
+					EnvVars: []string{"COMPRESSOR_POLICY"},
+				},
 				&cli.StringFlag{
 					Name:    "fs-chunk-size",
 					Value:   "0x100000",
@@ -406,18 +1210,109 @@ func main() {
 					Usage:   "size of batch data chunks, must be power of two, between 0x1000-0x1000000 or zero, [default: 0]",
 					EnvVars: []string{"BATCH_SIZE"},
 				},
+				&cli.StringFlag{
+					Name:    "if-nydus",
+					Value:   "",
+					Usage:   "Behavior if the source image has Nydus manifests, possible values: `skip` (copy the source image to target unchanged), `rebase` (convert the OCI manifests in source index again, reusing the Nydus blobs), `rebuild` (convert the OCI manifests in source index again)",
+					EnvVars: []string{"IF_NYDUS"},
+				},
+				&cli.StringFlag{
+					Name:    "features",
+					Value:   "",
+					Usage:   "RAFS features required in the target image, separated by comma, the conversion fails if nydus-image can't build them, possible values: `blob-toc`, `inline-chunk-digest`, `batch`",
+					EnvVars: []string{"FEATURES"},
+				},
 				&cli.StringFlag{
 					Name:    "work-dir",
 					Value:   "./tmp",
 					Usage:   "Working directory for image conversion",
 					EnvVars: []string{"WORK_DIR"},
 				},
+				workDirQuotaFlag(),
+				keepWorkDirFlag(workdir.KeepOnFailure),
 				&cli.StringFlag{
 					Name:    "nydus-image",
 					Value:   "nydus-image",
 					Usage:   "Path to the nydus-image binary, default to search in PATH",
 					EnvVars: []string{"NYDUS_IMAGE"},
 				},
+				&cli.StringFlag{
+					Name:    "source-format",
+					Value:   converter.SourceFormatOCI,
+					Usage:   "Format of source image layers, possible values: 'oci', 'estargz', 'zstdchunked' (use the prefetch landmarks of eStargz or zstd:chunked layers as prefetch patterns)",
+					EnvVars: []string{"SOURCE_FORMAT"},
+				},
+				&cli.StringSliceFlag{
+					Name:    "encrypt-recipient",
+					Usage:   "Encrypt the layers of Nydus image with ocicrypt for the recipient, for example: 'jwe:pubkey.pem', 'pkcs7:cert.pem', 'provider:<keyprovider>', can be specified multiple times",
+					EnvVars: []string{"ENCRYPT_RECIPIENT"},
+				},
+				&cli.StringFlag{
+					Name:    "sign-cosign-key",
+					Value:   "",
+					Usage:   "Sign the pushed Nydus image by cosign with the private key path or KMS URI, the key password is read from COSIGN_PASSWORD",
+					EnvVars: []string{"SIGN_COSIGN_KEY"},
+				},
+				&cli.BoolFlag{
+					Name:    "sign-cosign-keyless",
+					Value:   false,
+					Usage:   "Sign the pushed Nydus image by cosign in keyless mode, conflicts with --sign-cosign-key",
+					EnvVars: []string{"SIGN_COSIGN_KEYLESS"},
+				},
+				&cli.StringFlag{
+					Name:    "cosign",
+					Value:   "cosign",
+					Usage:   "Path to the cosign binary, default to search in PATH",
+					EnvVars: []string{"COSIGN"},
+				},
+				&cli.StringFlag{
+					Name:    "preheat-dragonfly-endpoint",
+					Value:   "",
+					Usage:   "Endpoint of Dragonfly manager to preheat the target image in P2P network after conversion, e.g. 'http://dragonfly-manager:8080'",
+					EnvVars: []string{"PREHEAT_DRAGONFLY_ENDPOINT"},
+				},
+				&cli.StringFlag{
+					Name:    "preheat-dragonfly-token",
+					Value:   "",
+					Usage:   "Personal access token of Dragonfly manager to create preheat jobs",
+					EnvVars: []string{"PREHEAT_DRAGONFLY_TOKEN"},
+				},
+				&cli.StringFlag{
+					Name:    "preheat-scope",
+					Value:   preheat.ScopeSingleSeedPeer,
+					Usage:   "Scope of preheat, possible values: 'single_seed_peer', 'all_seed_peers', 'all_peers'",
+					EnvVars: []string{"PREHEAT_SCOPE"},
+				},
+				&cli.DurationFlag{
+					Name:    "preheat-timeout",
+					Value:   0,
+					Usage:   "Wait for the preheat to finish within the timeout, don't wait if 0, the conversion doesn't fail if preheat fails",
+					EnvVars: []string{"PREHEAT_TIMEOUT"},
+				},
+				&cli.BoolFlag{
+					Name:    "zstdchunked-interop",
+					Value:   false,
+					Usage:   "Keep the source zstd:chunked manifest alongside the Nydus manifest in an OCI image index, implies --merge-platform, requires --source-format zstdchunked",
+					EnvVars: []string{"ZSTDCHUNKED_INTEROP"},
+				},
+				&cli.BoolFlag{
+					Name:    "stream",
+					Value:   false,
+					Usage:   "Stream source layers from registry during conversion instead of downloading them into work directory, which reduces the disk usage",
+					EnvVars: []string{"STREAM"},
+				},
+				&cli.StringFlag{
+					Name:    "output-type",
+					Value:   outputTypeRegistry,
+					Usage:   "Where to output the target image, possible values: 'registry', 'oci-layout'",
+					EnvVars: []string{"OUTPUT_TYPE"},
+				},
+				&cli.StringFlag{
+					Name:    "output-dir",
+					Value:   "",
+					Usage:   "OCI image layout directory to write the target image, required by '--output-type oci-layout'",
+					EnvVars: []string{"OUTPUT_DIR"},
+				},
 				&cli.StringFlag{
 					Name:    "output-json",
 					Value:   "",
@@ -442,19 +1337,58 @@ func main() {
 					Usage:   "Delay between push retries (e.g. 5s, 1m, 1h)",
 					EnvVars: []string{"PUSH_RETRY_DELAY"},
 				},
+				&cli.StringFlag{
+					Name:    "pull-rate-limit",
+					Value:   "0",
+					Usage:   "Limit the total bandwidth in bytes per second to pull source image, e.g. '50MB', '0' means unlimited",
+					EnvVars: []string{"PULL_RATE_LIMIT"},
+				},
+				&cli.StringFlag{
+					Name:    "push-rate-limit",
+					Value:   "0",
+					Usage:   "Limit the total bandwidth in bytes per second to push target image, e.g. '50MB', '0' means unlimited",
+					EnvVars: []string{"PUSH_RATE_LIMIT"},
+				},
+				&cli.StringFlag{
+					Name:    "push-chunk-size",
+					Value:   "64MB",
+					Usage:   "Upload the blobs larger than the size in chunks, and resume the upload from the last chunk after failure, '0' to disable",
+					EnvVars: []string{"PUSH_CHUNK_SIZE"},
+				},
 			},
 			Action: func(c *cli.Context) error {
 				setupLogLevel(c)
 
-				targetRef, err := getTargetReference(c)
-				if err != nil {
-					return err
+				var err error
+				var targetRef string
+				batchManifest := c.String("batch")
+				if batchManifest != "" {
+					if c.String("source") != "" {
+						return fmt.Errorf("--batch conflicts with --source")
+					}
+					if c.String("target") != "" {
+						return fmt.Errorf("--batch conflicts with --target")
+					}
+					if c.String("build-cache-tag") != "" {
+						return fmt.Errorf("--batch conflicts with --build-cache-tag, use --build-cache instead")
+					}
+				} else {
+					if c.String("source") == "" {
+						return fmt.Errorf("--source or --batch is required")
+					}
+					targetRef, err = getTargetReference(c)
+					if err != nil {
+						return err
+					}
 				}
 
 				backendType, backendConfig, err := getBackendConfig(c, "", false)
 				if err != nil {
 					return err
 				}
+				if err := checkBackendType("", backendType, convertBackendTypes); err != nil {
+					return err
+				}
 
 				cacheRef, err := getCacheReference(c, targetRef)
 				if err != nil {
@@ -464,10 +1398,42 @@ func main() {
 				if cacheMaxRecords < 1 {
 					return fmt.Errorf("--build-cache-max-records should be greater than 0")
 				}
-				if cacheMaxRecords > maxCacheMaxRecords {
+				cacheVersion := c.String("build-cache-version")
+				cacheMode := c.String("build-cache-mode")
+				possibleCacheModes := []string{cache.ModeImage, cache.ModeCAS}
+				if !isPossibleValue(possibleCacheModes, cacheMode) {
+					return fmt.Errorf("--build-cache-mode should be one of %v", possibleCacheModes)
+				}
+				// The records of content-addressed build cache aren't in
+				// one cache image, so they're not limited by the layers of
+				// image.
+				contentAddressed := cache.IsContentAddressed(cacheRef, cacheMode)
+				if cacheMaxRecords > maxCacheMaxRecords && !contentAddressed {
 					return fmt.Errorf("--build-cache-max-records should not be greater than %d", maxCacheMaxRecords)
 				}
-				cacheVersion := c.String("build-cache-version")
+				cachePolicy := c.String("build-cache-policy")
+				if !isPossibleValue(cache.Policies, cachePolicy) {
+					return fmt.Errorf("--build-cache-policy should be one of %v", cache.Policies)
+				}
+				workDirQuota, err := getWorkDirQuota(c)
+				if err != nil {
+					return err
+				}
+				keepWorkDir, err := getKeepWorkDir(c)
+				if err != nil {
+					return err
+				}
+				cacheMaxSize, err := humanize.ParseBytes(c.String("build-cache-max-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --build-cache-max-size")
+				}
+				if (cachePolicy != cache.PolicyLRU || cacheMaxSize > 0) && !contentAddressed {
+					return fmt.Errorf("--build-cache-policy and --build-cache-max-size require --build-cache-mode cas, a dir:// or an object storage build cache")
+				}
+				cacheBackendConfig, err := getCacheBackendConfig(c, cacheRef)
+				if err != nil {
+					return err
+				}
 
 				fsVersion := c.String("fs-version")
 				possibleFsVersions := []string{"5", "6"}
@@ -475,18 +1441,140 @@ func main() {
 					return fmt.Errorf("--fs-version should be one of %v", possibleFsVersions)
 				}
 
+				if !isPossibleValue(preheat.Scopes, c.String("preheat-scope")) {
+					return fmt.Errorf("--preheat-scope should be one of %v", preheat.Scopes)
+				}
+
 				prefetchPatterns, err := getPrefetchPatterns(c)
 				if err != nil {
 					return err
 				}
 
+				var compressorPolicy *converter.CompressorPolicy
+				if c.String("compressor-policy") != "" {
+					if compressorPolicy, err = converter.ParseCompressorPolicy(c.String("compressor-policy")); err != nil {
+						return errors.Wrap(err, "invalid --compressor-policy")
+					}
+				}
+
+				if c.String("if-nydus") != "" && !isPossibleValue(converter.IfNydusModes, c.String("if-nydus")) {
+					return fmt.Errorf("--if-nydus should be one of %v", converter.IfNydusModes)
+				}
+
+				var features []string
+				for _, feature := range strings.Split(c.String("features"), ",") {
+					if feature = strings.TrimSpace(feature); feature != "" {
+						features = append(features, feature)
+					}
+				}
+
+				sourceFormat := c.String("source-format")
+				possibleSourceFormats := []string{converter.SourceFormatOCI, converter.SourceFormatEStargz, converter.SourceFormatZstdChunked}
+				if !isPossibleValue(possibleSourceFormats, sourceFormat) {
+					return fmt.Errorf("--source-format should be one of %v", possibleSourceFormats)
+				}
+				if c.String("sign-cosign-key") != "" && c.Bool("sign-cosign-keyless") {
+					return fmt.Errorf("--sign-cosign-key conflicts with --sign-cosign-keyless")
+				}
+				if c.Bool("zstdchunked-interop") && sourceFormat != converter.SourceFormatZstdChunked {
+					return fmt.Errorf("--zstdchunked-interop requires --source-format %s", converter.SourceFormatZstdChunked)
+				}
+				if !isPossibleValue(progress.Modes, c.String("progress")) {
+					return fmt.Errorf("--progress should be one of %v", progress.Modes)
+				}
+				sbomFormat := c.String("sbom")
+				possibleSBOMFormats := []string{sbom.FormatSPDX, sbom.FormatCycloneDX}
+				if sbomFormat != "" && !isPossibleValue(possibleSBOMFormats, sbomFormat) {
+					return fmt.Errorf("--sbom should be one of %v", possibleSBOMFormats)
+				}
+				fileDigestMinSize, err := humanize.ParseBytes(c.String("file-digest-min-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --file-digest-min-size option")
+				}
+				if fileDigestMinSize > 0 && !c.Bool("file-digests") {
+					return fmt.Errorf("--file-digest-min-size requires --file-digests")
+				}
+				annotations, err := converter.ParseAnnotations(c.StringSlice("annotation"))
+				if err != nil {
+					return err
+				}
+				var hooks *converter.Hooks
+				if hookScript := c.String("hook-script"); hookScript != "" {
+					if _, err := os.Stat(hookScript); err != nil {
+						return errors.Wrap(err, "invalid --hook-script option")
+					}
+					hooks = converter.ScriptHooks(hookScript)
+				}
+				if c.Int("oci-tail-layers") < 0 {
+					return fmt.Errorf("--oci-tail-layers should not be negative")
+				}
+				ociTailMaxSize, err := humanize.ParseBytes(c.String("oci-tail-max-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --oci-tail-max-size option")
+				}
+				if ociTailMaxSize > 0 && c.Int("oci-tail-layers") == 0 {
+					return fmt.Errorf("--oci-tail-max-size requires --oci-tail-layers")
+				}
+
 				chunkDictRef := ""
+				var chunkDicts []converter.ChunkDict
 				chunkDict := c.String("chunk-dict")
+				if chunkDict != "" && c.Bool("chunk-dict-from-target") {
+					return fmt.Errorf("--chunk-dict and --chunk-dict-from-target can't be specified together")
+				}
 				if chunkDict != "" {
-					_, _, chunkDictRef, err = converter.ParseChunkDictArgs(chunkDict)
+					dicts, err := converter.ParseChunkDicts(chunkDict)
 					if err != nil {
 						return errors.Wrap(err, "parse chunk dict arguments")
 					}
+					chunkDictRef, chunkDicts = converter.SplitChunkDicts(dicts)
+				}
+				var chunkDictCatalog []converter.ChunkDictCatalogEntry
+				if catalogPath := c.String("chunk-dict-catalog"); catalogPath != "" {
+					if c.Bool("chunk-dict-from-target") {
+						return fmt.Errorf("--chunk-dict-catalog and --chunk-dict-from-target can't be specified together")
+					}
+					catalog, err := converter.ParseChunkDictCatalog(catalogPath)
+					if err != nil {
+						return err
+					}
+					chunkDictCatalog = catalog.ChunkDicts
+				}
+
+				outputLayout, err := getOutputLayout(c)
+				if err != nil {
+					return err
+				}
+
+				pushChunkSize, err := humanize.ParseBytes(c.String("push-chunk-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --push-chunk-size option")
+				}
+
+				pullRateLimit, err := humanize.ParseBytes(c.String("pull-rate-limit"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --pull-rate-limit option")
+				}
+				pushRateLimit, err := humanize.ParseBytes(c.String("push-rate-limit"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --push-rate-limit option")
+				}
+
+				sourceTransport, err := getTransportOption(c, "source-")
+				if err != nil {
+					return err
+				}
+				targetTransport, err := getTransportOption(c, "target-")
+				if err != nil {
+					return err
+				}
+				sourceCredential, err := getCredentialOption(c, "source-")
+				if err != nil {
+					return err
+				}
+				targetCredential, err := getCredentialOption(c, "target-")
+				if err != nil {
+					return err
 				}
 
 				docker2OCI := false
@@ -505,14 +1593,24 @@ func main() {
 
 				opt := converter.Opt{
 					WorkDir:        c.String("work-dir"),
+					WorkDirQuota:   workDirQuota,
+					KeepWorkDir:    keepWorkDir,
 					NydusImagePath: c.String("nydus-image"),
 
 					SourceBackendType:   c.String("source-backend-type"),
 					SourceBackendConfig: c.String("source-backend-config"),
+					SourceFormat:        sourceFormat,
+					ZstdChunkedInterop:  c.Bool("zstdchunked-interop"),
 					Source:              c.String("source"),
+					ContainerdAddress:   c.String("containerd-address"),
 					Target:              targetRef,
 					SourceInsecure:      c.Bool("source-insecure"),
 					TargetInsecure:      c.Bool("target-insecure"),
+					SourceTransport:     sourceTransport,
+					TargetTransport:     targetTransport,
+					SourceCredential:    sourceCredential,
+					TargetCredential:    targetCredential,
+					SourceMirrors:       c.StringSlice("source-mirror"),
 
 					BackendType:      backendType,
 					BackendConfig:    backendConfig,
@@ -522,28 +1620,81 @@ func main() {
 					CacheInsecure:   c.Bool("build-cache-insecure"),
 					CacheMaxRecords: cacheMaxRecords,
 					CacheVersion:    cacheVersion,
+					CacheMode:       cacheMode,
+					CachePolicy:     cachePolicy,
+					CacheMaxSize:    int64(cacheMaxSize),
+
+					CacheBackendConfig: cacheBackendConfig,
 
-					ChunkDictRef:      chunkDictRef,
-					ChunkDictInsecure: c.Bool("chunk-dict-insecure"),
+					ChunkDictRef:        chunkDictRef,
+					ChunkDictInsecure:   c.Bool("chunk-dict-insecure"),
+					ChunkDictFromTarget: c.Bool("chunk-dict-from-target"),
+					ChunkDicts:          chunkDicts,
+					ChunkDictCatalog:    chunkDictCatalog,
 
 					PrefetchPatterns: prefetchPatterns,
+					PrefetchAuto:     c.Bool("prefetch-auto"),
 					MergePlatform:    c.Bool("merge-platform"),
 					Docker2OCI:       docker2OCI,
 					FsVersion:        fsVersion,
 					FsAlignChunk:     c.Bool("backend-aligned-chunk") || c.Bool("fs-align-chunk"),
 					Compressor:       c.String("compressor"),
+					CompressorPolicy: compressorPolicy,
 					ChunkSize:        c.String("chunk-size"),
 					BatchSize:        c.String("batch-size"),
+					Features:         features,
+					IfNydus:          c.String("if-nydus"),
 
-					OCIRef:       c.Bool("oci-ref"),
-					WithReferrer: c.Bool("with-referrer"),
-					AllPlatforms: c.Bool("all-platforms"),
-					Platforms:    c.String("platform"),
+					OCIRef:        c.Bool("oci-ref"),
+					WithReferrer:  c.Bool("with-referrer"),
+					CopyReferrers: c.Bool("copy-referrers"),
+					SBOMFormat:    sbomFormat,
+					AllPlatforms:  c.Bool("all-platforms"),
+					Platforms:     c.String("platform"),
 
-					OutputJSON:     c.String("output-json"),
-					WithPlainHTTP:  c.Bool("plain-http"),
-					PushRetryCount: c.Int("push-retry-count"),
-					PushRetryDelay: c.String("push-retry-delay"),
+					OCITailLayers:  c.Int("oci-tail-layers"),
+					OCITailMaxSize: int64(ociTailMaxSize),
+					Flatten:        c.Bool("flatten"),
+					Annotations:    annotations,
+
+					AllowNondistributable: c.Bool("allow-nondistributable-artifacts"),
+					Hooks:                 hooks,
+
+					FileDigests:       c.Bool("file-digests"),
+					FileDigestMinSize: int64(fileDigestMinSize),
+
+					OutputJSON:        c.String("output-json"),
+					OutputLayout:      outputLayout,
+					EncryptRecipients: c.StringSlice("encrypt-recipient"),
+					CosignPath:        c.String("cosign"),
+					SignCosignKey:     c.String("sign-cosign-key"),
+					SignCosignKeyless: c.Bool("sign-cosign-keyless"),
+					WithPlainHTTP:     c.Bool("plain-http"),
+					Stream:            c.Bool("stream"),
+					PushRetryCount:    c.Int("push-retry-count"),
+					PushRetryDelay:    c.String("push-retry-delay"),
+					PushChunkSize:     int64(pushChunkSize),
+					PullRateLimit:     int64(pullRateLimit),
+					PushRateLimit:     int64(pushRateLimit),
+					Progress:          c.String("progress"),
+
+					PreheatDragonflyEndpoint: c.String("preheat-dragonfly-endpoint"),
+					PreheatDragonflyToken:    c.String("preheat-dragonfly-token"),
+					PreheatScope:             c.String("preheat-scope"),
+					PreheatTimeout:           c.Duration("preheat-timeout"),
+				}
+
+				if batchManifest != "" {
+					images, err := getBatchImages(batchManifest, c.String("target-suffix"))
+					if err != nil {
+						return err
+					}
+					_, err = converter.BatchConvert(context.Background(), converter.BatchOpt{
+						Opt:     opt,
+						Images:  images,
+						Workers: c.Uint("batch-workers"),
+					})
+					return err
 				}
 
 				return converter.Convert(context.Background(), opt)
@@ -561,10 +1712,23 @@ func main() {
 				},
 				&cli.StringFlag{
 					Name:     "target",
-					Required: true,
-					Usage:    "Target (Nydus) image reference",
+					Required: false,
+					Usage:    "Target (Nydus) image reference, conflicts with --targets-file",
 					EnvVars:  []string{"TARGET"},
 				},
+				&cli.PathFlag{
+					Name:      "targets-file",
+					Required:  false,
+					TakesFile: true,
+					Usage:     "File listing the Nydus image references to be checked in batch, one reference per line, conflicts with --source and --target",
+					EnvVars:   []string{"TARGETS_FILE"},
+				},
+				&cli.UintFlag{
+					Name:    "batch-workers",
+					Value:   checker.DefaultBatchWorkers,
+					Usage:   "Maximum number of images to be checked concurrently with --targets-file",
+					EnvVars: []string{"BATCH_WORKERS"},
+				},
 				&cli.BoolFlag{
 					Name:     "source-insecure",
 					Required: false,
@@ -577,11 +1741,101 @@ func main() {
 					Usage:    "Skip verifying server certs for HTTPS target registry",
 					EnvVars:  []string{"TARGET_INSECURE"},
 				},
+				&cli.PathFlag{
+					Name:      "source-ca-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify server certs of source registry, appended to system CAs",
+					EnvVars:   []string{"SOURCE_CA_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "source-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded client certificate for mutual TLS with source registry, requires --source-key",
+					EnvVars:   []string{"SOURCE_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "source-key",
+					TakesFile: true,
+					Usage:     "PEM encoded client key for mutual TLS with source registry, requires --source-cert",
+					EnvVars:   []string{"SOURCE_KEY"},
+				},
+				&cli.PathFlag{
+					Name:      "target-ca-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify server certs of target registry, appended to system CAs",
+					EnvVars:   []string{"TARGET_CA_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "target-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded client certificate for mutual TLS with target registry, requires --target-key",
+					EnvVars:   []string{"TARGET_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "target-key",
+					TakesFile: true,
+					Usage:     "PEM encoded client key for mutual TLS with target registry, requires --target-cert",
+					EnvVars:   []string{"TARGET_KEY"},
+				},
+				&cli.StringFlag{
+					Name:    "source-username",
+					Value:   "",
+					Usage:   "Username to access source registry, overrides the credential in docker config",
+					EnvVars: []string{"SOURCE_USERNAME"},
+				},
+				&cli.StringFlag{
+					Name:    "source-password",
+					Value:   "",
+					Usage:   "Password to access source registry, requires --source-username",
+					EnvVars: []string{"SOURCE_PASSWORD"},
+				},
+				&cli.StringFlag{
+					Name:    "source-token",
+					Value:   "",
+					Usage:   "Identity token (OAuth2 refresh token) to access source registry",
+					EnvVars: []string{"SOURCE_TOKEN"},
+				},
+				&cli.StringFlag{
+					Name:    "source-credential-helper",
+					Value:   "",
+					Usage:   "Docker credential helper to get credential of source registry, e.g. 'ecr-login' for docker-credential-ecr-login",
+					EnvVars: []string{"SOURCE_CREDENTIAL_HELPER"},
+				},
+				&cli.StringFlag{
+					Name:    "target-username",
+					Value:   "",
+					Usage:   "Username to access target registry, overrides the credential in docker config",
+					EnvVars: []string{"TARGET_USERNAME"},
+				},
+				&cli.StringFlag{
+					Name:    "target-password",
+					Value:   "",
+					Usage:   "Password to access target registry, requires --target-username",
+					EnvVars: []string{"TARGET_PASSWORD"},
+				},
+				&cli.StringFlag{
+					Name:    "target-token",
+					Value:   "",
+					Usage:   "Identity token (OAuth2 refresh token) to access target registry",
+					EnvVars: []string{"TARGET_TOKEN"},
+				},
+				&cli.StringFlag{
+					Name:    "target-credential-helper",
+					Value:   "",
+					Usage:   "Docker credential helper to get credential of target registry, e.g. 'gcloud' for docker-credential-gcloud",
+					EnvVars: []string{"TARGET_CREDENTIAL_HELPER"},
+				},
+				&cli.StringFlag{
+					Name:    "proxy",
+					Value:   "",
+					Usage:   "HTTP/HTTPS/SOCKS5 proxy URL to access registries, overrides HTTP_PROXY and HTTPS_PROXY, e.g. 'http://proxy.example.com:3128'",
+					EnvVars: []string{"REGISTRY_PROXY"},
+				},
 
 				&cli.StringFlag{
 					Name:    "source-backend-type",
 					Value:   "",
-					Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:   "Type of storage backend, possible values: 'oss', 's3', 'localfs', 'ipfs'",
 					EnvVars: []string{"BACKEND_TYPE"},
 				},
 				&cli.StringFlag{
@@ -601,7 +1855,7 @@ func main() {
 				&cli.StringFlag{
 					Name:    "target-backend-type",
 					Value:   "",
-					Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:   "Type of storage backend, possible values: 'oss', 's3', 'localfs', 'ipfs'",
 					EnvVars: []string{"BACKEND_TYPE"},
 				},
 				&cli.StringFlag{
@@ -624,21 +1878,58 @@ func main() {
 					Usage:   "Verify that the image contains an image index with both OCI and Nydus manifests",
 					EnvVars: []string{"MULTI_PLATFORM"},
 				},
-				&cli.StringFlag{
-					Name:  "platform",
-					Value: "linux/" + runtime.GOARCH,
-					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+				&cli.BoolFlag{
+					Name:    "deep",
+					Value:   false,
+					Usage:   "Read all the blobs from registry or storage backend to verify the data and blob table, and read all the files of target image",
+					EnvVars: []string{"DEEP"},
 				},
-
-				&cli.StringFlag{
-					Name:    "work-dir",
-					Value:   "./output",
-					Usage:   "Working directory for image verification",
-					EnvVars: []string{"WORK_DIR"},
+				&cli.BoolFlag{
+					Name:    "static",
+					Value:   false,
+					Usage:   "Verify the bootstrap metadata without nydusd and FUSE mount to run in unprivileged environment, the files of images are not compared, conflicts with --prefetch-coverage and --prefetch-files",
+					EnvVars: []string{"STATIC"},
 				},
-				&cli.StringFlag{
-					Name:    "nydus-image",
-					Value:   "nydus-image",
+				&cli.BoolFlag{
+					Name:    "with-referrer",
+					Value:   false,
+					Usage:   "Require the target image to be associated to the source image as a referrer by '--with-referrer' of convert",
+					EnvVars: []string{"WITH_REFERRER"},
+				},
+				&cli.BoolFlag{
+					Name:    "prefetch-coverage",
+					Value:   false,
+					Usage:   "Mount target image and replay the prefetch table of bootstrap to report the prefetch coverage",
+					EnvVars: []string{"PREFETCH_COVERAGE"},
+				},
+				&cli.PathFlag{
+					Name:      "prefetch-files",
+					Value:     "",
+					TakesFile: true,
+					Usage:     "Replay the prefetch list in the file instead of the prefetch table to report the prefetch coverage, one absolute path per line as '--prefetch-patterns' of convert",
+					EnvVars:   []string{"PREFETCH_FILES"},
+				},
+				&cli.StringFlag{
+					Name:    "output-json",
+					Value:   "",
+					Usage:   "File path to save the check report in JSON format, for example: './check.json', the reports of all images are aggregated with --targets-file",
+					EnvVars: []string{"OUTPUT_JSON"},
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+				},
+
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./output",
+					Usage:   "Working directory for image verification",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				&cli.StringFlag{
+					Name:    "nydus-image",
+					Value:   "nydus-image",
 					Usage:   "Path to the nydus-image binary, default to search in PATH",
 					EnvVars: []string{"NYDUS_IMAGE"},
 				},
@@ -648,26 +1939,89 @@ func main() {
 					Usage:   "Path to the nydusd binary, default to search in PATH",
 					EnvVars: []string{"NYDUSD"},
 				},
+
+				&cli.StringFlag{
+					Name:    "verify-cosign-key",
+					Value:   "",
+					Usage:   "Verify the cosign signature of target image with the public key path or KMS URI",
+					EnvVars: []string{"VERIFY_COSIGN_KEY"},
+				},
+				&cli.StringFlag{
+					Name:    "verify-cosign-identity",
+					Value:   "",
+					Usage:   "Verify the keyless cosign signature of target image with the certificate identity, requires --verify-cosign-oidc-issuer",
+					EnvVars: []string{"VERIFY_COSIGN_IDENTITY"},
+				},
+				&cli.StringFlag{
+					Name:    "verify-cosign-oidc-issuer",
+					Value:   "",
+					Usage:   "OIDC issuer of the certificate to verify the keyless cosign signature",
+					EnvVars: []string{"VERIFY_COSIGN_OIDC_ISSUER"},
+				},
+				&cli.StringFlag{
+					Name:    "cosign",
+					Value:   "cosign",
+					Usage:   "Path to the cosign binary, default to search in PATH",
+					EnvVars: []string{"COSIGN"},
+				},
 			},
 			Action: func(c *cli.Context) error {
 				setupLogLevel(c)
 
+				targetsFile := c.String("targets-file")
+				if targetsFile != "" {
+					if c.String("source") != "" {
+						return fmt.Errorf("--targets-file conflicts with --source")
+					}
+					if c.String("target") != "" {
+						return fmt.Errorf("--targets-file conflicts with --target")
+					}
+				} else if c.String("target") == "" {
+					return fmt.Errorf("--target or --targets-file is required")
+				}
+				if c.Bool("static") && (c.Bool("prefetch-coverage") || c.String("prefetch-files") != "") {
+					return fmt.Errorf("--static conflicts with --prefetch-coverage and --prefetch-files")
+				}
+
 				sourceBackendType, sourceBackendConfig, err := getBackendConfig(c, "source-", false)
 				if err != nil {
 					return err
 				}
+				if err := checkBackendType("source-", sourceBackendType, nydusdBackendTypes); err != nil {
+					return err
+				}
 
 				targetBackendType, targetBackendConfig, err := getBackendConfig(c, "target-", false)
 				if err != nil {
 					return err
 				}
+				if err := checkBackendType("target-", targetBackendType, nydusdBackendTypes); err != nil {
+					return err
+				}
+
+				sourceTransport, err := getTransportOption(c, "source-")
+				if err != nil {
+					return err
+				}
+				targetTransport, err := getTransportOption(c, "target-")
+				if err != nil {
+					return err
+				}
+				sourceCredential, err := getCredentialOption(c, "source-")
+				if err != nil {
+					return err
+				}
+				targetCredential, err := getCredentialOption(c, "target-")
+				if err != nil {
+					return err
+				}
 
 				_, arch, err := provider.ExtractOsArch(c.String("platform"))
 				if err != nil {
 					return err
 				}
 
-				checker, err := checker.New(checker.Opt{
+				opt := checker.Opt{
 					WorkDir: c.String("work-dir"),
 
 					Source:              c.String("source"),
@@ -678,17 +2032,63 @@ func main() {
 					SourceBackendConfig: sourceBackendConfig,
 					TargetBackendType:   targetBackendType,
 					TargetBackendConfig: targetBackendConfig,
+					SourceTransport:     sourceTransport,
+					TargetTransport:     targetTransport,
+					SourceCredential:    sourceCredential,
+					TargetCredential:    targetCredential,
 
 					MultiPlatform:  c.Bool("multi-platform"),
 					NydusImagePath: c.String("nydus-image"),
 					NydusdPath:     c.String("nydusd"),
 					ExpectedArch:   arch,
-				})
+
+					CosignPath:       c.String("cosign"),
+					CosignKey:        c.String("verify-cosign-key"),
+					CosignIdentity:   c.String("verify-cosign-identity"),
+					CosignOIDCIssuer: c.String("verify-cosign-oidc-issuer"),
+
+					WithReferrer:      c.Bool("with-referrer"),
+					Deep:              c.Bool("deep"),
+					Static:            c.Bool("static"),
+					PrefetchCoverage:  c.Bool("prefetch-coverage"),
+					PrefetchFilesPath: c.String("prefetch-files"),
+					OutputJSON:        c.String("output-json"),
+				}
+
+				if targetsFile != "" {
+					targets, err := checker.ParseTargetsFile(targetsFile)
+					if err != nil {
+						return err
+					}
+					report, err := checker.BatchCheck(context.Background(), checker.BatchOpt{
+						Opt:     opt,
+						Targets: targets,
+						Workers: c.Uint("batch-workers"),
+					})
+					if report != nil {
+						if err := checker.PrintBatchReport(os.Stdout, report); err != nil {
+							return err
+						}
+					}
+					return err
+				}
+
+				imageChecker, err := checker.New(opt)
 				if err != nil {
 					return err
 				}
 
-				return checker.Check(context.Background())
+				if err := imageChecker.Check(context.Background()); err != nil {
+					// Exit with the code by the class of failure, so that CI
+					// can distinguish metadata errors from data corruption.
+					if code := checker.ExitCode(err); code != checker.ExitCodeError {
+						logrus.Error(err)
+						return cli.Exit("", code)
+					}
+					return err
+				}
+
+				return nil
 			},
 		},
 		{
@@ -727,9 +2127,15 @@ func main() {
 						&cli.StringFlag{
 							Name:    "backend-type",
 							Value:   "",
-							Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+							Usage:   "Type of storage backend, possible values: 'oss', 's3', 'azblob', 'gcs', 'external', 'http', 'ipfs', 'hdfs', 'localfs'",
 							EnvVars: []string{"BACKEND_TYPE"},
 						},
+						&cli.PathFlag{
+							Name:      "backend-plugin",
+							TakesFile: true,
+							Usage:     "Path to the backend plugin binary if --backend-type is 'external'",
+							EnvVars:   []string{"BACKEND_PLUGIN"},
+						},
 						&cli.StringFlag{
 							Name:    "backend-config",
 							Value:   "",
@@ -750,6 +2156,31 @@ func main() {
 							Usage:   "Working directory for generating chunkdict image",
 							EnvVars: []string{"WORK_DIR"},
 						},
+						&cli.BoolFlag{
+							Name:    "incremental",
+							Value:   false,
+							Usage:   "Save only the sources not indexed before into the database in work directory, and generate chunkdict image from all the indexed images",
+							EnvVars: []string{"INCREMENTAL"},
+						},
+						&cli.StringFlag{
+							Name:    "database-backend-type",
+							Value:   "",
+							Usage:   "Type of object storage backend to share the database between build nodes, possible values: 'oss', 's3'",
+							EnvVars: []string{"DATABASE_BACKEND_TYPE"},
+						},
+						&cli.StringFlag{
+							Name:    "database-backend-config",
+							Value:   "",
+							Usage:   "Json configuration string for database backend",
+							EnvVars: []string{"DATABASE_BACKEND_CONFIG"},
+						},
+						&cli.PathFlag{
+							Name:      "database-backend-config-file",
+							Value:     "",
+							TakesFile: true,
+							Usage:     "Json configuration file for database backend",
+							EnvVars:   []string{"DATABASE_BACKEND_CONFIG_FILE"},
+						},
 						&cli.StringFlag{
 							Name:    "nydus-image",
 							Value:   "nydus-image",
@@ -775,6 +2206,10 @@ func main() {
 						if err != nil {
 							return err
 						}
+						databaseBackendType, databaseBackendConfig, err := getBackendConfig(c, "database-", false)
+						if err != nil {
+							return err
+						}
 
 						_, arch, err := provider.ExtractOsArch(c.String("platform"))
 						if err != nil {
@@ -796,6 +2231,10 @@ func main() {
 							ExpectedArch:   arch,
 							AllPlatforms:   c.Bool("all-platforms"),
 							Platforms:      c.String("platform"),
+							Incremental:    c.Bool("incremental"),
+
+							DatabaseBackendType:   databaseBackendType,
+							DatabaseBackendConfig: databaseBackendConfig,
 						})
 						if err != nil {
 							return err
@@ -804,6 +2243,127 @@ func main() {
 						return generator.Generate(context.Background())
 					},
 				},
+				{
+					Name:  "stat",
+					Usage: "Report chunk statistics of the database generated by chunkdict generate, and projected savings for a candidate image (experimental)",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    "work-dir",
+							Value:   "./output",
+							Usage:   "Working directory of chunkdict generate containing the database",
+							EnvVars: []string{"WORK_DIR"},
+						},
+						&cli.StringFlag{
+							Name:    "candidate",
+							Value:   "",
+							Usage:   "Nydus image reference to project the savings deduplicated by the images in database",
+							EnvVars: []string{"CANDIDATE"},
+						},
+						&cli.BoolFlag{
+							Name:    "candidate-insecure",
+							Value:   false,
+							Usage:   "Skip verifying server certs for HTTPS candidate registry",
+							EnvVars: []string{"CANDIDATE_INSECURE"},
+						},
+						&cli.StringFlag{
+							Name:    "database-backend-type",
+							Value:   "",
+							Usage:   "Type of object storage backend to share the database between build nodes, possible values: 'oss', 's3'",
+							EnvVars: []string{"DATABASE_BACKEND_TYPE"},
+						},
+						&cli.StringFlag{
+							Name:    "database-backend-config",
+							Value:   "",
+							Usage:   "Json configuration string for database backend",
+							EnvVars: []string{"DATABASE_BACKEND_CONFIG"},
+						},
+						&cli.PathFlag{
+							Name:      "database-backend-config-file",
+							Value:     "",
+							TakesFile: true,
+							Usage:     "Json configuration file for database backend",
+							EnvVars:   []string{"DATABASE_BACKEND_CONFIG_FILE"},
+						},
+						&cli.IntFlag{
+							Name:    "top",
+							Value:   10,
+							Usage:   "Number of the most shared chunks across images to report",
+							EnvVars: []string{"TOP"},
+						},
+						&cli.StringFlag{
+							Name:    "output-json",
+							Value:   "",
+							Usage:   "File path to save the statistics in JSON format, for example: './stat.json'",
+							EnvVars: []string{"OUTPUT_JSON"},
+						},
+						&cli.StringFlag{
+							Name:    "nydus-image",
+							Value:   "nydus-image",
+							Usage:   "Path to the nydus-image binary, default to search in PATH",
+							EnvVars: []string{"NYDUS_IMAGE"},
+						},
+						&cli.StringFlag{
+							Name:    "sqlite3",
+							Value:   "sqlite3",
+							Usage:   "Path to the sqlite3 binary to query the database, default to search in PATH",
+							EnvVars: []string{"SQLITE3"},
+						},
+						&cli.StringFlag{
+							Name:  "platform",
+							Value: "linux/" + runtime.GOARCH,
+							Usage: "Specify platform identifier to choose the candidate image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						setupLogLevel(c)
+
+						databaseBackendType, databaseBackendConfig, err := getBackendConfig(c, "database-", false)
+						if err != nil {
+							return err
+						}
+
+						_, arch, err := provider.ExtractOsArch(c.String("platform"))
+						if err != nil {
+							return err
+						}
+
+						var sources []string
+						if candidate := c.String("candidate"); candidate != "" {
+							sources = []string{candidate}
+						}
+						statGenerator, err := generator.New(generator.Opt{
+							Sources:        sources,
+							SourceInsecure: c.Bool("candidate-insecure"),
+							WorkDir:        c.String("work-dir"),
+							NydusImagePath: c.String("nydus-image"),
+							ExpectedArch:   arch,
+
+							DatabaseBackendType:   databaseBackendType,
+							DatabaseBackendConfig: databaseBackendConfig,
+						})
+						if err != nil {
+							return err
+						}
+
+						stat, err := statGenerator.Stat(context.Background(), generator.StatOpt{
+							Sqlite3Path: c.String("sqlite3"),
+							Top:         c.Int("top"),
+						})
+						if err != nil {
+							return err
+						}
+						generator.PrintStatistics(os.Stdout, stat)
+
+						if outputJSON := c.String("output-json"); outputJSON != "" {
+							data, err := json.MarshalIndent(stat, "", "  ")
+							if err != nil {
+								return errors.Wrap(err, "marshal statistics")
+							}
+							return os.WriteFile(outputJSON, data, 0644)
+						}
+						return nil
+					},
+				},
 			},
 		},
 		{
@@ -811,10 +2371,10 @@ func main() {
 			Aliases: []string{"view"},
 			Usage:   "Mount the nydus image as a filesystem",
 			Flags: []cli.Flag{
-				&cli.StringFlag{
+				&cli.StringSliceFlag{
 					Name:     "target",
 					Required: true,
-					Usage:    "Target (Nydus) image reference",
+					Usage:    "Target (Nydus) image reference, specify multiple times to mount the images under the subdirectories of mount path by a shared nydusd",
 					EnvVars:  []string{"TARGET"},
 				},
 				&cli.BoolFlag{
@@ -828,7 +2388,7 @@ func main() {
 					Name:     "backend-type",
 					Value:    "",
 					Required: false,
-					Usage:    "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:    "Type of storage backend, possible values: 'oss', 's3', 'localfs', 'ipfs'",
 					EnvVars:  []string{"BACKEND_TYPE"},
 				},
 				&cli.StringFlag{
@@ -850,6 +2410,24 @@ func main() {
 					Usage:   "Enable full image data prefetch",
 					EnvVars: []string{"PREFETCH"},
 				},
+				&cli.BoolFlag{
+					Name:    "writable",
+					Value:   false,
+					Usage:   "Stack a writable overlayfs on the mounted image, the modifications are discarded on umount",
+					EnvVars: []string{"WRITABLE"},
+				},
+				&cli.StringFlag{
+					Name:    "mode",
+					Value:   viewer.ModeFuse,
+					Usage:   "Mount mode, possible values: 'fuse', 'block' (export RAFS v6 image as EROFS block device by nydusd through NBD)",
+					EnvVars: []string{"MODE"},
+				},
+				&cli.StringFlag{
+					Name:    "nbd-device",
+					Value:   viewer.DefaultNBDDevice,
+					Usage:   "NBD device node to export the image for '--mode block'",
+					EnvVars: []string{"NBD_DEVICE"},
+				},
 				&cli.StringFlag{
 					Name:    "mount-path",
 					Value:   "./image-fs",
@@ -881,25 +2459,26 @@ func main() {
 				backendType, backendConfig, err := getBackendConfig(c, "", false)
 				if err != nil {
 					return err
-				} else if backendConfig == "" {
-
-					backendType = "registry"
-					parsed, err := reference.ParseNormalizedNamed(c.String("target"))
-					if err != nil {
-						return err
-					}
+				}
+				if err := checkBackendType("", backendType, nydusdBackendTypes); err != nil {
+					return err
+				}
 
-					backendConfigStruct, err := utils.NewRegistryBackendConfig(parsed, c.Bool("target-insecure"))
-					if err != nil {
-						return errors.Wrap(err, "parse registry backend configuration")
+				var images []viewer.Image
+				for _, target := range c.StringSlice("target") {
+					image := viewer.Image{
+						Target:        target,
+						BackendType:   backendType,
+						BackendConfig: backendConfig,
 					}
-
-					bytes, err := json.Marshal(backendConfigStruct)
-					if err != nil {
-						return errors.Wrap(err, "marshal registry backend configuration")
+					if backendConfig == "" {
+						image.BackendType = "registry"
+						image.BackendConfig, err = getRegistryBackendConfig(target, c.Bool("target-insecure"))
+						if err != nil {
+							return err
+						}
 					}
-					backendConfig = string(bytes)
-
+					images = append(images, image)
 				}
 
 				_, arch, err := provider.ExtractOsArch(c.String("platform"))
@@ -907,9 +2486,8 @@ func main() {
 					return err
 				}
 
-				fsViewer, err := viewer.New(viewer.Opt{
+				opt := viewer.Opt{
 					WorkDir:        c.String("work-dir"),
-					Target:         c.String("target"),
 					TargetInsecure: c.Bool("target-insecure"),
 					MountPath:      c.String("mount-path"),
 					NydusdPath:     c.String("nydusd"),
@@ -917,7 +2495,22 @@ func main() {
 					BackendConfig:  backendConfig,
 					ExpectedArch:   arch,
 					Prefetch:       c.Bool("prefetch"),
-				})
+					Writable:       c.Bool("writable"),
+					Mode:           c.String("mode"),
+					NBDDevice:      c.String("nbd-device"),
+				}
+				if len(images) > 1 {
+					multiViewer, err := viewer.NewMulti(opt, images)
+					if err != nil {
+						return err
+					}
+					return multiViewer.View(context.Background())
+				}
+
+				opt.Target = images[0].Target
+				opt.BackendType = images[0].BackendType
+				opt.BackendConfig = images[0].BackendConfig
+				fsViewer, err := viewer.New(opt)
 				if err != nil {
 					return err
 				}
@@ -926,56 +2519,572 @@ func main() {
 			},
 		},
 		{
-			Name:    "build",
-			Aliases: []string{"pack"},
-			Usage:   "Build a Nydus filesystem from a source directory",
+			Name:  "inspect",
+			Usage: "Print the metadata of Nydus image by parsing its bootstrap, without mounting the image",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
-					Name:     "source-dir",
-					Aliases:  []string{"target-dir"}, // for compatibility
+					Name:     "target",
 					Required: true,
-					Usage:    "Source directory to build Nydus filesystem from",
-					EnvVars:  []string{"SOURCE_DIR"},
+					Usage:    "Target (Nydus) image reference",
+					EnvVars:  []string{"TARGET"},
 				},
-				&cli.StringFlag{
-					Name:     "output-dir",
-					Aliases:  []string{"o"},
+				&cli.BoolFlag{
+					Name:     "target-insecure",
 					Required: false,
-					Usage:    "Output directory for built artifacts",
-					EnvVars:  []string{"OUTPUT_DIR"},
+					Usage:    "Skip verifying server certs for HTTPS target registry",
+					EnvVars:  []string{"TARGET_INSECURE"},
 				},
 				&cli.StringFlag{
-					Name:     "name",
-					Aliases:  []string{"meta", "bootstrap"}, // for compatibility
-					Required: true,
-					Usage:    "Image name, which will be used as suffix for the generated Nydus image bootstrap/data blobs",
-					EnvVars:  []string{"BOOTSTRAP", "IMAGE_NAME"},
-				},
-
-				&cli.BoolFlag{
-					Name:    "backend-push",
-					Value:   false,
-					Usage:   "Push generated Nydus filesystem to storage backend",
-					EnvVars: []string{"BACKEND_PUSH"},
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
 				},
 				&cli.StringFlag{
-					Name:        "backend-type",
-					Value:       "oss",
-					DefaultText: "oss",
-					Usage:       "Type of storage backend, possible values: 'oss', 's3'",
-					EnvVars:     []string{"BACKEND_TYPE"},
+					Name:    "output",
+					Value:   inspector.OutputTable,
+					Usage:   "Output format, possible values: 'table', 'json'",
+					EnvVars: []string{"OUTPUT"},
 				},
 				&cli.StringFlag{
-					Name:    "backend-config",
-					Value:   "",
-					Usage:   "Json configuration string for storage backend",
-					EnvVars: []string{"BACKEND_CONFIG"},
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory for image inspection, will be cleaned up after inspecting",
+					EnvVars: []string{"WORK_DIR"},
 				},
-				&cli.PathFlag{
-					Name:      "backend-config-file",
-					TakesFile: true,
-					Usage:     "Json configuration file for storage backend",
-					EnvVars:   []string{"BACKEND_CONFIG_FILE"},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				switch c.String("output") {
+				case inspector.OutputTable, inspector.OutputJSON:
+				default:
+					return errors.Errorf("unsupported output format '%s'", c.String("output"))
+				}
+
+				_, arch, err := provider.ExtractOsArch(c.String("platform"))
+				if err != nil {
+					return err
+				}
+
+				result, err := inspector.Inspect(context.Background(), inspector.Opt{
+					WorkDir:        c.String("work-dir"),
+					Target:         c.String("target"),
+					TargetInsecure: c.Bool("target-insecure"),
+					ExpectedArch:   arch,
+				})
+				if err != nil {
+					return err
+				}
+
+				return inspector.Print(os.Stdout, c.String("output"), result)
+			},
+		},
+		{
+			Name:      "ls",
+			Usage:     "List the files in Nydus image by parsing its bootstrap, without mounting the image",
+			ArgsUsage: "<image reference> <path>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:     "target-insecure",
+					Required: false,
+					Usage:    "Skip verifying server certs for HTTPS target registry",
+					EnvVars:  []string{"TARGET_INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory to pull image bootstrap, will be cleaned up after pulling",
+					EnvVars: []string{"WORK_DIR"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				image, filePath, err := openImage(c)
+				if err != nil {
+					return err
+				}
+				files, err := image.List(filePath)
+				if err != nil {
+					return err
+				}
+
+				return inspector.PrintList(os.Stdout, files)
+			},
+		},
+		{
+			Name:      "cat",
+			Usage:     "Print the file content in Nydus image by fetching only its chunks, without mounting the image",
+			ArgsUsage: "<image reference> <path>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:     "target-insecure",
+					Required: false,
+					Usage:    "Skip verifying server certs for HTTPS target registry",
+					EnvVars:  []string{"TARGET_INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory to pull image bootstrap, will be cleaned up after pulling",
+					EnvVars: []string{"WORK_DIR"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				image, filePath, err := openImage(c)
+				if err != nil {
+					return err
+				}
+
+				return image.Cat(context.Background(), filePath, os.Stdout)
+			},
+		},
+		{
+			Name:      "diff",
+			Usage:     "Compare the files and chunks of two Nydus images by parsing their bootstraps",
+			ArgsUsage: "<base image reference> <target image reference>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "insecure",
+					Usage:   "Skip verifying server certs for HTTPS registry of both images",
+					EnvVars: []string{"INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Specify platform identifier to choose image manifest, possible values: 'linux/amd64' and 'linux/arm64'",
+				},
+				&cli.StringFlag{
+					Name:    "output",
+					Value:   inspector.OutputTable,
+					Usage:   "Output format, possible values: 'table', 'json'",
+					EnvVars: []string{"OUTPUT"},
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory to pull image bootstraps, will be cleaned up after pulling",
+					EnvVars: []string{"WORK_DIR"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				if c.NArg() != 2 {
+					return errors.Errorf("expected arguments <base image reference> <target image reference>, but got %d arguments", c.NArg())
+				}
+				switch c.String("output") {
+				case inspector.OutputTable, inspector.OutputJSON:
+				default:
+					return errors.Errorf("unsupported output format '%s'", c.String("output"))
+				}
+
+				_, arch, err := provider.ExtractOsArch(c.String("platform"))
+				if err != nil {
+					return err
+				}
+
+				var images []*inspector.Image
+				for _, ref := range c.Args().Slice() {
+					image, err := inspector.Open(context.Background(), inspector.Opt{
+						WorkDir:        c.String("work-dir"),
+						Target:         ref,
+						TargetInsecure: c.Bool("insecure"),
+						ExpectedArch:   arch,
+					})
+					if err != nil {
+						return errors.Wrapf(err, "open image %s", ref)
+					}
+					images = append(images, image)
+				}
+
+				return inspector.PrintDiff(os.Stdout, c.String("output"), inspector.Diff(images[0], images[1]))
+			},
+		},
+		{
+			Name:    "gc",
+			Aliases: []string{"prune"},
+			Usage:   "Delete the blobs in storage backend which are not referenced by the specified Nydus images",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{
+					Name:     "image",
+					Required: true,
+					Usage:    "Nydus image reference whose blobs are kept, specify multiple times for all the images using the backend",
+					EnvVars:  []string{"IMAGE"},
+				},
+				&cli.BoolFlag{
+					Name:    "insecure",
+					Usage:   "Skip verifying server certs for HTTPS registry of images",
+					EnvVars: []string{"INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:     "backend-type",
+					Required: true,
+					Usage:    "Type of storage backend, possible values: 'oss', 's3', 'external', 'http', 'hdfs', 'localfs'",
+					EnvVars:  []string{"BACKEND_TYPE"},
+				},
+				&cli.PathFlag{
+					Name:      "backend-plugin",
+					TakesFile: true,
+					Usage:     "Path to the backend plugin binary if --backend-type is 'external'",
+					EnvVars:   []string{"BACKEND_PLUGIN"},
+				},
+				&cli.StringFlag{
+					Name:    "backend-config",
+					Usage:   "Json configuration string for storage backend",
+					EnvVars: []string{"BACKEND_CONFIG"},
+				},
+				&cli.PathFlag{
+					Name:      "backend-config-file",
+					TakesFile: true,
+					Usage:     "Json configuration file for storage backend",
+					EnvVars:   []string{"BACKEND_CONFIG_FILE"},
+				},
+				&cli.DurationFlag{
+					Name:    "grace-period",
+					Value:   24 * time.Hour,
+					Usage:   "Keep the orphaned blobs modified within the period, which may be uploaded by ongoing conversions",
+					EnvVars: []string{"GRACE_PERIOD"},
+				},
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Usage:   "Only list the orphaned blobs without deleting them",
+					EnvVars: []string{"DRY_RUN"},
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory to pull image bootstraps, will be cleaned up after pulling",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				existenceCacheFlag(),
+				existenceCacheTTLFlag(),
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				backendType, backendConfig, err := getBackendConfig(c, "", true)
+				if err != nil {
+					return err
+				}
+				bkd, err := backend.NewBackend(backendType, []byte(backendConfig), nil)
+				if err != nil {
+					return errors.Wrap(err, "create storage backend")
+				}
+				// The deleted blobs are removed from the existence cache
+				// shared with other commands.
+				existenceCache, err := openExistenceCache(c)
+				if err != nil {
+					return err
+				}
+				if existenceCache != nil {
+					bkd = backend.WithExistenceCache(bkd, backendType, []byte(backendConfig), existenceCache)
+					defer saveExistenceCache(existenceCache)
+				}
+				pruner, ok := bkd.(backend.Pruner)
+				if !ok {
+					return errors.Errorf("backend type '%s' is not supported to collect blobs", backendType)
+				}
+
+				report, err := gc.GC(context.Background(), gc.Opt{
+					WorkDir:     c.String("work-dir"),
+					Images:      c.StringSlice("image"),
+					Insecure:    c.Bool("insecure"),
+					Backend:     pruner,
+					GracePeriod: c.Duration("grace-period"),
+					DryRun:      c.Bool("dry-run"),
+				})
+				if err != nil {
+					return err
+				}
+
+				return gc.PrintReport(os.Stdout, report)
+			},
+		},
+		{
+			Name:  "clean",
+			Usage: "Delete the stale temp directories in working directory left behind by crashed or interrupted commands",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory to clean up",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				&cli.DurationFlag{
+					Name:    "ttl",
+					Value:   24 * time.Hour,
+					Usage:   "Keep the temp directories modified within the duration, the ones in use by running commands are always kept",
+					EnvVars: []string{"TTL"},
+				},
+				&cli.BoolFlag{
+					Name:    "dry-run",
+					Usage:   "Only list the stale temp directories without deleting them",
+					EnvVars: []string{"DRY_RUN"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				report, err := workdir.Clean(workdir.Opt{
+					WorkDir: c.String("work-dir"),
+					TTL:     c.Duration("ttl"),
+					DryRun:  c.Bool("dry-run"),
+				})
+				if err != nil {
+					return err
+				}
+
+				return workdir.PrintReport(os.Stdout, report)
+			},
+		},
+		{
+			Name:  "doctor",
+			Usage: "Check the environment of nydusify, like nydus binaries, kernel support and registry connectivity",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "nydus-image",
+					Value:   "nydus-image",
+					Usage:   "Path to the nydus-image binary, default to search in PATH",
+					EnvVars: []string{"NYDUS_IMAGE"},
+				},
+				&cli.StringFlag{
+					Name:    "nydusd",
+					Value:   "nydusd",
+					Usage:   "Path to the nydusd binary, default to search in PATH",
+					EnvVars: []string{"NYDUSD"},
+				},
+				&cli.StringSliceFlag{
+					Name:    "registry",
+					Usage:   "Registry host to check the connectivity, e.g. docker.io or localhost:5000, specify multiple times for multiple registries",
+					EnvVars: []string{"REGISTRY"},
+				},
+				&cli.BoolFlag{
+					Name:    "insecure",
+					Usage:   "Skip verifying server certs and fall back to plain HTTP for the registries",
+					EnvVars: []string{"INSECURE"},
+				},
+				&cli.PathFlag{
+					Name:      "ca-cert",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify server certs of the registries, appended to system CAs",
+					EnvVars:   []string{"CA_CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "cert",
+					TakesFile: true,
+					Usage:     "PEM encoded client certificate for mutual TLS with the registries, requires --key",
+					EnvVars:   []string{"CERT"},
+				},
+				&cli.PathFlag{
+					Name:      "key",
+					TakesFile: true,
+					Usage:     "PEM encoded client key for mutual TLS with the registries, requires --cert",
+					EnvVars:   []string{"KEY"},
+				},
+				&cli.StringFlag{
+					Name:    "proxy",
+					Usage:   "HTTP/HTTPS/SOCKS5 proxy to access the registries, default to the proxy in environment variables",
+					EnvVars: []string{"PROXY"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				transport, err := getTransportOption(c, "")
+				if err != nil {
+					return err
+				}
+				report := doctor.Run(c.Context, doctor.Opt{
+					NydusImagePath: c.String("nydus-image"),
+					NydusdPath:     c.String("nydusd"),
+					Registries:     c.StringSlice("registry"),
+					Transport:      transport.WithInsecure(c.Bool("insecure")),
+				})
+				if err := doctor.PrintReport(os.Stdout, report); err != nil {
+					return err
+				}
+				if report.Failed() {
+					return errors.New("environment check failed")
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "completion",
+			Usage:     "Generate the shell completion script",
+			ArgsUsage: "bash|zsh|fish",
+			Description: `Load the completion in current shell:
+
+   bash: source <(nydusify completion bash)
+   zsh:  source <(nydusify completion zsh)
+   fish: nydusify completion fish | source
+
+Or write the script to the completion directory of shell to load it in every shell.`,
+			Action: func(c *cli.Context) error {
+				script, err := completionScript(c.App, c.Args().First())
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprint(c.App.Writer, script)
+				return err
+			},
+		},
+		{
+			Name:  "cache",
+			Usage: "Manage the content-addressed build cache",
+			Subcommands: []*cli.Command{
+				{
+					Name:  "stat",
+					Usage: "Show the usage of build cache",
+					Flags: cacheFlags(),
+					Action: func(c *cli.Context) error {
+						setupLogLevel(c)
+
+						backendConfig, err := getCacheBackendConfig(c, c.String("build-cache"))
+						if err != nil {
+							return err
+						}
+						stat, err := converter.CacheStat(context.Background(), converter.CacheOpt{
+							WorkDir:       c.String("work-dir"),
+							Ref:           c.String("build-cache"),
+							Insecure:      c.Bool("build-cache-insecure"),
+							BackendConfig: backendConfig,
+						})
+						if err != nil {
+							return err
+						}
+						return cache.PrintStat(os.Stdout, *stat)
+					},
+				},
+				{
+					Name:  "prune",
+					Usage: "Evict the entries of build cache until it's within the budget",
+					Flags: append(cacheFlags(),
+						&cli.StringFlag{
+							Name:    "policy",
+							Value:   cache.PolicyLRU,
+							Usage:   "Eviction policy, 'lru' (least recently used) or 'lfu' (least frequently used)",
+							EnvVars: []string{"POLICY"},
+						},
+						&cli.StringFlag{
+							Name:    "max-size",
+							Value:   "0",
+							Usage:   "Maximum total size of converted layers, e.g. 100GB, 0 means unlimited",
+							EnvVars: []string{"MAX_SIZE"},
+						},
+						&cli.UintFlag{
+							Name:    "max-records",
+							Usage:   "Maximum number of entries, 0 means unlimited",
+							EnvVars: []string{"MAX_RECORDS"},
+						},
+					),
+					Action: func(c *cli.Context) error {
+						setupLogLevel(c)
+
+						if !isPossibleValue(cache.Policies, c.String("policy")) {
+							return fmt.Errorf("--policy should be one of %v", cache.Policies)
+						}
+						maxSize, err := humanize.ParseBytes(c.String("max-size"))
+						if err != nil {
+							return errors.Wrap(err, "invalid --max-size")
+						}
+						if maxSize == 0 && c.Uint("max-records") == 0 {
+							return fmt.Errorf("either --max-size or --max-records is required")
+						}
+						backendConfig, err := getCacheBackendConfig(c, c.String("build-cache"))
+						if err != nil {
+							return err
+						}
+
+						evicted, err := converter.PruneCache(context.Background(), converter.CacheOpt{
+							WorkDir:       c.String("work-dir"),
+							Ref:           c.String("build-cache"),
+							Insecure:      c.Bool("build-cache-insecure"),
+							BackendConfig: backendConfig,
+							Policy:        c.String("policy"),
+							MaxSize:       int64(maxSize),
+							MaxRecords:    c.Uint("max-records"),
+						})
+						for _, entry := range evicted {
+							logrus.Infof("evicted layer %s converted from %s", entry.Layer.Digest, entry.Source)
+						}
+						logrus.Infof("evicted %d entries", len(evicted))
+						return err
+					},
+				},
+			},
+		},
+		{
+			Name:    "build",
+			Aliases: []string{"pack"},
+			Usage:   "Build a Nydus filesystem from a source directory",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "source-dir",
+					Aliases:  []string{"target-dir"}, // for compatibility
+					Required: false,
+					Usage:    "Source directory to build Nydus filesystem from",
+					EnvVars:  []string{"SOURCE_DIR"},
+				},
+				&cli.StringFlag{
+					Name:     "source-tar",
+					Aliases:  []string{"source"},
+					Required: false,
+					Usage:    "Source (gzip compressed) tar file to build Nydus filesystem from instead of source directory, '-' to read the tar stream from stdin",
+					EnvVars:  []string{"SOURCE_TAR"},
+				},
+				&cli.StringFlag{
+					Name:     "output-dir",
+					Aliases:  []string{"o"},
+					Required: false,
+					Usage:    "Output directory for built artifacts",
+					EnvVars:  []string{"OUTPUT_DIR"},
+				},
+				&cli.StringFlag{
+					Name:     "name",
+					Aliases:  []string{"meta", "bootstrap"}, // for compatibility
+					Required: true,
+					Usage:    "Image name, which will be used as suffix for the generated Nydus image bootstrap/data blobs",
+					EnvVars:  []string{"BOOTSTRAP", "IMAGE_NAME"},
+				},
+
+				&cli.BoolFlag{
+					Name:    "backend-push",
+					Value:   false,
+					Usage:   "Push generated Nydus filesystem to storage backend",
+					EnvVars: []string{"BACKEND_PUSH"},
+				},
+				&cli.StringFlag{
+					Name:        "backend-type",
+					Value:       "oss",
+					DefaultText: "oss",
+					Usage:       "Type of storage backend, possible values: 'oss', 's3', 'azblob', 'gcs'",
+					EnvVars:     []string{"BACKEND_TYPE"},
+				},
+				&cli.StringFlag{
+					Name:    "backend-config",
+					Value:   "",
+					Usage:   "Json configuration string for storage backend",
+					EnvVars: []string{"BACKEND_CONFIG"},
+				},
+				&cli.PathFlag{
+					Name:      "backend-config-file",
+					TakesFile: true,
+					Usage:     "Json configuration file for storage backend",
+					EnvVars:   []string{"BACKEND_CONFIG_FILE"},
 				},
 
 				&cli.StringFlag{
@@ -993,6 +3102,26 @@ func main() {
 					Usage:   "Compact parent bootstrap before building the image when needed",
 					EnvVars: []string{"COMPACT"},
 				},
+				&cli.StringFlag{
+					Name:    "output-image",
+					Usage:   "Push a Nydus image wrapping the built bootstrap and blobs to registry, the blobs are not included if they are pushed to storage backend",
+					EnvVars: []string{"OUTPUT_IMAGE"},
+				},
+				&cli.BoolFlag{
+					Name:    "output-image-insecure",
+					Usage:   "Skip verifying server certs for HTTPS output image registry",
+					EnvVars: []string{"OUTPUT_IMAGE_INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Platform in the config of output image, for example: 'linux/arm64'",
+				},
+				&cli.BoolFlag{
+					Name:    "incremental",
+					Usage:   "Build only the files changed since the last build of the same name in output directory into a new blob, with the last built bootstrap as parent",
+					EnvVars: []string{"INCREMENTAL"},
+				},
 				&cli.PathFlag{
 					Name:      "compact-config-file",
 					TakesFile: true,
@@ -1029,8 +3158,28 @@ func main() {
 					Usage:   "Path to the nydus-image binary, default to search in PATH",
 					EnvVars: []string{"NYDUS_IMAGE"},
 				},
+				&cli.StringFlag{
+					Name:    "builder",
+					Value:   "nydus-image",
+					Usage:   "Builder to build Nydus image, possible values: 'nydus-image', 'native' (built-in RAFS v6 builder without nydus-image binary, not supporting chunk-dict, parent-bootstrap and compact)",
+					EnvVars: []string{"BUILDER"},
+				},
 			},
 			Before: func(ctx *cli.Context) error {
+				switch ctx.String("builder") {
+				case "nydus-image", "native":
+				default:
+					return errors.Errorf("unsupported builder '%s'", ctx.String("builder"))
+				}
+				if ctx.String("source-dir") == "" && ctx.String("source-tar") == "" {
+					return errors.New("either --source-dir or --source-tar is required")
+				}
+				if ctx.String("source-dir") != "" && ctx.String("source-tar") != "" {
+					return errors.New("--source-dir conflicts with --source-tar")
+				}
+				if ctx.String("source-tar") != "" {
+					return nil
+				}
 				sourcePath := ctx.String("source-dir")
 				fi, err := os.Stat(sourcePath)
 				if err != nil {
@@ -1068,6 +3217,7 @@ func main() {
 				if p, err = packer.New(packer.Opt{
 					LogLevel:       logrus.GetLevel(),
 					NydusImagePath: c.String("nydus-image"),
+					NativeBuilder:  c.String("builder") == "native",
 					OutputDir:      c.String("output-dir"),
 					BackendConfig:  backendConfig,
 				}); err != nil {
@@ -1076,6 +3226,7 @@ func main() {
 
 				if res, err = p.Pack(context.Background(), packer.PackRequest{
 					SourceDir:    c.String("source-dir"),
+					SourceTar:    c.String("source-tar"),
 					ImageName:    c.String("name"),
 					PushToRemote: c.Bool("backend-push"),
 					FsVersion:    c.String("fs-version"),
@@ -1086,27 +3237,36 @@ func main() {
 					Parent:            c.String("parent-bootstrap"),
 					TryCompact:        c.Bool("compact"),
 					CompactConfigPath: c.String("compact-config-file"),
+					Incremental:       c.Bool("incremental"),
+
+					OutputImage:         c.String("output-image"),
+					OutputImageInsecure: c.Bool("output-image-insecure"),
+					Platform:            c.String("platform"),
 				}); err != nil {
 					return err
 				}
 				logrus.Infof("successfully built Nydus image (bootstrap:'%s', blob:'%s')", res.Meta, res.Blob)
+				if res.Image != "" {
+					logrus.Infof("successfully pushed Nydus image %s", res.Image)
+				}
 				return nil
 			},
 		},
 		{
-			Name:  "copy",
-			Usage: "Copy an image from source to target",
+			Name:      "copy",
+			Usage:     "Copy an image from source to target",
+			ArgsUsage: "[<source repository> <target repository>...] (with --repo-sync)",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     "source",
-					Required: true,
+					Required: false,
 					Usage:    "Source image reference",
 					EnvVars:  []string{"SOURCE"},
 				},
-				&cli.StringFlag{
+				&cli.StringSliceFlag{
 					Name:     "target",
 					Required: false,
-					Usage:    "Target image reference",
+					Usage:    "Target image reference, specify multiple times to replicate the image to several registries with one pull",
 					EnvVars:  []string{"TARGET"},
 				},
 				&cli.BoolFlag{
@@ -1125,9 +3285,15 @@ func main() {
 				&cli.StringFlag{
 					Name:    "source-backend-type",
 					Value:   "",
-					Usage:   "Type of storage backend, possible values: 'oss', 's3'",
+					Usage:   "Type of storage backend, possible values: 'oss', 's3', 'azblob', 'gcs', 'external', 'http', 'ipfs', 'hdfs', 'localfs'",
 					EnvVars: []string{"BACKEND_TYPE"},
 				},
+				&cli.PathFlag{
+					Name:      "source-backend-plugin",
+					TakesFile: true,
+					Usage:     "Path to the backend plugin binary if --source-backend-type is 'external'",
+					EnvVars:   []string{"SOURCE_BACKEND_PLUGIN"},
+				},
 				&cli.StringFlag{
 					Name:    "source-backend-config",
 					Value:   "",
@@ -1141,6 +3307,33 @@ func main() {
 					Usage:     "Json configuration file for storage backend",
 					EnvVars:   []string{"BACKEND_CONFIG_FILE"},
 				},
+				&cli.StringFlag{
+					Name:    "target-backend-type",
+					Value:   "",
+					Usage:   "Type of storage backend to relocate the Nydus blobs to, the blob layers are removed from target image, possible values: 'oss', 's3', 'azblob', 'gcs', 'external', 'http', 'ipfs', 'hdfs', 'localfs'",
+					EnvVars: []string{"TARGET_BACKEND_TYPE"},
+				},
+				&cli.PathFlag{
+					Name:      "target-backend-plugin",
+					TakesFile: true,
+					Usage:     "Path to the backend plugin binary if --target-backend-type is 'external'",
+					EnvVars:   []string{"TARGET_BACKEND_PLUGIN"},
+				},
+				&cli.StringFlag{
+					Name:    "target-backend-config",
+					Value:   "",
+					Usage:   "Json configuration string for target storage backend",
+					EnvVars: []string{"TARGET_BACKEND_CONFIG"},
+				},
+				&cli.PathFlag{
+					Name:      "target-backend-config-file",
+					Value:     "",
+					TakesFile: true,
+					Usage:     "Json configuration file for target storage backend",
+					EnvVars:   []string{"TARGET_BACKEND_CONFIG_FILE"},
+				},
+				existenceCacheFlag(),
+				existenceCacheTTLFlag(),
 
 				&cli.BoolFlag{
 					Name:  "all-platforms",
@@ -1152,12 +3345,62 @@ func main() {
 					Value: "linux/" + runtime.GOARCH,
 					Usage: "Copy images for specific platforms, for example: 'linux/amd64,linux/arm64'",
 				},
+				&cli.StringFlag{
+					Name:  "filter-platform",
+					Value: "",
+					Usage: "Strip the unmatched platforms from image index and keep the index, for example: 'linux/amd64,linux/arm64', conflicts with --platform and --all-platforms",
+				},
+				&cli.BoolFlag{
+					Name:  "strip-attestations",
+					Value: false,
+					Usage: "Strip the attestation manifests from image index",
+				},
+				&cli.BoolFlag{
+					Name:  "strip-foreign-layers",
+					Value: false,
+					Usage: "Strip the manifests referring foreign layers (e.g. Windows base layers) from image index",
+				},
+				&cli.BoolFlag{
+					Name:  "repo-sync",
+					Value: false,
+					Usage: "Sync the tags of source repository to target repositories given by arguments, the up-to-date tags are skipped",
+				},
+				&cli.StringFlag{
+					Name:  "include-tags",
+					Value: "",
+					Usage: "Regular expression of the tags to sync with --repo-sync, for example: '^v[0-9]+'",
+				},
+				&cli.StringFlag{
+					Name:  "exclude-tags",
+					Value: "",
+					Usage: "Regular expression of the tags to skip with --repo-sync, for example: '-rc[0-9]*$'",
+				},
+				&cli.BoolFlag{
+					Name:  "preserve-digest",
+					Value: false,
+					Usage: "Copy the image index and manifests as is to keep the image digest identical, requires --all-platforms",
+				},
 
 				&cli.StringFlag{
 					Name:  "push-chunk-size",
 					Value: "0MB",
 					Usage: "Chunk size for pushing a blob layer in chunked",
 				},
+				&cli.IntFlag{
+					Name:  "max-concurrent-blobs",
+					Value: 0,
+					Usage: "Count of blobs copied concurrently, default to 5",
+				},
+				&cli.StringFlag{
+					Name:  "blob-chunk-size",
+					Value: "0MB",
+					Usage: "Read the blobs larger than the chunk size by concurrent range requests of the size, for example: '64MB', requires --blob-concurrency",
+				},
+				&cli.IntFlag{
+					Name:  "blob-concurrency",
+					Value: 1,
+					Usage: "Count of concurrent range requests to read a blob larger than --blob-chunk-size",
+				},
 
 				&cli.StringFlag{
 					Name:    "work-dir",
@@ -1165,6 +3408,8 @@ func main() {
 					Usage:   "Working directory for image copy",
 					EnvVars: []string{"WORK_DIR"},
 				},
+				workDirQuotaFlag(),
+				keepWorkDirFlag(workdir.KeepNever),
 				&cli.StringFlag{
 					Name:    "nydus-image",
 					Value:   "nydus-image",
@@ -1179,6 +3424,10 @@ func main() {
 				if err != nil {
 					return err
 				}
+				targetBackendType, targetBackendConfig, err := getBackendConfig(c, "target-", false)
+				if err != nil {
+					return err
+				}
 
 				pushChunkSize, err := humanize.ParseBytes(c.String("push-chunk-size"))
 				if err != nil {
@@ -1188,30 +3437,86 @@ func main() {
 					logrus.Infof("will copy layer with chunk size %s", c.String("push-chunk-size"))
 				}
 
+				blobChunkSize, err := humanize.ParseBytes(c.String("blob-chunk-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --blob-chunk-size option")
+				}
+
+				if c.String("filter-platform") != "" && (c.IsSet("platform") || c.Bool("all-platforms")) {
+					return fmt.Errorf("--filter-platform conflicts with --platform and --all-platforms")
+				}
+
+				source := c.String("source")
+				targets := c.StringSlice("target")
+				if c.Bool("repo-sync") {
+					if c.NArg() < 2 {
+						return fmt.Errorf("source and target repositories are required by --repo-sync")
+					}
+					source, targets = c.Args().First(), c.Args().Tail()
+				}
+				if source == "" || len(targets) == 0 {
+					return fmt.Errorf("both --source and --target are required")
+				}
+
+				workDirQuota, err := getWorkDirQuota(c)
+				if err != nil {
+					return err
+				}
+				keepWorkDir, err := getKeepWorkDir(c)
+				if err != nil {
+					return err
+				}
+				existenceCache, err := openExistenceCache(c)
+				if err != nil {
+					return err
+				}
+				defer saveExistenceCache(existenceCache)
 				opt := copier.Opt{
 					WorkDir:        c.String("work-dir"),
+					WorkDirQuota:   workDirQuota,
+					KeepWorkDir:    keepWorkDir,
 					NydusImagePath: c.String("nydus-image"),
 
-					Source:         c.String("source"),
-					Target:         c.String("target"),
+					Source:         source,
+					Target:         targets[0],
+					ExtraTargets:   targets[1:],
 					SourceInsecure: c.Bool("source-insecure"),
 					TargetInsecure: c.Bool("target-insecure"),
 
 					SourceBackendType:   sourceBackendType,
 					SourceBackendConfig: sourceBackendConfig,
+					TargetBackendType:   targetBackendType,
+					TargetBackendConfig: targetBackendConfig,
+					ExistenceCache:      existenceCache,
 
 					AllPlatforms: c.Bool("all-platforms"),
 					Platforms:    c.String("platform"),
 
+					FilterPlatforms:    c.String("filter-platform"),
+					StripAttestations:  c.Bool("strip-attestations"),
+					StripForeignLayers: c.Bool("strip-foreign-layers"),
+					PreserveDigest:     c.Bool("preserve-digest"),
+
 					PushChunkSize: int64(pushChunkSize),
+
+					MaxConcurrentBlobs: c.Int("max-concurrent-blobs"),
+					BlobChunkSize:      int64(blobChunkSize),
+					BlobConcurrency:    c.Int("blob-concurrency"),
 				}
 
+				if c.Bool("repo-sync") {
+					return copier.Sync(context.Background(), copier.SyncOpt{
+						Opt:         opt,
+						IncludeTags: c.String("include-tags"),
+						ExcludeTags: c.String("exclude-tags"),
+					})
+				}
 				return copier.Copy(context.Background(), opt)
 			},
 		},
 		{
-			Name:  "optimize",
-			Usage: "Optimize a source nydus image and push to the target",
+			Name:  "revert",
+			Usage: "Revert a Nydus image to an OCI or eStargz image",
 			Flags: []cli.Flag{
 				&cli.StringFlag{
 					Name:     "source",
@@ -1222,6 +3527,131 @@ func main() {
 				&cli.StringFlag{
 					Name:     "target",
 					Required: true,
+					Usage:    "Target (OCI) image reference",
+					EnvVars:  []string{"TARGET"},
+				},
+				&cli.BoolFlag{
+					Name:     "source-insecure",
+					Required: false,
+					Usage:    "Skip verifying server certs for HTTPS source registry",
+					EnvVars:  []string{"SOURCE_INSECURE"},
+				},
+				&cli.BoolFlag{
+					Name:     "target-insecure",
+					Required: false,
+					Usage:    "Skip verifying server certs for HTTPS target registry",
+					EnvVars:  []string{"TARGET_INSECURE"},
+				},
+				&cli.StringFlag{
+					Name:    "target-format",
+					Value:   reverter.TargetFormatOCI,
+					Usage:   "Layer format of target image, possible values: 'oci', 'estargz'",
+					EnvVars: []string{"TARGET_FORMAT"},
+				},
+				&cli.StringFlag{
+					Name:    "source-backend-type",
+					Value:   "",
+					Usage:   "Type of storage backend of the blobs if source image has only the bootstrap layer, possible values: 'oss', 's3', 'azblob', 'gcs', 'external', 'http', 'ipfs', 'hdfs', 'localfs'",
+					EnvVars: []string{"SOURCE_BACKEND_TYPE"},
+				},
+				&cli.PathFlag{
+					Name:      "source-backend-plugin",
+					TakesFile: true,
+					Usage:     "Path to the backend plugin binary if --source-backend-type is 'external'",
+					EnvVars:   []string{"SOURCE_BACKEND_PLUGIN"},
+				},
+				&cli.StringFlag{
+					Name:    "source-backend-config",
+					Value:   "",
+					Usage:   "Json configuration string for source storage backend",
+					EnvVars: []string{"SOURCE_BACKEND_CONFIG"},
+				},
+				&cli.PathFlag{
+					Name:      "source-backend-config-file",
+					Value:     "",
+					TakesFile: true,
+					Usage:     "Json configuration file for source storage backend",
+					EnvVars:   []string{"SOURCE_BACKEND_CONFIG_FILE"},
+				},
+				&cli.BoolFlag{
+					Name:    "plain-http",
+					Value:   false,
+					Usage:   "Enable plain http for image pull and push",
+					EnvVars: []string{"PLAIN_HTTP"},
+				},
+
+				&cli.BoolFlag{
+					Name:  "all-platforms",
+					Value: false,
+					Usage: "Revert images for all platforms, conflicts with --platform",
+				},
+				&cli.StringFlag{
+					Name:  "platform",
+					Value: "linux/" + runtime.GOARCH,
+					Usage: "Revert images for specific platforms, for example: 'linux/amd64,linux/arm64'",
+				},
+
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory for image revert",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				&cli.StringFlag{
+					Name:    "nydus-image",
+					Value:   "nydus-image",
+					Usage:   "Path to the nydus-image binary, default to search in PATH",
+					EnvVars: []string{"NYDUS_IMAGE"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				targetFormat := c.String("target-format")
+				possibleFormats := []string{reverter.TargetFormatOCI, reverter.TargetFormatEStargz}
+				if !isPossibleValue(possibleFormats, targetFormat) {
+					return fmt.Errorf("--target-format should be one of %v", possibleFormats)
+				}
+				sourceBackendType, sourceBackendConfig, err := getBackendConfig(c, "source-", false)
+				if err != nil {
+					return err
+				}
+
+				opt := reverter.Opt{
+					WorkDir:        c.String("work-dir"),
+					NydusImagePath: c.String("nydus-image"),
+
+					Source:         c.String("source"),
+					Target:         c.String("target"),
+					SourceInsecure: c.Bool("source-insecure"),
+					TargetInsecure: c.Bool("target-insecure"),
+					WithPlainHTTP:  c.Bool("plain-http"),
+
+					SourceBackendType:   sourceBackendType,
+					SourceBackendConfig: sourceBackendConfig,
+
+					AllPlatforms: c.Bool("all-platforms"),
+					Platforms:    c.String("platform"),
+
+					TargetFormat: targetFormat,
+				}
+
+				return reverter.Revert(context.Background(), opt)
+			},
+		},
+		{
+			Name:  "optimize",
+			Usage: "Optimize a source nydus image and push to the target",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "source",
+					Required: false,
+					Usage:    "Source (Nydus) image reference",
+					EnvVars:  []string{"SOURCE"},
+				},
+				&cli.StringFlag{
+					Name:     "target",
+					Required: false,
 					Usage:    "Target (Nydus) image reference",
 					EnvVars:  []string{"TARGET"},
 				},
@@ -1250,6 +3680,23 @@ func main() {
 					Usage:    "File path to include prefetch files for optimization",
 					EnvVars:  []string{"PREFETCH_FILES"},
 				},
+				&cli.StringSliceFlag{
+					Name:     "access-trace",
+					Required: false,
+					Usage:    "Access pattern file exported by nydusd API '/api/v1/metrics/pattern' to generate prefetch files ordered by first access, conflicts with --prefetch-files",
+					EnvVars:  []string{"ACCESS_TRACE"},
+				},
+				&cli.StringFlag{
+					Name:    "hot-blob-max-size",
+					Value:   "0",
+					Usage:   "Max size of prefetch blob, only the most frequently accessed files within the size are prefetched, 0 means unlimited",
+					EnvVars: []string{"HOT_BLOB_MAX_SIZE"},
+				},
+				&cli.BoolFlag{
+					Name:    "verify",
+					Usage:   "Verify the optimized image is content-identical to the source image before pushing",
+					EnvVars: []string{"VERIFY"},
+				},
 
 				&cli.StringFlag{
 					Name:    "work-dir",
@@ -1281,6 +3728,10 @@ func main() {
 				if pushChunkSize > 0 {
 					logrus.Infof("will push layer with chunk size %s", c.String("push-chunk-size"))
 				}
+				hotBlobMaxSize, err := humanize.ParseBytes(c.String("hot-blob-max-size"))
+				if err != nil {
+					return errors.Wrap(err, "invalid --hot-blob-max-size option")
+				}
 				opt := optimizer.Opt{
 					WorkDir:        c.String("work-dir"),
 					NydusImagePath: c.String("nydus-image"),
@@ -1295,10 +3746,119 @@ func main() {
 
 					PushChunkSize:     int64(pushChunkSize),
 					PrefetchFilesPath: c.String("prefetch-files"),
+					AccessTracePaths:  c.StringSlice("access-trace"),
+					HotBlobMaxSize:    hotBlobMaxSize,
+					Verify:            c.Bool("verify"),
+				}
+				// The flags are not required by cli, otherwise they are
+				// also required by subcommand.
+				if opt.Source == "" || opt.Target == "" {
+					return errors.New("--source and --target are required")
+				}
+				if opt.PrefetchFilesPath == "" && len(opt.AccessTracePaths) == 0 {
+					return errors.New("either --prefetch-files or --access-trace is required")
 				}
 
 				return optimizer.Optimize(context.Background(), opt)
 			},
+			Subcommands: []*cli.Command{
+				{
+					Name:  "record",
+					Usage: "Record the files accessed by a running Nydus container as prefetch files",
+					Flags: []cli.Flag{
+						&cli.StringFlag{
+							Name:    "containerd-address",
+							Value:   "/run/containerd/containerd.sock",
+							Usage:   "Containerd address, optionally with \"unix://\" prefix [$CONTAINERD_ADDRESS] (default \"/run/containerd/containerd.sock\")",
+							EnvVars: []string{"CONTAINERD_ADDR"},
+						},
+						&cli.StringFlag{
+							Name:    "namespace",
+							Aliases: []string{"n"},
+							Value:   "default",
+							Usage:   "Container namespace, default with \"default\" namespace",
+							EnvVars: []string{"NAMESPACE"},
+						},
+						&cli.StringFlag{
+							Name:    "container",
+							Usage:   "Container ID (supports short ID, full ID) to find the nydusd serving its rootfs",
+							EnvVars: []string{"CONTAINER"},
+						},
+						&cli.StringFlag{
+							Name:    "api-sock",
+							Usage:   "API socket of nydusd, detected from --container if not specified",
+							EnvVars: []string{"API_SOCK"},
+						},
+						&cli.StringFlag{
+							Name:    "fs-id",
+							Usage:   "Mount id of RAFS instance in nydusd, like \"/<snapshot-id>\" of shared nydusd",
+							EnvVars: []string{"FS_ID"},
+						},
+						&cli.StringFlag{
+							Name:    "source",
+							Usage:   "Source (Nydus) image reference to map accessed files, default to the image of --container",
+							EnvVars: []string{"SOURCE"},
+						},
+						&cli.BoolFlag{
+							Name:    "source-insecure",
+							Usage:   "Skip verifying server certs for HTTPS source registry",
+							EnvVars: []string{"SOURCE_INSECURE"},
+						},
+						&cli.StringFlag{
+							Name:    "bootstrap",
+							Usage:   "Bootstrap of Nydus image to map accessed files instead of pulling from --source",
+							EnvVars: []string{"BOOTSTRAP"},
+						},
+						&cli.DurationFlag{
+							Name:    "duration",
+							Value:   time.Minute,
+							Usage:   "Warm-up window of workload to record, interrupt to stop early",
+							EnvVars: []string{"DURATION"},
+						},
+						&cli.StringFlag{
+							Name:     "output",
+							Aliases:  []string{"o"},
+							Required: true,
+							Usage:    "File path to write prefetch files, which can be used by --prefetch-files",
+							EnvVars:  []string{"OUTPUT"},
+						},
+						&cli.StringFlag{
+							Name:    "work-dir",
+							Value:   "./tmp",
+							Usage:   "Working directory for recording",
+							EnvVars: []string{"WORK_DIR"},
+						},
+					},
+					Action: func(c *cli.Context) error {
+						setupLogLevel(c)
+
+						opt := optimizer.RecordOpt{
+							WorkDir: c.String("work-dir"),
+
+							ContainerdAddress: c.String("containerd-address"),
+							Namespace:         c.String("namespace"),
+							ContainerID:       c.String("container"),
+
+							APISock: c.String("api-sock"),
+							FsID:    c.String("fs-id"),
+
+							BootstrapPath:  c.String("bootstrap"),
+							Source:         c.String("source"),
+							SourceInsecure: c.Bool("source-insecure"),
+
+							Duration:   c.Duration("duration"),
+							OutputPath: c.String("output"),
+						}
+						if opt.ContainerID == "" && opt.APISock == "" {
+							return errors.New("either --container or --api-sock is required")
+						}
+
+						ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+						defer cancel()
+						return optimizer.Record(ctx, opt)
+					},
+				},
+			},
 		},
 		{
 			Name:  "commit",
@@ -1310,6 +3870,8 @@ func main() {
 					Usage:   "Working directory for commit workflow",
 					EnvVars: []string{"WORK_DIR"},
 				},
+				workDirQuotaFlag(),
+				keepWorkDirFlag(workdir.KeepNever),
 				&cli.StringFlag{
 					Name:    "nydus-image",
 					Value:   "nydus-image",
@@ -1322,6 +3884,24 @@ func main() {
 					Usage:   "Containerd address, optionally with \"unix://\" prefix [$CONTAINERD_ADDRESS] (default \"/run/containerd/containerd.sock\")",
 					EnvVars: []string{"CONTAINERD_ADDR"},
 				},
+				&cli.StringFlag{
+					Name:    "runtime",
+					Value:   "containerd",
+					Usage:   "Container runtime of the container, possible values: containerd, docker, crio",
+					EnvVars: []string{"RUNTIME"},
+				},
+				&cli.StringFlag{
+					Name:    "runtime-address",
+					Value:   "",
+					Usage:   "Docker Engine socket (default \"/var/run/docker.sock\") or CRI-O container state directory (default \"/run/containers/storage/overlay-containers\")",
+					EnvVars: []string{"RUNTIME_ADDRESS"},
+				},
+				&cli.StringFlag{
+					Name:    "oci-runtime",
+					Value:   "runc",
+					Usage:   "OCI runtime binary used to pause the CRI-O container",
+					EnvVars: []string{"OCI_RUNTIME"},
+				},
 				&cli.StringFlag{
 					Name:    "namespace",
 					Aliases: []string{"n"},
@@ -1337,10 +3917,36 @@ func main() {
 				},
 				&cli.StringFlag{
 					Name:     "target",
-					Required: true,
+					Required: false,
 					Usage:    "Target nydus image reference",
 					EnvVars:  []string{"TARGET"},
 				},
+				&cli.BoolFlag{
+					Name:     "pause",
+					Value:    true,
+					Required: false,
+					Usage:    "Pause the container while capturing its changes to get a consistent snapshot, the container is resumed automatically",
+					EnvVars:  []string{"PAUSE"},
+				},
+				&cli.BoolFlag{
+					Name:     "no-pause",
+					Required: false,
+					Usage:    "Commit the container without pausing it, the committed changes may be inconsistent",
+					EnvVars:  []string{"NO_PAUSE"},
+				},
+				&cli.StringFlag{
+					Name:     "pause-method",
+					Value:    "runtime",
+					Required: false,
+					Usage:    "The way to pause the container, possible values: runtime (pause by container runtime), freezer (freeze cgroup of container)",
+					EnvVars:  []string{"PAUSE_METHOD"},
+				},
+				&cli.StringFlag{
+					Name:     "export-dir",
+					Required: false,
+					Usage:    "Export the committed blobs and bootstrap to the directory instead of pushing to target",
+					EnvVars:  []string{"EXPORT_DIR"},
+				},
 				&cli.BoolFlag{
 					Name:     "source-insecure",
 					Required: false,
@@ -1361,6 +3967,33 @@ func main() {
 					Usage:       "The maximum times allowed to be committed",
 					EnvVars:     []string{"MAXIMUM_TIMES"},
 				},
+				&cli.StringSliceFlag{
+					Name:     "change",
+					Aliases:  []string{"c"},
+					Required: false,
+					Usage:    "Apply Dockerfile instruction to the committed image config, like 'ENV FOO=bar', supports CMD, ENTRYPOINT, ENV, EXPOSE, LABEL, STOPSIGNAL, USER, VOLUME and WORKDIR",
+					EnvVars:  []string{"CHANGE"},
+				},
+				&cli.StringFlag{
+					Name:     "author",
+					Aliases:  []string{"a"},
+					Required: false,
+					Usage:    "Author of the committed image, like 'Nydus <nydus@example.com>'",
+					EnvVars:  []string{"AUTHOR"},
+				},
+				&cli.StringFlag{
+					Name:     "message",
+					Aliases:  []string{"m"},
+					Required: false,
+					Usage:    "Commit message recorded in the image history",
+					EnvVars:  []string{"MESSAGE"},
+				},
+				&cli.BoolFlag{
+					Name:     "squash",
+					Required: false,
+					Usage:    "Squash the layers committed before and the container changes into one layer, which resets the committed times",
+					EnvVars:  []string{"SQUASH"},
+				},
 				&cli.StringSliceFlag{
 					Name:     "with-path",
 					Aliases:  []string{"with-mount-path"},
@@ -1368,6 +4001,12 @@ func main() {
 					Usage:    "The external directory (for example mountpoint) in container that need to be committed",
 					EnvVars:  []string{"WITH_PATH"},
 				},
+				&cli.StringSliceFlag{
+					Name:     "exclude",
+					Required: false,
+					Usage:    "The glob pattern (.dockerignore syntax) of paths in container to be excluded from commit, the patterns in .nydusignore of container root are added",
+					EnvVars:  []string{"EXCLUDE"},
+				},
 			},
 			Action: func(c *cli.Context) error {
 				setupLogLevel(c)
@@ -1389,11 +4028,35 @@ func main() {
 					return withPaths, withoutPaths
 				}
 
+				if c.String("target") == "" && c.String("export-dir") == "" {
+					return errors.New("either --target or --export-dir is required")
+				}
+				if c.String("target") != "" && c.String("export-dir") != "" {
+					return errors.New("--target conflicts with --export-dir")
+				}
+				if c.IsSet("pause") && c.Bool("pause") && c.Bool("no-pause") {
+					return errors.New("--pause conflicts with --no-pause")
+				}
+
+				workDirQuota, err := getWorkDirQuota(c)
+				if err != nil {
+					return err
+				}
+				keepWorkDir, err := getKeepWorkDir(c)
+				if err != nil {
+					return err
+				}
+
 				withPaths, withoutPaths := parsePaths(c.StringSlice("with-path"))
 				opt := committer.Opt{
 					WorkDir:           c.String("work-dir"),
+					WorkDirQuota:      workDirQuota,
+					KeepWorkDir:       keepWorkDir,
 					NydusImagePath:    c.String("nydus-image"),
 					ContainerdAddress: c.String("containerd-address"),
+					Runtime:           c.String("runtime"),
+					RuntimeAddress:    c.String("runtime-address"),
+					OCIRuntime:        c.String("oci-runtime"),
 					Namespace:         c.String("namespace"),
 					ContainerID:       c.String("container"),
 					TargetRef:         c.String("target"),
@@ -1402,6 +4065,14 @@ func main() {
 					MaximumTimes:      c.Int("maximum-times"),
 					WithPaths:         withPaths,
 					WithoutPaths:      withoutPaths,
+					Excludes:          c.StringSlice("exclude"),
+					ExportDir:         c.String("export-dir"),
+					NoPause:           c.Bool("no-pause") || !c.Bool("pause"),
+					PauseMethod:       c.String("pause-method"),
+					Squash:            c.Bool("squash"),
+					Changes:           c.StringSlice("change"),
+					Author:            c.String("author"),
+					Message:           c.String("message"),
 				}
 				cm, err := committer.NewCommitter(opt)
 				if err != nil {
@@ -1410,13 +4081,306 @@ func main() {
 				return cm.Commit(c.Context, opt)
 			},
 		},
+		{
+			Name:  "serve",
+			Usage: "Run as a conversion service which exposes a REST API to manage conversion jobs",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "address",
+					Value:   "127.0.0.1:8080",
+					Usage:   "Address to listen on for the REST API",
+					EnvVars: []string{"ADDRESS"},
+				},
+				&cli.UintFlag{
+					Name:    "workers",
+					Value:   4,
+					Usage:   "Maximum number of conversion jobs running at the same time",
+					EnvVars: []string{"WORKERS"},
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory for image conversion",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				&cli.StringFlag{
+					Name:    "nydus-image",
+					Value:   "nydus-image",
+					Usage:   "Path to the nydus-image binary, default to search in PATH",
+					EnvVars: []string{"NYDUS_IMAGE"},
+				},
+				&cli.DurationFlag{
+					Name:    "job-retention",
+					Value:   24 * time.Hour,
+					Usage:   "Remove the finished jobs after the duration",
+					EnvVars: []string{"JOB_RETENTION"},
+				},
+				&cli.UintFlag{
+					Name:    "max-finished-jobs",
+					Value:   1000,
+					Usage:   "Maximum number of finished jobs kept, the earliest finished ones are removed first",
+					EnvVars: []string{"MAX_FINISHED_JOBS"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				if c.Uint("workers") < 1 {
+					return fmt.Errorf("--workers should be greater than 0")
+				}
+
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+
+				return server.New(server.Opt{
+					Address:         c.String("address"),
+					Workers:         c.Uint("workers"),
+					WorkDir:         c.String("work-dir"),
+					NydusImagePath:  c.String("nydus-image"),
+					JobRetention:    c.Duration("job-retention"),
+					MaxFinishedJobs: c.Uint("max-finished-jobs"),
+				}).Run(ctx)
+			},
+		},
+		{
+			Name:  "watch",
+			Usage: "Receive registry webhook notifications and convert the pushed images to Nydus images automatically",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "address",
+					Value:   "127.0.0.1:8080",
+					Usage:   "Address to listen on for webhook notifications",
+					EnvVars: []string{"ADDRESS"},
+				},
+				&cli.StringFlag{
+					Name:    "path",
+					Value:   "/webhook",
+					Usage:   "URL path to receive webhook notifications of Harbor or Docker Registry v2",
+					EnvVars: []string{"WEBHOOK_PATH"},
+				},
+				&cli.StringFlag{
+					Name:    "secret",
+					Value:   "",
+					Usage:   "Secret compared with the 'Authorization' header of webhook requests, not checked if empty",
+					EnvVars: []string{"WEBHOOK_SECRET"},
+				},
+				&cli.StringFlag{
+					Name:    "target-suffix",
+					Value:   "-nydus",
+					Usage:   "Suffix appended to the tag of pushed image as the target image, the pushed images with this suffix are ignored",
+					EnvVars: []string{"TARGET_SUFFIX"},
+				},
+				&cli.StringSliceFlag{
+					Name:    "namespace",
+					Usage:   "Glob pattern of repository namespaces to convert, for example 'library' or 'team/*', convert all namespaces if not specified",
+					EnvVars: []string{"NAMESPACE"},
+				},
+				&cli.UintFlag{
+					Name:    "workers",
+					Value:   4,
+					Usage:   "Maximum number of conversions running at the same time",
+					EnvVars: []string{"WORKERS"},
+				},
+				&cli.BoolFlag{
+					Name:    "source-insecure",
+					Usage:   "Skip verifying server certs for HTTPS source registry",
+					EnvVars: []string{"SOURCE_INSECURE"},
+				},
+				&cli.BoolFlag{
+					Name:    "target-insecure",
+					Usage:   "Skip verifying server certs for HTTPS target registry",
+					EnvVars: []string{"TARGET_INSECURE"},
+				},
+				&cli.BoolFlag{
+					Name:    "plain-http",
+					Usage:   "Use plain HTTP for the source and target registry",
+					EnvVars: []string{"PLAIN_HTTP"},
+				},
+				&cli.StringFlag{
+					Name:    "build-cache",
+					Value:   "",
+					Usage:   "Specify a cache image to accelerate nydus image conversion",
+					EnvVars: []string{"BUILD_CACHE"},
+				},
+				&cli.BoolFlag{
+					Name:    "oci",
+					Usage:   "Convert Docker media types to OCI media types",
+					EnvVars: []string{"OCI"},
+				},
+				&cli.StringFlag{
+					Name:    "fs-version",
+					Value:   "6",
+					Usage:   "Nydus image format version number, possible values: 5, 6",
+					EnvVars: []string{"FS_VERSION"},
+				},
+				&cli.StringFlag{
+					Name:    "compressor",
+					Value:   "zstd",
+					Usage:   "Algorithm to compress image data blob, possible values: none, lz4_block, zstd",
+					EnvVars: []string{"COMPRESSOR"},
+				},
+				&cli.StringFlag{
+					Name:    "work-dir",
+					Value:   "./tmp",
+					Usage:   "Working directory for image conversion",
+					EnvVars: []string{"WORK_DIR"},
+				},
+				&cli.StringFlag{
+					Name:    "nydus-image",
+					Value:   "nydus-image",
+					Usage:   "Path to the nydus-image binary, default to search in PATH",
+					EnvVars: []string{"NYDUS_IMAGE"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				if c.Uint("workers") < 1 {
+					return fmt.Errorf("--workers should be greater than 0")
+				}
+				possibleFsVersions := []string{"5", "6"}
+				if !isPossibleValue(possibleFsVersions, c.String("fs-version")) {
+					return fmt.Errorf("--fs-version should be one of %v", possibleFsVersions)
+				}
+
+				w, err := watcher.New(watcher.Opt{
+					Address:      c.String("address"),
+					Path:         c.String("path"),
+					Secret:       c.String("secret"),
+					TargetSuffix: c.String("target-suffix"),
+					Namespaces:   c.StringSlice("namespace"),
+					Workers:      c.Uint("workers"),
+					ConvertOptions: api.ConvertOptions{
+						SourceInsecure: c.Bool("source-insecure"),
+						TargetInsecure: c.Bool("target-insecure"),
+						PlainHTTP:      c.Bool("plain-http"),
+						WorkDir:        c.String("work-dir"),
+						NydusImagePath: c.String("nydus-image"),
+						CacheRef:       c.String("build-cache"),
+						Docker2OCI:     c.Bool("oci"),
+						FsVersion:      c.String("fs-version"),
+						Compressor:     c.String("compressor"),
+					},
+				})
+				if err != nil {
+					return err
+				}
+
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+
+				return w.Run(ctx)
+			},
+		},
+		{
+			Name:  "operator",
+			Usage: "Run Kubernetes controller converting images declared by NydusConversion resources as jobs",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "api-server",
+					Value:   "",
+					Usage:   "URL of Kubernetes API server, e.g. 'http://127.0.0.1:8001' served by 'kubectl proxy', use the in-cluster service account if empty",
+					EnvVars: []string{"KUBE_API_SERVER"},
+				},
+				&cli.PathFlag{
+					Name:      "token-file",
+					TakesFile: true,
+					Usage:     "File containing the bearer token to access Kubernetes API server",
+					EnvVars:   []string{"KUBE_TOKEN_FILE"},
+				},
+				&cli.PathFlag{
+					Name:      "ca-file",
+					TakesFile: true,
+					Usage:     "PEM encoded CA bundle to verify the certificate of Kubernetes API server",
+					EnvVars:   []string{"KUBE_CA_FILE"},
+				},
+				&cli.StringFlag{
+					Name:    "namespace",
+					Value:   "",
+					Usage:   "Namespace of NydusConversion resources to watch, watch all namespaces if empty",
+					EnvVars: []string{"WATCH_NAMESPACE"},
+				},
+				&cli.StringFlag{
+					Name:     "image",
+					Required: true,
+					Usage:    "Nydusify image running the conversion jobs, can be overridden by NydusConversion",
+					EnvVars:  []string{"NYDUSIFY_IMAGE"},
+				},
+				&cli.StringFlag{
+					Name:    "job-service-account",
+					Value:   "",
+					Usage:   "Service account of conversion jobs, use the default service account of namespace if empty",
+					EnvVars: []string{"JOB_SERVICE_ACCOUNT"},
+				},
+				&cli.IntFlag{
+					Name:    "backoff-limit",
+					Value:   2,
+					Usage:   "Number of retries of a failed conversion job, can be overridden by NydusConversion",
+					EnvVars: []string{"BACKOFF_LIMIT"},
+				},
+				&cli.DurationFlag{
+					Name:    "resync",
+					Value:   30 * time.Second,
+					Usage:   "Interval to list all NydusConversion resources and check the status of their jobs",
+					EnvVars: []string{"RESYNC"},
+				},
+				&cli.IntFlag{
+					Name:    "workers",
+					Value:   4,
+					Usage:   "Number of NydusConversion resources reconciled at the same time",
+					EnvVars: []string{"WORKERS"},
+				},
+				&cli.StringFlag{
+					Name:    "metrics-address",
+					Value:   ":9090",
+					Usage:   "Address to serve prometheus metrics on '/metrics', disabled if empty",
+					EnvVars: []string{"METRICS_ADDRESS"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				setupLogLevel(c)
+
+				if c.Int("backoff-limit") < 0 {
+					return fmt.Errorf("--backoff-limit should not be negative")
+				}
+
+				client, err := operator.NewClient(operator.ClientConfig{
+					Server:    c.String("api-server"),
+					TokenFile: c.String("token-file"),
+					CAFile:    c.String("ca-file"),
+				})
+				if err != nil {
+					return err
+				}
+				ctrl, err := operator.New(client, operator.Opt{
+					Namespace:         c.String("namespace"),
+					Image:             c.String("image"),
+					JobServiceAccount: c.String("job-service-account"),
+					BackoffLimit:      int32(c.Int("backoff-limit")),
+					Resync:            c.Duration("resync"),
+					Workers:           c.Int("workers"),
+					MetricsAddress:    c.String("metrics-address"),
+				})
+				if err != nil {
+					return err
+				}
+
+				ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer cancel()
+
+				return ctrl.Run(ctx)
+			},
+		},
 	}
 
 	if !utils.IsSupportedArch(runtime.GOARCH) {
 		logrus.Fatal("Nydusify can only work under architecture 'amd64' and 'arm64'")
 	}
 
-	if err := app.Run(os.Args); err != nil {
+	err := app.Run(os.Args)
+	// The backend plugins are killed before exit.
+	backend.CleanupPlugins()
+	if err != nil {
 		logrus.Fatal(err)
 	}
 }
@@ -1470,5 +4434,16 @@ func getGlobalFlags() []cli.Flag {
 			Usage:    "Write logs to a file",
 			EnvVars:  []string{"LOG_FILE"},
 		},
+		&cli.PathFlag{
+			Name:      "config",
+			TakesFile: true,
+			Usage:     "Configuration file in YAML providing the defaults of flags, default to ~/.nydusify/config.yaml if it exists",
+			EnvVars:   []string{"NYDUSIFY_CONFIG"},
+		},
+		&cli.StringFlag{
+			Name:    "otlp-endpoint",
+			Usage:   "Export the traces of conversion to an OpenTelemetry OTLP/HTTP endpoint, e.g. http://localhost:4318",
+			EnvVars: []string{"OTLP_ENDPOINT"},
+		},
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
@@ -200,6 +201,21 @@ func TestGetBackendConfig(t *testing.T) {
 	}
 }
 
+func TestCheckBackendType(t *testing.T) {
+	require.NoError(t, checkBackendType("", "", convertBackendTypes))
+	require.NoError(t, checkBackendType("", "oss", convertBackendTypes))
+	require.NoError(t, checkBackendType("source-", "ipfs", nydusdBackendTypes))
+
+	err := checkBackendType("", "azblob", convertBackendTypes)
+	require.ErrorContains(t, err, "--backend-type azblob is not supported by this command")
+	err = checkBackendType("target-", "azblob", nydusdBackendTypes)
+	require.ErrorContains(t, err, "--target-backend-type azblob is not supported by this command")
+	err = checkBackendType("", "gcs", convertBackendTypes)
+	require.ErrorContains(t, err, "--backend-type gcs is not supported by this command")
+	err = checkBackendType("source-", "gcs", nydusdBackendTypes)
+	require.ErrorContains(t, err, "--source-backend-type gcs is not supported by this command")
+}
+
 func TestGetTargetReference(t *testing.T) {
 	app := &cli.App{
 		Flags: []cli.Flag{
@@ -258,6 +274,28 @@ func TestGetTargetReference(t *testing.T) {
 	require.Equal(t, "testTarget", target)
 }
 
+func TestGetBatchImages(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "batch.yaml")
+	err := os.WriteFile(manifestPath, []byte(`
+images:
+  - source: localhost:5000/nginx:latest
+    target: localhost:5000/nginx:nydus
+  - source: localhost:5000/busybox:latest
+`), 0644)
+	require.NoError(t, err)
+
+	images, err := getBatchImages(manifestPath, "")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--target-suffix is required")
+	require.Nil(t, images)
+
+	images, err = getBatchImages(manifestPath, "-nydus")
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	require.Equal(t, "localhost:5000/nginx:nydus", images[0].Target)
+	require.Equal(t, "localhost:5000/busybox:latest-nydus", images[1].Target)
+}
+
 func TestGetCacheReference(t *testing.T) {
 	app := &cli.App{
 		Flags: []cli.Flag{
@@ -347,7 +385,7 @@ func TestGetPrefetchPatterns(t *testing.T) {
 
 func TestGetGlobalFlags(t *testing.T) {
 	flags := getGlobalFlags()
-	require.Equal(t, 3, len(flags))
+	require.Equal(t, 5, len(flags))
 }
 
 func TestSetupLogLevelWithLogFile(t *testing.T) {
@@ -391,3 +429,149 @@ func TestSetupLogLevelWithInvalidLogFile(t *testing.T) {
 	logrusOutput := logrus.StandardLogger().Out
 	assert.NotNil(t, logrusOutput)
 }
+
+func TestGetOutputLayout(t *testing.T) {
+	app := &cli.App{}
+
+	flagSet := flag.NewFlagSet("test", flag.PanicOnError)
+	flagSet.String("output-type", outputTypeRegistry, "")
+	flagSet.String("output-dir", "", "")
+	outputLayout, err := getOutputLayout(cli.NewContext(app, flagSet, nil))
+	require.NoError(t, err)
+	require.Empty(t, outputLayout)
+
+	require.NoError(t, flagSet.Set("output-dir", "./out"))
+	_, err = getOutputLayout(cli.NewContext(app, flagSet, nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--output-dir is only supported")
+
+	require.NoError(t, flagSet.Set("output-type", outputTypeOCILayout))
+	outputLayout, err = getOutputLayout(cli.NewContext(app, flagSet, nil))
+	require.NoError(t, err)
+	require.Equal(t, "./out", outputLayout)
+
+	require.NoError(t, flagSet.Set("output-dir", ""))
+	_, err = getOutputLayout(cli.NewContext(app, flagSet, nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--output-dir is required")
+
+	require.NoError(t, flagSet.Set("output-type", "tarball"))
+	_, err = getOutputLayout(cli.NewContext(app, flagSet, nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--output-type should be one of")
+}
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+log-level: debug
+work-dir: /var/lib/nydusify
+commands:
+  convert:
+    source: foo:latest
+    platform: [linux/amd64, linux/arm64]
+    retry: 3
+    timeout: 1m
+  cache prune:
+    work-dir: /data/cache
+`), 0644))
+
+	var values map[string]interface{}
+	newApp := func() *cli.App {
+		app := &cli.App{
+			Flags: []cli.Flag{
+				&cli.PathFlag{Name: "config"},
+				&cli.StringFlag{Name: "log-level", Value: "info"},
+			},
+			Before: applyConfig,
+		}
+		app.Commands = []*cli.Command{
+			{
+				Name: "convert",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "source", Required: true},
+					&cli.StringFlag{Name: "work-dir", Value: "./tmp"},
+					&cli.StringSliceFlag{Name: "platform"},
+					&cli.UintFlag{Name: "retry"},
+					&cli.DurationFlag{Name: "timeout"},
+				},
+				Action: func(c *cli.Context) error {
+					values = map[string]interface{}{
+						"log-level": c.String("log-level"),
+						"source":    c.String("source"),
+						"work-dir":  c.String("work-dir"),
+						"platform":  c.StringSlice("platform"),
+						"retry":     c.Uint("retry"),
+						"timeout":   c.Duration("timeout").String(),
+					}
+					return nil
+				},
+			},
+			{
+				Name: "cache",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "prune",
+						Flags: []cli.Flag{&cli.StringFlag{Name: "work-dir", Value: "./tmp"}},
+						Action: func(c *cli.Context) error {
+							values = map[string]interface{}{"work-dir": c.String("work-dir")}
+							return nil
+						},
+					},
+				},
+			},
+		}
+		return app
+	}
+
+	require.NoError(t, newApp().Run([]string{"nydusify", "--config", path, "convert"}))
+	require.Equal(t, map[string]interface{}{
+		"log-level": "debug",
+		"source":    "foo:latest",
+		"work-dir":  "/var/lib/nydusify",
+		"platform":  []string{"linux/amd64", "linux/arm64"},
+		"retry":     uint(3),
+		"timeout":   "1m0s",
+	}, values)
+
+	// The flags on command line take precedence.
+	require.NoError(t, newApp().Run([]string{"nydusify", "--config", path, "--log-level", "warn", "convert", "--platform", "linux/s390x", "--work-dir", "/tmp"}))
+	require.Equal(t, "warn", values["log-level"])
+	require.Equal(t, []string{"linux/s390x"}, values["platform"])
+	require.Equal(t, "/tmp", values["work-dir"])
+
+	require.NoError(t, newApp().Run([]string{"nydusify", "--config", path, "cache", "prune"}))
+	require.Equal(t, "/data/cache", values["work-dir"])
+
+	require.NoError(t, os.WriteFile(path, []byte("commands:\n  convert:\n    sources: foo:latest\n"), 0644))
+	require.ErrorContains(t, newApp().Run([]string{"nydusify", "--config", path, "convert"}), "unknown flag sources of command convert")
+	require.NoError(t, os.WriteFile(path, []byte("commands:\n  convert:\n    retry: -1\n"), 0644))
+	require.ErrorContains(t, newApp().Run([]string{"nydusify", "--config", path, "convert"}), "invalid value of retry")
+}
+
+func TestCompletionScript(t *testing.T) {
+	app := &cli.App{
+		Name:     "Nydusify",
+		HelpName: "nydusify",
+		Commands: []*cli.Command{
+			{Name: "convert", Flags: []cli.Flag{&cli.StringFlag{Name: "source"}}},
+		},
+	}
+
+	script, err := completionScript(app, "bash")
+	require.NoError(t, err)
+	require.Contains(t, script, "complete -o bashdefault -o default -F _nydusify_complete nydusify")
+
+	script, err = completionScript(app, "zsh")
+	require.NoError(t, err)
+	require.Contains(t, script, "compdef _nydusify nydusify")
+
+	script, err = completionScript(app, "fish")
+	require.NoError(t, err)
+	require.Contains(t, script, "complete -c nydusify")
+	require.Contains(t, script, "-l source")
+	require.Equal(t, "Nydusify", app.Name)
+
+	_, err = completionScript(app, "powershell")
+	require.Error(t, err)
+}
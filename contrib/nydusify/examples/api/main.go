@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+)
+
+func main() {
+	// Cancel the conversion on Ctrl+C
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	err := api.Convert(ctx, api.ConvertOptions{
+		Source:         "localhost:5000/ubuntu:latest",
+		Target:         "localhost:5000/ubuntu:latest-nydus",
+		SourceInsecure: true,
+		TargetInsecure: true,
+
+		NydusImagePath: "/path/to/nydus-image",
+		Docker2OCI:     true,
+
+		Progress: func(event api.Event) {
+			logrus.WithError(event.Err).Infof("%s: %s", event.Stage, event.Message)
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
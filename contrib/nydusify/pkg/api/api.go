@@ -0,0 +1,94 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package api exposes the nydusify operations as a stable Go API, so
+// that CI systems and custom controllers can embed image conversion,
+// verification, copying and committing without shelling out to the CLI.
+//
+// Every operation accepts a context for cancellation and an options
+// struct, the zero value of an option falls back to the same default
+// value as the corresponding CLI flag. For example:
+//
+//	err := api.Convert(ctx, api.ConvertOptions{
+//		Source: "localhost:5000/ubuntu:latest",
+//		Target: "localhost:5000/ubuntu:latest-nydus",
+//		Progress: func(event api.Event) {
+//			log.Printf("%s %s: %s", event.Operation, event.Stage, event.Message)
+//		},
+//	})
+package api
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+const (
+	defaultWorkDir        = "./tmp"
+	defaultNydusImagePath = "nydus-image"
+	defaultNydusdPath     = "nydusd"
+	defaultPlatform       = "linux/" + runtime.GOARCH
+)
+
+// Stage is the stage of an operation reported by progress events.
+type Stage string
+
+const (
+	StageStarted   Stage = "started"
+	StageSucceeded Stage = "succeeded"
+	StageFailed    Stage = "failed"
+)
+
+// Event is a progress event of an operation.
+type Event struct {
+	// Operation is the name of operation, e.g. "convert".
+	Operation string
+	Stage     Stage
+	Message   string
+	Time      time.Time
+	// Err is the failure reason if the stage is StageFailed.
+	Err error
+}
+
+// ProgressFunc receives progress events of an operation, it's called
+// synchronously so it should not block.
+type ProgressFunc func(Event)
+
+// run runs the operation and reports its progress, the operation isn't
+// started if the context has been canceled.
+func run(ctx context.Context, operation, message string, progress ProgressFunc, fn func(ctx context.Context) error) error {
+	report := func(stage Stage, err error) {
+		if progress != nil {
+			progress(Event{
+				Operation: operation,
+				Stage:     stage,
+				Message:   message,
+				Time:      time.Now(),
+				Err:       err,
+			})
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		report(StageFailed, err)
+		return err
+	}
+
+	report(StageStarted, nil)
+	if err := fn(ctx); err != nil {
+		report(StageFailed, err)
+		return err
+	}
+	report(StageSucceeded, nil)
+
+	return nil
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,162 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter"
+)
+
+func TestRun(t *testing.T) {
+	var stages []Stage
+	progress := func(event Event) {
+		require.Equal(t, "test", event.Operation)
+		require.Equal(t, "message", event.Message)
+		stages = append(stages, event.Stage)
+	}
+
+	err := run(context.Background(), "test", "message", progress, func(context.Context) error {
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Stage{StageStarted, StageSucceeded}, stages)
+
+	stages = nil
+	err = run(context.Background(), "test", "message", progress, func(context.Context) error {
+		return fmt.Errorf("mock error")
+	})
+	require.Error(t, err)
+	require.Equal(t, []Stage{StageStarted, StageFailed}, stages)
+
+	stages = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = run(ctx, "test", "message", progress, func(context.Context) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, called)
+	require.Equal(t, []Stage{StageFailed}, stages)
+
+	// Progress callback is optional.
+	err = run(context.Background(), "test", "message", nil, func(context.Context) error {
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestConvertOptions(t *testing.T) {
+	_, err := ConvertOptions{Source: "localhost:5000/busybox:latest"}.toOpt()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "both source and target are required")
+
+	opt, err := ConvertOptions{
+		Source: "localhost:5000/busybox:latest",
+		Target: "localhost:5000/busybox:latest-nydus",
+		OCIRef: true,
+	}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "./tmp", opt.WorkDir)
+	require.Equal(t, "nydus-image", opt.NydusImagePath)
+	require.Equal(t, "linux/"+runtime.GOARCH, opt.Platforms)
+	require.Equal(t, "6", opt.FsVersion)
+	require.Equal(t, "zstd", opt.Compressor)
+	require.Equal(t, "0x100000", opt.ChunkSize)
+	require.Equal(t, uint(200), opt.CacheMaxRecords)
+	require.Equal(t, 3, opt.PushRetryCount)
+	require.Equal(t, "5s", opt.PushRetryDelay)
+	require.True(t, opt.Docker2OCI)
+
+	hooks := converter.ScriptHooks("./hook.sh")
+	opt, err = ConvertOptions{
+		Source:         "localhost:5000/busybox:latest",
+		Target:         "localhost:5000/busybox:latest-nydus",
+		ChunkDict:      "bootstrap:registry:localhost:5000/dict:latest",
+		PushRetryDelay: time.Minute,
+		Hooks:          hooks,
+	}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "localhost:5000/dict:latest", opt.ChunkDictRef)
+	require.Equal(t, "1m0s", opt.PushRetryDelay)
+	require.Same(t, hooks, opt.Hooks)
+
+	_, err = ConvertOptions{
+		Source:    "localhost:5000/busybox:latest",
+		Target:    "localhost:5000/busybox:latest-nydus",
+		ChunkDict: "invalid",
+	}.toOpt()
+	require.Error(t, err)
+}
+
+func TestCheckOptions(t *testing.T) {
+	_, err := CheckOptions{}.toOpt()
+	require.Error(t, err)
+
+	opt, err := CheckOptions{Target: "localhost:5000/busybox:latest-nydus"}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "./output", opt.WorkDir)
+	require.Equal(t, "nydusd", opt.NydusdPath)
+	require.Equal(t, runtime.GOARCH, opt.ExpectedArch)
+}
+
+func TestCopyOptions(t *testing.T) {
+	_, err := CopyOptions{Source: "localhost:5000/busybox:latest"}.toOpt()
+	require.Error(t, err)
+
+	opt, err := CopyOptions{
+		Source:       "localhost:5000/busybox:latest",
+		Target:       "file:///tmp/busybox.tar",
+		AllPlatforms: true,
+	}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "file:///tmp/busybox.tar", opt.Target)
+	require.True(t, opt.AllPlatforms)
+	opt, err = CopyOptions{
+		Source:       "localhost:5000/busybox:latest",
+		Target:       "localhost:5001/busybox:latest",
+		ExtraTargets: []string{"localhost:5002/busybox:latest"},
+	}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, []string{"localhost:5002/busybox:latest"}, opt.ExtraTargets)
+}
+
+func TestCommitOptions(t *testing.T) {
+	_, err := CommitOptions{Target: "localhost:5000/busybox:committed"}.toOpt()
+	require.Error(t, err)
+
+	opt, err := CommitOptions{
+		ContainerID: "abc",
+		Target:      "localhost:5000/busybox:committed",
+	}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "default", opt.Namespace)
+	require.Equal(t, "/run/containerd/containerd.sock", opt.ContainerdAddress)
+	require.Equal(t, 400, opt.MaximumTimes)
+	require.Equal(t, "localhost:5000/busybox:committed", opt.TargetRef)
+	require.Equal(t, "containerd", opt.Runtime)
+	require.Equal(t, "runc", opt.OCIRuntime)
+	require.Equal(t, "runtime", opt.PauseMethod)
+	require.False(t, opt.NoPause)
+
+	opt, err = CommitOptions{ContainerID: "abc", ExportDir: "/tmp/committed"}.toOpt()
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/committed", opt.ExportDir)
+
+	_, err = CommitOptions{
+		ContainerID: "abc",
+		Target:      "localhost:5000/busybox:committed",
+		ExportDir:   "/tmp/committed",
+	}.toOpt()
+	require.Error(t, err)
+}
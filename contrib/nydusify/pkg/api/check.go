@@ -0,0 +1,82 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker"
+)
+
+// CheckOptions are the options to verify a nydus image, and compare it
+// with the source OCI image if Source is specified.
+type CheckOptions struct {
+	// Source is the reference of the optional source OCI image.
+	Source string
+	// Target is the reference of nydus image to be verified.
+	Target         string
+	SourceInsecure bool
+	TargetInsecure bool
+
+	SourceBackendType   string
+	SourceBackendConfig string
+	TargetBackendType   string
+	TargetBackendConfig string
+
+	MultiPlatform bool
+	// Arch is the architecture of image to be verified, default to the
+	// architecture of current host.
+	Arch string
+
+	// WorkDir is default to "./output", where the image information
+	// is dumped to.
+	WorkDir        string
+	NydusImagePath string
+	NydusdPath     string
+
+	Progress ProgressFunc
+}
+
+func (opts CheckOptions) toOpt() (checker.Opt, error) {
+	if opts.Target == "" {
+		return checker.Opt{}, fmt.Errorf("target is required")
+	}
+
+	return checker.Opt{
+		WorkDir: valueOrDefault(opts.WorkDir, "./output"),
+
+		Source:              opts.Source,
+		Target:              opts.Target,
+		SourceInsecure:      opts.SourceInsecure,
+		TargetInsecure:      opts.TargetInsecure,
+		SourceBackendType:   opts.SourceBackendType,
+		SourceBackendConfig: opts.SourceBackendConfig,
+		TargetBackendType:   opts.TargetBackendType,
+		TargetBackendConfig: opts.TargetBackendConfig,
+
+		MultiPlatform:  opts.MultiPlatform,
+		NydusImagePath: valueOrDefault(opts.NydusImagePath, defaultNydusImagePath),
+		NydusdPath:     valueOrDefault(opts.NydusdPath, defaultNydusdPath),
+		ExpectedArch:   valueOrDefault(opts.Arch, runtime.GOARCH),
+	}, nil
+}
+
+// Check verifies the manifest, bootstrap and filesystem of the nydus image.
+func Check(ctx context.Context, opts CheckOptions) error {
+	opt, err := opts.toOpt()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("check %s", opts.Target)
+	return run(ctx, "check", message, opts.Progress, func(ctx context.Context) error {
+		checker, err := checker.New(opt)
+		if err != nil {
+			return err
+		}
+		return checker.Check(ctx)
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/committer"
+)
+
+// CommitOptions are the options to commit the changes of a container
+// running with a nydus image to a new nydus image.
+type CommitOptions struct {
+	// ContainerID is the full ID of container.
+	ContainerID string
+	// Target is the reference of committed nydus image.
+	Target         string
+	SourceInsecure bool
+	TargetInsecure bool
+	// ExportDir exports the committed blobs and bootstrap to the
+	// directory instead of pushing to Target.
+	ExportDir string
+
+	// ContainerdAddress is default to "/run/containerd/containerd.sock".
+	ContainerdAddress string
+	// Namespace is the containerd namespace, default to "default".
+	Namespace string
+	// Runtime is the container runtime (containerd, docker or crio),
+	// default to containerd. RuntimeAddress is the Docker Engine socket or
+	// the CRI-O container state directory, and OCIRuntime is the OCI
+	// runtime binary to pause CRI-O containers, default to "runc".
+	Runtime        string
+	RuntimeAddress string
+	OCIRuntime     string
+
+	// MaximumTimes is the max number of times the image can be
+	// committed, default to 400.
+	MaximumTimes int
+
+	// NoPause commits the container without pausing it. PauseMethod is
+	// "runtime" (default) or "freezer" to freeze the cgroup of container.
+	NoPause     bool
+	PauseMethod string
+
+	// Squash squashes the committed layers and the container changes
+	// into one layer.
+	Squash bool
+
+	// Changes are the Dockerfile instructions like "ENV FOO=bar" applied
+	// to the image config, Author and Message are recorded in the history.
+	Changes []string
+	Author  string
+	Message string
+
+	// WithPaths are the extra mount paths in container to be committed,
+	// and WithoutPaths are the paths to be excluded.
+	WithPaths    []string
+	WithoutPaths []string
+
+	// Excludes are the glob patterns of paths excluded from commit.
+	Excludes []string
+
+	WorkDir        string
+	NydusImagePath string
+
+	Progress ProgressFunc
+}
+
+func (opts CommitOptions) toOpt() (committer.Opt, error) {
+	if opts.ContainerID == "" || (opts.Target == "" && opts.ExportDir == "") {
+		return committer.Opt{}, fmt.Errorf("both container ID and target (or export directory) are required")
+	}
+	if opts.Target != "" && opts.ExportDir != "" {
+		return committer.Opt{}, fmt.Errorf("target conflicts with export directory")
+	}
+
+	maximumTimes := opts.MaximumTimes
+	if maximumTimes == 0 {
+		maximumTimes = 400
+	}
+
+	return committer.Opt{
+		WorkDir:           valueOrDefault(opts.WorkDir, defaultWorkDir),
+		NydusImagePath:    valueOrDefault(opts.NydusImagePath, defaultNydusImagePath),
+		ContainerdAddress: valueOrDefault(opts.ContainerdAddress, "/run/containerd/containerd.sock"),
+		Namespace:         valueOrDefault(opts.Namespace, "default"),
+		Runtime:           valueOrDefault(opts.Runtime, committer.RuntimeContainerd),
+		RuntimeAddress:    opts.RuntimeAddress,
+		OCIRuntime:        valueOrDefault(opts.OCIRuntime, "runc"),
+
+		ContainerID:    opts.ContainerID,
+		TargetRef:      opts.Target,
+		SourceInsecure: opts.SourceInsecure,
+		TargetInsecure: opts.TargetInsecure,
+		MaximumTimes:   maximumTimes,
+
+		WithPaths:    opts.WithPaths,
+		WithoutPaths: opts.WithoutPaths,
+		Excludes:     opts.Excludes,
+		ExportDir:    opts.ExportDir,
+		NoPause:      opts.NoPause,
+		PauseMethod:  valueOrDefault(opts.PauseMethod, committer.PauseRuntime),
+		Squash:       opts.Squash,
+		Changes:      opts.Changes,
+		Author:       opts.Author,
+		Message:      opts.Message,
+	}, nil
+}
+
+// Commit commits the changes of container to a new nydus image and
+// pushes it to the target reference, or exports it to the directory.
+func Commit(ctx context.Context, opts CommitOptions) error {
+	opt, err := opts.toOpt()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("commit container %s to %s", opts.ContainerID, valueOrDefault(opts.Target, opts.ExportDir))
+	return run(ctx, "commit", message, opts.Progress, func(ctx context.Context) error {
+		cm, err := committer.NewCommitter(opt)
+		if err != nil {
+			return errors.Wrap(err, "create committer")
+		}
+		return cm.Commit(ctx, opt)
+	})
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter"
+)
+
+// ConvertOptions are the options to convert an OCI image to a nydus image.
+type ConvertOptions struct {
+	// Source is the reference of source OCI image.
+	Source string
+	// Target is the reference of target nydus image.
+	Target         string
+	SourceInsecure bool
+	TargetInsecure bool
+	PlainHTTP      bool
+	// OutputLayout is the OCI image layout directory to write the target
+	// image instead of pushing it, the image is named by Target in layout.
+	OutputLayout string
+	// Stream reads source layers from registry on demand instead of
+	// downloading them into WorkDir.
+	Stream bool
+
+	// WorkDir is default to "./tmp", it's removed after conversion if it
+	// doesn't exist before.
+	WorkDir string
+	// NydusImagePath is default to search `nydus-image` in PATH.
+	NydusImagePath string
+
+	// Platforms is default to "linux/${GOARCH}" of current host, for example
+	// "linux/amd64,linux/arm64", conflicts with AllPlatforms.
+	Platforms     string
+	AllPlatforms  bool
+	MergePlatform bool
+
+	// BackendType is one of "oss", "s3" and "localfs", the blobs are pushed
+	// to target registry if it's empty.
+	BackendType      string
+	BackendConfig    string
+	BackendForcePush bool
+
+	CacheRef        string
+	CacheInsecure   bool
+	CacheVersion    string
+	CacheMaxRecords uint
+	// CacheMode is "image" (default) or "cas", see converter.Opt.CacheMode.
+	CacheMode string
+
+	// ChunkDict is a chunk dict expression, for example
+	// "bootstrap:registry:localhost:5000/namespace/app:chunk_dict".
+	ChunkDict         string
+	ChunkDictInsecure bool
+
+	Docker2OCI       bool
+	OCIRef           bool
+	WithReferrer     bool
+	FsVersion        string
+	FsAlignChunk     bool
+	Compressor       string
+	ChunkSize        string
+	BatchSize        string
+	PrefetchPatterns string
+
+	PushRetryCount int
+	PushRetryDelay time.Duration
+
+	// Hooks run the user logic at the stages of conversion, e.g. scanning
+	// the source layers, see converter.Hooks.
+	Hooks *converter.Hooks
+
+	Progress ProgressFunc
+}
+
+func (opts ConvertOptions) toOpt() (converter.Opt, error) {
+	if opts.Source == "" || opts.Target == "" {
+		return converter.Opt{}, fmt.Errorf("both source and target are required")
+	}
+
+	chunkDictRef := ""
+	if opts.ChunkDict != "" {
+		var err error
+		if _, _, chunkDictRef, err = converter.ParseChunkDictArgs(opts.ChunkDict); err != nil {
+			return converter.Opt{}, err
+		}
+	}
+
+	cacheMaxRecords := opts.CacheMaxRecords
+	if cacheMaxRecords == 0 {
+		cacheMaxRecords = 200
+	}
+	pushRetryCount := opts.PushRetryCount
+	if pushRetryCount == 0 {
+		pushRetryCount = 3
+	}
+	pushRetryDelay := opts.PushRetryDelay
+	if pushRetryDelay == 0 {
+		pushRetryDelay = 5 * time.Second
+	}
+
+	return converter.Opt{
+		WorkDir:        valueOrDefault(opts.WorkDir, defaultWorkDir),
+		NydusImagePath: valueOrDefault(opts.NydusImagePath, defaultNydusImagePath),
+
+		Source:         opts.Source,
+		Target:         opts.Target,
+		SourceInsecure: opts.SourceInsecure,
+		TargetInsecure: opts.TargetInsecure,
+		WithPlainHTTP:  opts.PlainHTTP,
+		OutputLayout:   opts.OutputLayout,
+		Stream:         opts.Stream,
+
+		BackendType:      opts.BackendType,
+		BackendConfig:    opts.BackendConfig,
+		BackendForcePush: opts.BackendForcePush,
+
+		CacheRef:        opts.CacheRef,
+		CacheInsecure:   opts.CacheInsecure,
+		CacheVersion:    valueOrDefault(opts.CacheVersion, "v1"),
+		CacheMaxRecords: cacheMaxRecords,
+		CacheMode:       valueOrDefault(opts.CacheMode, "image"),
+
+		ChunkDictRef:      chunkDictRef,
+		ChunkDictInsecure: opts.ChunkDictInsecure,
+
+		MergePlatform:    opts.MergePlatform,
+		Docker2OCI:       opts.Docker2OCI || opts.OCIRef,
+		OCIRef:           opts.OCIRef,
+		WithReferrer:     opts.WithReferrer,
+		FsVersion:        valueOrDefault(opts.FsVersion, "6"),
+		FsAlignChunk:     opts.FsAlignChunk,
+		Compressor:       valueOrDefault(opts.Compressor, "zstd"),
+		ChunkSize:        valueOrDefault(opts.ChunkSize, "0x100000"),
+		BatchSize:        valueOrDefault(opts.BatchSize, "0"),
+		PrefetchPatterns: opts.PrefetchPatterns,
+
+		AllPlatforms: opts.AllPlatforms,
+		Platforms:    valueOrDefault(opts.Platforms, defaultPlatform),
+
+		PushRetryCount: pushRetryCount,
+		PushRetryDelay: pushRetryDelay.String(),
+
+		Hooks: opts.Hooks,
+	}, nil
+}
+
+// Convert converts the source OCI image to a nydus image and pushes it
+// to the target reference.
+func Convert(ctx context.Context, opts ConvertOptions) error {
+	opt, err := opts.toOpt()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("convert %s to %s", opts.Source, opts.Target)
+	return run(ctx, "convert", message, opts.Progress, func(ctx context.Context) error {
+		return converter.Convert(ctx, opt)
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/copier"
+)
+
+// CopyOptions are the options to copy an OCI or nydus image between
+// registries or local tarballs.
+type CopyOptions struct {
+	// Source and Target are image references, or local tarball paths
+	// starting with "file://".
+	Source         string
+	Target         string
+	SourceInsecure bool
+	TargetInsecure bool
+	// ExtraTargets are the additional target image references, the image
+	// is pulled once and pushed to all the targets.
+	ExtraTargets []string
+
+	// SourceBackendType and SourceBackendConfig specify the storage
+	// backend of nydus blobs, the blobs are pulled from it and pushed
+	// to the target registry.
+	SourceBackendType   string
+	SourceBackendConfig string
+	// TargetBackendType and TargetBackendConfig specify the storage backend
+	// to relocate the nydus blobs to, the blob layers are removed from the
+	// target image.
+	TargetBackendType   string
+	TargetBackendConfig string
+
+	Platforms    string
+	AllPlatforms bool
+
+	// FilterPlatforms, StripAttestations and StripForeignLayers strip the
+	// manifests from image index, PreserveDigest keeps the image digest
+	// identical by copying the index and manifests as is.
+	FilterPlatforms    string
+	StripAttestations  bool
+	StripForeignLayers bool
+	PreserveDigest     bool
+
+	// PushChunkSize enables chunked push of layers if it's positive.
+	PushChunkSize int64
+	// MaxConcurrentBlobs is the count of blobs copied concurrently, the blobs
+	// larger than BlobChunkSize are read by BlobConcurrency range requests.
+	MaxConcurrentBlobs int
+	BlobChunkSize      int64
+	BlobConcurrency    int
+
+	WorkDir        string
+	NydusImagePath string
+
+	Progress ProgressFunc
+}
+
+func (opts CopyOptions) toOpt() (copier.Opt, error) {
+	if opts.Source == "" || opts.Target == "" {
+		return copier.Opt{}, fmt.Errorf("both source and target are required")
+	}
+
+	return copier.Opt{
+		WorkDir:        valueOrDefault(opts.WorkDir, defaultWorkDir),
+		NydusImagePath: valueOrDefault(opts.NydusImagePath, defaultNydusImagePath),
+
+		Source:         opts.Source,
+		Target:         opts.Target,
+		ExtraTargets:   opts.ExtraTargets,
+		SourceInsecure: opts.SourceInsecure,
+		TargetInsecure: opts.TargetInsecure,
+
+		SourceBackendType:   opts.SourceBackendType,
+		SourceBackendConfig: opts.SourceBackendConfig,
+		TargetBackendType:   opts.TargetBackendType,
+		TargetBackendConfig: opts.TargetBackendConfig,
+
+		AllPlatforms: opts.AllPlatforms,
+		Platforms:    valueOrDefault(opts.Platforms, defaultPlatform),
+
+		FilterPlatforms:    opts.FilterPlatforms,
+		StripAttestations:  opts.StripAttestations,
+		StripForeignLayers: opts.StripForeignLayers,
+		PreserveDigest:     opts.PreserveDigest,
+
+		PushChunkSize: opts.PushChunkSize,
+
+		MaxConcurrentBlobs: opts.MaxConcurrentBlobs,
+		BlobChunkSize:      opts.BlobChunkSize,
+		BlobConcurrency:    opts.BlobConcurrency,
+	}, nil
+}
+
+// Copy copies the image from source to target.
+func Copy(ctx context.Context, opts CopyOptions) error {
+	opt, err := opts.toOpt()
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("copy %s to %s", opts.Source, opts.Target)
+	return run(ctx, "copy", message, opts.Progress, func(ctx context.Context) error {
+		return copier.Copy(ctx, opt)
+	})
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+const (
+	// Azure allows at most 50000 blocks in a block blob, so the max blob
+	// size is about 50000 * azblobBlockSize.
+	azblobBlockSize = 64 * 1024 * 1024 // 64MB
+	// azblobUploadConcurrency is the number of blocks uploaded in parallel.
+	azblobUploadConcurrency = 8
+)
+
+type AzureBlobBackend struct {
+	// objectPrefix is the path prefix of the uploaded blob in container.
+	objectPrefix  string
+	containerName string
+	serviceURL    string
+	client        *azblob.Client
+}
+
+// AzblobConfig is the configuration of Azure Blob Storage backend, the
+// credential is chosen in below order:
+// 1. SAS token, if `sas_token` is specified;
+// 2. shared key, if `account_key` is specified;
+// 3. managed identity, if `managed_identity` is enabled, the user-assigned
+// identity is used if `managed_identity_client_id` is specified;
+// 4. the default Azure credential chain, which covers environment variables,
+// workload identity, managed identity and Azure CLI.
+type AzblobConfig struct {
+	AccountName             string `json:"account_name,omitempty"`
+	AccountKey              string `json:"account_key,omitempty"`
+	SASToken                string `json:"sas_token,omitempty"`
+	ManagedIdentity         bool   `json:"managed_identity,omitempty"`
+	ManagedIdentityClientID string `json:"managed_identity_client_id,omitempty"`
+	// Endpoint is the service URL, default to `https://${account_name}.blob.core.windows.net`.
+	Endpoint      string `json:"endpoint,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	ObjectPrefix  string `json:"object_prefix,omitempty"`
+}
+
+func newAzblobBackend(rawConfig []byte) (*AzureBlobBackend, error) {
+	cfg := &AzblobConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "parse Azure Blob storage backend configuration")
+	}
+
+	if cfg.ContainerName == "" || (cfg.AccountName == "" && cfg.Endpoint == "") {
+		return nil, fmt.Errorf("invalid Azure Blob configuration: missing 'container_name' or 'account_name'")
+	}
+	serviceURL := cfg.Endpoint
+	if serviceURL == "" {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", cfg.AccountName)
+	}
+
+	client, err := newAzblobClient(cfg, serviceURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "create Azure Blob client")
+	}
+
+	return &AzureBlobBackend{
+		objectPrefix:  cfg.ObjectPrefix,
+		containerName: cfg.ContainerName,
+		serviceURL:    serviceURL,
+		client:        client,
+	}, nil
+}
+
+func newAzblobClient(cfg *AzblobConfig, serviceURL string) (*azblob.Client, error) {
+	if cfg.SASToken != "" {
+		sasURL, err := url.Parse(serviceURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse service URL")
+		}
+		sasURL.RawQuery = strings.TrimPrefix(cfg.SASToken, "?")
+		return azblob.NewClientWithNoCredential(sasURL.String(), nil)
+	}
+
+	if cfg.AccountKey != "" {
+		cred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "create shared key credential")
+		}
+		return azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	}
+
+	var cred azcore.TokenCredential
+	var err error
+	if cfg.ManagedIdentity || cfg.ManagedIdentityClientID != "" {
+		opts := &azidentity.ManagedIdentityCredentialOptions{}
+		if cfg.ManagedIdentityClientID != "" {
+			opts.ID = azidentity.ClientID(cfg.ManagedIdentityClientID)
+		}
+		cred, err = azidentity.NewManagedIdentityCredential(opts)
+	} else {
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "create token credential")
+	}
+
+	return azblob.NewClient(serviceURL, cred, nil)
+}
+
+// Upload blob to Azure Blob Storage as a block blob, the blob is split
+// into blocks which are uploaded concurrently.
+func (b *AzureBlobBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "azblob", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	blobObjectKey := b.blobObjectKey(blobID)
+
+	desc := blobDesc(size, blobID)
+	desc.URLs = append(desc.URLs, b.remoteID(blobObjectKey))
+
+	if !forcePush {
+		if exist, err := b.existObject(ctx, blobObjectKey); err != nil {
+			return nil, errors.Wrap(err, "check object existence")
+		} else if exist {
+			logrus.Infof("skip upload because blob exists: %s", blobID)
+			return &desc, nil
+		}
+	}
+
+	start := time.Now()
+
+	blobFile, err := os.Open(blobPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "open blob file")
+	}
+	defer blobFile.Close()
+
+	if _, err := b.client.UploadFile(ctx, b.containerName, blobObjectKey, blobFile, &azblob.UploadFileOptions{
+		BlockSize:   azblobBlockSize,
+		Concurrency: azblobUploadConcurrency,
+	}); err != nil {
+		return nil, errors.Wrap(err, "upload blob to azblob backend")
+	}
+
+	logrus.Debugf("uploaded blob %s to azblob backend, costs %s", blobObjectKey, time.Since(start))
+
+	return &desc, nil
+}
+
+func (b *AzureBlobBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *AzureBlobBackend) Check(blobID string) (bool, error) {
+	return b.existObject(context.TODO(), b.blobObjectKey(blobID))
+}
+
+func (b *AzureBlobBackend) Type() Type {
+	return AzblobBackend
+}
+
+func (b *AzureBlobBackend) existObject(ctx context.Context, objectKey string) (bool, error) {
+	_, err := b.blobClient(objectKey).GetProperties(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *AzureBlobBackend) blobObjectKey(blobID string) string {
+	return b.objectPrefix + blobID
+}
+
+func (b *AzureBlobBackend) blobClient(objectKey string) *blob.Client {
+	return b.client.ServiceClient().NewContainerClient(b.containerName).NewBlobClient(objectKey)
+}
+
+type azblobRangeReader struct {
+	b         *AzureBlobBackend
+	objectKey string
+}
+
+func (rr *azblobRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	resp, err := rr.b.client.DownloadStream(context.TODO(), rr.b.containerName, rr.objectKey, &azblob.DownloadStreamOptions{
+		Range: azblob.HTTPRange{Offset: offset, Count: size},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "download blob range")
+	}
+	return resp.Body, nil
+}
+
+func (b *AzureBlobBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &azblobRangeReader{b: b, objectKey: b.blobObjectKey(blobID)}, nil
+}
+
+func (b *AzureBlobBackend) Reader(blobID string) (io.ReadCloser, error) {
+	resp, err := b.client.DownloadStream(context.TODO(), b.containerName, b.blobObjectKey(blobID), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "download blob")
+	}
+	return resp.Body, nil
+}
+
+func (b *AzureBlobBackend) Size(blobID string) (int64, error) {
+	props, err := b.blobClient(b.blobObjectKey(blobID)).GetProperties(context.TODO(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "get blob properties")
+	}
+	if props.ContentLength == nil {
+		return 0, fmt.Errorf("no content length in blob properties")
+	}
+	return *props.ContentLength, nil
+}
+
+func (b *AzureBlobBackend) remoteID(blobObjectKey string) string {
+	remoteURL, _ := url.Parse(b.serviceURL)
+	remoteURL.Path = path.Join(remoteURL.Path, b.containerName, blobObjectKey)
+	return remoteURL.String()
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAzblobBackend(t *testing.T) {
+	backend, err := newAzblobBackend([]byte(`
+	{
+		"account_name": "test",
+		"account_key": "dGVzdEtleQ==",
+		"container_name": "blobs",
+		"object_prefix": "nydus/"
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "nydus/", backend.objectPrefix)
+	require.Equal(t, "blobs", backend.containerName)
+	require.Equal(t, "https://test.blob.core.windows.net/", backend.serviceURL)
+	require.Equal(t, "nydus/111", backend.blobObjectKey("111"))
+	require.Equal(t, "https://test.blob.core.windows.net/blobs/nydus/111", backend.remoteID(backend.blobObjectKey("111")))
+	require.Equal(t, AzblobBackend, backend.Type())
+
+	backend, err = newAzblobBackend([]byte(`
+	{
+		"endpoint": "http://127.0.0.1:10000/devstoreaccount1",
+		"sas_token": "?sv=2022-11-02&sig=test",
+		"container_name": "blobs"
+	}`))
+	require.NoError(t, err)
+	require.Contains(t, backend.client.URL(), "sv=2022-11-02&sig=test")
+	require.Equal(t, "http://127.0.0.1:10000/devstoreaccount1/blobs/111", backend.remoteID(backend.blobObjectKey("111")))
+
+	backend, err = newAzblobBackend([]byte(`{"account_name": "test"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing 'container_name' or 'account_name'")
+	require.Nil(t, backend)
+
+	backend, err = newAzblobBackend([]byte(`{"account_name": "test",}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "parse Azure Blob storage backend configuration")
+	require.Nil(t, backend)
+}
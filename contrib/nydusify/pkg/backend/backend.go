@@ -8,12 +8,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
@@ -21,6 +25,13 @@ import (
 // 1. registry: complying to OCI distribution specification, push blob file
 // to registry and use the registry as a storage.
 // 2. oss: A object storage backend, which uses its SDK to transfer blob file.
+// 3. s3, azblob and gcs: Same as oss, but for AWS S3, Azure Blob Storage and
+// Google Cloud Storage.
+// 4. external: The storage accessed by the backend plugin, see Plugin.
+// 5. http: A static HTTP file server, which uploads blobs by WebDAV.
+// 6. ipfs: An IPFS node, see IPFSBackend.
+// 7. hdfs: A directory of HDFS accessed by WebHDFS.
+// 8. localfs: A local directory, see LocalFSBackend.
 type Backend interface {
 	// TODO: Hopefully, we can pass `Layer` struct in, thus to be able to cook both
 	// file handle and file path.
@@ -33,6 +44,22 @@ type Backend interface {
 	Size(blobID string) (int64, error)
 }
 
+// BlobObject is the blob stored in object storage backend.
+type BlobObject struct {
+	ID           string
+	Size         int64
+	LastModified time.Time
+}
+
+// Pruner is implemented by the object storage backends supporting to list
+// and delete the blobs, which is used to collect the orphaned blobs.
+type Pruner interface {
+	// List returns the blobs under the object prefix, the objects not named
+	// by blob ID are ignored.
+	List(ctx context.Context) ([]BlobObject, error)
+	Delete(ctx context.Context, blobID string) error
+}
+
 // TODO: Directly forward blob data to storage backend
 
 type Type = int
@@ -41,8 +68,29 @@ const (
 	OssBackend Type = iota
 	RegistryBackend
 	S3backend
+	AzblobBackend
+	GcsBackend
+	ExternalBackend
+	HttpBackend
+	IpfsBackend
+	HdfsBackend
+	LocalfsBackend
 )
 
+// isBlobID checks if the object name is the hex of sha256 digest.
+func isBlobID(name string) bool {
+	return digest.SHA256.Validate(name) == nil
+}
+
+// startUpload starts the span of uploading blob to the backend of type bt.
+func startUpload(ctx context.Context, bt, blobID string, size int64) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "backend.upload",
+		attribute.String("backend", bt),
+		attribute.String("blob", blobID),
+		attribute.Int64("size", size),
+	)
+}
+
 func blobDesc(size int64, blobID string) ocispec.Descriptor {
 	blobDigest := digest.NewDigestFromEncoded(digest.SHA256, blobID)
 	desc := ocispec.Descriptor{
@@ -75,6 +123,20 @@ func NewBackend(bt string, config []byte, remote *remote.Remote) (Backend, error
 		return newRegistryBackend(config, remote)
 	case "s3":
 		return newS3Backend(config)
+	case "azblob":
+		return newAzblobBackend(config)
+	case "gcs":
+		return newGCSBackend(config)
+	case "external":
+		return newExternalBackend(config)
+	case "http":
+		return newHTTPBackend(config)
+	case "ipfs":
+		return newIPFSBackend(config)
+	case "hdfs":
+		return newHDFSBackend(config)
+	case "localfs":
+		return newLocalFSBackend(config)
 	default:
 		return nil, fmt.Errorf("unsupported backend type %s", bt)
 	}
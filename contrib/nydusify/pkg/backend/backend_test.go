@@ -53,6 +53,30 @@ func TestNewBackend(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, S3backend, backend.Type())
 
+	azblobConfigJSON := `
+	{
+		"account_name": "test",
+		"account_key": "dGVzdEtleQ==",
+		"container_name": "blobs",
+		"object_prefix": "blob"
+	}`
+	require.True(t, json.Valid([]byte(azblobConfigJSON)))
+	backend, err = NewBackend("azblob", []byte(azblobConfigJSON), nil)
+	require.NoError(t, err)
+	require.Equal(t, AzblobBackend, backend.Type())
+
+	gcsConfigJSON := `
+	{
+		"endpoint": "http://localhost:4443",
+		"bucket_name": "test",
+		"object_prefix": "blob",
+		"anonymous": true
+	}`
+	require.True(t, json.Valid([]byte(gcsConfigJSON)))
+	backend, err = NewBackend("gcs", []byte(gcsConfigJSON), nil)
+	require.NoError(t, err)
+	require.Equal(t, GcsBackend, backend.Type())
+
 	testRegistryRemote, err := provider.DefaultRemote("test", false)
 	require.NoError(t, err)
 	backend, err = NewBackend("registry", nil, testRegistryRemote)
@@ -0,0 +1,279 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/metrics"
+)
+
+// existenceCacheVersion is the version of the existence cache file, the
+// file of other versions is ignored.
+const existenceCacheVersion = 1
+
+type existenceEntry struct {
+	// Time is when the blob is known to exist in backend.
+	Time time.Time `json:"time"`
+	// Desc is the descriptor returned by uploading the blob, which is
+	// absent if the blob is only checked.
+	Desc *ocispec.Descriptor `json:"desc,omitempty"`
+}
+
+type existenceFile struct {
+	Version int                       `json:"version"`
+	Entries map[string]existenceEntry `json:"entries"`
+}
+
+// ExistenceCache records the blobs known to exist in backends in a local
+// file, so that the repeated conversions don't check the existence of the
+// same blobs in backend again and again. Only the existence is cached, the
+// blob is checked in backend again after the entry expires, in case it's
+// deleted by others.
+type ExistenceCache struct {
+	path string
+	ttl  time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]existenceEntry
+	// deleted are the keys of blobs deleted by this process, which are
+	// removed from the file on saving.
+	deleted map[string]bool
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// OpenExistenceCache loads the existence cache from the file of path, the
+// entries older than ttl are ignored. The file is created on Save if it
+// doesn't exist.
+func OpenExistenceCache(path string, ttl time.Duration) (*ExistenceCache, error) {
+	if ttl <= 0 {
+		return nil, errors.Errorf("invalid TTL %s of existence cache", ttl)
+	}
+	cache := &ExistenceCache{
+		path:    path,
+		ttl:     ttl,
+		entries: map[string]existenceEntry{},
+		deleted: map[string]bool{},
+	}
+	entries, err := cache.load()
+	if err != nil {
+		return nil, err
+	}
+	cache.entries = entries
+	return cache, nil
+}
+
+// load reads the unexpired entries from the cache file, a corrupted file
+// is ignored as the cache can always be rebuilt from backend.
+func (cache *ExistenceCache) load() (map[string]existenceEntry, error) {
+	entries := map[string]existenceEntry{}
+	data, err := os.ReadFile(cache.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, errors.Wrap(err, "read existence cache")
+	}
+	file := existenceFile{}
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != existenceCacheVersion {
+		return entries, nil
+	}
+	now := time.Now()
+	for key, entry := range file.Entries {
+		if now.Sub(entry.Time) < cache.ttl {
+			entries[key] = entry
+		}
+	}
+	return entries, nil
+}
+
+func (cache *ExistenceCache) get(key string) (existenceEntry, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	entry, ok := cache.entries[key]
+	if ok && time.Since(entry.Time) >= cache.ttl {
+		delete(cache.entries, key)
+		return existenceEntry{}, false
+	}
+	return entry, ok
+}
+
+func (cache *ExistenceCache) put(key string, desc *ocispec.Descriptor) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries[key] = existenceEntry{Time: time.Now(), Desc: desc}
+	delete(cache.deleted, key)
+}
+
+func (cache *ExistenceCache) remove(key string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.entries, key)
+	cache.deleted[key] = true
+}
+
+// Stats returns the count of cache hits and misses.
+func (cache *ExistenceCache) Stats() (int64, int64) {
+	return cache.hits.Load(), cache.misses.Load()
+}
+
+// Save writes the entries into the cache file. The file is shared by the
+// processes, so the entries are merged with the ones saved by others in the
+// meantime under a file lock.
+func (cache *ExistenceCache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(cache.path), 0755); err != nil {
+		return errors.Wrap(err, "create directory of existence cache")
+	}
+	lock, err := os.OpenFile(cache.path+".lock", os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.Wrap(err, "create lock file of existence cache")
+	}
+	defer lock.Close()
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return errors.Wrap(err, "lock existence cache")
+	}
+
+	entries, err := cache.load()
+	if err != nil {
+		return err
+	}
+
+	cache.mutex.Lock()
+	for key := range cache.deleted {
+		delete(entries, key)
+	}
+	for key, entry := range cache.entries {
+		if saved, ok := entries[key]; !ok || entry.Time.After(saved.Time) {
+			entries[key] = entry
+		}
+	}
+	cache.mutex.Unlock()
+
+	data, err := json.Marshal(existenceFile{Version: existenceCacheVersion, Entries: entries})
+	if err != nil {
+		return errors.Wrap(err, "marshal existence cache")
+	}
+	tmp := cache.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "write existence cache")
+	}
+	if err := os.Rename(tmp, cache.path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "rename existence cache")
+	}
+	return nil
+}
+
+// WithExistenceCache returns the backend checking the existence of blobs in
+// cache before backend. The blobs are cached by the backend type bt and its
+// config, so that a cache is shared by different backends. The returned
+// backend implements Pruner if bkd does.
+func WithExistenceCache(bkd Backend, bt string, config []byte, cache *ExistenceCache) Backend {
+	sum := sha256.Sum256(append([]byte(bt+"\x00"), config...))
+	cached := &cachedBackend{
+		Backend: bkd,
+		bt:      bt,
+		prefix:  hex.EncodeToString(sum[:]) + "/",
+		cache:   cache,
+	}
+	if pruner, ok := bkd.(Pruner); ok {
+		return &cachedPruner{cachedBackend: cached, pruner: pruner}
+	}
+	return cached
+}
+
+// copyDesc returns a copy of desc, so that the cached descriptor isn't
+// modified by callers.
+func copyDesc(desc *ocispec.Descriptor) *ocispec.Descriptor {
+	copied := *desc
+	copied.URLs = append([]string(nil), desc.URLs...)
+	if desc.Annotations != nil {
+		copied.Annotations = make(map[string]string, len(desc.Annotations))
+		for key, value := range desc.Annotations {
+			copied.Annotations[key] = value
+		}
+	}
+	return &copied
+}
+
+type cachedBackend struct {
+	Backend
+	bt     string
+	prefix string
+	cache  *ExistenceCache
+}
+
+// lookup returns the cache entry of blob and records the hit or miss.
+func (b *cachedBackend) lookup(blobID string, needDesc bool) (existenceEntry, bool) {
+	entry, ok := b.cache.get(b.prefix + blobID)
+	if ok && (!needDesc || entry.Desc != nil) {
+		b.cache.hits.Add(1)
+		metrics.BackendExistenceCache(b.bt, true)
+		return entry, true
+	}
+	b.cache.misses.Add(1)
+	metrics.BackendExistenceCache(b.bt, false)
+	return existenceEntry{}, false
+}
+
+func (b *cachedBackend) Upload(ctx context.Context, blobID, blobPath string, blobSize int64, forcePush bool) (*ocispec.Descriptor, error) {
+	if !forcePush {
+		if entry, ok := b.lookup(blobID, true); ok {
+			return copyDesc(entry.Desc), nil
+		}
+	}
+	desc, err := b.Backend.Upload(ctx, blobID, blobPath, blobSize, forcePush)
+	if err != nil {
+		return nil, err
+	}
+	if desc != nil {
+		b.cache.put(b.prefix+blobID, copyDesc(desc))
+	}
+	return desc, nil
+}
+
+func (b *cachedBackend) Check(blobID string) (bool, error) {
+	if _, ok := b.lookup(blobID, false); ok {
+		return true, nil
+	}
+	exist, err := b.Backend.Check(blobID)
+	if err != nil {
+		return false, err
+	}
+	// Only the existing blobs are cached, the missing ones are likely
+	// uploaded soon.
+	if exist {
+		b.cache.put(b.prefix+blobID, nil)
+	}
+	return exist, nil
+}
+
+type cachedPruner struct {
+	*cachedBackend
+	pruner Pruner
+}
+
+func (b *cachedPruner) List(ctx context.Context) ([]BlobObject, error) {
+	return b.pruner.List(ctx)
+}
+
+func (b *cachedPruner) Delete(ctx context.Context, blobID string) error {
+	b.cache.remove(b.prefix + blobID)
+	return b.pruner.Delete(ctx, blobID)
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExistenceCache(t *testing.T) {
+	dir := t.TempDir()
+	config := []byte(`{"dir": "` + dir + `"}`)
+	localfs, err := newLocalFSBackend(config)
+	require.NoError(t, err)
+	cachePath := filepath.Join(t.TempDir(), "cache", "existence.json")
+
+	cache, err := OpenExistenceCache(cachePath, time.Hour)
+	require.NoError(t, err)
+	bkd := WithExistenceCache(localfs, "localfs", config, cache)
+	_, ok := bkd.(Pruner)
+	require.True(t, ok)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	otherID := "0e1c7a2f4b7ab0fc5b8d6c13bb6d86a4bd0cc4a29e8c7ed4a64c6f2e4f2ae3b1"
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, []byte("nydus"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, otherID), []byte("nydus"), 0644))
+
+	// The missing blob isn't cached.
+	exist, err := bkd.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+	desc, err := bkd.Upload(context.Background(), blobID, blobPath, 5, false)
+	require.NoError(t, err)
+	exist, err = bkd.Check(otherID)
+	require.NoError(t, err)
+	require.True(t, exist)
+	hits, misses := cache.Stats()
+	require.Equal(t, int64(0), hits)
+	require.Equal(t, int64(3), misses)
+	require.NoError(t, cache.Save())
+
+	// The blobs are known to exist by the cache saved by the last command,
+	// even if they are removed from backend behind the cache.
+	require.NoError(t, os.Remove(filepath.Join(dir, blobID)))
+	require.NoError(t, os.Remove(filepath.Join(dir, otherID)))
+	cache, err = OpenExistenceCache(cachePath, time.Hour)
+	require.NoError(t, err)
+	bkd = WithExistenceCache(localfs, "localfs", config, cache)
+	exist, err = bkd.Check(blobID)
+	require.NoError(t, err)
+	require.True(t, exist)
+	cachedDesc, err := bkd.Upload(context.Background(), blobID, blobPath, 5, false)
+	require.NoError(t, err)
+	require.Equal(t, desc.Digest, cachedDesc.Digest)
+	require.Equal(t, desc.Annotations, cachedDesc.Annotations)
+	exist, err = bkd.Check(otherID)
+	require.NoError(t, err)
+	require.True(t, exist)
+	hits, misses = cache.Stats()
+	require.Equal(t, int64(3), hits)
+	require.Equal(t, int64(0), misses)
+
+	// The blobs of other backends aren't shared.
+	otherConfig := []byte(`{"dir": "` + t.TempDir() + `"}`)
+	exist, err = WithExistenceCache(localfs, "localfs", otherConfig, cache).Check(otherID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	// The deleted blob is removed from the cache file.
+	require.NoError(t, bkd.(Pruner).Delete(context.Background(), otherID))
+	require.NoError(t, cache.Save())
+	cache, err = OpenExistenceCache(cachePath, time.Hour)
+	require.NoError(t, err)
+	bkd = WithExistenceCache(localfs, "localfs", config, cache)
+	exist, err = bkd.Check(otherID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	// The expired blobs are checked in backend again.
+	cache, err = OpenExistenceCache(cachePath, time.Nanosecond)
+	require.NoError(t, err)
+	exist, err = WithExistenceCache(localfs, "localfs", config, cache).Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	_, err = OpenExistenceCache(cachePath, 0)
+	require.ErrorContains(t, err, "invalid TTL")
+}
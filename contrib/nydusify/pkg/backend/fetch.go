@@ -0,0 +1,84 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// fetchAttempts is the count of attempts to read a blob mismatching its
+	// digest.
+	fetchAttempts = 3
+	// fetchConcurrency is the count of blobs fetched concurrently.
+	fetchConcurrency = 5
+)
+
+// ErrBlobMismatch is returned if the blob read from backend doesn't match
+// its digest, which may be corrupted by flaky network or proxies.
+var ErrBlobMismatch = errors.New("blob mismatch")
+
+// fetchBlobOnce reads the blob into dir through a temporary file, which is
+// renamed to the blob ID after verifying the blob by its digest.
+func fetchBlobOnce(bkd Backend, blobID, dir string) error {
+	rc, err := bkd.Reader(blobID)
+	if err != nil {
+		return errors.Wrap(err, "get blob reader")
+	}
+	defer rc.Close()
+
+	file, err := os.CreateTemp(dir, blobID+".fetching-")
+	if err != nil {
+		return errors.Wrap(err, "create temporary blob file")
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	digester := digest.SHA256.Digester()
+	if _, err := io.Copy(io.MultiWriter(file, digester.Hash()), rc); err != nil {
+		return errors.Wrap(err, "read blob")
+	}
+	if actual := digester.Digest(); actual.Encoded() != blobID {
+		return errors.Wrapf(ErrBlobMismatch, "read digest %s, expected sha256:%s", actual, blobID)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "close blob file")
+	}
+	return os.Rename(file.Name(), filepath.Join(dir, blobID))
+}
+
+// FetchBlobs reads the blobs in backend into dir named by blob ID, which is
+// the blob directory of `nydus-image` and nydusd. The blob is verified by
+// its digest, and read again on mismatch.
+func FetchBlobs(ctx context.Context, bkd Backend, blobIDs []string, dir string) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(fetchConcurrency)
+	for _, blobID := range blobIDs {
+		eg.Go(func() error {
+			for attempt := 1; ; attempt++ {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				err := fetchBlobOnce(bkd, blobID, dir)
+				if err == nil {
+					return nil
+				}
+				if !errors.Is(err, ErrBlobMismatch) || attempt >= fetchAttempts {
+					return errors.Wrapf(err, "fetch blob %s", blobID)
+				}
+				logrus.WithError(err).WithField("blob", blobID).Warnf("read blob again (attempt %d/%d)", attempt+1, fetchAttempts)
+			}
+		})
+	}
+	return eg.Wait()
+}
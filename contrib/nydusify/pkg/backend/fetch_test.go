@@ -0,0 +1,50 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchBlobs(t *testing.T) {
+	sourceDir := t.TempDir()
+	bkd, err := newLocalFSBackend([]byte(`{"dir": "` + sourceDir + `", "layout": "sharded"}`))
+	require.NoError(t, err)
+
+	var blobIDs []string
+	for _, data := range []string{"blob1", "blob2"} {
+		blobID := digest.FromString(data).Encoded()
+		blobPath := filepath.Join(t.TempDir(), blobID)
+		require.NoError(t, os.WriteFile(blobPath, []byte(data), 0644))
+		_, err := bkd.Upload(context.Background(), blobID, blobPath, int64(len(data)), false)
+		require.NoError(t, err)
+		blobIDs = append(blobIDs, blobID)
+	}
+
+	// The blobs are fetched into the flat directory.
+	dir := t.TempDir()
+	require.NoError(t, FetchBlobs(context.Background(), bkd, blobIDs, dir))
+	data, err := os.ReadFile(filepath.Join(dir, blobIDs[1]))
+	require.NoError(t, err)
+	require.Equal(t, "blob2", string(data))
+
+	// The corrupted blob isn't fetched.
+	require.NoError(t, os.WriteFile(bkd.blobPath(blobIDs[0]), []byte("corrupted"), 0644))
+	dir = t.TempDir()
+	err = FetchBlobs(context.Background(), bkd, blobIDs[:1], dir)
+	require.ErrorIs(t, err, ErrBlobMismatch)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	err = FetchBlobs(context.Background(), bkd, []string{digest.FromString("blob3").Encoded()}, t.TempDir())
+	require.ErrorContains(t, err, "get blob reader")
+}
@@ -0,0 +1,381 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsReadWriteScope  = "https://www.googleapis.com/auth/devstorage.read_write"
+	// The chunk size of resumable upload must be a multiple of 256KB.
+	gcsUploadChunkSize = 16 * 1024 * 1024 // 16MB
+	// gcsUploadChunkRetries is the max retry count of uploading a
+	// chunk, the upload is resumed from the offset persisted by GCS.
+	gcsUploadChunkRetries = 3
+)
+
+// GCSBackend uploads blobs to Google Cloud Storage via its JSON API,
+// which is also implemented by fake-gcs-server for testing.
+type GCSBackend struct {
+	objectPrefix string
+	bucketName   string
+	endpoint     string
+	client       *http.Client
+}
+
+type GCSConfig struct {
+	// Endpoint is default to `https://storage.googleapis.com`, it can be
+	// changed to a fake-gcs-server address like `http://localhost:4443`.
+	Endpoint     string `json:"endpoint,omitempty"`
+	BucketName   string `json:"bucket_name,omitempty"`
+	ObjectPrefix string `json:"object_prefix,omitempty"`
+	// CredentialsFile and CredentialsJSON specify a service account key,
+	// the application default credentials (e.g. workload identity) are
+	// used if neither of them is specified.
+	CredentialsFile string `json:"credentials_file,omitempty"`
+	CredentialsJSON string `json:"credentials_json,omitempty"`
+	// Anonymous disables authentication, mostly for fake-gcs-server.
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+func newGCSBackend(rawConfig []byte) (*GCSBackend, error) {
+	cfg := &GCSConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "parse GCS storage backend configuration")
+	}
+	if cfg.BucketName == "" {
+		return nil, fmt.Errorf("invalid GCS configuration: missing 'bucket_name'")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = gcsDefaultEndpoint
+	}
+
+	client, err := newGCSClient(context.Background(), cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create GCS client")
+	}
+
+	return &GCSBackend{
+		objectPrefix: cfg.ObjectPrefix,
+		bucketName:   cfg.BucketName,
+		endpoint:     strings.TrimSuffix(cfg.Endpoint, "/"),
+		client:       client,
+	}, nil
+}
+
+func newGCSClient(ctx context.Context, cfg *GCSConfig) (*http.Client, error) {
+	if cfg.Anonymous {
+		return &http.Client{}, nil
+	}
+
+	credentialsJSON := []byte(cfg.CredentialsJSON)
+	if cfg.CredentialsFile != "" {
+		data, err := os.ReadFile(cfg.CredentialsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read credentials file")
+		}
+		credentialsJSON = data
+	}
+
+	var creds *google.Credentials
+	var err error
+	if len(credentialsJSON) > 0 {
+		creds, err = google.CredentialsFromJSON(ctx, credentialsJSON, gcsReadWriteScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcsReadWriteScope)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "find credentials")
+	}
+
+	return oauth2.NewClient(ctx, creds.TokenSource), nil
+}
+
+// Upload blob to GCS by resumable upload, the blob is uploaded chunk by
+// chunk, and a failed chunk is retried from the offset persisted by GCS.
+func (b *GCSBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "gcs", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	blobObjectKey := b.blobObjectKey(blobID)
+
+	desc := blobDesc(size, blobID)
+	desc.URLs = append(desc.URLs, b.remoteID(blobObjectKey))
+
+	if !forcePush {
+		if exist, err := b.existObject(ctx, blobObjectKey); err != nil {
+			return nil, errors.Wrap(err, "check object existence")
+		} else if exist {
+			logrus.Infof("skip upload because blob exists: %s", blobID)
+			return &desc, nil
+		}
+	}
+
+	start := time.Now()
+
+	blobFile, err := os.Open(blobPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "open blob file")
+	}
+	defer blobFile.Close()
+
+	stat, err := blobFile.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "stat blob file")
+	}
+
+	sessionURL, err := b.initiateUpload(ctx, blobObjectKey, stat.Size())
+	if err != nil {
+		return nil, errors.Wrap(err, "initiate resumable upload")
+	}
+	if err := b.uploadChunks(ctx, sessionURL, blobFile, stat.Size()); err != nil {
+		return nil, errors.Wrap(err, "upload blob to gcs backend")
+	}
+
+	logrus.Debugf("uploaded blob %s to gcs backend, costs %s", blobObjectKey, time.Since(start))
+
+	return &desc, nil
+}
+
+func (b *GCSBackend) initiateUpload(ctx context.Context, objectKey string, size int64) (string, error) {
+	uploadURL := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=resumable&name=%s",
+		b.endpoint, url.PathEscape(b.bucketName), url.QueryEscape(objectKey))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", gcsResponseError(resp)
+	}
+
+	sessionURL := resp.Header.Get("Location")
+	if sessionURL == "" {
+		return "", fmt.Errorf("no session location in response")
+	}
+	return sessionURL, nil
+}
+
+func (b *GCSBackend) uploadChunks(ctx context.Context, sessionURL string, file io.ReaderAt, size int64) error {
+	offset := int64(0)
+	retries := 0
+	for {
+		chunkSize := min(size-offset, gcsUploadChunkSize)
+		done, persisted, err := b.uploadChunk(ctx, sessionURL, file, offset, chunkSize, size)
+		if err == nil && !done && persisted <= offset {
+			err = fmt.Errorf("no data persisted at offset %d", offset)
+		}
+		if err != nil {
+			if retries >= gcsUploadChunkRetries {
+				return err
+			}
+			retries++
+			logrus.WithError(err).Warnf("retry to upload chunk at offset %d", offset)
+			// Query the persisted offset and resume the upload from it.
+			done, persisted, err = b.uploadChunk(ctx, sessionURL, file, 0, 0, size)
+			if err != nil {
+				return errors.Wrap(err, "query upload status")
+			}
+		} else {
+			retries = 0
+		}
+		if done {
+			return nil
+		}
+		offset = persisted
+	}
+}
+
+// uploadChunk uploads the chunk [offset, offset+chunkSize) of the file, an
+// empty chunk only queries the upload status. It returns whether the upload
+// is completed and the size of data persisted by GCS.
+func (b *GCSBackend) uploadChunk(ctx context.Context, sessionURL string, file io.ReaderAt, offset, chunkSize, size int64) (bool, int64, error) {
+	var body io.Reader
+	contentRange := fmt.Sprintf("bytes */%d", size)
+	if chunkSize > 0 {
+		body = io.NewSectionReader(file, offset, chunkSize)
+		contentRange = fmt.Sprintf("bytes %d-%d/%d", offset, offset+chunkSize-1, size)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, sessionURL, body)
+	if err != nil {
+		return false, 0, err
+	}
+	req.ContentLength = chunkSize
+	req.Header.Set("Content-Range", contentRange)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, size, nil
+	case http.StatusPermanentRedirect:
+		// The `Range` header looks like `bytes=0-1023`, and it's absent
+		// if no data has been persisted.
+		persisted := int64(0)
+		if value := resp.Header.Get("Range"); value != "" {
+			var start, end int64
+			if _, err := fmt.Sscanf(value, "bytes=%d-%d", &start, &end); err != nil {
+				return false, 0, errors.Wrapf(err, "invalid range header %s", value)
+			}
+			persisted = end + 1
+		}
+		return false, persisted, nil
+	default:
+		return false, 0, gcsResponseError(resp)
+	}
+}
+
+func (b *GCSBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *GCSBackend) Check(blobID string) (bool, error) {
+	return b.existObject(context.TODO(), b.blobObjectKey(blobID))
+}
+
+func (b *GCSBackend) Type() Type {
+	return GcsBackend
+}
+
+type gcsObject struct {
+	Size string `json:"size"`
+}
+
+func (b *GCSBackend) getObject(ctx context.Context, objectKey string) (*gcsObject, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.objectURL(objectKey), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, gcsResponseError(resp)
+	}
+
+	var object gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return nil, errors.Wrap(err, "decode object metadata")
+	}
+	return &object, nil
+}
+
+func (b *GCSBackend) existObject(ctx context.Context, objectKey string) (bool, error) {
+	_, err := b.getObject(ctx, objectKey)
+	if err != nil {
+		var respErr *gcsError
+		if errors.As(err, &respErr) && respErr.statusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *GCSBackend) blobObjectKey(blobID string) string {
+	return b.objectPrefix + blobID
+}
+
+// objectURL returns the URL of object metadata, the `/` in object
+// name must be escaped in JSON API.
+func (b *GCSBackend) objectURL(objectKey string) string {
+	return fmt.Sprintf("%s/storage/v1/b/%s/o/%s", b.endpoint,
+		url.PathEscape(b.bucketName), strings.ReplaceAll(url.PathEscape(objectKey), "/", "%2F"))
+}
+
+func (b *GCSBackend) download(objectKey string, httpRange string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, b.objectURL(objectKey)+"?alt=media", nil)
+	if err != nil {
+		return nil, err
+	}
+	if httpRange != "" {
+		req.Header.Set("Range", httpRange)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		return nil, gcsResponseError(resp)
+	}
+	return resp.Body, nil
+}
+
+type gcsRangeReader struct {
+	b         *GCSBackend
+	objectKey string
+}
+
+func (rr *gcsRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	return rr.b.download(rr.objectKey, fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+}
+
+func (b *GCSBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &gcsRangeReader{b: b, objectKey: b.blobObjectKey(blobID)}, nil
+}
+
+func (b *GCSBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return b.download(b.blobObjectKey(blobID), "")
+}
+
+func (b *GCSBackend) Size(blobID string) (int64, error) {
+	object, err := b.getObject(context.TODO(), b.blobObjectKey(blobID))
+	if err != nil {
+		return 0, errors.Wrap(err, "get object size")
+	}
+	return strconv.ParseInt(object.Size, 10, 64)
+}
+
+func (b *GCSBackend) remoteID(blobObjectKey string) string {
+	return fmt.Sprintf("%s/%s/%s", b.endpoint, b.bucketName, blobObjectKey)
+}
+
+type gcsError struct {
+	statusCode int
+	message    string
+}
+
+func (e *gcsError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.statusCode, e.message)
+}
+
+func gcsResponseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &gcsError{statusCode: resp.StatusCode, message: strings.TrimSpace(string(body))}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGCSServer implements a minimal subset of GCS JSON API, the first
+// chunk upload of each session always fails to test resumable upload.
+type fakeGCSServer struct {
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	sessions map[string][]byte
+	failed   map[string]bool
+}
+
+func (s *fakeGCSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/test/o":
+		name := r.URL.Query().Get("name")
+		s.sessions[name] = []byte{}
+		w.Header().Set("Location", fmt.Sprintf("http://%s/session/%s", r.Host, name))
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/session/"):
+		name := strings.TrimPrefix(r.URL.Path, "/session/")
+		data, _ := io.ReadAll(r.Body)
+		var total int64
+		if r.ContentLength > 0 {
+			if !s.failed[name] {
+				s.failed[name] = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var start, end int64
+			fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			s.sessions[name] = append(s.sessions[name][:start], data...)
+		} else {
+			fmt.Sscanf(r.Header.Get("Content-Range"), "bytes */%d", &total)
+		}
+		if int64(len(s.sessions[name])) == total {
+			s.objects[name] = s.sessions[name]
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if len(s.sessions[name]) > 0 {
+			w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.sessions[name])-1))
+		}
+		w.WriteHeader(http.StatusPermanentRedirect)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/test/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/test/o/")
+		data, ok := s.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("alt") == "media" {
+			http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+			return
+		}
+		fmt.Fprintf(w, `{"name": %q, "size": "%d"}`, name, len(data))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestNewGCSBackend(t *testing.T) {
+	backend, err := newGCSBackend([]byte(`
+	{
+		"endpoint": "http://localhost:4443/",
+		"bucket_name": "test",
+		"object_prefix": "blob/",
+		"anonymous": true
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:4443", backend.endpoint)
+	require.Equal(t, "blob/111", backend.blobObjectKey("111"))
+	require.Equal(t, "http://localhost:4443/test/blob/111", backend.remoteID(backend.blobObjectKey("111")))
+	require.Equal(t, "http://localhost:4443/storage/v1/b/test/o/blob%2F111", backend.objectURL(backend.blobObjectKey("111")))
+	require.Equal(t, GcsBackend, backend.Type())
+
+	backend, err = newGCSBackend([]byte(`{"anonymous": true}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing 'bucket_name'")
+	require.Nil(t, backend)
+
+	backend, err = newGCSBackend([]byte(`{"bucket_name": "test", "credentials_file": "/path/not/exist"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "read credentials file")
+	require.Nil(t, backend)
+}
+
+func TestGCSUpload(t *testing.T) {
+	server := httptest.NewServer(&fakeGCSServer{
+		objects:  map[string][]byte{},
+		sessions: map[string][]byte{},
+		failed:   map[string]bool{},
+	})
+	defer server.Close()
+
+	backend, err := newGCSBackend([]byte(fmt.Sprintf(`{"endpoint": %q, "bucket_name": "test", "anonymous": true}`, server.URL)))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), gcsUploadChunkSize/4)
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0644))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	desc, err := backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	require.Equal(t, []string{server.URL + "/test/" + blobID}, desc.URLs)
+
+	exist, err = backend.Check(blobID)
+	require.NoError(t, err)
+	require.True(t, exist)
+
+	size, err := backend.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blobData)), size)
+
+	reader, err := backend.Reader(blobID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData, data)
+
+	rangeReader, err := backend.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err = rangeReader.Reader(5, 10)
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData[5:15], data)
+
+	_, err = backend.Reader("not-exist")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404")
+}
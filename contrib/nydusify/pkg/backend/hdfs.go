@@ -0,0 +1,394 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+// HDFSBackend stores blobs in a directory of HDFS by WebHDFS REST API. The
+// blob is written to a temporary file and renamed to the blob ID, so that
+// the partially written blob is never visible.
+type HDFSBackend struct {
+	endpoint        string
+	dir             string
+	user            string
+	delegationToken string
+	replication     int
+	client          *http.Client
+}
+
+type HDFSConfig struct {
+	// Endpoint is the WebHDFS address of namenode, e.g.
+	// `http://namenode:9870`.
+	Endpoint string `json:"endpoint"`
+	// Dir is the absolute HDFS directory of blobs.
+	Dir string `json:"dir"`
+	// User is the user name of simple authentication.
+	User string `json:"user,omitempty"`
+	// DelegationToken is the delegation token of secure cluster.
+	DelegationToken string `json:"delegation_token,omitempty"`
+	// Replication is the replication of blob files, zero means the default
+	// of cluster.
+	Replication int `json:"replication,omitempty"`
+	// SkipVerify skips the verification of server certificate.
+	SkipVerify bool `json:"skip_verify,omitempty"`
+}
+
+func newHDFSBackend(rawConfig []byte) (*HDFSBackend, error) {
+	cfg := &HDFSConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "parse HDFS storage backend configuration")
+	}
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("invalid HDFS configuration: missing 'endpoint'")
+	}
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("invalid HDFS configuration: missing 'dir'")
+	}
+	if !path.IsAbs(cfg.Dir) {
+		return nil, fmt.Errorf("invalid HDFS configuration: 'dir' should be an absolute path")
+	}
+	if cfg.Replication < 0 {
+		return nil, fmt.Errorf("invalid HDFS configuration: negative 'replication'")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.SkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &HDFSBackend{
+		endpoint:        strings.TrimSuffix(cfg.Endpoint, "/"),
+		dir:             path.Clean(cfg.Dir),
+		user:            cfg.User,
+		delegationToken: cfg.DelegationToken,
+		replication:     cfg.Replication,
+		client:          &http.Client{Transport: transport},
+	}, nil
+}
+
+// hdfsError is the RemoteException returned by WebHDFS.
+type hdfsError struct {
+	StatusCode    int
+	Exception     string `json:"exception"`
+	JavaClassName string `json:"javaClassName"`
+	Message       string `json:"message"`
+}
+
+func (e *hdfsError) Error() string {
+	if e.Exception == "" {
+		return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Exception, e.Message)
+}
+
+func isHDFSNotFound(err error) bool {
+	var hdfsErr *hdfsError
+	return errors.As(err, &hdfsErr) && hdfsErr.StatusCode == http.StatusNotFound
+}
+
+func hdfsResponseError(resp *http.Response) error {
+	hdfsErr := &hdfsError{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	remote := struct {
+		RemoteException *hdfsError `json:"RemoteException"`
+	}{RemoteException: hdfsErr}
+	if err := json.Unmarshal(data, &remote); err != nil || hdfsErr.Message == "" {
+		hdfsErr.Message = strings.TrimSpace(string(data))
+	}
+	return hdfsErr
+}
+
+func (b *HDFSBackend) opURL(hdfsPath, op string, params url.Values) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("op", op)
+	if b.user != "" {
+		params.Set("user.name", b.user)
+	}
+	if b.delegationToken != "" {
+		params.Set("delegation", b.delegationToken)
+	}
+	return fmt.Sprintf("%s/webhdfs/v1%s?%s", b.endpoint, (&url.URL{Path: hdfsPath}).EscapedPath(), params.Encode())
+}
+
+// do calls the operation of WebHDFS on hdfsPath, the response is returned
+// if its status code is expected.
+func (b *HDFSBackend) do(ctx context.Context, method, hdfsPath, op string, params url.Values, expected int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.opURL(hdfsPath, op, params), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "call %s", op)
+	}
+	if resp.StatusCode != expected {
+		defer resp.Body.Close()
+		return nil, errors.Wrapf(hdfsResponseError(resp), "call %s", op)
+	}
+	return resp, nil
+}
+
+func (b *HDFSBackend) doJSON(ctx context.Context, method, hdfsPath, op string, params url.Values, out interface{}) error {
+	resp, err := b.do(ctx, method, hdfsPath, op, params, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decode response of %s", op)
+}
+
+func (b *HDFSBackend) blobPath(blobID string) string {
+	return path.Join(b.dir, blobID)
+}
+
+type hdfsFileStatus struct {
+	PathSuffix       string `json:"pathSuffix"`
+	Length           int64  `json:"length"`
+	ModificationTime int64  `json:"modificationTime"`
+	Type             string `json:"type"`
+}
+
+func (b *HDFSBackend) status(ctx context.Context, blobID string) (*hdfsFileStatus, error) {
+	out := struct {
+		FileStatus hdfsFileStatus `json:"FileStatus"`
+	}{}
+	if err := b.doJSON(ctx, http.MethodGet, b.blobPath(blobID), "GETFILESTATUS", nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.FileStatus, nil
+}
+
+// create writes the blob file to hdfsPath in two steps of WebHDFS: the
+// namenode redirects the request to a datanode, then the data is sent to
+// the datanode.
+func (b *HDFSBackend) create(ctx context.Context, hdfsPath, blobPath string) error {
+	params := url.Values{"overwrite": {"true"}}
+	if b.replication > 0 {
+		params.Set("replication", strconv.Itoa(b.replication))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.opURL(hdfsPath, "CREATE", params), nil)
+	if err != nil {
+		return err
+	}
+	client := *b.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "call CREATE")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		return errors.Wrap(hdfsResponseError(resp), "call CREATE")
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("no datanode location in response of CREATE")
+	}
+
+	blobFile, err := os.Open(blobPath)
+	if err != nil {
+		return errors.Wrap(err, "open blob file")
+	}
+	defer blobFile.Close()
+	stat, err := blobFile.Stat()
+	if err != nil {
+		return errors.Wrap(err, "stat blob file")
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, location, blobFile)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = stat.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if resp, err = b.client.Do(req); err != nil {
+		return errors.Wrap(err, "write blob to datanode")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Wrap(hdfsResponseError(resp), "write blob to datanode")
+	}
+	return nil
+}
+
+func (b *HDFSBackend) rename(ctx context.Context, src, dst string) (bool, error) {
+	out := struct {
+		Boolean bool `json:"boolean"`
+	}{}
+	if err := b.doJSON(ctx, http.MethodPut, src, "RENAME", url.Values{"destination": {dst}}, &out); err != nil {
+		return false, err
+	}
+	return out.Boolean, nil
+}
+
+func (b *HDFSBackend) delete(ctx context.Context, hdfsPath string) error {
+	out := struct {
+		Boolean bool `json:"boolean"`
+	}{}
+	return b.doJSON(ctx, http.MethodDelete, hdfsPath, "DELETE", nil, &out)
+}
+
+func (b *HDFSBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "hdfs", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	desc := blobDesc(size, blobID)
+	// The credentials are excluded from the URL in image.
+	desc.URLs = append(desc.URLs, fmt.Sprintf("%s/webhdfs/v1%s?op=OPEN", b.endpoint, b.blobPath(blobID)))
+
+	if !forcePush {
+		if exist, err := b.Check(blobID); err != nil {
+			return nil, errors.Wrap(err, "check blob existence")
+		} else if exist {
+			logrus.Infof("skip upload because blob exists: %s", blobID)
+			return &desc, nil
+		}
+	}
+
+	start := time.Now()
+
+	tmpPath := b.blobPath(fmt.Sprintf("%s.uploading-%d", blobID, start.UnixNano()))
+	if err := b.create(ctx, tmpPath, blobPath); err != nil {
+		return nil, errors.Wrap(err, "upload blob to hdfs")
+	}
+	renamed, err := b.rename(ctx, tmpPath, b.blobPath(blobID))
+	if err == nil && !renamed {
+		// The blob exists, which is replaced if forcing push.
+		if err = b.delete(ctx, b.blobPath(blobID)); err == nil {
+			renamed, err = b.rename(ctx, tmpPath, b.blobPath(blobID))
+		}
+	}
+	if err == nil && !renamed {
+		err = fmt.Errorf("failed to rename %s to %s", tmpPath, b.blobPath(blobID))
+	}
+	if err != nil {
+		if err := b.delete(context.Background(), tmpPath); err != nil {
+			logrus.WithError(err).Warnf("failed to remove temporary file %s", tmpPath)
+		}
+		return nil, errors.Wrap(err, "upload blob to hdfs")
+	}
+
+	logrus.Debugf("uploaded blob %s to hdfs, costs %s", blobID, time.Since(start))
+
+	return &desc, nil
+}
+
+func (b *HDFSBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *HDFSBackend) Check(blobID string) (bool, error) {
+	if _, err := b.status(context.TODO(), blobID); err != nil {
+		if isHDFSNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *HDFSBackend) Type() Type {
+	return HdfsBackend
+}
+
+// open reads the blob from offset, the namenode redirects the request to a
+// datanode, which is followed by HTTP client. Negative length reads to the
+// end of blob.
+func (b *HDFSBackend) open(blobID string, offset, length int64) (io.ReadCloser, error) {
+	params := url.Values{}
+	if offset > 0 {
+		params.Set("offset", strconv.FormatInt(offset, 10))
+	}
+	if length >= 0 {
+		params.Set("length", strconv.FormatInt(length, 10))
+	}
+	resp, err := b.do(context.TODO(), http.MethodGet, b.blobPath(blobID), "OPEN", params, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+type hdfsRangeReader struct {
+	b      *HDFSBackend
+	blobID string
+}
+
+func (rr *hdfsRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	return rr.b.open(rr.blobID, offset, size)
+}
+
+func (b *HDFSBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &hdfsRangeReader{b: b, blobID: blobID}, nil
+}
+
+func (b *HDFSBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return b.open(blobID, 0, -1)
+}
+
+func (b *HDFSBackend) Size(blobID string) (int64, error) {
+	status, err := b.status(context.TODO(), blobID)
+	if err != nil {
+		return 0, errors.Wrap(err, "get blob size")
+	}
+	return status.Length, nil
+}
+
+func (b *HDFSBackend) List(ctx context.Context) ([]BlobObject, error) {
+	out := struct {
+		FileStatuses struct {
+			FileStatus []hdfsFileStatus `json:"FileStatus"`
+		} `json:"FileStatuses"`
+	}{}
+	if err := b.doJSON(ctx, http.MethodGet, b.dir, "LISTSTATUS", nil, &out); err != nil {
+		if isHDFSNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "list blobs")
+	}
+
+	var blobs []BlobObject
+	for _, status := range out.FileStatuses.FileStatus {
+		if status.Type != "FILE" || !isBlobID(status.PathSuffix) {
+			continue
+		}
+		blobs = append(blobs, BlobObject{
+			ID:           status.PathSuffix,
+			Size:         status.Length,
+			LastModified: time.UnixMilli(status.ModificationTime),
+		})
+	}
+	return blobs, nil
+}
+
+func (b *HDFSBackend) Delete(ctx context.Context, blobID string) error {
+	if err := b.delete(ctx, b.blobPath(blobID)); err != nil {
+		return errors.Wrap(err, "delete blob")
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebHDFSServer implements a minimal subset of WebHDFS, which serves
+// as both namenode and datanode.
+type fakeWebHDFSServer struct {
+	mutex sync.Mutex
+	files map[string][]byte
+}
+
+func (s *fakeWebHDFSServer) notFound(w http.ResponseWriter, hdfsPath string) {
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprintf(w, `{"RemoteException": {"exception": "FileNotFoundException", "message": "File does not exist: %s"}}`, hdfsPath)
+}
+
+func (s *fakeWebHDFSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/datanode") {
+		data, _ := io.ReadAll(r.Body)
+		s.files[strings.TrimPrefix(r.URL.Path, "/datanode")] = data
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	hdfsPath := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	query := r.URL.Query()
+	if query.Get("user.name") != "nydus" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch query.Get("op") {
+	case "CREATE":
+		w.Header().Set("Location", fmt.Sprintf("http://%s/datanode%s", r.Host, hdfsPath))
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	case "RENAME":
+		data, ok := s.files[hdfsPath]
+		_, exist := s.files[query.Get("destination")]
+		if ok && !exist {
+			s.files[query.Get("destination")] = data
+			delete(s.files, hdfsPath)
+		}
+		fmt.Fprintf(w, `{"boolean": %t}`, ok && !exist)
+	case "DELETE":
+		_, ok := s.files[hdfsPath]
+		delete(s.files, hdfsPath)
+		fmt.Fprintf(w, `{"boolean": %t}`, ok)
+	case "GETFILESTATUS":
+		data, ok := s.files[hdfsPath]
+		if !ok {
+			s.notFound(w, hdfsPath)
+			return
+		}
+		fmt.Fprintf(w, `{"FileStatus": {"length": %d, "modificationTime": 1700000000000, "type": "FILE"}}`, len(data))
+	case "LISTSTATUS":
+		statuses := []hdfsFileStatus{}
+		for name, data := range s.files {
+			if path.Dir(name) == hdfsPath {
+				statuses = append(statuses, hdfsFileStatus{PathSuffix: path.Base(name), Length: int64(len(data)), ModificationTime: 1700000000000, Type: "FILE"})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": statuses}})
+	case "OPEN":
+		data, ok := s.files[hdfsPath]
+		if !ok {
+			s.notFound(w, hdfsPath)
+			return
+		}
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		data = data[offset:]
+		if length := query.Get("length"); length != "" {
+			n, _ := strconv.Atoi(length)
+			data = data[:n]
+		}
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestNewHDFSBackend(t *testing.T) {
+	backend, err := newHDFSBackend([]byte(`{"endpoint": "http://namenode:9870/", "dir": "/nydus/blobs/"}`))
+	require.NoError(t, err)
+	require.Equal(t, "/nydus/blobs/111", backend.blobPath("111"))
+	require.Equal(t, "http://namenode:9870/webhdfs/v1/nydus/blobs/111?op=OPEN", backend.opURL(backend.blobPath("111"), "OPEN", nil))
+	require.Equal(t, HdfsBackend, backend.Type())
+
+	backend, err = newHDFSBackend([]byte(`{"endpoint": "http://namenode:9870"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing 'dir'")
+	require.Nil(t, backend)
+
+	backend, err = newHDFSBackend([]byte(`{"endpoint": "http://namenode:9870", "dir": "nydus"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "absolute path")
+	require.Nil(t, backend)
+}
+
+func TestHDFSUpload(t *testing.T) {
+	fake := &fakeWebHDFSServer{files: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	backend, err := newHDFSBackend([]byte(fmt.Sprintf(`{"endpoint": %q, "dir": "/nydus", "user": "nydus"}`, server.URL)))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), 1024)
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0644))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	desc, err := backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	require.Equal(t, []string{server.URL + "/webhdfs/v1/nydus/" + blobID + "?op=OPEN"}, desc.URLs)
+
+	// The existing blob is replaced by forcing push.
+	_, err = backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), true)
+	require.NoError(t, err)
+	require.Len(t, fake.files, 1)
+
+	size, err := backend.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blobData)), size)
+
+	reader, err := backend.Reader(blobID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData, data)
+
+	rangeReader, err := backend.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err = rangeReader.Reader(5, 10)
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData[5:15], data)
+
+	blobs, err := backend.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	require.Equal(t, blobID, blobs[0].ID)
+	require.Equal(t, int64(1700000000), blobs[0].LastModified.Unix())
+
+	require.NoError(t, backend.Delete(context.Background(), blobID))
+	exist, err = backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	_, err = backend.Reader(blobID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "FileNotFoundException")
+}
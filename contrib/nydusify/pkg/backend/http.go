@@ -0,0 +1,388 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+// HTTPBackend reads blobs from a static HTTP file server like nginx, and
+// uploads, lists and deletes blobs by WebDAV methods if the server supports
+// WebDAV.
+type HTTPBackend struct {
+	baseURL  *url.URL
+	username string
+	password string
+	headers  map[string]string
+	webDAV   bool
+	client   *http.Client
+}
+
+type HTTPConfig struct {
+	// URL is the base URL of blobs, the blob is at the URL followed by
+	// blob ID, e.g. `http://blobs.example.com/nydus/`.
+	URL string `json:"url"`
+	// Username and Password are the credentials of basic authentication.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Headers are added to each request, e.g. an authorization token.
+	Headers map[string]string `json:"headers,omitempty"`
+	// WebDAV enables uploading, listing and deleting blobs by WebDAV, the
+	// static HTTP server is read-only.
+	WebDAV bool `json:"webdav,omitempty"`
+	// SkipVerify skips the verification of server certificate.
+	SkipVerify bool `json:"skip_verify,omitempty"`
+}
+
+func newHTTPBackend(rawConfig []byte) (*HTTPBackend, error) {
+	cfg := &HTTPConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "parse HTTP storage backend configuration")
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("invalid HTTP configuration: missing 'url'")
+	}
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid HTTP configuration: parse 'url'")
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid HTTP configuration: unsupported scheme of 'url' %s", cfg.URL)
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.SkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &HTTPBackend{
+		baseURL:  baseURL,
+		username: cfg.Username,
+		password: cfg.Password,
+		headers:  cfg.Headers,
+		webDAV:   cfg.WebDAV,
+		client:   &http.Client{Transport: transport},
+	}, nil
+}
+
+func (b *HTTPBackend) blobURL(blobID string) string {
+	return b.baseURL.JoinPath(blobID).String()
+}
+
+func (b *HTTPBackend) newRequest(ctx context.Context, method, url string, body io.Reader, header map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if b.username != "" || b.password != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	for key, value := range b.headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+func (b *HTTPBackend) do(ctx context.Context, method, url string, body io.Reader, header map[string]string) (*http.Response, error) {
+	req, err := b.newRequest(ctx, method, url, body, header)
+	if err != nil {
+		return nil, err
+	}
+	return b.client.Do(req)
+}
+
+// Upload blob to the WebDAV server by PUT, the collections of base URL are
+// created if they don't exist.
+func (b *HTTPBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "http", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	if !b.webDAV {
+		return nil, fmt.Errorf("uploading blob requires a WebDAV server, enable 'webdav' in HTTP backend configuration")
+	}
+
+	desc := blobDesc(size, blobID)
+	desc.URLs = append(desc.URLs, b.blobURL(blobID))
+
+	if !forcePush {
+		if exist, err := b.exist(ctx, blobID); err != nil {
+			return nil, errors.Wrap(err, "check blob existence")
+		} else if exist {
+			logrus.Infof("skip upload because blob exists: %s", blobID)
+			return &desc, nil
+		}
+	}
+
+	start := time.Now()
+
+	put := func() (*http.Response, error) {
+		blobFile, err := os.Open(blobPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "open blob file")
+		}
+		defer blobFile.Close()
+		stat, err := blobFile.Stat()
+		if err != nil {
+			return nil, errors.Wrap(err, "stat blob file")
+		}
+		req, err := b.newRequest(ctx, http.MethodPut, b.blobURL(blobID), blobFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = stat.Size()
+		return b.client.Do(req)
+	}
+
+	resp, err := put()
+	if err != nil {
+		return nil, errors.Wrap(err, "upload blob to http backend")
+	}
+	if resp.StatusCode == http.StatusConflict {
+		// The parent collection doesn't exist.
+		resp.Body.Close()
+		if err := b.makeCollections(ctx); err != nil {
+			return nil, errors.Wrap(err, "create collections")
+		}
+		if resp, err = put(); err != nil {
+			return nil, errors.Wrap(err, "upload blob to http backend")
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(httpResponseError(resp), "upload blob to http backend")
+	}
+
+	logrus.Debugf("uploaded blob %s to http backend, costs %s", blobID, time.Since(start))
+
+	return &desc, nil
+}
+
+// makeCollections creates the collections of base URL from the root by
+// MKCOL, the existing collections are skipped.
+func (b *HTTPBackend) makeCollections(ctx context.Context) error {
+	collection := *b.baseURL
+	collection.Path = "/"
+	for _, name := range strings.Split(strings.Trim(b.baseURL.Path, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		collection.Path = path.Join(collection.Path, name) + "/"
+		resp, err := b.do(ctx, "MKCOL", collection.String(), nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		// The collection exists if the method isn't allowed.
+		case http.StatusCreated, http.StatusOK, http.StatusMethodNotAllowed:
+		default:
+			return errors.Wrapf(httpResponseError(resp), "create collection %s", collection.Path)
+		}
+	}
+	return nil
+}
+
+func (b *HTTPBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *HTTPBackend) head(ctx context.Context, blobID string) (*http.Response, error) {
+	resp, err := b.do(ctx, http.MethodHead, b.blobURL(blobID), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+func (b *HTTPBackend) exist(ctx context.Context, blobID string) (bool, error) {
+	resp, err := b.head(ctx, blobID)
+	if err != nil {
+		return false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, httpResponseError(resp)
+}
+
+func (b *HTTPBackend) Check(blobID string) (bool, error) {
+	return b.exist(context.TODO(), blobID)
+}
+
+func (b *HTTPBackend) Type() Type {
+	return HttpBackend
+}
+
+func (b *HTTPBackend) download(blobID, httpRange string) (io.ReadCloser, error) {
+	header := map[string]string{}
+	if httpRange != "" {
+		header["Range"] = httpRange
+	}
+	resp, err := b.do(context.TODO(), http.MethodGet, b.blobURL(blobID), nil, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK && httpRange != "" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("range request is not supported by server")
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		defer resp.Body.Close()
+		return nil, httpResponseError(resp)
+	}
+	return resp.Body, nil
+}
+
+type httpRangeReader struct {
+	b      *HTTPBackend
+	blobID string
+}
+
+func (rr *httpRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	return rr.b.download(rr.blobID, fmt.Sprintf("bytes=%d-%d", offset, offset+size-1))
+}
+
+func (b *HTTPBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &httpRangeReader{b: b, blobID: blobID}, nil
+}
+
+func (b *HTTPBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return b.download(blobID, "")
+}
+
+func (b *HTTPBackend) Size(blobID string) (int64, error) {
+	resp, err := b.head(context.TODO(), blobID)
+	if err != nil {
+		return 0, errors.Wrap(err, "get blob size")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.Wrap(httpResponseError(resp), "get blob size")
+	}
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("no content length in response")
+	}
+	return resp.ContentLength, nil
+}
+
+// davMultistatus is the response of WebDAV PROPFIND.
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ContentLength string    `xml:"getcontentlength"`
+				LastModified  string    `xml:"getlastmodified"`
+				ResourceType  *struct{} `xml:"resourcetype>collection"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const davPropfind = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><getcontentlength/><getlastmodified/><resourcetype/></prop></propfind>`
+
+// List lists the blobs under base URL by WebDAV PROPFIND.
+func (b *HTTPBackend) List(ctx context.Context) ([]BlobObject, error) {
+	if !b.webDAV {
+		return nil, fmt.Errorf("listing blobs requires a WebDAV server, enable 'webdav' in HTTP backend configuration")
+	}
+	resp, err := b.do(ctx, "PROPFIND", b.baseURL.String(), strings.NewReader(davPropfind), map[string]string{
+		"Depth":        "1",
+		"Content-Type": "application/xml",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "list blobs")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, errors.Wrap(httpResponseError(resp), "list blobs")
+	}
+
+	var status davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, errors.Wrap(err, "decode PROPFIND response")
+	}
+
+	var blobs []BlobObject
+	for _, response := range status.Responses {
+		href, err := url.PathUnescape(response.Href)
+		if err != nil {
+			continue
+		}
+		blobID := path.Base(strings.TrimSuffix(href, "/"))
+		if !isBlobID(blobID) {
+			continue
+		}
+		blob := BlobObject{ID: blobID}
+		collection := false
+		for _, propstat := range response.Propstat {
+			prop := propstat.Prop
+			if prop.ResourceType != nil {
+				collection = true
+			}
+			if prop.ContentLength != "" {
+				blob.Size, _ = strconv.ParseInt(prop.ContentLength, 10, 64)
+			}
+			if prop.LastModified != "" {
+				blob.LastModified, _ = http.ParseTime(prop.LastModified)
+			}
+		}
+		if !collection {
+			blobs = append(blobs, blob)
+		}
+	}
+	return blobs, nil
+}
+
+// Delete deletes the blob by WebDAV DELETE, the blob not found is ignored.
+func (b *HTTPBackend) Delete(ctx context.Context, blobID string) error {
+	if !b.webDAV {
+		return fmt.Errorf("deleting blob requires a WebDAV server, enable 'webdav' in HTTP backend configuration")
+	}
+	resp, err := b.do(ctx, http.MethodDelete, b.blobURL(blobID), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "delete blob")
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return errors.Wrap(httpResponseError(resp), "delete blob")
+}
+
+func httpResponseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
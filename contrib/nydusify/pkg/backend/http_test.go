@@ -0,0 +1,120 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/webdav"
+)
+
+func TestNewHTTPBackend(t *testing.T) {
+	backend, err := newHTTPBackend([]byte(`{"url": "http://localhost:8080/nydus"}`))
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/nydus/111", backend.blobURL("111"))
+	require.Equal(t, HttpBackend, backend.Type())
+
+	backend, err = newHTTPBackend([]byte(`{"webdav": true}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "missing 'url'")
+	require.Nil(t, backend)
+
+	backend, err = newHTTPBackend([]byte(`{"url": "ftp://localhost/nydus"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported scheme")
+	require.Nil(t, backend)
+}
+
+func TestHTTPStatic(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	backend, err := newHTTPBackend([]byte(fmt.Sprintf(`{"url": "%s/"}`, server.URL)))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), 1024)
+	blobPath := filepath.Join(dir, blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0644))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.True(t, exist)
+
+	rangeReader, err := backend.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err := rangeReader.Reader(5, 10)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData[5:15], data)
+
+	_, err = backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires a WebDAV server")
+}
+
+func TestHTTPWebDAV(t *testing.T) {
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	})
+	defer server.Close()
+
+	backend, err := newHTTPBackend([]byte(fmt.Sprintf(`{"url": "%s/nydus/blobs", "webdav": true}`, server.URL)))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), 1024)
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0644))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	// The collections are created on the first upload.
+	desc, err := backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	require.Equal(t, []string{server.URL + "/nydus/blobs/" + blobID}, desc.URLs)
+
+	size, err := backend.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blobData)), size)
+
+	reader, err := backend.Reader(blobID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData, data)
+
+	blobs, err := backend.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	require.Equal(t, blobID, blobs[0].ID)
+	require.Equal(t, int64(len(blobData)), blobs[0].Size)
+	require.False(t, blobs[0].LastModified.IsZero())
+
+	require.NoError(t, backend.Delete(context.Background(), blobID))
+	exist, err = backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	_, err = backend.Reader(blobID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404")
+}
@@ -0,0 +1,325 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	defaultIPFSAPI     = "http://127.0.0.1:5001"
+	defaultIPFSGateway = "http://127.0.0.1:8080"
+	defaultIPFSDir     = "/nydus"
+)
+
+// IPFSBackend stores blobs in an IPFS node by its Kubo RPC API. The blobs
+// are added and pinned to the node, and linked as the files named by blob
+// ID in a directory of MFS (Mutable File System) of the node, so that the
+// blobs can be found by blob ID, and read by nydusd from the IPFS gateway
+// by the path of directory CID followed by blob ID.
+type IPFSBackend struct {
+	api     string
+	gateway string
+	dir     string
+	client  *http.Client
+}
+
+type IPFSConfig struct {
+	// API is the address of Kubo RPC API, default `http://127.0.0.1:5001`.
+	API string `json:"api,omitempty"`
+	// Gateway is the address of IPFS gateway used by nydusd to read blobs,
+	// default `http://127.0.0.1:8080`.
+	Gateway string `json:"gateway,omitempty"`
+	// Dir is the MFS directory of blobs, default `/nydus`.
+	Dir string `json:"dir,omitempty"`
+}
+
+func newIPFSBackend(rawConfig []byte) (*IPFSBackend, error) {
+	cfg := &IPFSConfig{}
+	if len(rawConfig) > 0 {
+		if err := json.Unmarshal(rawConfig, cfg); err != nil {
+			return nil, errors.Wrap(err, "parse IPFS storage backend configuration")
+		}
+	}
+	if cfg.API == "" {
+		cfg.API = defaultIPFSAPI
+	}
+	if cfg.Gateway == "" {
+		cfg.Gateway = defaultIPFSGateway
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = defaultIPFSDir
+	}
+	if !path.IsAbs(cfg.Dir) {
+		return nil, fmt.Errorf("invalid IPFS configuration: 'dir' should be an absolute path of MFS")
+	}
+	for _, addr := range []string{cfg.API, cfg.Gateway} {
+		if u, err := url.Parse(addr); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid IPFS configuration: unsupported address %s", addr)
+		}
+	}
+
+	return &IPFSBackend{
+		api:     strings.TrimSuffix(cfg.API, "/"),
+		gateway: strings.TrimSuffix(cfg.Gateway, "/"),
+		dir:     path.Clean(cfg.Dir),
+		client:  &http.Client{},
+	}, nil
+}
+
+// ipfsError is the error returned by Kubo RPC API.
+type ipfsError struct {
+	Message string
+}
+
+func (e *ipfsError) Error() string {
+	return e.Message
+}
+
+func isIPFSNotFound(err error) bool {
+	var ipfsErr *ipfsError
+	return errors.As(err, &ipfsErr) && strings.Contains(ipfsErr.Message, "does not exist")
+}
+
+// call calls the command of Kubo RPC API with args, the response body is
+// returned if succeeded. All commands are called by POST as required by
+// the API.
+func (b *IPFSBackend) call(ctx context.Context, command string, args url.Values, body io.Reader, contentType string) (io.ReadCloser, error) {
+	reqURL := fmt.Sprintf("%s/api/v0/%s?%s", b.api, command, args.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		ipfsErr := &ipfsError{}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err := json.Unmarshal(data, ipfsErr); err != nil || ipfsErr.Message == "" {
+			ipfsErr.Message = fmt.Sprintf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		return nil, errors.Wrapf(ipfsErr, "call %s", command)
+	}
+	return resp.Body, nil
+}
+
+func (b *IPFSBackend) callJSON(ctx context.Context, command string, args url.Values, out interface{}) error {
+	body, err := b.call(ctx, command, args, nil, "")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, body)
+		return err
+	}
+	return errors.Wrapf(json.NewDecoder(body).Decode(out), "decode response of %s", command)
+}
+
+func (b *IPFSBackend) blobPath(blobID string) string {
+	return path.Join(b.dir, blobID)
+}
+
+type ipfsStat struct {
+	Hash string
+	Size int64
+	Type string
+}
+
+func (b *IPFSBackend) stat(ctx context.Context, mfsPath string) (*ipfsStat, error) {
+	stat := &ipfsStat{}
+	if err := b.callJSON(ctx, "files/stat", url.Values{"arg": {mfsPath}}, stat); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+// add adds and pins the blob file to IPFS, returns the CID of blob.
+func (b *IPFSBackend) add(ctx context.Context, blobPath string) (string, error) {
+	blobFile, err := os.Open(blobPath)
+	if err != nil {
+		return "", errors.Wrap(err, "open blob file")
+	}
+	defer blobFile.Close()
+
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+	go func() {
+		part, err := form.CreateFormFile("file", path.Base(blobPath))
+		if err == nil {
+			_, err = io.Copy(part, blobFile)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	body, err := b.call(ctx, "add", url.Values{
+		"pin":         {"true"},
+		"cid-version": {"1"},
+		"quieter":     {"true"},
+	}, reader, form.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	added := struct{ Hash string }{}
+	if err := json.NewDecoder(body).Decode(&added); err != nil {
+		return "", errors.Wrap(err, "decode response of add")
+	}
+	if added.Hash == "" {
+		return "", fmt.Errorf("no CID in response of add")
+	}
+	return added.Hash, nil
+}
+
+// Upload adds the blob to IPFS and links it in the MFS directory by blob
+// ID, the CID of blob is recorded in the annotation of blob descriptor.
+func (b *IPFSBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "ipfs", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	desc := blobDesc(size, blobID)
+	cidDesc := func(cid string) *ocispec.Descriptor {
+		desc.URLs = append(desc.URLs, "ipfs://"+cid)
+		desc.Annotations[utils.LayerAnnotationNydusIPFSCID] = cid
+		return &desc
+	}
+
+	stat, err := b.stat(ctx, b.blobPath(blobID))
+	if err != nil && !isIPFSNotFound(err) {
+		return nil, errors.Wrap(err, "check blob existence")
+	}
+	if stat != nil && !forcePush {
+		logrus.Infof("skip upload because blob exists: %s", blobID)
+		return cidDesc(stat.Hash), nil
+	}
+
+	start := time.Now()
+
+	cid, err := b.add(ctx, blobPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "add blob to IPFS")
+	}
+	if stat != nil {
+		if err := b.callJSON(ctx, "files/rm", url.Values{"arg": {b.blobPath(blobID)}}, nil); err != nil {
+			return nil, errors.Wrap(err, "remove existing blob from MFS")
+		}
+	}
+	if err := b.callJSON(ctx, "files/mkdir", url.Values{"arg": {b.dir}, "parents": {"true"}}, nil); err != nil {
+		return nil, errors.Wrap(err, "create MFS directory")
+	}
+	if err := b.callJSON(ctx, "files/cp", url.Values{"arg": {"/ipfs/" + cid, b.blobPath(blobID)}}, nil); err != nil {
+		return nil, errors.Wrap(err, "link blob in MFS")
+	}
+
+	logrus.Debugf("uploaded blob %s to IPFS as %s, costs %s", blobID, cid, time.Since(start))
+
+	return cidDesc(cid), nil
+}
+
+func (b *IPFSBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *IPFSBackend) Check(blobID string) (bool, error) {
+	if _, err := b.stat(context.TODO(), b.blobPath(blobID)); err != nil {
+		if isIPFSNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *IPFSBackend) Type() Type {
+	return IpfsBackend
+}
+
+func (b *IPFSBackend) read(blobID string, offset, count int64) (io.ReadCloser, error) {
+	args := url.Values{"arg": {b.blobPath(blobID)}}
+	if offset > 0 {
+		args.Set("offset", strconv.FormatInt(offset, 10))
+	}
+	if count >= 0 {
+		args.Set("count", strconv.FormatInt(count, 10))
+	}
+	return b.call(context.TODO(), "files/read", args, nil, "")
+}
+
+type ipfsRangeReader struct {
+	b      *IPFSBackend
+	blobID string
+}
+
+func (rr *ipfsRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	return rr.b.read(rr.blobID, offset, size)
+}
+
+func (b *IPFSBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &ipfsRangeReader{b: b, blobID: blobID}, nil
+}
+
+func (b *IPFSBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return b.read(blobID, 0, -1)
+}
+
+func (b *IPFSBackend) Size(blobID string) (int64, error) {
+	stat, err := b.stat(context.TODO(), b.blobPath(blobID))
+	if err != nil {
+		return 0, errors.Wrap(err, "get blob size")
+	}
+	return stat.Size, nil
+}
+
+// NydusdIPFSConfig resolves the MFS directory of blobs in IPFS backend to
+// its current CID, and returns the `http-proxy` backend configuration for
+// nydusd to read the blobs from IPFS gateway, because nydusd can't access
+// IPFS directly.
+func NydusdIPFSConfig(ctx context.Context, rawConfig []byte) (string, string, error) {
+	b, err := newIPFSBackend(rawConfig)
+	if err != nil {
+		return "", "", err
+	}
+	stat, err := b.stat(ctx, b.dir)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "resolve MFS directory %s", b.dir)
+	}
+	config, err := json.Marshal(map[string]string{
+		"addr": b.gateway,
+		"path": "/ipfs/" + stat.Hash,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return "http-proxy", string(config), nil
+}
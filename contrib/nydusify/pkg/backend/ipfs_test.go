@@ -0,0 +1,190 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// fakeIPFSServer implements a minimal subset of Kubo RPC API, the CID of
+// content is faked by its digest.
+type fakeIPFSServer struct {
+	mutex   sync.Mutex
+	blocks  map[string][]byte
+	files   map[string]string
+	dirs    map[string]bool
+	methods []string
+}
+
+func (s *fakeIPFSServer) fail(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]interface{}{"Message": message, "Code": 0, "Type": "error"})
+}
+
+func (s *fakeIPFSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	command := strings.TrimPrefix(r.URL.Path, "/api/v0/")
+	s.methods = append(s.methods, command)
+	args := r.URL.Query()["arg"]
+
+	switch command {
+	case "add":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			s.fail(w, err.Error())
+			return
+		}
+		data, _ := io.ReadAll(file)
+		cid := "bafy" + digest.FromBytes(data).Encoded()[:32]
+		s.blocks[cid] = data
+		json.NewEncoder(w).Encode(map[string]interface{}{"Name": "blob", "Hash": cid, "Size": strconv.Itoa(len(data))})
+	case "files/mkdir":
+		for dir := args[0]; dir != "/"; dir = path.Dir(dir) {
+			s.dirs[dir] = true
+		}
+	case "files/cp":
+		if !s.dirs[path.Dir(args[1])] {
+			s.fail(w, "file does not exist")
+			return
+		}
+		s.files[args[1]] = strings.TrimPrefix(args[0], "/ipfs/")
+	case "files/rm":
+		delete(s.files, args[0])
+	case "files/stat":
+		if s.dirs[args[0]] {
+			json.NewEncoder(w).Encode(map[string]interface{}{"Hash": "bafydir", "Type": "directory"})
+			return
+		}
+		cid, ok := s.files[args[0]]
+		if !ok {
+			s.fail(w, "file does not exist")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Hash": cid, "Size": len(s.blocks[cid]), "Type": "file"})
+	case "files/read":
+		cid, ok := s.files[args[0]]
+		if !ok {
+			s.fail(w, "file does not exist")
+			return
+		}
+		data := s.blocks[cid]
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		data = data[offset:]
+		if count := r.URL.Query().Get("count"); count != "" {
+			n, _ := strconv.Atoi(count)
+			data = data[:n]
+		}
+		w.Write(data)
+	default:
+		s.fail(w, "unknown command")
+	}
+}
+
+func TestNewIPFSBackend(t *testing.T) {
+	backend, err := newIPFSBackend([]byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, defaultIPFSAPI, backend.api)
+	require.Equal(t, "/nydus/111", backend.blobPath("111"))
+	require.Equal(t, IpfsBackend, backend.Type())
+
+	backend, err = newIPFSBackend([]byte(`{"dir": "nydus"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "absolute path")
+	require.Nil(t, backend)
+
+	backend, err = newIPFSBackend([]byte(`{"api": "/ip4/127.0.0.1/tcp/5001"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported address")
+	require.Nil(t, backend)
+}
+
+func TestIPFSUpload(t *testing.T) {
+	fake := &fakeIPFSServer{
+		blocks: map[string][]byte{},
+		files:  map[string]string{},
+		dirs:   map[string]bool{},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	config := fmt.Sprintf(`{"api": %q, "gateway": "http://gateway.local", "dir": "/nydus/blobs"}`, server.URL)
+	backend, err := newIPFSBackend([]byte(config))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), 1024)
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0644))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	desc, err := backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	cid := desc.Annotations[utils.LayerAnnotationNydusIPFSCID]
+	require.NotEmpty(t, cid)
+	require.Equal(t, []string{"ipfs://" + cid}, desc.URLs)
+
+	// The existing blob is skipped.
+	fake.methods = nil
+	desc, err = backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	require.Equal(t, cid, desc.Annotations[utils.LayerAnnotationNydusIPFSCID])
+	require.Equal(t, []string{"files/stat"}, fake.methods)
+
+	size, err := backend.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blobData)), size)
+
+	reader, err := backend.Reader(blobID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData, data)
+
+	rangeReader, err := backend.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err = rangeReader.Reader(5, 10)
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData[5:15], data)
+
+	_, err = backend.Size("not-exist")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "file does not exist")
+
+	backendType, backendConfig, err := NydusdIPFSConfig(context.Background(), []byte(config))
+	require.NoError(t, err)
+	require.Equal(t, "http-proxy", backendType)
+	require.JSONEq(t, `{"addr": "http://gateway.local", "path": "/ipfs/bafydir"}`, backendConfig)
+}
@@ -0,0 +1,285 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+const (
+	// LocalFSLayoutFlat stores the blobs at `<dir>/<blob ID>`, which is
+	// readable by the localfs backend of nydusd.
+	LocalFSLayoutFlat = "flat"
+	// LocalFSLayoutSharded stores the blobs at `<dir>/<first 2 hex of blob
+	// ID>/<blob ID>`, to avoid too many entries in a directory.
+	LocalFSLayoutSharded = "sharded"
+)
+
+// LocalFSBackend stores blobs in a local directory. The blob is written to
+// a temporary file and renamed to the blob ID, so that the partially
+// written blob is never visible. The blobs are hardlinked rather than
+// copied if possible, from the blob file to upload or the same blob in the
+// alternative directories, like the blob directories of other images.
+type LocalFSBackend struct {
+	dir     string
+	layout  string
+	altDirs []string
+}
+
+type LocalFSConfig struct {
+	// Dir is the directory of blobs.
+	Dir string `json:"dir"`
+	// Layout is the layout of blobs in directory, possible values: `flat`
+	// (default), `sharded`.
+	Layout string `json:"layout,omitempty"`
+	// AltDirs are the blob directories in the same layout, whose blobs are
+	// hardlinked into Dir instead of uploading the blob again.
+	AltDirs []string `json:"alt_dirs,omitempty"`
+}
+
+func newLocalFSBackend(rawConfig []byte) (*LocalFSBackend, error) {
+	cfg := &LocalFSConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "parse localfs storage backend configuration")
+	}
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("invalid localfs configuration: missing 'dir'")
+	}
+	switch cfg.Layout {
+	case "":
+		cfg.Layout = LocalFSLayoutFlat
+	case LocalFSLayoutFlat, LocalFSLayoutSharded:
+	default:
+		return nil, fmt.Errorf("invalid localfs configuration: unsupported 'layout' %s, possible values: '%s', '%s'",
+			cfg.Layout, LocalFSLayoutFlat, LocalFSLayoutSharded)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create blob directory")
+	}
+
+	return &LocalFSBackend{
+		dir:     cfg.Dir,
+		layout:  cfg.Layout,
+		altDirs: cfg.AltDirs,
+	}, nil
+}
+
+func (b *LocalFSBackend) blobPathIn(dir, blobID string) string {
+	if b.layout == LocalFSLayoutSharded && len(blobID) > 2 {
+		return filepath.Join(dir, blobID[:2], blobID)
+	}
+	return filepath.Join(dir, blobID)
+}
+
+func (b *LocalFSBackend) blobPath(blobID string) string {
+	return b.blobPathIn(b.dir, blobID)
+}
+
+// place links or copies src to the temporary file in the directory of dst,
+// then renames it to dst.
+func place(src, dst string) (linked bool, retErr error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return false, errors.Wrap(err, "create temporary file")
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if retErr != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// Hardlink to the temporary path, which is replaced atomically.
+	tmp.Close()
+	if err := os.Remove(tmpPath); err != nil {
+		return false, errors.Wrap(err, "remove temporary file")
+	}
+	if err := os.Link(src, tmpPath); err == nil {
+		linked = true
+	} else if err := copyBlobFile(src, tmpPath); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return false, errors.Wrap(err, "rename blob file")
+	}
+	return linked, nil
+}
+
+func copyBlobFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "open blob file")
+	}
+	defer srcFile.Close()
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "create blob file")
+	}
+	defer dstFile.Close()
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return errors.Wrap(err, "copy blob file")
+	}
+	return errors.Wrap(dstFile.Sync(), "sync blob file")
+}
+
+func (b *LocalFSBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	_, span := startUpload(ctx, "localfs", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	desc := blobDesc(size, blobID)
+
+	if !forcePush {
+		if exist, err := b.Check(blobID); err != nil {
+			return nil, errors.Wrap(err, "check blob existence")
+		} else if exist {
+			logrus.Infof("skip upload because blob exists: %s", blobID)
+			return &desc, nil
+		}
+	}
+
+	target := b.blobPath(blobID)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, errors.Wrap(err, "create blob directory")
+	}
+
+	start := time.Now()
+
+	src := blobPath
+	for _, dir := range b.altDirs {
+		altPath := b.blobPathIn(dir, blobID)
+		if _, err := os.Stat(altPath); err == nil {
+			src = altPath
+			break
+		}
+	}
+	linked, err := place(src, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "upload blob to localfs backend")
+	}
+	if linked && src == blobPath {
+		// The blob file to upload may be only readable by owner.
+		if err := os.Chmod(target, 0644); err != nil {
+			return nil, errors.Wrap(err, "change mode of blob file")
+		}
+	}
+
+	logrus.Debugf("uploaded blob %s to localfs backend (from %s, hardlinked %t), costs %s", blobID, src, linked, time.Since(start))
+
+	return &desc, nil
+}
+
+func (b *LocalFSBackend) Finalize(_ bool) error {
+	return nil
+}
+
+func (b *LocalFSBackend) Check(blobID string) (bool, error) {
+	if _, err := os.Stat(b.blobPath(blobID)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *LocalFSBackend) Type() Type {
+	return LocalfsBackend
+}
+
+type localFSRangeReader struct {
+	path string
+}
+
+func (rr *localFSRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	file, err := os.Open(rr.path)
+	if err != nil {
+		return nil, err
+	}
+	return &readCloser{io.NewSectionReader(file, offset, size), file}, nil
+}
+
+// readCloser closes the file of section reader.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (b *LocalFSBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &localFSRangeReader{path: b.blobPath(blobID)}, nil
+}
+
+func (b *LocalFSBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return os.Open(b.blobPath(blobID))
+}
+
+func (b *LocalFSBackend) Size(blobID string) (int64, error) {
+	info, err := os.Stat(b.blobPath(blobID))
+	if err != nil {
+		return 0, errors.Wrap(err, "get blob size")
+	}
+	return info.Size(), nil
+}
+
+func (b *LocalFSBackend) List(_ context.Context) ([]BlobObject, error) {
+	dirs := []string{b.dir}
+	if b.layout == LocalFSLayoutSharded {
+		entries, err := os.ReadDir(b.dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "list blob directory")
+		}
+		dirs = dirs[:0]
+		for _, entry := range entries {
+			if entry.IsDir() && len(entry.Name()) == 2 {
+				dirs = append(dirs, filepath.Join(b.dir, entry.Name()))
+			}
+		}
+	}
+
+	var blobs []BlobObject
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "list blob directory")
+		}
+		for _, entry := range entries {
+			if !entry.Type().IsRegular() || !isBlobID(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, errors.Wrap(err, "stat blob")
+			}
+			blobs = append(blobs, BlobObject{
+				ID:           entry.Name(),
+				Size:         info.Size(),
+				LastModified: info.ModTime(),
+			})
+		}
+	}
+	return blobs, nil
+}
+
+func (b *LocalFSBackend) Delete(_ context.Context, blobID string) error {
+	if err := os.Remove(b.blobPath(blobID)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "delete blob")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func inode(t *testing.T, path string) uint64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Sys().(*syscall.Stat_t).Ino
+}
+
+func TestNewLocalFSBackend(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := newLocalFSBackend([]byte(`{"dir": "` + dir + `"}`))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "abcd"), backend.blobPath("abcd"))
+	require.Equal(t, LocalfsBackend, backend.Type())
+
+	backend, err = newLocalFSBackend([]byte(`{"dir": "` + dir + `", "layout": "sharded"}`))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "ab", "abcd"), backend.blobPath("abcd"))
+
+	_, err = newLocalFSBackend([]byte(`{"layout": "sharded"}`))
+	require.ErrorContains(t, err, "missing 'dir'")
+
+	_, err = newLocalFSBackend([]byte(`{"dir": "` + dir + `", "layout": "nested"}`))
+	require.ErrorContains(t, err, "unsupported 'layout' nested")
+}
+
+func TestLocalFSUpload(t *testing.T) {
+	workDir := t.TempDir()
+	altDir := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "blobs")
+
+	backend, err := newLocalFSBackend([]byte(`{"dir": "` + dir + `", "layout": "sharded", "alt_dirs": ["` + altDir + `"]}`))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobData := bytes.Repeat([]byte("nydus"), 1024)
+	blobPath := filepath.Join(workDir, blobID)
+	require.NoError(t, os.WriteFile(blobPath, blobData, 0600))
+
+	exist, err := backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	// The blob file to upload is hardlinked.
+	_, err = backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), false)
+	require.NoError(t, err)
+	require.Equal(t, inode(t, blobPath), inode(t, filepath.Join(dir, "20", blobID)))
+	info, err := os.Stat(backend.blobPath(blobID))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	size, err := backend.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blobData)), size)
+
+	rangeReader, err := backend.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err := rangeReader.Reader(5, 10)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	require.Equal(t, blobData[5:15], data)
+
+	// The blob in alternative directory is hardlinked by forcing push.
+	altPath := filepath.Join(altDir, "20", blobID)
+	require.NoError(t, os.MkdirAll(filepath.Dir(altPath), 0755))
+	require.NoError(t, os.WriteFile(altPath, blobData, 0644))
+	_, err = backend.Upload(context.Background(), blobID, blobPath, int64(len(blobData)), true)
+	require.NoError(t, err)
+	require.Equal(t, inode(t, altPath), inode(t, backend.blobPath(blobID)))
+
+	// The temporary files are not listed.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "20", "."+blobID+".tmp-1"), nil, 0644))
+	blobs, err := backend.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	require.Equal(t, blobID, blobs[0].ID)
+	require.Equal(t, int64(len(blobData)), blobs[0].Size)
+
+	require.NoError(t, backend.Delete(context.Background(), blobID))
+	require.NoError(t, backend.Delete(context.Background(), blobID))
+	exist, err = backend.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// For multipart uploads, OSS and S3 have a maximum number of 10000
+	// parts, the part size is increased for the larger blobs.
+	maxMultipartParts = 10000
+	// maxPartSize is the maximum part size of OSS and S3.
+	maxPartSize = 5 * 1024 * 1024 * 1024 // 5GB
+
+	defaultPartSize        = 200 * 1024 * 1024 // 200MB
+	defaultPartConcurrency = 10
+	defaultPartRetries     = 3
+)
+
+// MultipartConfig tunes the multipart upload of blobs to object storage,
+// the parts of a blob are uploaded concurrently and retried separately.
+type MultipartConfig struct {
+	// PartSize is the part size in bytes, default 200MB.
+	PartSize int64 `json:"part_size,omitempty"`
+	// PartConcurrency is the count of parts of a blob uploaded
+	// concurrently, default 10.
+	PartConcurrency int `json:"part_concurrency,omitempty"`
+	// PartRetries is the count of retries of a failed part, default 3.
+	PartRetries *int `json:"part_retries,omitempty"`
+}
+
+// validate checks the configuration of backend and fills the defaults, the
+// part size should be at least minPartSize.
+func (cfg *MultipartConfig) validate(backend string, minPartSize int64) error {
+	if cfg.PartSize == 0 {
+		cfg.PartSize = defaultPartSize
+	} else if cfg.PartSize < minPartSize || cfg.PartSize > maxPartSize {
+		return fmt.Errorf("invalid %s configuration: 'part_size' should be between %d and %d", backend, minPartSize, int64(maxPartSize))
+	}
+	if cfg.PartConcurrency == 0 {
+		cfg.PartConcurrency = defaultPartConcurrency
+	} else if cfg.PartConcurrency < 0 {
+		return fmt.Errorf("invalid %s configuration: negative 'part_concurrency'", backend)
+	}
+	if cfg.PartRetries == nil {
+		retries := defaultPartRetries
+		cfg.PartRetries = &retries
+	} else if *cfg.PartRetries < 0 {
+		return fmt.Errorf("invalid %s configuration: negative 'part_retries'", backend)
+	}
+	return nil
+}
+
+// partSize returns the part size to upload the blob of size, which is
+// increased if the blob has too many parts.
+func (cfg *MultipartConfig) partSize(size int64) int64 {
+	partSize := cfg.PartSize
+	if minSize := (size + maxMultipartParts - 1) / maxMultipartParts; partSize < minSize {
+		partSize = minSize
+	}
+	return partSize
+}
+
+// blobFileSize returns the size of blob file, the size to upload may be
+// unknown by callers.
+func blobFileSize(blobPath string, size int64) (int64, error) {
+	if size > 0 {
+		return size, nil
+	}
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "stat blob file")
+	}
+	return info.Size(), nil
+}
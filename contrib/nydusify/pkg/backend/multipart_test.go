@@ -0,0 +1,32 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartConfig(t *testing.T) {
+	cfg := MultipartConfig{}
+	require.NoError(t, cfg.validate("OSS", minOSSPartSize))
+	require.Equal(t, int64(defaultPartSize), cfg.PartSize)
+	require.Equal(t, defaultPartConcurrency, cfg.PartConcurrency)
+	require.Equal(t, defaultPartRetries, *cfg.PartRetries)
+
+	// The part size is increased if the blob has too many parts.
+	require.Equal(t, int64(defaultPartSize), cfg.partSize(1024))
+	require.Equal(t, int64(defaultPartSize), cfg.partSize(maxMultipartParts*defaultPartSize))
+	require.Equal(t, int64(defaultPartSize+1), cfg.partSize(maxMultipartParts*defaultPartSize+1))
+
+	retries := 0
+	cfg = MultipartConfig{PartSize: minOSSPartSize, PartRetries: &retries}
+	require.NoError(t, cfg.validate("OSS", minOSSPartSize))
+	require.Equal(t, 0, *cfg.PartRetries)
+
+	cfg = MultipartConfig{PartSize: maxPartSize + 1}
+	require.ErrorContains(t, cfg.validate("OSS", minOSSPartSize), "invalid OSS configuration: 'part_size' should be between")
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrObjectNotFound is returned if the object doesn't exist.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrPreconditionFailed is returned if the object is created or
+	// modified by others since it's read.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// ObjectStore reads and writes the objects by the full keys in a bucket,
+// the writes are conditional for the optimistic concurrency among the
+// clients sharing the bucket.
+type ObjectStore interface {
+	// GetObject returns the reader and ETag of object.
+	GetObject(ctx context.Context, key string) (io.ReadCloser, string, error)
+	// PutObject writes the object if its ETag is etag, or if it doesn't
+	// exist when etag is empty, otherwise ErrPreconditionFailed is returned.
+	PutObject(ctx context.Context, key string, reader io.Reader, size int64, etag string) error
+	// ExistObject checks if the object exists.
+	ExistObject(ctx context.Context, key string) (bool, error)
+	// ListObjects returns the keys of objects with the prefix.
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	// DeleteObject deletes the object, it's not an error if the object
+	// doesn't exist.
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// NewObjectStore creates the object store of bucket in storage backend, the
+// config is in the same format of storage backend, e.g. the endpoint and
+// credentials, but the bucket name and object prefix are ignored.
+func NewObjectStore(bt, bucket string, config []byte) (ObjectStore, error) {
+	cfg := map[string]interface{}{}
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, errors.Wrap(err, "parse storage backend configuration")
+		}
+	}
+	cfg["bucket_name"] = bucket
+	delete(cfg, "object_prefix")
+	config, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch bt {
+	case "oss":
+		return newOSSBackend(config)
+	case "s3":
+		return newS3Backend(config)
+	default:
+		return nil, fmt.Errorf("unsupported object store type %s", bt)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,14 +23,14 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
-)
 
-const (
-	// For multipart uploads, OSS has a maximum number of 10000 chunks,
-	// so we can only upload blob size of about 10000 * multipartChunkSize.
-	multipartChunkSize = 200 * 1024 * 1024 /// 200MB
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
+// minOSSPartSize is the minimum part size of OSS multipart upload.
+const minOSSPartSize = 100 * 1024 // 100KB
+
 type multipartStatus struct {
 	imur          *oss.InitiateMultipartUploadResult
 	parts         []oss.UploadPart
@@ -43,41 +44,149 @@ type OSSBackend struct {
 	// to make it a path-like object.
 	objectPrefix string
 	bucket       *oss.Bucket
+	multipart    MultipartConfig
 	ms           []multipartStatus
 	msMutex      sync.Mutex
 }
 
-func newOSSBackend(rawConfig []byte) (*OSSBackend, error) {
-	var configMap map[string]string
-	if err := json.Unmarshal(rawConfig, &configMap); err != nil {
-		return nil, errors.Wrap(err, "Parse OSS storage backend configuration")
+type OSSConfig struct {
+	Endpoint   string `json:"endpoint"`
+	BucketName string `json:"bucket_name"`
+
+	// Below items are not mandatory
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	AccessKeySecret string `json:"access_key_secret,omitempty"`
+	ObjectPrefix    string `json:"object_prefix,omitempty"`
+	// SecurityToken is the STS token of temporary access key.
+	SecurityToken string `json:"security_token,omitempty"`
+
+	// RoleARN is the RAM role assumed by STS with the access key, the
+	// temporary credentials are refreshed before expiration.
+	RoleARN         string `json:"role_arn,omitempty"`
+	RoleSessionName string `json:"role_session_name,omitempty"`
+	ExternalID      string `json:"external_id,omitempty"`
+	// STSEndpoint is the endpoint URL of STS, default
+	// `https://sts.aliyuncs.com`.
+	STSEndpoint string `json:"sts_endpoint,omitempty"`
+	// InstanceRole is the RAM role attached to ECS instance, whose
+	// credentials are got from metadata service and refreshed before
+	// expiration.
+	InstanceRole string `json:"instance_role,omitempty"`
+
+	// Accelerate accesses the bucket by the transfer acceleration endpoint,
+	// which should be enabled for the bucket.
+	Accelerate bool `json:"accelerate,omitempty"`
+	// AccelerateEndpoint is the transfer acceleration endpoint, default
+	// `oss-accelerate.aliyuncs.com`, or `oss-accelerate-overseas.aliyuncs.com`
+	// for the regions outside Chinese mainland.
+	AccelerateEndpoint string `json:"accelerate_endpoint,omitempty"`
+
+	MultipartConfig
+}
+
+const defaultOSSAccelerateEndpoint = "oss-accelerate.aliyuncs.com"
+
+func validateOSSConfig(cfg *OSSConfig) error {
+	if cfg.Endpoint == "" || cfg.BucketName == "" {
+		return fmt.Errorf("invalid OSS configuration: missing 'endpoint' or 'bucket'")
+	}
+	if cfg.SecurityToken != "" && cfg.AccessKeyID == "" {
+		return fmt.Errorf("invalid OSS configuration: 'security_token' requires 'access_key_id' and 'access_key_secret'")
 	}
+	if cfg.RoleARN != "" {
+		if cfg.AccessKeyID == "" || cfg.AccessKeySecret == "" {
+			return fmt.Errorf("invalid OSS configuration: 'role_arn' requires 'access_key_id' and 'access_key_secret' to assume role")
+		}
+		if cfg.InstanceRole != "" {
+			return fmt.Errorf("invalid OSS configuration: 'role_arn' conflicts with 'instance_role'")
+		}
+	} else {
+		for _, field := range [][2]string{
+			{"role_session_name", cfg.RoleSessionName},
+			{"external_id", cfg.ExternalID},
+			{"sts_endpoint", cfg.STSEndpoint},
+		} {
+			if field[1] != "" {
+				return fmt.Errorf("invalid OSS configuration: '%s' requires 'role_arn'", field[0])
+			}
+		}
+	}
+	if cfg.InstanceRole != "" && cfg.AccessKeyID != "" {
+		return fmt.Errorf("invalid OSS configuration: 'instance_role' conflicts with 'access_key_id'")
+	}
+	if cfg.AccelerateEndpoint != "" && !cfg.Accelerate {
+		return fmt.Errorf("invalid OSS configuration: 'accelerate_endpoint' requires 'accelerate'")
+	}
+	return cfg.MultipartConfig.validate("OSS", minOSSPartSize)
+}
 
-	endpoint := configMap["endpoint"]
-	bucketName := configMap["bucket_name"]
+// ossEndpoint returns the endpoint to access, the scheme of endpoint is kept
+// for transfer acceleration.
+func ossEndpoint(cfg *OSSConfig) string {
+	if !cfg.Accelerate {
+		return cfg.Endpoint
+	}
+	endpoint := cfg.AccelerateEndpoint
+	if endpoint == "" {
+		endpoint = defaultOSSAccelerateEndpoint
+	}
+	if !strings.Contains(endpoint, "://") {
+		if scheme, _, ok := strings.Cut(cfg.Endpoint, "://"); ok {
+			endpoint = scheme + "://" + endpoint
+		}
+	}
+	return endpoint
+}
 
-	// Below items are not mandatory
-	accessKeyID := configMap["access_key_id"]
-	accessKeySecret := configMap["access_key_secret"]
-	objectPrefix := configMap["object_prefix"]
+func newOSSBackend(rawConfig []byte) (*OSSBackend, error) {
+	cfg := &OSSConfig{}
+	if err := json.Unmarshal(rawConfig, cfg); err != nil {
+		return nil, errors.Wrap(err, "Parse OSS storage backend configuration")
+	}
+	if err := validateOSSConfig(cfg); err != nil {
+		return nil, err
+	}
 
-	if endpoint == "" || bucketName == "" {
-		return nil, fmt.Errorf("invalid OSS configuration: missing 'endpoint' or 'bucket'")
+	options := []oss.ClientOption{
+		// Verify the CRC64 of each part and object uploaded.
+		oss.EnableCRC(true),
+	}
+	switch {
+	case cfg.RoleARN != "":
+		stsEndpoint := cfg.STSEndpoint
+		if stsEndpoint == "" {
+			stsEndpoint = defaultOSSSTSEndpoint
+		}
+		sessionName := cfg.RoleSessionName
+		if sessionName == "" {
+			sessionName = fmt.Sprintf("nydusify-%d", time.Now().Unix())
+		}
+		options = append(options, oss.SetCredentialsProvider(newOSSCredentialsProvider(ossAssumeRole(
+			&http.Client{}, strings.TrimSuffix(stsEndpoint, "/"), cfg.AccessKeyID, cfg.AccessKeySecret, cfg.SecurityToken,
+			cfg.RoleARN, sessionName, cfg.ExternalID,
+		))))
+	case cfg.InstanceRole != "":
+		options = append(options, oss.SetCredentialsProvider(newOSSCredentialsProvider(ossInstanceRole(
+			&http.Client{}, ossMetadataEndpoint, cfg.InstanceRole,
+		))))
+	case cfg.SecurityToken != "":
+		options = append(options, oss.SecurityToken(cfg.SecurityToken))
 	}
 
-	client, err := oss.New(endpoint, accessKeyID, accessKeySecret)
+	client, err := oss.New(ossEndpoint(cfg), cfg.AccessKeyID, cfg.AccessKeySecret, options...)
 	if err != nil {
 		return nil, errors.Wrap(err, "Create client")
 	}
 
-	bucket, err := client.Bucket(bucketName)
+	bucket, err := client.Bucket(cfg.BucketName)
 	if err != nil {
 		return nil, errors.Wrap(err, "Create bucket")
 	}
 
 	return &OSSBackend{
-		objectPrefix: objectPrefix,
+		objectPrefix: cfg.ObjectPrefix,
 		bucket:       bucket,
+		multipart:    cfg.MultipartConfig,
 	}, nil
 }
 
@@ -110,7 +219,10 @@ func calcCrc64ECMA(path string) (uint64, error) {
 
 // Upload blob as image layer to oss backend and verify
 // integrity by calculate CRC64.
-func (b *OSSBackend) Upload(_ context.Context, blobID, blobPath string, size int64, forcePush bool) (*ocispec.Descriptor, error) {
+func (b *OSSBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	_, span := startUpload(ctx, "oss", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
 	blobObjectKey := b.objectPrefix + blobID
 
 	desc := blobDesc(size, blobID)
@@ -138,8 +250,13 @@ func (b *OSSBackend) Upload(_ context.Context, blobID, blobPath string, size int
 		crc64ErrChan <- e
 	}()
 
-	logrus.Debugf("upload %s using multipart method", blobObjectKey)
-	chunks, err := oss.SplitFileByPartSize(blobPath, multipartChunkSize)
+	fileSize, err := blobFileSize(blobPath, size)
+	if err != nil {
+		return nil, err
+	}
+	partSize := b.multipart.partSize(fileSize)
+	logrus.Debugf("upload %s using multipart method, part size %d", blobObjectKey, partSize)
+	chunks, err := oss.SplitFileByPartSize(blobPath, partSize)
 	if err != nil {
 		return nil, errors.Wrap(err, "split file by part size")
 	}
@@ -150,16 +267,20 @@ func (b *OSSBackend) Upload(_ context.Context, blobID, blobPath string, size int
 	}
 
 	eg := new(errgroup.Group)
+	eg.SetLimit(b.multipart.PartConcurrency)
 	partsChan := make(chan oss.UploadPart, len(chunks))
 	for _, chunk := range chunks {
 		ck := chunk
 		eg.Go(func() error {
-			p, err := b.bucket.UploadPartFromFile(imur, blobPath, ck.Offset, ck.Size, ck.Number)
-			if err != nil {
-				return errors.Wrap(err, "upload part from file")
-			}
-			partsChan <- p
-			return nil
+			// Retry the failed part only rather than the whole blob.
+			return utils.RetryWithAttempts(func() error {
+				p, err := b.bucket.UploadPartFromFile(imur, blobPath, ck.Offset, ck.Size, ck.Number)
+				if err != nil {
+					return errors.Wrapf(err, "upload part %d from file", ck.Number)
+				}
+				partsChan <- p
+				return nil
+			}, *b.multipart.PartRetries+1)
 		})
 	}
 
@@ -294,6 +415,99 @@ func (b *OSSBackend) Size(blobID string) (int64, error) {
 	return size, nil
 }
 
+func (b *OSSBackend) List(_ context.Context) ([]BlobObject, error) {
+	var blobs []BlobObject
+	options := []oss.Option{oss.Prefix(b.objectPrefix)}
+	for {
+		result, err := b.bucket.ListObjectsV2(options...)
+		if err != nil {
+			return nil, errors.Wrap(err, "list objects")
+		}
+		for _, object := range result.Objects {
+			blobID := strings.TrimPrefix(object.Key, b.objectPrefix)
+			if !isBlobID(blobID) {
+				continue
+			}
+			blobs = append(blobs, BlobObject{
+				ID:           blobID,
+				Size:         object.Size,
+				LastModified: object.LastModified,
+			})
+		}
+		if !result.IsTruncated {
+			return blobs, nil
+		}
+		options = []oss.Option{oss.Prefix(b.objectPrefix), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
+func (b *OSSBackend) Delete(_ context.Context, blobID string) error {
+	return b.bucket.DeleteObject(b.objectPrefix + blobID)
+}
+
 func (b *OSSBackend) remoteID(blobID string) string {
 	return fmt.Sprintf("oss://%s/%s%s", b.bucket.BucketName, b.objectPrefix, blobID)
 }
+
+func ossStatusCode(err error) int {
+	var serviceError oss.ServiceError
+	if errors.As(err, &serviceError) {
+		return serviceError.StatusCode
+	}
+	return 0
+}
+
+func (b *OSSBackend) GetObject(_ context.Context, key string) (io.ReadCloser, string, error) {
+	var header http.Header
+	reader, err := b.bucket.GetObject(key, oss.GetResponseHeader(&header))
+	if err != nil {
+		if ossStatusCode(err) == http.StatusNotFound {
+			return nil, "", ErrObjectNotFound
+		}
+		return nil, "", errors.Wrapf(err, "get object %s", key)
+	}
+	return reader, header.Get("ETag"), nil
+}
+
+// PutObject writes the object if it's not modified, or forbids overwriting
+// the existing object if etag is empty.
+func (b *OSSBackend) PutObject(_ context.Context, key string, reader io.Reader, size int64, etag string) error {
+	options := []oss.Option{oss.ContentLength(size), oss.ForbidOverWrite(true)}
+	if etag != "" {
+		options = []oss.Option{oss.ContentLength(size), oss.IfMatch(etag)}
+	}
+	if err := b.bucket.PutObject(key, reader, options...); err != nil {
+		// 409 is returned if the object exists and overwriting is forbidden.
+		if code := ossStatusCode(err); code == http.StatusPreconditionFailed || code == http.StatusConflict {
+			return ErrPreconditionFailed
+		}
+		return errors.Wrapf(err, "put object %s", key)
+	}
+	return nil
+}
+
+func (b *OSSBackend) ExistObject(_ context.Context, key string) (bool, error) {
+	return b.bucket.IsObjectExist(key)
+}
+
+func (b *OSSBackend) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	options := []oss.Option{oss.Prefix(prefix)}
+	for {
+		result, err := b.bucket.ListObjectsV2(options...)
+		if err != nil {
+			return nil, errors.Wrap(err, "list objects")
+		}
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		if !result.IsTruncated {
+			return keys, nil
+		}
+		options = []oss.Option{oss.Prefix(prefix), oss.ContinuationToken(result.NextContinuationToken)}
+	}
+}
+
+func (b *OSSBackend) DeleteObject(_ context.Context, key string) error {
+	return errors.Wrapf(b.bucket.DeleteObject(key), "delete object %s", key)
+}
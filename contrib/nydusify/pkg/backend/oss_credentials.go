@@ -0,0 +1,231 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultOSSSTSEndpoint = "https://sts.aliyuncs.com"
+	// ossMetadataEndpoint is the metadata service of ECS instance.
+	ossMetadataEndpoint = "http://100.100.100.200"
+	// ossCredentialsRefreshWindow is the time before expiration to refresh
+	// the temporary credentials, so that the credentials don't expire in
+	// the middle of request.
+	ossCredentialsRefreshWindow = 5 * time.Minute
+	ossRoleSessionDuration      = time.Hour
+)
+
+// ossCredentials is the temporary credentials of STS, which is also the
+// format of ECS metadata service.
+type ossCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	AccessKeySecret string    `json:"AccessKeySecret"`
+	SecurityToken   string    `json:"SecurityToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func (c *ossCredentials) GetAccessKeyID() string {
+	return c.AccessKeyID
+}
+
+func (c *ossCredentials) GetAccessKeySecret() string {
+	return c.AccessKeySecret
+}
+
+func (c *ossCredentials) GetSecurityToken() string {
+	return c.SecurityToken
+}
+
+// ossCredentialsProvider provides the temporary credentials fetched by
+// fetch, which are refreshed before expiration, so that the long running
+// uploads don't fail with expired token.
+type ossCredentialsProvider struct {
+	mutex       sync.Mutex
+	fetch       func(ctx context.Context) (*ossCredentials, error)
+	credentials *ossCredentials
+	now         func() time.Time
+}
+
+func newOSSCredentialsProvider(fetch func(ctx context.Context) (*ossCredentials, error)) *ossCredentialsProvider {
+	return &ossCredentialsProvider{fetch: fetch, now: time.Now}
+}
+
+// GetCredentialsE is preferred by OSS SDK, which fails the request if the
+// credentials can't be refreshed.
+func (p *ossCredentialsProvider) GetCredentialsE() (oss.Credentials, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.credentials != nil && p.now().Add(ossCredentialsRefreshWindow).Before(p.credentials.Expiration) {
+		return p.credentials, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	credentials, err := p.fetch(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "refresh OSS credentials")
+	}
+	logrus.Debugf("refreshed OSS credentials, expires at %s", credentials.Expiration)
+	p.credentials = credentials
+	return credentials, nil
+}
+
+func (p *ossCredentialsProvider) GetCredentials() oss.Credentials {
+	credentials, err := p.GetCredentialsE()
+	if err != nil {
+		logrus.WithError(err).Warn("failed to get OSS credentials")
+		return &ossCredentials{}
+	}
+	return credentials
+}
+
+// percentEncode encodes the value of Alibaba Cloud RPC API.
+func percentEncode(value string) string {
+	value = url.QueryEscape(value)
+	value = strings.ReplaceAll(value, "+", "%20")
+	value = strings.ReplaceAll(value, "*", "%2A")
+	return strings.ReplaceAll(value, "%7E", "~")
+}
+
+// signRPC signs the query of Alibaba Cloud RPC API by HMAC-SHA1, see
+// https://www.alibabacloud.com/help/en/sdk/product-overview/rpc-mechanism.
+func signRPC(method string, query url.Values, accessKeySecret string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, percentEncode(key)+"="+percentEncode(query.Get(key)))
+	}
+	stringToSign := method + "&" + percentEncode("/") + "&" + percentEncode(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(accessKeySecret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ossAssumeRole returns the function fetching the credentials of role by
+// STS AssumeRole with the source access key.
+func ossAssumeRole(client *http.Client, endpoint, accessKeyID, accessKeySecret, securityToken, roleARN, sessionName, externalID string) func(ctx context.Context) (*ossCredentials, error) {
+	return func(ctx context.Context) (*ossCredentials, error) {
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		query := url.Values{
+			"Action":           {"AssumeRole"},
+			"Version":          {"2015-04-01"},
+			"Format":           {"JSON"},
+			"RoleArn":          {roleARN},
+			"RoleSessionName":  {sessionName},
+			"DurationSeconds":  {strconv.Itoa(int(ossRoleSessionDuration.Seconds()))},
+			"AccessKeyId":      {accessKeyID},
+			"SignatureMethod":  {"HMAC-SHA1"},
+			"SignatureVersion": {"1.0"},
+			"SignatureNonce":   {hex.EncodeToString(nonce)},
+			"Timestamp":        {time.Now().UTC().Format("2006-01-02T15:04:05Z")},
+		}
+		if externalID != "" {
+			query.Set("ExternalId", externalID)
+		}
+		if securityToken != "" {
+			query.Set("SecurityToken", securityToken)
+		}
+		query.Set("Signature", signRPC(http.MethodGet, query, accessKeySecret))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "assume role")
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, errors.Wrap(err, "read response of assume role")
+		}
+		result := struct {
+			Code        string          `json:"Code"`
+			Message     string          `json:"Message"`
+			Credentials *ossCredentials `json:"Credentials"`
+		}{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, errors.Wrapf(err, "decode response of assume role with status code %d", resp.StatusCode)
+		}
+		if resp.StatusCode != http.StatusOK || result.Credentials == nil {
+			return nil, fmt.Errorf("assume role %s: %s: %s", roleARN, result.Code, result.Message)
+		}
+		return result.Credentials, nil
+	}
+}
+
+// ossInstanceRole returns the function fetching the credentials of RAM
+// role attached to ECS instance from metadata service, the token of
+// metadata service is used if it's in hardened mode.
+func ossInstanceRole(client *http.Client, endpoint, role string) func(ctx context.Context) (*ossCredentials, error) {
+	return func(ctx context.Context) (*ossCredentials, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/latest/meta-data/ram/security-credentials/%s", endpoint, url.PathEscape(role)), nil)
+		if err != nil {
+			return nil, err
+		}
+		tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+		if err != nil {
+			return nil, err
+		}
+		tokenReq.Header.Set("X-aliyun-ecs-metadata-token-ttl-seconds", "21600")
+		if resp, err := client.Do(tokenReq); err == nil {
+			token, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				req.Header.Set("X-aliyun-ecs-metadata-token", string(token))
+			}
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "get credentials of instance role")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return nil, fmt.Errorf("get credentials of instance role %s: unexpected status code %d: %s", role, resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		result := struct {
+			Code string `json:"Code"`
+			ossCredentials
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, errors.Wrap(err, "decode credentials of instance role")
+		}
+		if result.Code != "Success" {
+			return nil, fmt.Errorf("get credentials of instance role %s: %s", role, result.Code)
+		}
+		return &result.ossCredentials, nil
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRPC(t *testing.T) {
+	query := url.Values{
+		"Action":  {"AssumeRole"},
+		"RoleArn": {"acs:ram::123:role/nydus"},
+		"Comment": {"a b*c~"},
+	}
+	// The string to sign is `GET&%2F&Action%3DAssumeRole%26Comment%3Da%2520b%252Ac~%26RoleArn%3Dacs%253Aram%253A%253A123%253Arole%252Fnydus`.
+	require.Equal(t, "KZqYC+LRDl9TzET5h5XePRHTPwQ=", signRPC(http.MethodGet, query, "testSK"))
+}
+
+func TestOSSAssumeRole(t *testing.T) {
+	requests := 0
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		query := r.URL.Query()
+		signature := query.Get("Signature")
+		query.Del("Signature")
+		if signature != signRPC(http.MethodGet, query, "testSK") || query.Get("AccessKeyId") != "testAK" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"Code": "SignatureDoesNotMatch", "Message": "Specified signature is not matched"}`)
+			return
+		}
+		require.Equal(t, "acs:ram::123:role/nydus", query.Get("RoleArn"))
+		require.Equal(t, "external", query.Get("ExternalId"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Credentials": map[string]string{
+				"AccessKeyId":     fmt.Sprintf("STS.AK%d", requests),
+				"AccessKeySecret": "STS.SK",
+				"SecurityToken":   "token",
+				"Expiration":      expiration.Format(time.RFC3339),
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider := newOSSCredentialsProvider(ossAssumeRole(server.Client(), server.URL, "testAK", "testSK", "", "acs:ram::123:role/nydus", "nydusify", "external"))
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	credentials, err := provider.GetCredentialsE()
+	require.NoError(t, err)
+	require.Equal(t, "STS.AK1", credentials.GetAccessKeyID())
+	require.Equal(t, "token", credentials.GetSecurityToken())
+	require.Equal(t, expiration, credentials.(*ossCredentials).Expiration)
+
+	// The credentials are cached until they are about to expire.
+	credentials, err = provider.GetCredentialsE()
+	require.NoError(t, err)
+	require.Equal(t, "STS.AK1", credentials.GetAccessKeyID())
+	now = expiration.Add(-time.Minute)
+	credentials, err = provider.GetCredentialsE()
+	require.NoError(t, err)
+	require.Equal(t, "STS.AK2", credentials.GetAccessKeyID())
+
+	provider = newOSSCredentialsProvider(ossAssumeRole(server.Client(), server.URL, "testAK", "wrongSK", "", "acs:ram::123:role/nydus", "nydusify", "external"))
+	_, err = provider.GetCredentialsE()
+	require.ErrorContains(t, err, "SignatureDoesNotMatch")
+	require.Empty(t, provider.GetCredentials().GetAccessKeyID())
+}
+
+func TestOSSInstanceRole(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			fmt.Fprint(w, "metadata-token")
+		case r.URL.Path == "/latest/meta-data/ram/security-credentials/nydus" && r.Header.Get("X-aliyun-ecs-metadata-token") == "metadata-token":
+			fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "STS.AK", "AccessKeySecret": "STS.SK", "SecurityToken": "token", "Expiration": %q}`,
+				time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	credentials, err := ossInstanceRole(server.Client(), server.URL, "nydus")(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "STS.AK", credentials.AccessKeyID)
+	require.Equal(t, "token", credentials.SecurityToken)
+
+	_, err = ossInstanceRole(server.Client(), server.URL, "other")(context.Background())
+	require.ErrorContains(t, err, "unexpected status code 404")
+}
@@ -5,10 +5,18 @@
 package backend
 
 import (
+	"context"
 	"encoding/json"
 	"hash/crc64"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -135,3 +143,106 @@ func TestNewOSSBackend(t *testing.T) {
 	require.Contains(t, err.Error(), "Parse OSS storage backend configuration")
 	require.Nil(t, backend)
 }
+
+func TestNewOSSBackendOptions(t *testing.T) {
+	backend, err := newOSSBackend([]byte(`
+	{
+		"bucket_name": "test",
+		"endpoint": "https://oss-cn-hangzhou.aliyuncs.com",
+		"access_key_id": "testAK",
+		"access_key_secret": "testSK",
+		"security_token": "token",
+		"accelerate": true
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, "https://oss-accelerate.aliyuncs.com", backend.bucket.Client.Config.Endpoint)
+	require.Equal(t, "token", backend.bucket.Client.Config.SecurityToken)
+	require.True(t, backend.bucket.Client.Config.IsEnableCRC)
+
+	backend, err = newOSSBackend([]byte(`
+	{
+		"bucket_name": "test",
+		"endpoint": "oss-cn-hangzhou.aliyuncs.com",
+		"access_key_id": "testAK",
+		"access_key_secret": "testSK",
+		"role_arn": "acs:ram::123:role/nydus"
+	}`))
+	require.NoError(t, err)
+	require.IsType(t, &ossCredentialsProvider{}, backend.bucket.Client.Config.CredentialsProvider)
+
+	for _, tc := range []struct {
+		config string
+		err    string
+	}{
+		{`{"security_token": "token"}`, "'security_token' requires 'access_key_id' and 'access_key_secret'"},
+		{`{"role_arn": "acs:ram::123:role/nydus"}`, "'role_arn' requires 'access_key_id' and 'access_key_secret'"},
+		{`{"external_id": "external"}`, "'external_id' requires 'role_arn'"},
+		{`{"instance_role": "nydus", "access_key_id": "testAK", "access_key_secret": "testSK"}`, "'instance_role' conflicts with 'access_key_id'"},
+		{`{"accelerate_endpoint": "oss-accelerate-overseas.aliyuncs.com"}`, "'accelerate_endpoint' requires 'accelerate'"},
+	} {
+		cfg := &OSSConfig{Endpoint: "oss-cn-hangzhou.aliyuncs.com", BucketName: "test"}
+		require.NoError(t, json.Unmarshal([]byte(tc.config), cfg))
+		require.ErrorContains(t, validateOSSConfig(cfg), tc.err, tc.config)
+	}
+}
+
+func TestOSSUploadParts(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	var mutex sync.Mutex
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			w.Write([]byte(`<InitiateMultipartUploadResult><Bucket>test</Bucket><Key>blob</Key><UploadId>upload</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload":
+			current := inflight.Add(1)
+			defer inflight.Add(-1)
+			for {
+				observed := maxInflight.Load()
+				if current <= observed || maxInflight.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			part := query.Get("partNumber")
+			mutex.Lock()
+			attempts[part]++
+			attempt := attempts[part]
+			mutex.Unlock()
+			// The first attempt of part 3 fails.
+			if part == "3" && attempt == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("ETag", `"etag-`+part+`"`)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := newOSSBackend([]byte(`
+	{
+		"bucket_name": "test",
+		"endpoint": "` + server.URL + `",
+		"access_key_id": "testAK",
+		"access_key_secret": "testSK",
+		"part_size": 102400,
+		"part_concurrency": 2,
+		"part_retries": 1
+	}`))
+	require.NoError(t, err)
+
+	blobID := "205eed24cbec29ad9cb4593a73168ef1803402370a82f7d51ce25646fc2f943a"
+	blobPath := filepath.Join(t.TempDir(), blobID)
+	require.NoError(t, os.WriteFile(blobPath, []byte(strings.Repeat("n", 5*102400)), 0644))
+	_, err = backend.Upload(context.Background(), blobID, blobPath, 0, true)
+	require.NoError(t, err)
+
+	require.Len(t, backend.ms, 1)
+	require.Len(t, backend.ms[0].parts, 5)
+	require.Equal(t, int32(2), maxInflight.Load())
+	require.Equal(t, map[string]int{"1": 1, "2": 1, "3": 2, "4": 1, "5": 1}, attempts)
+}
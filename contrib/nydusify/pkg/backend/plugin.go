@@ -0,0 +1,354 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/rpc"
+	"os"
+	"os/exec"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+// pluginReadSize is the size of data read from backend plugin at once.
+const pluginReadSize = 4 << 20
+
+var pluginHandshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "NYDUS_BACKEND_PLUGIN",
+	MagicCookieValue: "nydus-backend-plugin",
+}
+
+// Plugin is implemented by the external backend plugins to store blobs in
+// the storage not supported by nydusify, like the object storage with
+// custom authentication or internal blob stores. The plugin is a binary
+// serving the implementation by ServePlugin, which is started by nydusify
+// for the backend type "external".
+type Plugin interface {
+	// Init initializes the plugin with the backend configuration.
+	Init(config []byte) error
+	// Upload uploads the blob file at blobPath, the blob existing in
+	// backend is overwritten only if forcePush is true.
+	Upload(blobID, blobPath string, size int64, forcePush bool) error
+	Check(blobID string) (bool, error)
+	Size(blobID string) (int64, error)
+	// ReadAt reads at most length bytes of blob from offset, less bytes
+	// are returned only at the end of blob.
+	ReadAt(blobID string, offset, length int64) ([]byte, error)
+	// List and Delete are used to collect the orphaned blobs, the plugin
+	// may return an error if it's not supported.
+	List() ([]BlobObject, error)
+	Delete(blobID string) error
+	Finalize(cancel bool) error
+}
+
+// ServePlugin serves the backend plugin implementation, it's called by the
+// main function of plugin binary and never returns.
+func ServePlugin(impl Plugin) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: pluginHandshake,
+		Plugins: map[string]plugin.Plugin{
+			"backend": &rpcPlugin{impl: impl},
+		},
+	})
+}
+
+// CleanupPlugins kills the processes of backend plugins, it should be
+// called before nydusify exits.
+func CleanupPlugins() {
+	plugin.CleanupClients()
+}
+
+// ExternalConfig is the configuration of external backend.
+type ExternalConfig struct {
+	// Plugin is the path of backend plugin binary.
+	Plugin string `json:"plugin"`
+	// Config is the backend configuration passed to the plugin.
+	Config json.RawMessage `json:"config"`
+}
+
+type UploadArgs struct {
+	BlobID    string
+	BlobPath  string
+	Size      int64
+	ForcePush bool
+}
+
+type ReadArgs struct {
+	BlobID string
+	Offset int64
+	Length int64
+}
+
+// rpcServer serves the plugin implementation over net/rpc in the plugin
+// process.
+type rpcServer struct {
+	impl Plugin
+}
+
+func (s *rpcServer) Init(config []byte, _ *struct{}) error {
+	return s.impl.Init(config)
+}
+
+func (s *rpcServer) Upload(args UploadArgs, _ *struct{}) error {
+	return s.impl.Upload(args.BlobID, args.BlobPath, args.Size, args.ForcePush)
+}
+
+func (s *rpcServer) Check(blobID string, exist *bool) (err error) {
+	*exist, err = s.impl.Check(blobID)
+	return err
+}
+
+func (s *rpcServer) Size(blobID string, size *int64) (err error) {
+	*size, err = s.impl.Size(blobID)
+	return err
+}
+
+func (s *rpcServer) ReadAt(args ReadArgs, data *[]byte) (err error) {
+	*data, err = s.impl.ReadAt(args.BlobID, args.Offset, args.Length)
+	return err
+}
+
+func (s *rpcServer) List(_ struct{}, blobs *[]BlobObject) (err error) {
+	*blobs, err = s.impl.List()
+	return err
+}
+
+func (s *rpcServer) Delete(blobID string, _ *struct{}) error {
+	return s.impl.Delete(blobID)
+}
+
+func (s *rpcServer) Finalize(cancel bool, _ *struct{}) error {
+	return s.impl.Finalize(cancel)
+}
+
+// rpcClient calls the plugin over net/rpc in nydusify process.
+type rpcClient struct {
+	client *rpc.Client
+}
+
+func (c *rpcClient) Init(config []byte) error {
+	return c.client.Call("Plugin.Init", config, &struct{}{})
+}
+
+func (c *rpcClient) Upload(blobID, blobPath string, size int64, forcePush bool) error {
+	return c.client.Call("Plugin.Upload", UploadArgs{
+		BlobID:    blobID,
+		BlobPath:  blobPath,
+		Size:      size,
+		ForcePush: forcePush,
+	}, &struct{}{})
+}
+
+func (c *rpcClient) Check(blobID string) (bool, error) {
+	var exist bool
+	err := c.client.Call("Plugin.Check", blobID, &exist)
+	return exist, err
+}
+
+func (c *rpcClient) Size(blobID string) (int64, error) {
+	var size int64
+	err := c.client.Call("Plugin.Size", blobID, &size)
+	return size, err
+}
+
+func (c *rpcClient) ReadAt(blobID string, offset, length int64) ([]byte, error) {
+	var data []byte
+	err := c.client.Call("Plugin.ReadAt", ReadArgs{BlobID: blobID, Offset: offset, Length: length}, &data)
+	return data, err
+}
+
+func (c *rpcClient) List() ([]BlobObject, error) {
+	var blobs []BlobObject
+	err := c.client.Call("Plugin.List", struct{}{}, &blobs)
+	return blobs, err
+}
+
+func (c *rpcClient) Delete(blobID string) error {
+	return c.client.Call("Plugin.Delete", blobID, &struct{}{})
+}
+
+func (c *rpcClient) Finalize(cancel bool) error {
+	return c.client.Call("Plugin.Finalize", cancel, &struct{}{})
+}
+
+// rpcPlugin is the go-plugin definition of backend plugin.
+type rpcPlugin struct {
+	impl Plugin
+}
+
+func (p *rpcPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &rpcServer{impl: p.impl}, nil
+}
+
+func (rpcPlugin) Client(_ *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &rpcClient{client: c}, nil
+}
+
+// PluginBackend stores the blobs by the backend plugin.
+type PluginBackend struct {
+	plugin Plugin
+	client *plugin.Client
+}
+
+func newExternalBackend(rawConfig []byte) (*PluginBackend, error) {
+	var config ExternalConfig
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		return nil, errors.Wrap(err, "parse external backend config")
+	}
+	if config.Plugin == "" {
+		return nil, errors.New("backend plugin is required by external backend")
+	}
+	if _, err := os.Stat(config.Plugin); err != nil {
+		return nil, errors.Wrap(err, "stat backend plugin")
+	}
+
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: pluginHandshake,
+		Plugins: map[string]plugin.Plugin{
+			"backend": &rpcPlugin{},
+		},
+		Cmd:     exec.Command(config.Plugin),
+		Managed: true,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Output: hclog.DefaultOutput,
+			Level:  hclog.Error,
+			Name:   "backend-plugin",
+		}),
+	})
+	protocol, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, errors.Wrapf(err, "start backend plugin %s", config.Plugin)
+	}
+	raw, err := protocol.Dispense("backend")
+	if err != nil {
+		client.Kill()
+		return nil, errors.Wrapf(err, "dispense backend plugin %s", config.Plugin)
+	}
+	impl := raw.(Plugin)
+	if err := impl.Init(config.Config); err != nil {
+		client.Kill()
+		return nil, errors.Wrap(err, "init backend plugin")
+	}
+	logrus.Debugf("loaded backend plugin %s", config.Plugin)
+
+	return &PluginBackend{plugin: impl, client: client}, nil
+}
+
+func (b *PluginBackend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	_, span := startUpload(ctx, "external", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
+	if err := b.plugin.Upload(blobID, blobPath, size, forcePush); err != nil {
+		return nil, errors.Wrap(err, "upload blob by backend plugin")
+	}
+	desc := blobDesc(size, blobID)
+	return &desc, nil
+}
+
+func (b *PluginBackend) Finalize(cancel bool) error {
+	defer b.client.Kill()
+	return b.plugin.Finalize(cancel)
+}
+
+func (b *PluginBackend) Check(blobID string) (bool, error) {
+	return b.plugin.Check(blobID)
+}
+
+func (b *PluginBackend) Type() Type {
+	return ExternalBackend
+}
+
+func (b *PluginBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return &pluginReader{plugin: b.plugin, blobID: blobID, limit: -1}, nil
+}
+
+// pluginRangeReader reads the ranges of blob from backend plugin.
+type pluginRangeReader struct {
+	plugin Plugin
+	blobID string
+}
+
+func (rr *pluginRangeReader) Reader(offset int64, size int64) (io.ReadCloser, error) {
+	return &pluginReader{plugin: rr.plugin, blobID: rr.blobID, offset: offset, limit: size}, nil
+}
+
+func (b *PluginBackend) RangeReader(blobID string) (remotes.RangeReadCloser, error) {
+	return &pluginRangeReader{plugin: b.plugin, blobID: blobID}, nil
+}
+
+func (b *PluginBackend) Size(blobID string) (int64, error) {
+	return b.plugin.Size(blobID)
+}
+
+func (b *PluginBackend) List(_ context.Context) ([]BlobObject, error) {
+	blobs, err := b.plugin.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "list blobs by backend plugin")
+	}
+	var result []BlobObject
+	for _, blob := range blobs {
+		if isBlobID(blob.ID) {
+			result = append(result, blob)
+		}
+	}
+	return result, nil
+}
+
+func (b *PluginBackend) Delete(_ context.Context, blobID string) error {
+	return b.plugin.Delete(blobID)
+}
+
+// pluginReader reads blob from offset sequentially by backend plugin, at
+// most limit bytes are read unless limit is negative.
+type pluginReader struct {
+	plugin Plugin
+	blobID string
+	offset int64
+	limit  int64
+	buf    []byte
+}
+
+func (r *pluginReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.limit == 0 {
+			return 0, io.EOF
+		}
+		length := int64(pluginReadSize)
+		if r.limit > 0 && r.limit < length {
+			length = r.limit
+		}
+		data, err := r.plugin.ReadAt(r.blobID, r.offset, length)
+		if err != nil {
+			return 0, errors.Wrap(err, "read blob by backend plugin")
+		}
+		if len(data) == 0 {
+			return 0, io.EOF
+		}
+		r.buf = data
+		r.offset += int64(len(data))
+		if r.limit > 0 {
+			r.limit -= int64(len(data))
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *pluginReader) Close() error {
+	return nil
+}
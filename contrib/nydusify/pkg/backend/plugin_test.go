@@ -0,0 +1,159 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// memPlugin stores the blobs in memory.
+type memPlugin struct {
+	config    string
+	blobs     map[string][]byte
+	finalized bool
+}
+
+func (p *memPlugin) Init(config []byte) error {
+	p.config = string(config)
+	p.blobs = map[string][]byte{}
+	return nil
+}
+
+func (p *memPlugin) Upload(blobID, blobPath string, _ int64, forcePush bool) error {
+	if _, ok := p.blobs[blobID]; ok && !forcePush {
+		return nil
+	}
+	data, err := os.ReadFile(blobPath)
+	if err != nil {
+		return err
+	}
+	p.blobs[blobID] = data
+	return nil
+}
+
+func (p *memPlugin) Check(blobID string) (bool, error) {
+	_, ok := p.blobs[blobID]
+	return ok, nil
+}
+
+func (p *memPlugin) Size(blobID string) (int64, error) {
+	data, ok := p.blobs[blobID]
+	if !ok {
+		return 0, errors.Errorf("blob %s not found", blobID)
+	}
+	return int64(len(data)), nil
+}
+
+func (p *memPlugin) ReadAt(blobID string, offset, length int64) ([]byte, error) {
+	data, ok := p.blobs[blobID]
+	if !ok {
+		return nil, errors.Errorf("blob %s not found", blobID)
+	}
+	if offset >= int64(len(data)) {
+		return nil, nil
+	}
+	end := offset + length
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	return data[offset:end], nil
+}
+
+func (p *memPlugin) List() ([]BlobObject, error) {
+	var blobs []BlobObject
+	for id, data := range p.blobs {
+		blobs = append(blobs, BlobObject{ID: id, Size: int64(len(data))})
+	}
+	blobs = append(blobs, BlobObject{ID: "bootstrap"})
+	return blobs, nil
+}
+
+func (p *memPlugin) Delete(blobID string) error {
+	delete(p.blobs, blobID)
+	return nil
+}
+
+func (p *memPlugin) Finalize(_ bool) error {
+	p.finalized = true
+	return nil
+}
+
+func TestPluginBackend(t *testing.T) {
+	impl := &memPlugin{}
+	client, _ := plugin.TestPluginRPCConn(t, map[string]plugin.Plugin{
+		"backend": &rpcPlugin{impl: impl},
+	}, nil)
+	defer client.Close()
+	raw, err := client.Dispense("backend")
+	require.NoError(t, err)
+	rpc := raw.(Plugin)
+	require.NoError(t, rpc.Init([]byte(`{"bucket":"nydus"}`)))
+	require.Equal(t, `{"bucket":"nydus"}`, impl.config)
+
+	bkd := &PluginBackend{plugin: rpc, client: plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: pluginHandshake,
+	})}
+	require.Equal(t, ExternalBackend, bkd.Type())
+
+	blobID := strings.Repeat("a", 64)
+	data := bytes.Repeat([]byte("nydus"), pluginReadSize/5+1)
+	blobPath := filepath.Join(t.TempDir(), "blob")
+	require.NoError(t, os.WriteFile(blobPath, data, 0644))
+	desc, err := bkd.Upload(context.Background(), blobID, blobPath, int64(len(data)), false)
+	require.NoError(t, err)
+	require.Equal(t, "sha256:"+blobID, desc.Digest.String())
+
+	exist, err := bkd.Check(blobID)
+	require.NoError(t, err)
+	require.True(t, exist)
+	size, err := bkd.Size(blobID)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), size)
+	_, err = bkd.Size(strings.Repeat("b", 64))
+	require.ErrorContains(t, err, "not found")
+
+	// The blob larger than a read is read by multiple calls.
+	reader, err := bkd.Reader(blobID)
+	require.NoError(t, err)
+	read, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+
+	rangeReader, err := bkd.RangeReader(blobID)
+	require.NoError(t, err)
+	reader, err = rangeReader.Reader(3, 7)
+	require.NoError(t, err)
+	read, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, data[3:10], read)
+
+	blobs, err := bkd.List(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []BlobObject{{ID: blobID, Size: int64(len(data))}}, blobs)
+	require.NoError(t, bkd.Delete(context.Background(), blobID))
+	exist, err = bkd.Check(blobID)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	require.NoError(t, bkd.Finalize(false))
+	require.True(t, impl.finalized)
+}
+
+func TestNewExternalBackend(t *testing.T) {
+	_, err := NewBackend("external", []byte(`{"config":{}}`), nil)
+	require.ErrorContains(t, err, "backend plugin is required")
+	_, err = NewBackend("external", []byte(`{"plugin":"/not-exist"}`), nil)
+	require.ErrorContains(t, err, "stat backend plugin")
+}
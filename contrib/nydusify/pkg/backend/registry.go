@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 )
 
 type Registry struct {
@@ -18,7 +19,10 @@ type Registry struct {
 
 func (r *Registry) Upload(
 	ctx context.Context, blobID, blobPath string, size int64, _ bool,
-) (*ocispec.Descriptor, error) {
+) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "registry", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
 	// The `forcePush` option is useless for registry backend, because
 	// the blob existed in registry can't be pushed again.
 
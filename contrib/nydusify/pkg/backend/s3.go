@@ -13,19 +13,25 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/containerd/containerd/v2/core/remotes"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 )
 
 type S3Backend struct {
@@ -33,20 +39,131 @@ type S3Backend struct {
 	// For example, if the blobID which should be uploaded is "abc",
 	// and the objectPrefix is "path/to/my-registry/", then the object key will be
 	// "path/to/my-registry/abc".
-	objectPrefix       string
-	bucketName         string
-	endpointWithScheme string
-	client             *s3.Client
+	objectPrefix         string
+	bucketName           string
+	endpointWithScheme   string
+	pathStyle            bool
+	serverSideEncryption types.ServerSideEncryption
+	sseKMSKeyID          string
+	multipart            MultipartConfig
+	client               *s3.Client
 }
 
 type S3Config struct {
 	AccessKeyID     string `json:"access_key_id,omitempty"`
 	AccessKeySecret string `json:"access_key_secret,omitempty"`
-	Endpoint        string `json:"endpoint,omitempty"`
-	Scheme          string `json:"scheme,omitempty"`
-	BucketName      string `json:"bucket_name,omitempty"`
-	Region          string `json:"region,omitempty"`
-	ObjectPrefix    string `json:"object_prefix,omitempty"`
+	// SessionToken is the session token of temporary access key.
+	SessionToken string `json:"session_token,omitempty"`
+	Endpoint     string `json:"endpoint,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BucketName   string `json:"bucket_name,omitempty"`
+	Region       string `json:"region,omitempty"`
+	ObjectPrefix string `json:"object_prefix,omitempty"`
+	// ForcePathStyle accesses the bucket by the path of URL rather than the
+	// virtual host, default true for S3 compatible storages.
+	ForcePathStyle *bool `json:"force_path_style,omitempty"`
+
+	// RoleARN is the role assumed by STS, with the access key or the
+	// default credentials of AWS SDK as source credentials.
+	RoleARN         string `json:"role_arn,omitempty"`
+	RoleSessionName string `json:"role_session_name,omitempty"`
+	ExternalID      string `json:"external_id,omitempty"`
+	// WebIdentityTokenFile is the OIDC token file to assume RoleARN, like
+	// the token of IAM roles for service accounts (IRSA) in EKS, which is
+	// also picked up from `AWS_WEB_IDENTITY_TOKEN_FILE` and `AWS_ROLE_ARN`
+	// by the default credentials.
+	WebIdentityTokenFile string `json:"web_identity_token_file,omitempty"`
+	// STSEndpoint is the endpoint URL of STS, default to the endpoint of
+	// region.
+	STSEndpoint string `json:"sts_endpoint,omitempty"`
+
+	// ServerSideEncryption is the server-side encryption of uploaded
+	// objects, possible values: `AES256` (SSE-S3), `aws:kms` (SSE-KMS).
+	ServerSideEncryption string `json:"server_side_encryption,omitempty"`
+	// SSEKMSKeyID is the KMS key of SSE-KMS, default to the AWS managed key.
+	SSEKMSKeyID string `json:"sse_kms_key_id,omitempty"`
+
+	MultipartConfig
+}
+
+func validateS3Config(cfg *S3Config) error {
+	if cfg.BucketName == "" || cfg.Region == "" {
+		return fmt.Errorf("invalid S3 configuration: missing 'bucket_name' or 'region'")
+	}
+	if (cfg.AccessKeyID == "") != (cfg.AccessKeySecret == "") {
+		return fmt.Errorf("invalid S3 configuration: 'access_key_id' and 'access_key_secret' should be specified together")
+	}
+	if cfg.SessionToken != "" && cfg.AccessKeyID == "" {
+		return fmt.Errorf("invalid S3 configuration: 'session_token' requires 'access_key_id' and 'access_key_secret'")
+	}
+	if cfg.RoleARN == "" {
+		for _, field := range [][2]string{
+			{"role_session_name", cfg.RoleSessionName},
+			{"external_id", cfg.ExternalID},
+			{"web_identity_token_file", cfg.WebIdentityTokenFile},
+			{"sts_endpoint", cfg.STSEndpoint},
+		} {
+			if field[1] != "" {
+				return fmt.Errorf("invalid S3 configuration: '%s' requires 'role_arn'", field[0])
+			}
+		}
+	}
+	if cfg.WebIdentityTokenFile != "" {
+		if cfg.AccessKeyID != "" {
+			return fmt.Errorf("invalid S3 configuration: 'web_identity_token_file' conflicts with 'access_key_id'")
+		}
+		if cfg.ExternalID != "" {
+			return fmt.Errorf("invalid S3 configuration: 'external_id' isn't supported with 'web_identity_token_file'")
+		}
+	}
+	switch types.ServerSideEncryption(cfg.ServerSideEncryption) {
+	case "", types.ServerSideEncryptionAes256:
+		if cfg.SSEKMSKeyID != "" {
+			return fmt.Errorf("invalid S3 configuration: 'sse_kms_key_id' requires 'server_side_encryption' to be '%s'", types.ServerSideEncryptionAwsKms)
+		}
+	case types.ServerSideEncryptionAwsKms:
+	default:
+		return fmt.Errorf("invalid S3 configuration: unsupported 'server_side_encryption' %s, possible values: '%s', '%s'",
+			cfg.ServerSideEncryption, types.ServerSideEncryptionAes256, types.ServerSideEncryptionAwsKms)
+	}
+	return cfg.MultipartConfig.validate("S3", manager.MinUploadPartSize)
+}
+
+// s3Credentials returns the credentials provider by configuration, or nil
+// to use the default credentials of AWS SDK.
+func s3Credentials(cfg *S3Config, awsConfig aws.Config) aws.CredentialsProvider {
+	var provider aws.CredentialsProvider
+	if cfg.AccessKeyID != "" {
+		provider = credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.AccessKeySecret, cfg.SessionToken)
+	}
+	if cfg.RoleARN == "" {
+		return provider
+	}
+
+	if provider != nil {
+		awsConfig.Credentials = provider
+	}
+	stsClient := sts.NewFromConfig(awsConfig, func(o *sts.Options) {
+		if cfg.STSEndpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.STSEndpoint)
+		}
+	})
+	if cfg.WebIdentityTokenFile != "" {
+		provider = stscreds.NewWebIdentityRoleProvider(stsClient, cfg.RoleARN, stscreds.IdentityTokenFile(cfg.WebIdentityTokenFile), func(o *stscreds.WebIdentityRoleOptions) {
+			o.RoleSessionName = cfg.RoleSessionName
+		})
+	} else {
+		provider = stscreds.NewAssumeRoleProvider(stsClient, cfg.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if cfg.RoleSessionName != "" {
+				o.RoleSessionName = cfg.RoleSessionName
+			}
+			if cfg.ExternalID != "" {
+				o.ExternalID = aws.String(cfg.ExternalID)
+			}
+		})
+	}
+	// The temporary credentials are refreshed before expiration.
+	return aws.NewCredentialsCache(provider)
 }
 
 func newS3Backend(rawConfig []byte) (*S3Backend, error) {
@@ -62,34 +179,51 @@ func newS3Backend(rawConfig []byte) (*S3Backend, error) {
 	}
 	endpointWithScheme := fmt.Sprintf("%s://%s", cfg.Scheme, cfg.Endpoint)
 
-	if cfg.BucketName == "" || cfg.Region == "" {
-		return nil, fmt.Errorf("invalid S3 configuration: missing 'bucket_name' or 'region'")
+	if err := validateS3Config(cfg); err != nil {
+		return nil, err
 	}
+	pathStyle := cfg.ForcePathStyle == nil || *cfg.ForcePathStyle
 
-	s3AWSConfig, err := awscfg.LoadDefaultConfig(context.TODO())
+	s3AWSConfig, err := awscfg.LoadDefaultConfig(context.TODO(), awscfg.WithRegion(cfg.Region))
 	if err != nil {
 		return nil, errors.Wrap(err, "load default AWS config")
 	}
+	provider := s3Credentials(cfg, s3AWSConfig)
 
 	client := s3.NewFromConfig(s3AWSConfig, func(o *s3.Options) {
 		o.BaseEndpoint = &endpointWithScheme
 		o.Region = cfg.Region
-		o.UsePathStyle = true
-		if len(cfg.AccessKeySecret) > 0 && len(cfg.AccessKeyID) > 0 {
-			o.Credentials = credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.AccessKeySecret, "")
+		if provider != nil {
+			o.Credentials = provider
 		}
-		o.UsePathStyle = true
+		o.UsePathStyle = pathStyle
 	})
 
 	return &S3Backend{
-		objectPrefix:       cfg.ObjectPrefix,
-		bucketName:         cfg.BucketName,
-		endpointWithScheme: endpointWithScheme,
-		client:             client,
+		objectPrefix:         cfg.ObjectPrefix,
+		bucketName:           cfg.BucketName,
+		endpointWithScheme:   endpointWithScheme,
+		pathStyle:            pathStyle,
+		serverSideEncryption: types.ServerSideEncryption(cfg.ServerSideEncryption),
+		sseKMSKeyID:          cfg.SSEKMSKeyID,
+		multipart:            cfg.MultipartConfig,
+		client:               client,
 	}, nil
 }
 
-func (b *S3Backend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (*ocispec.Descriptor, error) {
+// encrypt sets the server-side encryption of object to put.
+func (b *S3Backend) encrypt(input *s3.PutObjectInput) *s3.PutObjectInput {
+	input.ServerSideEncryption = b.serverSideEncryption
+	if b.sseKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(b.sseKMSKeyID)
+	}
+	return input
+}
+
+func (b *S3Backend) Upload(ctx context.Context, blobID, blobPath string, size int64, forcePush bool) (_ *ocispec.Descriptor, retErr error) {
+	ctx, span := startUpload(ctx, "s3", blobID, size)
+	defer func() { tracing.End(span, retErr) }()
+
 	blobObjectKey := b.blobObjectKey(blobID)
 
 	desc := blobDesc(size, blobID)
@@ -112,15 +246,24 @@ func (b *S3Backend) Upload(ctx context.Context, blobID, blobPath string, size in
 	}
 	defer blobFile.Close()
 
+	fileSize, err := blobFileSize(blobPath, size)
+	if err != nil {
+		return nil, err
+	}
 	uploader := manager.NewUploader(b.client, func(u *manager.Uploader) {
-		u.PartSize = multipartChunkSize
+		u.PartSize = b.multipart.partSize(fileSize)
+		u.Concurrency = b.multipart.PartConcurrency
+		// Each part is retried by the retryer of client separately.
+		u.ClientOptions = append(u.ClientOptions, func(o *s3.Options) {
+			o.RetryMaxAttempts = *b.multipart.PartRetries + 1
+		})
 	})
-	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+	_, err = uploader.Upload(ctx, b.encrypt(&s3.PutObjectInput{
 		Bucket:            aws.String(b.bucketName),
 		Key:               aws.String(blobObjectKey),
 		Body:              blobFile,
 		ChecksumAlgorithm: types.ChecksumAlgorithmCrc32,
-	})
+	}))
 	if err != nil {
 		return nil, errors.Wrap(err, "upload blob to s3 backend")
 	}
@@ -201,8 +344,123 @@ func (b *S3Backend) Size(blobID string) (int64, error) {
 	return *output.ObjectSize, nil
 }
 
+func (b *S3Backend) List(ctx context.Context) ([]BlobObject, error) {
+	var blobs []BlobObject
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: &b.bucketName,
+		Prefix: &b.objectPrefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "list objects")
+		}
+		for _, object := range page.Contents {
+			blobID := strings.TrimPrefix(aws.ToString(object.Key), b.objectPrefix)
+			if !isBlobID(blobID) {
+				continue
+			}
+			blobs = append(blobs, BlobObject{
+				ID:           blobID,
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return blobs, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, blobID string) error {
+	objectKey := b.blobObjectKey(blobID)
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &b.bucketName,
+		Key:    &objectKey,
+	})
+	return err
+}
+
 func (b *S3Backend) remoteID(blobObjectKey string) string {
 	remoteURL, _ := url.Parse(b.endpointWithScheme)
-	remoteURL.Path = path.Join(remoteURL.Path, b.bucketName, blobObjectKey)
+	if b.pathStyle {
+		remoteURL.Path = path.Join(remoteURL.Path, b.bucketName, blobObjectKey)
+	} else {
+		remoteURL.Host = b.bucketName + "." + remoteURL.Host
+		remoteURL.Path = path.Join(remoteURL.Path, blobObjectKey)
+	}
 	return remoteURL.String()
 }
+
+func s3StatusCode(err error) int {
+	var responseError *awshttp.ResponseError
+	if errors.As(err, &responseError) {
+		return responseError.ResponseError.HTTPStatusCode()
+	}
+	return 0
+}
+
+func (b *S3Backend) GetObject(ctx context.Context, key string) (io.ReadCloser, string, error) {
+	output, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &b.bucketName,
+		Key:    &key,
+	})
+	if err != nil {
+		if s3StatusCode(err) == http.StatusNotFound {
+			return nil, "", ErrObjectNotFound
+		}
+		return nil, "", errors.Wrapf(err, "get object %s", key)
+	}
+	return output.Body, aws.ToString(output.ETag), nil
+}
+
+// PutObject writes the object with the conditional headers of S3, see
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/conditional-writes.html.
+func (b *S3Backend) PutObject(ctx context.Context, key string, reader io.Reader, size int64, etag string) error {
+	condition := smithyhttp.SetHeaderValue("If-None-Match", "*")
+	if etag != "" {
+		condition = smithyhttp.SetHeaderValue("If-Match", etag)
+	}
+	_, err := b.client.PutObject(ctx, b.encrypt(&s3.PutObjectInput{
+		Bucket:        &b.bucketName,
+		Key:           &key,
+		Body:          reader,
+		ContentLength: aws.Int64(size),
+	}), s3.WithAPIOptions(condition))
+	if err != nil {
+		// 409 is returned if the object is written concurrently.
+		if code := s3StatusCode(err); code == http.StatusPreconditionFailed || code == http.StatusConflict {
+			return ErrPreconditionFailed
+		}
+		return errors.Wrapf(err, "put object %s", key)
+	}
+	return nil
+}
+
+func (b *S3Backend) ExistObject(ctx context.Context, key string) (bool, error) {
+	return b.existObject(ctx, key)
+}
+
+func (b *S3Backend) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: &b.bucketName,
+		Prefix: &prefix,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "list objects")
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	return keys, nil
+}
+
+func (b *S3Backend) DeleteObject(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &b.bucketName,
+		Key:    &key,
+	})
+	return errors.Wrapf(err, "delete object %s", key)
+}
@@ -9,7 +9,11 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -117,3 +121,69 @@ func TestNewS3Backend(t *testing.T) {
 	require.Contains(t, err.Error(), "invalid S3 configuration: missing 'bucket_name' or 'region'")
 	require.Nil(t, backend)
 }
+
+func TestS3ConfigValidation(t *testing.T) {
+	for _, tc := range []struct {
+		config string
+		err    string
+	}{
+		{`{"access_key_id": "testAK"}`, "'access_key_id' and 'access_key_secret' should be specified together"},
+		{`{"session_token": "token"}`, "'session_token' requires 'access_key_id' and 'access_key_secret'"},
+		{`{"external_id": "id"}`, "'external_id' requires 'role_arn'"},
+		{`{"web_identity_token_file": "/var/run/token"}`, "'web_identity_token_file' requires 'role_arn'"},
+		{`{"role_arn": "arn:aws:iam::123456789012:role/nydus", "web_identity_token_file": "/var/run/token", "access_key_id": "testAK", "access_key_secret": "testSK"}`, "'web_identity_token_file' conflicts with 'access_key_id'"},
+		{`{"server_side_encryption": "aws:kms:dsse"}`, "unsupported 'server_side_encryption' aws:kms:dsse"},
+		{`{"server_side_encryption": "AES256", "sse_kms_key_id": "key"}`, "'sse_kms_key_id' requires 'server_side_encryption' to be 'aws:kms'"},
+		{`{"server_side_encryption": "aws:kms", "sse_kms_key_id": "key"}`, ""},
+		{`{"role_arn": "arn:aws:iam::123456789012:role/nydus", "external_id": "id", "sts_endpoint": "http://localhost:9000"}`, ""},
+		{`{"part_size": 1048576}`, "'part_size' should be between 5242880 and 5368709120"},
+		{`{"part_concurrency": -1}`, "negative 'part_concurrency'"},
+		{`{"part_retries": -1}`, "negative 'part_retries'"},
+		{`{"part_size": 67108864, "part_concurrency": 16, "part_retries": 0}`, ""},
+	} {
+		cfg := &S3Config{BucketName: "test", Region: "region1"}
+		require.NoError(t, json.Unmarshal([]byte(tc.config), cfg))
+		err := validateS3Config(cfg)
+		if tc.err == "" {
+			require.NoError(t, err, tc.config)
+		} else {
+			require.ErrorContains(t, err, tc.err, tc.config)
+		}
+	}
+}
+
+func TestNewS3BackendOptions(t *testing.T) {
+	backend, err := newS3Backend([]byte(`
+	{
+		"bucket_name": "test",
+		"region": "region1",
+		"access_key_id": "testAK",
+		"access_key_secret": "testSK",
+		"session_token": "testToken",
+		"force_path_style": false,
+		"server_side_encryption": "aws:kms",
+		"sse_kms_key_id": "testKey"
+	}`))
+	require.NoError(t, err)
+	require.False(t, backend.client.Options().UsePathStyle)
+	require.Equal(t, "https://test.s3.amazonaws.com/111", backend.remoteID("111"))
+	testCredentials, err := backend.client.Options().Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "testToken", testCredentials.SessionToken)
+
+	input := backend.encrypt(&s3.PutObjectInput{})
+	require.Equal(t, types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+	require.Equal(t, "testKey", aws.ToString(input.SSEKMSKeyId))
+
+	// The role is assumed lazily when retrieving credentials.
+	backend, err = newS3Backend([]byte(`
+	{
+		"bucket_name": "test",
+		"region": "region1",
+		"role_arn": "arn:aws:iam::123456789012:role/nydus",
+		"web_identity_token_file": "/var/run/secrets/token"
+	}`))
+	require.NoError(t, err)
+	require.True(t, backend.client.Options().UsePathStyle)
+	require.True(t, aws.IsCredentialsProvider(backend.client.Options().Credentials, &stscreds.WebIdentityRoleProvider{}))
+}
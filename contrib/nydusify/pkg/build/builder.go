@@ -29,6 +29,10 @@ type BuilderOption struct {
 	Compressor   string
 	ChunkSize    string
 	FsVersion    string
+
+	// SourceType is the type of RootfsPath passed to `nydus-image create
+	// --type`, the directory is built if it's empty.
+	SourceType string
 }
 
 type CompactOption struct {
@@ -53,6 +57,28 @@ type GenerateOption struct {
 	OutputPath             string
 }
 
+type MergeOption struct {
+	// SourceBootstrapPaths are merged in order, the files in the latter
+	// bootstraps override the ones in the former.
+	SourceBootstrapPaths []string
+	TargetBootstrapPath  string
+	// ParentBootstrapPath is the bootstrap of lower layers which the
+	// source bootstraps are merged onto.
+	ParentBootstrapPath string
+	// OriginalBlobIDs are the blob IDs of source bootstraps in order, e.g.
+	// the digests of OCI layers referenced by the OCI ref bootstraps.
+	OriginalBlobIDs []string
+}
+
+type UnpackOption struct {
+	BootstrapPath string
+	BlobPath      string
+	// BlobDir is the directory of the blobs named by blob ID, for the
+	// bootstrap referring multiple blobs.
+	BlobDir    string
+	OutputPath string
+}
+
 type Builder struct {
 	binaryPath string
 	stdout     io.Writer
@@ -155,6 +181,10 @@ func (builder *Builder) Run(option BuilderOption) error {
 		args = append(args, "--chunk-size", option.ChunkSize)
 	}
 
+	if option.SourceType != "" {
+		args = append(args, "--type", option.SourceType)
+	}
+
 	args = append(args, option.RootfsPath)
 
 	return builder.run(args, option.PrefetchPatterns)
@@ -179,3 +209,44 @@ func (builder *Builder) Generate(option GenerateOption) error {
 
 	return builder.run(args, "")
 }
+
+// Unpack calls `nydus-image unpack` to generate an OCI tar layer from the
+// bootstrap and data blob of a nydus layer.
+func (builder *Builder) Unpack(option UnpackOption) error {
+	args := []string{
+		"unpack",
+		"--log-level",
+		"warn",
+		"--bootstrap",
+		option.BootstrapPath,
+		"--output",
+		option.OutputPath,
+	}
+	if option.BlobPath != "" {
+		args = append(args, "--blob", option.BlobPath)
+	} else if option.BlobDir != "" {
+		args = append(args, "--blob-dir", option.BlobDir)
+	}
+
+	return builder.run(args, "")
+}
+
+// Merge calls `nydus-image merge` to overlay the bootstraps into one.
+func (builder *Builder) Merge(option MergeOption) error {
+	args := []string{
+		"merge",
+		"--log-level",
+		"warn",
+		"--bootstrap",
+		option.TargetBootstrapPath,
+	}
+	if option.ParentBootstrapPath != "" {
+		args = append(args, "--parent-bootstrap", option.ParentBootstrapPath)
+	}
+	if len(option.OriginalBlobIDs) > 0 {
+		args = append(args, "--original-blob-ids", strings.Join(option.OriginalBlobIDs, ","))
+	}
+	args = append(args, option.SourceBootstrapPaths...)
+
+	return builder.run(args, "")
+}
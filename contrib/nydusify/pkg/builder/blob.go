@@ -0,0 +1,243 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"lukechampine.com/blake3"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// chunk is a piece of file data stored in data blob, the chunks with the
+// same digest are deduplicated in a blob.
+type chunk struct {
+	digest             [32]byte
+	index              uint32
+	compressedOffset   uint64
+	compressedSize     uint32
+	uncompressedOffset uint64
+	uncompressedSize   uint32
+	compressed         bool
+	fileOffset         uint64
+}
+
+// blobWriter dumps file chunks into data blob, followed by the compression
+// context table and its header:
+//
+//	chunk data | compression context table | compression context table header
+type blobWriter struct {
+	opt     *Option
+	w       io.Writer
+	hasher  hash.Hash
+	encoder *zstd.Encoder
+
+	chunks  []*chunk
+	digests map[[32]byte]*chunk
+
+	compressedOffset   uint64
+	uncompressedOffset uint64
+
+	// Fields below are available after finalized.
+	blobID             string
+	blobSize           uint64
+	ciCompressor       uint32
+	ciOffset           uint64
+	ciCompressedSize   uint64
+	ciUncompressedSize uint64
+}
+
+func newBlobWriter(opt *Option, w io.Writer) (*blobWriter, error) {
+	bw := &blobWriter{
+		opt:     opt,
+		hasher:  sha256.New(),
+		digests: make(map[[32]byte]*chunk),
+	}
+	bw.w = io.MultiWriter(w, bw.hasher)
+	if opt.Compressor == CompressorZstd {
+		encoder, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, errors.Wrap(err, "create zstd encoder")
+		}
+		bw.encoder = encoder
+	}
+	return bw, nil
+}
+
+func (bw *blobWriter) digest(data []byte) [32]byte {
+	if bw.opt.Digester == DigesterSHA256 {
+		return sha256.Sum256(data)
+	}
+	return blake3.Sum256(data)
+}
+
+// compress returns the original data if compression doesn't reduce the size.
+func (bw *blobWriter) compress(data []byte) ([]byte, bool) {
+	if bw.encoder == nil || len(data) == 0 {
+		return data, false
+	}
+	compressed := bw.encoder.EncodeAll(data, make([]byte, 0, len(data)))
+	if len(compressed) >= len(data) {
+		return data, false
+	}
+	return compressed, true
+}
+
+// writeFile dumps the data of regular file into blob by chunk.
+func (bw *blobWriter) writeFile(node *inode) error {
+	if node.size == 0 {
+		return nil
+	}
+
+	file, err := os.Open(node.path)
+	if err != nil {
+		return errors.Wrap(err, "open file")
+	}
+	defer file.Close()
+
+	chunkSize := uint64(bw.opt.ChunkSize)
+	buf := make([]byte, chunkSize)
+	for offset := uint64(0); offset < node.size; offset += chunkSize {
+		size := min(chunkSize, node.size-offset)
+		data := buf[:size]
+		if _, err := io.ReadFull(file, data); err != nil {
+			return errors.Wrapf(err, "read file %s", node.path)
+		}
+
+		digest := bw.digest(data)
+		if c, ok := bw.digests[digest]; ok && c.uncompressedSize == uint32(size) {
+			node.chunks = append(node.chunks, c)
+			continue
+		}
+
+		c, err := bw.writeChunk(data, digest)
+		if err != nil {
+			return errors.Wrapf(err, "write chunk of file %s", node.path)
+		}
+		c.fileOffset = offset
+		node.chunks = append(node.chunks, c)
+	}
+
+	return nil
+}
+
+func (bw *blobWriter) writeChunk(data []byte, digest [32]byte) (*chunk, error) {
+	compressed, isCompressed := bw.compress(data)
+	if _, err := bw.w.Write(compressed); err != nil {
+		return nil, err
+	}
+
+	c := &chunk{
+		digest:             digest,
+		index:              uint32(len(bw.chunks)),
+		compressedOffset:   bw.compressedOffset,
+		compressedSize:     uint32(len(compressed)),
+		uncompressedOffset: bw.uncompressedOffset,
+		uncompressedSize:   uint32(len(data)),
+		compressed:         isCompressed,
+	}
+	bw.compressedOffset += uint64(len(compressed))
+	// The uncompressed chunks are aligned to block, so that they can be
+	// addressed by EROFS block address.
+	bw.uncompressedOffset += roundUp(uint64(len(data)), blockSize)
+
+	bw.chunks = append(bw.chunks, c)
+	bw.digests[digest] = c
+
+	return c, nil
+}
+
+func (bw *blobWriter) blobCompressor() uint32 {
+	if bw.encoder != nil {
+		return rafs.CompressorZstd
+	}
+	return rafs.CompressorNone
+}
+
+func (bw *blobWriter) blobDigester() uint32 {
+	if bw.opt.Digester == DigesterSHA256 {
+		return digesterSHA256
+	}
+	return digesterBlake3
+}
+
+// finalize dumps the compression context table and header into blob, then
+// calculates the blob id. It does nothing if there is no chunk.
+func (bw *blobWriter) finalize() error {
+	if len(bw.chunks) == 0 {
+		return nil
+	}
+
+	var table bytes.Buffer
+	for _, c := range bw.chunks {
+		info := newChunkInfoV1(c.compressedOffset, c.compressedSize, c.uncompressedOffset, c.uncompressedSize)
+		if err := binary.Write(&table, binary.LittleEndian, info); err != nil {
+			return err
+		}
+	}
+	ciData, compressed := bw.compress(table.Bytes())
+	bw.ciCompressor = rafs.CompressorNone
+	if compressed {
+		bw.ciCompressor = rafs.CompressorZstd
+	}
+	bw.ciOffset = bw.compressedOffset
+	bw.ciCompressedSize = uint64(len(ciData))
+	bw.ciUncompressedSize = uint64(table.Len())
+
+	header := blobMetaHeader{
+		Magic:              blobMetaMagic,
+		Features:           bw.features(),
+		CiCompressor:       bw.ciCompressor,
+		CiEntries:          uint32(len(bw.chunks)),
+		CiOffset:           bw.ciOffset,
+		CiCompressedSize:   bw.ciCompressedSize,
+		CiUncompressedSize: bw.ciUncompressedSize,
+		Magic2:             blobMetaMagic,
+	}
+	if _, err := bw.w.Write(ciData); err != nil {
+		return errors.Wrap(err, "write compression context table")
+	}
+	if err := binary.Write(bw.w, binary.LittleEndian, header); err != nil {
+		return errors.Wrap(err, "write compression context table header")
+	}
+
+	bw.blobSize = bw.ciOffset + bw.ciCompressedSize + blobMetaHeaderSize
+	bw.blobID = hex.EncodeToString(bw.hasher.Sum(nil))
+
+	return nil
+}
+
+func (bw *blobWriter) features() uint32 {
+	return blobFeatureAligned | blobFeatureCapTarToc
+}
+
+// blobEntry returns the blob table entry in bootstrap.
+func (bw *blobWriter) blobEntry() rafs.BlobEntry {
+	entry := rafs.BlobEntry{
+		BlobIndex:          0,
+		ChunkSize:          bw.opt.ChunkSize,
+		ChunkCount:         uint32(len(bw.chunks)),
+		CompressionAlgo:    bw.blobCompressor(),
+		DigestAlgo:         bw.blobDigester(),
+		Features:           bw.features(),
+		CompressedSize:     bw.blobSize,
+		UncompressedSize:   bw.uncompressedOffset,
+		CiCompressor:       bw.ciCompressor,
+		CiOffset:           bw.ciOffset,
+		CiCompressedSize:   bw.ciCompressedSize,
+		CiUncompressedSize: bw.ciUncompressedSize,
+	}
+	copy(entry.BlobID[:], bw.blobID)
+	return entry
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// RAFS v6 bootstrap layout:
+//
+//	+----+-------+---------------+------------+-------+--------+------------------+
+//	| 1k | super | extended      | blob table | empty | inodes | chunk info table |
+//	|    | block | super block + |            | block |        |                  |
+//	|    |       | device slots  |            |       |        |                  |
+//	+----+-------+---------------+------------+-------+--------+------------------+
+//
+// The first block of meta area is left empty to avoid using 0 as root nid,
+// which is treated as a deleted file by some programs.
+type bootstrapWriter struct {
+	opt    *Option
+	inodes []*inode
+	blob   *blobWriter
+
+	metaAddr uint64
+	buf      []byte
+}
+
+func (bw *bootstrapWriter) nid(node *inode) uint64 {
+	return (node.offset - bw.metaAddr) / rafs.InodeSlotSize
+}
+
+// splitDirents splits the dirents of directory by block, the dirents and
+// names of a block must not exceed the block, returns the directory size.
+func splitDirents(node *inode) uint64 {
+	entries := []dirEntry{{name: ".", inode: node}, {name: "..", inode: node.parent}}
+	entries = append(entries, node.children...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	node.direntBlocks = nil
+	var block []dirEntry
+	var used, size uint64
+	for _, entry := range entries {
+		length := uint64(rafs.DirentSize + len(entry.name))
+		if used+length > blockSize {
+			node.direntBlocks = append(node.direntBlocks, block)
+			size += blockSize
+			block, used = nil, 0
+		}
+		block = append(block, entry)
+		used += length
+	}
+	node.direntBlocks = append(node.direntBlocks, block)
+
+	return size + used
+}
+
+func (bw *bootstrapWriter) layout() {
+	offset := bw.metaAddr + blockSize
+
+	for _, node := range bw.inodes {
+		inodeSize := node.inodeSize()
+		switch {
+		case node.isReg():
+			node.offset = offset
+			node.layout = rafs.ErofsInodeChunkBased
+			offset += roundUp(inodeSize, rafs.ChunkAddrSize) + uint64(len(node.chunks))*rafs.ChunkAddrSize
+		case node.isDir():
+			node.size = splitDirents(node)
+			offset = bw.layoutWithTail(node, offset, inodeSize)
+		case node.isSymlink():
+			offset = bw.layoutWithTail(node, offset, inodeSize)
+		default:
+			node.offset = offset
+			node.layout = rafs.ErofsInodeFlatPlain
+			offset += inodeSize
+		}
+		offset = roundUp(offset, rafs.InodeSlotSize)
+	}
+
+	bw.buf = make([]byte, roundUp(offset, blockSize))
+}
+
+// layoutWithTail lays out directory or symlink, the tail data is inlined
+// after inode if they fit in a block, the other data is stored in the
+// blocks following the inode.
+func (bw *bootstrapWriter) layoutWithTail(node *inode, offset, inodeSize uint64) uint64 {
+	tail := node.size % blockSize
+	if tail != 0 && inodeSize+tail <= blockSize {
+		// Inline data must not cross the block boundary.
+		if blockSize-offset%blockSize < inodeSize+tail {
+			offset = roundUp(offset, blockSize)
+		}
+		node.offset = offset
+		node.layout = rafs.ErofsInodeFlatInline
+		offset += inodeSize + tail
+		if node.size != tail {
+			offset = roundUp(offset, blockSize)
+		}
+		node.dataOffset = offset
+		return offset + roundDown(node.size, blockSize)
+	}
+
+	node.offset = offset
+	node.layout = rafs.ErofsInodeFlatPlain
+	offset = roundUp(offset+inodeSize, blockSize)
+	node.dataOffset = offset
+	return roundUp(offset+node.size, blockSize)
+}
+
+func (bw *bootstrapWriter) put(offset uint64, data interface{}) error {
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, data); err != nil {
+		return err
+	}
+	if offset+uint64(buf.Len()) > uint64(len(bw.buf)) {
+		return errors.Errorf("write beyond bootstrap size at offset 0x%x", offset)
+	}
+	copy(bw.buf[offset:], buf.Bytes())
+	return nil
+}
+
+func (bw *bootstrapWriter) dumpInode(node *inode) error {
+	var u uint32
+	switch {
+	case node.isReg():
+		// The chunk size is encoded as log2(chunk_size) - block bits.
+		chunkBits := uint16(0)
+		for size := bw.opt.ChunkSize; size > 1; size >>= 1 {
+			chunkBits++
+		}
+		u = uint32(rafs.ErofsChunkFormatIndexes | (chunkBits - erofsBlockBits))
+	case node.isDir(), node.isSymlink():
+		u = uint32(node.dataOffset / blockSize)
+	default:
+		u = node.rdev
+	}
+
+	var xattrICount uint16
+	if size := node.xattrSize(); size > 0 {
+		xattrICount = uint16((size-rafs.XattrIbodyHeaderSize)/rafs.XattrEntrySize + 1)
+	}
+
+	if err := bw.put(node.offset, rafs.ExtendedInode{
+		Format:      rafs.ErofsInodeLayoutExtended | node.layout<<1,
+		XattrICount: xattrICount,
+		Mode:        uint16(node.mode),
+		Size:        node.size,
+		U:           u,
+		Ino:         node.ino,
+		UID:         node.uid,
+		GID:         node.gid,
+		Mtime:       node.mtime,
+		MtimeNsec:   node.mtimeNs,
+		Nlink:       node.nlink,
+	}); err != nil {
+		return errors.Wrapf(err, "write inode of %s", node.path)
+	}
+
+	if len(node.xattrs) > 0 {
+		offset := node.offset + rafs.ExtendedInodeSize + rafs.XattrIbodyHeaderSize
+		for _, pair := range node.xattrs {
+			copy(bw.buf[offset:], []byte{uint8(len(pair.name)), pair.index})
+			binary.LittleEndian.PutUint16(bw.buf[offset+2:], uint16(len(pair.value)))
+			offset += rafs.XattrEntrySize
+			offset += uint64(copy(bw.buf[offset:], pair.name))
+			offset += uint64(copy(bw.buf[offset:], pair.value))
+			offset = roundUp(offset, rafs.XattrEntrySize)
+		}
+	}
+
+	switch {
+	case node.isReg():
+		return bw.dumpChunkAddrs(node)
+	case node.isDir():
+		return bw.dumpDirents(node)
+	case node.isSymlink():
+		copy(bw.buf[bw.dataPosition(node, 0):], node.symlink)
+	}
+
+	return nil
+}
+
+// dataPosition returns the position of the block of inode data in bootstrap.
+func (bw *bootstrapWriter) dataPosition(node *inode, block int) uint64 {
+	if node.layout == rafs.ErofsInodeFlatInline && uint64(block) == node.size/blockSize {
+		return node.offset + node.inodeSize()
+	}
+	return node.dataOffset + uint64(block)*blockSize
+}
+
+func (bw *bootstrapWriter) dumpChunkAddrs(node *inode) error {
+	offset := roundUp(node.offset+node.inodeSize(), rafs.ChunkAddrSize)
+	for _, c := range node.chunks {
+		addr := newChunkAddr(0, c.index, uint32(c.uncompressedOffset/blockSize))
+		if err := bw.put(offset, addr); err != nil {
+			return errors.Wrapf(err, "write chunk address of %s", node.path)
+		}
+		offset += rafs.ChunkAddrSize
+	}
+	return nil
+}
+
+func (bw *bootstrapWriter) dumpDirents(node *inode) error {
+	for idx, block := range node.direntBlocks {
+		pos := bw.dataPosition(node, idx)
+		nameOff := uint64(len(block) * rafs.DirentSize)
+		for i, entry := range block {
+			if err := bw.put(pos+uint64(i*rafs.DirentSize), rafs.Dirent{
+				Nid:      bw.nid(entry.inode),
+				NameOff:  uint16(nameOff),
+				FileType: fileType(entry.inode.mode),
+			}); err != nil {
+				return errors.Wrapf(err, "write dirent of %s", node.path)
+			}
+			copy(bw.buf[pos+nameOff:], entry.name)
+			nameOff += uint64(len(entry.name))
+		}
+	}
+	return nil
+}
+
+// dumpChunkTable appends the chunk information table sorted by digest to
+// bootstrap, which is used by nydusd to look up chunks by digest.
+func (bw *bootstrapWriter) dumpChunkTable() (uint64, uint64, error) {
+	chunks := append([]*chunk{}, bw.blob.chunks...)
+	sort.Slice(chunks, func(i, j int) bool {
+		return bytes.Compare(chunks[i].digest[:], chunks[j].digest[:]) < 0
+	})
+
+	var table bytes.Buffer
+	for _, c := range chunks {
+		info := rafs.ChunkInfo{
+			BlockID:            c.digest,
+			CompressedSize:     c.compressedSize,
+			UncompressedSize:   c.uncompressedSize,
+			CompressedOffset:   c.compressedOffset,
+			UncompressedOffset: c.uncompressedOffset,
+			FileOffset:         c.fileOffset,
+			Index:              c.index,
+		}
+		if c.compressed {
+			info.Flags |= rafs.ChunkFlagCompressed
+		}
+		if err := binary.Write(&table, binary.LittleEndian, info); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	offset := uint64(len(bw.buf))
+	size := uint64(table.Len())
+	bw.buf = append(bw.buf, table.Bytes()...)
+	bw.buf = append(bw.buf, make([]byte, roundUp(uint64(len(bw.buf)), blockSize)-uint64(len(bw.buf)))...)
+
+	return offset, size, nil
+}
+
+func (bw *bootstrapWriter) flags(hasXattr bool) uint64 {
+	flags := uint64(rafs.FlagExplicitUIDGID | rafs.FlagEncryptionNone)
+	if bw.opt.Compressor == CompressorZstd {
+		flags |= rafs.FlagCompressionZstd
+	} else {
+		flags |= rafs.FlagCompressionNone
+	}
+	if bw.opt.Digester == DigesterSHA256 {
+		flags |= rafs.FlagHashSHA256
+	} else {
+		flags |= rafs.FlagHashBlake3
+	}
+	if hasXattr {
+		flags |= rafs.FlagHasXattr
+	}
+	return flags
+}
+
+// dump generates the bootstrap, the data blob must be finalized before.
+func (bw *bootstrapWriter) dump() ([]byte, error) {
+	// The blob with no chunk is omitted since nydusd rejects it.
+	var blobs []rafs.BlobEntry
+	if len(bw.blob.chunks) > 0 {
+		blobs = append(blobs, bw.blob.blobEntry())
+	}
+
+	blobTableOffset := roundUp(rafs.DevTableOffset+uint64(len(blobs)*rafs.DeviceSlotSize), blockSize)
+	blobTableSize := uint64(len(blobs) * rafs.BlobEntrySize)
+	bw.metaAddr = roundUp(blobTableOffset+blobTableSize, blockSize)
+
+	bw.layout()
+
+	hasXattr := false
+	for _, node := range bw.inodes {
+		if err := bw.dumpInode(node); err != nil {
+			return nil, err
+		}
+		hasXattr = hasXattr || len(node.xattrs) > 0
+	}
+
+	chunkTableOffset, chunkTableSize, err := bw.dumpChunkTable()
+	if err != nil {
+		return nil, errors.Wrap(err, "write chunk table")
+	}
+
+	var blocks uint32
+	mappedBlkAddr := roundUp(uint64(len(bw.buf)), blockSegmentAlignment) / blockSize
+	for idx, blob := range blobs {
+		slot := rafs.DeviceSlot{
+			Blocks:        uint32(blob.UncompressedSize / blockSize),
+			MappedBlkAddr: uint32(mappedBlkAddr),
+		}
+		copy(slot.BlobID[:], blob.BlobID[:])
+		if err := bw.put(rafs.DevTableOffset+uint64(idx*rafs.DeviceSlotSize), slot); err != nil {
+			return nil, errors.Wrap(err, "write device slot")
+		}
+		if err := bw.put(blobTableOffset+uint64(idx*rafs.BlobEntrySize), blob); err != nil {
+			return nil, errors.Wrap(err, "write blob table")
+		}
+		blocks += slot.Blocks
+		mappedBlkAddr += uint64(slot.Blocks)
+	}
+
+	if err := bw.put(rafs.SuperOffset, rafs.SuperBlock{
+		Magic:           rafs.ErofsSuperMagic,
+		FeatureCompat:   rafs.ErofsFeatureCompatRafsV6,
+		BlkSzBits:       erofsBlockBits,
+		RootNid:         uint16(bw.nid(bw.inodes[0])),
+		Inos:            uint64(len(bw.inodes)),
+		Blocks:          blocks,
+		MetaBlkAddr:     uint32(bw.metaAddr / blockSize),
+		FeatureIncompat: rafs.ErofsFeatureIncompatChunkedFile | rafs.ErofsFeatureIncompatDeviceTable,
+		ExtraDevices:    uint16(len(blobs)),
+		DevtSlotOff:     rafs.DevTableOffset / rafs.DeviceSlotSize,
+	}); err != nil {
+		return nil, errors.Wrap(err, "write super block")
+	}
+
+	if err := bw.put(rafs.SuperOffset+rafs.SuperBlockSize, rafs.ExtSuperBlock{
+		Flags:            bw.flags(hasXattr),
+		BlobTableOffset:  blobTableOffset,
+		BlobTableSize:    uint32(blobTableSize),
+		ChunkSize:        bw.opt.ChunkSize,
+		ChunkTableOffset: chunkTableOffset,
+		ChunkTableSize:   chunkTableSize,
+	}); err != nil {
+		return nil, errors.Wrap(err, "write extended super block")
+	}
+
+	return bw.buf, nil
+}
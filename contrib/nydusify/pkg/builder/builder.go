@@ -0,0 +1,196 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package builder implements a native RAFS v6 builder, which generates
+// the bootstrap and data blob from a directory without the nydus-image
+// binary. It supports a subset of `nydus-image create`: parent bootstrap,
+// chunk dict and prefetch are not supported. It's only used by the packer
+// of `nydusify build`, the conversion still requires nydus-image to build
+// layers with inlined bootstrap and merge the layer bootstraps.
+package builder
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CompressorNone = "none"
+	CompressorZstd = "zstd"
+
+	DigesterBlake3 = "blake3"
+	DigesterSHA256 = "sha256"
+
+	DefaultChunkSize = 0x100000
+
+	minChunkSize = 0x1000
+	maxChunkSize = 0x1000000
+)
+
+type Option struct {
+	RootfsPath    string
+	BootstrapPath string
+	BlobPath      string
+	Compressor    string
+	Digester      string
+	ChunkSize     uint32
+	// Skip the OCI whiteout files if WhiteoutSpec is "oci".
+	WhiteoutSpec string
+}
+
+type Result struct {
+	// BlobID is empty if no data blob is generated.
+	BlobID   string
+	BlobSize uint64
+}
+
+func (opt *Option) validate() error {
+	if opt.RootfsPath == "" || opt.BootstrapPath == "" || opt.BlobPath == "" {
+		return errors.New("rootfs, bootstrap and blob path must be specified")
+	}
+	switch opt.Compressor {
+	case "":
+		opt.Compressor = CompressorZstd
+	case CompressorNone, CompressorZstd:
+	default:
+		return errors.Errorf("unsupported compressor %s", opt.Compressor)
+	}
+	switch opt.Digester {
+	case "":
+		opt.Digester = DigesterBlake3
+	case DigesterBlake3, DigesterSHA256:
+	default:
+		return errors.Errorf("unsupported digester %s", opt.Digester)
+	}
+	if opt.ChunkSize == 0 {
+		opt.ChunkSize = DefaultChunkSize
+	}
+	if opt.ChunkSize < minChunkSize || opt.ChunkSize > maxChunkSize || opt.ChunkSize&(opt.ChunkSize-1) != 0 {
+		return errors.Errorf("invalid chunk size 0x%x, must be power of two between 0x%x and 0x%x",
+			opt.ChunkSize, minChunkSize, maxChunkSize)
+	}
+	return nil
+}
+
+// Build generates RAFS v6 bootstrap and data blob from the rootfs directory.
+func Build(opt Option) (*Result, error) {
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+
+	inodes, err := loadTree(opt.RootfsPath, opt.WhiteoutSpec == "oci")
+	if err != nil {
+		return nil, errors.Wrap(err, "load source directory")
+	}
+
+	blobFile, err := os.Create(opt.BlobPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "create blob file")
+	}
+	defer blobFile.Close()
+
+	blob, err := newBlobWriter(&opt, blobFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range inodes {
+		if node.isReg() {
+			if err := blob.writeFile(node); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := blob.finalize(); err != nil {
+		return nil, errors.Wrap(err, "finalize blob")
+	}
+	if err := blobFile.Close(); err != nil {
+		return nil, errors.Wrap(err, "close blob file")
+	}
+
+	bw := &bootstrapWriter{opt: &opt, inodes: inodes, blob: blob}
+	bootstrap, err := bw.dump()
+	if err != nil {
+		return nil, errors.Wrap(err, "dump bootstrap")
+	}
+	if err := os.WriteFile(opt.BootstrapPath, bootstrap, 0644); err != nil {
+		return nil, errors.Wrap(err, "write bootstrap")
+	}
+
+	logrus.Debugf("built bootstrap %s with %d inodes and %d chunks", opt.BootstrapPath, len(inodes), len(blob.chunks))
+
+	return &Result{
+		BlobID:   blob.blobID,
+		BlobSize: blob.blobSize,
+	}, nil
+}
+
+// Builder is a drop-in replacement of `build.Builder` for `nydus-image create`.
+type Builder struct{}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+func (builder *Builder) Run(option build.BuilderOption) error {
+	if option.ParentBootstrapPath != "" {
+		return errors.New("parent bootstrap is not supported by native builder")
+	}
+	if option.ChunkDict != "" {
+		return errors.New("chunk dict is not supported by native builder")
+	}
+	if option.SourceType != "" {
+		return errors.Errorf("source type %s is not supported by native builder", option.SourceType)
+	}
+	if strings.TrimSpace(option.PrefetchPatterns) != "" {
+		return errors.New("prefetch is not supported by native builder")
+	}
+	if option.FsVersion != "" && option.FsVersion != "6" {
+		return errors.Errorf("fs version %s is not supported by native builder", option.FsVersion)
+	}
+
+	var chunkSize uint64
+	if option.ChunkSize != "" {
+		var err error
+		chunkSize, err = strconv.ParseUint(option.ChunkSize, 0, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid chunk size %s", option.ChunkSize)
+		}
+	}
+
+	result, err := Build(Option{
+		RootfsPath:    option.RootfsPath,
+		BootstrapPath: option.BootstrapPath,
+		BlobPath:      option.BlobPath,
+		Compressor:    option.Compressor,
+		ChunkSize:     uint32(chunkSize),
+		WhiteoutSpec:  option.WhiteoutSpec,
+	})
+	if err != nil {
+		return err
+	}
+
+	if option.OutputJSONPath != "" {
+		output := struct {
+			Blobs []string `json:"blobs"`
+		}{Blobs: []string{}}
+		if result.BlobID != "" {
+			output.Blobs = append(output.Blobs, result.BlobID)
+		}
+		data, err := json.Marshal(output)
+		if err != nil {
+			return errors.Wrap(err, "marshal output json")
+		}
+		if err := os.WriteFile(option.OutputJSONPath, data, 0644); err != nil {
+			return errors.Wrap(err, "write output json")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,350 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/xattr"
+	"github.com/stretchr/testify/require"
+)
+
+type testImage struct {
+	t         *testing.T
+	bootstrap []byte
+	blob      []byte
+	sb        rafs.SuperBlock
+	ext       rafs.ExtSuperBlock
+	blobEntry rafs.BlobEntry
+}
+
+func (img *testImage) read(offset uint64, data interface{}) {
+	err := binary.Read(bytes.NewReader(img.bootstrap[offset:]), binary.LittleEndian, data)
+	require.NoError(img.t, err)
+}
+
+func (img *testImage) inodeOffset(nid uint64) uint64 {
+	return uint64(img.sb.MetaBlkAddr)*blockSize + nid*rafs.InodeSlotSize
+}
+
+func (img *testImage) inode(nid uint64) rafs.ExtendedInode {
+	var ino rafs.ExtendedInode
+	img.read(img.inodeOffset(nid), &ino)
+	require.Equal(img.t, uint16(rafs.ErofsInodeLayoutExtended), ino.Format&1)
+	return ino
+}
+
+func (img *testImage) inodeSize(ino rafs.ExtendedInode) uint64 {
+	size := uint64(rafs.ExtendedInodeSize)
+	if ino.XattrICount > 0 {
+		size += rafs.XattrIbodyHeaderSize + uint64(ino.XattrICount-1)*rafs.XattrEntrySize
+	}
+	return size
+}
+
+// data reads the data of directory or symlink inode.
+func (img *testImage) data(nid uint64) []byte {
+	ino := img.inode(nid)
+	layout := ino.Format >> 1
+	offset := uint64(ino.U) * blockSize
+	switch layout {
+	case rafs.ErofsInodeFlatPlain:
+		return img.bootstrap[offset : offset+ino.Size]
+	case rafs.ErofsInodeFlatInline:
+		full := roundDown(ino.Size, blockSize)
+		data := append([]byte{}, img.bootstrap[offset:offset+full]...)
+		tail := img.inodeOffset(nid) + img.inodeSize(ino)
+		return append(data, img.bootstrap[tail:tail+ino.Size-full]...)
+	}
+	img.t.Fatalf("unexpected layout %d", layout)
+	return nil
+}
+
+func (img *testImage) readDir(nid uint64) map[string]rafs.Dirent {
+	data := img.data(nid)
+	entries := make(map[string]rafs.Dirent)
+	var names []string
+	for blk := uint64(0); blk < uint64(len(data)); blk += blockSize {
+		block := data[blk:min(blk+blockSize, uint64(len(data)))]
+		var first rafs.Dirent
+		require.NoError(img.t, binary.Read(bytes.NewReader(block), binary.LittleEndian, &first))
+		count := int(first.NameOff) / rafs.DirentSize
+		for i := 0; i < count; i++ {
+			var d rafs.Dirent
+			require.NoError(img.t, binary.Read(bytes.NewReader(block[i*rafs.DirentSize:]), binary.LittleEndian, &d))
+			end := len(block)
+			if i+1 < count {
+				end = int(binary.LittleEndian.Uint16(block[(i+1)*rafs.DirentSize+8:]))
+			}
+			name := strings.TrimRight(string(block[d.NameOff:end]), "\x00")
+			names = append(names, name)
+			entries[name] = d
+		}
+	}
+	for i := 1; i < len(names); i++ {
+		require.Less(img.t, names[i-1], names[i])
+	}
+	return entries
+}
+
+func (img *testImage) xattrs(nid uint64) map[string]string {
+	ino := img.inode(nid)
+	pairs := make(map[string]string)
+	offset := img.inodeOffset(nid) + rafs.ExtendedInodeSize + rafs.XattrIbodyHeaderSize
+	end := img.inodeOffset(nid) + img.inodeSize(ino)
+	for offset < end {
+		nameLen := uint64(img.bootstrap[offset])
+		index := img.bootstrap[offset+1]
+		valueSize := uint64(binary.LittleEndian.Uint16(img.bootstrap[offset+2:]))
+		offset += rafs.XattrEntrySize
+		var prefix string
+		for _, p := range xattrPrefixes {
+			if p.index == index {
+				prefix = p.prefix
+			}
+		}
+		name := prefix + string(img.bootstrap[offset:offset+nameLen])
+		pairs[name] = string(img.bootstrap[offset+nameLen : offset+nameLen+valueSize])
+		offset = roundUp(offset+nameLen+valueSize, rafs.XattrEntrySize)
+	}
+	return pairs
+}
+
+func (img *testImage) readFile(nid uint64) []byte {
+	ino := img.inode(nid)
+	require.Equal(img.t, uint16(rafs.ErofsInodeChunkBased), ino.Format>>1)
+	chunkSize := uint64(1) << (ino.U&0x1f + erofsBlockBits)
+	require.Equal(img.t, uint64(img.ext.ChunkSize), chunkSize)
+
+	var ciTable []byte
+	entry := img.blobEntry
+	ci := img.blob[entry.CiOffset : entry.CiOffset+entry.CiCompressedSize]
+	if entry.CiCompressor == rafs.CompressorZstd {
+		decoder, err := zstd.NewReader(nil)
+		require.NoError(img.t, err)
+		ciTable, err = decoder.DecodeAll(ci, nil)
+		require.NoError(img.t, err)
+	} else {
+		ciTable = ci
+	}
+	require.Equal(img.t, entry.CiUncompressedSize, uint64(len(ciTable)))
+
+	var data []byte
+	count := (ino.Size + chunkSize - 1) / chunkSize
+	offset := roundUp(img.inodeOffset(nid)+img.inodeSize(ino), rafs.ChunkAddrSize)
+	for i := uint64(0); i < count; i++ {
+		var addr rafs.ChunkAddr
+		img.read(offset+i*rafs.ChunkAddrSize, &addr)
+		require.Equal(img.t, uint16(1), addr.BlobAddrHi&0xff)
+		index := uint32(addr.BlobAddrLo) | uint32(addr.BlobAddrHi&0xff00)<<8
+
+		var info chunkInfoV1
+		err := binary.Read(bytes.NewReader(ciTable[index*chunkInfoV1Size:]), binary.LittleEndian, &info)
+		require.NoError(img.t, err)
+		compOffset := info.CompInfo & 0xff_ffff_ffff
+		compSize := (info.CompInfo>>44 | (info.CompInfo>>20)&0xf00000) + 1
+		uncompOffset := info.UncompInfo & 0xfff_ffff_f000
+		uncompSize := (info.UncompInfo>>44 | (info.UncompInfo<<12)&0xf00000) + 1
+		require.Equal(img.t, uint64(addr.BlkAddr)*blockSize, uncompOffset)
+
+		raw := img.blob[compOffset : compOffset+compSize]
+		if compSize != uncompSize && entry.CompressionAlgo == rafs.CompressorZstd {
+			decoder, err := zstd.NewReader(nil)
+			require.NoError(img.t, err)
+			raw, err = decoder.DecodeAll(raw, nil)
+			require.NoError(img.t, err)
+		}
+		require.Equal(img.t, uncompSize, uint64(len(raw)))
+		data = append(data, raw...)
+	}
+
+	return data
+}
+
+func loadTestImage(t *testing.T, bootstrapPath, blobPath string) *testImage {
+	bootstrap, err := os.ReadFile(bootstrapPath)
+	require.NoError(t, err)
+	require.Zero(t, len(bootstrap)%blockSize)
+	img := &testImage{t: t, bootstrap: bootstrap}
+	img.read(rafs.SuperOffset, &img.sb)
+	img.read(rafs.SuperOffset+rafs.SuperBlockSize, &img.ext)
+	require.Equal(t, uint32(rafs.ErofsSuperMagic), img.sb.Magic)
+	require.Equal(t, uint8(erofsBlockBits), img.sb.BlkSzBits)
+	require.Equal(t, uint32(rafs.ErofsFeatureIncompatChunkedFile|rafs.ErofsFeatureIncompatDeviceTable), img.sb.FeatureIncompat)
+	require.Equal(t, uint16(rafs.DevTableOffset/rafs.DeviceSlotSize), img.sb.DevtSlotOff)
+
+	if blobPath != "" {
+		img.blob, err = os.ReadFile(blobPath)
+		require.NoError(t, err)
+		require.Equal(t, uint16(1), img.sb.ExtraDevices)
+		require.Equal(t, uint32(rafs.BlobEntrySize), img.ext.BlobTableSize)
+		img.read(img.ext.BlobTableOffset, &img.blobEntry)
+
+		var slot rafs.DeviceSlot
+		img.read(rafs.DevTableOffset, &slot)
+		require.Equal(t, img.blobEntry.BlobID, slot.BlobID)
+		require.Equal(t, img.sb.Blocks, slot.Blocks)
+		require.Zero(t, uint64(slot.MappedBlkAddr)*blockSize%blockSegmentAlignment)
+		require.GreaterOrEqual(t, uint64(slot.MappedBlkAddr)*blockSize, uint64(len(bootstrap)))
+
+		digest := sha256.Sum256(img.blob)
+		require.Equal(t, hex.EncodeToString(digest[:]), strings.TrimRight(string(slot.BlobID[:]), "\x00"))
+		require.Equal(t, uint64(len(img.blob)), img.blobEntry.CompressedSize)
+
+		var header blobMetaHeader
+		require.NoError(t, binary.Read(bytes.NewReader(img.blob[len(img.blob)-blobMetaHeaderSize:]), binary.LittleEndian, &header))
+		require.Equal(t, uint32(blobMetaMagic), header.Magic)
+		require.Equal(t, uint32(blobMetaMagic), header.Magic2)
+		require.Equal(t, img.blobEntry.ChunkCount, header.CiEntries)
+		require.Equal(t, uint64(img.blobEntry.ChunkCount)*rafs.ChunkInfoSize, img.ext.ChunkTableSize)
+	} else {
+		require.Zero(t, img.sb.ExtraDevices)
+		require.Zero(t, img.ext.BlobTableSize)
+	}
+
+	return img
+}
+
+func TestBuild(t *testing.T) {
+	rootfs := t.TempDir()
+	output := t.TempDir()
+
+	big := make([]byte, 0x28000)
+	for i := range big {
+		big[i] = byte(i/1000%251) ^ byte(i%7)
+	}
+	small := []byte("hello nydus")
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "dir/sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "big"), big, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "dir/small"), small, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "dir/dup"), small, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "empty"), nil, 0644))
+	require.NoError(t, os.Link(filepath.Join(rootfs, "big"), filepath.Join(rootfs, "dir/sub/link")))
+	require.NoError(t, os.Symlink("../big", filepath.Join(rootfs, "dir/symlink")))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, ".wh.removed"), nil, 0644))
+	hasXattr := xattr.Set(filepath.Join(rootfs, "dir/small"), "user.nydus", []byte("value")) == nil
+	// Enough entries to spread the dirents across multiple blocks.
+	for i := 0; i < 300; i++ {
+		name := filepath.Join(rootfs, "dir/sub", strings.Repeat("f", 10)+string(rune('a'+i%26))+string(rune('a'+i/26)))
+		require.NoError(t, os.WriteFile(name, nil, 0644))
+	}
+
+	for _, compressor := range []string{CompressorZstd, CompressorNone} {
+		t.Run(compressor, func(t *testing.T) {
+			bootstrapPath := filepath.Join(output, compressor+".boot")
+			blobPath := filepath.Join(output, compressor+".blob")
+			result, err := Build(Option{
+				RootfsPath:    rootfs,
+				BootstrapPath: bootstrapPath,
+				BlobPath:      blobPath,
+				Compressor:    compressor,
+				ChunkSize:     0x10000,
+				WhiteoutSpec:  "oci",
+			})
+			require.NoError(t, err)
+
+			img := loadTestImage(t, bootstrapPath, blobPath)
+			require.Equal(t, result.BlobID, strings.TrimRight(string(img.blobEntry.BlobID[:]), "\x00"))
+			require.Equal(t, uint64(len(img.blob)), result.BlobSize)
+			// big (3 chunks) and small, the duplicated chunks are stored once.
+			require.Equal(t, uint32(4), img.blobEntry.ChunkCount)
+			require.Equal(t, uint16(128), img.sb.RootNid)
+
+			root := img.readDir(uint64(img.sb.RootNid))
+			require.Len(t, root, 5)
+			require.NotContains(t, root, ".wh.removed")
+			require.Equal(t, uint64(img.sb.RootNid), root[".."].Nid)
+			require.Equal(t, uint8(rafs.FileTypeRegular), root["big"].FileType)
+			require.Equal(t, big, img.readFile(root["big"].Nid))
+			require.Equal(t, uint32(2), img.inode(root["big"].Nid).Nlink)
+			require.Zero(t, img.inode(root["empty"].Nid).Size)
+			require.Equal(t, uint32(3), img.inode(uint64(img.sb.RootNid)).Nlink)
+
+			dir := img.readDir(root["dir"].Nid)
+			require.Equal(t, root["dir"].Nid, dir["."].Nid)
+			require.Equal(t, uint64(img.sb.RootNid), dir[".."].Nid)
+			require.Equal(t, small, img.readFile(dir["small"].Nid))
+			require.Equal(t, small, img.readFile(dir["dup"].Nid))
+			require.Equal(t, uint16(syscall.S_IFREG|0600), img.inode(dir["small"].Nid).Mode)
+			if hasXattr {
+				require.NotZero(t, img.ext.Flags&rafs.FlagHasXattr)
+				require.Equal(t, map[string]string{"user.nydus": "value"}, img.xattrs(dir["small"].Nid))
+			}
+			require.Equal(t, uint8(rafs.FileTypeSymlink), dir["symlink"].FileType)
+			require.Equal(t, []byte("../big"), img.data(dir["symlink"].Nid))
+
+			sub := img.readDir(dir["sub"].Nid)
+			require.Len(t, sub, 303)
+			require.Greater(t, img.inode(dir["sub"].Nid).Size, uint64(blockSize))
+			require.Equal(t, root["big"].Nid, sub["link"].Nid)
+		})
+	}
+}
+
+func TestBuildEmpty(t *testing.T) {
+	output := t.TempDir()
+	bootstrapPath := filepath.Join(output, "bootstrap")
+	outputJSONPath := filepath.Join(output, "output.json")
+	err := New().Run(build.BuilderOption{
+		RootfsPath:     t.TempDir(),
+		BootstrapPath:  bootstrapPath,
+		BlobPath:       filepath.Join(output, "blob"),
+		OutputJSONPath: outputJSONPath,
+		FsVersion:      "6",
+		ChunkSize:      "0x100000",
+	})
+	require.NoError(t, err)
+
+	img := loadTestImage(t, bootstrapPath, "")
+	root := img.readDir(uint64(img.sb.RootNid))
+	require.Len(t, root, 2)
+
+	data, err := os.ReadFile(outputJSONPath)
+	require.NoError(t, err)
+	var blobs struct {
+		Blobs []string `json:"blobs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &blobs))
+	require.Empty(t, blobs.Blobs)
+}
+
+func TestBuilderRun(t *testing.T) {
+	err := New().Run(build.BuilderOption{FsVersion: "5"})
+	require.Error(t, err)
+
+	err = New().Run(build.BuilderOption{ChunkDict: "bootstrap=/tmp/dict"})
+	require.Error(t, err)
+
+	err = New().Run(build.BuilderOption{ParentBootstrapPath: "/tmp/parent"})
+	require.Error(t, err)
+
+	err = New().Run(build.BuilderOption{
+		RootfsPath:    t.TempDir(),
+		BootstrapPath: "bootstrap",
+		BlobPath:      "blob",
+		ChunkSize:     "0x1001",
+	})
+	require.Error(t, err)
+
+	err = New().Run(build.BuilderOption{
+		RootfsPath:    t.TempDir(),
+		BootstrapPath: "bootstrap",
+		BlobPath:      "blob",
+		Compressor:    "lz4_block",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// The on-disk structures of blob meta, they must be kept in sync with
+// `storage/src/meta/mod.rs`, the structures of bootstrap are defined in
+// package rafs. All fields are encoded in little-endian.
+
+const (
+	blockSize = 4096
+
+	chunkInfoV1Size    = 16
+	blobMetaHeaderSize = 4096
+
+	// The mapped block address of data blobs is aligned to 512KB.
+	blockSegmentAlignment = 0x80000
+
+	erofsBlockBits = 12
+
+	blobFeatureAligned   = 0x00000001
+	blobFeatureCapTarToc = 0x40000000
+
+	blobMetaMagic = 0xb10bb10b
+)
+
+// Algorithm numbers of digester used by blob table.
+const (
+	digesterBlake3 = 0
+	digesterSHA256 = 1
+)
+
+func newChunkAddr(blobIndex, ciIndex, blkAddr uint32) rafs.ChunkAddr {
+	// The device id 0 is the bootstrap, so blob index is bumped by 1.
+	return rafs.ChunkAddr{
+		BlobAddrLo: uint16(ciIndex),
+		BlobAddrHi: uint16((ciIndex>>8)&0xff00) | uint16(blobIndex+1),
+		BlkAddr:    blkAddr,
+	}
+}
+
+// chunkInfoV1 is the entry of compression context table in data blob.
+type chunkInfoV1 struct {
+	// 20bits: size (low), 32bits: offset, 4bits: size (high), 8bits reserved
+	UncompInfo uint64
+	// 20bits: size (low), 4bits: size (high), offset: 40bits
+	CompInfo uint64
+}
+
+func newChunkInfoV1(compOffset uint64, compSize uint32, uncompOffset uint64, uncompSize uint32) chunkInfoV1 {
+	cs := uint64(compSize - 1)
+	us := uint64(uncompSize - 1)
+	return chunkInfoV1{
+		UncompInfo: (us&0x0fffff)<<44 | uncompOffset&0xfff_ffff_f000 | (us&0xf00000)>>12,
+		CompInfo:   (cs&0x0fffff)<<44 | (cs&0xf00000)<<20 | compOffset&0xff_ffff_ffff,
+	}
+}
+
+type blobMetaHeader struct {
+	Magic              uint32
+	Features           uint32
+	CiCompressor       uint32
+	CiEntries          uint32
+	CiOffset           uint64
+	CiCompressedSize   uint64
+	CiUncompressedSize uint64
+	CiZranOffset       uint64
+	CiZranSize         uint64
+	CiZranCount        uint32
+	Reserved           [blobMetaHeaderSize - 64]byte
+	Magic2             uint32
+}
+
+func roundUp(v, align uint64) uint64 {
+	return (v + align - 1) / align * align
+}
+
+func roundDown(v, align uint64) uint64 {
+	return v / align * align
+}
@@ -0,0 +1,300 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+const whiteoutPrefix = ".wh."
+
+// The xattr name prefixes which can be stored in RAFS v6 inode, the value is
+// the EROFS xattr name index.
+var xattrPrefixes = []struct {
+	prefix string
+	index  uint8
+}{
+	{"user.", 1},
+	{"system.posix_acl_access", 2},
+	{"system.posix_acl_default", 3},
+	{"trusted.", 4},
+	{"security.", 6},
+}
+
+type xattrPair struct {
+	index uint8
+	// name is the xattr name without prefix.
+	name  string
+	value []byte
+}
+
+type dirEntry struct {
+	name  string
+	inode *inode
+}
+
+// inode is a file in source directory, the hardlinks of a file share the
+// same inode.
+type inode struct {
+	path    string
+	mode    uint32
+	uid     uint32
+	gid     uint32
+	rdev    uint32
+	mtime   uint64
+	mtimeNs uint32
+	size    uint64
+	nlink   uint32
+	ino     uint32
+	symlink string
+	xattrs  []xattrPair
+	// children of directory, sorted by name.
+	children []dirEntry
+	parent   *inode
+	chunks   []*chunk
+
+	// offset of the inode in bootstrap.
+	offset uint64
+	// dataOffset is the offset of dirents or symlink blocks in bootstrap,
+	// not including the tail data inlined after inode.
+	dataOffset uint64
+	layout     uint16
+	// dirents of directory, split by block.
+	direntBlocks [][]dirEntry
+}
+
+func (i *inode) isDir() bool {
+	return i.mode&syscall.S_IFMT == syscall.S_IFDIR
+}
+
+func (i *inode) isReg() bool {
+	return i.mode&syscall.S_IFMT == syscall.S_IFREG
+}
+
+func (i *inode) isSymlink() bool {
+	return i.mode&syscall.S_IFMT == syscall.S_IFLNK
+}
+
+func (i *inode) xattrSize() uint64 {
+	if len(i.xattrs) == 0 {
+		return 0
+	}
+	size := uint64(rafs.XattrIbodyHeaderSize)
+	for _, pair := range i.xattrs {
+		size = roundUp(size+rafs.XattrEntrySize+uint64(len(pair.name)+len(pair.value)), rafs.XattrEntrySize)
+	}
+	return size
+}
+
+// inodeSize returns the size of on-disk inode including inline xattrs.
+func (i *inode) inodeSize() uint64 {
+	return rafs.ExtendedInodeSize + i.xattrSize()
+}
+
+func fileType(mode uint32) uint8 {
+	switch mode & syscall.S_IFMT {
+	case syscall.S_IFREG:
+		return rafs.FileTypeRegular
+	case syscall.S_IFDIR:
+		return rafs.FileTypeDir
+	case syscall.S_IFCHR:
+		return rafs.FileTypeChrdev
+	case syscall.S_IFBLK:
+		return rafs.FileTypeBlkdev
+	case syscall.S_IFIFO:
+		return rafs.FileTypeFifo
+	case syscall.S_IFSOCK:
+		return rafs.FileTypeSock
+	case syscall.S_IFLNK:
+		return rafs.FileTypeSymlink
+	default:
+		return rafs.FileTypeUnknown
+	}
+}
+
+type treeBuilder struct {
+	skipWhiteout bool
+	// hardlinks maps (dev, ino) of the source files to the inodes.
+	hardlinks map[[2]uint64]*inode
+}
+
+// loadTree scans the source directory, returns the inodes in BFS order, the
+// first one is root.
+func loadTree(root string, skipWhiteout bool) ([]*inode, error) {
+	tb := &treeBuilder{
+		skipWhiteout: skipWhiteout,
+		hardlinks:    make(map[[2]uint64]*inode),
+	}
+
+	rootInode, err := tb.newInode(root)
+	if err != nil {
+		return nil, err
+	}
+	if !rootInode.isDir() {
+		return nil, errors.Errorf("%s is not a directory", root)
+	}
+	rootInode.parent = rootInode
+	rootInode.ino = 1
+	// The ".." of root refers to itself.
+	rootInode.nlink++
+
+	inodes := []*inode{rootInode}
+	for idx := 0; idx < len(inodes); idx++ {
+		dir := inodes[idx]
+		if !dir.isDir() {
+			continue
+		}
+		if err := tb.loadChildren(dir); err != nil {
+			return nil, err
+		}
+		for _, child := range dir.children {
+			// The hardlinks are only appended once.
+			if child.inode.ino == 0 {
+				child.inode.ino = uint32(len(inodes) + 1)
+				inodes = append(inodes, child.inode)
+			}
+		}
+	}
+
+	return inodes, nil
+}
+
+func (tb *treeBuilder) loadChildren(dir *inode) error {
+	entries, err := os.ReadDir(dir.path)
+	if err != nil {
+		return errors.Wrapf(err, "read directory %s", dir.path)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if tb.skipWhiteout && strings.HasPrefix(name, whiteoutPrefix) {
+			logrus.Debugf("skip whiteout file %s", filepath.Join(dir.path, name))
+			continue
+		}
+		child, err := tb.newInode(filepath.Join(dir.path, name))
+		if err != nil {
+			return err
+		}
+		if child.isDir() {
+			child.parent = dir
+			dir.nlink++
+		}
+		child.nlink++
+		dir.children = append(dir.children, dirEntry{name: name, inode: child})
+	}
+	sort.Slice(dir.children, func(i, j int) bool {
+		return dir.children[i].name < dir.children[j].name
+	})
+
+	return nil
+}
+
+func (tb *treeBuilder) newInode(path string) (*inode, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(path, &stat); err != nil {
+		return nil, errors.Wrapf(err, "lstat %s", path)
+	}
+
+	mode := uint32(stat.Mode)
+	key := [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}
+	if mode&syscall.S_IFMT != syscall.S_IFDIR && stat.Nlink > 1 {
+		if node, ok := tb.hardlinks[key]; ok {
+			return node, nil
+		}
+	}
+
+	node := &inode{
+		path:    path,
+		mode:    mode,
+		uid:     stat.Uid,
+		gid:     stat.Gid,
+		mtime:   uint64(stat.Mtim.Sec),
+		mtimeNs: uint32(stat.Mtim.Nsec),
+	}
+	switch mode & syscall.S_IFMT {
+	case syscall.S_IFREG:
+		node.size = uint64(stat.Size)
+	case syscall.S_IFDIR:
+		// For "." and the entry in parent directory.
+		node.nlink = 1
+	case syscall.S_IFLNK:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read link %s", path)
+		}
+		node.symlink = target
+		node.size = uint64(len(target))
+	case syscall.S_IFCHR, syscall.S_IFBLK:
+		node.rdev = uint32(stat.Rdev)
+	}
+
+	xattrs, err := loadXattrs(path)
+	if err != nil {
+		return nil, err
+	}
+	node.xattrs = xattrs
+
+	if mode&syscall.S_IFMT != syscall.S_IFDIR && stat.Nlink > 1 {
+		tb.hardlinks[key] = node
+	}
+
+	return node, nil
+}
+
+func loadXattrs(path string) ([]xattrPair, error) {
+	names, err := xattr.LList(path)
+	if err != nil {
+		if isXattrUnsupported(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "list xattrs of %s", path)
+	}
+	sort.Strings(names)
+
+	var pairs []xattrPair
+	for _, name := range names {
+		index, suffix, ok := matchXattrPrefix(name)
+		if !ok {
+			logrus.Warnf("skip unsupported xattr %s of %s", name, path)
+			continue
+		}
+		value, err := xattr.LGet(path, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get xattr %s of %s", name, path)
+		}
+		if len(suffix) > 0xff || len(value) > 0xffff {
+			return nil, errors.Errorf("xattr %s of %s is too big", name, path)
+		}
+		pairs = append(pairs, xattrPair{index: index, name: suffix, value: value})
+	}
+
+	return pairs, nil
+}
+
+func matchXattrPrefix(name string) (uint8, string, bool) {
+	for _, prefix := range xattrPrefixes {
+		if strings.HasPrefix(name, prefix.prefix) {
+			return prefix.index, strings.TrimPrefix(name, prefix.prefix), true
+		}
+	}
+	return 0, "", false
+}
+
+func isXattrUnsupported(err error) bool {
+	if xerr, ok := err.(*xattr.Error); ok {
+		return xerr.Err == syscall.ENOTSUP || xerr.Err == syscall.EOPNOTSUPP
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// DirScheme is the prefix of the build cache in local directory, e.g.
+// "dir:///var/cache/nydusify".
+const DirScheme = "dir://"
+
+// IsDir checks if the build cache ref is a local directory.
+func IsDir(ref string) bool {
+	return strings.HasPrefix(ref, DirScheme)
+}
+
+// dirStore stores the entries and converted layers in a local directory,
+// which can be shared by the conversions on the same host:
+//
+//	<root>/entries/<algorithm>/<encoded key>.json
+//	<root>/blobs/<algorithm>/<encoded digest>
+//
+// The files are written into temp files and renamed, so the concurrent
+// conversions never read a partial file.
+type dirStore struct {
+	root string
+}
+
+func newDirStore(ref string) (*dirStore, error) {
+	root := strings.TrimPrefix(ref, DirScheme)
+	if root == "" || !filepath.IsAbs(root) {
+		return nil, errors.Errorf("invalid cache directory %s, should be an absolute path like dir:///var/cache/nydusify", ref)
+	}
+	for _, dir := range []string{"entries", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, errors.Wrap(err, "create cache directory")
+		}
+	}
+	return &dirStore{root: root}, nil
+}
+
+func (store *dirStore) entryPath(key digest.Digest) string {
+	return filepath.Join(store.root, "entries", key.Algorithm().String(), key.Encoded()+".json")
+}
+
+func (store *dirStore) blobPath(dgst digest.Digest) string {
+	return filepath.Join(store.root, "blobs", dgst.Algorithm().String(), dgst.Encoded())
+}
+
+func (store *dirStore) Get(_ context.Context, key digest.Digest) (*Entry, error) {
+	if err := key.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid cache key")
+	}
+	data, err := os.ReadFile(store.entryPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "read cache entry")
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, errors.Wrapf(err, "invalid cache entry %s", key)
+	}
+	if entry.Key != key {
+		return nil, errors.Errorf("unmatched cache entry key %s, expected %s", entry.Key, key)
+	}
+	if err := entry.Layer.Digest.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid cache entry %s", key)
+	}
+	// The blob may be removed manually to reclaim the space.
+	if _, err := os.Stat(store.blobPath(entry.Layer.Digest)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "stat cached layer")
+	}
+	return &entry, nil
+}
+
+func (store *dirStore) Open(_ context.Context, entry *Entry) (io.ReadCloser, error) {
+	file, err := os.Open(store.blobPath(entry.Layer.Digest))
+	if err != nil {
+		return nil, errors.Wrap(err, "open cached layer")
+	}
+	return file, nil
+}
+
+func (store *dirStore) Put(_ context.Context, entry *Entry, reader io.Reader) error {
+	if err := entry.Layer.Digest.Validate(); err != nil {
+		return errors.Wrap(err, "invalid cached layer digest")
+	}
+	blobPath := store.blobPath(entry.Layer.Digest)
+	if _, err := os.Stat(blobPath); err != nil {
+		if err := writeFile(blobPath, func(writer io.Writer) error {
+			verifier := entry.Layer.Digest.Verifier()
+			n, err := io.Copy(io.MultiWriter(writer, verifier), reader)
+			if err != nil {
+				return err
+			}
+			if n != entry.Layer.Size || !verifier.Verified() {
+				return errors.Errorf("digest mismatch of cached layer %s", entry.Layer.Digest)
+			}
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "write cached layer")
+		}
+	}
+
+	stored := *entry
+	stored.touch(time.Now())
+	return store.writeEntry(&stored)
+}
+
+func (store *dirStore) writeEntry(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshal cache entry")
+	}
+	return errors.Wrap(writeFile(store.entryPath(entry.Key), func(writer io.Writer) error {
+		_, err := writer.Write(data)
+		return err
+	}), "write cache entry")
+}
+
+func (store *dirStore) Touch(_ context.Context, entry *Entry) error {
+	entry.Hits++
+	entry.touch(time.Now())
+	return store.writeEntry(entry)
+}
+
+func (store *dirStore) List(ctx context.Context) ([]*Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(store.root, "entries", "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	entries := []*Entry{}
+	for _, path := range paths {
+		key := digest.NewDigestFromEncoded(digest.Algorithm(filepath.Base(filepath.Dir(path))), strings.TrimSuffix(filepath.Base(path), ".json"))
+		entry, err := store.Get(ctx, key)
+		if err != nil {
+			// The entry may be deleted by the concurrent pruning.
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (store *dirStore) Delete(ctx context.Context, key digest.Digest) error {
+	entry, err := store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := os.Remove(store.entryPath(key)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove cache entry")
+	}
+
+	entries, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range entries {
+		if other.Layer.Digest == entry.Layer.Digest {
+			return nil
+		}
+	}
+	if err := os.Remove(store.blobPath(entry.Layer.Digest)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove cached layer")
+	}
+	return nil
+}
+
+// writeFile writes the file by write function into a temp file, and renames
+// it to path once succeeded.
+func writeFile(path string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	err = write(tmpFile)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
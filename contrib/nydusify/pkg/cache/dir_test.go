@@ -0,0 +1,71 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := newDirStore("dir://relative/path")
+	assert.NotNil(t, err)
+
+	store, err := newDirStore(DirScheme + t.TempDir())
+	assert.Nil(t, err)
+
+	data := "nydus blob"
+	layer := ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    digest.FromString(data),
+		Size:      int64(len(data)),
+	}
+	entry := NewEntry(digest.FromString("layer"), map[string]string{"fs_version": "6"}, layer)
+
+	_, err = store.Get(ctx, entry.Key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// The corrupted layer is rejected.
+	err = store.Put(ctx, entry, strings.NewReader("corrupted"))
+	assert.NotNil(t, err)
+	_, err = store.Get(ctx, entry.Key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Nil(t, store.Put(ctx, entry, strings.NewReader(data)))
+	got, err := store.Get(ctx, entry.Key)
+	assert.Nil(t, err)
+	assert.Equal(t, entry.Key, got.Key)
+	assert.Equal(t, entry.Layer, got.Layer)
+	assert.False(t, got.Created.IsZero())
+
+	reader, err := store.Open(ctx, got)
+	assert.Nil(t, err)
+	defer reader.Close()
+	read, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, data, string(read))
+
+	// The layer is shared by the entries with the same converted layer.
+	other := NewEntry(digest.FromString("other"), nil, layer)
+	assert.Nil(t, store.Put(ctx, other, strings.NewReader("")))
+	_, err = store.Get(ctx, other.Key)
+	assert.Nil(t, err)
+
+	// The entry is not found once the layer is removed.
+	assert.Nil(t, os.Remove(store.blobPath(layer.Digest)))
+	_, err = store.Get(ctx, entry.Key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PolicyLRU evicts the least recently used entries first.
+	PolicyLRU = "lru"
+	// PolicyLFU evicts the least frequently used entries first, the least
+	// recently used one is evicted first if they are used equally.
+	PolicyLFU = "lfu"
+)
+
+// Policies are the supported eviction policies.
+var Policies = []string{PolicyLRU, PolicyLFU}
+
+// EvictOpt is the budget of content-addressed store, zero means unlimited.
+type EvictOpt struct {
+	Policy string
+	// MaxSize is the maximum total size of converted layers, a layer shared
+	// by multiple entries is counted once.
+	MaxSize int64
+	// MaxRecords is the maximum number of entries.
+	MaxRecords uint
+}
+
+// Stat is the usage of content-addressed store.
+type Stat struct {
+	Records uint
+	Layers  uint
+	Size    int64
+	Hits    uint64
+	// Oldest and Newest are the least and the most recent used time.
+	Oldest time.Time
+	Newest time.Time
+}
+
+// lastUsed returns the time of last use, which is the created time if the
+// entry is never hit.
+func (entry *Entry) lastUsed() time.Time {
+	if entry.LastUsed.IsZero() {
+		return entry.Created
+	}
+	return entry.LastUsed
+}
+
+// Stats returns the usage of entries.
+func Stats(entries []*Entry) Stat {
+	stat := Stat{}
+	layers := map[digest.Digest]bool{}
+	for _, entry := range entries {
+		stat.Records++
+		stat.Hits += entry.Hits
+		if !layers[entry.Layer.Digest] {
+			layers[entry.Layer.Digest] = true
+			stat.Layers++
+			stat.Size += entry.Layer.Size
+		}
+		lastUsed := entry.lastUsed()
+		if stat.Oldest.IsZero() || lastUsed.Before(stat.Oldest) {
+			stat.Oldest = lastUsed
+		}
+		if lastUsed.After(stat.Newest) {
+			stat.Newest = lastUsed
+		}
+	}
+	return stat
+}
+
+// selectEvicted returns the entries to evict by the policy until the
+// entries left are within the budget.
+func selectEvicted(entries []*Entry, opt EvictOpt) ([]*Entry, error) {
+	sorted := append([]*Entry{}, entries...)
+	switch opt.Policy {
+	case PolicyLRU, "":
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].lastUsed().Before(sorted[j].lastUsed())
+		})
+	case PolicyLFU:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].Hits != sorted[j].Hits {
+				return sorted[i].Hits < sorted[j].Hits
+			}
+			return sorted[i].lastUsed().Before(sorted[j].lastUsed())
+		})
+	default:
+		return nil, fmt.Errorf("invalid eviction policy %s, should be one of %v", opt.Policy, Policies)
+	}
+
+	refs := map[digest.Digest]int{}
+	var size int64
+	for _, entry := range sorted {
+		if refs[entry.Layer.Digest] == 0 {
+			size += entry.Layer.Size
+		}
+		refs[entry.Layer.Digest]++
+	}
+
+	records := uint(len(sorted))
+	evicted := []*Entry{}
+	for _, entry := range sorted {
+		overSize := opt.MaxSize > 0 && size > opt.MaxSize
+		overRecords := opt.MaxRecords > 0 && records > opt.MaxRecords
+		if !overSize && !overRecords {
+			break
+		}
+		evicted = append(evicted, entry)
+		records--
+		refs[entry.Layer.Digest]--
+		if refs[entry.Layer.Digest] == 0 {
+			size -= entry.Layer.Size
+		}
+	}
+	return evicted, nil
+}
+
+// Prune evicts the entries in store by the policy until the store is within
+// the budget, and returns the evicted entries.
+func Prune(ctx context.Context, store Store, opt EvictOpt) ([]*Entry, error) {
+	if opt.MaxSize <= 0 && opt.MaxRecords == 0 {
+		return nil, nil
+	}
+	entries, err := store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list cache entries")
+	}
+	evicted, err := selectEvicted(entries, opt)
+	if err != nil {
+		return nil, err
+	}
+	for idx, entry := range evicted {
+		if err := store.Delete(ctx, entry.Key); err != nil {
+			return evicted[:idx], errors.Wrapf(err, "delete cache entry %s", entry.Key)
+		}
+	}
+	return evicted, nil
+}
+
+// PrintStat prints the usage of content-addressed store.
+func PrintStat(writer io.Writer, stat Stat) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Records:\t%d\n", stat.Records)
+	fmt.Fprintf(tw, "Layers:\t%d\n", stat.Layers)
+	fmt.Fprintf(tw, "Size:\t%s\n", humanize.IBytes(uint64(stat.Size)))
+	fmt.Fprintf(tw, "Hits:\t%d\n", stat.Hits)
+	if stat.Records > 0 {
+		fmt.Fprintf(tw, "Least recently used:\t%s\n", stat.Oldest.Format(time.RFC3339))
+		fmt.Fprintf(tw, "Most recently used:\t%s\n", stat.Newest.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
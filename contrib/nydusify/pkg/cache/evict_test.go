@@ -0,0 +1,111 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeEntry(source, layer string, size int64, lastUsed time.Time, hits uint64) *Entry {
+	entry := NewEntry(digest.FromString(source), nil, ocispec.Descriptor{
+		Digest: digest.FromString(layer),
+		Size:   size,
+	})
+	entry.Created = lastUsed
+	entry.LastUsed = lastUsed
+	entry.Hits = hits
+	return entry
+}
+
+func sources(entries []*Entry) []digest.Digest {
+	digests := []digest.Digest{}
+	for _, entry := range entries {
+		digests = append(digests, entry.Source)
+	}
+	return digests
+}
+
+func TestSelectEvicted(t *testing.T) {
+	now := time.Now()
+	a := makeEntry("a", "layer-a", 100, now.Add(-3*time.Hour), 10)
+	b := makeEntry("b", "layer-b", 200, now.Add(-2*time.Hour), 1)
+	c := makeEntry("c", "layer-c", 300, now.Add(-1*time.Hour), 1)
+	entries := []*Entry{c, a, b}
+
+	evicted, err := selectEvicted(entries, EvictOpt{Policy: PolicyLRU, MaxSize: 400})
+	assert.Nil(t, err)
+	assert.Equal(t, sources([]*Entry{a, b}), sources(evicted))
+
+	evicted, err = selectEvicted(entries, EvictOpt{Policy: PolicyLFU, MaxSize: 400})
+	assert.Nil(t, err)
+	assert.Equal(t, sources([]*Entry{b}), sources(evicted))
+
+	evicted, err = selectEvicted(entries, EvictOpt{Policy: PolicyLRU, MaxRecords: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, sources([]*Entry{a}), sources(evicted))
+
+	evicted, err = selectEvicted(entries, EvictOpt{Policy: PolicyLRU, MaxSize: 600, MaxRecords: 3})
+	assert.Nil(t, err)
+	assert.Empty(t, evicted)
+
+	// The layer shared by entries is counted once.
+	d := makeEntry("d", "layer-c", 300, now, 0)
+	evicted, err = selectEvicted([]*Entry{a, b, c, d}, EvictOpt{Policy: PolicyLRU, MaxSize: 600})
+	assert.Nil(t, err)
+	assert.Empty(t, evicted)
+
+	_, err = selectEvicted(entries, EvictOpt{Policy: "fifo", MaxSize: 1})
+	assert.NotNil(t, err)
+}
+
+func TestStats(t *testing.T) {
+	now := time.Now()
+	a := makeEntry("a", "layer-a", 100, now.Add(-time.Hour), 2)
+	b := makeEntry("b", "layer-a", 100, now, 3)
+	stat := Stats([]*Entry{a, b})
+	assert.Equal(t, Stat{Records: 2, Layers: 1, Size: 100, Hits: 5, Oldest: a.LastUsed, Newest: b.LastUsed}, stat)
+}
+
+func TestPruneDirStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := newDirStore(DirScheme + t.TempDir())
+	assert.Nil(t, err)
+
+	put := func(source, data string) *Entry {
+		entry := NewEntry(digest.FromString(source), nil, ocispec.Descriptor{
+			Digest: digest.FromString(data),
+			Size:   int64(len(data)),
+		})
+		assert.Nil(t, store.Put(ctx, entry, strings.NewReader(data)))
+		got, err := store.Get(ctx, entry.Key)
+		assert.Nil(t, err)
+		return got
+	}
+	a := put("a", "data-a")
+	b := put("b", "data-b")
+	assert.Nil(t, store.Touch(ctx, a))
+	assert.Equal(t, uint64(1), a.Hits)
+
+	entries, err := store.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	evicted, err := Prune(ctx, store, EvictOpt{Policy: PolicyLFU, MaxRecords: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []digest.Digest{b.Source}, sources(evicted))
+
+	entries, err = store.List(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []digest.Digest{a.Source}, sources(entries))
+	_, err = store.Open(ctx, b)
+	assert.NotNil(t, err)
+}
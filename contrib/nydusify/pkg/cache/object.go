@@ -0,0 +1,242 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+)
+
+// ObjectSchemes are the schemes of the build cache in object storage, e.g.
+// "s3://bucket/prefix", mapped to the types of storage backend.
+var ObjectSchemes = map[string]string{
+	"s3://":  "s3",
+	"oss://": "oss",
+}
+
+// maxTouchAttempts limits the attempts to update the usage of entry which
+// is updated concurrently by others.
+const maxTouchAttempts = 5
+
+// IsObject checks if the build cache ref is in object storage.
+func IsObject(ref string) bool {
+	for scheme := range ObjectSchemes {
+		if strings.HasPrefix(ref, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseObjectRef returns the backend type, bucket and prefix of the build
+// cache in object storage.
+func parseObjectRef(ref string) (string, string, string, error) {
+	for scheme, bt := range ObjectSchemes {
+		if !strings.HasPrefix(ref, scheme) {
+			continue
+		}
+		u, err := url.Parse(ref)
+		if err != nil || u.Host == "" {
+			return "", "", "", errors.Errorf("invalid cache location %s, should be like %sbucket/prefix", ref, scheme)
+		}
+		prefix := strings.Trim(u.Path, "/")
+		if prefix != "" {
+			prefix += "/"
+		}
+		return bt, u.Host, prefix, nil
+	}
+	return "", "", "", errors.Errorf("unsupported cache location %s", ref)
+}
+
+// objectStore stores the entries and converted layers in object storage
+// shared by the ephemeral CI runners, in the same layout as dirStore under
+// the prefix. The objects are created only if they don't exist, and the
+// usage of entry is updated only if it isn't modified since read.
+type objectStore struct {
+	prefix  string
+	objects backend.ObjectStore
+}
+
+func newObjectStore(ref string, backendConfig string) (*objectStore, error) {
+	bt, bucket, prefix, err := parseObjectRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := backend.NewObjectStore(bt, bucket, []byte(backendConfig))
+	if err != nil {
+		return nil, errors.Wrapf(err, "create %s object store", bt)
+	}
+	return &objectStore{prefix: prefix, objects: objects}, nil
+}
+
+func (store *objectStore) entryKey(key digest.Digest) string {
+	return path.Join(store.prefix+"entries", key.Algorithm().String(), key.Encoded()+".json")
+}
+
+func (store *objectStore) blobKey(dgst digest.Digest) string {
+	return path.Join(store.prefix+"blobs", dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// getEntry returns the entry of key and the ETag of its object.
+func (store *objectStore) getEntry(ctx context.Context, key digest.Digest) (*Entry, string, error) {
+	if err := key.Validate(); err != nil {
+		return nil, "", errors.Wrap(err, "invalid cache key")
+	}
+	reader, etag, err := store.objects.GetObject(ctx, store.entryKey(key))
+	if err != nil {
+		if errors.Is(err, backend.ErrObjectNotFound) {
+			return nil, "", ErrNotFound
+		}
+		return nil, "", errors.Wrap(err, "read cache entry")
+	}
+	defer reader.Close()
+
+	var entry Entry
+	if err := json.NewDecoder(reader).Decode(&entry); err != nil {
+		return nil, "", errors.Wrapf(err, "invalid cache entry %s", key)
+	}
+	if entry.Key != key {
+		return nil, "", errors.Errorf("unmatched cache entry key %s, expected %s", entry.Key, key)
+	}
+	if err := entry.Layer.Digest.Validate(); err != nil {
+		return nil, "", errors.Wrapf(err, "invalid cache entry %s", key)
+	}
+	return &entry, etag, nil
+}
+
+func (store *objectStore) Get(ctx context.Context, key digest.Digest) (*Entry, error) {
+	entry, _, err := store.getEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	// The layer may be deleted by the concurrent pruning.
+	exist, err := store.objects.ExistObject(ctx, store.blobKey(entry.Layer.Digest))
+	if err != nil {
+		return nil, errors.Wrap(err, "check cached layer")
+	}
+	if !exist {
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (store *objectStore) Open(ctx context.Context, entry *Entry) (io.ReadCloser, error) {
+	reader, _, err := store.objects.GetObject(ctx, store.blobKey(entry.Layer.Digest))
+	if err != nil {
+		return nil, errors.Wrap(err, "open cached layer")
+	}
+	return reader, nil
+}
+
+func (store *objectStore) putEntry(ctx context.Context, entry *Entry, etag string) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshal cache entry")
+	}
+	return store.objects.PutObject(ctx, store.entryKey(entry.Key), bytes.NewReader(data), int64(len(data)), etag)
+}
+
+// Put uploads the layer and the entry, the layer or entry uploaded by others
+// concurrently is kept.
+func (store *objectStore) Put(ctx context.Context, entry *Entry, reader io.Reader) error {
+	blobKey := store.blobKey(entry.Layer.Digest)
+	exist, err := store.objects.ExistObject(ctx, blobKey)
+	if err != nil {
+		return errors.Wrap(err, "check cached layer")
+	}
+	if !exist {
+		err := store.objects.PutObject(ctx, blobKey, reader, entry.Layer.Size, "")
+		if err != nil && !errors.Is(err, backend.ErrPreconditionFailed) {
+			return errors.Wrap(err, "upload cached layer")
+		}
+	}
+
+	stored := *entry
+	stored.touch(time.Now())
+	if err := store.putEntry(ctx, &stored, ""); err != nil && !errors.Is(err, backend.ErrPreconditionFailed) {
+		return errors.Wrap(err, "upload cache entry")
+	}
+	return nil
+}
+
+// Touch updates the usage of entry read with its ETag, and retries with the
+// latest entry if it's updated by others concurrently.
+func (store *objectStore) Touch(ctx context.Context, entry *Entry) error {
+	for attempt := 0; attempt < maxTouchAttempts; attempt++ {
+		latest, etag, err := store.getEntry(ctx, entry.Key)
+		if err != nil {
+			return err
+		}
+		latest.Hits++
+		latest.touch(time.Now())
+		err = store.putEntry(ctx, latest, etag)
+		if err == nil {
+			*entry = *latest
+			return nil
+		}
+		if !errors.Is(err, backend.ErrPreconditionFailed) {
+			return errors.Wrap(err, "update cache entry")
+		}
+	}
+	return errors.Errorf("cache entry %s is updated concurrently", entry.Key)
+}
+
+func (store *objectStore) List(ctx context.Context) ([]*Entry, error) {
+	keys, err := store.objects.ListObjects(ctx, store.prefix+"entries/")
+	if err != nil {
+		return nil, err
+	}
+	entries := []*Entry{}
+	for _, objectKey := range keys {
+		if !strings.HasSuffix(objectKey, ".json") {
+			continue
+		}
+		key := digest.NewDigestFromEncoded(digest.Algorithm(path.Base(path.Dir(objectKey))), strings.TrimSuffix(path.Base(objectKey), ".json"))
+		entry, err := store.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (store *objectStore) Delete(ctx context.Context, key digest.Digest) error {
+	entry, _, err := store.getEntry(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := store.objects.DeleteObject(ctx, store.entryKey(key)); err != nil {
+		return err
+	}
+
+	entries, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range entries {
+		if other.Layer.Digest == entry.Layer.Digest {
+			return nil
+		}
+	}
+	return store.objects.DeleteObject(ctx, store.blobKey(entry.Layer.Digest))
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// memObjects is the in-memory object store with conditional writes, the
+// ETag is the digest of object content.
+type memObjects struct {
+	sync.Mutex
+	objects map[string][]byte
+	// beforePut is called before the conditional write, to simulate the
+	// concurrent writes by others.
+	beforePut func(key string)
+}
+
+func newMemObjects() *memObjects {
+	return &memObjects{objects: map[string][]byte{}}
+}
+
+func (m *memObjects) GetObject(_ context.Context, key string) (io.ReadCloser, string, error) {
+	m.Lock()
+	defer m.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, "", backend.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), digest.FromBytes(data).String(), nil
+}
+
+func (m *memObjects) PutObject(_ context.Context, key string, reader io.Reader, _ int64, etag string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if m.beforePut != nil {
+		m.beforePut(key)
+	}
+	m.Lock()
+	defer m.Unlock()
+	current, ok := m.objects[key]
+	if (etag == "" && ok) || (etag != "" && (!ok || digest.FromBytes(current).String() != etag)) {
+		return backend.ErrPreconditionFailed
+	}
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memObjects) ExistObject(_ context.Context, key string) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.objects[key]
+	return ok, nil
+}
+
+func (m *memObjects) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+	keys := []string{}
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *memObjects) DeleteObject(_ context.Context, key string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func TestParseObjectRef(t *testing.T) {
+	bt, bucket, prefix, err := parseObjectRef("s3://bucket/ci/cache/")
+	assert.Nil(t, err)
+	assert.Equal(t, "s3", bt)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "ci/cache/", prefix)
+
+	bt, bucket, prefix, err = parseObjectRef("oss://bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, "oss", bt)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "", prefix)
+
+	_, _, _, err = parseObjectRef("s3:///prefix")
+	assert.NotNil(t, err)
+
+	assert.True(t, IsObject("s3://bucket"))
+	assert.False(t, IsObject("localhost:5000/cache"))
+	assert.False(t, IsObject("dir:///var/cache"))
+}
+
+func TestObjectStore(t *testing.T) {
+	ctx := context.Background()
+	objects := newMemObjects()
+	store := &objectStore{prefix: "cache/", objects: objects}
+
+	data := "nydus blob"
+	layer := ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    digest.FromString(data),
+		Size:      int64(len(data)),
+	}
+	entry := NewEntry(digest.FromString("layer"), map[string]string{"fs_version": "6"}, layer)
+
+	_, err := store.Get(ctx, entry.Key)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	assert.Nil(t, store.Put(ctx, entry, strings.NewReader(data)))
+	got, err := store.Get(ctx, entry.Key)
+	assert.Nil(t, err)
+	assert.Equal(t, entry.Layer, got.Layer)
+	_, ok := objects.objects["cache/blobs/sha256/"+layer.Digest.Encoded()]
+	assert.True(t, ok)
+
+	// The entry put by others concurrently is kept.
+	assert.Nil(t, store.Put(ctx, entry, strings.NewReader(data)))
+
+	reader, err := store.Open(ctx, got)
+	assert.Nil(t, err)
+	read, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+	reader.Close()
+	assert.Equal(t, data, string(read))
+
+	// The hit recorded by others concurrently isn't lost.
+	raced := false
+	objects.beforePut = func(string) {
+		if !raced {
+			raced = true
+			other, err := store.Get(ctx, entry.Key)
+			assert.Nil(t, err)
+			objects.beforePut = nil
+			assert.Nil(t, store.Touch(ctx, other))
+		}
+	}
+	assert.Nil(t, store.Touch(ctx, got))
+	assert.True(t, raced)
+	assert.Equal(t, uint64(2), got.Hits)
+
+	entries, err := store.List(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint64(2), entries[0].Hits)
+
+	// The layer shared by other entries is kept.
+	other := NewEntry(digest.FromString("layer"), map[string]string{"fs_version": "5"}, layer)
+	assert.Nil(t, store.Put(ctx, other, strings.NewReader(data)))
+	assert.Nil(t, store.Delete(ctx, entry.Key))
+	_, err = store.Get(ctx, other.Key)
+	assert.Nil(t, err)
+
+	assert.Nil(t, store.Delete(ctx, other.Key))
+	keys, err := objects.ListObjects(ctx, "cache/")
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,253 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// registryStore stores every entry as an image in the cache repository,
+// which is tagged by the key of entry. The config of image is the entry,
+// and the only layer is the converted layer, so that the layer is kept by
+// the garbage collection of registry.
+type registryStore struct {
+	repo      string
+	newRemote func(ref string) (*remote.Remote, error)
+	registry  Registry
+}
+
+func newRegistryStore(ref string, newRemote func(ref string) (*remote.Remote, error), registry Registry) (*registryStore, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cache repository %s", ref)
+	}
+	return &registryStore{
+		repo:      named.Name(),
+		newRemote: newRemote,
+		registry:  registry,
+	}, nil
+}
+
+// entryTag returns the tag of entry image, like "sha256-<hex>".
+func entryTag(key digest.Digest) string {
+	return fmt.Sprintf("%s-%s", key.Algorithm(), key.Encoded())
+}
+
+// parseEntryTag returns the key of entry image tag, the other tags in the
+// repository are skipped.
+func parseEntryTag(tag string) (digest.Digest, bool) {
+	algorithm, encoded, ok := strings.Cut(tag, "-")
+	if !ok {
+		return "", false
+	}
+	key := digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded)
+	return key, key.Validate() == nil
+}
+
+// entryManifest returns the manifest of entry image and its config.
+func entryManifest(entry *Entry) (*Manifest, *ocispec.Descriptor, []byte, error) {
+	configDesc, configBytes, err := utils.MarshalToDesc(entry, MediaTypeEntry)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "marshal cache entry")
+	}
+	return &Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Manifest: ocispec.Manifest{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2,
+			},
+			Config: *configDesc,
+			Layers: []ocispec.Descriptor{entry.Layer},
+			Annotations: map[string]string{
+				utils.ManifestNydusCache: ModeCAS,
+			},
+		},
+	}, configDesc, configBytes, nil
+}
+
+// withHTTP calls fn with the remote, and retries with plain HTTP if the
+// registry doesn't support HTTPS.
+func withHTTP(remoter *remote.Remote, fn func() error) error {
+	err := fn()
+	if err != nil && utils.RetryWithHTTP(err) {
+		remoter.MaybeWithHTTP(err)
+		if remoter.IsWithHTTP() {
+			err = fn()
+		}
+	}
+	return err
+}
+
+func (store *registryStore) remote(key digest.Digest) (*remote.Remote, error) {
+	return store.newRemote(store.repo + ":" + entryTag(key))
+}
+
+func (store *registryStore) Get(ctx context.Context, key digest.Digest) (*Entry, error) {
+	remoter, err := store.remote(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := withHTTP(remoter, func() error {
+		manifestDesc, err := remoter.Resolve(ctx)
+		if err != nil {
+			return err
+		}
+		var manifest Manifest
+		if err := pullJSON(ctx, remoter, *manifestDesc, &manifest); err != nil {
+			return errors.Wrap(err, "pull cache entry manifest")
+		}
+		if manifest.Config.MediaType != MediaTypeEntry {
+			return fmt.Errorf("invalid cache entry media type %s", manifest.Config.MediaType)
+		}
+		return errors.Wrap(pullJSON(ctx, remoter, manifest.Config, &entry), "pull cache entry")
+	}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if entry.Key != key {
+		return nil, fmt.Errorf("unmatched cache entry key %s, expected %s", entry.Key, key)
+	}
+
+	return &entry, nil
+}
+
+func pullJSON(ctx context.Context, remoter *remote.Remote, desc ocispec.Descriptor, v interface{}) error {
+	reader, err := remoter.Pull(ctx, desc, true)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(v)
+}
+
+func (store *registryStore) Open(ctx context.Context, entry *Entry) (io.ReadCloser, error) {
+	remoter, err := store.remote(entry.Key)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.ReadCloser
+	err = withHTTP(remoter, func() error {
+		reader, err = remoter.Pull(ctx, entry.Layer, true)
+		return err
+	})
+	return reader, err
+}
+
+func (store *registryStore) Put(ctx context.Context, entry *Entry, reader io.Reader) error {
+	stored := *entry
+	stored.touch(time.Now())
+	return store.push(ctx, &stored, reader)
+}
+
+// push pushes the entry image, the layer is skipped if reader is nil.
+func (store *registryStore) push(ctx context.Context, entry *Entry, reader io.Reader) error {
+	remoter, err := store.remote(entry.Key)
+	if err != nil {
+		return err
+	}
+	manifest, configDesc, configBytes, err := entryManifest(entry)
+	if err != nil {
+		return err
+	}
+	manifestDesc, manifestBytes, err := utils.MarshalToDesc(manifest, manifest.MediaType)
+	if err != nil {
+		return errors.Wrap(err, "marshal cache entry manifest")
+	}
+
+	// The layer reader can't be rewound to retry with plain HTTP, so push
+	// the small config first to detect the protocol of registry.
+	if err := withHTTP(remoter, func() error {
+		return remoter.Push(ctx, *configDesc, true, bytes.NewReader(configBytes))
+	}); err != nil {
+		return errors.Wrap(err, "push cache entry")
+	}
+	if reader != nil {
+		if err := remoter.Push(ctx, entry.Layer, true, reader); err != nil {
+			return errors.Wrap(err, "push cached layer")
+		}
+	}
+	if err := remoter.Push(ctx, *manifestDesc, false, bytes.NewReader(manifestBytes)); err != nil {
+		return errors.Wrap(err, "push cache entry manifest")
+	}
+
+	return nil
+}
+
+// Touch updates the usage in the config of entry image, the previous
+// manifest is untagged and reclaimed by the garbage collection of registry.
+func (store *registryStore) Touch(ctx context.Context, entry *Entry) error {
+	entry.Hits++
+	entry.touch(time.Now())
+	return store.push(ctx, entry, nil)
+}
+
+func (store *registryStore) List(ctx context.Context) ([]*Entry, error) {
+	if store.registry == nil {
+		return nil, fmt.Errorf("listing cache entries in registry is not supported")
+	}
+	tags, err := store.registry.Tags(ctx, store.repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "list tags of %s", store.repo)
+	}
+	entries := []*Entry{}
+	for _, tag := range tags {
+		key, ok := parseEntryTag(tag)
+		if !ok {
+			continue
+		}
+		entry, err := store.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "get cache entry %s", key)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Delete deletes the manifest of entry image, the layer is reclaimed by the
+// garbage collection of registry once it's not referenced.
+func (store *registryStore) Delete(ctx context.Context, key digest.Digest) error {
+	if store.registry == nil {
+		return fmt.Errorf("deleting cache entries in registry is not supported")
+	}
+	remoter, err := store.remote(key)
+	if err != nil {
+		return err
+	}
+	var manifestDesc *ocispec.Descriptor
+	if err := withHTTP(remoter, func() error {
+		manifestDesc, err = remoter.Resolve(ctx)
+		return err
+	}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "resolve cache entry %s", key)
+	}
+	return store.registry.DeleteManifest(ctx, store.repo+":"+entryTag(key), manifestDesc.Digest)
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+)
+
+const (
+	// ModeImage stores the cache records in the manifest of a cache image,
+	// the records are only shared by the conversions using the same cache
+	// image.
+	ModeImage = "image"
+	// ModeCAS stores the converted layers in a content-addressed store keyed
+	// by the source layer digest and build parameters, so that the layers
+	// are shared across images and target repositories.
+	ModeCAS = "cas"
+
+	// MediaTypeEntry is the media type of cache entry in content-addressed
+	// store.
+	MediaTypeEntry = "application/vnd.nydus.cache.entry.v2+json"
+)
+
+// ErrNotFound is returned if the entry is not in the store.
+var ErrNotFound = errors.New("cache entry not found")
+
+// Entry is the record of a layer converted from the source layer with the
+// build parameters in content-addressed store.
+type Entry struct {
+	Key    digest.Digest     `json:"key"`
+	Source digest.Digest     `json:"source"`
+	Params map[string]string `json:"params"`
+	// Layer is the converted nydus blob layer.
+	Layer ocispec.Descriptor `json:"layer"`
+
+	// Created, LastUsed and Hits are the usage of entry for eviction.
+	Created  time.Time `json:"created,omitempty"`
+	LastUsed time.Time `json:"last_used,omitempty"`
+	Hits     uint64    `json:"hits,omitempty"`
+}
+
+// touch records the use of entry at now.
+func (entry *Entry) touch(now time.Time) {
+	if entry.Created.IsZero() {
+		entry.Created = now
+	}
+	entry.LastUsed = now
+}
+
+// Key returns the content address of the layer converted from the source
+// layer with the build parameters, like fs version, chunk size and
+// compressor, which affect the converted layer.
+func Key(source digest.Digest, params map[string]string) digest.Digest {
+	// The keys of map are sorted by json marshaling.
+	data, _ := json.Marshal(struct {
+		Source digest.Digest     `json:"source"`
+		Params map[string]string `json:"params"`
+	}{
+		Source: source,
+		Params: params,
+	})
+	return digest.FromBytes(data)
+}
+
+// NewEntry creates the entry of the layer converted from source layer.
+func NewEntry(source digest.Digest, params map[string]string, layer ocispec.Descriptor) *Entry {
+	return &Entry{
+		Key:    Key(source, params),
+		Source: source,
+		Params: params,
+		Layer:  layer,
+	}
+}
+
+// Store is the content-addressed store of converted layers.
+type Store interface {
+	// Get returns the entry of key, or ErrNotFound if it's not in store.
+	Get(ctx context.Context, key digest.Digest) (*Entry, error)
+	// Open returns the reader of the converted layer of entry.
+	Open(ctx context.Context, entry *Entry) (io.ReadCloser, error)
+	// Put stores the entry with the converted layer read from reader.
+	Put(ctx context.Context, entry *Entry, reader io.Reader) error
+	// Touch records a hit of entry.
+	Touch(ctx context.Context, entry *Entry) error
+	// List returns all the entries in store.
+	List(ctx context.Context) ([]*Entry, error)
+	// Delete removes the entry of key, the converted layer is removed
+	// once it's not used by any entry.
+	Delete(ctx context.Context, key digest.Digest) error
+}
+
+// Registry lists and deletes the entry images in registry, which aren't
+// supported by remote.Remote.
+type Registry interface {
+	// Tags returns the tags in repository.
+	Tags(ctx context.Context, repo string) ([]string, error)
+	// DeleteManifest deletes the manifest of dgst in the repository of ref.
+	DeleteManifest(ctx context.Context, ref string, dgst digest.Digest) error
+}
+
+// IsContentAddressed checks if the build cache of ref is content-addressed
+// in mode, the build cache not in registry is always content-addressed.
+func IsContentAddressed(ref, mode string) bool {
+	return mode == ModeCAS || IsDir(ref) || IsObject(ref)
+}
+
+// StoreOpt is the option to access the content-addressed store.
+type StoreOpt struct {
+	// NewRemote and Registry access the store in registry.
+	NewRemote func(ref string) (*remote.Remote, error)
+	Registry  Registry
+	// BackendConfig is the configuration of storage backend, e.g. endpoint
+	// and credentials, to access the store in object storage, the bucket
+	// and prefix are specified by the ref.
+	BackendConfig string
+}
+
+// NewStore creates the content-addressed store of ref, which is a local
+// directory prefixed by DirScheme, a location in object storage prefixed by
+// one of ObjectSchemes, or the repository of registry to store the entries
+// as images tagged by keys.
+func NewStore(ref string, opt StoreOpt) (Store, error) {
+	var store Store
+	var err error
+	switch {
+	case IsDir(ref):
+		store, err = newDirStore(ref)
+	case IsObject(ref):
+		store, err = newObjectStore(ref, opt.BackendConfig)
+	default:
+		store, err = newRegistryStore(ref, opt.NewRemote, opt.Registry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStore{store: store}, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestKey(t *testing.T) {
+	source := digest.FromString("layer")
+	params := map[string]string{"compressor": "zstd", "fs_version": "6"}
+
+	key := Key(source, params)
+	assert.Equal(t, key, Key(source, map[string]string{"fs_version": "6", "compressor": "zstd"}))
+	assert.NotEqual(t, key, Key(source, map[string]string{"compressor": "lz4_block", "fs_version": "6"}))
+	assert.NotEqual(t, key, Key(digest.FromString("other"), params))
+
+	assert.Equal(t, "sha256-"+key.Encoded(), entryTag(key))
+	parsed, ok := parseEntryTag(entryTag(key))
+	assert.True(t, ok)
+	assert.Equal(t, key, parsed)
+	_, ok = parseEntryTag("latest")
+	assert.False(t, ok)
+	_, ok = parseEntryTag("sha256-invalid")
+	assert.False(t, ok)
+}
+
+func TestEntryManifest(t *testing.T) {
+	layer := ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    digest.FromString("blob"),
+		Size:      4,
+	}
+	entry := NewEntry(digest.FromString("layer"), map[string]string{"fs_version": "6"}, layer)
+
+	manifest, configDesc, configBytes, err := entryManifest(entry)
+	assert.Nil(t, err)
+	assert.Equal(t, MediaTypeEntry, configDesc.MediaType)
+	assert.Equal(t, digest.FromBytes(configBytes), configDesc.Digest)
+	assert.Equal(t, *configDesc, manifest.Config)
+	assert.Equal(t, []ocispec.Descriptor{layer}, manifest.Layers)
+	assert.Equal(t, ModeCAS, manifest.Annotations[utils.ManifestNydusCache])
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cache
+
+import (
+	"context"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+)
+
+// tracedStore traces the accesses to store.
+type tracedStore struct {
+	store Store
+}
+
+// endSpan ends the span of access, ErrNotFound is a cache miss rather than
+// an error.
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, ErrNotFound) {
+		span.SetAttributes(attribute.Bool("hit", false))
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+func (store *tracedStore) Get(ctx context.Context, key digest.Digest) (*Entry, error) {
+	ctx, span := tracing.Start(ctx, "cache.get", attribute.String("key", key.String()))
+	entry, err := store.store.Get(ctx, key)
+	endSpan(span, err)
+	return entry, err
+}
+
+func (store *tracedStore) Open(ctx context.Context, entry *Entry) (io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "cache.open", attribute.String("layer", entry.Layer.Digest.String()))
+	reader, err := store.store.Open(ctx, entry)
+	endSpan(span, err)
+	return reader, err
+}
+
+func (store *tracedStore) Put(ctx context.Context, entry *Entry, reader io.Reader) error {
+	ctx, span := tracing.Start(ctx, "cache.put",
+		attribute.String("key", entry.Key.String()),
+		attribute.String("layer", entry.Layer.Digest.String()),
+		attribute.Int64("size", entry.Layer.Size),
+	)
+	err := store.store.Put(ctx, entry, reader)
+	endSpan(span, err)
+	return err
+}
+
+func (store *tracedStore) Touch(ctx context.Context, entry *Entry) error {
+	ctx, span := tracing.Start(ctx, "cache.touch", attribute.String("key", entry.Key.String()))
+	err := store.store.Touch(ctx, entry)
+	endSpan(span, err)
+	return err
+}
+
+func (store *tracedStore) List(ctx context.Context) ([]*Entry, error) {
+	ctx, span := tracing.Start(ctx, "cache.list")
+	entries, err := store.store.List(ctx)
+	span.SetAttributes(attribute.Int("entries", len(entries)))
+	endSpan(span, err)
+	return entries, err
+}
+
+func (store *tracedStore) Delete(ctx context.Context, key digest.Digest) error {
+	ctx, span := tracing.Start(ctx, "cache.delete", attribute.String("key", key.String()))
+	err := store.store.Delete(ctx, key)
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBatchWorkers is the number of images checked concurrently in
+// batch mode when no worker count is specified.
+const DefaultBatchWorkers = 4
+
+type BatchOpt struct {
+	Opt
+
+	Targets []string
+	// Workers is the maximum number of images checked concurrently.
+	Workers uint
+}
+
+// BatchReport is the aggregated report of batch check, the reports of
+// images are in the order of targets.
+type BatchReport struct {
+	Total    int       `json:"total"`
+	Passed   int       `json:"passed"`
+	Failed   int       `json:"failed"`
+	Duration string    `json:"duration"`
+	Results  []*Report `json:"results"`
+}
+
+// ParseTargetsFile reads the Nydus image references to be checked from
+// the file, one reference per line, the empty lines and the lines starting
+// with '#' are ignored.
+func ParseTargetsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open targets file")
+	}
+	defer file.Close()
+
+	var targets []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.ContainsAny(line, " \t") {
+			return nil, fmt.Errorf("invalid image reference %q in targets file", line)
+		}
+		if seen[line] {
+			return nil, fmt.Errorf("image %s is duplicated in targets file", line)
+		}
+		seen[line] = true
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read targets file")
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no image found in targets file %s", path)
+	}
+
+	return targets, nil
+}
+
+func checkImage(ctx context.Context, opt Opt) (*Report, error) {
+	checker, err := New(opt)
+	if err != nil {
+		report := &Report{Target: opt.Target, Rules: []RuleReport{}}
+		report.finish(err)
+		return report, err
+	}
+	return checker.run(ctx)
+}
+
+// BatchCheck checks all the images in opt.Targets with at most opt.Workers
+// checks running at the same time. Every image is checked in its own
+// subdirectory of work directory, a failed check doesn't stop the others.
+// The aggregated report is saved to opt.OutputJSON if specified.
+func BatchCheck(ctx context.Context, opt BatchOpt) (*BatchReport, error) {
+	if opt.Source != "" {
+		return nil, fmt.Errorf("source image is not supported in batch check")
+	}
+
+	workers := opt.Workers
+	if workers == 0 {
+		workers = DefaultBatchWorkers
+	}
+
+	start := time.Now()
+	report := BatchReport{
+		Total:   len(opt.Targets),
+		Results: make([]*Report, len(opt.Targets)),
+	}
+
+	var mutex sync.Mutex
+	eg := errgroup.Group{}
+	eg.SetLimit(int(workers))
+	for idx, target := range opt.Targets {
+		eg.Go(func() error {
+			imageOpt := opt.Opt
+			imageOpt.Target = target
+			imageOpt.WorkDir = filepath.Join(opt.WorkDir, strconv.Itoa(idx))
+			imageOpt.OutputJSON = ""
+
+			logrus.Infof("checking image %s", target)
+			result, err := checkImage(ctx, imageOpt)
+			if err != nil {
+				logrus.WithError(err).Errorf("failed to check image %s", target)
+			} else {
+				logrus.Infof("verified image %s", target)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Results[idx] = result
+			if err != nil {
+				report.Failed++
+			} else {
+				report.Passed++
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	report.Duration = time.Since(start).String()
+
+	if opt.OutputJSON != "" {
+		if err := dumpReport(&report, opt.OutputJSON); err != nil {
+			return &report, err
+		}
+	}
+
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("failed to check %d of %d images", report.Failed, report.Total)
+	}
+
+	return &report, nil
+}
+
+// failedRule returns the name of failed rule in the report, or "-" if
+// the check failed before running the rules.
+func failedRule(report *Report) string {
+	for _, rule := range report.Rules {
+		if rule.Status == RuleStatusFailed {
+			return rule.Name
+		}
+	}
+	return "-"
+}
+
+// PrintBatchReport prints the summary table of batch check.
+func PrintBatchReport(writer io.Writer, report *BatchReport) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tSTATUS\tFAILED RULE\tEXIT CODE\tDURATION")
+	for _, result := range report.Results {
+		if result.Passed {
+			fmt.Fprintf(tw, "%s\t%s\t-\t0\t%s\n", result.Target, RuleStatusPassed, result.Duration)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", result.Target, RuleStatusFailed, failedRule(result), result.ExitCode, result.Duration)
+	}
+	fmt.Fprintf(tw, "\n%d images checked, %d passed, %d failed in %s\n", report.Total, report.Passed, report.Failed, report.Duration)
+	return tw.Flush()
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTargetsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "targets.txt")
+	require.NoError(t, os.WriteFile(path, []byte(`# nightly audit
+localhost:5000/busybox:nydus
+
+  localhost:5000/nginx:nydus
+`), 0644))
+	targets, err := ParseTargetsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"localhost:5000/busybox:nydus", "localhost:5000/nginx:nydus"}, targets)
+
+	emptyPath := filepath.Join(dir, "empty.txt")
+	require.NoError(t, os.WriteFile(emptyPath, []byte("# nothing\n"), 0644))
+	_, err = ParseTargetsFile(emptyPath)
+	require.ErrorContains(t, err, "no image found")
+
+	duplicatedPath := filepath.Join(dir, "duplicated.txt")
+	require.NoError(t, os.WriteFile(duplicatedPath, []byte("busybox:nydus\nbusybox:nydus\n"), 0644))
+	_, err = ParseTargetsFile(duplicatedPath)
+	require.ErrorContains(t, err, "duplicated")
+
+	invalidPath := filepath.Join(dir, "invalid.txt")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("busybox:latest busybox:nydus\n"), 0644))
+	_, err = ParseTargetsFile(invalidPath)
+	require.ErrorContains(t, err, "invalid image reference")
+
+	_, err = ParseTargetsFile(filepath.Join(dir, "not-found.txt"))
+	require.Error(t, err)
+}
+
+func TestBatchCheckWithSource(t *testing.T) {
+	_, err := BatchCheck(context.Background(), BatchOpt{
+		Opt:     Opt{Source: "localhost:5000/busybox:latest"},
+		Targets: []string{"localhost:5000/busybox:nydus"},
+	})
+	require.ErrorContains(t, err, "source image is not supported")
+}
+
+func TestPrintBatchReport(t *testing.T) {
+	report := &BatchReport{
+		Total:    2,
+		Passed:   1,
+		Failed:   1,
+		Duration: "3s",
+		Results: []*Report{
+			{Target: "busybox:nydus", Passed: true, Duration: "1s"},
+			{
+				Target:   "nginx:nydus",
+				ExitCode: ExitCodeBootstrap,
+				Duration: "2s",
+				Rules: []RuleReport{
+					{Name: "manifest", Status: RuleStatusPassed},
+					{Name: "bootstrap", Status: RuleStatusFailed},
+					{Name: "filesystem", Status: RuleStatusSkipped},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintBatchReport(&buf, report))
+	require.Equal(t, `TARGET         STATUS  FAILED RULE  EXIT CODE  DURATION
+busybox:nydus  passed  -            0          1s
+nginx:nydus    failed  bootstrap    4          2s
+
+2 images checked, 1 passed, 1 failed in 3s
+`, buf.String())
+}
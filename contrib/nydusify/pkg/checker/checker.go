@@ -8,6 +8,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -15,6 +16,7 @@ import (
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/rule"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
@@ -31,11 +33,50 @@ type Opt struct {
 	SourceBackendConfig string
 	TargetBackendType   string
 	TargetBackendConfig string
+	// SourceTransport and TargetTransport are the CA bundles, client
+	// certificates and proxy to access the source and target registries.
+	SourceTransport remote.TransportOption
+	TargetTransport remote.TransportOption
+	// SourceCredential and TargetCredential override the credentials in
+	// docker config to access the source and target registries.
+	SourceCredential provider.CredentialOption
+	TargetCredential provider.CredentialOption
 
 	MultiPlatform  bool
 	NydusImagePath string
 	NydusdPath     string
 	ExpectedArch   string
+
+	// CosignPath is the path of cosign binary to verify the signature of
+	// target image by CosignKey, or by the certificate identity and OIDC
+	// issuer for keyless signature.
+	CosignPath       string
+	CosignKey        string
+	CosignIdentity   string
+	CosignOIDCIssuer string
+
+	// WithReferrer requires the nydus manifest to be associated to the
+	// source image as a referrer.
+	WithReferrer bool
+
+	// Deep reads all the blobs from registry or storage backend to verify
+	// the data and blob table, and reads all the files of target image.
+	Deep bool
+
+	// Static verifies the bootstrap metadata in Go without mounting the
+	// images by nydusd, so that the check can run in unprivileged
+	// environment, the files of images are not compared.
+	Static bool
+
+	// PrefetchCoverage replays the prefetch list of target image to report
+	// the prefetch coverage, the list is read from PrefetchFilesPath if
+	// specified, otherwise from the prefetch table of bootstrap.
+	PrefetchCoverage  bool
+	PrefetchFilesPath string
+
+	// OutputJSON is the file path to save the check report in JSON
+	// format, the report is saved even if the check fails.
+	OutputJSON string
 }
 
 // Checker validates nydus image manifest, bootstrap and mounts filesystem
@@ -48,7 +89,7 @@ type Checker struct {
 
 // New creates Checker instance, target is the nydus image reference.
 func New(opt Opt) (*Checker, error) {
-	targetRemote, err := provider.DefaultRemote(opt.Target, opt.TargetInsecure)
+	targetRemote, err := provider.NewRemote(opt.Target, opt.TargetTransport.WithInsecure(opt.TargetInsecure), opt.TargetCredential)
 	if err != nil {
 		return nil, errors.Wrap(err, "init target image parser")
 	}
@@ -59,7 +100,7 @@ func New(opt Opt) (*Checker, error) {
 
 	var sourceParser *parser.Parser
 	if opt.Source != "" {
-		sourceRemote, err := provider.DefaultRemote(opt.Source, opt.SourceInsecure)
+		sourceRemote, err := provider.NewRemote(opt.Source, opt.SourceTransport.WithInsecure(opt.SourceInsecure), opt.SourceCredential)
 		if err != nil {
 			return nil, errors.Wrap(err, "Init source image parser")
 		}
@@ -79,24 +120,43 @@ func New(opt Opt) (*Checker, error) {
 }
 
 // Check checks nydus image, and outputs image information to work
-// directory, the check workflow is composed of various rules.
+// directory, the check workflow is composed of various rules. The
+// *RuleError is returned if a rule fails, see ExitCode.
 func (checker *Checker) Check(ctx context.Context) error {
-	if err := checker.check(ctx); err != nil {
-		if utils.RetryWithHTTP(err) {
-			if checker.sourceParser != nil {
-				checker.sourceParser.Remote.MaybeWithHTTP(err)
+	report, err := checker.run(ctx)
+	if checker.OutputJSON != "" {
+		if dumpErr := dumpReport(report, checker.OutputJSON); dumpErr != nil {
+			if err == nil {
+				return dumpErr
 			}
-			checker.targetParser.Remote.MaybeWithHTTP(err)
-			return checker.check(ctx)
+			logrus.WithError(dumpErr).Warn("failed to save check report")
 		}
-		return err
 	}
-	return nil
+	return err
+}
+
+// run checks nydus image and returns the report of check, the report is
+// returned even if the check fails.
+func (checker *Checker) run(ctx context.Context) (*Report, error) {
+	start := time.Now()
+	report := checker.newReport()
+	err := checker.check(ctx, report)
+	if err != nil && utils.RetryWithHTTP(err) {
+		if checker.sourceParser != nil {
+			checker.sourceParser.Remote.MaybeWithHTTP(err)
+		}
+		checker.targetParser.Remote.MaybeWithHTTP(err)
+		report = checker.newReport()
+		err = checker.check(ctx, report)
+	}
+	report.finish(err)
+	report.Duration = time.Since(start).String()
+	return report, err
 }
 
 // Check checks nydus image, and outputs image information to work
 // directory, the check workflow is composed of various rules.
-func (checker *Checker) check(ctx context.Context) error {
+func (checker *Checker) check(ctx context.Context, report *Report) error {
 	logrus.WithField("image", checker.targetParser.Remote.Ref).Infof("parsing image")
 	targetParsed, err := checker.targetParser.Parse(ctx)
 	if err != nil {
@@ -110,6 +170,8 @@ func (checker *Checker) check(ctx context.Context) error {
 			return errors.Wrap(err, "parse source image")
 		}
 	}
+	report.TargetDigest = manifestDigest(targetParsed)
+	report.SourceDigest = manifestDigest(sourceParsed)
 
 	if err := os.RemoveAll(checker.WorkDir); err != nil {
 		return errors.Wrap(err, "clean up work directory")
@@ -128,13 +190,30 @@ func (checker *Checker) check(ctx context.Context) error {
 	}
 
 	rules := []rule.Rule{
+		&rule.SignatureRule{
+			CosignPath:            checker.CosignPath,
+			Key:                   checker.CosignKey,
+			CertificateIdentity:   checker.CosignIdentity,
+			CertificateOIDCIssuer: checker.CosignOIDCIssuer,
+			TargetParsed:          targetParsed,
+			TargetInsecure:        checker.TargetInsecure,
+		},
 		&rule.ManifestRule{
 			SourceParsed: sourceParsed,
 			TargetParsed: targetParsed,
 		},
+		&rule.IndexRule{
+			TargetParsed: targetParsed,
+		},
+		&rule.ReferrerRule{
+			SourceParsed: sourceParsed,
+			TargetParsed: targetParsed,
+			Required:     checker.WithReferrer,
+		},
 		&rule.BootstrapRule{
 			WorkDir:        checker.WorkDir,
 			NydusImagePath: checker.NydusImagePath,
+			Static:         checker.Static,
 
 			SourceParsed:        sourceParsed,
 			TargetParsed:        targetParsed,
@@ -143,9 +222,39 @@ func (checker *Checker) check(ctx context.Context) error {
 			TargetBackendType:   checker.TargetBackendType,
 			TargetBackendConfig: checker.TargetBackendConfig,
 		},
-		&rule.FilesystemRule{
+	}
+	if checker.Deep {
+		rules = append(rules, &rule.DataRule{
+			WorkDir: checker.WorkDir,
+
+			SourceParsed:        sourceParsed,
+			TargetParsed:        targetParsed,
+			SourceBackendType:   checker.SourceBackendType,
+			SourceBackendConfig: checker.SourceBackendConfig,
+			TargetBackendType:   checker.TargetBackendType,
+			TargetBackendConfig: checker.TargetBackendConfig,
+		})
+	}
+	if checker.PrefetchCoverage || checker.PrefetchFilesPath != "" {
+		rules = append(rules, &rule.PrefetchRule{
+			WorkDir:           checker.WorkDir,
+			NydusImagePath:    checker.NydusImagePath,
+			NydusdPath:        checker.NydusdPath,
+			PrefetchFilesPath: checker.PrefetchFilesPath,
+
+			TargetImage: &rule.Image{
+				Parsed:   targetParsed,
+				Insecure: checker.TargetInsecure,
+			},
+			TargetBackendType:   checker.TargetBackendType,
+			TargetBackendConfig: checker.TargetBackendConfig,
+		})
+	}
+	if !checker.Static {
+		rules = append(rules, &rule.FilesystemRule{
 			WorkDir:    checker.WorkDir,
 			NydusdPath: checker.NydusdPath,
+			Deep:       checker.Deep,
 
 			SourceImage: &rule.Image{
 				Parsed:   sourceParsed,
@@ -159,12 +268,27 @@ func (checker *Checker) check(ctx context.Context) error {
 			SourceBackendConfig: checker.SourceBackendConfig,
 			TargetBackendType:   checker.TargetBackendType,
 			TargetBackendConfig: checker.TargetBackendConfig,
-		},
+		})
 	}
 
-	for _, rule := range rules {
-		if err := rule.Validate(); err != nil {
-			return errors.Wrapf(err, "validate %s failed", rule.Name())
+	for idx, rule := range rules {
+		start := time.Now()
+		err := rule.Validate()
+		ruleReport := RuleReport{
+			Name:     rule.Name(),
+			Status:   RuleStatusPassed,
+			Duration: time.Since(start).String(),
+		}
+		if err != nil {
+			ruleReport.Status = RuleStatusFailed
+			ruleReport.Error = err.Error()
+		}
+		report.Rules = append(report.Rules, ruleReport)
+		if err != nil {
+			for _, skipped := range rules[idx+1:] {
+				report.Rules = append(report.Rules, RuleReport{Name: skipped.Name(), Status: RuleStatusSkipped})
+			}
+			return &RuleError{Rule: rule.Name(), Err: err}
 		}
 	}
 
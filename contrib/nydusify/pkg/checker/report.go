@@ -0,0 +1,144 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+)
+
+// The exit codes of check command by the class of failure, so that CI can
+// distinguish the metadata errors from data corruption.
+const (
+	// ExitCodeError means the check can't be completed, e.g., failed to
+	// pull the image or invalid options.
+	ExitCodeError = 1
+	// ExitCodeSignature means the signature of target image is invalid.
+	ExitCodeSignature = 2
+	// ExitCodeManifest means the image manifest, config, index or referrer
+	// association is invalid.
+	ExitCodeManifest = 3
+	// ExitCodeBootstrap means the bootstrap is invalid.
+	ExitCodeBootstrap = 4
+	// ExitCodeData means the blobs are corrupted or inconsistent with
+	// the blob table of bootstrap.
+	ExitCodeData = 5
+	// ExitCodeFilesystem means the files differ between source and target
+	// image, or the image can't be mounted by nydusd.
+	ExitCodeFilesystem = 6
+)
+
+const (
+	RuleStatusPassed  = "passed"
+	RuleStatusFailed  = "failed"
+	RuleStatusSkipped = "skipped"
+)
+
+var ruleExitCodes = map[string]int{
+	"signature":  ExitCodeSignature,
+	"manifest":   ExitCodeManifest,
+	"index":      ExitCodeManifest,
+	"referrer":   ExitCodeManifest,
+	"bootstrap":  ExitCodeBootstrap,
+	"data":       ExitCodeData,
+	"filesystem": ExitCodeFilesystem,
+}
+
+// RuleError is returned by Check if a rule failed to validate the image.
+type RuleError struct {
+	Rule string
+	Err  error
+}
+
+func (err *RuleError) Error() string {
+	return fmt.Sprintf("validate %s failed: %s", err.Rule, err.Err)
+}
+
+func (err *RuleError) Unwrap() error {
+	return err.Err
+}
+
+// ExitCode returns the exit code by the class of check failure.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ruleErr *RuleError
+	if errors.As(err, &ruleErr) {
+		if code, ok := ruleExitCodes[ruleErr.Rule]; ok {
+			return code
+		}
+	}
+	return ExitCodeError
+}
+
+// RuleReport is the validation result of a rule.
+type RuleReport struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Report is the machine-readable report of image verification.
+type Report struct {
+	Source       string       `json:"source,omitempty"`
+	SourceDigest string       `json:"source_digest,omitempty"`
+	Target       string       `json:"target"`
+	TargetDigest string       `json:"target_digest,omitempty"`
+	Passed       bool         `json:"passed"`
+	ExitCode     int          `json:"exit_code"`
+	Error        string       `json:"error,omitempty"`
+	Duration     string       `json:"duration"`
+	Rules        []RuleReport `json:"rules"`
+}
+
+func (checker *Checker) newReport() *Report {
+	return &Report{
+		Source: checker.Source,
+		Target: checker.Target,
+		Rules:  []RuleReport{},
+	}
+}
+
+// manifestDigest returns the digest of the checked manifest, the nydus
+// manifest is preferred if the image contains both.
+func manifestDigest(parsed *parser.Parsed) string {
+	if parsed == nil {
+		return ""
+	}
+	if parsed.NydusImage != nil {
+		return parsed.NydusImage.Desc.Digest.String()
+	}
+	if parsed.OCIImage != nil {
+		return parsed.OCIImage.Desc.Digest.String()
+	}
+	return ""
+}
+
+// finish completes the report by the check result.
+func (report *Report) finish(err error) {
+	report.Passed = err == nil
+	report.ExitCode = ExitCode(err)
+	if err != nil {
+		report.Error = err.Error()
+	}
+}
+
+func dumpReport(report interface{}, path string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal check report")
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return errors.Wrap(err, "write check report")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, 0, ExitCode(nil))
+	require.Equal(t, ExitCodeError, ExitCode(fmt.Errorf("parse nydus image")))
+	require.Equal(t, ExitCodeError, ExitCode(&RuleError{Rule: "unknown", Err: fmt.Errorf("invalid")}))
+
+	err := errors.Wrap(&RuleError{Rule: "data", Err: fmt.Errorf("1 of 2 blobs are corrupted")}, "check")
+	require.Equal(t, ExitCodeData, ExitCode(err))
+	require.Equal(t, "check: validate data failed: 1 of 2 blobs are corrupted", err.Error())
+	require.Equal(t, ExitCodeManifest, ExitCode(&RuleError{Rule: "manifest", Err: fmt.Errorf("invalid")}))
+	require.Equal(t, ExitCodeFilesystem, ExitCode(&RuleError{Rule: "filesystem", Err: fmt.Errorf("invalid")}))
+}
+
+func TestDumpReport(t *testing.T) {
+	checker := Checker{Opt: Opt{Target: "localhost:5000/busybox:nydus"}}
+	report := checker.newReport()
+	report.Rules = append(report.Rules,
+		RuleReport{Name: "manifest", Status: RuleStatusPassed, Duration: "1ms"},
+		RuleReport{Name: "bootstrap", Status: RuleStatusFailed, Error: "invalid", Duration: "2ms"},
+		RuleReport{Name: "filesystem", Status: RuleStatusSkipped},
+	)
+	report.finish(&RuleError{Rule: "bootstrap", Err: fmt.Errorf("invalid")})
+
+	path := filepath.Join(t.TempDir(), "check.json")
+	require.NoError(t, dumpReport(report, path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var loaded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	require.Equal(t, false, loaded["passed"])
+	require.Equal(t, float64(ExitCodeBootstrap), loaded["exit_code"])
+	require.Equal(t, "validate bootstrap failed: invalid", loaded["error"])
+	require.NotContains(t, loaded, "source")
+	rules := loaded["rules"].([]interface{})
+	require.Len(t, rules, 3)
+	require.Equal(t, map[string]interface{}{"name": "filesystem", "status": "skipped"}, rules[2])
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/containerd/nydus-snapshotter/pkg/label"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
@@ -23,6 +24,9 @@ import (
 type BootstrapRule struct {
 	WorkDir        string
 	NydusImagePath string
+	// Static parses and verifies the RAFS v6 bootstrap in Go instead of
+	// calling `nydus-image check`.
+	Static bool
 
 	SourceParsed        *parser.Parsed
 	TargetParsed        *parser.Parsed
@@ -40,24 +44,54 @@ func (rule *BootstrapRule) Name() string {
 	return "bootstrap"
 }
 
-func (rule *BootstrapRule) validate(parsed *parser.Parsed, dir string) error {
-	if parsed == nil || parsed.NydusImage == nil {
-		return nil
+// blobs verifies the bootstrap and returns the blob list in its blob table.
+func (rule *BootstrapRule) blobs(bootstrapPath, outputPath string) ([]string, error) {
+	if rule.Static {
+		bootstrap, err := rafs.Load(bootstrapPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid nydus bootstrap format")
+		}
+		logrus.Infof("verified bootstrap metadata: %d files, %d blobs", len(bootstrap.Files), len(bootstrap.Blobs))
+		var blobs []string
+		for _, blob := range bootstrap.Blobs {
+			blobs = append(blobs, blob.ID)
+		}
+		return blobs, nil
 	}
 
-	logrus.WithField("type", tool.CheckImageType(parsed)).WithField("image", parsed.Remote.Ref).Info("checking bootstrap")
-
-	bootstrapDir := filepath.Join(rule.WorkDir, dir, "nydus_bootstrap")
-	outputPath := filepath.Join(rule.WorkDir, dir, "nydus_output.json")
-
 	// Get blob list in the blob table of bootstrap by calling
 	// `nydus-image check` command
 	builder := tool.NewBuilder(rule.NydusImagePath)
 	if err := builder.Check(tool.BuilderOption{
-		BootstrapPath:   filepath.Join(bootstrapDir, utils.BootstrapFileNameInLayer),
+		BootstrapPath:   bootstrapPath,
 		DebugOutputPath: outputPath,
 	}); err != nil {
-		return errors.Wrap(err, "invalid nydus bootstrap format")
+		return nil, errors.Wrap(err, "invalid nydus bootstrap format")
+	}
+
+	// Parse blob list from blob table of bootstrap
+	var out output
+	outputBytes, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read bootstrap debug json")
+	}
+	if err := json.Unmarshal(outputBytes, &out); err != nil {
+		return nil, errors.Wrap(err, "unmarshal bootstrap output JSON")
+	}
+	return out.Blobs, nil
+}
+
+func (rule *BootstrapRule) validate(parsed *parser.Parsed, dir string) error {
+	if parsed == nil || parsed.NydusImage == nil {
+		return nil
+	}
+
+	logrus.WithField("type", tool.CheckImageType(parsed)).WithField("image", parsed.Remote.Ref).Info("checking bootstrap")
+
+	bootstrapPath := filepath.Join(rule.WorkDir, dir, "nydus_bootstrap", utils.BootstrapFileNameInLayer)
+	blobs, err := rule.blobs(bootstrapPath, filepath.Join(rule.WorkDir, dir, "nydus_output.json"))
+	if err != nil {
+		return err
 	}
 
 	// Parse blob list from blob layers in nydus manifest
@@ -73,18 +107,9 @@ func (rule *BootstrapRule) validate(parsed *parser.Parsed, dir string) error {
 		}
 	}
 
-	// Parse blob list from blob table of bootstrap
-	var out output
-	outputBytes, err := os.ReadFile(outputPath)
-	if err != nil {
-		return errors.Wrap(err, "read bootstrap debug json")
-	}
-	if err := json.Unmarshal(outputBytes, &out); err != nil {
-		return errors.Wrap(err, "unmarshal bootstrap output JSON")
-	}
 	blobListInBootstrap := map[string]bool{}
 	lostInLayer := false
-	for _, blobID := range out.Blobs {
+	for _, blobID := range blobs {
 		blobListInBootstrap[blobID] = true
 		if !blobListInLayer[blobID] {
 			lostInLayer = true
@@ -0,0 +1,210 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	modelspec "github.com/CloudNativeAI/model-spec/specs-go/v1"
+	"github.com/containerd/nydus-snapshotter/pkg/label"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// DataRule reads all the blobs in the blob table of bootstrap from registry
+// or storage backend, to verify the blob data by the blob IDs, which are the
+// sha256 digests of blobs, and the blob table by the manifest layers. It
+// relies on the blob list output by BootstrapRule.
+type DataRule struct {
+	WorkDir string
+
+	SourceParsed        *parser.Parsed
+	TargetParsed        *parser.Parsed
+	SourceBackendType   string
+	SourceBackendConfig string
+	TargetBackendType   string
+	TargetBackendConfig string
+}
+
+func (rule *DataRule) Name() string {
+	return "data"
+}
+
+// blobLayers returns the blob layers in nydus manifest by blob ID, the
+// bootstrap layer is excluded.
+func blobLayers(manifest *ocispec.Manifest) map[string]ocispec.Descriptor {
+	layers := map[string]ocispec.Descriptor{}
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" {
+			continue
+		}
+		layers[layer.Digest.Hex()] = layer
+	}
+	return layers
+}
+
+// checkBlobTable validates the blob table of bootstrap by the blob layers in
+// manifest, the blobs are not in manifest if they are stored in backend.
+func checkBlobTable(blobIDs []string, layers map[string]ocispec.Descriptor) error {
+	var problems []string
+	inTable := map[string]bool{}
+	for _, blobID := range blobIDs {
+		if inTable[blobID] {
+			problems = append(problems, fmt.Sprintf("blob %s is duplicated in blob table", blobID))
+		}
+		inTable[blobID] = true
+		if err := digest.SHA256.Validate(blobID); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid blob ID %s in blob table", blobID))
+		}
+		if len(layers) > 0 {
+			if _, ok := layers[blobID]; !ok {
+				problems = append(problems, fmt.Sprintf("blob %s in blob table is not found in manifest layers", blobID))
+			}
+		}
+	}
+	for blobID, layer := range layers {
+		// The OCI reference layers may be not referenced by the blob table.
+		if !inTable[blobID] && layer.Annotations[label.NydusRefLayer] == "" {
+			problems = append(problems, fmt.Sprintf("blob %s in manifest layers is not found in blob table", blobID))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// verifyBlob reads the blob to verify its digest and size, size is ignored
+// if it's negative.
+func verifyBlob(reader io.Reader, blobID string, size int64) error {
+	digester := digest.SHA256.Digester()
+	read, err := io.Copy(digester.Hash(), reader)
+	if err != nil {
+		return errors.Wrap(err, "read blob")
+	}
+	if size >= 0 && read != size {
+		return fmt.Errorf("size mismatch, read %d, expected %d", read, size)
+	}
+	if actual := digester.Digest().Hex(); actual != blobID {
+		return fmt.Errorf("digest mismatch, read sha256:%s", actual)
+	}
+	return nil
+}
+
+func (rule *DataRule) validate(parsed *parser.Parsed, dir, backendType, backendConfig string) error {
+	if parsed == nil || parsed.NydusImage == nil {
+		return nil
+	}
+	manifest := &parsed.NydusImage.Manifest
+	if manifest.ArtifactType == modelspec.ArtifactTypeModelManifest {
+		logrus.WithField("image", parsed.Remote.Ref).Info("skip checking data of model artifact in external backend")
+		return nil
+	}
+
+	logrus.WithField("type", tool.CheckImageType(parsed)).WithField("image", parsed.Remote.Ref).Info("checking data")
+
+	var out output
+	outputBytes, err := os.ReadFile(filepath.Join(rule.WorkDir, dir, "nydus_output.json"))
+	if err != nil {
+		return errors.Wrap(err, "read bootstrap debug json")
+	}
+	if err := json.Unmarshal(outputBytes, &out); err != nil {
+		return errors.Wrap(err, "unmarshal bootstrap output JSON")
+	}
+
+	layers := map[string]ocispec.Descriptor{}
+	if backendType == "" {
+		layers = blobLayers(manifest)
+	}
+	if err := checkBlobTable(out.Blobs, layers); err != nil {
+		return errors.Wrap(err, "inconsistent blob table")
+	}
+
+	var bkd backend.Backend
+	if backendType != "" {
+		bkd, err = backend.NewBackend(backendType, []byte(backendConfig), nil)
+		if err != nil {
+			return errors.Wrap(err, "new backend")
+		}
+	}
+
+	var mutex sync.Mutex
+	var corrupted []string
+	ctx := context.Background()
+	eg := errgroup.Group{}
+	eg.SetLimit(int(WorkerCount))
+	for _, blobID := range out.Blobs {
+		blobID := blobID
+		eg.Go(func() error {
+			var reader io.ReadCloser
+			var err error
+			size := int64(-1)
+			if bkd != nil {
+				reader, err = bkd.Reader(blobID)
+			} else {
+				desc, ok := layers[blobID]
+				if ok {
+					size = desc.Size
+				} else {
+					desc = ocispec.Descriptor{
+						MediaType: utils.MediaTypeNydusBlob,
+						Digest:    digest.NewDigestFromEncoded(digest.SHA256, blobID),
+					}
+				}
+				reader, err = parsed.Remote.Pull(ctx, desc, true)
+			}
+			if err == nil {
+				err = verifyBlob(reader, blobID, size)
+				reader.Close()
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("blob", blobID).Error("corrupted blob")
+				mutex.Lock()
+				corrupted = append(corrupted, blobID)
+				mutex.Unlock()
+				return nil
+			}
+			logrus.WithField("blob", blobID).Debug("verified blob")
+			return nil
+		})
+	}
+	eg.Wait()
+
+	if len(corrupted) > 0 {
+		sort.Strings(corrupted)
+		return fmt.Errorf("%d of %d blobs are corrupted: %s", len(corrupted), len(out.Blobs), strings.Join(corrupted, ", "))
+	}
+	logrus.Infof("verified %d blobs", len(out.Blobs))
+	return nil
+}
+
+func (rule *DataRule) Validate() error {
+	if err := rule.validate(rule.SourceParsed, "source", rule.SourceBackendType, rule.SourceBackendConfig); err != nil {
+		return errors.Wrap(err, "source image: invalid nydus data")
+	}
+
+	if err := rule.validate(rule.TargetParsed, "target", rule.TargetBackendType, rule.TargetBackendConfig); err != nil {
+		return errors.Wrap(err, "target image: invalid nydus data")
+	}
+
+	return nil
+}
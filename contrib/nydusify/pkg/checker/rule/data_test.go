@@ -0,0 +1,62 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/containerd/nydus-snapshotter/pkg/label"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestDataName(t *testing.T) {
+	rule := DataRule{}
+	require.Equal(t, "data", rule.Name())
+}
+
+func TestCheckBlobTable(t *testing.T) {
+	blob1 := digest.FromString("blob1")
+	blob2 := digest.FromString("blob2")
+	ref := digest.FromString("ref")
+	manifest := ocispec.Manifest{
+		Layers: []ocispec.Descriptor{
+			{Digest: blob1},
+			{Digest: blob2},
+			{Digest: ref, Annotations: map[string]string{label.NydusRefLayer: ref.String()}},
+			{Digest: digest.FromString("bootstrap"), Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"}},
+		},
+	}
+	layers := blobLayers(&manifest)
+	require.Len(t, layers, 3)
+
+	require.NoError(t, checkBlobTable([]string{blob1.Hex(), blob2.Hex()}, layers))
+	require.NoError(t, checkBlobTable([]string{blob1.Hex(), blob2.Hex(), ref.Hex()}, layers))
+	// The blobs are not in manifest if they are stored in backend.
+	require.NoError(t, checkBlobTable([]string{blob1.Hex()}, nil))
+
+	err := checkBlobTable([]string{blob1.Hex()}, layers)
+	require.ErrorContains(t, err, "blob "+blob2.Hex()+" in manifest layers is not found in blob table")
+
+	err = checkBlobTable([]string{blob1.Hex(), blob2.Hex(), blob2.Hex(), "invalid"}, layers)
+	require.ErrorContains(t, err, "blob "+blob2.Hex()+" is duplicated in blob table")
+	require.ErrorContains(t, err, "invalid blob ID invalid in blob table")
+	require.ErrorContains(t, err, "blob invalid in blob table is not found in manifest layers")
+}
+
+func TestVerifyBlob(t *testing.T) {
+	data := "blob data"
+	blobID := digest.FromString(data).Hex()
+
+	require.NoError(t, verifyBlob(strings.NewReader(data), blobID, int64(len(data))))
+	require.NoError(t, verifyBlob(strings.NewReader(data), blobID, -1))
+	require.ErrorContains(t, verifyBlob(strings.NewReader(data), blobID, 100), "size mismatch")
+	require.ErrorContains(t, verifyBlob(bytes.NewReader([]byte("corrupted")), blobID, -1), "digest mismatch")
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	DiffMissingInTarget = "missing_in_target"
+	DiffMissingInSource = "missing_in_source"
+	DiffMismatch        = "mismatch"
+)
+
+// FieldDiff is a field of file mismatched between source and target image.
+type FieldDiff struct {
+	Field  string `json:"field"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// FileDiff is a file differing between source and target image.
+type FileDiff struct {
+	Path   string      `json:"path"`
+	Kind   string      `json:"kind"`
+	Fields []FieldDiff `json:"fields,omitempty"`
+}
+
+// DiffReport is the structured report of filesystem comparison.
+type DiffReport struct {
+	SourceFiles int        `json:"source_files"`
+	TargetFiles int        `json:"target_files"`
+	Diffs       []FileDiff `json:"diffs"`
+}
+
+func formatRdev(mode os.FileMode, rdev uint64) string {
+	if mode&os.ModeDevice != 0 {
+		return fmt.Sprintf("%d:%d", unix.Major(rdev), unix.Minor(rdev))
+	}
+	return fmt.Sprintf("%d", rdev)
+}
+
+func formatHoles(holes []Extent) string {
+	parts := make([]string, 0, len(holes))
+	for _, hole := range holes {
+		parts = append(parts, fmt.Sprintf("%d+%d", hole.Offset, hole.Length))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// compareNode returns the mismatched fields of the file in source and target
+// image.
+func compareNode(source, target *Node) []FieldDiff {
+	var diffs []FieldDiff
+	add := func(field, source, target string) {
+		if source != target {
+			diffs = append(diffs, FieldDiff{Field: field, Source: source, Target: target})
+		}
+	}
+
+	add("size", fmt.Sprintf("%d", source.Size), fmt.Sprintf("%d", target.Size))
+	add("mode", source.Mode.String(), target.Mode.String())
+	add("rdev", formatRdev(source.Mode, source.Rdev), formatRdev(target.Mode, target.Rdev))
+	add("symlink", source.Symlink, target.Symlink)
+	add("uid", fmt.Sprintf("%d", source.UID), fmt.Sprintf("%d", target.UID))
+	add("gid", fmt.Sprintf("%d", source.GID), fmt.Sprintf("%d", target.GID))
+	add("hash", hex.EncodeToString(source.Hash), hex.EncodeToString(target.Hash))
+	add("links", strings.Join(source.Links, ","), strings.Join(target.Links, ","))
+	add("mtime", formatTime(source.ModTime), formatTime(target.ModTime))
+	// The holes are not reported by some filesystems, like RAFS mounted
+	// by nydusd, the layout is compared only if reported.
+	if target.Holes != nil && !reflect.DeepEqual(source.Holes, target.Holes) {
+		add("holes", formatHoles(source.Holes), formatHoles(target.Holes))
+	}
+
+	var names []string
+	for name := range source.Xattrs {
+		names = append(names, name)
+	}
+	for name := range target.Xattrs {
+		if _, ok := source.Xattrs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sourceValue, sourceOK := source.Xattrs[name]
+		targetValue, targetOK := target.Xattrs[name]
+		if sourceOK && targetOK && string(sourceValue) == string(targetValue) {
+			continue
+		}
+		diffs = append(diffs, FieldDiff{
+			Field:  "xattr " + name,
+			Source: formatXattr(sourceValue, sourceOK),
+			Target: formatXattr(targetValue, targetOK),
+		})
+	}
+
+	return diffs
+}
+
+func formatTime(sec int64) string {
+	if sec == 0 {
+		return ""
+	}
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
+
+func formatXattr(value []byte, ok bool) string {
+	if !ok {
+		return "<none>"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// diffNodes compares the files in source and target image, the root
+// directory is ignored.
+func diffNodes(sourceNodes, targetNodes map[string]Node) *DiffReport {
+	report := &DiffReport{
+		SourceFiles: len(sourceNodes),
+		TargetFiles: len(targetNodes),
+		Diffs:       []FileDiff{},
+	}
+	for path, sourceNode := range sourceNodes {
+		targetNode, exist := targetNodes[path]
+		if !exist {
+			report.Diffs = append(report.Diffs, FileDiff{Path: path, Kind: DiffMissingInTarget})
+			continue
+		}
+		if path == "/" {
+			continue
+		}
+		if fields := compareNode(&sourceNode, &targetNode); len(fields) > 0 {
+			report.Diffs = append(report.Diffs, FileDiff{Path: path, Kind: DiffMismatch, Fields: fields})
+		}
+	}
+	for path := range targetNodes {
+		if _, exist := sourceNodes[path]; !exist {
+			report.Diffs = append(report.Diffs, FileDiff{Path: path, Kind: DiffMissingInSource})
+		}
+	}
+	sort.Slice(report.Diffs, func(i, j int) bool {
+		return report.Diffs[i].Path < report.Diffs[j].Path
+	})
+	return report
+}
+
+// String summarizes the file difference in a line.
+func (diff *FileDiff) String() string {
+	switch diff.Kind {
+	case DiffMissingInTarget:
+		return fmt.Sprintf("%s: not found in target image", diff.Path)
+	case DiffMissingInSource:
+		return fmt.Sprintf("%s: not found in source image", diff.Path)
+	}
+	fields := make([]string, 0, len(diff.Fields))
+	for _, field := range diff.Fields {
+		fields = append(fields, fmt.Sprintf("%s [source] %s [target] %s", field.Field, field.Source, field.Target))
+	}
+	return fmt.Sprintf("%s: %s", diff.Path, strings.Join(fields, ", "))
+}
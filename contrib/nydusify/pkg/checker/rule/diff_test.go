@@ -0,0 +1,101 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestDiffNodes(t *testing.T) {
+	file := Node{
+		Path:    "/file",
+		Size:    10,
+		Mode:    0644,
+		Xattrs:  map[string][]byte{"user.a": []byte("1")},
+		Hash:    []byte{1},
+		ModTime: 1700000000,
+	}
+	device := Node{Path: "/dev/null", Mode: os.ModeDevice | os.ModeCharDevice | 0666, Rdev: unix.Mkdev(1, 3)}
+	source := map[string]Node{
+		"/":         {Path: "/", Mode: os.ModeDir | 0755},
+		"/file":     file,
+		"/dev/null": device,
+		"/removed":  {Path: "/removed"},
+	}
+
+	changedFile := file
+	changedFile.Xattrs = map[string][]byte{"user.b": []byte("2")}
+	changedFile.Links = []string{"/link"}
+	changedFile.ModTime = 1700000001
+	changedDevice := device
+	changedDevice.Rdev = unix.Mkdev(1, 5)
+	target := map[string]Node{
+		"/":         {Path: "/", Mode: os.ModeDir | 0700},
+		"/file":     changedFile,
+		"/dev/null": changedDevice,
+		"/added":    {Path: "/added"},
+	}
+
+	require.Empty(t, diffNodes(source, source).Diffs)
+
+	report := diffNodes(source, target)
+	require.Equal(t, 4, report.SourceFiles)
+	require.Equal(t, 4, report.TargetFiles)
+	require.Equal(t, []FileDiff{
+		{Path: "/added", Kind: DiffMissingInSource},
+		{Path: "/dev/null", Kind: DiffMismatch, Fields: []FieldDiff{
+			{Field: "rdev", Source: "1:3", Target: "1:5"},
+		}},
+		{Path: "/file", Kind: DiffMismatch, Fields: []FieldDiff{
+			{Field: "links", Source: "", Target: "/link"},
+			{Field: "mtime", Source: "2023-11-14T22:13:20Z", Target: "2023-11-14T22:13:21Z"},
+			{Field: "xattr user.a", Source: `"1"`, Target: "<none>"},
+			{Field: "xattr user.b", Source: "<none>", Target: `"2"`},
+		}},
+		{Path: "/removed", Kind: DiffMissingInTarget},
+	}, report.Diffs)
+	require.Equal(t, "/removed: not found in target image", report.Diffs[3].String())
+	require.Equal(t, "/dev/null: rdev [source] 1:3 [target] 1:5", report.Diffs[1].String())
+}
+
+func TestCompareHoles(t *testing.T) {
+	source := Node{Path: "/sparse", Holes: []Extent{{Offset: 0, Length: 4096}}}
+	// The holes are ignored if not reported by target filesystem.
+	require.Empty(t, compareNode(&source, &Node{Path: "/sparse"}))
+	require.Equal(t, []FieldDiff{{Field: "holes", Source: "[0+4096]", Target: "[0+8192]"}},
+		compareNode(&source, &Node{Path: "/sparse", Holes: []Extent{{Offset: 0, Length: 8192}}}))
+}
+
+func TestWalk(t *testing.T) {
+	rootfs := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "file"), []byte("data"), 0644))
+	require.NoError(t, os.Link(filepath.Join(rootfs, "file"), filepath.Join(rootfs, "link")))
+	sparse, err := os.Create(filepath.Join(rootfs, "sparse"))
+	require.NoError(t, err)
+	_, err = sparse.WriteAt([]byte("data"), 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, sparse.Close())
+
+	rule := FilesystemRule{}
+	nodes, err := rule.walk(rootfs)
+	require.NoError(t, err)
+	require.Len(t, nodes, 4)
+	require.Equal(t, []string{"/link"}, nodes["/file"].Links)
+	require.Equal(t, []string{"/file"}, nodes["/link"].Links)
+	require.NotZero(t, nodes["/file"].ModTime)
+	require.Zero(t, nodes["/"].ModTime)
+
+	holes := nodes["/sparse"].Holes
+	if holes == nil {
+		t.Skip("holes are not reported by filesystem")
+	}
+	require.Equal(t, int64(0), holes[0].Offset)
+	require.LessOrEqual(t, holes[0].Length, int64(1<<20))
+}
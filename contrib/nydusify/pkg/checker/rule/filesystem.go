@@ -11,7 +11,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"reflect"
+	"sort"
 	"syscall"
 
 	modelspec "github.com/CloudNativeAI/model-spec/specs-go/v1"
@@ -23,11 +23,18 @@ import (
 	"github.com/pkg/errors"
 	"github.com/pkg/xattr"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
 )
 
 // WorkerCount specifies source layer pull concurrency
 var WorkerCount uint = 8
 
+// diffReportFileName is the report in work directory of the files differing
+// between source and target image.
+const diffReportFileName = "filesystem_diff.json"
+
+const maxPrintedDiffs = 20
+
 // FilesystemRule compares file metadata and data in the two mountpoints:
 // Mounted by nydusd for nydus image,
 // Mounted by Overlayfs for OCI image.
@@ -41,6 +48,9 @@ type FilesystemRule struct {
 	SourceBackendConfig string
 	TargetBackendType   string
 	TargetBackendConfig string
+	// Deep reads all the files of target image even if no source image
+	// to compare with, the chunk digests are validated by nydusd for v5.
+	Deep bool
 }
 
 type Image struct {
@@ -59,6 +69,19 @@ type Node struct {
 	GID     uint32
 	Xattrs  map[string][]byte
 	Hash    []byte
+	// Links are the other paths hardlinked to the file in rootfs.
+	Links []string
+	// ModTime is the modification time in seconds, it's ignored for the
+	// directories, which may be created implicitly without timestamp.
+	ModTime int64
+	// Holes are the holes of sparse file, nil if no hole is reported.
+	Holes []Extent
+}
+
+// Extent is a range of file.
+type Extent struct {
+	Offset int64
+	Length int64
 }
 
 type RegistryBackendConfig struct {
@@ -72,8 +95,8 @@ type RegistryBackendConfig struct {
 func (node *Node) String() string {
 	return fmt.Sprintf(
 		"path: %s, size: %d, mode: %d, rdev: %d, symink: %s, uid: %d, gid: %d, "+
-			"xattrs: %v, hash: %s", node.Path, node.Size, node.Mode, node.Rdev, node.Symlink,
-		node.UID, node.GID, node.Xattrs, hex.EncodeToString(node.Hash),
+			"xattrs: %v, hash: %s, links: %v, mtime: %d, holes: %v", node.Path, node.Size, node.Mode, node.Rdev, node.Symlink,
+		node.UID, node.GID, node.Xattrs, hex.EncodeToString(node.Hash), node.Links, node.ModTime, node.Holes,
 	)
 }
 
@@ -100,8 +123,44 @@ func getXattrs(path string) (map[string][]byte, error) {
 	return xattrs, nil
 }
 
+// fileHoles returns the holes of file by SEEK_DATA and SEEK_HOLE, or nil if
+// the filesystem doesn't support them.
+func fileHoles(path string, size int64) ([]Extent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", path)
+	}
+	defer file.Close()
+
+	fd := int(file.Fd())
+	var holes []Extent
+	for offset := int64(0); offset < size; {
+		data, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err == unix.ENXIO {
+			// No more data until the end of file.
+			holes = append(holes, Extent{Offset: offset, Length: size - offset})
+			break
+		} else if err != nil {
+			return nil, nil
+		}
+		if data > offset {
+			holes = append(holes, Extent{Offset: offset, Length: data - offset})
+		}
+		if offset, err = unix.Seek(fd, data, unix.SEEK_HOLE); err != nil {
+			return nil, nil
+		}
+	}
+	return holes, nil
+}
+
+type inodeKey struct {
+	dev uint64
+	ino uint64
+}
+
 func (rule *FilesystemRule) walk(rootfs string) (map[string]Node, error) {
 	nodes := map[string]Node{}
+	inodes := map[inodeKey][]string{}
 
 	if err := filepath.Walk(rootfs, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -143,11 +202,27 @@ func (rule *FilesystemRule) walk(rootfs string) (map[string]Node, error) {
 		// Calculate file data hash if the `backend-type` option be specified,
 		// this will cause that nydusd read data from backend, it's network load
 		var hash []byte
+		var holes []Extent
 		if info.Mode().IsRegular() {
 			hash, err = utils.HashFile(path)
 			if err != nil {
 				return err
 			}
+			// The allocated blocks are less than size for sparse file.
+			if stat.Blocks*512 < size {
+				if holes, err = fileHoles(path, size); err != nil {
+					return err
+				}
+			}
+		}
+
+		var modTime int64
+		if !info.IsDir() {
+			modTime = info.ModTime().Unix()
+			if stat.Nlink > 1 {
+				key := inodeKey{dev: uint64(stat.Dev), ino: stat.Ino}
+				inodes[key] = append(inodes[key], rootfsPath)
+			}
 		}
 
 		node := Node{
@@ -160,6 +235,8 @@ func (rule *FilesystemRule) walk(rootfs string) (map[string]Node, error) {
 			GID:     stat.Gid,
 			Xattrs:  xattrs,
 			Hash:    hash,
+			ModTime: modTime,
+			Holes:   holes,
 		}
 		nodes[rootfsPath] = node
 
@@ -168,6 +245,24 @@ func (rule *FilesystemRule) walk(rootfs string) (map[string]Node, error) {
 		return nil, err
 	}
 
+	// The link count may include the links hidden by upper layers, so
+	// compare the hardlinks visible in rootfs.
+	for _, paths := range inodes {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			node := nodes[path]
+			for _, link := range paths {
+				if link != path {
+					node.Links = append(node.Links, link)
+				}
+			}
+			nodes[path] = node
+		}
+	}
+
 	return nodes, nil
 }
 
@@ -386,26 +481,52 @@ func (rule *FilesystemRule) verify(sourceRootfs, targetRootfs string) error {
 		return errors.Wrap(err, "walk rootfs of source image")
 	}
 
-	for path, sourceNode := range sourceNodes {
-		targetNode, exist := targetNodes[path]
-		if !exist {
-			return fmt.Errorf("file not found in target image: %s", path)
-		}
-		delete(targetNodes, path)
+	report := diffNodes(sourceNodes, targetNodes)
+	if len(report.Diffs) == 0 {
+		return nil
+	}
 
-		if path != "/" && !reflect.DeepEqual(sourceNode, targetNode) {
-			return fmt.Errorf("file not match in target image:\n\t[source] %s\n\t[target] %s", sourceNode.String(), targetNode.String())
+	// Print the first differences, and save all of them into the report.
+	for idx, diff := range report.Diffs {
+		if idx == maxPrintedDiffs {
+			logrus.Errorf("... and %d more differences", len(report.Diffs)-idx)
+			break
 		}
+		logrus.Error(diff.String())
+	}
+	reportPath := filepath.Join(rule.WorkDir, diffReportFileName)
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal diff report")
 	}
+	if err := os.WriteFile(reportPath, data, 0644); err != nil {
+		return errors.Wrap(err, "write diff report")
+	}
+	return fmt.Errorf("%d files differ between source and target image, see the report %s", len(report.Diffs), reportPath)
+}
 
-	for path := range targetNodes {
-		return fmt.Errorf("file not found in source image: %s", path)
+// readAll mounts the target image, and reads all the files by walking.
+func (rule *FilesystemRule) readAll() error {
+	umountTarget, err := rule.mountImage(rule.TargetImage, "target")
+	if err != nil {
+		return err
 	}
+	defer umountTarget()
 
+	logrus.Infof("reading filesystem")
+	nodes, err := rule.walk(filepath.Join(rule.WorkDir, "target/mnt"))
+	if err != nil {
+		return errors.Wrap(err, "walk rootfs of target image")
+	}
+	logrus.Infof("read %d files", len(nodes))
 	return nil
 }
 
 func (rule *FilesystemRule) Validate() error {
+	if rule.SourceImage.Parsed == nil && rule.TargetImage.Parsed != nil && rule.Deep {
+		return rule.readAll()
+	}
+
 	// Skip filesystem validation if no source or target image be specified
 	if rule.SourceImage.Parsed == nil || rule.TargetImage.Parsed == nil {
 		return nil
@@ -0,0 +1,189 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// annotationDockerReferenceType marks the attestation manifests in index
+// pushed by buildkit, which have no valid platform.
+const annotationDockerReferenceType = "vnd.docker.reference.type"
+
+// IndexRule validates the consistency of the platform entries in the index
+// of target image, e.g. the index merging the OCI and Nydus manifests by
+// `--merge-platform`.
+type IndexRule struct {
+	TargetParsed *parser.Parsed
+}
+
+func (rule *IndexRule) Name() string {
+	return "index"
+}
+
+func isNydusDesc(desc *ocispec.Descriptor) bool {
+	return desc.ArtifactType == utils.ArtifactTypeNydusImageManifest || utils.IsNydusPlatform(desc.Platform)
+}
+
+// platformKey formats the platform without the OS features, which are used
+// to mark Nydus manifest.
+func platformKey(platform *ocispec.Platform) string {
+	return platforms.Format(platforms.Normalize(ocispec.Platform{
+		OS:           platform.OS,
+		Architecture: platform.Architecture,
+		Variant:      platform.Variant,
+	}))
+}
+
+// indexEntries returns the OCI and Nydus manifests in index by platform,
+// the attestation manifests are ignored.
+func indexEntries(index *ocispec.Index) (map[string][]ocispec.Descriptor, map[string][]ocispec.Descriptor, []string) {
+	var problems []string
+	ociEntries := map[string][]ocispec.Descriptor{}
+	nydusEntries := map[string][]ocispec.Descriptor{}
+	for _, desc := range index.Manifests {
+		if desc.Annotations[annotationDockerReferenceType] != "" {
+			continue
+		}
+		if desc.Platform == nil {
+			if isNydusDesc(&desc) {
+				problems = append(problems, fmt.Sprintf("nydus manifest %s has no platform", desc.Digest))
+			}
+			continue
+		}
+		key := platformKey(desc.Platform)
+		if isNydusDesc(&desc) {
+			nydusEntries[key] = append(nydusEntries[key], desc)
+		} else {
+			ociEntries[key] = append(ociEntries[key], desc)
+		}
+	}
+	return ociEntries, nydusEntries, problems
+}
+
+// checkIndex validates the platform entries of index: a platform has at
+// most one OCI manifest and one Nydus manifest, and every Nydus manifest
+// has the OCI manifest of the same platform in the merged index.
+func checkIndex(index *ocispec.Index) error {
+	ociEntries, nydusEntries, problems := indexEntries(index)
+	for key, descs := range ociEntries {
+		if len(descs) > 1 {
+			problems = append(problems, fmt.Sprintf("%d OCI manifests for platform %s", len(descs), key))
+		}
+	}
+	for key, descs := range nydusEntries {
+		if len(descs) > 1 {
+			problems = append(problems, fmt.Sprintf("%d nydus manifests for platform %s", len(descs), key))
+		}
+		// The index is merged if containing any OCI manifest.
+		if len(ociEntries) > 0 && len(ociEntries[key]) == 0 {
+			problems = append(problems, fmt.Sprintf("nydus manifest %s for platform %s has no OCI manifest of the same platform", descs[0].Digest, key))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkPlatformManifest validates the manifest and config by the platform
+// entry in index.
+func checkPlatformManifest(desc *ocispec.Descriptor, manifest *ocispec.Manifest, config *ocispec.Image) error {
+	isNydus := parser.FindNydusBootstrapDesc(manifest) != nil
+	if isNydusDesc(desc) && !isNydus {
+		return fmt.Errorf("manifest %s is marked as nydus manifest in index, but has no nydus bootstrap layer", desc.Digest)
+	}
+	if !isNydusDesc(desc) && isNydus {
+		return fmt.Errorf("nydus manifest %s is not marked in index", desc.Digest)
+	}
+	expected := platformKey(desc.Platform)
+	actual := platformKey(&config.Platform)
+	if config.Variant == "" {
+		expected = platformKey(&ocispec.Platform{OS: desc.Platform.OS, Architecture: desc.Platform.Architecture})
+	}
+	if expected != actual {
+		return fmt.Errorf("platform of manifest %s is %s in index, but %s in image config", desc.Digest, expected, actual)
+	}
+	return nil
+}
+
+// pullJSON pulls the resource by descriptor and verifies its digest.
+func pullJSON(ctx context.Context, rmt *remote.Remote, desc ocispec.Descriptor, v interface{}) error {
+	reader, err := rmt.Pull(ctx, desc, true)
+	if err != nil {
+		return errors.Wrapf(err, "pull %s", desc.Digest)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return errors.Wrapf(err, "read %s", desc.Digest)
+	}
+	if actual := digest.FromBytes(data); actual != desc.Digest {
+		return fmt.Errorf("digest mismatch of %s, read %s", desc.Digest, actual)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (rule *IndexRule) Validate() error {
+	parsed := rule.TargetParsed
+	if parsed == nil || parsed.Index == nil {
+		return nil
+	}
+
+	logrus.WithField("image", parsed.Remote.Ref).Info("checking index")
+	if err := checkIndex(parsed.Index); err != nil {
+		return errors.Wrap(err, "target image: inconsistent index")
+	}
+
+	ctx := context.Background()
+	ociEntries, nydusEntries, _ := indexEntries(parsed.Index)
+	var problems []string
+	for _, entries := range []map[string][]ocispec.Descriptor{ociEntries, nydusEntries} {
+		for _, descs := range entries {
+			desc := descs[0]
+			var manifest ocispec.Manifest
+			if err := pullJSON(ctx, parsed.Remote, desc, &manifest); err != nil {
+				return errors.Wrap(err, "pull manifest")
+			}
+			var config ocispec.Image
+			if err := pullJSON(ctx, parsed.Remote, manifest.Config, &config); err != nil {
+				return errors.Wrap(err, "pull image config")
+			}
+			if err := checkPlatformManifest(&desc, &manifest, &config); err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			// The Nydus manifest associated by `--with-referrer` in merged
+			// index should refer to the OCI manifest of the same platform.
+			if manifest.Subject != nil && isNydusDesc(&desc) {
+				if oci := ociEntries[platformKey(desc.Platform)]; len(oci) > 0 && oci[0].Digest != manifest.Subject.Digest {
+					problems = append(problems, fmt.Sprintf("nydus manifest %s refers to %s, but the OCI manifest of the same platform is %s", desc.Digest, manifest.Subject.Digest, oci[0].Digest))
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("target image: inconsistent index: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
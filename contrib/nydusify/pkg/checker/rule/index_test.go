@@ -0,0 +1,68 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestCheckIndex(t *testing.T) {
+	amd64 := &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	nydusAMD64 := &ocispec.Platform{OS: "linux", Architecture: "amd64", OSFeatures: []string{utils.ManifestOSFeatureNydus}}
+	ociAMD64 := ocispec.Descriptor{Digest: digest.FromString("oci-amd64"), Platform: amd64}
+	ociARM64 := ocispec.Descriptor{Digest: digest.FromString("oci-arm64"), Platform: arm64}
+	nydusAMD64Desc := ocispec.Descriptor{Digest: digest.FromString("nydus-amd64"), Platform: nydusAMD64}
+	nydusARM64Desc := ocispec.Descriptor{
+		Digest:       digest.FromString("nydus-arm64"),
+		Platform:     &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+		ArtifactType: utils.ArtifactTypeNydusImageManifest,
+	}
+	attestation := ocispec.Descriptor{
+		Digest:      digest.FromString("attestation"),
+		Platform:    &ocispec.Platform{OS: "unknown", Architecture: "unknown"},
+		Annotations: map[string]string{annotationDockerReferenceType: "attestation-manifest"},
+	}
+
+	// Merged index.
+	require.NoError(t, checkIndex(&ocispec.Index{Manifests: []ocispec.Descriptor{ociAMD64, ociARM64, nydusAMD64Desc, nydusARM64Desc, attestation}}))
+	// Nydus only index.
+	require.NoError(t, checkIndex(&ocispec.Index{Manifests: []ocispec.Descriptor{nydusAMD64Desc, nydusARM64Desc}}))
+	// The OCI manifest may be not converted.
+	require.NoError(t, checkIndex(&ocispec.Index{Manifests: []ocispec.Descriptor{ociAMD64, ociARM64, nydusAMD64Desc}}))
+
+	err := checkIndex(&ocispec.Index{Manifests: []ocispec.Descriptor{ociAMD64, nydusAMD64Desc, nydusARM64Desc}})
+	require.EqualError(t, err, "nydus manifest "+nydusARM64Desc.Digest.String()+" for platform linux/arm64 has no OCI manifest of the same platform")
+
+	err = checkIndex(&ocispec.Index{Manifests: []ocispec.Descriptor{ociAMD64, ociAMD64, nydusAMD64Desc, {Digest: digest.FromString("no-platform"), ArtifactType: utils.ArtifactTypeNydusImageManifest}}})
+	require.ErrorContains(t, err, "2 OCI manifests for platform linux/amd64")
+	require.ErrorContains(t, err, "has no platform")
+}
+
+func TestCheckPlatformManifest(t *testing.T) {
+	nydusDesc := ocispec.Descriptor{
+		Digest:   digest.FromString("nydus"),
+		Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8", OSFeatures: []string{utils.ManifestOSFeatureNydus}},
+	}
+	nydusManifest := ocispec.Manifest{Layers: []ocispec.Descriptor{{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}}}
+	ociManifest := ocispec.Manifest{Layers: []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip}}}
+	config := ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: "arm64"}}
+
+	require.NoError(t, checkPlatformManifest(&nydusDesc, &nydusManifest, &config))
+	require.ErrorContains(t, checkPlatformManifest(&nydusDesc, &ociManifest, &config), "has no nydus bootstrap layer")
+
+	ociDesc := ocispec.Descriptor{Digest: digest.FromString("oci"), Platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"}}
+	require.ErrorContains(t, checkPlatformManifest(&ociDesc, &nydusManifest, &config), "is not marked in index")
+	require.ErrorContains(t, checkPlatformManifest(&ociDesc, &ociManifest, &config), "is linux/amd64 in index, but linux/arm64 in image config")
+}
@@ -0,0 +1,251 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// prefetchReportFileName is the report in work directory of the prefetch
+// coverage of target image.
+const prefetchReportFileName = "prefetch_coverage.json"
+
+// PrefetchRule mounts the target image by nydusd and replays the prefetch
+// list by reading all the files in it, to report how many of the prefetch
+// entries exist and how much of the blob data they cover. The prefetch list
+// is read from PrefetchFilesPath if specified, otherwise from the prefetch
+// table of bootstrap.
+type PrefetchRule struct {
+	WorkDir           string
+	NydusImagePath    string
+	NydusdPath        string
+	PrefetchFilesPath string
+
+	TargetImage         *Image
+	TargetBackendType   string
+	TargetBackendConfig string
+}
+
+// PrefetchReport is the prefetch coverage of nydus image.
+type PrefetchReport struct {
+	Entries  int      `json:"entries"`
+	Existing int      `json:"existing"`
+	Missing  []string `json:"missing"`
+	// Files and Size are the regular files read by replaying the prefetch
+	// list, the files in the prefetched directories are included.
+	Files int   `json:"files"`
+	Size  int64 `json:"size"`
+	// BlobSize is the total uncompressed size of blobs in bootstrap.
+	BlobSize int64  `json:"blob_size"`
+	Duration string `json:"duration"`
+}
+
+func (rule *PrefetchRule) Name() string {
+	return "prefetch"
+}
+
+// readPrefetchFiles reads the prefetch list in the format of nydusify
+// `--prefetch-patterns`, one absolute path per line.
+func readPrefetchFiles(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open prefetch files")
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		entries = append(entries, filepath.Clean("/"+line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read prefetch files")
+	}
+	return entries, nil
+}
+
+// prefetchTable reads the prefetch list from the prefetch table of bootstrap,
+// one entry for each path of the prefetched inodes.
+func (rule *PrefetchRule) prefetchTable(bootstrapPath string) ([]string, error) {
+	inspector := tool.NewInspector(rule.NydusImagePath)
+	result, err := inspector.Inspect(tool.InspectOption{
+		Operation: tool.GetPrefetch,
+		Bootstrap: bootstrapPath,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "inspect prefetch table")
+	}
+	var entries []string
+	for _, info := range result.([]tool.PrefetchInfo) {
+		for _, path := range info.Path {
+			entries = append(entries, filepath.Clean("/"+path))
+		}
+	}
+	return entries, nil
+}
+
+func (rule *PrefetchRule) blobSize(bootstrapPath string) (int64, error) {
+	inspector := tool.NewInspector(rule.NydusImagePath)
+	result, err := inspector.Inspect(tool.InspectOption{
+		Operation: tool.GetBlobs,
+		Bootstrap: bootstrapPath,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "inspect blobs")
+	}
+	var size int64
+	for _, blob := range result.(tool.BlobInfoList) {
+		size += int64(blob.DecompressedSize)
+	}
+	return size, nil
+}
+
+// replayPrefetch reads the prefetch entries in rootfs, the files in the
+// prefetched directories are read recursively, and every file is read
+// only once.
+func replayPrefetch(rootfs string, entries []string) (*PrefetchReport, error) {
+	report := &PrefetchReport{Entries: len(entries), Missing: []string{}}
+	read := map[string]bool{}
+
+	readFile := func(path string, info fs.FileInfo) error {
+		if !info.Mode().IsRegular() || read[path] {
+			return nil
+		}
+		read[path] = true
+		file, err := os.Open(path)
+		if err != nil {
+			return errors.Wrapf(err, "open prefetch file %s", path)
+		}
+		defer file.Close()
+		if _, err := io.Copy(io.Discard, file); err != nil {
+			return errors.Wrapf(err, "read prefetch file %s", path)
+		}
+		report.Files++
+		report.Size += info.Size()
+		return nil
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(rootfs, entry)
+		info, err := os.Lstat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				report.Missing = append(report.Missing, entry)
+				continue
+			}
+			return nil, errors.Wrapf(err, "stat prefetch entry %s", entry)
+		}
+		report.Existing++
+		if !info.IsDir() {
+			if err := readFile(path, info); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := filepath.Walk(path, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return readFile(path, info)
+		}); err != nil {
+			return nil, errors.Wrapf(err, "walk prefetch directory %s", entry)
+		}
+	}
+
+	return report, nil
+}
+
+func ratio(part, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", float64(part)*100/float64(total))
+}
+
+func (rule *PrefetchRule) Validate() error {
+	if rule.TargetImage.Parsed == nil || rule.TargetImage.Parsed.NydusImage == nil {
+		return nil
+	}
+
+	bootstrapPath := filepath.Join(rule.WorkDir, "target/nydus_bootstrap", utils.BootstrapFileNameInLayer)
+	var entries []string
+	var err error
+	if rule.PrefetchFilesPath != "" {
+		entries, err = readPrefetchFiles(rule.PrefetchFilesPath)
+	} else {
+		entries, err = rule.prefetchTable(bootstrapPath)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		logrus.Info("skip checking prefetch coverage, no prefetch entry found")
+		return nil
+	}
+
+	blobSize, err := rule.blobSize(bootstrapPath)
+	if err != nil {
+		return err
+	}
+
+	fsRule := FilesystemRule{
+		WorkDir:             rule.WorkDir,
+		NydusdPath:          rule.NydusdPath,
+		TargetImage:         rule.TargetImage,
+		TargetBackendType:   rule.TargetBackendType,
+		TargetBackendConfig: rule.TargetBackendConfig,
+	}
+	umount, err := fsRule.mountNydusImage(rule.TargetImage, "target")
+	if err != nil {
+		return err
+	}
+	defer umount()
+
+	logrus.Infof("replaying %d prefetch entries", len(entries))
+	start := time.Now()
+	report, err := replayPrefetch(filepath.Join(rule.WorkDir, "target/mnt"), entries)
+	if err != nil {
+		return err
+	}
+	report.BlobSize = blobSize
+	report.Duration = time.Since(start).String()
+
+	for _, entry := range report.Missing {
+		logrus.Warnf("prefetch entry %s is not found in image", entry)
+	}
+	logrus.Infof(
+		"prefetch coverage: %d of %d (%s) entries exist, %d files of %d bytes read in %s, cover %s of blob data",
+		report.Existing, report.Entries, ratio(int64(report.Existing), int64(report.Entries)),
+		report.Files, report.Size, report.Duration, ratio(report.Size, report.BlobSize),
+	)
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal prefetch report")
+	}
+	if err := os.WriteFile(filepath.Join(rule.WorkDir, prefetchReportFileName), data, 0644); err != nil {
+		return errors.Wrap(err, "write prefetch report")
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefetchName(t *testing.T) {
+	rule := PrefetchRule{}
+	require.Equal(t, "prefetch", rule.Name())
+}
+
+func TestReadPrefetchFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefetch.files")
+	require.NoError(t, os.WriteFile(path, []byte("/usr/bin\n\n  /etc/hosts  \nlib/\n"), 0644))
+	entries, err := readPrefetchFiles(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/usr/bin", "/etc/hosts", "/lib"}, entries)
+}
+
+func TestReplayPrefetch(t *testing.T) {
+	rootfs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "usr/bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "usr/bin/sh"), []byte("shell"), 0755))
+	require.NoError(t, os.Symlink("sh", filepath.Join(rootfs, "usr/bin/bash")))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "etc"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "etc/hosts"), []byte("localhost"), 0644))
+
+	report, err := replayPrefetch(rootfs, []string{"/usr/bin", "/usr/bin/sh", "/etc/hosts", "/not-found"})
+	require.NoError(t, err)
+	require.Equal(t, 4, report.Entries)
+	require.Equal(t, 3, report.Existing)
+	require.Equal(t, []string{"/not-found"}, report.Missing)
+	// The file in the prefetched directory is read only once.
+	require.Equal(t, 2, report.Files)
+	require.Equal(t, int64(len("shell")+len("localhost")), report.Size)
+
+	require.Equal(t, "50.00%", ratio(1, 2))
+	require.Equal(t, "-", ratio(1, 0))
+}
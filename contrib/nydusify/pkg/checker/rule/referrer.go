@@ -0,0 +1,97 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/v2/core/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+)
+
+// ReferrerRule validates the subject of Nydus manifest associated to the
+// source image by `--with-referrer`, which should refer to the source OCI
+// manifest of the same platform.
+type ReferrerRule struct {
+	SourceParsed *parser.Parsed
+	TargetParsed *parser.Parsed
+	// Required fails the check if the Nydus manifest has no subject.
+	Required bool
+}
+
+func (rule *ReferrerRule) Name() string {
+	return "referrer"
+}
+
+// checkSubject validates the subject of Nydus manifest by the source OCI
+// manifest, or by the manifest and config of subject if no source.
+func checkSubject(subject *ocispec.Descriptor, target *parser.Image, source *ocispec.Descriptor, subjectManifest *ocispec.Manifest, subjectConfig *ocispec.Image) error {
+	if subject.MediaType != ocispec.MediaTypeImageManifest && subject.MediaType != images.MediaTypeDockerSchema2Manifest {
+		return fmt.Errorf("subject %s is not an image manifest: %s", subject.Digest, subject.MediaType)
+	}
+	if source != nil {
+		if subject.Digest != source.Digest {
+			return fmt.Errorf("subject %s doesn't refer to source manifest %s", subject.Digest, source.Digest)
+		}
+		return nil
+	}
+	if parser.FindNydusBootstrapDesc(subjectManifest) != nil {
+		return fmt.Errorf("subject %s refers to a nydus manifest", subject.Digest)
+	}
+	if subjectConfig.OS != target.Config.OS || subjectConfig.Architecture != target.Config.Architecture {
+		return fmt.Errorf(
+			"platform of subject %s is %s/%s, but %s/%s of nydus manifest", subject.Digest,
+			subjectConfig.OS, subjectConfig.Architecture, target.Config.OS, target.Config.Architecture,
+		)
+	}
+	return nil
+}
+
+func (rule *ReferrerRule) Validate() error {
+	parsed := rule.TargetParsed
+	if parsed == nil || parsed.NydusImage == nil {
+		return nil
+	}
+
+	subject := parsed.NydusImage.Manifest.Subject
+	if subject == nil {
+		if rule.Required {
+			return errors.New("target image: nydus manifest has no subject referring to source image")
+		}
+		return nil
+	}
+
+	logrus.WithField("image", parsed.Remote.Ref).WithField("subject", subject.Digest).Info("checking referrer")
+	if rule.SourceParsed != nil {
+		if rule.SourceParsed.OCIImage == nil {
+			return errors.New("source image: no OCI manifest found to be referred by nydus manifest")
+		}
+		if err := checkSubject(subject, parsed.NydusImage, &rule.SourceParsed.OCIImage.Desc, nil, nil); err != nil {
+			return errors.Wrap(err, "target image: invalid referrer")
+		}
+		return nil
+	}
+
+	// The subject is pushed to the repository of target image by conversion.
+	ctx := context.Background()
+	var manifest ocispec.Manifest
+	if err := pullJSON(ctx, parsed.Remote, *subject, &manifest); err != nil {
+		return errors.Wrap(err, "target image: pull subject manifest")
+	}
+	var config ocispec.Image
+	if err := pullJSON(ctx, parsed.Remote, manifest.Config, &config); err != nil {
+		return errors.Wrap(err, "target image: pull subject config")
+	}
+	if err := checkSubject(subject, parsed.NydusImage, nil, &manifest, &config); err != nil {
+		return errors.Wrap(err, "target image: invalid referrer")
+	}
+
+	return nil
+}
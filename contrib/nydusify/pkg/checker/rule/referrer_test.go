@@ -0,0 +1,36 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestCheckSubject(t *testing.T) {
+	target := &parser.Image{Config: ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"}}}
+	source := &ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("source")}
+	subject := &ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: source.Digest}
+
+	require.NoError(t, checkSubject(subject, target, source, nil, nil))
+	require.ErrorContains(t, checkSubject(&ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("other")}, target, source, nil, nil), "doesn't refer to source manifest")
+	require.ErrorContains(t, checkSubject(&ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: source.Digest}, target, source, nil, nil), "is not an image manifest")
+
+	ociManifest := &ocispec.Manifest{Layers: []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayerGzip}}}
+	nydusManifest := &ocispec.Manifest{Layers: []ocispec.Descriptor{{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}}}
+	config := &ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"}}
+	require.NoError(t, checkSubject(subject, target, nil, ociManifest, config))
+	require.ErrorContains(t, checkSubject(subject, target, nil, nydusManifest, config), "refers to a nydus manifest")
+	require.ErrorContains(t, checkSubject(subject, target, nil, ociManifest, &ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: "arm64"}}), "platform of subject")
+}
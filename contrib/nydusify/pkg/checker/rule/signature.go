@@ -0,0 +1,52 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/signer"
+)
+
+// SignatureRule verifies the cosign signature of nydus image, it's skipped
+// if neither public key nor certificate identity is specified.
+type SignatureRule struct {
+	CosignPath            string
+	Key                   string
+	CertificateIdentity   string
+	CertificateOIDCIssuer string
+
+	TargetParsed   *parser.Parsed
+	TargetInsecure bool
+}
+
+func (rule *SignatureRule) Name() string {
+	return "signature"
+}
+
+func (rule *SignatureRule) Validate() error {
+	if rule.Key == "" && rule.CertificateIdentity == "" {
+		return nil
+	}
+
+	ref := rule.TargetParsed.Remote.Ref
+	logrus.WithField("image", ref).Info("checking signature")
+
+	cosign := signer.NewCosign(rule.CosignPath)
+	if err := cosign.Verify(signer.VerifyOption{
+		Ref:                   ref,
+		Key:                   rule.Key,
+		CertificateIdentity:   rule.CertificateIdentity,
+		CertificateOIDCIssuer: rule.CertificateOIDCIssuer,
+		Insecure:              rule.TargetInsecure,
+		PlainHTTP:             rule.TargetParsed.Remote.IsWithHTTP(),
+	}); err != nil {
+		return errors.Wrap(err, "verify cosign signature")
+	}
+
+	return nil
+}
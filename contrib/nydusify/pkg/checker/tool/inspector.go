@@ -14,6 +14,7 @@ import (
 
 const (
 	GetBlobs = iota
+	GetPrefetch
 )
 
 type InspectOption struct {
@@ -41,6 +42,13 @@ func (infos BlobInfoList) String() string {
 	return string(jsonBytes)
 }
 
+// PrefetchInfo is an entry of the prefetch table in bootstrap, a file may
+// have multiple paths by hardlinks.
+type PrefetchInfo struct {
+	Inode uint64   `json:"inode"`
+	Path  []string `json:"path"`
+}
+
 type Inspector struct {
 	binaryPath string
 }
@@ -71,6 +79,18 @@ func (p *Inspector) Inspect(option InspectOption) (interface{}, error) {
 			return nil, err
 		}
 		return blobs, nil
+	case GetPrefetch:
+		args = append(args, "prefetch")
+		cmd := exec.Command(p.binaryPath, args...)
+		msg, err := cmd.CombinedOutput()
+		if err != nil {
+			return nil, errors.Wrap(err, string(msg))
+		}
+		var prefetch []PrefetchInfo
+		if err = json.Unmarshal(msg, &prefetch); err != nil {
+			return nil, err
+		}
+		return prefetch, nil
 	}
 	return nil, fmt.Errorf("not support method %d", option.Operation)
 }
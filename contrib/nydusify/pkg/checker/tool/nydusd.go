@@ -15,11 +15,14 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"syscall"
 	"text/template"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
 )
 
 type NydusdConfig struct {
@@ -36,11 +39,19 @@ type NydusdConfig struct {
 	MountPath                    string
 	Mode                         string
 	DigestValidate               bool
+	// NBDDevice exports the image as an EROFS block device through the NBD
+	// device node by `nydusd nbd` and mounts it instead of FUSE, which
+	// requires RAFS v6 image.
+	NBDDevice string
+	// Shared starts nydusd without bootstrap, the images are mounted by
+	// MountRafs under the subdirectories of mount path.
+	Shared bool
 }
 
 // Nydusd runs nydusd binary.
 type Nydusd struct {
 	NydusdConfig
+	process *os.Process
 }
 
 type daemonInfo struct {
@@ -76,8 +87,36 @@ var configTpl = `
 }
 `
 
+// blockConfigTpl is the blob cache entry for `nydusd nbd` to export the
+// image as a block device.
+var blockConfigTpl = `
+{
+	"type": "bootstrap",
+	"id": "nydusify",
+	"domain_id": "nydusify",
+	"config_v2": {
+		"version": 2,
+		"id": "nydusify",
+		"backend": {
+			"type": "{{.BackendType}}",
+			"{{.BackendType}}": {{.BackendConfig}}
+		},
+		"cache": {
+			"type": "filecache",
+			"filecache": {
+				"work_dir": "{{.BlobCacheDir}}"
+			}
+		},
+		"metadata_path": "{{.BootstrapPath}}"
+	}
+}
+`
+
 func makeConfig(conf NydusdConfig) error {
 	tpl := template.Must(template.New("").Parse(configTpl))
+	if conf.NBDDevice != "" {
+		tpl = template.Must(template.New("").Parse(blockConfigTpl))
+	}
 
 	var ret bytes.Buffer
 	if conf.BackendType == "" {
@@ -88,6 +127,14 @@ func makeConfig(conf NydusdConfig) error {
 			return errors.Errorf("empty backend configuration string")
 		}
 	}
+	if conf.BackendType == "ipfs" {
+		// Nydusd reads the blobs in IPFS from gateway.
+		backendType, backendConfig, err := backend.NydusdIPFSConfig(context.Background(), []byte(conf.BackendConfig))
+		if err != nil {
+			return errors.Wrap(err, "resolve IPFS backend")
+		}
+		conf.BackendType, conf.BackendConfig = backendType, backendConfig
+	}
 	if err := tpl.Execute(&ret, conf); err != nil {
 		return errors.New("failed to prepare configuration file for Nydusd")
 	}
@@ -99,10 +146,8 @@ func makeConfig(conf NydusdConfig) error {
 	return nil
 }
 
-// Wait until Nydusd ready by checking daemon state RUNNING
-func checkReady(ctx context.Context, sock string) (<-chan bool, error) {
-	ready := make(chan bool)
-
+// newAPIClient creates the HTTP client to access the API of nydusd.
+func newAPIClient(sock string) *http.Client {
 	transport := &http.Transport{
 		MaxIdleConns:          10,
 		IdleConnTimeout:       10 * time.Second,
@@ -116,10 +161,16 @@ func checkReady(ctx context.Context, sock string) (<-chan bool, error) {
 		},
 	}
 
-	client := &http.Client{
+	return &http.Client{
 		Timeout:   30 * time.Second,
 		Transport: transport,
 	}
+}
+
+// Wait until Nydusd ready by checking daemon state RUNNING
+func checkReady(ctx context.Context, sock string) (<-chan bool, error) {
+	ready := make(chan bool)
+	client := newAPIClient(sock)
 
 	go func() {
 		for {
@@ -183,15 +234,41 @@ func (nydusd *Nydusd) Mount() error {
 		"--log-level",
 		"warn",
 	}
+	if nydusd.Shared {
+		args = []string{
+			"--mountpoint",
+			nydusd.MountPath,
+			"--apisock",
+			nydusd.APISockPath,
+			"--log-level",
+			"warn",
+		}
+	}
+	if nydusd.NBDDevice != "" {
+		args = []string{
+			"nbd",
+			nydusd.NBDDevice,
+			"--config",
+			nydusd.ConfigPath,
+			"--apisock",
+			nydusd.APISockPath,
+			"--log-level",
+			"warn",
+		}
+	}
 
 	cmd := exec.Command(nydusd.NydusdPath, args...)
 	logrus.Debugf("Command: %s %s", nydusd.NydusdPath, strings.Join(args, " "))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "run Nydusd binary")
+	}
+	nydusd.process = cmd.Process
 	runErr := make(chan error)
 	go func() {
-		runErr <- cmd.Run()
+		runErr <- cmd.Wait()
 	}()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -208,6 +285,9 @@ func (nydusd *Nydusd) Mount() error {
 			return errors.Wrap(err, "run Nydusd binary")
 		}
 	case <-ready:
+		if nydusd.NBDDevice != "" {
+			return nydusd.mountBlockDevice()
+		}
 		return nil
 	case <-time.After(30 * time.Second):
 		return errors.New("timeout to wait Nydusd ready")
@@ -216,6 +296,72 @@ func (nydusd *Nydusd) Mount() error {
 	return nil
 }
 
+type mountRequest struct {
+	Source string `json:"source"`
+	FsType string `json:"fs_type"`
+	Config string `json:"config"`
+}
+
+// MountRafs mounts the RAFS image to the subdirectory of mount path by
+// the API of shared nydusd, the mountpoint is relative to the mount path,
+// e.g. "/image".
+func (nydusd *Nydusd) MountRafs(mountpoint, bootstrapPath, configPath string) error {
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return errors.Wrap(err, "read config file for Nydusd")
+	}
+	body, err := json.Marshal(mountRequest{
+		Source: bootstrapPath,
+		FsType: "rafs",
+		Config: string(config),
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal mount request")
+	}
+
+	client := newAPIClient(nydusd.APISockPath)
+	url := fmt.Sprintf("http://unix/api/v1/mount?mountpoint=%s", mountpoint)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "request to mount %s", mountpoint)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to mount %s: %s %s", mountpoint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// mountBlockDevice mounts the EROFS filesystem in the block device
+// exported by nydusd.
+func (nydusd *Nydusd) mountBlockDevice() error {
+	cmd := exec.Command("mount", "-t", "erofs", "-o", "ro", nydusd.NBDDevice, nydusd.MountPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if stopErr := nydusd.stop(); stopErr != nil {
+			logrus.WithError(stopErr).Warn("failed to stop Nydusd")
+		}
+		return errors.Wrapf(err, "mount EROFS block device %s", nydusd.NBDDevice)
+	}
+	return nil
+}
+
+// stop stops nydusd serving the block device, which doesn't exit on
+// umount unlike FUSE.
+func (nydusd *Nydusd) stop() error {
+	if nydusd.process == nil {
+		return nil
+	}
+	if err := nydusd.process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	nydusd.process = nil
+	return nil
+}
+
 func (nydusd *Nydusd) Umount(silent bool) error {
 	if _, err := os.Stat(nydusd.MountPath); err == nil {
 		cmd := exec.Command("umount", nydusd.MountPath)
@@ -228,5 +374,8 @@ func (nydusd *Nydusd) Umount(silent bool) error {
 			return err
 		}
 	}
+	if nydusd.NBDDevice != "" {
+		return nydusd.stop()
+	}
 	return nil
 }
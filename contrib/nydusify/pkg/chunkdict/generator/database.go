@@ -0,0 +1,146 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+)
+
+// databaseStore persists the database and indexed images of work directory
+// in an object storage backend, to share them between the build nodes. The
+// objects are named by the files under the object prefix of backend.
+type databaseStore struct {
+	backend backend.Backend
+	workDir string
+	// base is the indexed images downloaded, to detect the database updated
+	// by other nodes meanwhile.
+	base []indexedImage
+}
+
+func newDatabaseStore(backendType, backendConfig, workDir string) (*databaseStore, error) {
+	bkd, err := backend.NewBackend(backendType, []byte(backendConfig), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new database backend")
+	}
+	return &databaseStore{backend: bkd, workDir: workDir}, nil
+}
+
+// remoteIndexedImages returns the indexed images in backend, or nil if the
+// database is not uploaded yet.
+func (store *databaseStore) remoteIndexedImages() ([]indexedImage, error) {
+	exist, err := store.backend.Check(indexFileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "check %s in backend", indexFileName)
+	}
+	if !exist {
+		return nil, nil
+	}
+	dir, err := os.MkdirTemp(store.workDir, "index-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp directory")
+	}
+	defer os.RemoveAll(dir)
+	if err := store.download(indexFileName, filepath.Join(dir, indexFileName)); err != nil {
+		return nil, err
+	}
+	return loadIndexedImages(dir)
+}
+
+// Download replaces the database and indexed images in work directory with
+// the ones in backend, nothing is changed if they are not uploaded yet.
+func (store *databaseStore) Download() error {
+	exist, err := store.backend.Check(indexFileName)
+	if err != nil {
+		return errors.Wrapf(err, "check %s in backend", indexFileName)
+	}
+	if !exist {
+		logrus.Infof("No database is found in backend")
+		return nil
+	}
+	// The index is uploaded after the database, so the database exists if
+	// the index exists.
+	for _, name := range []string{indexFileName, databaseFileName} {
+		if err := store.download(name, filepath.Join(store.workDir, name)); err != nil {
+			return err
+		}
+	}
+	store.base, err = loadIndexedImages(store.workDir)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Downloaded database of %d images from backend", len(store.base))
+	return nil
+}
+
+func (store *databaseStore) download(name, path string) error {
+	reader, err := store.backend.Reader(name)
+	if err != nil {
+		return errors.Wrapf(err, "read %s from backend", name)
+	}
+	defer reader.Close()
+
+	// Write into a temp file first, to keep the local one if failed.
+	tmpPath := path + ".download"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return errors.Wrapf(err, "create %s", tmpPath)
+	}
+	defer os.Remove(tmpPath)
+	if _, err := io.Copy(file, reader); err != nil {
+		file.Close()
+		return errors.Wrapf(err, "download %s", name)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return errors.Wrapf(os.Rename(tmpPath, path), "rename %s", tmpPath)
+}
+
+// Upload uploads the database and indexed images in work directory to
+// backend. Unless overwrite, it fails if the database in backend is updated
+// since downloaded, the generation should be retried on the updated database
+// then. The check is not atomic, so the nodes should not finish at the same
+// time.
+func (store *databaseStore) Upload(ctx context.Context, overwrite bool) error {
+	if !overwrite {
+		remote, err := store.remoteIndexedImages()
+		if err != nil {
+			return err
+		}
+		if len(remote) != 0 && !reflect.DeepEqual(remote, store.base) {
+			return errors.Errorf("database in backend is updated by others since downloaded (%d images indexed now, %d before), please retry", len(remote), len(store.base))
+		}
+	}
+
+	// Upload the index at last, which marks the database is complete.
+	for _, name := range []string{databaseFileName, indexFileName} {
+		path := filepath.Join(store.workDir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrapf(err, "stat %s", name)
+		}
+		if _, err := store.backend.Upload(ctx, name, path, info.Size(), true); err != nil {
+			if err := store.backend.Finalize(true); err != nil {
+				logrus.WithError(err).Warn("cancel uploading database")
+			}
+			return errors.Wrapf(err, "upload %s to backend", name)
+		}
+	}
+	// The multipart uploads of OSS are completed in order by finalization.
+	if err := store.backend.Finalize(false); err != nil {
+		return errors.Wrap(err, "finalize uploading database")
+	}
+	logrus.Infof("Uploaded database to backend")
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+)
+
+type fakeBackend struct {
+	objects   map[string][]byte
+	finalized int
+}
+
+func (b *fakeBackend) Upload(_ context.Context, blobID, blobPath string, _ int64, _ bool) (*ocispec.Descriptor, error) {
+	data, err := os.ReadFile(blobPath)
+	if err != nil {
+		return nil, err
+	}
+	b.objects[blobID] = data
+	return &ocispec.Descriptor{}, nil
+}
+
+func (b *fakeBackend) Finalize(bool) error {
+	b.finalized++
+	return nil
+}
+
+func (b *fakeBackend) Check(blobID string) (bool, error) {
+	_, ok := b.objects[blobID]
+	return ok, nil
+}
+
+func (b *fakeBackend) Type() backend.Type {
+	return backend.S3backend
+}
+
+func (b *fakeBackend) Reader(blobID string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b.objects[blobID])), nil
+}
+
+func (b *fakeBackend) RangeReader(string) (remotes.RangeReadCloser, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) Size(blobID string) (int64, error) {
+	return int64(len(b.objects[blobID])), nil
+}
+
+func TestDatabaseStore(t *testing.T) {
+	bkd := &fakeBackend{objects: map[string][]byte{}}
+	workDir := t.TempDir()
+	store := &databaseStore{backend: bkd, workDir: workDir}
+
+	// Nothing is downloaded at first.
+	require.NoError(t, store.Download())
+	_, err := os.Stat(filepath.Join(workDir, databaseFileName))
+	require.True(t, os.IsNotExist(err))
+
+	nginx := indexedImage{Reference: "nginx:latest", Digest: "sha256:1"}
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, databaseFileName), []byte("nginx"), 0644))
+	require.NoError(t, saveIndexedImages(workDir, []indexedImage{nginx}))
+	require.NoError(t, store.Upload(context.Background(), false))
+	require.Equal(t, []byte("nginx"), bkd.objects[databaseFileName])
+	require.Equal(t, 1, bkd.finalized)
+
+	// Another node contributes to the database.
+	otherDir := t.TempDir()
+	other := &databaseStore{backend: bkd, workDir: otherDir}
+	require.NoError(t, other.Download())
+	require.Equal(t, []indexedImage{nginx}, other.base)
+	data, err := os.ReadFile(filepath.Join(otherDir, databaseFileName))
+	require.NoError(t, err)
+	require.Equal(t, []byte("nginx"), data)
+
+	redis := indexedImage{Reference: "redis:latest", Digest: "sha256:2"}
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, databaseFileName), []byte("nginx,redis"), 0644))
+	require.NoError(t, saveIndexedImages(otherDir, []indexedImage{nginx, redis}))
+	require.NoError(t, other.Upload(context.Background(), false))
+
+	// The database updated by the other node is not overwritten.
+	store.base = []indexedImage{nginx}
+	require.Error(t, store.Upload(context.Background(), false))
+	require.Equal(t, []byte("nginx,redis"), bkd.objects[databaseFileName])
+	require.NoError(t, store.Upload(context.Background(), true))
+	require.Equal(t, []byte("nginx"), bkd.objects[databaseFileName])
+}
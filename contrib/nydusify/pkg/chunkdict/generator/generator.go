@@ -34,6 +34,8 @@ import (
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
+const databaseFileName = "database.db"
+
 // Opt defines Chunkdict generate options.
 // Note: sources is one or more Nydus image references.
 type Opt struct {
@@ -52,6 +54,17 @@ type Opt struct {
 
 	AllPlatforms bool
 	Platforms    string
+
+	// Incremental saves only the sources not indexed before into the
+	// database in work directory, and generates the chunkdict from all the
+	// images in database. Otherwise the database is generated from scratch.
+	Incremental bool
+	// DatabaseBackendType and DatabaseBackendConfig specify the object
+	// storage backend to share the database between build nodes, the
+	// database is downloaded before the incremental generation, and uploaded
+	// after the generation.
+	DatabaseBackendType   string
+	DatabaseBackendConfig string
 }
 
 // Generator generates chunkdict by deduplicating multiple nydus images
@@ -59,6 +72,7 @@ type Opt struct {
 type Generator struct {
 	Opt
 	sourcesParser []*parser.Parser
+	database      *databaseStore
 }
 
 type output struct {
@@ -85,33 +99,57 @@ func New(opt Opt) (*Generator, error) {
 		Opt:           opt,
 		sourcesParser: sourcesParser,
 	}
+	if opt.DatabaseBackendType != "" {
+		database, err := newDatabaseStore(opt.DatabaseBackendType, opt.DatabaseBackendConfig, opt.WorkDir)
+		if err != nil {
+			return nil, err
+		}
+		generator.database = database
+	}
 
 	return generator, nil
 }
 
 // Generate saves multiple Nydus bootstraps into the database one by one.
 func (generator *Generator) Generate(ctx context.Context) error {
-	var bootstrapPaths []string
-	bootstrapPaths, err := generator.pull(ctx)
+	indexed, err := generator.prepareDatabase()
+	if err != nil {
+		return err
+	}
 
+	bootstrapPaths, images, err := generator.pull(ctx, indexed)
 	if err != nil {
 		if utils.RetryWithHTTP(err) {
 			for index := range generator.Sources {
 				generator.sourcesParser[index].Remote.MaybeWithHTTP(err)
 			}
 		}
-		bootstrapPaths, err = generator.pull(ctx)
+		bootstrapPaths, images, err = generator.pull(ctx, indexed)
 		if err != nil {
 			return err
 		}
 	}
+	if len(bootstrapPaths) == 0 {
+		logrus.Infof("All sources are indexed already, chunk dictionary is up to date")
+		return nil
+	}
 
 	chunkdictBootstrapPath, outputPath, err := generator.generate(ctx, bootstrapPaths)
 	if err != nil {
 		return err
 	}
+	indexed = append(indexed, images...)
+	if err := saveIndexedImages(generator.WorkDir, indexed); err != nil {
+		return err
+	}
+	if generator.database != nil {
+		if err := generator.database.Upload(ctx, !generator.Incremental); err != nil {
+			return err
+		}
+	}
 
-	if err := generator.push(ctx, chunkdictBootstrapPath, outputPath); err != nil {
+	// The chunkdict may reference the blobs of all the indexed images.
+	if err := generator.push(ctx, sourceReferences(indexed), chunkdictBootstrapPath, outputPath); err != nil {
 		return err
 	}
 
@@ -119,28 +157,65 @@ func (generator *Generator) Generate(ctx context.Context) error {
 	return nil
 }
 
-// Pull the bootstrap of nydus image
-func (generator *Generator) pull(ctx context.Context) ([]string, error) {
+// prepareDatabase returns the images indexed in database for incremental
+// generation, or removes the database to generate from scratch.
+func (generator *Generator) prepareDatabase() ([]indexedImage, error) {
+	if err := os.MkdirAll(generator.WorkDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create work directory")
+	}
+	if generator.Incremental {
+		if generator.database != nil {
+			if err := generator.database.Download(); err != nil {
+				return nil, err
+			}
+		}
+		indexed, err := loadIndexedImages(generator.WorkDir)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("%d images are indexed in database", len(indexed))
+		return indexed, nil
+	}
+	for _, name := range []string{databaseFileName, indexFileName} {
+		if err := os.Remove(filepath.Join(generator.WorkDir, name)); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "remove %s", name)
+		}
+	}
+	return nil, nil
+}
+
+// Pull the bootstrap of nydus image, the images indexed already are skipped.
+func (generator *Generator) pull(ctx context.Context, indexed []indexedImage) ([]string, []indexedImage, error) {
 	var bootstrapPaths []string
+	var images []indexedImage
 	for index := range generator.Sources {
 		sourceParsed, err := generator.sourcesParser[index].Parse(ctx)
 		if err != nil {
-			return nil, errors.Wrap(err, "parse Nydus image")
+			return nil, nil, errors.Wrap(err, "parse Nydus image")
+		}
+		image := indexedImage{Reference: generator.Sources[index]}
+		if sourceParsed.NydusImage != nil {
+			image.Digest = sourceParsed.NydusImage.Desc.Digest
+		}
+		if isIndexed(indexed, image) || isIndexed(images, image) {
+			logrus.Infof("Skip %s indexed already", image.Reference)
+			continue
 		}
 
 		// Create a directory to store the image bootstrap
 		nydusImageName := strings.Replace(generator.Sources[index], "/", ":", -1)
 		bootstrapDirPath := filepath.Join(generator.WorkDir, nydusImageName)
 		if err := os.MkdirAll(bootstrapDirPath, fs.ModePerm); err != nil {
-			return nil, errors.Wrap(err, "creat work directory")
+			return nil, nil, errors.Wrap(err, "creat work directory")
 		}
 		if err := generator.Output(ctx, sourceParsed, bootstrapDirPath, index); err != nil {
-			return nil, errors.Wrap(err, "output image information")
+			return nil, nil, errors.Wrap(err, "output image information")
 		}
 		bootstrapPath := filepath.Join(bootstrapDirPath, "nydus_bootstrap")
 		bootstrapPaths = append(bootstrapPaths, bootstrapPath)
+		images = append(images, image)
 	}
-	return bootstrapPaths, nil
+	return bootstrapPaths, images, nil
 }
 
 func (generator *Generator) generate(_ context.Context, bootstrapSlice []string) (string, string, error) {
@@ -152,9 +227,9 @@ func (generator *Generator) generate(_ context.Context, bootstrapSlice []string)
 	databaseType := "sqlite"
 	var databasePath string
 	if strings.HasPrefix(generator.WorkDir, "/") {
-		databasePath = databaseType + "://" + filepath.Join(generator.WorkDir, "database.db")
+		databasePath = databaseType + "://" + filepath.Join(generator.WorkDir, databaseFileName)
 	} else {
-		databasePath = databaseType + "://" + filepath.Join(currentDir, generator.WorkDir, "database.db")
+		databasePath = databaseType + "://" + filepath.Join(currentDir, generator.WorkDir, databaseFileName)
 	}
 	outputPath := filepath.Join(generator.WorkDir, "nydus_bootstrap_output.json")
 
@@ -171,9 +246,9 @@ func (generator *Generator) generate(_ context.Context, bootstrapSlice []string)
 	return chunkdictBootstrapPath, outputPath, nil
 }
 
-func hosts(generator *Generator) remote.HostFunc {
+func hosts(generator *Generator, sources []string) remote.HostFunc {
 	maps := make(map[string]bool)
-	for _, source := range generator.Sources {
+	for _, source := range sources {
 		maps[source] = generator.SourceInsecure
 	}
 
@@ -183,7 +258,7 @@ func hosts(generator *Generator) remote.HostFunc {
 	}
 }
 
-func (generator *Generator) push(ctx context.Context, chunkdictBootstrapPath string, outputPath string) error {
+func (generator *Generator) push(ctx context.Context, sources []string, chunkdictBootstrapPath string, outputPath string) error {
 	// Basic configuration
 	ctx = namespaces.WithNamespace(ctx, "nydusify")
 	platformMC, err := platformutil.ParsePlatforms(generator.AllPlatforms, generator.Platforms)
@@ -191,7 +266,7 @@ func (generator *Generator) push(ctx context.Context, chunkdictBootstrapPath str
 		return err
 	}
 
-	pvd, err := provider.New(generator.WorkDir, hosts(generator), 200, "v1", platformMC, 0, nil)
+	pvd, err := provider.New(generator.WorkDir, hosts(generator, sources), 200, "v1", platformMC, 0, nil)
 	if err != nil {
 		return err
 	}
@@ -205,11 +280,11 @@ func (generator *Generator) push(ctx context.Context, chunkdictBootstrapPath str
 	}
 
 	// Pull source image
-	for index := range generator.Sources {
-		if err := pvd.Pull(ctx, generator.Sources[index]); err != nil {
+	for index := range sources {
+		if err := pvd.Pull(ctx, sources[index]); err != nil {
 			if errdefs.NeedsRetryWithHTTP(err) {
 				pvd.UsePlainHTTP()
-				if err := pvd.Pull(ctx, generator.Sources[index]); err != nil {
+				if err := pvd.Pull(ctx, sources[index]); err != nil {
 					return errors.Wrap(err, "try to pull image")
 				}
 			} else {
@@ -218,8 +293,8 @@ func (generator *Generator) push(ctx context.Context, chunkdictBootstrapPath str
 		}
 	}
 
-	logrus.Infof("pulled source image %s", generator.Sources[0])
-	sourceImage, err := pvd.Image(ctx, generator.Sources[0])
+	logrus.Infof("pulled source image %s", sources[0])
+	sourceImage, err := pvd.Image(ctx, sources[0])
 	if err != nil {
 		return errors.Wrap(err, "find image from store")
 	}
@@ -0,0 +1,71 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// indexFileName is the file in work directory recording the images whose
+// chunks and blobs are saved in database.
+const indexFileName = "indexed_images.json"
+
+type indexedImage struct {
+	Reference string `json:"reference"`
+	// Digest is the digest of the Nydus manifest saved, the image is
+	// indexed again if the reference points to another manifest.
+	Digest digest.Digest `json:"digest"`
+}
+
+func loadIndexedImages(workDir string) ([]indexedImage, error) {
+	data, err := os.ReadFile(filepath.Join(workDir, indexFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read indexed images")
+	}
+	var images []indexedImage
+	if err := json.Unmarshal(data, &images); err != nil {
+		return nil, errors.Wrap(err, "unmarshal indexed images")
+	}
+	return images, nil
+}
+
+func saveIndexedImages(workDir string, images []indexedImage) error {
+	data, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(os.WriteFile(filepath.Join(workDir, indexFileName), data, 0644), "write indexed images")
+}
+
+func isIndexed(images []indexedImage, image indexedImage) bool {
+	for _, indexed := range images {
+		if indexed == image {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceReferences returns the unique references of the indexed images, in
+// the order of being indexed.
+func sourceReferences(images []indexedImage) []string {
+	var refs []string
+	seen := map[string]bool{}
+	for _, image := range images {
+		if !seen[image.Reference] {
+			seen[image.Reference] = true
+			refs = append(refs, image.Reference)
+		}
+	}
+	return refs
+}
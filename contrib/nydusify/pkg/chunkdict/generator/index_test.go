@@ -0,0 +1,34 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexedImages(t *testing.T) {
+	dir := t.TempDir()
+	images, err := loadIndexedImages(dir)
+	require.NoError(t, err)
+	require.Empty(t, images)
+
+	images = []indexedImage{
+		{Reference: "localhost:5000/redis:nydus", Digest: digest.FromString("redis-v1")},
+		{Reference: "localhost:5000/nginx:nydus", Digest: digest.FromString("nginx")},
+		{Reference: "localhost:5000/redis:nydus", Digest: digest.FromString("redis-v2")},
+	}
+	require.NoError(t, saveIndexedImages(dir, images))
+	loaded, err := loadIndexedImages(dir)
+	require.NoError(t, err)
+	require.Equal(t, images, loaded)
+
+	require.True(t, isIndexed(loaded, indexedImage{Reference: "localhost:5000/nginx:nydus", Digest: digest.FromString("nginx")}))
+	// The image is indexed again once the reference is pushed again.
+	require.False(t, isIndexed(loaded, indexedImage{Reference: "localhost:5000/nginx:nydus", Digest: digest.FromString("nginx-v2")}))
+	require.Equal(t, []string{"localhost:5000/redis:nydus", "localhost:5000/nginx:nydus"}, sourceReferences(loaded))
+}
@@ -0,0 +1,290 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+)
+
+// The candidate image is saved into a copy of database with the name and
+// version, which are parsed from its bootstrap directory by nydus-image.
+const (
+	candidateName    = "nydusify-stat-candidate"
+	candidateVersion = "latest"
+)
+
+// StatOpt defines chunkdict stat options.
+type StatOpt struct {
+	// Sqlite3Path is the path to the sqlite3 binary to query the database.
+	Sqlite3Path string
+	// Top is the number of the most shared chunks to report.
+	Top int
+}
+
+// SharedChunk is a chunk shared by multiple images in database.
+type SharedChunk struct {
+	Digest string `json:"digest"`
+	Images int    `json:"images"`
+	Size   uint64 `json:"size"`
+}
+
+// Projection is the projected savings of the candidate image deduplicated
+// by the images in database.
+type Projection struct {
+	Image        string `json:"image"`
+	Chunks       int    `json:"chunks"`
+	Size         uint64 `json:"size"`
+	SharedChunks int    `json:"shared_chunks"`
+	SavedSize    uint64 `json:"saved_size"`
+}
+
+// Statistics is the statistics of the chunks in database, all the sizes are
+// the compressed sizes of chunks.
+type Statistics struct {
+	Images       int `json:"images"`
+	Chunks       int `json:"chunks"`
+	UniqueChunks int `json:"unique_chunks"`
+	// TotalSize is the size of all the chunks of images.
+	TotalSize uint64 `json:"total_size"`
+	// DedupedSize is the size of the chunks after deduplication.
+	DedupedSize  uint64        `json:"deduped_size"`
+	SharedChunks []SharedChunk `json:"shared_chunks"`
+	Candidate    *Projection   `json:"candidate,omitempty"`
+}
+
+// Stat collects the statistics of the database in work directory, or in
+// database backend if specified, and projects the savings of the first source
+// as candidate image if any.
+func (generator *Generator) Stat(ctx context.Context, opt StatOpt) (*Statistics, error) {
+	if generator.database != nil {
+		if err := os.MkdirAll(generator.WorkDir, 0755); err != nil {
+			return nil, errors.Wrap(err, "create work directory")
+		}
+		if err := generator.database.Download(); err != nil {
+			return nil, err
+		}
+	}
+	databasePath := filepath.Join(generator.WorkDir, databaseFileName)
+	if _, err := os.Stat(databasePath); err != nil {
+		return nil, errors.Wrap(err, "database is not generated by `chunkdict generate` in work directory")
+	}
+	stat, err := queryStatistics(opt.Sqlite3Path, databasePath, opt.Top)
+	if err != nil {
+		return nil, err
+	}
+	if len(generator.Sources) == 0 {
+		return stat, nil
+	}
+
+	stat.Candidate, err = generator.project(ctx, opt.Sqlite3Path, databasePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "project savings of %s", generator.Sources[0])
+	}
+	return stat, nil
+}
+
+// project saves the candidate image into a copy of database, to find the
+// chunks of candidate existing in the other images.
+func (generator *Generator) project(ctx context.Context, sqlite3Path, databasePath string) (*Projection, error) {
+	workDir, err := os.MkdirTemp(generator.WorkDir, "stat-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	copiedPath, err := filepath.Abs(filepath.Join(workDir, databaseFileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := copyFile(databasePath, copiedPath); err != nil {
+		return nil, errors.Wrap(err, "copy database")
+	}
+
+	sourceParsed, err := generator.sourcesParser[0].Parse(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse Nydus image")
+	}
+	bootstrapDirPath := filepath.Join(workDir, candidateName+":"+candidateVersion)
+	if err := os.MkdirAll(bootstrapDirPath, 0755); err != nil {
+		return nil, errors.Wrap(err, "create bootstrap directory")
+	}
+	if err := generator.Output(ctx, sourceParsed, bootstrapDirPath, 0); err != nil {
+		return nil, errors.Wrap(err, "output image information")
+	}
+
+	builder := build.NewBuilder(generator.NydusImagePath)
+	if err := builder.Generate(build.GenerateOption{
+		BootstrapPaths:         []string{filepath.Join(bootstrapDirPath, "nydus_bootstrap")},
+		ChunkdictBootstrapPath: filepath.Join(workDir, "chunkdict_bootstrap"),
+		DatabasePath:           "sqlite://" + copiedPath,
+		OutputPath:             filepath.Join(workDir, "nydus_bootstrap_output.json"),
+	}); err != nil {
+		return nil, errors.Wrap(err, "save candidate image into database")
+	}
+
+	projection, err := queryProjection(sqlite3Path, copiedPath)
+	if err != nil {
+		return nil, err
+	}
+	projection.Image = generator.Sources[0]
+	return projection, nil
+}
+
+func copyFile(src, dst string) error {
+	reader, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	writer, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// query runs the SQL in the read-only database by sqlite3, and decodes the
+// rows in JSON into result.
+func query(sqlite3Path, databasePath, sql string, result interface{}) error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(sqlite3Path, "-readonly", "-json", databasePath, sql)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	logrus.Debugf("\tCommand: %s", cmd.String())
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "query database: %s", bytes.TrimSpace(stderr.Bytes()))
+	}
+	// Nothing is printed by sqlite3 for empty result.
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	return errors.Wrap(json.Unmarshal(stdout.Bytes(), result), "decode query result")
+}
+
+func queryStatistics(sqlite3Path, databasePath string, top int) (*Statistics, error) {
+	var totals []struct {
+		Images int    `json:"images"`
+		Chunks int    `json:"chunks"`
+		Size   uint64 `json:"size"`
+	}
+	if err := query(sqlite3Path, databasePath, `SELECT
+		COUNT(DISTINCT image_reference || ':' || version) AS images,
+		COUNT(*) AS chunks,
+		IFNULL(SUM(chunk_compressed_size), 0) AS size
+		FROM chunk`, &totals); err != nil {
+		return nil, err
+	}
+
+	var deduped []struct {
+		Chunks int    `json:"chunks"`
+		Size   uint64 `json:"size"`
+	}
+	if err := query(sqlite3Path, databasePath, `SELECT
+		COUNT(*) AS chunks,
+		IFNULL(SUM(size), 0) AS size
+		FROM (SELECT MAX(chunk_compressed_size) AS size FROM chunk GROUP BY chunk_digest)`, &deduped); err != nil {
+		return nil, err
+	}
+
+	stat := Statistics{SharedChunks: []SharedChunk{}}
+	if len(totals) > 0 {
+		stat.Images = totals[0].Images
+		stat.Chunks = totals[0].Chunks
+		stat.TotalSize = totals[0].Size
+	}
+	if len(deduped) > 0 {
+		stat.UniqueChunks = deduped[0].Chunks
+		stat.DedupedSize = deduped[0].Size
+	}
+
+	if top > 0 {
+		if err := query(sqlite3Path, databasePath, fmt.Sprintf(`SELECT
+			chunk_digest AS digest,
+			COUNT(DISTINCT image_reference || ':' || version) AS images,
+			MAX(chunk_compressed_size) AS size
+			FROM chunk GROUP BY chunk_digest HAVING images > 1
+			ORDER BY images DESC, size DESC, digest LIMIT %d`, top), &stat.SharedChunks); err != nil {
+			return nil, err
+		}
+	}
+
+	return &stat, nil
+}
+
+func queryProjection(sqlite3Path, databasePath string) (*Projection, error) {
+	var projections []Projection
+	if err := query(sqlite3Path, databasePath, fmt.Sprintf(`SELECT
+		COUNT(*) AS chunks,
+		IFNULL(SUM(size), 0) AS size,
+		IFNULL(SUM(shared), 0) AS shared_chunks,
+		IFNULL(SUM(shared * size), 0) AS saved_size
+		FROM (SELECT MAX(c.chunk_compressed_size) AS size, EXISTS (
+			SELECT 1 FROM chunk o WHERE o.chunk_digest = c.chunk_digest
+			AND NOT (o.image_reference = '%[1]s' AND o.version = '%[2]s')
+		) AS shared
+		FROM chunk c WHERE c.image_reference = '%[1]s' AND c.version = '%[2]s'
+		GROUP BY c.chunk_digest)`, candidateName, candidateVersion), &projections); err != nil {
+		return nil, err
+	}
+	if len(projections) == 0 {
+		return &Projection{}, nil
+	}
+	return &projections[0], nil
+}
+
+// PrintStatistics prints the statistics in human readable format.
+func PrintStatistics(writer io.Writer, stat *Statistics) {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Images:\t%d\n", stat.Images)
+	fmt.Fprintf(tw, "Chunks:\t%d (%d unique)\n", stat.Chunks, stat.UniqueChunks)
+	fmt.Fprintf(tw, "Total size:\t%s\n", humanize.Bytes(stat.TotalSize))
+	fmt.Fprintf(tw, "Deduped size:\t%s (%s saved)\n", humanize.Bytes(stat.DedupedSize), formatRatio(stat.TotalSize-stat.DedupedSize, stat.TotalSize))
+	tw.Flush()
+
+	if len(stat.SharedChunks) > 0 {
+		fmt.Fprintln(writer)
+		tw = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SHARED CHUNK\tIMAGES\tSIZE")
+		for _, chunk := range stat.SharedChunks {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", chunk.Digest, chunk.Images, humanize.Bytes(chunk.Size))
+		}
+		tw.Flush()
+	}
+
+	if candidate := stat.Candidate; candidate != nil {
+		fmt.Fprintln(writer)
+		tw = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "Candidate:\t%s\n", candidate.Image)
+		fmt.Fprintf(tw, "Chunks:\t%d (%d indexed already)\n", candidate.Chunks, candidate.SharedChunks)
+		fmt.Fprintf(tw, "Size:\t%s\n", humanize.Bytes(candidate.Size))
+		fmt.Fprintf(tw, "Projected savings:\t%s\n", formatRatio(candidate.SavedSize, candidate.Size))
+		tw.Flush()
+	}
+}
+
+func formatRatio(size, total uint64) string {
+	if total == 0 {
+		return humanize.Bytes(size)
+	}
+	return fmt.Sprintf("%s, %.1f%%", humanize.Bytes(size), float64(size)*100/float64(total))
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package generator
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createDatabase(t *testing.T, sqlite3Path string, chunks ...string) string {
+	databasePath := filepath.Join(t.TempDir(), databaseFileName)
+	sql := `CREATE TABLE chunk (
+		id INTEGER PRIMARY KEY, image_reference TEXT, version TEXT, chunk_blob_id TEXT NOT NULL,
+		chunk_digest TEXT, chunk_crc32 INT, chunk_compressed_size INT, chunk_uncompressed_size INT,
+		chunk_compressed_offset INT, chunk_uncompressed_offset INT
+	);`
+	for _, chunk := range chunks {
+		sql += "INSERT INTO chunk (image_reference, version, chunk_blob_id, chunk_digest, chunk_compressed_size) VALUES " + chunk + ";"
+	}
+	require.NoError(t, exec.Command(sqlite3Path, databasePath, sql).Run())
+	return databasePath
+}
+
+func TestQueryStatistics(t *testing.T) {
+	sqlite3Path, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 is not found")
+	}
+
+	databasePath := createDatabase(t, sqlite3Path)
+	stat, err := queryStatistics(sqlite3Path, databasePath, 10)
+	require.NoError(t, err)
+	require.Equal(t, &Statistics{SharedChunks: []SharedChunk{}}, stat)
+
+	databasePath = createDatabase(t, sqlite3Path,
+		"('nginx', 'v1', 'blob1', 'a', 100)",
+		"('nginx', 'v1', 'blob1', 'b', 200)",
+		"('nginx', 'v2', 'blob2', 'a', 100)",
+		"('nginx', 'v2', 'blob2', 'b', 200)",
+		"('nginx', 'v2', 'blob2', 'c', 300)",
+		"('redis', 'v1', 'blob3', 'a', 100)",
+		"('redis', 'v1', 'blob3', 'd', 400)",
+	)
+	stat, err = queryStatistics(sqlite3Path, databasePath, 1)
+	require.NoError(t, err)
+	require.Equal(t, &Statistics{
+		Images:       3,
+		Chunks:       7,
+		UniqueChunks: 4,
+		TotalSize:    1400,
+		DedupedSize:  1000,
+		SharedChunks: []SharedChunk{{Digest: "a", Images: 3, Size: 100}},
+	}, stat)
+
+	stat, err = queryStatistics(sqlite3Path, databasePath, 10)
+	require.NoError(t, err)
+	require.Equal(t, []SharedChunk{{Digest: "a", Images: 3, Size: 100}, {Digest: "b", Images: 2, Size: 200}}, stat.SharedChunks)
+
+	var buf bytes.Buffer
+	PrintStatistics(&buf, stat)
+	require.Contains(t, buf.String(), "1.0 kB (400 B, 28.6% saved)")
+}
+
+func TestQueryProjection(t *testing.T) {
+	sqlite3Path, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("sqlite3 is not found")
+	}
+
+	databasePath := createDatabase(t, sqlite3Path,
+		"('nginx', 'v1', 'blob1', 'a', 100)",
+		"('nginx', 'v1', 'blob1', 'b', 200)",
+		"('nydusify-stat-candidate', 'latest', 'blob2', 'a', 100)",
+		"('nydusify-stat-candidate', 'latest', 'blob2', 'c', 300)",
+	)
+	projection, err := queryProjection(sqlite3Path, databasePath)
+	require.NoError(t, err)
+	require.Equal(t, &Projection{Chunks: 2, Size: 400, SharedChunks: 1, SavedSize: 100}, projection)
+
+	_, err = queryProjection(sqlite3Path, filepath.Join(t.TempDir(), "missing.db"))
+	require.Error(t, err)
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// splitWords splits the words separated by whitespaces, the quotes and
+// backslash escapes are handled like the shell.
+func splitWords(value string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, c := range value {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", value)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// parseKeyValues parses the "KEY=VALUE ..." pairs, or the legacy "KEY VALUE"
+// form if allowed.
+func parseKeyValues(value string, legacy bool) ([][2]string, error) {
+	words, err := splitWords(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("missing key value pairs")
+	}
+	if legacy && !strings.Contains(words[0], "=") {
+		parts := strings.SplitN(strings.TrimSpace(value), " ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("missing value of %s", parts[0])
+		}
+		return [][2]string{{parts[0], strings.TrimSpace(parts[1])}}, nil
+	}
+	pairs := [][2]string{}
+	for _, word := range words {
+		parts := strings.SplitN(word, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid key value pair %q", word)
+		}
+		pairs = append(pairs, [2]string{parts[0], parts[1]})
+	}
+	return pairs, nil
+}
+
+// parseCommand parses the exec form (JSON array) or the shell form of
+// command, the shell form is run by "/bin/sh -c".
+func parseCommand(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		command := []string{}
+		if err := json.Unmarshal([]byte(value), &command); err != nil {
+			return nil, errors.Wrap(err, "invalid exec form")
+		}
+		return command, nil
+	}
+	if value == "" {
+		return nil, fmt.Errorf("missing command")
+	}
+	return []string{"/bin/sh", "-c", value}, nil
+}
+
+// parseList parses the JSON array or the whitespace separated words.
+func parseList(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		list := []string{}
+		if err := json.Unmarshal([]byte(value), &list); err != nil {
+			return nil, errors.Wrap(err, "invalid JSON array")
+		}
+		return list, nil
+	}
+	list, err := splitWords(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("missing value")
+	}
+	return list, nil
+}
+
+func setEnv(env []string, key, value string) []string {
+	for idx := range env {
+		if strings.SplitN(env[idx], "=", 2)[0] == key {
+			env[idx] = key + "=" + value
+			return env
+		}
+	}
+	return append(env, key+"="+value)
+}
+
+// applyChange applies a Dockerfile instruction like "ENV FOO=bar" to the
+// image config, the instructions supported by `docker commit --change` are
+// CMD, ENTRYPOINT, ENV, EXPOSE, LABEL, STOPSIGNAL, USER, VOLUME and WORKDIR.
+func applyChange(config *ocispec.ImageConfig, change string) error {
+	parts := strings.SplitN(strings.TrimSpace(change), " ", 2)
+	instruction := strings.ToUpper(parts[0])
+	value := ""
+	if len(parts) == 2 {
+		value = strings.TrimSpace(parts[1])
+	}
+	if value == "" {
+		return fmt.Errorf("missing value of %s", instruction)
+	}
+
+	switch instruction {
+	case "CMD":
+		command, err := parseCommand(value)
+		if err != nil {
+			return err
+		}
+		config.Cmd = command
+	case "ENTRYPOINT":
+		command, err := parseCommand(value)
+		if err != nil {
+			return err
+		}
+		config.Entrypoint = command
+		// Follow Dockerfile, the CMD is reset if ENTRYPOINT is set.
+		config.Cmd = nil
+	case "ENV":
+		pairs, err := parseKeyValues(value, true)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			config.Env = setEnv(config.Env, pair[0], pair[1])
+		}
+	case "LABEL":
+		pairs, err := parseKeyValues(value, false)
+		if err != nil {
+			return err
+		}
+		if config.Labels == nil {
+			config.Labels = map[string]string{}
+		}
+		for _, pair := range pairs {
+			config.Labels[pair[0]] = pair[1]
+		}
+	case "EXPOSE":
+		ports, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		if config.ExposedPorts == nil {
+			config.ExposedPorts = map[string]struct{}{}
+		}
+		for _, port := range ports {
+			if !strings.Contains(port, "/") {
+				port += "/tcp"
+			}
+			config.ExposedPorts[port] = struct{}{}
+		}
+	case "VOLUME":
+		volumes, err := parseList(value)
+		if err != nil {
+			return err
+		}
+		if config.Volumes == nil {
+			config.Volumes = map[string]struct{}{}
+		}
+		for _, volume := range volumes {
+			config.Volumes[volume] = struct{}{}
+		}
+	case "USER":
+		config.User = value
+	case "WORKDIR":
+		config.WorkingDir = value
+	case "STOPSIGNAL":
+		config.StopSignal = value
+	default:
+		return fmt.Errorf("unsupported instruction %s", instruction)
+	}
+
+	return nil
+}
+
+// mutateConfig applies the changes to the image config, and records the
+// commit in the image history.
+func mutateConfig(image *ocispec.Image, opt Opt) error {
+	for _, change := range opt.Changes {
+		if err := applyChange(&image.Config, change); err != nil {
+			return errors.Wrapf(err, "apply change %q", change)
+		}
+	}
+
+	created := time.Now().UTC()
+	image.Created = &created
+	if opt.Author != "" {
+		image.Author = opt.Author
+	}
+	createdBy := "nydusify commit"
+	if len(opt.Changes) > 0 {
+		createdBy += " --change " + strings.Join(opt.Changes, " --change ")
+	}
+	image.History = append(image.History, ocispec.History{
+		Created:   &created,
+		CreatedBy: createdBy,
+		Author:    opt.Author,
+		Comment:   opt.Message,
+	})
+
+	return nil
+}
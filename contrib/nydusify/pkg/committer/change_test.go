@@ -0,0 +1,60 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyChange(t *testing.T) {
+	config := ocispec.ImageConfig{
+		Env: []string{"PATH=/usr/bin", "FOO=old"},
+		Cmd: []string{"sh"},
+	}
+
+	require.NoError(t, applyChange(&config, `ENV FOO=bar BAZ="hello world"`))
+	require.Equal(t, []string{"PATH=/usr/bin", "FOO=bar", "BAZ=hello world"}, config.Env)
+	require.NoError(t, applyChange(&config, `env LEGACY a value`))
+	require.Equal(t, "LEGACY=a value", config.Env[3])
+
+	require.NoError(t, applyChange(&config, `ENTRYPOINT ["/app", "--serve"]`))
+	require.Equal(t, []string{"/app", "--serve"}, config.Entrypoint)
+	require.Nil(t, config.Cmd)
+	require.NoError(t, applyChange(&config, `CMD echo hello`))
+	require.Equal(t, []string{"/bin/sh", "-c", "echo hello"}, config.Cmd)
+
+	require.NoError(t, applyChange(&config, `LABEL version=1.0 "description"='my app'`))
+	require.Equal(t, map[string]string{"version": "1.0", "description": "my app"}, config.Labels)
+	require.NoError(t, applyChange(&config, `EXPOSE 80 53/udp`))
+	require.Equal(t, map[string]struct{}{"80/tcp": {}, "53/udp": {}}, config.ExposedPorts)
+	require.NoError(t, applyChange(&config, `VOLUME ["/data"]`))
+	require.Equal(t, map[string]struct{}{"/data": {}}, config.Volumes)
+	require.NoError(t, applyChange(&config, `USER nobody`))
+	require.NoError(t, applyChange(&config, `WORKDIR /app`))
+	require.Equal(t, "nobody", config.User)
+	require.Equal(t, "/app", config.WorkingDir)
+
+	require.ErrorContains(t, applyChange(&config, `RUN make`), "unsupported instruction RUN")
+	require.ErrorContains(t, applyChange(&config, `ENV`), "missing value of ENV")
+	require.ErrorContains(t, applyChange(&config, `LABEL foo`), "invalid key value pair")
+	require.Error(t, applyChange(&config, `CMD ["unterminated"`))
+}
+
+func TestMutateConfig(t *testing.T) {
+	image := ocispec.Image{}
+	require.NoError(t, mutateConfig(&image, Opt{
+		Changes: []string{"ENV FOO=bar"},
+		Author:  "nydus",
+		Message: "add foo",
+	}))
+	require.Equal(t, []string{"FOO=bar"}, image.Config.Env)
+	require.Equal(t, "nydus", image.Author)
+	require.Len(t, image.History, 1)
+	require.Equal(t, "add foo", image.History[0].Comment)
+	require.Equal(t, "nydusify commit --change ENV FOO=bar", image.History[0].CreatedBy)
+}
@@ -20,7 +20,6 @@ import (
 	"time"
 
 	"github.com/BraveY/snapshotter-converter/converter"
-	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/pkg/labels"
 	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/containerd/v2/plugins/content/local"
@@ -36,15 +35,30 @@ import (
 	parserPkg "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
 )
 
 // Opt defines the options for committing container changes
 type Opt struct {
-	WorkDir           string
+	WorkDir string
+	// WorkDirQuota limits the usage of WorkDir in bytes, zero means
+	// unlimited.
+	WorkDirQuota int64
+	// KeepWorkDir is the policy to keep the temp directory in WorkDir
+	// after commit, which is removed by default.
+	KeepWorkDir       string
 	ContainerdAddress string
 	NydusImagePath    string
 	Namespace         string
 
+	// Runtime is the container runtime (containerd, docker or crio) of
+	// container, default to containerd. RuntimeAddress is the Docker Engine
+	// socket or the CRI-O container state directory, and OCIRuntime is the
+	// OCI runtime binary used to pause CRI-O containers.
+	Runtime        string
+	RuntimeAddress string
+	OCIRuntime     string
+
 	ContainerID    string
 	SourceInsecure bool
 	TargetRef      string
@@ -55,12 +69,36 @@ type Opt struct {
 
 	WithPaths    []string
 	WithoutPaths []string
+
+	// Excludes are the glob patterns of paths omitted from the committed
+	// changes, the patterns in .nydusignore of container root are added.
+	Excludes []string
+
+	// ExportDir exports the committed blobs and bootstrap to the directory
+	// instead of pushing the image to TargetRef.
+	ExportDir string
+
+	// NoPause commits the container without pausing it, the committed
+	// changes may be inconsistent. PauseMethod is the way to pause the
+	// container, PauseRuntime (default) or PauseFreezer.
+	NoPause     bool
+	PauseMethod string
+
+	// Squash squashes the layers committed before and the changes of this
+	// time into one layer, which resets the committed times.
+	Squash bool
+
+	// Changes are the Dockerfile instructions like "ENV FOO=bar" applied to
+	// the image config, Author and Message are recorded in the history.
+	Changes []string
+	Author  string
+	Message string
 }
 
 type Committer struct {
 	workDir string
 	builder string
-	manager *Manager
+	manager Runtime
 }
 
 // NewCommitter creates a new Committer instance
@@ -69,12 +107,12 @@ func NewCommitter(opt Opt) (*Committer, error) {
 		return nil, errors.Wrap(err, "prepare work dir")
 	}
 
-	workDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-commiter-")
+	workDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix+"commiter-")
 	if err != nil {
 		return nil, errors.Wrap(err, "create temp dir")
 	}
 
-	cm, err := NewManager(opt.ContainerdAddress)
+	cm, err := NewRuntime(opt)
 	if err != nil {
 		return nil, errors.Wrap(err, "new container manager")
 	}
@@ -86,16 +124,41 @@ func NewCommitter(opt Opt) (*Committer, error) {
 	}, nil
 }
 
-func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
+func (cm *Committer) Commit(ctx context.Context, opt Opt) (retErr error) {
+	ctx = namespaces.WithNamespace(ctx, opt.Namespace)
+	unlock, err := workdir.Lock(cm.workDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlock()
+		if workdir.Keep(opt.KeepWorkDir, workdir.KeepNever, retErr) {
+			logrus.Infof("temp directory is kept in %s", cm.workDir)
+			return
+		}
+		os.RemoveAll(cm.workDir)
+	}()
+	ctx, stopWatch := utils.WatchQuota(ctx, opt.WorkDir, opt.WorkDirQuota)
+	defer stopWatch()
+	defer func() {
+		retErr = utils.QuotaError(ctx, retErr)
+	}()
+
 	// Resolve container ID first
 	if err := cm.resolveContainerID(ctx, &opt); err != nil {
 		return errors.Wrap(err, "failed to resolve container ID")
 	}
-
-	ctx = namespaces.WithNamespace(ctx, opt.Namespace)
-	targetRef, err := ValidateRef(opt.TargetRef)
-	if err != nil {
-		return errors.Wrap(err, "parse target image name")
+	var targetRef string
+	if opt.ExportDir != "" {
+		if err := os.MkdirAll(opt.ExportDir, 0755); err != nil {
+			return errors.Wrap(err, "prepare export directory")
+		}
+	} else {
+		ref, err := ValidateRef(opt.TargetRef)
+		if err != nil {
+			return errors.Wrap(err, "parse target image name")
+		}
+		targetRef = ref
 	}
 
 	inspect, err := cm.manager.Inspect(ctx, opt.ContainerID)
@@ -105,6 +168,20 @@ func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
 
 	originalSourceRef := inspect.Image
 
+	excluder, err := loadExcluder(inspect.Pid, opt.Excludes)
+	if err != nil {
+		return errors.Wrap(err, "load exclude patterns")
+	}
+
+	var pauser pauser = cm.manager
+	switch opt.PauseMethod {
+	case "", PauseRuntime:
+	case PauseFreezer:
+		pauser = newCgroupFreezer(inspect.Pid)
+	default:
+		return fmt.Errorf("unsupported pause method %s", opt.PauseMethod)
+	}
+
 	logrus.Infof("pulling base bootstrap")
 	start := time.Now()
 	image, committedLayers, err := cm.pullBootstrap(ctx, originalSourceRef, "bootstrap-base", opt.SourceInsecure)
@@ -113,16 +190,29 @@ func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
 	}
 	logrus.Infof("pulled base bootstrap, elapsed: %s", time.Since(start))
 
-	if committedLayers >= opt.MaximumTimes {
-		return fmt.Errorf("reached maximum committed times %d", opt.MaximumTimes)
+	if err := mutateConfig(&image.Config, opt); err != nil {
+		return errors.Wrap(err, "mutate image config")
+	}
+
+	// The committed layers are squashed into one layer with the changes of
+	// this time, so the lower layers exclude them.
+	var squashedLayers []ocispec.Descriptor
+	if opt.Squash {
+		image.Manifest.Layers, squashedLayers = splitCommittedLayers(image)
+	} else if committedLayers >= opt.MaximumTimes {
+		return fmt.Errorf("reached maximum committed times %d, use squash to merge the committed layers", opt.MaximumTimes)
 	}
 	if opt.FsVersion, opt.Compressor, err = cm.obtainBootStrapInfo(ctx, "bootstrap-base"); err != nil {
 		return errors.Wrap(err, "obtain bootstrap FsVersion and Compressor")
 	}
+	if err := checkSpace(inspect.UpperDir, squashedLayers, opt); err != nil {
+		return err
+	}
 
-	// Push lower blobs
+	// Push lower blobs, which are referred by the exported bootstrap but
+	// kept in source registry in export mode.
 	for idx, layer := range image.Manifest.Layers {
-		if layer.MediaType == utils.MediaTypeNydusBlob {
+		if layer.MediaType == utils.MediaTypeNydusBlob && opt.ExportDir == "" {
 			name := fmt.Sprintf("blob-mount-%d", idx)
 			if _, err := cm.pushBlob(ctx, name, layer.Digest, originalSourceRef, targetRef, opt.TargetInsecure, image); err != nil {
 				return errors.Wrap(err, "push lower blob")
@@ -132,60 +222,37 @@ func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
 
 	mountList := NewMountList()
 
-	var upperBlob *Blob
-	mountBlobs := make([]Blob, len(opt.WithPaths))
-	commit := func() error {
+	// Capture the changes of container into blobs, the container is paused
+	// only during capturing, the blobs are pushed after the container is
+	// resumed.
+	var upperBlobDigest *digest.Digest
+	mountBlobDigests := make([]*digest.Digest, len(opt.WithPaths))
+	var appendedBlobDigests []*digest.Digest
+	capture := func() error {
 		eg := errgroup.Group{}
 		eg.Go(func() error {
-			var upperBlobDigest *digest.Digest
 			if err := withRetry(func() error {
-				upperBlobDigest, err = cm.commitUpperByDiff(ctx, mountList.Add, opt.WithPaths, opt.WithoutPaths, inspect.LowerDirs, inspect.UpperDir, "blob-upper", opt.FsVersion, opt.Compressor)
+				var err error
+				upperBlobDigest, err = cm.commitUpperByDiff(ctx, mountList.Add, opt.WithPaths, opt.WithoutPaths, excluder, inspect.LowerDirs, inspect.UpperDir, "blob-upper", opt.FsVersion, opt.Compressor)
 				return err
 			}, 3); err != nil {
 				return errors.Wrap(err, "commit upper")
 			}
-			logrus.Infof("pushing blob for upper")
-			start := time.Now()
-			upperBlobDesc, err := cm.pushBlob(ctx, "blob-upper", *upperBlobDigest, originalSourceRef, targetRef, opt.TargetInsecure, image)
-			if err != nil {
-				return errors.Wrap(err, "push upper blob")
-			}
-			upperBlob = &Blob{
-				Name: "blob-upper",
-				Desc: *upperBlobDesc,
-			}
-			logrus.Infof("pushed blob for upper, elapsed: %s", time.Since(start))
 			return nil
 		})
 
-		if len(opt.WithPaths) > 0 {
-			for idx := range opt.WithPaths {
-				func(idx int) {
-					eg.Go(func() error {
-						withPath := opt.WithPaths[idx]
-						name := fmt.Sprintf("blob-mount-%d", idx)
-						var mountBlobDigest *digest.Digest
-						if err := withRetry(func() error {
-							mountBlobDigest, err = cm.commitMountByNSEnter(ctx, inspect.Pid, withPath, name, opt.FsVersion, opt.Compressor)
-							return err
-						}, 3); err != nil {
-							return errors.Wrap(err, "commit mount")
-						}
-						logrus.Infof("pushing blob for mount")
-						start := time.Now()
-						mountBlobDesc, err := cm.pushBlob(ctx, name, *mountBlobDigest, originalSourceRef, targetRef, opt.TargetInsecure, image)
-						if err != nil {
-							return errors.Wrap(err, "push mount blob")
-						}
-						mountBlobs[idx] = Blob{
-							Name: name,
-							Desc: *mountBlobDesc,
-						}
-						logrus.Infof("pushed blob for mount, elapsed: %s", time.Since(start))
-						return nil
-					})
-				}(idx)
-			}
+		for idx := range opt.WithPaths {
+			eg.Go(func() error {
+				name := fmt.Sprintf("blob-mount-%d", idx)
+				if err := withRetry(func() error {
+					var err error
+					mountBlobDigests[idx], err = cm.commitMountByNSEnter(ctx, inspect.Pid, opt.WithPaths[idx], name, excluder, opt.FsVersion, opt.Compressor)
+					return err
+				}, 3); err != nil {
+					return errors.Wrap(err, "commit mount")
+				}
+				return nil
+			})
 		}
 
 		if err := eg.Wait(); err != nil {
@@ -193,38 +260,22 @@ func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
 		}
 
 		appendedEg := errgroup.Group{}
-		appendedMutex := sync.Mutex{}
 		if len(mountList.paths) > 0 {
 			logrus.Infof("need commit appended mount path: %s", strings.Join(mountList.paths, ", "))
 		}
+		appendedBlobDigests = make([]*digest.Digest, len(mountList.paths))
 		for idx := range mountList.paths {
-			func(idx int) {
-				appendedEg.Go(func() error {
-					mountPath := mountList.paths[idx]
-					name := fmt.Sprintf("blob-appended-mount-%d", idx)
-					var mountBlobDigest *digest.Digest
-					if err := withRetry(func() error {
-						mountBlobDigest, err = cm.commitMountByNSEnter(ctx, inspect.Pid, mountPath, name, opt.FsVersion, opt.Compressor)
-						return err
-					}, 3); err != nil {
-						return errors.Wrap(err, "commit appended mount")
-					}
-					logrus.Infof("pushing blob for appended mount")
-					start := time.Now()
-					mountBlobDesc, err := cm.pushBlob(ctx, name, *mountBlobDigest, originalSourceRef, targetRef, opt.TargetInsecure, image)
-					if err != nil {
-						return errors.Wrap(err, "push appended mount blob")
-					}
-					appendedMutex.Lock()
-					mountBlobs = append(mountBlobs, Blob{
-						Name: name,
-						Desc: *mountBlobDesc,
-					})
-					appendedMutex.Unlock()
-					logrus.Infof("pushed blob for appended mount, elapsed: %s", time.Since(start))
-					return nil
-				})
-			}(idx)
+			appendedEg.Go(func() error {
+				name := fmt.Sprintf("blob-appended-mount-%d", idx)
+				if err := withRetry(func() error {
+					var err error
+					appendedBlobDigests[idx], err = cm.commitMountByNSEnter(ctx, inspect.Pid, mountList.paths[idx], name, excluder, opt.FsVersion, opt.Compressor)
+					return err
+				}, 3); err != nil {
+					return errors.Wrap(err, "commit appended mount")
+				}
+				return nil
+			})
 		}
 
 		return appendedEg.Wait()
@@ -238,16 +289,87 @@ func (cm *Committer) Commit(ctx context.Context, opt Opt) error {
 		return errors.Wrap(err, "failed to sync filesystem")
 	}
 
-	if err := cm.pause(ctx, opt.ContainerID, commit); err != nil {
-		return errors.Wrap(err, "pause container to commit")
+	if opt.NoPause {
+		logrus.Warnf("committing container without pausing, the committed changes may be inconsistent")
+		if err := capture(); err != nil {
+			return err
+		}
+	} else {
+		if err := cm.pause(ctx, pauser, opt.ContainerID, capture); err != nil {
+			return errors.Wrap(err, "pause container to commit")
+		}
+	}
+
+	upperBlobName := "blob-upper"
+	if opt.Squash {
+		blobNames := []string{upperBlobName}
+		for idx := range mountBlobDigests {
+			blobNames = append(blobNames, fmt.Sprintf("blob-mount-%d", idx))
+		}
+		for idx := range appendedBlobDigests {
+			blobNames = append(blobNames, fmt.Sprintf("blob-appended-mount-%d", idx))
+		}
+		upperBlobName = "blob-squashed"
+		upperBlobDigest, err = cm.squash(ctx, opt, originalSourceRef, squashedLayers, blobNames, upperBlobName)
+		if err != nil {
+			return errors.Wrap(err, "squash committed layers")
+		}
+		mountBlobDigests, appendedBlobDigests = nil, nil
+	}
+
+	var upperBlob *Blob
+	mountBlobs := make([]Blob, len(mountBlobDigests)+len(appendedBlobDigests))
+	eg := errgroup.Group{}
+	store := func(name string, blobDigest digest.Digest, set func(Blob)) {
+		eg.Go(func() error {
+			logrus.Infof("pushing blob %s", name)
+			start := time.Now()
+			desc, err := cm.storeBlob(ctx, opt, name, blobDigest, originalSourceRef, targetRef, image)
+			if err != nil {
+				return errors.Wrapf(err, "push blob %s", name)
+			}
+			set(Blob{Name: name, Desc: *desc})
+			logrus.Infof("pushed blob %s, elapsed: %s", name, time.Since(start))
+			return nil
+		})
+	}
+	store(upperBlobName, *upperBlobDigest, func(blob Blob) {
+		upperBlob = &blob
+	})
+	for idx, blobDigest := range mountBlobDigests {
+		store(fmt.Sprintf("blob-mount-%d", idx), *blobDigest, func(blob Blob) {
+			mountBlobs[idx] = blob
+		})
+	}
+	for idx, blobDigest := range appendedBlobDigests {
+		store(fmt.Sprintf("blob-appended-mount-%d", idx), *blobDigest, func(blob Blob) {
+			mountBlobs[len(mountBlobDigests)+idx] = blob
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
 	}
 
-	logrus.Infof("merging base and upper bootstraps")
-	_, bootstrapDiffID, err := cm.mergeBootstrap(ctx, *upperBlob, mountBlobs, "bootstrap-base", "bootstrap-merged.tar")
+	var bootstrapDiffID *digest.Digest
+	if opt.Squash {
+		logrus.Infof("merging lower and squashed bootstraps")
+		bootstrapDiffID, err = cm.squashBootstrap(ctx, originalSourceRef, opt.SourceInsecure, image.Manifest.Layers, *upperBlob, "bootstrap-merged.tar")
+	} else {
+		logrus.Infof("merging base and upper bootstraps")
+		_, bootstrapDiffID, err = cm.mergeBootstrap(ctx, *upperBlob, mountBlobs, "bootstrap-base", "bootstrap-merged.tar")
+	}
 	if err != nil {
 		return errors.Wrap(err, "merge bootstrap")
 	}
 
+	if opt.ExportDir != "" {
+		if err := cm.exportBootstrap("bootstrap-merged.tar", opt.ExportDir); err != nil {
+			return errors.Wrap(err, "export bootstrap")
+		}
+		logrus.Infof("exported committed blobs and bootstrap to %s", opt.ExportDir)
+		return nil
+	}
+
 	logrus.Infof("pushing committed image to %s", targetRef)
 	if err := cm.pushManifest(ctx, *image, *bootstrapDiffID, targetRef, "bootstrap-merged.tar", opt.FsVersion, upperBlob, mountBlobs, opt.TargetInsecure); err != nil {
 		return errors.Wrap(err, "push manifest")
@@ -318,7 +440,7 @@ func (cm *Committer) pullBootstrap(ctx context.Context, ref, bootstrapName strin
 	return parsed.NydusImage, committedLayers, nil
 }
 
-func (cm *Committer) commitUpperByDiff(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, lowerDirs, upperDir, blobName, fsversion, compressor string) (*digest.Digest, error) {
+func (cm *Committer) commitUpperByDiff(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, excluder *diff.Excluder, lowerDirs, upperDir, blobName, fsversion, compressor string) (*digest.Digest, error) {
 	logrus.Infof("committing upper")
 	start := time.Now()
 
@@ -341,7 +463,7 @@ func (cm *Committer) commitUpperByDiff(ctx context.Context, appendMount func(pat
 		return nil, errors.Wrap(err, "initialize pack to blob")
 	}
 
-	if err := diff.Diff(ctx, appendMount, withPaths, withoutPaths, tarWc, lowerDirs, upperDir); err != nil {
+	if err := diff.Diff(ctx, appendMount, withPaths, withoutPaths, excluder, tarWc, lowerDirs, upperDir); err != nil {
 		return nil, errors.Wrap(err, "make diff")
 	}
 
@@ -505,22 +627,26 @@ func (cm *Committer) pushBlob(ctx context.Context, blobName string, blobDigest d
 	return &blobDesc, nil
 }
 
-func (cm *Committer) pause(ctx context.Context, containerID string, handle func() error) error {
+// pause pauses the container during handle, the container is always resumed
+// even if the handle fails or the context is canceled.
+func (cm *Committer) pause(ctx context.Context, pauser pauser, containerID string, handle func() error) error {
 	logrus.Infof("pausing container: %s", containerID)
-	if err := cm.manager.Pause(ctx, containerID); err != nil {
+	if err := pauser.Pause(ctx, containerID); err != nil {
 		return errors.Wrap(err, "pause container")
 	}
+	start := time.Now()
 
+	resumeCtx := context.WithoutCancel(ctx)
 	if err := handle(); err != nil {
 		logrus.Infof("unpausing container: %s", containerID)
-		if err := cm.manager.UnPause(ctx, containerID); err != nil {
+		if err := pauser.UnPause(resumeCtx, containerID); err != nil {
 			logrus.Errorf("unpause container: %s", containerID)
 		}
 		return err
 	}
 
-	logrus.Infof("unpausing container: %s", containerID)
-	return cm.manager.UnPause(ctx, containerID)
+	logrus.Infof("unpausing container: %s, paused: %s", containerID, time.Since(start))
+	return pauser.UnPause(resumeCtx, containerID)
 }
 
 // syncFilesystem forces filesystem sync to ensure all changes are written to disk.
@@ -692,7 +818,7 @@ func (cm *Committer) makeDesc(x interface{}, oldDesc ocispec.Descriptor) ([]byte
 	return data, &newDesc, nil
 }
 
-func (cm *Committer) commitMountByNSEnter(ctx context.Context, containerPid int, sourceDir, name, fsversion, compressor string) (*digest.Digest, error) {
+func (cm *Committer) commitMountByNSEnter(ctx context.Context, containerPid int, sourceDir, name string, excluder *diff.Excluder, fsversion, compressor string) (*digest.Digest, error) {
 	logrus.Infof("committing mount: %s", sourceDir)
 	start := time.Now()
 
@@ -715,7 +841,7 @@ func (cm *Committer) commitMountByNSEnter(ctx context.Context, containerPid int,
 		return nil, errors.Wrap(err, "initialize pack to blob")
 	}
 
-	if err := copyFromContainer(ctx, containerPid, sourceDir, tarWc); err != nil {
+	if err := copyFromContainer(ctx, containerPid, sourceDir, tarWc, excluder); err != nil {
 		return nil, errors.Wrapf(err, "copy %s from pid %d", sourceDir, containerPid)
 	}
 
@@ -780,13 +906,34 @@ func (cm *Committer) mergeBootstrap(
 	return blobDigests, &bootstrapDiffID, nil
 }
 
-func copyFromContainer(ctx context.Context, containerPid int, source string, target io.Writer) error {
+func copyFromContainer(ctx context.Context, containerPid int, source string, target io.Writer, excluder *diff.Excluder) error {
 	config := &Config{
 		Mount:  true,
 		Target: containerPid,
 	}
 
-	stderr, err := config.ExecuteContext(ctx, target, "tar", "--xattrs", "--ignore-failed-read", "--absolute-names", "-cf", "-", source)
+	// Filter the excluded paths from the tar stream.
+	output := target
+	var pipeWriter *io.PipeWriter
+	filterErr := make(chan error, 1)
+	if excluder != nil {
+		var pipeReader *io.PipeReader
+		pipeReader, pipeWriter = io.Pipe()
+		go func() {
+			err := filterTar(pipeReader, target, excluder)
+			pipeReader.CloseWithError(err)
+			filterErr <- err
+		}()
+		output = pipeWriter
+	}
+
+	stderr, err := config.ExecuteContext(ctx, output, "tar", "--xattrs", "--ignore-failed-read", "--absolute-names", "-cf", "-", source)
+	if pipeWriter != nil {
+		pipeWriter.Close()
+		if ferr := <-filterErr; ferr != nil && err == nil {
+			return errors.Wrap(ferr, "exclude paths")
+		}
+	}
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("execute tar: %s", strings.TrimSpace(stderr)))
 	}
@@ -900,38 +1047,9 @@ func (cm *Committer) resolveContainerID(ctx context.Context, opt *Opt) error {
 
 	logrus.Infof("resolving container ID prefix %s to full ID", opt.ContainerID)
 
-	var (
-		fullID     string
-		matchCount int
-	)
-
-	// Create containerd client directly
-	client, err := client.New(cm.manager.address)
+	fullID, err := cm.manager.Resolve(ctx, opt.ContainerID)
 	if err != nil {
-		return fmt.Errorf("failed to create containerd client: %w", err)
-	}
-	defer client.Close()
-
-	// Set namespace in context
-	ctx = namespaces.WithNamespace(ctx, opt.Namespace)
-
-	walker := NewContainerWalker(client, func(_ context.Context, found Found) error {
-		fullID = found.Container.ID()
-		matchCount = found.MatchCount
-		return nil
-	})
-
-	n, err := walker.Walk(ctx, opt.ContainerID)
-	if err != nil {
-		return fmt.Errorf("failed to walk containers: %w", err)
-	}
-
-	if n == 0 {
-		return fmt.Errorf("no container found with ID : %s", opt.ContainerID)
-	}
-
-	if matchCount > 1 {
-		return fmt.Errorf("ambiguous container ID  '%s' matches multiple containers, please provide a more specific ID", opt.ContainerID)
+		return err
 	}
 
 	opt.ContainerID = fullID
@@ -0,0 +1,140 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCRIOStateDir = "/run/containers/storage/overlay-containers"
+	defaultOCIRuntime   = "runc"
+
+	crioImageNameAnnotation = "io.kubernetes.cri-o.ImageName"
+	crioImageAnnotation     = "io.kubernetes.cri-o.Image"
+)
+
+// CRIORuntime is the runtime adapter of CRI-O, which reads the containers
+// from the state directory of CRI-O, and pauses the containers by the OCI
+// runtime of CRI-O.
+type CRIORuntime struct {
+	stateDir   string
+	ociRuntime string
+}
+
+// NewCRIORuntime creates the adapter of CRI-O with the container state
+// directory, default to "/run/containers/storage/overlay-containers", and
+// the OCI runtime binary, default to "runc".
+func NewCRIORuntime(stateDir, ociRuntime string) *CRIORuntime {
+	if stateDir == "" {
+		stateDir = defaultCRIOStateDir
+	}
+	if ociRuntime == "" {
+		ociRuntime = defaultOCIRuntime
+	}
+	return &CRIORuntime{
+		stateDir:   stateDir,
+		ociRuntime: ociRuntime,
+	}
+}
+
+func (c *CRIORuntime) Resolve(_ context.Context, containerID string) (string, error) {
+	entries, err := os.ReadDir(c.stateDir)
+	if err != nil {
+		return "", errors.Wrap(err, "read CRI-O state directory")
+	}
+	matched := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), containerID) {
+			matched = append(matched, entry.Name())
+		}
+	}
+	if len(matched) == 0 {
+		return "", fmt.Errorf("no container found with ID : %s", containerID)
+	}
+	if len(matched) > 1 {
+		return "", fmt.Errorf("ambiguous container ID  '%s' matches multiple containers, please provide a more specific ID", containerID)
+	}
+	return matched[0], nil
+}
+
+func (c *CRIORuntime) Inspect(_ context.Context, containerID string) (*InspectResult, error) {
+	userData := filepath.Join(c.stateDir, containerID, "userdata")
+	specBytes, err := os.ReadFile(filepath.Join(userData, "config.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "read container config")
+	}
+	spec := oci.Spec{}
+	if err := json.Unmarshal(specBytes, &spec); err != nil {
+		return nil, errors.Wrap(err, "unmarshal container config")
+	}
+
+	image := spec.Annotations[crioImageNameAnnotation]
+	if image == "" {
+		image = spec.Annotations[crioImageAnnotation]
+	}
+	if image == "" {
+		return nil, fmt.Errorf("image of container %s is not found", containerID)
+	}
+	if image, err = normalizeImage(image); err != nil {
+		return nil, err
+	}
+
+	pidBytes, err := os.ReadFile(filepath.Join(userData, "pidfile"))
+	if err != nil {
+		return nil, errors.Wrap(err, "read container pid file")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid container pid")
+	}
+
+	lowerDirs, upperDir, err := overlayDirs(pid)
+	if err != nil {
+		return nil, errors.Wrap(err, "obtain container overlay directories")
+	}
+
+	mounts := []Mount{}
+	for _, mount := range spec.Mounts {
+		mounts = append(mounts, Mount{
+			Destination: mount.Destination,
+			Source:      mount.Source,
+		})
+	}
+
+	return &InspectResult{
+		LowerDirs: lowerDirs,
+		UpperDir:  upperDir,
+		Image:     image,
+		Mounts:    mounts,
+		Pid:       pid,
+	}, nil
+}
+
+func (c *CRIORuntime) runtime(ctx context.Context, action, containerID string) error {
+	output, err := exec.CommandContext(ctx, c.ociRuntime, action, containerID).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "%s container by %s: %s", action, c.ociRuntime, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (c *CRIORuntime) Pause(ctx context.Context, containerID string) error {
+	return c.runtime(ctx, "pause", containerID)
+}
+
+func (c *CRIORuntime) UnPause(ctx context.Context, containerID string) error {
+	return c.runtime(ctx, "resume", containerID)
+}
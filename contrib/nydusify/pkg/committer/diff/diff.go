@@ -29,7 +29,7 @@ func overlaySupportIndex() bool {
 //
 // WriteUpperdir writes a layer tar archive into the specified writer, based on
 // the diff information stored in the upperdir.
-func writeUpperdir(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, w io.Writer, upperdir string, lower []mount.Mount) error {
+func writeUpperdir(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, excluder *Excluder, w io.Writer, upperdir string, lower []mount.Mount) error {
 	emptyLower, err := os.MkdirTemp("", "buildkit") // empty directory used for the lower of diff view
 	if err != nil {
 		return errors.Wrapf(err, "failed to create temp dir")
@@ -53,7 +53,7 @@ func writeUpperdir(ctx context.Context, appendMount func(path string), withPaths
 	return mount.WithTempMount(ctx, lower, func(lowerRoot string) error {
 		return mount.WithTempMount(ctx, upperView, func(upperViewRoot string) error {
 			cw := archive.NewChangeWriter(&cancellableWriter{ctx, w}, upperViewRoot)
-			if err := Changes(ctx, appendMount, withPaths, withoutPaths, cw.HandleChange, upperdir, upperViewRoot, lowerRoot); err != nil {
+			if err := Changes(ctx, appendMount, withPaths, withoutPaths, excluder, cw.HandleChange, upperdir, upperViewRoot, lowerRoot); err != nil {
 				if err2 := cw.Close(); err2 != nil {
 					return errors.Wrapf(err, "failed to record upperdir changes (close error: %v)", err2)
 				}
@@ -64,7 +64,7 @@ func writeUpperdir(ctx context.Context, appendMount func(path string), withPaths
 	})
 }
 
-func Diff(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, writer io.Writer, lowerDirs, upperDir string) error {
+func Diff(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, excluder *Excluder, writer io.Writer, lowerDirs, upperDir string) error {
 	emptyLower, err := os.MkdirTemp("", "nydus-cli-diff")
 	if err != nil {
 		return errors.Wrapf(err, "create temp dir")
@@ -106,7 +106,7 @@ func Diff(ctx context.Context, appendMount func(path string), withPaths []string
 		return errors.Wrap(err, "get upper dir")
 	}
 
-	if err = writeUpperdir(ctx, appendMount, withPaths, withoutPaths, &cancellableWriter{ctx, writer}, upperDir, lower); err != nil {
+	if err = writeUpperdir(ctx, appendMount, withPaths, withoutPaths, excluder, &cancellableWriter{ctx, writer}, upperDir, lower); err != nil {
 		return errors.Wrap(err, "write diff")
 	}
 
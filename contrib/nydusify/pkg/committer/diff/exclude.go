@@ -0,0 +1,123 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type pattern struct {
+	negate   bool
+	segments []string
+}
+
+// Excluder matches the paths excluded from the diff by the glob patterns in
+// the syntax of .dockerignore. The pattern containing "/" is anchored at the
+// root, otherwise it matches the file name in any directory, "**" matches any
+// number of directories. The pattern prefixed with "!" re-includes the paths,
+// the last matched pattern wins. The paths under a matched directory are
+// matched too.
+type Excluder struct {
+	patterns []pattern
+	negated  bool
+}
+
+// NewExcluder creates the excluder of the patterns.
+func NewExcluder(patterns []string) (*Excluder, error) {
+	excluder := &Excluder{}
+	for _, raw := range patterns {
+		value := strings.TrimSpace(raw)
+		negate := strings.HasPrefix(value, "!")
+		value = strings.TrimSpace(strings.TrimPrefix(value, "!"))
+		value = strings.TrimSuffix(value, "/")
+		if value == "" {
+			continue
+		}
+
+		var segments []string
+		if strings.Contains(value, "/") {
+			segments = strings.Split(strings.Trim(filepath.Clean("/"+value), "/"), "/")
+		} else {
+			segments = []string{"**", value}
+		}
+		for _, segment := range segments {
+			if _, err := filepath.Match(segment, ""); err != nil {
+				return nil, errors.Wrapf(err, "invalid exclude pattern %q", raw)
+			}
+		}
+		excluder.patterns = append(excluder.patterns, pattern{negate: negate, segments: segments})
+		excluder.negated = excluder.negated || negate
+	}
+	return excluder, nil
+}
+
+// ParseIgnoreFile reads the patterns in ignore file, one pattern per line,
+// the blank lines and the lines starting with "#" are ignored.
+func ParseIgnoreFile(reader io.Reader) ([]string, error) {
+	patterns := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+// match checks if the pattern segments match the path segments or the
+// parent directory of them.
+func match(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if patterns[0] == "**" {
+		for idx := 0; idx <= len(segments); idx++ {
+			if match(patterns[1:], segments[idx:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(patterns[0], segments[0]); !ok {
+		return false
+	}
+	return match(patterns[1:], segments[1:])
+}
+
+// Excluded checks if the absolute path in container is excluded.
+func (e *Excluder) Excluded(path string) bool {
+	if e == nil || len(e.patterns) == 0 {
+		return false
+	}
+	path = strings.Trim(filepath.Clean("/"+path), "/")
+	if path == "" {
+		return false
+	}
+	segments := strings.Split(path, "/")
+
+	excluded := false
+	for _, pattern := range e.patterns {
+		if match(pattern.segments, segments) {
+			excluded = !pattern.negate
+		}
+	}
+	return excluded
+}
+
+// SkipDir checks if the excluded directory can be skipped entirely, it can't
+// if any path under it may be re-included.
+func (e *Excluder) SkipDir(path string) bool {
+	return e != nil && !e.negated && e.Excluded(path)
+}
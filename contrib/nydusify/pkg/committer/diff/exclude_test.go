@@ -0,0 +1,48 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExcluder(t *testing.T) {
+	excluder, err := NewExcluder([]string{"*.tmp", "/var/cache", "root/**/__pycache__", "/data/*.log", "!/data/keep.log"})
+	require.NoError(t, err)
+
+	require.True(t, excluder.Excluded("/a.tmp"))
+	require.True(t, excluder.Excluded("/home/user/b.tmp"))
+	require.True(t, excluder.Excluded("/var/cache"))
+	require.True(t, excluder.Excluded("/var/cache/apt/pkgcache.bin"))
+	require.True(t, excluder.Excluded("/root/__pycache__"))
+	require.True(t, excluder.Excluded("/root/app/lib/__pycache__/x.pyc"))
+	require.True(t, excluder.Excluded("/data/app.log"))
+
+	require.False(t, excluder.Excluded("/"))
+	require.False(t, excluder.Excluded("/var/cached"))
+	require.False(t, excluder.Excluded("/var/lib/cache"))
+	require.False(t, excluder.Excluded("/data/keep.log"))
+	require.False(t, excluder.Excluded("/data/sub/app.log"))
+	require.False(t, excluder.SkipDir("/var/cache"))
+
+	excluder, err = NewExcluder([]string{"/var/cache/"})
+	require.NoError(t, err)
+	require.True(t, excluder.SkipDir("/var/cache"))
+
+	var nilExcluder *Excluder
+	require.False(t, nilExcluder.Excluded("/a.tmp"))
+
+	_, err = NewExcluder([]string{"[a-"})
+	require.Error(t, err)
+}
+
+func TestParseIgnoreFile(t *testing.T) {
+	patterns, err := ParseIgnoreFile(strings.NewReader("# cache\n/var/cache\n\n  *.tmp  \n!keep.tmp\n"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"/var/cache", "*.tmp", "!keep.tmp"}, patterns)
+}
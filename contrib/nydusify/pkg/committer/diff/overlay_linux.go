@@ -125,7 +125,7 @@ func (w *cancellableWriter) Write(p []byte) (int, error) {
 // "upperdir" for computing the diff. "upperdirView" is overlayfs mounted view of
 // the upperdir that doesn't contain whiteouts. This is used for computing
 // changes under opaque directories.
-func Changes(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, changeFn fs.ChangeFunc, upperdir, upperdirView, base string) error {
+func Changes(ctx context.Context, appendMount func(path string), withPaths []string, withoutPaths []string, excluder *Excluder, changeFn fs.ChangeFunc, upperdir, upperdirView, base string) error {
 	err := filepath.Walk(upperdir, func(path string, f os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -175,6 +175,15 @@ func Changes(ctx context.Context, appendMount func(path string), withPaths []str
 			return nil
 		}
 
+		// Skip excluded path, the deleted entries are kept to not expose
+		// the files in lower layers.
+		if !isDelete && excluder.Excluded(path) {
+			if f.IsDir() && excluder.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		var kind fs.ChangeKind
 		var skipRecord bool
 		if isDelete {
@@ -213,6 +222,9 @@ func Changes(ctx context.Context, appendMount func(path string), withPaths []str
 				// this directory. We use "upperdirView" directory which doesn't contain whiteouts.
 				if err := fs.Changes(ctx, filepath.Join(base, path), filepath.Join(upperdirView, path),
 					func(k fs.ChangeKind, p string, f os.FileInfo, err error) error {
+						if k != fs.ChangeKindDelete && excluder.Excluded(filepath.Join(path, p)) {
+							return nil
+						}
 						return changeFn(k, filepath.Join(path, p), f, err) // rebase path to be based on the opaque dir
 					},
 				); err != nil {
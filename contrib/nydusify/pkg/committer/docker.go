@@ -0,0 +1,158 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultDockerAddress = "/var/run/docker.sock"
+
+type dockerContainer struct {
+	ID     string `json:"Id"`
+	Config struct {
+		Image string
+	}
+	State struct {
+		Pid     int
+		Running bool
+	}
+	GraphDriver struct {
+		Name string
+		Data map[string]string
+	}
+	Mounts []struct {
+		Source      string
+		Destination string
+	}
+}
+
+// DockerRuntime is the runtime adapter of Docker Engine, which accesses the
+// containers by the Docker Engine API.
+type DockerRuntime struct {
+	client *http.Client
+}
+
+// NewDockerRuntime creates the adapter of Docker Engine listening on the unix
+// socket address, default to "/var/run/docker.sock".
+func NewDockerRuntime(address string) *DockerRuntime {
+	sock := strings.TrimPrefix(address, "unix://")
+	if sock == "" {
+		sock = defaultDockerAddress
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 5 * time.Second,
+			}
+			return dialer.DialContext(ctx, "unix", sock)
+		},
+	}
+	return &DockerRuntime{
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+func (d *DockerRuntime) request(ctx context.Context, method, path string, expected int, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request docker engine")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	return nil
+}
+
+func (d *DockerRuntime) inspect(ctx context.Context, containerID string) (*dockerContainer, error) {
+	container := dockerContainer{}
+	if err := d.request(ctx, http.MethodGet, "/containers/"+url.PathEscape(containerID)+"/json", http.StatusOK, &container); err != nil {
+		return nil, errors.Wrapf(err, "inspect container %s", containerID)
+	}
+	return &container, nil
+}
+
+// Resolve resolves the container ID prefix or container name by Docker
+// Engine, which rejects the ambiguous prefix.
+func (d *DockerRuntime) Resolve(ctx context.Context, containerID string) (string, error) {
+	container, err := d.inspect(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	return container.ID, nil
+}
+
+func (d *DockerRuntime) Inspect(ctx context.Context, containerID string) (*InspectResult, error) {
+	container, err := d.inspect(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	if !container.State.Running {
+		return nil, fmt.Errorf("container %s is not running", containerID)
+	}
+	image, err := normalizeImage(container.Config.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	// The overlay2 graph driver records the overlay directories, otherwise
+	// (for example the containerd image store with nydus snapshotter) they
+	// are obtained from the rootfs mount of container process.
+	lowerDirs := container.GraphDriver.Data["LowerDir"]
+	upperDir := container.GraphDriver.Data["UpperDir"]
+	if lowerDirs == "" || upperDir == "" {
+		if lowerDirs, upperDir, err = overlayDirs(container.State.Pid); err != nil {
+			return nil, errors.Wrap(err, "obtain container overlay directories")
+		}
+	}
+
+	mounts := []Mount{}
+	for _, mount := range container.Mounts {
+		mounts = append(mounts, Mount{
+			Destination: mount.Destination,
+			Source:      mount.Source,
+		})
+	}
+
+	return &InspectResult{
+		LowerDirs: lowerDirs,
+		UpperDir:  upperDir,
+		Image:     image,
+		Mounts:    mounts,
+		Pid:       container.State.Pid,
+	}, nil
+}
+
+func (d *DockerRuntime) Pause(ctx context.Context, containerID string) error {
+	return d.request(ctx, http.MethodPost, "/containers/"+url.PathEscape(containerID)+"/pause", http.StatusNoContent, nil)
+}
+
+func (d *DockerRuntime) UnPause(ctx context.Context, containerID string) error {
+	return d.request(ctx, http.MethodPost, "/containers/"+url.PathEscape(containerID)+"/unpause", http.StatusNoContent, nil)
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/committer/diff"
+)
+
+// ignoreFile is the file in the root of container listing the patterns
+// excluded from commit.
+const ignoreFile = ".nydusignore"
+
+// loadExcluder creates the excluder of the patterns and the ones in the
+// .nydusignore file of container, the latter take precedence.
+func loadExcluder(pid int, patterns []string) (*diff.Excluder, error) {
+	patterns = append([]string{}, patterns...)
+
+	file, err := os.Open(fmt.Sprintf("/proc/%d/root/%s", pid, ignoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "open %s in container", ignoreFile)
+	}
+	if err == nil {
+		defer file.Close()
+		ignored, err := diff.ParseIgnoreFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s in container", ignoreFile)
+		}
+		logrus.Infof("loaded %d exclude patterns from %s", len(ignored), ignoreFile)
+		patterns = append(patterns, ignored...)
+	}
+
+	return diff.NewExcluder(patterns)
+}
+
+// filterTar copies the tar stream without the excluded entries.
+func filterTar(reader io.Reader, writer io.Writer, excluder *diff.Excluder) error {
+	tr := tar.NewReader(reader)
+	tw := tar.NewWriter(writer)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read tar")
+		}
+		if excluder.Excluded(hdr.Name) {
+			continue
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrap(err, "write tar header")
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return errors.Wrap(err, "write tar entry")
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	parserPkg "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	// exportBootstrapName is the RAFS bootstrap file in export directory.
+	exportBootstrapName = "bootstrap"
+	// exportBootstrapLayerName is the bootstrap layer tar in export directory.
+	exportBootstrapLayerName = "bootstrap.tar"
+)
+
+func copyFile(src, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	if _, err := io.Copy(target, source); err != nil {
+		return err
+	}
+	return target.Sync()
+}
+
+// storeBlob pushes the committed blob to target registry, or exports it to
+// the export directory if it's specified.
+func (cm *Committer) storeBlob(
+	ctx context.Context, opt Opt, blobName string, blobDigest digest.Digest, sourceRef, targetRef string, image *parserPkg.Image,
+) (*ocispec.Descriptor, error) {
+	if opt.ExportDir == "" {
+		return cm.pushBlob(ctx, blobName, blobDigest, sourceRef, targetRef, opt.TargetInsecure, image)
+	}
+	return cm.exportBlob(blobName, blobDigest, opt.ExportDir)
+}
+
+// exportBlob copies the committed blob to the export directory, the blob is
+// named by its digest like the blob directory of localfs backend.
+func (cm *Committer) exportBlob(blobName string, blobDigest digest.Digest, exportDir string) (*ocispec.Descriptor, error) {
+	blobPath := filepath.Join(cm.workDir, blobName)
+	info, err := os.Stat(blobPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat blob")
+	}
+
+	exportPath := filepath.Join(exportDir, blobDigest.Encoded())
+	if err := copyFile(blobPath, exportPath); err != nil {
+		return nil, errors.Wrapf(err, "export blob %s", blobName)
+	}
+	logrus.Infof("exported blob %s to %s", blobName, exportPath)
+
+	return &ocispec.Descriptor{
+		Digest:    blobDigest,
+		Size:      info.Size(),
+		MediaType: utils.MediaTypeNydusBlob,
+		Annotations: map[string]string{
+			utils.LayerAnnotationUncompressed: blobDigest.String(),
+			utils.LayerAnnotationNydusBlob:    "true",
+		},
+	}, nil
+}
+
+// exportBootstrap copies the merged bootstrap layer and the RAFS bootstrap in
+// it to the export directory.
+func (cm *Committer) exportBootstrap(mergedBootstrapName, exportDir string) error {
+	layerPath := filepath.Join(cm.workDir, mergedBootstrapName)
+	if err := copyFile(layerPath, filepath.Join(exportDir, exportBootstrapLayerName)); err != nil {
+		return errors.Wrap(err, "export bootstrap layer")
+	}
+
+	layer, err := os.Open(layerPath)
+	if err != nil {
+		return errors.Wrap(err, "open bootstrap layer")
+	}
+	defer layer.Close()
+	bootstrapPath := filepath.Join(exportDir, exportBootstrapName)
+	if err := utils.UnpackFile(layer, utils.BootstrapFileNameInLayer, bootstrapPath); err != nil {
+		return errors.Wrap(err, "unpack bootstrap")
+	}
+	logrus.Infof("exported bootstrap to %s", bootstrapPath)
+
+	return nil
+}
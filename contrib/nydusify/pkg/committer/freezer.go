@@ -0,0 +1,137 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// PauseRuntime pauses the container by the container runtime.
+	PauseRuntime = "runtime"
+	// PauseFreezer freezes the cgroup of container process directly.
+	PauseFreezer = "freezer"
+
+	cgroupRoot = "/sys/fs/cgroup"
+)
+
+// pauser freezes and thaws the container during commit.
+type pauser interface {
+	Pause(ctx context.Context, containerID string) error
+	UnPause(ctx context.Context, containerID string) error
+}
+
+// cgroupFreezer freezes the cgroup of container process by the cgroup v2
+// cgroup.freeze or the cgroup v1 freezer controller, which is used if the
+// runtime can't pause the container.
+type cgroupFreezer struct {
+	pid  int
+	root string
+}
+
+func newCgroupFreezer(pid int) *cgroupFreezer {
+	return &cgroupFreezer{pid: pid, root: cgroupRoot}
+}
+
+// parseCgroupFile returns the cgroup v2 path and the cgroup v1 freezer path
+// in /proc/<pid>/cgroup.
+func parseCgroupFile(reader io.Reader) (string, string, error) {
+	var unified, freezer string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			unified = parts[2]
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			if controller == "freezer" {
+				freezer = parts[2]
+			}
+		}
+	}
+	return unified, freezer, scanner.Err()
+}
+
+// state returns the freezer state file, the values to freeze and thaw, and
+// the content of state file after frozen.
+func (f *cgroupFreezer) state() (string, string, string, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", f.pid))
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "open cgroup file")
+	}
+	defer file.Close()
+	unified, freezer, err := parseCgroupFile(file)
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "parse cgroup file")
+	}
+	if freezer != "" {
+		return filepath.Join(f.root, "freezer", freezer, "freezer.state"), "FROZEN", "THAWED", nil
+	}
+	if unified != "" {
+		return filepath.Join(f.root, unified, "cgroup.freeze"), "1", "0", nil
+	}
+	return "", "", "", fmt.Errorf("freezer cgroup of process %d is not found", f.pid)
+}
+
+func (f *cgroupFreezer) Pause(ctx context.Context, _ string) error {
+	path, frozen, _, err := f.state()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(frozen), 0644); err != nil {
+		return errors.Wrap(err, "freeze cgroup")
+	}
+
+	// Wait until all the processes in cgroup are frozen.
+	eventsPath := filepath.Join(filepath.Dir(path), "cgroup.events")
+	for {
+		var done bool
+		if filepath.Base(path) == "freezer.state" {
+			state, err := os.ReadFile(path)
+			if err != nil {
+				return errors.Wrap(err, "read freezer state")
+			}
+			done = strings.TrimSpace(string(state)) == frozen
+		} else {
+			events, err := os.ReadFile(eventsPath)
+			if err != nil {
+				return errors.Wrap(err, "read cgroup events")
+			}
+			done = strings.Contains(string(events), "frozen 1")
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (f *cgroupFreezer) UnPause(_ context.Context, _ string) error {
+	path, _, thawed, err := f.state()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(thawed), 0644); err != nil {
+		return errors.Wrap(err, "thaw cgroup")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCgroupFile(t *testing.T) {
+	unified, freezer, err := parseCgroupFile(strings.NewReader("0::/system.slice/docker-abc.scope\n"))
+	require.NoError(t, err)
+	require.Equal(t, "/system.slice/docker-abc.scope", unified)
+	require.Empty(t, freezer)
+
+	unified, freezer, err = parseCgroupFile(strings.NewReader(`12:cpu,cpuacct:/default/abc
+7:freezer:/default/abc
+0::/
+`))
+	require.NoError(t, err)
+	require.Equal(t, "/", unified)
+	require.Equal(t, "/default/abc", freezer)
+}
@@ -7,6 +7,7 @@ package committer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	containerdclient "github.com/containerd/containerd/v2/client"
@@ -27,6 +28,37 @@ type Mount struct {
 	Source      string
 }
 
+const (
+	RuntimeContainerd = "containerd"
+	RuntimeDocker     = "docker"
+	RuntimeCRIO       = "crio"
+)
+
+// Runtime manages the containers to be committed in a container runtime.
+type Runtime interface {
+	// Resolve resolves the container ID prefix (or name) to the full ID.
+	Resolve(ctx context.Context, containerID string) (string, error)
+	Inspect(ctx context.Context, containerID string) (*InspectResult, error)
+	Pause(ctx context.Context, containerID string) error
+	UnPause(ctx context.Context, containerID string) error
+}
+
+// NewRuntime creates the runtime adapter specified by opt.Runtime, which is
+// default to containerd.
+func NewRuntime(opt Opt) (Runtime, error) {
+	switch opt.Runtime {
+	case "", RuntimeContainerd:
+		return NewManager(opt.ContainerdAddress)
+	case RuntimeDocker:
+		return NewDockerRuntime(opt.RuntimeAddress), nil
+	case RuntimeCRIO:
+		return NewCRIORuntime(opt.RuntimeAddress, opt.OCIRuntime), nil
+	default:
+		return nil, fmt.Errorf("unsupported container runtime %s", opt.Runtime)
+	}
+}
+
+// Manager is the runtime adapter of containerd.
 type Manager struct {
 	address string
 }
@@ -37,6 +69,40 @@ func NewManager(addr string) (*Manager, error) {
 	}, nil
 }
 
+func (m *Manager) Resolve(ctx context.Context, containerID string) (string, error) {
+	var (
+		fullID     string
+		matchCount int
+	)
+
+	client, err := containerdclient.New(m.address)
+	if err != nil {
+		return "", fmt.Errorf("failed to create containerd client: %w", err)
+	}
+	defer client.Close()
+
+	walker := NewContainerWalker(client, func(_ context.Context, found Found) error {
+		fullID = found.Container.ID()
+		matchCount = found.MatchCount
+		return nil
+	})
+
+	n, err := walker.Walk(ctx, containerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to walk containers: %w", err)
+	}
+
+	if n == 0 {
+		return "", fmt.Errorf("no container found with ID : %s", containerID)
+	}
+
+	if matchCount > 1 {
+		return "", fmt.Errorf("ambiguous container ID  '%s' matches multiple containers, please provide a more specific ID", containerID)
+	}
+
+	return fullID, nil
+}
+
 func (m *Manager) Pause(ctx context.Context, containerID string) error {
 	client, err := containerdclient.New(m.address)
 	if err != nil {
@@ -0,0 +1,72 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// parseOverlayMountInfo returns the lower and upper directories of the
+// overlay mounted at the root in the mountinfo.
+func parseOverlayMountInfo(reader io.Reader) (string, string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// 36 35 0:31 / / rw,relatime - overlay overlay rw,lowerdir=...,upperdir=...,workdir=...
+		fields := strings.Split(scanner.Text(), " - ")
+		if len(fields) != 2 {
+			continue
+		}
+		mountFields := strings.Fields(fields[0])
+		superFields := strings.Fields(fields[1])
+		if len(mountFields) < 5 || mountFields[4] != "/" || len(superFields) < 3 || superFields[0] != "overlay" {
+			continue
+		}
+		var lowerDirs, upperDir string
+		for _, option := range strings.Split(superFields[2], ",") {
+			if strings.HasPrefix(option, "lowerdir=") {
+				lowerDirs = strings.TrimPrefix(option, "lowerdir=")
+			} else if strings.HasPrefix(option, "upperdir=") {
+				upperDir = strings.TrimPrefix(option, "upperdir=")
+			}
+		}
+		if lowerDirs == "" || upperDir == "" {
+			return "", "", fmt.Errorf("invalid overlay root mount options %s", superFields[2])
+		}
+		return lowerDirs, upperDir, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", fmt.Errorf("overlay root mount is not found")
+}
+
+// overlayDirs returns the lower and upper directories of the overlay rootfs
+// of the container process.
+func overlayDirs(pid int) (string, string, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/mountinfo", pid))
+	if err != nil {
+		return "", "", errors.Wrap(err, "open mountinfo")
+	}
+	defer file.Close()
+	return parseOverlayMountInfo(file)
+}
+
+// normalizeImage returns the full reference of the image name recorded by
+// the runtime, like "busybox" to "docker.io/library/busybox:latest".
+func normalizeImage(image string) (string, error) {
+	named, err := reference.ParseDockerRef(image)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid container image %s", image)
+	}
+	return named.String(), nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOverlayMountInfo(t *testing.T) {
+	mountInfo := `1580 1497 0:118 / / rw,relatime master:402 - overlay overlay rw,lowerdir=/var/lib/nydus/snapshots/2/fs:/var/lib/nydus/snapshots/1/fs,upperdir=/var/lib/nydus/snapshots/3/fs,workdir=/var/lib/nydus/snapshots/3/work
+1581 1580 0:121 / /proc rw,nosuid,nodev,noexec,relatime - proc proc rw
+`
+	lowerDirs, upperDir, err := parseOverlayMountInfo(strings.NewReader(mountInfo))
+	require.NoError(t, err)
+	require.Equal(t, "/var/lib/nydus/snapshots/2/fs:/var/lib/nydus/snapshots/1/fs", lowerDirs)
+	require.Equal(t, "/var/lib/nydus/snapshots/3/fs", upperDir)
+
+	_, _, err = parseOverlayMountInfo(strings.NewReader("1581 1580 0:121 / / rw - ext4 /dev/sda1 rw\n"))
+	require.ErrorContains(t, err, "overlay root mount is not found")
+}
+
+func TestNormalizeImage(t *testing.T) {
+	image, err := normalizeImage("busybox")
+	require.NoError(t, err)
+	require.Equal(t, "docker.io/library/busybox:latest", image)
+
+	_, err = normalizeImage("INVALID:image")
+	require.Error(t, err)
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// checkSpace fails fast if the work directory lacks the space estimated to
+// commit the container: the blob of changes estimated by the size of upper
+// directory, and the committed layers pulled to be squashed.
+func checkSpace(upperDir string, squashedLayers []ocispec.Descriptor, opt Opt) error {
+	required, err := utils.DirSize(upperDir)
+	if err != nil {
+		// The estimation is best effort, the changes are read again by
+		// committing.
+		logrus.WithError(err).Warn("failed to estimate the space required by commit")
+		return nil
+	}
+	for _, layer := range squashedLayers {
+		required += layer.Size
+	}
+	if err := utils.CheckSpace(opt.WorkDir, required, opt.WorkDirQuota); err != nil {
+		return errors.Wrap(err, "insufficient space to commit container")
+	}
+	return nil
+}
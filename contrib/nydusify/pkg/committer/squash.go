@@ -0,0 +1,498 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BraveY/snapshotter-converter/converter"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/continuity/fs"
+	"github.com/dustin/go-humanize"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/committer/diff/archive"
+	parserPkg "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = whiteoutPrefix + ".wh..opq"
+
+	paxSchilyXattr = "SCHILY.xattr."
+	opaqueXattr    = "trusted.overlay.opaque"
+)
+
+// squasher applies the OCI layer tars in order into a directory to squash
+// them into one layer. The whiteouts and opaque directories, which may hide
+// the files in the lower layers not squashed, are recorded and written into
+// the squashed layer.
+type squasher struct {
+	root      string
+	whiteouts map[string]bool
+	opaques   map[string]bool
+}
+
+func newSquasher(root string) *squasher {
+	return &squasher{
+		root:      root,
+		whiteouts: map[string]bool{},
+		opaques:   map[string]bool{},
+	}
+}
+
+// forget drops the whiteouts and opaque directories under the path.
+func (s *squasher) forget(path string, keep map[string]bool) {
+	for _, records := range []map[string]bool{s.whiteouts, s.opaques} {
+		for record := range records {
+			if strings.HasPrefix(record, path+"/") && !keep[record] {
+				delete(records, record)
+			}
+		}
+	}
+}
+
+// whiteout removes the path of the former layers, the whiteout only applies
+// to the former layers, so the path written in the same layer is kept.
+func (s *squasher) whiteout(path string, written map[string]bool) error {
+	if written[path] {
+		return nil
+	}
+	if err := os.RemoveAll(filepath.Join(s.root, path)); err != nil {
+		return errors.Wrapf(err, "remove whiteout path %s", path)
+	}
+	s.forget(path, nil)
+	delete(s.opaques, path)
+	s.whiteouts[path] = true
+	return nil
+}
+
+// opaque removes the children of the directory in the former layers.
+func (s *squasher) opaque(dir string, written map[string]bool) error {
+	entries, err := os.ReadDir(filepath.Join(s.root, dir))
+	if err != nil {
+		return errors.Wrapf(err, "read opaque directory %s", dir)
+	}
+	for _, entry := range entries {
+		child := filepath.Join(dir, entry.Name())
+		if written[child] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.root, child)); err != nil {
+			return errors.Wrapf(err, "remove opaque directory child %s", child)
+		}
+	}
+	s.forget(dir, written)
+	s.opaques[dir] = true
+	return nil
+}
+
+func (s *squasher) create(hdr *tar.Header, reader io.Reader, target string) error {
+	mode := uint32(hdr.Mode & 07777)
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.Mkdir(target, 0755); err != nil && !os.IsExist(err) {
+			return err
+		}
+	case tar.TypeReg:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(file, reader); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		return os.Symlink(hdr.Linkname, target)
+	case tar.TypeLink:
+		return os.Link(filepath.Join(s.root, filepath.Clean("/"+hdr.Linkname)), target)
+	case tar.TypeChar:
+		mode |= unix.S_IFCHR
+	case tar.TypeBlock:
+		mode |= unix.S_IFBLK
+	case tar.TypeFifo:
+		mode |= unix.S_IFIFO
+	}
+	if hdr.Typeflag == tar.TypeChar || hdr.Typeflag == tar.TypeBlock || hdr.Typeflag == tar.TypeFifo {
+		return unix.Mknod(target, mode, int(unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor))))
+	}
+	return nil
+}
+
+func (s *squasher) setAttributes(hdr *tar.Header, target string) error {
+	if err := os.Lchown(target, hdr.Uid, hdr.Gid); err != nil {
+		return errors.Wrap(err, "change owner")
+	}
+	if capability, ok := hdr.PAXRecords[paxSchilyXattr+"security.capability"]; ok {
+		if err := unix.Lsetxattr(target, "security.capability", []byte(capability), 0); err != nil {
+			return errors.Wrap(err, "set capabilities xattr")
+		}
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		return nil
+	}
+	if err := unix.Chmod(target, uint32(hdr.Mode&07777)); err != nil {
+		return errors.Wrap(err, "change mode")
+	}
+	return os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+}
+
+// apply applies the OCI layer tar on the former layers.
+func (s *squasher) apply(reader io.Reader) error {
+	written := map[string]bool{}
+	dirs := []*tar.Header{}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read layer tar")
+		}
+
+		path := filepath.Clean("/" + hdr.Name)
+		if path == "/" {
+			continue
+		}
+		dir, base := filepath.Split(path)
+		dir = filepath.Clean(dir)
+		if err := os.MkdirAll(filepath.Join(s.root, dir), 0755); err != nil {
+			return errors.Wrapf(err, "create parent directory of %s", path)
+		}
+
+		if base == whiteoutOpaque {
+			if err := s.opaque(dir, written); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			if err := s.whiteout(filepath.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)), written); err != nil {
+				return err
+			}
+			continue
+		}
+		if hdr.Typeflag == tar.TypeChar && hdr.Devmajor == 0 && hdr.Devminor == 0 {
+			// The whiteout in overlayfs format.
+			if err := s.whiteout(path, written); err != nil {
+				return err
+			}
+			continue
+		}
+
+		target := filepath.Join(s.root, path)
+		if info, err := os.Lstat(target); err == nil && !(info.IsDir() && hdr.Typeflag == tar.TypeDir) {
+			if err := os.RemoveAll(target); err != nil {
+				return errors.Wrapf(err, "remove replaced path %s", path)
+			}
+			s.forget(path, written)
+			delete(s.opaques, path)
+		}
+		// The directory replacing a removed one should hide the children of
+		// the removed one in lower layers.
+		replaced := s.whiteouts[path]
+		delete(s.whiteouts, path)
+
+		if err := s.create(hdr, tr, target); err != nil {
+			return errors.Wrapf(err, "create %s", path)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			if replaced || hdr.PAXRecords[paxSchilyXattr+opaqueXattr] == "y" {
+				s.opaques[path] = true
+			}
+			dirs = append(dirs, hdr)
+		} else if err := s.setAttributes(hdr, target); err != nil {
+			return errors.Wrapf(err, "set attributes of %s", path)
+		}
+		written[path] = true
+	}
+
+	// Set the attributes of directories after the children are created,
+	// which changes the modification time of directories.
+	for idx := len(dirs) - 1; idx >= 0; idx-- {
+		path := filepath.Clean("/" + dirs[idx].Name)
+		if _, err := os.Lstat(filepath.Join(s.root, path)); os.IsNotExist(err) {
+			continue
+		}
+		if err := s.setAttributes(dirs[idx], filepath.Join(s.root, path)); err != nil {
+			return errors.Wrapf(err, "set attributes of %s", path)
+		}
+	}
+
+	return nil
+}
+
+// write writes the squashed layer tar, the whiteouts and opaque markers of
+// a directory follow the directory entry.
+func (s *squasher) write(writer io.Writer) error {
+	whiteouts := map[string][]string{}
+	for path := range s.whiteouts {
+		whiteouts[filepath.Dir(path)] = append(whiteouts[filepath.Dir(path)], path)
+	}
+
+	cw := archive.NewChangeWriter(writer, s.root)
+	err := filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		path, err = filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		path = filepath.Join("/", path)
+
+		if path != "/" {
+			if err := cw.HandleChange(fs.ChangeKindAdd, path, info, nil); err != nil {
+				return err
+			}
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if s.opaques[path] {
+			if err := cw.HandleChange(fs.ChangeKindDelete, filepath.Join(path, whiteoutPrefix+".opq"), nil, nil); err != nil {
+				return err
+			}
+		}
+		sort.Strings(whiteouts[path])
+		for _, whiteout := range whiteouts[path] {
+			if err := cw.HandleChange(fs.ChangeKindDelete, whiteout, nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cw.Close()
+		return errors.Wrap(err, "write squashed layer")
+	}
+	return cw.Close()
+}
+
+// splitCommittedLayers splits the blob layers committed by nydusify from the
+// layers of image, the committed blobs are recorded in the annotation of
+// bootstrap layer.
+func splitCommittedLayers(image *parserPkg.Image) ([]ocispec.Descriptor, []ocispec.Descriptor) {
+	committed := map[string]bool{}
+	if bootstrapDesc := parserPkg.FindNydusBootstrapDesc(&image.Manifest); bootstrapDesc != nil {
+		if commitBlobs := bootstrapDesc.Annotations[utils.LayerAnnotationNydusCommitBlobs]; commitBlobs != "" {
+			for _, blob := range strings.Split(commitBlobs, ",") {
+				committed[blob] = true
+			}
+		}
+	}
+
+	lowers := []ocispec.Descriptor{}
+	squashed := []ocispec.Descriptor{}
+	for _, layer := range image.Manifest.Layers {
+		if layer.MediaType == utils.MediaTypeNydusBlob && committed[layer.Digest.String()] {
+			squashed = append(squashed, layer)
+		} else {
+			lowers = append(lowers, layer)
+		}
+	}
+	return lowers, squashed
+}
+
+func unpackEntry(ra content.ReaderAt, entry, target string) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = converter.UnpackEntry(ra, entry, file)
+	return err
+}
+
+// applyBlob unpacks the Nydus blob into an OCI layer tar and applies it.
+func (cm *Committer) applyBlob(s *squasher, ra content.ReaderAt, dir string) error {
+	bootstrapPath := filepath.Join(dir, "image.boot")
+	blobPath := filepath.Join(dir, "image.blob")
+	tarPath := filepath.Join(dir, "layer.tar")
+	defer func() {
+		os.Remove(bootstrapPath)
+		os.Remove(blobPath)
+		os.Remove(tarPath)
+	}()
+
+	if err := unpackEntry(ra, converter.EntryBootstrap, bootstrapPath); err != nil {
+		return errors.Wrap(err, "unpack layer bootstrap")
+	}
+	if err := unpackEntry(ra, converter.EntryBlob, blobPath); err != nil {
+		return errors.Wrap(err, "unpack layer blob")
+	}
+	if err := build.NewBuilder(cm.builder).Unpack(build.UnpackOption{
+		BootstrapPath: bootstrapPath,
+		BlobPath:      blobPath,
+		OutputPath:    tarPath,
+	}); err != nil {
+		return errors.Wrap(err, "unpack nydus layer to tar")
+	}
+
+	layerTar, err := os.Open(tarPath)
+	if err != nil {
+		return errors.Wrap(err, "open layer tar")
+	}
+	defer layerTar.Close()
+	return s.apply(layerTar)
+}
+
+// squash squashes the committed layers of image and the blobs committed this
+// time into one Nydus blob, the blobs are applied in order.
+func (cm *Committer) squash(
+	ctx context.Context, opt Opt, sourceRef string, committed []ocispec.Descriptor, blobNames []string, squashedName string,
+) (*digest.Digest, error) {
+	logrus.Infof("squashing %d committed layers", len(committed)+len(blobNames))
+	start := time.Now()
+
+	squashDir := filepath.Join(cm.workDir, "squash")
+	rootDir := filepath.Join(squashDir, "rootfs")
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create squash directory")
+	}
+	defer os.RemoveAll(squashDir)
+	s := newSquasher(rootDir)
+
+	if len(committed) > 0 {
+		remoter, err := provider.DefaultRemote(sourceRef, opt.SourceInsecure)
+		if err != nil {
+			return nil, errors.Wrap(err, "create remote")
+		}
+		for _, layer := range committed {
+			ra, err := remoter.ReaderAt(ctx, layer, true)
+			if err != nil && utils.RetryWithHTTP(err) {
+				remoter.MaybeWithHTTP(err)
+				ra, err = remoter.ReaderAt(ctx, layer, true)
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "open committed layer %s", layer.Digest)
+			}
+			err = cm.applyBlob(s, ra, squashDir)
+			ra.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "apply committed layer %s", layer.Digest)
+			}
+		}
+	}
+
+	for _, name := range blobNames {
+		ra, err := local.OpenReader(filepath.Join(cm.workDir, name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "open blob %s", name)
+		}
+		err = cm.applyBlob(s, ra, squashDir)
+		ra.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "apply blob %s", name)
+		}
+	}
+
+	blob, err := os.Create(filepath.Join(cm.workDir, squashedName))
+	if err != nil {
+		return nil, errors.Wrap(err, "create squashed blob file")
+	}
+	defer blob.Close()
+
+	digester := digest.SHA256.Digester()
+	counter := Counter{}
+	tarWc, err := converter.Pack(ctx, io.MultiWriter(blob, digester.Hash(), &counter), converter.PackOption{
+		WorkDir:     cm.workDir,
+		FsVersion:   opt.FsVersion,
+		Compressor:  opt.Compressor,
+		BuilderPath: cm.builder,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "initialize pack to blob")
+	}
+	if err := s.write(tarWc); err != nil {
+		tarWc.Close()
+		return nil, err
+	}
+	if err := tarWc.Close(); err != nil {
+		return nil, errors.Wrap(err, "pack to blob")
+	}
+
+	blobDigest := digester.Digest()
+	logrus.Infof("squashed layers, size: %s, elapsed: %s", humanize.Bytes(uint64(counter.Size())), time.Since(start))
+
+	return &blobDigest, nil
+}
+
+// squashBootstrap merges the bootstraps of the lower blob layers and the
+// squashed blob, the committed layers squashed are not referred anymore.
+func (cm *Committer) squashBootstrap(
+	ctx context.Context, sourceRef string, insecure bool, lowers []ocispec.Descriptor, squashed Blob, mergedBootstrapName string,
+) (*digest.Digest, error) {
+	remoter, err := provider.DefaultRemote(sourceRef, insecure)
+	if err != nil {
+		return nil, errors.Wrap(err, "create remote")
+	}
+
+	layers := []converter.Layer{}
+	for _, layer := range lowers {
+		if layer.MediaType != utils.MediaTypeNydusBlob {
+			continue
+		}
+		ra, err := remoter.ReaderAt(ctx, layer, true)
+		if err != nil && utils.RetryWithHTTP(err) {
+			remoter.MaybeWithHTTP(err)
+			ra, err = remoter.ReaderAt(ctx, layer, true)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "open lower layer %s", layer.Digest)
+		}
+		defer ra.Close()
+		layers = append(layers, converter.Layer{
+			Digest:   layer.Digest,
+			ReaderAt: ra,
+		})
+	}
+
+	squashedRa, err := local.OpenReader(filepath.Join(cm.workDir, squashed.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "open reader for squashed blob")
+	}
+	defer squashedRa.Close()
+	layers = append(layers, converter.Layer{
+		Digest:   squashed.Desc.Digest,
+		ReaderAt: squashedRa,
+	})
+
+	bootstrap, err := os.Create(filepath.Join(cm.workDir, mergedBootstrapName))
+	if err != nil {
+		return nil, errors.Wrap(err, "create merged bootstrap file")
+	}
+	defer bootstrap.Close()
+
+	digester := digest.SHA256.Digester()
+	if _, err := converter.Merge(ctx, layers, io.MultiWriter(bootstrap, digester.Hash()), converter.MergeOption{
+		WorkDir:     cm.workDir,
+		WithTar:     true,
+		BuilderPath: cm.builder,
+	}); err != nil {
+		return nil, errors.Wrap(err, "merge bootstraps")
+	}
+	bootstrapDiffID := digester.Digest()
+
+	return &bootstrapDiffID, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package committer
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	name    string
+	content string
+}
+
+func makeLayer(t *testing.T, entries ...testEntry) io.Reader {
+	buf := bytes.Buffer{}
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		hdr := &tar.Header{
+			Name: entry.name,
+			Mode: 0644,
+			Uid:  os.Getuid(),
+			Gid:  os.Getgid(),
+			Size: int64(len(entry.content)),
+		}
+		if entry.name[len(entry.name)-1] == '/' {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		} else {
+			hdr.Typeflag = tar.TypeReg
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return &buf
+}
+
+func TestSquasher(t *testing.T) {
+	s := newSquasher(t.TempDir())
+
+	require.NoError(t, s.apply(makeLayer(t,
+		testEntry{name: "etc/"},
+		testEntry{name: "etc/a", content: "1"},
+		testEntry{name: "etc/b", content: "2"},
+		testEntry{name: "var/"},
+		testEntry{name: "var/log/"},
+		testEntry{name: "var/log/x", content: "x"},
+	)))
+	require.NoError(t, s.apply(makeLayer(t,
+		testEntry{name: "etc/"},
+		testEntry{name: "etc/.wh.a"},
+		testEntry{name: "etc/.wh.d"},
+		testEntry{name: "etc/c", content: "c"},
+		testEntry{name: "var/"},
+		testEntry{name: "var/.wh..wh..opq"},
+		testEntry{name: "var/new", content: "new"},
+	)))
+	require.NoError(t, s.apply(makeLayer(t,
+		testEntry{name: "etc/"},
+		testEntry{name: "etc/a", content: "3"},
+	)))
+
+	buf := bytes.Buffer{}
+	require.NoError(t, s.write(&buf))
+
+	files := map[string]string{}
+	names := []string{}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+		files[hdr.Name] = string(content)
+	}
+
+	require.Equal(t, []string{
+		"etc/", "etc/.wh.d", "etc/a", "etc/b", "etc/c", "var/", "var/.wh..wh..opq", "var/new",
+	}, names)
+	require.Equal(t, "3", files["etc/a"])
+	require.Equal(t, "2", files["etc/b"])
+	require.Equal(t, "new", files["var/new"])
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package config loads the configuration file of nydusify, which provides
+// the defaults of command line flags, so that the long commands in CI
+// scripts shrink to a few flags. The flags specified on command line or by
+// environment variables take precedence over the configuration file.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration file like:
+//
+//	work-dir: /var/lib/nydusify
+//	nydus-image: /usr/local/bin/nydus-image
+//	commands:
+//	  convert:
+//	    fs-version: 6
+//	    source-mirror: [mirror1.example.com, mirror2.example.com]
+//	    backend-type: s3
+//	    backend-config:
+//	      bucket_name: nydus
+//	      region: us-east-1
+//	  cache prune:
+//	    max-size: 100GiB
+type Config struct {
+	// Flags are the defaults of the flags of all commands, applied to the
+	// commands having the flag.
+	Flags map[string]interface{} `yaml:",inline"`
+	// Commands are the defaults of the flags of commands keyed by command
+	// path like "convert" or "cache prune", which override Flags.
+	Commands map[string]map[string]interface{} `yaml:"commands"`
+}
+
+// DefaultPath returns the path of configuration file used if not specified,
+// which is ~/.nydusify/config.yaml.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".nydusify", "config.yaml")
+}
+
+// Load loads the configuration file at path. An empty configuration is
+// returned if the file doesn't exist and optional is true, for the file at
+// default path.
+func Load(path string, optional bool) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if optional && os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, errors.Wrap(err, "read config file")
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "parse config file %s", path)
+	}
+	for name, value := range cfg.Flags {
+		if _, err := Strings(value); err != nil {
+			return nil, errors.Wrapf(err, "invalid value of %s in config file %s", name, path)
+		}
+	}
+	for command, flags := range cfg.Commands {
+		for name, value := range flags {
+			if _, err := Strings(value); err != nil {
+				return nil, errors.Wrapf(err, "invalid value of %s of command %s in config file %s", name, command, path)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// Defaults returns the defaults of the flags of command, the flags of all
+// commands are included.
+func (cfg *Config) Defaults(command string) map[string]interface{} {
+	defaults := map[string]interface{}{}
+	for name, value := range cfg.Flags {
+		defaults[name] = value
+	}
+	for name, value := range cfg.Commands[command] {
+		defaults[name] = value
+	}
+	return defaults
+}
+
+// Strings returns the value of flag in the configuration file as strings,
+// a list for the flags specified multiple times, or a scalar otherwise. A
+// map is encoded in JSON for the flags of JSON configuration, like the
+// configuration of storage backend.
+func Strings(value interface{}) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return []string{}, nil
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, errors.New("list of list or map is not supported")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "encode map in JSON")
+		}
+		return []string{string(data)}, nil
+	}
+	return []string{fmt.Sprint(value)}, nil
+}
+
+// CommandPath returns the key of command in the configuration file from
+// the names of command and its parents.
+func CommandPath(names ...string) string {
+	return strings.Join(names, " ")
+}
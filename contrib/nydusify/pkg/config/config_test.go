@@ -0,0 +1,62 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+work-dir: /var/lib/nydusify
+source-insecure: true
+commands:
+  convert:
+    work-dir: /data/nydusify
+    fs-version: 6
+    platform: [linux/amd64, linux/arm64]
+    backend-config:
+      bucket_name: nydus
+  cache prune:
+    max-size: 100GiB
+`), 0644))
+
+	cfg, err := Load(path, false)
+	require.NoError(t, err)
+
+	defaults := cfg.Defaults("convert")
+	require.Equal(t, "/data/nydusify", defaults["work-dir"])
+	require.Equal(t, true, defaults["source-insecure"])
+	values, err := Strings(defaults["fs-version"])
+	require.NoError(t, err)
+	require.Equal(t, []string{"6"}, values)
+	values, err = Strings(defaults["platform"])
+	require.NoError(t, err)
+	require.Equal(t, []string{"linux/amd64", "linux/arm64"}, values)
+	values, err = Strings(defaults["backend-config"])
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"bucket_name":"nydus"}`}, values)
+
+	defaults = cfg.Defaults(CommandPath("cache", "prune"))
+	require.Equal(t, "/var/lib/nydusify", defaults["work-dir"])
+	require.Equal(t, "100GiB", defaults["max-size"])
+	require.NotContains(t, defaults, "fs-version")
+
+	// The default config file is optional.
+	cfg, err = Load(filepath.Join(t.TempDir(), "not-exist.yaml"), true)
+	require.NoError(t, err)
+	require.Empty(t, cfg.Defaults("convert"))
+	_, err = Load(filepath.Join(t.TempDir(), "not-exist.yaml"), false)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("platform: [[linux/amd64]]\n"), 0644))
+	_, err = Load(path, false)
+	require.ErrorContains(t, err, "invalid value of platform")
+}
@@ -0,0 +1,271 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
+)
+
+// DefaultBatchWorkers is the number of images converted concurrently
+// in batch mode when no worker count is specified.
+const DefaultBatchWorkers = 4
+
+// BatchImage is a source/target image pair in a batch manifest.
+type BatchImage struct {
+	Source string `json:"source" yaml:"source"`
+	Target string `json:"target" yaml:"target"`
+}
+
+// BatchManifest lists the images to be converted in batch mode, it can
+// be written in either YAML or JSON, for example:
+//
+//	images:
+//	  - source: localhost:5000/library/busybox:latest
+//	    target: localhost:5000/library/busybox:latest-nydus
+//	  - source: localhost:5000/library/nginx:latest
+//	    target: localhost:5000/library/nginx:latest-nydus
+type BatchManifest struct {
+	Images []BatchImage `json:"images" yaml:"images"`
+}
+
+type BatchOpt struct {
+	Opt
+
+	Images []BatchImage
+	// Workers is the maximum number of images converted concurrently.
+	Workers uint
+}
+
+// BatchResult is the conversion result of a single image in batch mode.
+type BatchResult struct {
+	Source   string  `json:"source"`
+	Target   string  `json:"target"`
+	Success  bool    `json:"success"`
+	Error    string  `json:"error,omitempty"`
+	Duration string  `json:"duration"`
+	Metric   *Report `json:"metric,omitempty"`
+}
+
+// BatchReport is the consolidated report of a batch conversion.
+type BatchReport struct {
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Duration  string        `json:"duration"`
+	Results   []BatchResult `json:"results"`
+}
+
+// ParseBatchManifest reads the batch manifest from the file, since JSON
+// is a subset of YAML, both formats are accepted. The target of image may
+// be left empty to be generated by the caller, but a target can't be
+// specified for more than one image.
+func ParseBatchManifest(path string) (*BatchManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read batch manifest")
+	}
+
+	var manifest BatchManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "unmarshal batch manifest")
+	}
+
+	if len(manifest.Images) == 0 {
+		return nil, fmt.Errorf("no image found in batch manifest %s", path)
+	}
+	targets := map[string]int{}
+	for idx, image := range manifest.Images {
+		if image.Source == "" {
+			return nil, fmt.Errorf("source is required for image #%d in batch manifest", idx)
+		}
+		if image.Target == "" {
+			continue
+		}
+		if prev, ok := targets[image.Target]; ok {
+			return nil, fmt.Errorf("target %s of image #%d is duplicated with image #%d in batch manifest", image.Target, idx, prev)
+		}
+		targets[image.Target] = idx
+	}
+
+	return &manifest, nil
+}
+
+// workerCacheRef returns the build cache image of the batch worker, which
+// is tagged by the tag of cache image and the worker index. The first
+// worker uses the cache image as is.
+func workerCacheRef(ref string, worker int) (string, error) {
+	if worker == 0 {
+		return ref, nil
+	}
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse build cache reference %s", ref)
+	}
+	tagged, ok := named.(reference.NamedTagged)
+	if !ok {
+		return "", fmt.Errorf("build cache reference %s should be tagged", ref)
+	}
+	workerRef, err := reference.WithTag(reference.TrimNamed(named), fmt.Sprintf("%s-worker%d", tagged.Tag(), worker))
+	if err != nil {
+		return "", errors.Wrapf(err, "tag build cache reference %s", ref)
+	}
+	return reference.FamiliarString(workerRef), nil
+}
+
+// BatchConvert converts all the images in opt.Images with at most
+// opt.Workers conversions running at the same time. All conversions
+// share the same work directory and content-addressed build cache, a
+// failed conversion doesn't stop the others, the returned report records
+// the result of every image in the order of the manifest.
+//
+// The build cache image is read at the beginning of conversion and updated
+// at the end, so the updates of concurrent conversions would overwrite each
+// other. Every worker uses its own cache image instead, see workerCacheRef.
+func BatchConvert(ctx context.Context, opt BatchOpt) (*BatchReport, error) {
+	targets := map[string]int{}
+	for idx, image := range opt.Images {
+		if image.Source == "" || image.Target == "" {
+			return nil, fmt.Errorf("both source and target are required for image #%d", idx)
+		}
+		if prev, ok := targets[image.Target]; ok {
+			return nil, fmt.Errorf("target %s of image #%d is duplicated with image #%d", image.Target, idx, prev)
+		}
+		targets[image.Target] = idx
+	}
+
+	workers := int(opt.Workers)
+	if workers == 0 {
+		workers = DefaultBatchWorkers
+	}
+	// The content-addressed build cache is updated per entry, which is
+	// shared by the workers.
+	cacheRefs := make([]string, workers)
+	for worker := range cacheRefs {
+		cacheRefs[worker] = opt.CacheRef
+		if opt.CacheRef != "" && !cache.IsContentAddressed(opt.CacheRef, opt.CacheMode) {
+			ref, err := workerCacheRef(opt.CacheRef, worker)
+			if err != nil {
+				return nil, err
+			}
+			cacheRefs[worker] = ref
+		}
+	}
+
+	// The work directory must be prepared before any conversion starts,
+	// otherwise the first finished conversion would remove the directory
+	// still used by the others.
+	if _, err := os.Stat(opt.WorkDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
+				return nil, errors.Wrap(err, "prepare work directory")
+			}
+			defer os.RemoveAll(opt.WorkDir)
+		} else {
+			return nil, errors.Wrap(err, "stat work directory")
+		}
+	}
+
+	stopProgress, err := startProgress(&opt.Opt)
+	if err != nil {
+		return nil, err
+	}
+	defer stopProgress()
+
+	start := time.Now()
+	report := BatchReport{
+		Total:   len(opt.Images),
+		Results: make([]BatchResult, len(opt.Images)),
+	}
+
+	var mutex sync.Mutex
+	// idle is the indexes of the workers not converting an image.
+	idle := make(chan int, workers)
+	for worker := 0; worker < workers; worker++ {
+		idle <- worker
+	}
+	eg := errgroup.Group{}
+	eg.SetLimit(workers)
+	for idx, image := range opt.Images {
+		eg.Go(func() error {
+			worker := <-idle
+			defer func() { idle <- worker }()
+
+			imageOpt := opt.Opt
+			imageOpt.Source = image.Source
+			imageOpt.Target = image.Target
+			imageOpt.CacheRef = cacheRefs[worker]
+
+			logrus.Infof("converting image %s to %s", image.Source, image.Target)
+			imageStart := time.Now()
+			metric, err := convertImage(ctx, imageOpt)
+
+			result := BatchResult{
+				Source:   image.Source,
+				Target:   image.Target,
+				Success:  err == nil,
+				Duration: time.Since(imageStart).String(),
+				Metric:   metric,
+			}
+			if err != nil {
+				result.Error = err.Error()
+				logrus.WithError(err).Errorf("failed to convert image %s", image.Source)
+			} else {
+				logrus.Infof("converted image %s to %s", image.Source, image.Target)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Results[idx] = result
+			if err != nil {
+				report.Failed++
+			} else {
+				report.Succeeded++
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	report.Duration = time.Since(start).String()
+
+	if opt.OutputJSON != "" {
+		if err := dumpBatchReport(&report, opt.OutputJSON); err != nil {
+			return &report, err
+		}
+	}
+
+	if report.Failed > 0 {
+		return &report, fmt.Errorf("failed to convert %d of %d images", report.Failed, report.Total)
+	}
+
+	return &report, nil
+}
+
+func dumpBatchReport(report *BatchReport, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "create file for batch report")
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return errors.Wrap(err, "encode batch report")
+	}
+	return nil
+}
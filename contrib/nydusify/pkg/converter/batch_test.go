@@ -0,0 +1,140 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "batch.yaml")
+	err := os.WriteFile(yamlPath, []byte(`
+images:
+  - source: localhost:5000/busybox:latest
+    target: localhost:5000/busybox:latest-nydus
+  - source: localhost:5000/nginx:latest
+`), 0644)
+	require.NoError(t, err)
+	manifest, err := ParseBatchManifest(yamlPath)
+	require.NoError(t, err)
+	require.Equal(t, []BatchImage{
+		{Source: "localhost:5000/busybox:latest", Target: "localhost:5000/busybox:latest-nydus"},
+		{Source: "localhost:5000/nginx:latest"},
+	}, manifest.Images)
+
+	jsonPath := filepath.Join(dir, "batch.json")
+	err = os.WriteFile(jsonPath, []byte(`{"images": [{"source": "localhost:5000/busybox:latest", "target": "localhost:5000/busybox:nydus"}]}`), 0644)
+	require.NoError(t, err)
+	manifest, err = ParseBatchManifest(jsonPath)
+	require.NoError(t, err)
+	require.Equal(t, []BatchImage{
+		{Source: "localhost:5000/busybox:latest", Target: "localhost:5000/busybox:nydus"},
+	}, manifest.Images)
+
+	// Failure situation
+	_, err = ParseBatchManifest(filepath.Join(dir, "not-exist.yaml"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "read batch manifest")
+
+	emptyPath := filepath.Join(dir, "empty.yaml")
+	require.NoError(t, os.WriteFile(emptyPath, []byte("images: []"), 0644))
+	_, err = ParseBatchManifest(emptyPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no image found")
+
+	noSourcePath := filepath.Join(dir, "no-source.yaml")
+	require.NoError(t, os.WriteFile(noSourcePath, []byte("images:\n  - target: localhost:5000/busybox:nydus\n"), 0644))
+	_, err = ParseBatchManifest(noSourcePath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "source is required for image #0")
+
+	duplicatedPath := filepath.Join(dir, "duplicated.yaml")
+	require.NoError(t, os.WriteFile(duplicatedPath, []byte(`
+images:
+  - source: localhost:5000/busybox:latest
+    target: localhost:5000/busybox:nydus
+  - source: localhost:5000/busybox:1.36
+  - source: localhost:5000/busybox:1.37
+    target: localhost:5000/busybox:nydus
+`), 0644))
+	_, err = ParseBatchManifest(duplicatedPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "target localhost:5000/busybox:nydus of image #2 is duplicated with image #0")
+}
+
+func TestWorkerCacheRef(t *testing.T) {
+	ref, err := workerCacheRef("localhost:5000/cache:nydus", 0)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:5000/cache:nydus", ref)
+
+	ref, err = workerCacheRef("localhost:5000/cache:nydus", 2)
+	require.NoError(t, err)
+	require.Equal(t, "localhost:5000/cache:nydus-worker2", ref)
+
+	ref, err = workerCacheRef("myregistry/cache", 1)
+	require.NoError(t, err)
+	require.Equal(t, "myregistry/cache:latest-worker1", ref)
+
+	_, err = workerCacheRef("localhost:5000/cache@sha256:"+strings.Repeat("0", 64), 1)
+	require.Error(t, err)
+}
+
+func TestBatchConvert(t *testing.T) {
+	_, err := BatchConvert(context.Background(), BatchOpt{
+		Opt:    Opt{WorkDir: t.TempDir()},
+		Images: []BatchImage{{Source: "localhost:5000/busybox:latest"}},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "both source and target are required")
+
+	_, err = BatchConvert(context.Background(), BatchOpt{
+		Opt: Opt{WorkDir: t.TempDir()},
+		Images: []BatchImage{
+			{Source: "localhost:5000/busybox:1.36", Target: "localhost:5000/busybox:nydus"},
+			{Source: "localhost:5000/busybox:1.37", Target: "localhost:5000/busybox:nydus"},
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "target localhost:5000/busybox:nydus of image #1 is duplicated with image #0")
+
+	// Every image fails on the invalid platform, but all of them
+	// should still be recorded in the report.
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	report, err := BatchConvert(context.Background(), BatchOpt{
+		Opt: Opt{
+			WorkDir:    filepath.Join(t.TempDir(), "work"),
+			Platforms:  "invalid/platform/x/y",
+			OutputJSON: reportPath,
+		},
+		Images: []BatchImage{
+			{Source: "localhost:5000/busybox:latest", Target: "localhost:5000/busybox:nydus"},
+			{Source: "localhost:5000/nginx:latest", Target: "localhost:5000/nginx:nydus"},
+		},
+		Workers: 1,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to convert 2 of 2 images")
+	require.Equal(t, 2, report.Total)
+	require.Equal(t, 2, report.Failed)
+	require.Equal(t, "localhost:5000/nginx:latest", report.Results[1].Source)
+	require.False(t, report.Results[1].Success)
+	require.NotEmpty(t, report.Results[1].Error)
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var dumped BatchReport
+	require.NoError(t, json.Unmarshal(data, &dumped))
+	require.Equal(t, 2, dumped.Failed)
+	require.Len(t, dumped.Results, 2)
+}
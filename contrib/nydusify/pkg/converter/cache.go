@@ -0,0 +1,397 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"os"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	pkgPvd "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
+)
+
+// cacheParamKeys are the build parameters affecting the converted layer,
+// the others (e.g. work directory and backend) only affect where and how
+// the layer is built or stored.
+var cacheParamKeys = []string{
+	"compressor",
+	"fs_version",
+	"fs_chunk_size",
+	"fs_align_chunk",
+	"batch_size",
+	"chunk_dict_ref",
+	"backend_type",
+	"oci_ref",
+	"prefetch_patterns",
+	"cache_version",
+}
+
+// cacheParams returns the build parameters in the key of content-addressed
+// cache entry.
+func cacheParams(opt Opt) map[string]string {
+	cfg := getConfig(opt)
+	params := make(map[string]string, len(cacheParamKeys))
+	for _, key := range cacheParamKeys {
+		params[key] = cfg[key]
+	}
+	// The policy is only in the key if specified, so that the existing
+	// entries are still hit.
+	if opt.CompressorPolicy != nil {
+		params["compressor_policy"] = opt.CompressorPolicy.String()
+	}
+	return params
+}
+
+func newCacheStore(ref string, insecure bool, backendConfig string, pvd *provider.Provider) (cache.Store, error) {
+	return cache.NewStore(ref, cache.StoreOpt{
+		NewRemote: func(ref string) (*remote.Remote, error) {
+			return pkgPvd.DefaultRemote(ref, insecure)
+		},
+		Registry:      pvd,
+		BackendConfig: backendConfig,
+	})
+}
+
+// convertedLayers pairs the source layers with the nydus blobs converted
+// from them in target manifest, the blobs of chunk dict are skipped.
+func convertedLayers(source, target ocispec.Manifest, dictBlobs map[digest.Digest]bool) map[digest.Digest]ocispec.Descriptor {
+	var blobs []ocispec.Descriptor
+	for _, layer := range target.Layers {
+		if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" || dictBlobs[layer.Digest] {
+			continue
+		}
+		blobs = append(blobs, layer)
+	}
+	// Same as imageSize, no blob is generated for an empty layer, so they
+	// are paired only if the numbers are the same.
+	if len(blobs) != len(source.Layers) {
+		return nil
+	}
+	layers := make(map[digest.Digest]ocispec.Descriptor, len(blobs))
+	for idx, layer := range source.Layers {
+		layers[layer.Digest] = blobs[idx]
+	}
+	return layers
+}
+
+// buildCacheManifest returns the build cache manifest recording the nydus
+// blobs converted from the source layers, which is consumed by the build
+// cache of converter to skip the conversion of the source layers.
+func buildCacheManifest(sourceLayers []ocispec.Descriptor, entries map[digest.Digest]*cache.Entry, fsVersion, cacheVersion string) ocispec.Manifest {
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{},
+		Annotations: map[string]string{
+			utils.ManifestNydusCache:            cacheVersion,
+			utils.LayerAnnotationNydusFsVersion: fsVersion,
+		},
+	}
+	for _, sourceLayer := range sourceLayers {
+		entry, ok := entries[sourceLayer.Digest]
+		if !ok {
+			continue
+		}
+		appendCacheLayer(&manifest, sourceLayer, entry.Layer)
+	}
+	return manifest
+}
+
+// appendCacheLayer records the nydus blob converted from the source layer
+// in build cache manifest.
+func appendCacheLayer(manifest *ocispec.Manifest, sourceLayer, blob ocispec.Descriptor) {
+	blob.Annotations = copyAnnotations(blob.Annotations)
+	blob.Annotations[utils.LayerAnnotationNydusBlob] = "true"
+	blob.Annotations[utils.LayerAnnotationNydusSourceDigest] = sourceLayer.Digest.String()
+	sourceLayer.Annotations = copyAnnotations(sourceLayer.Annotations)
+	sourceLayer.Annotations[utils.LayerAnnotationNydusTargetDigest] = blob.Digest.String()
+	manifest.Layers = append(manifest.Layers, sourceLayer, blob)
+}
+
+func copyAnnotations(annotations map[string]string) map[string]string {
+	copied := make(map[string]string, len(annotations)+2)
+	for key, value := range annotations {
+		copied[key] = value
+	}
+	return copied
+}
+
+// seedBuildCache looks up the source layers in the content-addressed store,
+// downloads the nydus blobs found into content store, and returns the ref of
+// the local build cache image recording them.
+func seedBuildCache(ctx context.Context, pvd *provider.Provider, store cache.Store, source string, opt Opt) (string, error) {
+	if err := pvd.Pull(ctx, source); err != nil {
+		return "", errors.Wrap(err, "pull source image")
+	}
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return "", errors.Wrap(err, "get source image")
+	}
+	manifests, err := platformManifests(ctx, pvd.ContentStore(), *sourceDesc)
+	if err != nil {
+		return "", errors.Wrap(err, "read source manifests")
+	}
+
+	params := cacheParams(opt)
+	entries := map[digest.Digest]*cache.Entry{}
+	var sourceLayers []ocispec.Descriptor
+	for _, manifest := range manifests {
+		for _, layer := range manifest.manifest.Layers {
+			if _, ok := entries[layer.Digest]; ok {
+				continue
+			}
+			entry := lookupBuildCache(ctx, pvd.ContentStore(), store, layer.Digest, params)
+			if entry == nil {
+				continue
+			}
+			entries[layer.Digest] = entry
+			sourceLayers = append(sourceLayers, layer)
+		}
+	}
+	logrus.Infof("found %d converted layers in build cache %s", len(entries), opt.CacheRef)
+
+	manifest := buildCacheManifest(sourceLayers, entries, opt.FsVersion, opt.CacheVersion)
+	return writeBuildCacheImage(ctx, pvd, manifest)
+}
+
+// writeBuildCacheImage writes the build cache manifest into content store,
+// and returns the ref of the local build cache image.
+func writeBuildCacheImage(ctx context.Context, pvd *provider.Provider, manifest ocispec.Manifest) (string, error) {
+	manifestDesc, manifestData, err := utils.MarshalToDesc(manifest, ocispec.MediaTypeImageManifest)
+	if err != nil {
+		return "", err
+	}
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{ocispec.DescriptorEmptyJSON, ocispec.DescriptorEmptyJSON.Data},
+		{*manifestDesc, manifestData},
+	} {
+		if err := content.WriteBlob(ctx, pvd.ContentStore(), blob.desc.Digest.String(), bytes.NewReader(blob.data), blob.desc); err != nil {
+			return "", errors.Wrapf(err, "write blob %s", blob.desc.Digest)
+		}
+	}
+
+	return pvd.AddLocalImage(*manifestDesc), nil
+}
+
+func fetchCachedLayer(ctx context.Context, cs content.Store, store cache.Store, entry *cache.Entry) error {
+	if _, err := cs.Info(ctx, entry.Layer.Digest); err == nil {
+		return nil
+	}
+	reader, err := store.Open(ctx, entry)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return content.WriteBlob(ctx, cs, entry.Layer.Digest.String(), reader, entry.Layer)
+}
+
+// harvestBuildCache stores the nydus blobs converted in this conversion to
+// the content-addressed store, so that they can be reused by the other
+// images having the same source layers.
+// lookupBuildCache fetches the converted layer of source layer from build
+// cache into content store, nil is returned if it's not in build cache or
+// failed to fetch.
+func lookupBuildCache(ctx context.Context, cs content.Store, store cache.Store, layer digest.Digest, params map[string]string) *cache.Entry {
+	ctx, span := tracing.Start(ctx, "cache.lookup", attribute.String("layer", layer.String()))
+	defer span.End()
+
+	entry, err := store.Get(ctx, cache.Key(layer, params))
+	if err != nil {
+		if !errors.Is(err, cache.ErrNotFound) {
+			span.RecordError(err)
+			logrus.WithError(err).Warnf("failed to get build cache of layer %s", layer)
+		}
+		span.SetAttributes(attribute.Bool("hit", false))
+		return nil
+	}
+	if err := fetchCachedLayer(ctx, cs, store, entry); err != nil {
+		span.RecordError(err)
+		span.SetAttributes(attribute.Bool("hit", false))
+		logrus.WithError(err).Warnf("failed to fetch build cache of layer %s", layer)
+		return nil
+	}
+	if err := store.Touch(ctx, entry); err != nil {
+		logrus.WithError(err).Warnf("failed to update usage of build cache of layer %s", layer)
+	}
+	span.SetAttributes(attribute.Bool("hit", true), attribute.Int64("size", entry.Layer.Size))
+	return entry
+}
+
+func harvestBuildCache(ctx context.Context, pvd *provider.Provider, store cache.Store, source, target string, opt Opt) error {
+	cs := pvd.ContentStore()
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "get source image")
+	}
+	targetDesc, err := pvd.Image(ctx, target)
+	if err != nil {
+		return errors.Wrap(err, "get target image")
+	}
+	dictBlobs, err := chunkDictBlobs(ctx, pvd, opt.ChunkDictRef)
+	if err != nil {
+		return errors.Wrap(err, "get chunk dict blobs")
+	}
+	sourceManifests, err := platformManifests(ctx, cs, *sourceDesc)
+	if err != nil {
+		return errors.Wrap(err, "read source manifests")
+	}
+	targetManifests, err := platformManifests(ctx, cs, *targetDesc)
+	if err != nil {
+		return errors.Wrap(err, "read target manifests")
+	}
+
+	params := cacheParams(opt)
+	stored := map[digest.Digest]bool{}
+	for _, targetManifest := range targetManifests {
+		if !isNydusManifest(targetManifest.manifest) {
+			continue
+		}
+		sourceManifest := matchManifest(sourceManifests, targetManifest)
+		if sourceManifest == nil {
+			continue
+		}
+		for sourceDigest, blob := range convertedLayers(sourceManifest.manifest, targetManifest.manifest, dictBlobs) {
+			if stored[sourceDigest] {
+				continue
+			}
+			stored[sourceDigest] = true
+			entry := cache.NewEntry(sourceDigest, params, blob)
+			if _, err := store.Get(ctx, entry.Key); err == nil {
+				continue
+			} else if !errors.Is(err, cache.ErrNotFound) {
+				return errors.Wrapf(err, "get build cache of layer %s", sourceDigest)
+			}
+			if err := putCachedLayer(ctx, cs, store, entry); err != nil {
+				return errors.Wrapf(err, "put build cache of layer %s", sourceDigest)
+			}
+			logrus.Infof("stored nydus blob %s of layer %s to build cache", blob.Digest, sourceDigest)
+		}
+	}
+
+	return nil
+}
+
+func putCachedLayer(ctx context.Context, cs content.Store, store cache.Store, entry *cache.Entry) error {
+	ra, err := cs.ReaderAt(ctx, entry.Layer)
+	if err != nil {
+		return errors.Wrapf(err, "nydus blob %s not found in content store", entry.Layer.Digest)
+	}
+	defer ra.Close()
+	return store.Put(ctx, entry, content.NewReader(ra))
+}
+
+// pruneBuildCache evicts the entries of content-addressed store exceeding
+// the budget of build cache.
+func pruneBuildCache(ctx context.Context, store cache.Store, opt Opt) error {
+	evicted, err := cache.Prune(ctx, store, cache.EvictOpt{
+		Policy:     opt.CachePolicy,
+		MaxSize:    opt.CacheMaxSize,
+		MaxRecords: opt.CacheMaxRecords,
+	})
+	if len(evicted) > 0 {
+		logrus.Infof("evicted %d entries from build cache %s", len(evicted), opt.CacheRef)
+	}
+	return err
+}
+
+// CacheOpt is the option to manage the content-addressed build cache.
+type CacheOpt struct {
+	WorkDir  string
+	Ref      string
+	Insecure bool
+	// BackendConfig is the storage backend configuration to access the
+	// build cache in object storage.
+	BackendConfig string
+
+	Policy     string
+	MaxSize    int64
+	MaxRecords uint
+}
+
+// openCache opens the content-addressed build cache, the returned function
+// cleans up the temp directory.
+func openCache(opt CacheOpt) (cache.Store, func(), error) {
+	if cache.IsDir(opt.Ref) || cache.IsObject(opt.Ref) {
+		store, err := newCacheStore(opt.Ref, opt.Insecure, opt.BackendConfig, nil)
+		return store, func() {}, err
+	}
+
+	if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
+		return nil, nil, errors.Wrap(err, "prepare work directory")
+	}
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "create temp directory")
+	}
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, nil, err
+	}
+	cleanup := func() {
+		unlock()
+		os.RemoveAll(tmpDir)
+	}
+	pvd, err := provider.New(tmpDir, hosts(Opt{CacheRef: opt.Ref, CacheInsecure: opt.Insecure}), 200, "v1", platforms.All, 0, nil)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	store, err := newCacheStore(opt.Ref, opt.Insecure, opt.BackendConfig, pvd)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return store, cleanup, nil
+}
+
+// CacheStat returns the usage of content-addressed build cache.
+func CacheStat(ctx context.Context, opt CacheOpt) (*cache.Stat, error) {
+	store, cleanup, err := openCache(opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	entries, err := store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list cache entries")
+	}
+	stat := cache.Stats(entries)
+	return &stat, nil
+}
+
+// PruneCache evicts the entries of content-addressed build cache by the
+// policy until it's within the budget, and returns the evicted entries.
+func PruneCache(ctx context.Context, opt CacheOpt) ([]*cache.Entry, error) {
+	store, cleanup, err := openCache(opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return cache.Prune(ctx, store, cache.EvictOpt{
+		Policy:     opt.Policy,
+		MaxSize:    opt.MaxSize,
+		MaxRecords: opt.MaxRecords,
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestCacheParams(t *testing.T) {
+	opt := Opt{WorkDir: "/tmp/a", CacheRef: "localhost:5000/cache:a", Compressor: "zstd", FsVersion: "6"}
+	params := cacheParams(opt)
+	require.Equal(t, "zstd", params["compressor"])
+	require.Equal(t, "6", params["fs_version"])
+	require.NotContains(t, params, "work_dir")
+	require.NotContains(t, params, "cache_ref")
+
+	// The work directory and cache image don't affect the converted layer.
+	opt.WorkDir = "/tmp/b"
+	opt.CacheRef = "localhost:5000/cache:b"
+	require.Equal(t, params, cacheParams(opt))
+
+	opt.ChunkSize = "0x100000"
+	require.NotEqual(t, params, cacheParams(opt))
+}
+
+func TestConvertedLayers(t *testing.T) {
+	layer := func(name string) ocispec.Descriptor {
+		return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString(name)}
+	}
+	blob := func(name string) ocispec.Descriptor {
+		return ocispec.Descriptor{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString(name)}
+	}
+	bootstrap := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Digest:      digest.FromString("bootstrap"),
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}
+
+	source := ocispec.Manifest{Layers: []ocispec.Descriptor{layer("layer-1"), layer("layer-2")}}
+	target := ocispec.Manifest{Layers: []ocispec.Descriptor{blob("dict"), blob("blob-1"), blob("blob-2"), bootstrap}}
+	layers := convertedLayers(source, target, map[digest.Digest]bool{digest.FromString("dict"): true})
+	require.Equal(t, map[digest.Digest]ocispec.Descriptor{
+		digest.FromString("layer-1"): blob("blob-1"),
+		digest.FromString("layer-2"): blob("blob-2"),
+	}, layers)
+
+	// The layers can't be paired if a layer is empty.
+	target.Layers = []ocispec.Descriptor{blob("blob-1"), bootstrap}
+	require.Nil(t, convertedLayers(source, target, nil))
+}
+
+func TestBuildCacheManifest(t *testing.T) {
+	source := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-1")}
+	missing := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-2")}
+	blob := ocispec.Descriptor{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString("blob-1"), Size: 10}
+	entries := map[digest.Digest]*cache.Entry{
+		source.Digest: cache.NewEntry(source.Digest, nil, blob),
+	}
+
+	manifest := buildCacheManifest([]ocispec.Descriptor{source, missing}, entries, "6", "v1")
+	require.Equal(t, "v1", manifest.Annotations[utils.ManifestNydusCache])
+	require.Equal(t, "6", manifest.Annotations[utils.LayerAnnotationNydusFsVersion])
+	require.Len(t, manifest.Layers, 2)
+	require.Equal(t, source.Digest, manifest.Layers[0].Digest)
+	require.Equal(t, blob.Digest.String(), manifest.Layers[0].Annotations[utils.LayerAnnotationNydusTargetDigest])
+	require.Equal(t, blob.Digest, manifest.Layers[1].Digest)
+	require.Equal(t, source.Digest.String(), manifest.Layers[1].Annotations[utils.LayerAnnotationNydusSourceDigest])
+	require.Equal(t, "true", manifest.Layers[1].Annotations[utils.LayerAnnotationNydusBlob])
+	// The entry isn't modified.
+	require.Nil(t, entries[source.Digest].Layer.Annotations)
+}
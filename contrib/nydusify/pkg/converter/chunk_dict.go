@@ -5,8 +5,26 @@
 package converter
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
 var (
@@ -59,3 +77,243 @@ type ChunkDictOpt struct {
 	Args     string
 	Insecure bool
 }
+
+// ChunkDict is a chunk dict in registry or local file system.
+type ChunkDict struct {
+	// Source is one of "registry" and "local".
+	Source string
+	// Ref is the image reference of registry chunk dict, or the bootstrap
+	// path of local chunk dict.
+	Ref string
+}
+
+// ParseChunkDicts parses the comma separated chunk dict args like:
+// - bootstrap:registry:$repo:$tag,bootstrap:local:$path
+// The former chunk dicts take precedence over the latter ones.
+func ParseChunkDicts(args string) ([]ChunkDict, error) {
+	var dicts []ChunkDict
+	for _, arg := range strings.Split(args, ",") {
+		_, source, ref, err := ParseChunkDictArgs(strings.TrimSpace(arg))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse chunk dict %s", arg)
+		}
+		dicts = append(dicts, ChunkDict{Source: source, Ref: ref})
+	}
+	return dicts, nil
+}
+
+// SplitChunkDicts returns the only registry chunk dict to use as is, or
+// the chunk dicts to merge during conversion.
+func SplitChunkDicts(dicts []ChunkDict) (string, []ChunkDict) {
+	if len(dicts) == 1 && dicts[0].Source == "registry" {
+		return dicts[0].Ref, nil
+	}
+	return "", dicts
+}
+
+// chunkDictPlatform returns the only platform to convert, the chunk dicts
+// are merged for it.
+func chunkDictPlatform(opt Opt) (ocispec.Platform, error) {
+	if opt.AllPlatforms || strings.Contains(opt.Platforms, ",") {
+		return ocispec.Platform{}, fmt.Errorf("multiple chunk dicts are only supported to convert one platform")
+	}
+	if opt.Platforms == "" {
+		return platforms.DefaultSpec(), nil
+	}
+	return platforms.Parse(opt.Platforms)
+}
+
+// mergeChunkDicts merges the bootstraps of opt.ChunkDicts into a chunk dict
+// image in the content store of provider, and returns its reference. The
+// bootstraps are overlaid in reverse order, so that the files in the former
+// chunk dicts override the ones in the latter.
+func mergeChunkDicts(ctx context.Context, pvd *provider.Provider, workDir string, opt Opt) (string, error) {
+	platform, err := chunkDictPlatform(opt)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return "", errors.Wrap(err, "create chunk dict directory")
+	}
+
+	var bootstrapPaths []string
+	var blobs []ocispec.Descriptor
+	seen := map[digest.Digest]bool{}
+	for idx, dict := range opt.ChunkDicts {
+		path := dict.Ref
+		if dict.Source == "registry" {
+			path = filepath.Join(workDir, fmt.Sprintf("chunk_dict_%d.boot", idx))
+			dictBlobs, err := fetchChunkDict(ctx, pvd, dict.Ref, platform, path)
+			if err != nil {
+				return "", errors.Wrapf(err, "fetch chunk dict %s", dict.Ref)
+			}
+			for _, blob := range dictBlobs {
+				if !seen[blob.Digest] {
+					seen[blob.Digest] = true
+					blobs = append(blobs, blob)
+				}
+			}
+		}
+		bootstrapPaths = append([]string{path}, bootstrapPaths...)
+	}
+
+	bootstrapPath := filepath.Join(workDir, "chunk_dict.boot")
+	if err := build.NewBuilder(opt.NydusImagePath).Merge(build.MergeOption{
+		SourceBootstrapPaths: bootstrapPaths,
+		TargetBootstrapPath:  bootstrapPath,
+	}); err != nil {
+		return "", errors.Wrap(err, "merge chunk dict bootstraps")
+	}
+
+	desc, err := writeChunkDictImage(ctx, pvd.ContentStore(), bootstrapPath, opt.FsVersion, platform, blobs)
+	if err != nil {
+		return "", errors.Wrap(err, "write chunk dict image")
+	}
+	return pvd.AddLocalImage(*desc), nil
+}
+
+// fetchChunkDict fetches the bootstrap of the nydus manifest of platform in
+// chunk dict image into path, and returns the nydus blobs of the manifest.
+// The blobs are not fetched.
+func fetchChunkDict(ctx context.Context, pvd *provider.Provider, ref string, platform ocispec.Platform, path string) ([]ocispec.Descriptor, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := pvd.Resolver(ref)
+	if err != nil {
+		return nil, err
+	}
+	name, desc, err := resolver.Resolve(ctx, named.String())
+	if err != nil {
+		return nil, err
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	descs := []ocispec.Descriptor{desc}
+	if desc.MediaType == ocispec.MediaTypeImageIndex || desc.MediaType == images.MediaTypeDockerSchema2ManifestList {
+		var index ocispec.Index
+		if err := fetchJSON(ctx, fetcher, desc, &index); err != nil {
+			return nil, err
+		}
+		matcher := platforms.Only(platform)
+		descs = nil
+		for _, manifest := range index.Manifests {
+			if manifest.Platform != nil && matcher.Match(*manifest.Platform) {
+				descs = append(descs, manifest)
+			}
+		}
+	}
+
+	// The OCI manifests kept by `--merge-platform` are skipped.
+	for _, desc := range descs {
+		var manifest ocispec.Manifest
+		if err := fetchJSON(ctx, fetcher, desc, &manifest); err != nil {
+			return nil, err
+		}
+		var bootstrap *ocispec.Descriptor
+		var blobs []ocispec.Descriptor
+		for idx, layer := range manifest.Layers {
+			if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" {
+				bootstrap = &manifest.Layers[idx]
+			} else {
+				blobs = append(blobs, layer)
+			}
+		}
+		if bootstrap == nil {
+			continue
+		}
+
+		rc, err := fetcher.Fetch(ctx, *bootstrap)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch bootstrap")
+		}
+		defer rc.Close()
+		if err := utils.UnpackFile(rc, utils.BootstrapFileNameInLayer, path); err != nil {
+			return nil, errors.Wrap(err, "unpack bootstrap")
+		}
+		return blobs, nil
+	}
+
+	return nil, fmt.Errorf("no nydus manifest of platform %s found", platforms.Format(platform))
+}
+
+// packBootstrapLayer packs the bootstrap into a gzip layer, and returns the
+// layer descriptor without annotations, its data and diff id.
+func packBootstrapLayer(bootstrapPath string) (*ocispec.Descriptor, []byte, digest.Digest, error) {
+	reader, err := utils.PackTargz(bootstrapPath, utils.BootstrapFileNameInLayer, true)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "pack bootstrap")
+	}
+	diffID, _, err := utils.PackTargzInfo(bootstrapPath, utils.BootstrapFileNameInLayer, false)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "get bootstrap diff id")
+	}
+	return &ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}, data, diffID, nil
+}
+
+// writeChunkDictImage writes the chunk dict image of the bootstrap and
+// blobs into content store, only the bootstrap layer is written, the blobs
+// are referenced by the manifest.
+func writeChunkDictImage(ctx context.Context, store content.Store, bootstrapPath, fsVersion string, platform ocispec.Platform, blobs []ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	bootstrap, bootstrapData, diffID, err := packBootstrapLayer(bootstrapPath)
+	if err != nil {
+		return nil, err
+	}
+	bootstrap.Annotations = map[string]string{
+		utils.LayerAnnotationNydusBootstrap: "true",
+		utils.LayerAnnotationNydusFsVersion: fsVersion,
+		utils.LayerAnnotationUncompressed:   diffID.String(),
+	}
+
+	config := ocispec.Image{
+		Platform: platform,
+		RootFS:   ocispec.RootFS{Type: "layers"},
+	}
+	for _, blob := range blobs {
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, blob.Digest)
+	}
+	config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, diffID)
+	configDesc, configData, err := utils.MarshalToDesc(config, ocispec.MediaTypeImageConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    *configDesc,
+		Layers:    append(append([]ocispec.Descriptor{}, blobs...), *bootstrap),
+	}
+	manifestDesc, manifestData, err := utils.MarshalToDesc(manifest, ocispec.MediaTypeImageManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{*bootstrap, bootstrapData},
+		{*configDesc, configData},
+		{*manifestDesc, manifestData},
+	} {
+		if err := content.WriteBlob(ctx, store, blob.desc.Digest.String(), bytes.NewReader(blob.data), blob.desc); err != nil {
+			return nil, errors.Wrapf(err, "write blob %s", blob.desc.Digest)
+		}
+	}
+
+	return manifestDesc, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+// ChunkDictCatalogEntry maps a base image to the chunk dict for the images
+// built from it.
+type ChunkDictCatalogEntry struct {
+	// Base is the reference of base image.
+	Base string `json:"base" yaml:"base"`
+	// ChunkDict is the chunk dict expression like `--chunk-dict`.
+	ChunkDict string `json:"chunkDict" yaml:"chunkDict"`
+}
+
+// ChunkDictCatalog is the catalog of chunk dicts to select by the base
+// image of source image.
+type ChunkDictCatalog struct {
+	ChunkDicts []ChunkDictCatalogEntry `json:"chunkDicts" yaml:"chunkDicts"`
+}
+
+// ParseChunkDictCatalog reads the chunk dict catalog from the file, both
+// YAML and JSON formats are accepted.
+func ParseChunkDictCatalog(path string) (*ChunkDictCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read chunk dict catalog")
+	}
+
+	var catalog ChunkDictCatalog
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, errors.Wrap(err, "unmarshal chunk dict catalog")
+	}
+
+	if len(catalog.ChunkDicts) == 0 {
+		return nil, fmt.Errorf("no chunk dict found in catalog %s", path)
+	}
+	for idx, entry := range catalog.ChunkDicts {
+		if _, err := reference.ParseDockerRef(entry.Base); err != nil {
+			return nil, errors.Wrapf(err, "invalid base of chunk dict #%d in catalog", idx)
+		}
+		if _, err := ParseChunkDicts(entry.ChunkDict); err != nil {
+			return nil, errors.Wrapf(err, "invalid chunk dict #%d in catalog", idx)
+		}
+	}
+
+	return &catalog, nil
+}
+
+// selectChunkDict selects the chunk dict in catalog for the base image of
+// source image, it returns nil if no base image is matched.
+func selectChunkDict(ctx context.Context, pvd *provider.Provider, source string, catalog []ChunkDictCatalogEntry) (*ChunkDictCatalogEntry, error) {
+	sourceManifests, err := imageManifests(ctx, pvd, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "get source manifests")
+	}
+
+	bases := make([][]ocispec.Manifest, len(catalog))
+	for idx, entry := range catalog {
+		// A base image failed to fetch can't be matched, but it shouldn't
+		// fail the conversion.
+		if _, bases[idx], err = remoteManifests(ctx, pvd, entry.Base); err != nil {
+			logrus.WithError(err).Warnf("failed to get base image %s in chunk dict catalog", entry.Base)
+		}
+	}
+
+	idx := matchBaseImage(sourceManifests, catalog, bases)
+	if idx < 0 {
+		return nil, nil
+	}
+	return &catalog[idx], nil
+}
+
+// matchBaseImage returns the index of the base image of source manifests,
+// or -1 if none is matched. The base image named by the annotation of
+// source manifests is matched first, otherwise the base image whose layers
+// are the longest prefix of the layers of source manifests is matched.
+func matchBaseImage(sources []ocispec.Manifest, catalog []ChunkDictCatalogEntry, bases [][]ocispec.Manifest) int {
+	for _, source := range sources {
+		name := source.Annotations[ocispec.AnnotationBaseImageName]
+		if name == "" {
+			continue
+		}
+		for idx, entry := range catalog {
+			if sameReference(name, entry.Base) {
+				return idx
+			}
+		}
+	}
+
+	matched, matchedLayers := -1, 0
+	for idx := range catalog {
+		for _, base := range bases[idx] {
+			for _, source := range sources {
+				if len(base.Layers) > matchedLayers && isLayerPrefix(base.Layers, source.Layers) {
+					matched, matchedLayers = idx, len(base.Layers)
+				}
+			}
+		}
+	}
+	return matched
+}
+
+func isLayerPrefix(prefix, layers []ocispec.Descriptor) bool {
+	if len(prefix) > len(layers) {
+		return false
+	}
+	for idx := range prefix {
+		if prefix[idx].Digest != layers[idx].Digest {
+			return false
+		}
+	}
+	return true
+}
+
+func sameReference(a, b string) bool {
+	namedA, err := reference.ParseDockerRef(a)
+	if err != nil {
+		return false
+	}
+	namedB, err := reference.ParseDockerRef(b)
+	if err != nil {
+		return false
+	}
+	return namedA.String() == namedB.String()
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChunkDictCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+chunkDicts:
+  - base: debian:12
+    chunkDict: bootstrap:registry:localhost:5000/dict:debian-12
+  - base: python:3.12
+    chunkDict: bootstrap:registry:localhost:5000/dict:python-3.12,bootstrap:registry:localhost:5000/dict:debian-12
+`), 0644))
+	catalog, err := ParseChunkDictCatalog(path)
+	require.NoError(t, err)
+	require.Len(t, catalog.ChunkDicts, 2)
+	require.Equal(t, "python:3.12", catalog.ChunkDicts[1].Base)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"chunkDicts": [{"base": "debian:12", "chunkDict": "invalid"}]}`), 0644))
+	_, err = ParseChunkDictCatalog(path)
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{"chunkDicts": []}`), 0644))
+	_, err = ParseChunkDictCatalog(path)
+	require.Error(t, err)
+}
+
+func TestMatchBaseImage(t *testing.T) {
+	manifest := func(names ...string) ocispec.Manifest {
+		var manifest ocispec.Manifest
+		for _, name := range names {
+			manifest.Layers = append(manifest.Layers, ocispec.Descriptor{Digest: digest.FromString(name)})
+		}
+		return manifest
+	}
+	catalog := []ChunkDictCatalogEntry{{Base: "debian:12"}, {Base: "python:3.12"}, {Base: "alpine:3"}}
+	bases := [][]ocispec.Manifest{
+		{manifest("debian")},
+		{manifest("debian", "python")},
+		// The base image failed to fetch.
+		nil,
+	}
+
+	// The base image with the most layers matched is selected.
+	require.Equal(t, 1, matchBaseImage([]ocispec.Manifest{manifest("debian", "python", "app")}, catalog, bases))
+	require.Equal(t, 0, matchBaseImage([]ocispec.Manifest{manifest("debian", "app")}, catalog, bases))
+	require.Equal(t, -1, matchBaseImage([]ocispec.Manifest{manifest("app", "debian")}, catalog, bases))
+	require.Equal(t, -1, matchBaseImage([]ocispec.Manifest{{}}, catalog, bases))
+
+	// The base image named by annotation is selected first.
+	annotated := manifest("debian", "python", "app")
+	annotated.Annotations = map[string]string{ocispec.AnnotationBaseImageName: "docker.io/library/alpine:3"}
+	require.Equal(t, 2, matchBaseImage([]ocispec.Manifest{annotated}, catalog, bases))
+}
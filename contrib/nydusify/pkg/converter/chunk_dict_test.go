@@ -0,0 +1,76 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestParseChunkDicts(t *testing.T) {
+	dicts, err := ParseChunkDicts("bootstrap:registry:localhost:5000/os:dict, bootstrap:local:/tmp/python.boot")
+	require.NoError(t, err)
+	require.Equal(t, []ChunkDict{
+		{Source: "registry", Ref: "localhost:5000/os:dict"},
+		{Source: "local", Ref: "/tmp/python.boot"},
+	}, dicts)
+
+	_, err = ParseChunkDicts("bootstrap:registry:localhost:5000/os:dict,bootstrap:oss:dict")
+	require.Error(t, err)
+}
+
+func TestChunkDictPlatform(t *testing.T) {
+	platform, err := chunkDictPlatform(Opt{Platforms: "linux/arm64"})
+	require.NoError(t, err)
+	require.Equal(t, "arm64", platform.Architecture)
+
+	_, err = chunkDictPlatform(Opt{Platforms: "linux/amd64,linux/arm64"})
+	require.Error(t, err)
+	_, err = chunkDictPlatform(Opt{AllPlatforms: true})
+	require.Error(t, err)
+}
+
+func TestWriteChunkDictImage(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	bootstrapPath := filepath.Join(t.TempDir(), "chunk_dict.boot")
+	require.NoError(t, os.WriteFile(bootstrapPath, []byte("bootstrap"), 0644))
+	blob := ocispec.Descriptor{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString("blob"), Size: 4}
+
+	desc, err := writeChunkDictImage(ctx, store, bootstrapPath, "6", ocispec.Platform{OS: "linux", Architecture: "amd64"}, []ocispec.Descriptor{blob})
+	require.NoError(t, err)
+
+	manifests, err := platformManifests(ctx, store, *desc)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	layers := manifests[0].manifest.Layers
+	require.Len(t, layers, 2)
+	require.Equal(t, blob, layers[0])
+	require.Equal(t, "6", layers[1].Annotations[utils.LayerAnnotationNydusFsVersion])
+	require.True(t, isNydusManifest(manifests[0].manifest))
+
+	// Only the bootstrap is written, the blobs stay in registry.
+	_, err = store.Info(ctx, blob.Digest)
+	require.Error(t, err)
+	ra, err := store.ReaderAt(ctx, layers[1])
+	require.NoError(t, err)
+	defer ra.Close()
+	target := filepath.Join(t.TempDir(), "image.boot")
+	require.NoError(t, utils.UnpackFile(content.NewReader(ra), utils.BootstrapFileNameInLayer, target))
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "bootstrap", string(data))
+}
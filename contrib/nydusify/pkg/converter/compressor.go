@@ -0,0 +1,334 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	snapConv "github.com/BraveY/snapshotter-converter/converter"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/dustin/go-humanize"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	// CompressorAuto samples the layer content to pick the compressor.
+	CompressorAuto = "auto"
+
+	// defaultSmallLayerSize is the size of source layer below which the
+	// layer is small.
+	defaultSmallLayerSize = 10 * 1024 * 1024 // 10MB
+	// compressorSampleSize is the size of uncompressed layer content to
+	// estimate the compressibility.
+	compressorSampleSize = 4 * 1024 * 1024 // 4MB
+	// incompressibleRatio is the compression ratio of sample above which
+	// the layer isn't compressed, e.g. for the media or archives.
+	incompressibleRatio = 0.9
+)
+
+// compressors are supported by nydus-image.
+var compressors = []string{"none", "lz4_block", "zstd"}
+
+// CompressorPolicy selects the compressor of each layer by the size of
+// source layer, the layer in the size class not specified is compressed by
+// the default compressor.
+type CompressorPolicy struct {
+	// Small and Large are the compressors of the layers smaller and not
+	// smaller than Threshold, CompressorAuto picks the compressor by the
+	// compressibility of layer content.
+	Small     string
+	Large     string
+	Threshold int64
+}
+
+// ParseCompressorPolicy parses the policy in the format like
+// "small=none,large=zstd,threshold=10MB", or "auto" for all the layers.
+func ParseCompressorPolicy(value string) (*CompressorPolicy, error) {
+	policy := &CompressorPolicy{Threshold: defaultSmallLayerSize}
+	if value == CompressorAuto {
+		policy.Small = CompressorAuto
+		policy.Large = CompressorAuto
+		return policy, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid compressor policy item '%s', should be in the format of key=value", item)
+		}
+		switch key {
+		case "small", "large":
+			if name, _, ok := strings.Cut(val, ":"); ok {
+				return nil, fmt.Errorf("compression level of compressor %s is not supported by nydus-image", name)
+			}
+			if val != CompressorAuto && !isCompressor(val) {
+				return nil, fmt.Errorf("invalid compressor '%s' of %s layers, possible values: %v, %s", val, key, compressors, CompressorAuto)
+			}
+			if key == "small" {
+				policy.Small = val
+			} else {
+				policy.Large = val
+			}
+		case "threshold":
+			threshold, err := humanize.ParseBytes(val)
+			if err != nil || threshold == 0 {
+				return nil, fmt.Errorf("invalid threshold '%s' of compressor policy", val)
+			}
+			policy.Threshold = int64(threshold)
+		default:
+			return nil, fmt.Errorf("unknown compressor policy key '%s', possible keys: small, large, threshold", key)
+		}
+	}
+	return policy, nil
+}
+
+func isCompressor(name string) bool {
+	for _, compressor := range compressors {
+		if name == compressor {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the policy in the format parsed by ParseCompressorPolicy.
+func (policy *CompressorPolicy) String() string {
+	if policy == nil {
+		return ""
+	}
+	return fmt.Sprintf("small=%s,large=%s,threshold=%d", policy.Small, policy.Large, policy.Threshold)
+}
+
+// compressor returns the compressor of source layer, the defaultCompressor
+// is returned if the size class of layer isn't in policy, sample reads the
+// layer content for the auto selection.
+func (policy *CompressorPolicy) compressor(layer ocispec.Descriptor, defaultCompressor string, sample func() ([]byte, error)) (string, error) {
+	compressor := policy.Large
+	if layer.Size < policy.Threshold {
+		compressor = policy.Small
+	}
+	switch compressor {
+	case "":
+		return defaultCompressor, nil
+	case CompressorAuto:
+		data, err := sample()
+		if err != nil {
+			return "", err
+		}
+		return sampleCompressor(data)
+	default:
+		return compressor, nil
+	}
+}
+
+// sampleCompressor picks the compressor by the compression ratio of the
+// sample, the incompressible content isn't compressed to save CPU on both
+// conversion and runtime.
+func sampleCompressor(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "none", nil
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return "", errors.Wrap(err, "create zstd encoder")
+	}
+	defer encoder.Close()
+	ratio := float64(len(encoder.EncodeAll(data, nil))) / float64(len(data))
+	if ratio > incompressibleRatio {
+		return "none", nil
+	}
+	return "zstd", nil
+}
+
+// readLayerSample reads the head of uncompressed layer content.
+func readLayerSample(ctx context.Context, cs content.Store, layer ocispec.Descriptor) ([]byte, error) {
+	ra, err := cs.ReaderAt(ctx, layer)
+	if err != nil {
+		return nil, errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+	ds, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress layer")
+	}
+	defer ds.Close()
+	data, err := io.ReadAll(io.LimitReader(ds, compressorSampleSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "read layer")
+	}
+	return data, nil
+}
+
+// checkCompressorPolicy checks the options compatible with the compressor
+// policy, the layers are built by nydusify instead of the converter driver
+// in which the chunk dict, OCI ref and storage backend are handled.
+func checkCompressorPolicy(opt Opt) error {
+	switch {
+	case opt.ChunkDictRef != "" || opt.ChunkDictFromTarget || len(opt.ChunkDicts) > 0 || len(opt.ChunkDictCatalog) > 0:
+		return errors.New("compressor policy is not supported with chunk dict")
+	case opt.OCIRef:
+		return errors.New("compressor policy is not supported with OCI ref")
+	case opt.BackendType != "":
+		return errors.New("compressor policy is not supported with storage backend")
+	case opt.CacheRef != "" && !cache.IsContentAddressed(opt.CacheRef, opt.CacheMode):
+		return errors.New("compressor policy is only supported with content-addressed build cache")
+	}
+	return nil
+}
+
+// prebuildLayers builds the source layers whose compressor selected by the
+// policy differs from the default one, and records them in the local build
+// cache image, so that the converter driver reuses them instead of building
+// with the default compressor. The layers already in the build cache image
+// of cacheRef are skipped, the ref of new build cache image is returned.
+func prebuildLayers(ctx context.Context, pvd *provider.Provider, cacheRef, source string, platformMC platforms.MatchComparer, opt Opt) (string, error) {
+	cs := pvd.ContentStore()
+	if err := pvd.Pull(ctx, source); err != nil {
+		return "", errors.Wrap(err, "pull source image")
+	}
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return "", errors.Wrap(err, "get source image")
+	}
+	manifests, err := matchedManifests(ctx, cs, *sourceDesc, platformMC)
+	if err != nil {
+		return "", errors.Wrap(err, "read source manifests")
+	}
+
+	cacheManifest := buildCacheManifest(nil, nil, opt.FsVersion, opt.CacheVersion)
+	if cacheRef != "" {
+		cacheDesc, err := pvd.Image(ctx, cacheRef)
+		if err != nil {
+			return "", errors.Wrap(err, "get build cache image")
+		}
+		data, err := content.ReadBlob(ctx, cs, *cacheDesc)
+		if err != nil {
+			return "", errors.Wrap(err, "read build cache manifest")
+		}
+		if err := json.Unmarshal(data, &cacheManifest); err != nil {
+			return "", errors.Wrap(err, "unmarshal build cache manifest")
+		}
+	}
+	built := map[digest.Digest]bool{}
+	for _, layer := range cacheManifest.Layers {
+		if layer.Annotations[utils.LayerAnnotationNydusTargetDigest] != "" {
+			built[layer.Digest] = true
+		}
+	}
+
+	prebuilt := 0
+	for _, manifest := range manifests {
+		for _, layer := range manifest.Layers {
+			if built[layer.Digest] || !images.IsLayerType(layer.MediaType) {
+				continue
+			}
+			built[layer.Digest] = true
+			compressor, err := opt.CompressorPolicy.compressor(layer, opt.Compressor, func() ([]byte, error) {
+				return readLayerSample(ctx, cs, layer)
+			})
+			if err != nil {
+				return "", errors.Wrapf(err, "select compressor of layer %s", layer.Digest)
+			}
+			if compressor == opt.Compressor {
+				continue
+			}
+			logrus.Infof("building layer %s with compressor %s", layer.Digest, compressor)
+			blob, err := prebuildLayer(ctx, cs, layer, compressor, opt)
+			if err != nil {
+				return "", errors.Wrapf(err, "build layer %s", layer.Digest)
+			}
+			appendCacheLayer(&cacheManifest, layer, *blob)
+			prebuilt++
+		}
+	}
+	if prebuilt == 0 {
+		logrus.Infof("all layers are compressed by the default compressor %s", opt.Compressor)
+		return cacheRef, nil
+	}
+	logrus.Infof("built %d layers with the compressors selected by policy", prebuilt)
+
+	return writeBuildCacheImage(ctx, pvd, cacheManifest)
+}
+
+// prebuildLayer builds the source layer into nydus blob layer compressed by
+// compressor in content store, same as the converter driver.
+func prebuildLayer(ctx context.Context, cs content.Store, layer ocispec.Descriptor, compressor string, opt Opt) (*ocispec.Descriptor, error) {
+	ra, err := cs.ReaderAt(ctx, layer)
+	if err != nil {
+		return nil, errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+	ds, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress layer")
+	}
+	defer ds.Close()
+
+	writer, err := content.OpenWriter(ctx, cs, content.WithRef("nydusify-prebuild-"+layer.Digest.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "open blob writer")
+	}
+	defer writer.Close()
+	// The blob left by an interrupted conversion is written again.
+	if err := writer.Truncate(0); err != nil {
+		return nil, errors.Wrap(err, "truncate blob writer")
+	}
+
+	digester := digest.SHA256.Digester()
+	tw, err := snapConv.Pack(ctx, io.MultiWriter(writer, digester.Hash()), snapConv.PackOption{
+		WorkDir:          opt.WorkDir,
+		BuilderPath:      opt.NydusImagePath,
+		FsVersion:        opt.FsVersion,
+		Compressor:       compressor,
+		ChunkSize:        opt.ChunkSize,
+		BatchSize:        opt.BatchSize,
+		AlignedChunk:     opt.FsAlignChunk,
+		PrefetchPatterns: opt.PrefetchPatterns,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "pack layer")
+	}
+	if _, err := io.Copy(tw, ds); err != nil {
+		tw.Close()
+		return nil, errors.Wrap(err, "write layer to builder")
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "pack layer")
+	}
+
+	blobDigest := digester.Digest()
+	if err := writer.Commit(ctx, 0, blobDigest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return nil, errors.Wrap(err, "commit blob")
+	}
+	info, err := cs.Info(ctx, blobDigest)
+	if err != nil {
+		return nil, errors.Wrap(err, "get blob info")
+	}
+	return &ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    blobDigest,
+		Size:      info.Size,
+		Annotations: map[string]string{
+			// The blob is an uncompressed tar containing the nydus blob.
+			utils.LayerAnnotationUncompressed: blobDigest.String(),
+			utils.LayerAnnotationNydusBlob:    "true",
+		},
+	}, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompressorPolicy(t *testing.T) {
+	policy, err := ParseCompressorPolicy("small=none, large=auto,threshold=1MiB")
+	require.NoError(t, err)
+	require.Equal(t, &CompressorPolicy{Small: "none", Large: CompressorAuto, Threshold: 1 << 20}, policy)
+	require.Equal(t, "small=none,large=auto,threshold=1048576", policy.String())
+
+	policy, err = ParseCompressorPolicy("auto")
+	require.NoError(t, err)
+	require.Equal(t, &CompressorPolicy{Small: CompressorAuto, Large: CompressorAuto, Threshold: defaultSmallLayerSize}, policy)
+
+	for value, msg := range map[string]string{
+		"large=zstd:9":     "compression level",
+		"small=gzip":       "invalid compressor 'gzip'",
+		"small":            "format of key=value",
+		"medium=zstd":      "unknown compressor policy key",
+		"threshold=-1":     "invalid threshold",
+		"small=none,large": "format of key=value",
+	} {
+		_, err := ParseCompressorPolicy(value)
+		require.ErrorContains(t, err, msg, value)
+	}
+	require.Empty(t, (*CompressorPolicy)(nil).String())
+}
+
+func TestSelectCompressor(t *testing.T) {
+	random := make([]byte, 64*1024)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	text := bytes.Repeat([]byte("nydus image "), 8*1024)
+
+	sampled := 0
+	sample := func(data []byte) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			sampled++
+			return data, nil
+		}
+	}
+	small := ocispec.Descriptor{Size: 1024}
+	large := ocispec.Descriptor{Size: defaultSmallLayerSize}
+
+	policy, err := ParseCompressorPolicy("small=none")
+	require.NoError(t, err)
+	compressor, err := policy.compressor(small, "zstd", sample(nil))
+	require.NoError(t, err)
+	require.Equal(t, "none", compressor)
+	compressor, err = policy.compressor(large, "zstd", sample(nil))
+	require.NoError(t, err)
+	require.Equal(t, "zstd", compressor)
+	require.Zero(t, sampled)
+
+	// The incompressible layer isn't compressed.
+	policy, err = ParseCompressorPolicy("auto")
+	require.NoError(t, err)
+	compressor, err = policy.compressor(large, "lz4_block", sample(random))
+	require.NoError(t, err)
+	require.Equal(t, "none", compressor)
+	compressor, err = policy.compressor(small, "lz4_block", sample(text))
+	require.NoError(t, err)
+	require.Equal(t, "zstd", compressor)
+	compressor, err = policy.compressor(small, "lz4_block", sample(nil))
+	require.NoError(t, err)
+	require.Equal(t, "none", compressor)
+	require.Equal(t, 3, sampled)
+}
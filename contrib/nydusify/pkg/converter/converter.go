@@ -22,41 +22,97 @@ import (
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containers/ocicrypt/helpers"
+	accelcontent "github.com/goharbor/acceleration-service/pkg/content"
 	"github.com/goharbor/acceleration-service/pkg/converter"
 	"github.com/goharbor/acceleration-service/pkg/platformutil"
 	"github.com/opencontainers/go-digest"
 	"github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/cache"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/external/modctl"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/progress"
 	pkgPvd "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/snapshotter/external"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
 )
 
 type Opt struct {
-	WorkDir           string
+	WorkDir string
+	// WorkDirQuota limits the usage of WorkDir in bytes, zero means
+	// unlimited. The conversion fails once the quota is exceeded.
+	WorkDirQuota int64
+	// KeepWorkDir is the policy to keep the conversion directory in
+	// WorkDir after conversion, which is kept on failure by default to
+	// resume the conversion.
+	KeepWorkDir       string
 	ContainerdAddress string
 	NydusImagePath    string
 
 	Source       string
 	Target       string
 	ChunkDictRef string
+	// ChunkDictFromTarget uses the nydus image existing at target reference
+	// as chunk dict if ChunkDictRef is empty, so that only the chunks not
+	// in the previously pushed image are pushed.
+	ChunkDictFromTarget bool
+	// ChunkDicts are merged into one chunk dict used instead of ChunkDictRef,
+	// the former chunk dicts take precedence over the latter ones.
+	ChunkDicts []ChunkDict
+	// ChunkDictCatalog selects the chunk dict by the base image of source
+	// image if no chunk dict is specified.
+	ChunkDictCatalog []ChunkDictCatalogEntry
 
 	SourceBackendType   string
 	SourceBackendConfig string
+	// SourceFormat is the format of source image layers, the prefetch
+	// landmarks of eStargz and zstd:chunked layers are converted to nydus
+	// prefetch patterns.
+	SourceFormat string
+	// ZstdChunkedInterop keeps the source zstd:chunked manifest alongside
+	// the nydus manifest in target image index, so that the target image
+	// can be pulled by both podman and nydus runtimes.
+	ZstdChunkedInterop bool
 
 	SourceInsecure    bool
 	TargetInsecure    bool
 	ChunkDictInsecure bool
+	// SourceTransport and TargetTransport are the CA bundles, client
+	// certificates and proxy to access the source and target registries.
+	SourceTransport remote.TransportOption
+	TargetTransport remote.TransportOption
+	// SourceCredential and TargetCredential override the credentials in
+	// docker config to access the source and target registries.
+	SourceCredential pkgPvd.CredentialOption
+	TargetCredential pkgPvd.CredentialOption
+	// SourceMirrors are the mirrors of source registry tried in order before
+	// the source registry to pull source image.
+	SourceMirrors []string
 
 	CacheRef        string
 	CacheInsecure   bool
 	CacheVersion    string
 	CacheMaxRecords uint
+	// CacheMode is cache.ModeImage to record the converted layers in cache
+	// image, or cache.ModeCAS to store them in the content-addressed store
+	// shared across images.
+	CacheMode string
+	// CachePolicy and CacheMaxSize evict the entries of content-addressed
+	// build cache once it exceeds CacheMaxSize or CacheMaxRecords.
+	CachePolicy  string
+	CacheMaxSize int64
+	// CacheBackendConfig is the storage backend configuration to access
+	// the build cache in object storage, e.g. "s3://bucket/prefix".
+	CacheBackendConfig string
 
 	BackendType      string
 	BackendConfig    string
@@ -70,17 +126,100 @@ type Opt struct {
 	ChunkSize        string
 	BatchSize        string
 	PrefetchPatterns string
+	// PrefetchAuto generates the prefetch patterns from the entrypoint,
+	// cmd and env of source image config if PrefetchPatterns is not
+	// specified.
+	PrefetchAuto bool
+	// CompressorPolicy overrides Compressor for the layers selected by
+	// their size or content.
+	CompressorPolicy *CompressorPolicy
 	OCIRef           bool
 	WithReferrer     bool
-	WithPlainHTTP    bool
+	// CopyReferrers copies the referrers of source image to target image.
+	CopyReferrers bool
+	// SBOMFormat generates the SBOM of target image in the format (spdx
+	// or cyclonedx) and attaches it to target image as a referrer.
+	SBOMFormat    string
+	WithPlainHTTP bool
+	// FileDigests attaches the digests of regular files not smaller than
+	// FileDigestMinSize to target image as a referrer, for the runtimes
+	// to deduplicate the identical files across images in a local
+	// content-addressed cache.
+	FileDigests       bool
+	FileDigestMinSize int64
+	// IfNydus is one of IfNydusModes to convert the source image having
+	// nydus manifests, which is converted as usual if it's empty.
+	IfNydus string
+	// Features are the RAFS features required in target image, which are
+	// checked against nydus-image before conversion and verified in the
+	// bootstraps after conversion, see FeatureNames.
+	Features []string
+	// OCITailLayers keeps at most the top OCITailLayers gzip layers not
+	// larger than OCITailMaxSize (zero means no limit) as OCI layers
+	// referenced by OCI ref bootstrap, only the lower layers are converted
+	// to nydus blobs.
+	OCITailLayers  int
+	OCITailMaxSize int64
+	// Flatten squashes the layers of each source manifest into one layer
+	// before conversion, the layer history of source image is dropped.
+	Flatten bool
+	// Annotations are set on the nydus manifests and index of target
+	// image, overriding the ones carried over from source image.
+	Annotations map[string]string
+	// AllowNondistributable pulls, converts and pushes the non-distributable
+	// layers (e.g. the foreign layers of windows base images), otherwise
+	// the manifests having them are skipped in conversion.
+	AllowNondistributable bool
+	// Hooks run the user logic at the stages of conversion, see Hooks.
+	Hooks *Hooks
+	// Stream reads the source layers from registry on demand during
+	// conversion instead of downloading them into work directory.
+	Stream bool
 
 	AllPlatforms bool
 	Platforms    string
 
 	OutputJSON string
+	// OutputLayout is the OCI image layout directory to write the target
+	// image, instead of pushing it to the target registry.
+	OutputLayout string
+	// EncryptRecipients encrypts the layers of target image with ocicrypt
+	// for the recipients, e.g. "jwe:pubkey.pem", "pkcs7:cert.pem" or
+	// "provider:<keyprovider>".
+	EncryptRecipients []string
+
+	// CosignPath is the path of cosign binary to sign the target image
+	// with SignCosignKey, or in keyless mode if SignCosignKeyless is set.
+	CosignPath        string
+	SignCosignKey     string
+	SignCosignKeyless bool
+
+	// PreheatDragonflyEndpoint is the endpoint of Dragonfly manager to
+	// preheat the target image in P2P network after conversion, with the
+	// personal access token PreheatDragonflyToken.
+	PreheatDragonflyEndpoint string
+	PreheatDragonflyToken    string
+	// PreheatScope is one of preheat.Scopes.
+	PreheatScope string
+	// PreheatTimeout waits for the preheat to finish if it's positive.
+	PreheatTimeout time.Duration
 
 	PushRetryCount int
 	PushRetryDelay string
+	// PushChunkSize enables resumable upload for the blobs larger than it,
+	// the blobs are uploaded in chunks and the upload continues from the
+	// last received chunk after a transient failure.
+	PushChunkSize int64
+	// PullRateLimit and PushRateLimit limit the total bandwidth in bytes
+	// per second to pull and push images from and to registries.
+	PullRateLimit int64
+	PushRateLimit int64
+
+	// Progress is the mode to report the per-layer progress, see
+	// progress.Modes.
+	Progress string
+	// reporter is shared by the conversions in batch.
+	reporter progress.Reporter
 }
 
 type SourceBackendConfig struct {
@@ -97,61 +236,606 @@ func Convert(ctx context.Context, opt Opt) error {
 		return convertModelArtifact(ctx, opt)
 	}
 
+	stopProgress, err := startProgress(&opt)
+	if err != nil {
+		return err
+	}
+	ctx, span := tracing.Start(ctx, "convert", attribute.String("source", opt.Source), attribute.String("target", opt.Target))
+	report, err := convertImage(ctx, opt)
+	tracing.End(span, err)
+	stopProgress()
+
+	if report != nil && len(report.SizeAnalysis) > 0 {
+		printSizeSummary(os.Stderr, report.SizeAnalysis)
+	}
+	if opt.OutputJSON != "" && report != nil {
+		dumpMetric(report, opt.OutputJSON)
+	}
+	return err
+}
+
+// startProgress creates the progress reporter of opt.Progress, the returned
+// function stops reporting.
+func startProgress(opt *Opt) (func(), error) {
+	reporter, err := progress.New(opt.Progress, os.Stdout, os.Stderr)
+	if err != nil || reporter == nil {
+		return func() {}, err
+	}
+	opt.reporter = reporter
+	// Print the logs above the progress bars.
+	writer, isWriter := reporter.(io.Writer)
+	if isWriter {
+		logrus.SetOutput(writer)
+	}
+	return func() {
+		if isWriter {
+			logrus.SetOutput(os.Stderr)
+		}
+		reporter.Close()
+	}, nil
+}
+
+// convertImage converts an OCI image to a nydus image and returns the
+// metric and size analysis of the conversion.
+// preservesMetadata returns true if the metadata of source image is kept
+// in the target image. The nydus manifests are referenced by the referrer
+// of source image, and the encrypted image can't be rewritten.
+func preservesMetadata(opt Opt) bool {
+	return !opt.WithReferrer && len(opt.EncryptRecipients) == 0
+}
+
+// rewritesTarget returns true if the converted image is rewritten or
+// verified by the options before pushed to target.
+func rewritesTarget(opt Opt) bool {
+	return preservesMetadata(opt) || len(opt.Annotations) > 0 || len(opt.Features) > 0 || (opt.Hooks != nil && opt.Hooks.PrePush != nil)
+}
+
+// pushTarget pushes the target image, unless it has been pushed to target
+// by converter and isn't rewritten after that.
+func pushTarget(ctx context.Context, pvd *provider.Provider, convertTarget string, convertedDesc, targetDesc ocispec.Descriptor, target string) error {
+	if convertTarget == target && targetDesc.Digest == convertedDesc.Digest {
+		return nil
+	}
+	if err := pvd.Push(ctx, targetDesc, target); err != nil {
+		return errors.Wrap(err, "push target image")
+	}
+	return nil
+}
+
+func convertImage(ctx context.Context, opt Opt) (_ *Report, retErr error) {
 	ctx = namespaces.WithNamespace(ctx, "nydusify")
 	platformMC, err := platformutil.ParsePlatforms(opt.AllPlatforms, opt.Platforms)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// The platforms which can't be converted are pulled and pushed by
+	// provider with `--merge-platform`, then passed through to target index.
+	pullMC := platformMC
+	if opt.MergePlatform || opt.ZstdChunkedInterop {
+		platformMC = convertiblePlatforms{platformMC}
+	}
+	if err := opt.SourceCredential.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid source credential")
+	}
+	if err := opt.TargetCredential.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid target credential")
 	}
 
+	workDirCreated := false
 	if _, err := os.Stat(opt.WorkDir); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
-				return errors.Wrap(err, "prepare work directory")
+				return nil, errors.Wrap(err, "prepare work directory")
 			}
 			// We should only clean up when the work directory not exists
 			// before, otherwise it may delete user data by mistake.
-			defer os.RemoveAll(opt.WorkDir)
+			workDirCreated = true
 		} else {
-			return errors.Wrap(err, "stat work directory")
+			return nil, errors.Wrap(err, "stat work directory")
 		}
 	}
-	tmpDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-")
+	ctx, stopWatch := utils.WatchQuota(ctx, opt.WorkDir, opt.WorkDirQuota)
+	defer stopWatch()
+	defer func() {
+		retErr = utils.QuotaError(ctx, retErr)
+	}()
+
+	tmpDir, err := conversionDir(opt)
 	if err != nil {
-		return errors.Wrap(err, "create temp directory")
+		return nil, errors.Wrap(err, "get conversion directory")
+	}
+	if _, err := os.Stat(tmpDir); err == nil {
+		logrus.Infof("resume conversion from %s", tmpDir)
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create conversion directory")
 	}
-	pvd, err := provider.New(tmpDir, hosts(opt), opt.CacheMaxRecords, opt.CacheVersion, platformMC, 0, nil)
+	unlock, err := workdir.Lock(tmpDir)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// The conversion directory is kept on failure by default, so that the
+	// re-run of the same command can resume the conversion.
+	defer func() {
+		unlock()
+		if workdir.Keep(opt.KeepWorkDir, workdir.KeepOnFailure, retErr) {
+			if retErr != nil {
+				logrus.Infof("conversion directory is kept in %s, re-run the same command to resume", tmpDir)
+			} else {
+				logrus.Infof("conversion directory is kept in %s", tmpDir)
+			}
+			return
+		}
+		os.RemoveAll(tmpDir)
+		if workDirCreated {
+			os.RemoveAll(opt.WorkDir)
+		}
+	}()
+	var store content.Store
+	if opt.Stream {
+		baseStore, err := accelcontent.NewContent(hosts(opt), filepath.Join(tmpDir, "content"), tmpDir, "0MB")
+		if err != nil {
+			return nil, err
+		}
+		store = provider.NewStreamLayerContent(baseStore, hosts(opt))
+	}
+	pvd, err := provider.New(tmpDir, hosts(opt), opt.CacheMaxRecords, opt.CacheVersion, pullMC, 0, store)
+	if err != nil {
+		return nil, err
 	}
-	defer os.RemoveAll(tmpDir)
 
 	// Parse retry delay
 	retryDelay, err := time.ParseDuration(opt.PushRetryDelay)
 	if err != nil {
-		return errors.Wrap(err, "parse push retry delay")
+		return nil, errors.Wrap(err, "parse push retry delay")
 	}
 
 	// Set push retry configuration
 	pvd.SetPushRetryConfig(opt.PushRetryCount, retryDelay)
+	pvd.SetResumableUpload(opt.PushChunkSize)
+	pvd.SetRateLimit(opt.PullRateLimit, opt.PushRateLimit)
+	pvd.SetAllowNondistributable(opt.AllowNondistributable)
 
 	if opt.WithPlainHTTP {
 		pvd.UsePlainHTTP()
 	}
 
+	if opt.reporter != nil {
+		pvd.SetContentStore(progress.NewContent(pvd.ContentStore(), opt.reporter))
+	}
+
+	if opt.OutputLayout != "" {
+		if opt.OCIRef {
+			return nil, fmt.Errorf("OCI reference is not supported when output to OCI image layout")
+		}
+		if opt.shouldSign() {
+			return nil, fmt.Errorf("image signing is not supported when output to OCI image layout")
+		}
+		if opt.CopyReferrers {
+			return nil, fmt.Errorf("copying referrers is not supported when output to OCI image layout")
+		}
+		if opt.SBOMFormat != "" {
+			return nil, fmt.Errorf("SBOM is not supported when output to OCI image layout")
+		}
+		if opt.FileDigests {
+			return nil, fmt.Errorf("file digests are not supported when output to OCI image layout")
+		}
+		if opt.PreheatDragonflyEndpoint != "" {
+			return nil, fmt.Errorf("preheat is not supported when output to OCI image layout")
+		}
+		if opt.ChunkDictFromTarget {
+			return nil, fmt.Errorf("chunk dict from target is not supported when output to OCI image layout")
+		}
+		if err := pvd.SetOutputLayout(opt.Target, opt.OutputLayout); err != nil {
+			return nil, errors.Wrap(err, "set output layout")
+		}
+	}
+
+	if opt.CompressorPolicy != nil {
+		if err := checkCompressorPolicy(opt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFeatures(ctx, opt); err != nil {
+		return nil, err
+	}
+	if len(opt.Features) > 0 {
+		builder, err := wrapBuilder(filepath.Join(tmpDir, "builder"), opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "wrap builder for RAFS features")
+		}
+		opt.NydusImagePath = builder
+	}
+
+	if opt.OCITailLayers > 0 {
+		if err := checkOCITail(opt); err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.Flatten {
+		if err := checkFlatten(opt); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opt.Annotations) > 0 && (opt.WithReferrer || len(opt.EncryptRecipients) > 0) {
+		return nil, fmt.Errorf("annotations are not supported with referrer or image encryption")
+	}
+
+	if len(opt.EncryptRecipients) > 0 {
+		if opt.OCIRef {
+			return nil, fmt.Errorf("image encryption is not supported with OCI reference")
+		}
+		cc, err := helpers.CreateCryptoConfig(opt.EncryptRecipients, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "create crypto config")
+		}
+		if err := pvd.SetEncryption(opt.Target, &cc); err != nil {
+			return nil, errors.Wrap(err, "set encryption")
+		}
+	}
+
+	if opt.SourceTransport != (remote.TransportOption{}) && !provider.IsLocalSource(opt.Source) {
+		if err := pvd.SetTransport(opt.Source, opt.SourceTransport); err != nil {
+			return nil, errors.Wrap(err, "set source transport")
+		}
+	}
+	if len(opt.SourceMirrors) > 0 && !provider.IsLocalSource(opt.Source) {
+		if err := pvd.SetMirrors(opt.Source, opt.SourceMirrors); err != nil {
+			return nil, errors.Wrap(err, "set source mirrors")
+		}
+	}
+	if opt.TargetTransport != (remote.TransportOption{}) && opt.OutputLayout == "" {
+		if err := pvd.SetTransport(opt.Target, opt.TargetTransport); err != nil {
+			return nil, errors.Wrap(err, "set target transport")
+		}
+	}
+
+	source := opt.Source
+	if provider.IsLocalSource(source) {
+		if opt.OCIRef || opt.WithReferrer || opt.CopyReferrers {
+			return nil, fmt.Errorf("OCI reference and referrer are not supported for local source %s", source)
+		}
+		if strings.HasPrefix(source, provider.ContainerdScheme) {
+			containerdSource, err := provider.ParseContainerdSource(source, opt.ContainerdAddress)
+			if err != nil {
+				return nil, err
+			}
+			if source, err = pvd.ImportContainerd(ctx, containerdSource); err != nil {
+				return nil, errors.Wrap(err, "import containerd source")
+			}
+		} else {
+			localSource, err := provider.ParseLocalSource(source)
+			if err != nil {
+				return nil, err
+			}
+			if source, err = pvd.ImportLocal(ctx, localSource); err != nil {
+				return nil, errors.Wrap(err, "import local source")
+			}
+		}
+	}
+
+	if !provider.IsLocalSource(opt.Source) && opt.OutputLayout == "" {
+		if err := checkSpace(ctx, pvd, source, platformMC, opt); err != nil {
+			return nil, err
+		}
+	}
+
+	var nydusCacheRef string
+	if opt.IfNydus != "" {
+		isNydus, err := hasNydusManifest(ctx, pvd, source, provider.IsLocalSource(opt.Source), platformMC)
+		if err != nil {
+			return nil, errors.Wrap(err, "detect nydus source image")
+		}
+		if isNydus {
+			switch opt.IfNydus {
+			case IfNydusSkip:
+				if err := passThrough(ctx, pvd, source, opt.Target); err != nil {
+					return nil, errors.Wrap(err, "pass through nydus source image")
+				}
+				return &Report{}, nil
+			case IfNydusRebase, IfNydusRebuild:
+				if opt.CopyReferrers || opt.WithReferrer {
+					return nil, fmt.Errorf("referrer is not supported when converting nydus source image again")
+				}
+				if opt.IfNydus == IfNydusRebase && opt.CacheRef != "" {
+					return nil, fmt.Errorf("build cache is not supported when rebasing nydus source image")
+				}
+				if source, nydusCacheRef, err = stripNydusManifests(ctx, pvd, source, opt.IfNydus == IfNydusRebase, opt); err != nil {
+					return nil, errors.Wrap(err, "strip nydus manifests of source image")
+				}
+			default:
+				return nil, fmt.Errorf("unknown behavior %s for nydus source image", opt.IfNydus)
+			}
+		}
+	}
+
+	if opt.ZstdChunkedInterop {
+		if opt.SourceFormat != SourceFormatZstdChunked {
+			return nil, fmt.Errorf("zstd:chunked interop requires source format %s", SourceFormatZstdChunked)
+		}
+		opt.MergePlatform = true
+	}
+
+	// The manifests which can't be converted are skipped in conversion, and
+	// passed through to target index with `--merge-platform`.
+	var convertedManifests, passthrough []ocispec.Descriptor
+	if opt.MergePlatform || !opt.AllowNondistributable {
+		if convertedManifests, passthrough, err = splitPlatforms(ctx, pvd, source, pullMC, platformMC, opt.AllowNondistributable); err != nil {
+			return nil, errors.Wrap(err, "check platforms of source image")
+		}
+		if len(passthrough) > 0 {
+			platformMC = skipPlatforms{MatchComparer: platformMC, skipped: passthrough}
+		}
+		if !opt.MergePlatform {
+			passthrough = nil
+		}
+	}
+
+	if tocDecompressors(opt.SourceFormat) != nil || opt.PrefetchAuto {
+		if err := pvd.Pull(ctx, source); err != nil {
+			return nil, errors.Wrap(err, "pull source image")
+		}
+		image, err := pvd.Image(ctx, source)
+		if err != nil {
+			return nil, errors.Wrap(err, "get source image")
+		}
+		if tocDecompressors(opt.SourceFormat) != nil {
+			if err := applyEStargzPrefetch(ctx, pvd.ContentStore(), *image, platformMC, &opt); err != nil {
+				return nil, err
+			}
+		}
+		if opt.ZstdChunkedInterop {
+			if err := checkZstdChunkedAnnotations(ctx, pvd.ContentStore(), *image, platformMC); err != nil {
+				return nil, err
+			}
+		}
+		// The prefetch landmarks of source layers take precedence over
+		// the generated prefetch patterns.
+		if opt.PrefetchAuto {
+			if err := applyAutoPrefetch(ctx, pvd.ContentStore(), *image, platformMC, &opt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if opt.ChunkDictFromTarget && opt.ChunkDictRef == "" {
+		chunkDictRef, err := targetChunkDict(ctx, pvd, opt.Target)
+		if err != nil {
+			return nil, errors.Wrap(err, "get chunk dict from target")
+		}
+		if chunkDictRef != "" {
+			logrus.Infof("deduplicate chunks against %s", chunkDictRef)
+			opt.ChunkDictRef = chunkDictRef
+		} else {
+			logrus.Infof("no nydus image found at %s, skip deduplicating chunks against target", opt.Target)
+		}
+	}
+
+	if len(opt.ChunkDictCatalog) > 0 && opt.ChunkDictRef == "" && len(opt.ChunkDicts) == 0 {
+		entry, err := selectChunkDict(ctx, pvd, source, opt.ChunkDictCatalog)
+		if err != nil {
+			return nil, errors.Wrap(err, "select chunk dict from catalog")
+		}
+		if entry != nil {
+			logrus.Infof("select chunk dict %s for base image %s", entry.ChunkDict, entry.Base)
+			dicts, err := ParseChunkDicts(entry.ChunkDict)
+			if err != nil {
+				return nil, err
+			}
+			opt.ChunkDictRef, opt.ChunkDicts = SplitChunkDicts(dicts)
+		} else {
+			logrus.Infof("no base image of %s found in chunk dict catalog", opt.Source)
+		}
+	}
+
+	if len(opt.ChunkDicts) > 0 {
+		chunkDictRef, err := mergeChunkDicts(ctx, pvd, filepath.Join(tmpDir, "chunk_dict"), opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "merge chunk dicts")
+		}
+		opt.ChunkDictRef = chunkDictRef
+	}
+
+	convertSource := source
+	if opt.Flatten {
+		flattenCtx, span := tracing.Start(ctx, "flatten")
+		convertSource, err = flattenImage(flattenCtx, pvd, source, platformMC)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, errors.Wrap(err, "flatten source image")
+		}
+	}
+	// The pre-layer hook is called with the source layers while they're
+	// read, by the build cache and conversion as well.
+	var preLayer *preLayerContent
+	if opt.Hooks != nil && opt.Hooks.PreLayer != nil {
+		preLayer = newPreLayerContent(ctx, pvd, convertSource, platformMC, opt)
+		pvd.SetContentStore(preLayer)
+	}
+
+	cacheRef := opt.CacheRef
+	if nydusCacheRef != "" {
+		cacheRef = nydusCacheRef
+	}
+	var cacheStore cache.Store
+	if opt.CacheRef != "" && cache.IsContentAddressed(opt.CacheRef, opt.CacheMode) {
+		if cacheStore, err = newCacheStore(opt.CacheRef, opt.CacheInsecure, opt.CacheBackendConfig, pvd); err != nil {
+			return nil, errors.Wrap(err, "create build cache store")
+		}
+		seedCtx, span := tracing.Start(ctx, "cache.seed")
+		cacheRef, err = seedBuildCache(seedCtx, pvd, cacheStore, convertSource, opt)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, errors.Wrap(err, "seed build cache")
+		}
+	}
+
+	if opt.CompressorPolicy != nil {
+		prebuildCtx, span := tracing.Start(ctx, "prebuild")
+		cacheRef, err = prebuildLayers(prebuildCtx, pvd, cacheRef, convertSource, platformMC, opt)
+		tracing.End(span, err)
+		if err != nil {
+			return nil, errors.Wrap(err, "build layers by compressor policy")
+		}
+	}
+
 	cvt, err := converter.New(
 		converter.WithProvider(pvd),
 		converter.WithDriver("nydus", getConfig(opt)),
 		converter.WithPlatform(platformMC),
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	metric, err := cvt.Convert(ctx, opt.Source, opt.Target, opt.CacheRef)
-	if opt.OutputJSON != "" {
-		dumpMetric(metric, opt.OutputJSON)
+	convertTarget := opt.Target
+	var tails []ociTailManifest
+	if opt.OCITailLayers > 0 {
+		if convertSource, tails, err = splitOCITail(ctx, pvd, convertSource, platformMC, opt); err != nil {
+			return nil, errors.Wrap(err, "split OCI tail layers")
+		}
 	}
-	return err
+	// The converted image is rewritten or verified before pushed to target,
+	// so that the target isn't pushed twice, or pushed before the source
+	// layers are checked by pre-layer hook.
+	if len(tails) > 0 || len(passthrough) > 0 || preLayer != nil || rewritesTarget(opt) {
+		convertTarget = pvd.AddLocalTarget("converted")
+	}
+
+	// The pull and push of images are traced by provider, the time left in
+	// the span is spent on building the nydus layers.
+	buildCtx, span := tracing.Start(ctx, "build")
+	metric, err := cvt.Convert(buildCtx, convertSource, convertTarget, cacheRef)
+	tracing.End(span, err)
+	report := &Report{
+		Metric:             metric,
+		ConvertedPlatforms: platformNames(convertedManifests),
+		CopiedPlatforms:    platformNames(passthrough),
+	}
+	if err != nil {
+		return report, err
+	}
+	if preLayer != nil {
+		pvd.SetContentStore(preLayer.Store)
+		if err := runPreLayerHook(ctx, preLayer); err != nil {
+			return report, err
+		}
+	}
+
+	convertedDesc, err := pvd.Image(ctx, convertTarget)
+	if err != nil {
+		return report, errors.Wrap(err, "get converted image")
+	}
+	targetDesc := convertedDesc
+	if len(tails) > 0 {
+		if targetDesc, err = appendOCITail(ctx, pvd, convertTarget, tails, filepath.Join(tmpDir, "oci_tail"), opt); err != nil {
+			return report, errors.Wrap(err, "append OCI tail layers")
+		}
+	}
+	if len(passthrough) > 0 {
+		if targetDesc, err = appendManifests(ctx, pvd.ContentStore(), *targetDesc, passthrough); err != nil {
+			return report, errors.Wrap(err, "pass through platforms")
+		}
+		logrus.Infof("converted platforms: %s, copied platforms: %s", strings.Join(report.ConvertedPlatforms, ", "), strings.Join(report.CopiedPlatforms, ", "))
+	}
+	if opt.Hooks != nil && opt.Hooks.PostLayer != nil {
+		if err := runPostLayerHook(ctx, pvd.ContentStore(), *targetDesc, opt); err != nil {
+			return report, err
+		}
+	}
+	annotations := opt.Annotations
+	if opt.Hooks != nil && opt.Hooks.PrePush != nil {
+		if annotations, err = runPrePushHook(ctx, *targetDesc, opt); err != nil {
+			return report, err
+		}
+	}
+	if preservesMetadata(opt) {
+		sourceDesc, err := pvd.Image(ctx, convertSource)
+		if err != nil {
+			return report, errors.Wrap(err, "get source image")
+		}
+		if targetDesc, err = preserveMetadata(ctx, pvd.ContentStore(), *sourceDesc, *targetDesc, annotations); err != nil {
+			return report, errors.Wrap(err, "preserve metadata of source image")
+		}
+	}
+	if len(opt.Features) > 0 {
+		if err := verifyFeatures(ctx, pvd, pvd.AddLocalImage(*targetDesc), filepath.Join(tmpDir, "features"), opt.Features); err != nil {
+			return report, errors.Wrap(err, "verify RAFS features")
+		}
+	}
+	if err := pushTarget(ctx, pvd, convertTarget, *convertedDesc, *targetDesc, opt.Target); err != nil {
+		return report, err
+	}
+	if opt.Hooks != nil && opt.Hooks.PostPush != nil {
+		event := HookEvent{Stage: HookStagePostPush, Source: opt.Source, Target: opt.Target, Image: targetDesc}
+		if err := opt.Hooks.PostPush(ctx, event); err != nil {
+			return report, errors.Wrap(err, "post-push hook")
+		}
+	}
+
+	if cacheStore != nil {
+		// The target image is usable without build cache, so don't fail
+		// the conversion.
+		cacheCtx, span := tracing.Start(ctx, "cache.update")
+		err := harvestBuildCache(cacheCtx, pvd, cacheStore, convertSource, convertTarget, opt)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to update build cache %s", opt.CacheRef)
+		} else if err = pruneBuildCache(cacheCtx, cacheStore, opt); err != nil {
+			logrus.WithError(err).Warnf("failed to prune build cache %s", opt.CacheRef)
+		}
+		tracing.End(span, err)
+	}
+
+	// The uncompressed size of source layers is only analyzed for the JSON
+	// report, the layers aren't in content store in streaming conversion.
+	// The OCI tail layers aren't converted, so they aren't analyzed.
+	sizes, err := analyzeSize(ctx, pvd, convertSource, convertTarget, opt.ChunkDictRef, opt.OutputJSON != "" && !opt.Stream)
+	if err != nil {
+		logrus.WithError(err).Warn("failed to analyze image size")
+	}
+	report.SizeAnalysis = sizes
+	for _, size := range sizes {
+		if size.DedupRatio > 0 {
+			logrus.Infof("reused %.1f%% of blob data from chunk dict for platform %q", size.DedupRatio*100, size.Platform)
+		}
+	}
+
+	if opt.CopyReferrers {
+		if err := copyReferrers(ctx, pvd, source, opt.Target); err != nil {
+			return report, errors.Wrap(err, "copy referrers")
+		}
+	}
+
+	if opt.SBOMFormat != "" {
+		if err := attachSBOM(ctx, pvd, source, opt.Target, opt.SBOMFormat); err != nil {
+			return report, errors.Wrap(err, "attach SBOM")
+		}
+	}
+
+	if opt.FileDigests {
+		if err := attachFileDigests(ctx, pvd, opt.Target, filepath.Join(tmpDir, "file_digests"), opt.FileDigestMinSize); err != nil {
+			return report, errors.Wrap(err, "attach file digests")
+		}
+	}
+
+	if opt.shouldSign() {
+		if err := signImage(ctx, pvd, opt); err != nil {
+			return report, errors.Wrap(err, "sign target image")
+		}
+	}
+
+	if opt.PreheatDragonflyEndpoint != "" {
+		// The target image is usable without preheat, so don't fail the
+		// conversion.
+		if err := preheatImage(ctx, opt); err != nil {
+			logrus.WithError(err).Warnf("failed to preheat target image %s", opt.Target)
+		}
+	}
+
+	return report, nil
 }
 
 func convertModelFile(ctx context.Context, opt Opt) error {
@@ -167,11 +851,16 @@ func convertModelFile(ctx context.Context, opt Opt) error {
 			return errors.Wrap(err, "stat work directory")
 		}
 	}
-	tmpDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-")
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
 	if err != nil {
 		return errors.Wrap(err, "create temp directory")
 	}
 	defer os.RemoveAll(tmpDir)
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	attributesPath := filepath.Join(tmpDir, ".nydusattributes")
 	backendMetaPath := filepath.Join(tmpDir, ".backend.meta")
 	backendConfigPath := filepath.Join(tmpDir, ".backend.json")
@@ -238,11 +927,16 @@ func convertModelArtifact(ctx context.Context, opt Opt) error {
 			return errors.Wrap(err, "stat work directory")
 		}
 	}
-	tmpDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-")
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
 	if err != nil {
 		return errors.Wrap(err, "create temp directory")
 	}
 	defer os.RemoveAll(tmpDir)
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	contextDir, err := os.MkdirTemp(tmpDir, "context-")
 	if err != nil {
 		return errors.Wrap(err, "create temp directory")
@@ -5,8 +5,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	snapConv "github.com/BraveY/snapshotter-converter/converter"
@@ -14,10 +18,14 @@ import (
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	accelremote "github.com/goharbor/acceleration-service/pkg/remote"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/external/modctl"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
 	pkgPvd "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
@@ -453,7 +461,7 @@ func TestPushManifest(t *testing.T) {
 		})
 		defer makeDescPatches.Reset()
 
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return nil, errors.New("default remote failed mock error")
 		})
 		defer defaultRemotePatches.Reset()
@@ -467,7 +475,7 @@ func TestPushManifest(t *testing.T) {
 		})
 		defer makeDescPatches.Reset()
 
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -486,7 +494,7 @@ func TestPushManifest(t *testing.T) {
 		})
 		defer makeDescPatches.Reset()
 
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -506,7 +514,7 @@ func TestPushManifest(t *testing.T) {
 		})
 		defer makeDescPatches.Reset()
 
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -536,7 +544,7 @@ func TestPushManifest(t *testing.T) {
 		})
 		defer makeDescPatches.Reset()
 
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -564,7 +572,7 @@ func TestPushManifest(t *testing.T) {
 func TestGetSourceManifestSubject(t *testing.T) {
 	remoter := &remote.Remote{}
 	t.Run("Run default remote failed", func(t *testing.T) {
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return nil, errors.New("default remote failed mock error")
 		})
 		defer defaultRemotePatches.Reset()
@@ -573,7 +581,7 @@ func TestGetSourceManifestSubject(t *testing.T) {
 	})
 
 	t.Run("Run resolve failed", func(t *testing.T) {
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -587,7 +595,7 @@ func TestGetSourceManifestSubject(t *testing.T) {
 	})
 
 	t.Run("Run normal", func(t *testing.T) {
-		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.DefaultRemote, func(string, bool) (*remote.Remote, error) {
+		defaultRemotePatches := gomonkey.ApplyFunc(pkgPvd.NewRemote, func(string, remote.TransportOption, pkgPvd.CredentialOption) (*remote.Remote, error) {
 			return remoter, nil
 		})
 		defer defaultRemotePatches.Reset()
@@ -602,3 +610,55 @@ func TestGetSourceManifestSubject(t *testing.T) {
 	})
 
 }
+
+func TestPushTarget(t *testing.T) {
+	var mutex sync.Mutex
+	pushes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && strings.Contains(r.URL.Path, "/blobs/"):
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/"):
+			mutex.Lock()
+			pushes++
+			mutex.Unlock()
+			dgst, _ := digest.FromReader(r.Body)
+			w.Header().Set("Docker-Content-Digest", dgst.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	hosts := func(string) (accelremote.CredentialFunc, bool, error) {
+		return func(string) (string, string, error) { return "", "", nil }, true, nil
+	}
+	pvd, err := provider.New(t.TempDir(), hosts, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+	pvd.UsePlainHTTP()
+	pvd.SetPushRetryConfig(1, 0)
+
+	configDesc := writeTestJSON(t, store, ocispec.Image{}, ocispec.MediaTypeImageConfig)
+	manifestDesc := writeTestJSON(t, store, ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Config: configDesc}, ocispec.MediaTypeImageManifest)
+	target := strings.TrimPrefix(server.URL, "http://") + "/library/busybox:nydus"
+
+	// The converted image is pushed to local target, so it's pushed to
+	// target only once after the metadata is preserved.
+	opt := Opt{Target: target}
+	require.True(t, rewritesTarget(opt))
+	convertTarget := pvd.AddLocalTarget("converted")
+	require.NoError(t, pvd.Push(ctx, manifestDesc, convertTarget))
+	require.Equal(t, 0, pushes)
+	require.NoError(t, pushTarget(ctx, pvd, convertTarget, manifestDesc, manifestDesc, opt.Target))
+	require.Equal(t, 1, pushes)
+
+	// The image pushed to target by converter isn't pushed again.
+	opt = Opt{Target: target, WithReferrer: true}
+	require.False(t, rewritesTarget(opt))
+	require.NoError(t, pushTarget(ctx, pvd, opt.Target, manifestDesc, manifestDesc, opt.Target))
+	require.Equal(t, 1, pushes)
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+// targetChunkDict returns the nydus image existing at target reference,
+// pinned by digest, to be used as chunk dict, so that the chunks already
+// pushed to target repository are reused instead of being pushed again.
+// It returns empty if there is no nydus image at target reference.
+func targetChunkDict(ctx context.Context, pvd *provider.Provider, target string) (string, error) {
+	named, err := reference.ParseDockerRef(target)
+	if err != nil {
+		return "", err
+	}
+	desc, manifests, err := remoteManifests(ctx, pvd, target)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, manifest := range manifests {
+		if isNydusManifest(manifest) {
+			return named.Name() + "@" + desc.Digest.String(), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,240 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/platforms"
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/containerd/stargz-snapshotter/estargz/zstdchunked"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SourceFormatOCI         = "oci"
+	SourceFormatEStargz     = "estargz"
+	SourceFormatZstdChunked = "zstdchunked"
+)
+
+// estargzDecompressors are tried in order to parse the footer of eStargz
+// layer, the legacy stargz layers are also accepted.
+var estargzDecompressors = []estargz.Decompressor{
+	&estargz.GzipDecompressor{},
+	&estargz.LegacyGzipDecompressor{},
+}
+
+// zstdChunkedDecompressors parse the zstd:chunked layers, whose TOC is in
+// the same format as eStargz but stored in a zstd skippable frame.
+var zstdChunkedDecompressors = []estargz.Decompressor{
+	&zstdchunked.Decompressor{},
+}
+
+// tocDecompressors returns the decompressors to parse the TOC of source
+// layers in format, or nil if the format has no TOC.
+func tocDecompressors(format string) []estargz.Decompressor {
+	switch format {
+	case SourceFormatEStargz:
+		return estargzDecompressors
+	case SourceFormatZstdChunked:
+		return zstdChunkedDecompressors
+	default:
+		return nil
+	}
+}
+
+// applyEStargzPrefetch reads the TOC of the eStargz or zstd:chunked layers
+// in source image, and uses the files prioritized by the prefetch landmark
+// as the prefetch patterns of nydus image, unless the prefetch patterns are
+// specified by user explicitly.
+func applyEStargzPrefetch(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer, opt *Opt) error {
+	files, err := estargzPrefetchFiles(ctx, store, image, platformMC, tocDecompressors(opt.SourceFormat))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		logrus.Infof("no prefetch landmark found in %s layers", opt.SourceFormat)
+		return nil
+	}
+	if opt.PrefetchPatterns != "" && opt.PrefetchPatterns != "/" {
+		logrus.Infof("ignore %d prefetch files of %s layers, use the specified prefetch patterns", len(files), opt.SourceFormat)
+		return nil
+	}
+	logrus.Infof("use %d prefetch files of %s layers as prefetch patterns", len(files), opt.SourceFormat)
+	opt.PrefetchPatterns = strings.Join(files, "\n")
+	return nil
+}
+
+func estargzPrefetchFiles(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer, decompressors []estargz.Decompressor) ([]string, error) {
+	layers, err := imageLayers(ctx, store, image, platformMC)
+	if err != nil {
+		return nil, errors.Wrap(err, "get layers of source image")
+	}
+
+	var files []string
+	seenLayers := make(map[digest.Digest]bool)
+	seenFiles := make(map[string]bool)
+	for _, layer := range layers {
+		if seenLayers[layer.Digest] {
+			continue
+		}
+		seenLayers[layer.Digest] = true
+
+		toc, err := readLayerTOC(ctx, store, layer, decompressors)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read TOC of layer %s", layer.Digest)
+		}
+		for _, file := range prefetchFilesFromTOC(toc) {
+			if !seenFiles[file] {
+				seenFiles[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// imageLayers returns the layers of the manifests matching the platforms.
+func imageLayers(ctx context.Context, store content.Store, desc ocispec.Descriptor, platformMC platforms.MatchComparer) ([]ocispec.Descriptor, error) {
+	manifests, err := matchedManifests(ctx, store, desc, platformMC)
+	if err != nil {
+		return nil, err
+	}
+	var layers []ocispec.Descriptor
+	for _, manifest := range manifests {
+		layers = append(layers, manifest.Layers...)
+	}
+	return layers, nil
+}
+
+// matchedManifests returns the manifests matching the platforms.
+func matchedManifests(ctx context.Context, store content.Store, desc ocispec.Descriptor, platformMC platforms.MatchComparer) ([]ocispec.Manifest, error) {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, images.MediaTypeDockerSchema2ManifestList:
+		data, err := content.ReadBlob(ctx, store, desc)
+		if err != nil {
+			return nil, errors.Wrap(err, "read index")
+		}
+		var index ocispec.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, errors.Wrap(err, "unmarshal index")
+		}
+		var manifests []ocispec.Manifest
+		for _, manifest := range index.Manifests {
+			if manifest.Platform != nil && !platformMC.Match(*manifest.Platform) {
+				continue
+			}
+			matched, err := matchedManifests(ctx, store, manifest, platformMC)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, matched...)
+		}
+		return manifests, nil
+	case ocispec.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest:
+		data, err := content.ReadBlob(ctx, store, desc)
+		if err != nil {
+			return nil, errors.Wrap(err, "read manifest")
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, errors.Wrap(err, "unmarshal manifest")
+		}
+		return []ocispec.Manifest{manifest}, nil
+	default:
+		return nil, errors.Errorf("unsupported media type %s", desc.MediaType)
+	}
+}
+
+func readLayerTOC(ctx context.Context, store content.Store, layer ocispec.Descriptor, decompressors []estargz.Decompressor) (*estargz.JTOC, error) {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return nil, errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+	return readTOC(ra, ra.Size(), decompressors)
+}
+
+// checkZstdChunkedAnnotations warns about the source layers without the
+// zstd:chunked annotations, podman and CRI-O fall back to pull these layers
+// entirely.
+func checkZstdChunkedAnnotations(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer) error {
+	layers, err := imageLayers(ctx, store, image, platformMC)
+	if err != nil {
+		return errors.Wrap(err, "get layers of source image")
+	}
+	for _, layer := range layersWithoutZstdChunkedAnnotations(layers) {
+		logrus.Warnf("layer %s has no zstd:chunked annotations, it will be pulled entirely by podman", layer)
+	}
+	return nil
+}
+
+func layersWithoutZstdChunkedAnnotations(layers []ocispec.Descriptor) []digest.Digest {
+	var missing []digest.Digest
+	for _, layer := range layers {
+		if layer.Annotations[zstdchunked.ManifestChecksumAnnotation] == "" ||
+			layer.Annotations[zstdchunked.ManifestPositionAnnotation] == "" {
+			missing = append(missing, layer.Digest)
+		}
+	}
+	return missing
+}
+
+// readTOC parses the footer at the end of layer to locate the TOC, only
+// the footer and TOC are read, so it's cheap for a remote layer.
+func readTOC(ra io.ReaderAt, size int64, decompressors []estargz.Decompressor) (*estargz.JTOC, error) {
+	for _, decompressor := range decompressors {
+		footerSize := decompressor.FooterSize()
+		if size < footerSize {
+			continue
+		}
+		footer := make([]byte, footerSize)
+		if _, err := ra.ReadAt(footer, size-footerSize); err != nil && err != io.EOF {
+			return nil, errors.Wrap(err, "read footer")
+		}
+		_, tocOffset, tocSize, err := decompressor.ParseFooter(footer)
+		if err != nil || tocOffset < 0 || tocOffset > size-footerSize {
+			continue
+		}
+		if tocSize <= 0 {
+			tocSize = size - footerSize - tocOffset
+		}
+		toc, _, err := decompressor.ParseTOC(io.NewSectionReader(ra, tocOffset, tocSize))
+		if err != nil {
+			return nil, errors.Wrap(err, "parse TOC")
+		}
+		return toc, nil
+	}
+	return nil, errors.New("no valid footer found, the layer is not in eStargz or zstd:chunked format")
+}
+
+// prefetchFilesFromTOC returns the regular files placed before the prefetch
+// landmark, there is no file to prefetch if the layer has no landmark or
+// has the no-prefetch landmark.
+func prefetchFilesFromTOC(toc *estargz.JTOC) []string {
+	var files []string
+	for _, entry := range toc.Entries {
+		switch path.Clean("/" + entry.Name) {
+		case "/" + estargz.PrefetchLandmark:
+			return files
+		case "/" + estargz.NoPrefetchLandmark:
+			return nil
+		}
+		if entry.Type == "reg" {
+			files = append(files, path.Clean("/"+entry.Name))
+		}
+	}
+	return nil
+}
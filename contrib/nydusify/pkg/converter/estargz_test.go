@@ -0,0 +1,170 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/containerd/stargz-snapshotter/estargz/zstdchunked"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestLayer builds a minimal eStargz layer consisting of a payload,
+// the TOC and the footer.
+func buildTestLayer(t *testing.T, toc *estargz.JTOC) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("payload"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	tocOffset := buf.Len()
+	tocJSON, err := json.Marshal(toc)
+	require.NoError(t, err)
+	gz = gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     estargz.TOCTarName,
+		Mode:     0644,
+		Size:     int64(len(tocJSON)),
+	}))
+	_, err = tw.Write(tocJSON)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	// The footer is an empty gzip member, whose extra field records the
+	// TOC offset.
+	extra := []byte(fmt.Sprintf("SG\x16\x00%016xSTARGZ", tocOffset))
+	footer := []byte{0x1f, 0x8b, 0x08, 0x04, 0, 0, 0, 0, 0, 0xff}
+	footer = binary.LittleEndian.AppendUint16(footer, uint16(len(extra)))
+	footer = append(footer, extra...)
+	footer = append(footer, 0x01, 0x00, 0x00, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0)
+	require.Len(t, footer, estargz.FooterSize)
+
+	return append(buf.Bytes(), footer...)
+}
+
+// buildTestZstdChunkedLayer builds a minimal zstd:chunked layer and returns
+// it with the layer annotations.
+func buildTestZstdChunkedLayer(t *testing.T, toc *estargz.JTOC) ([]byte, map[string]string) {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = zw.Write([]byte("payload"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	annotations := map[string]string{}
+	compressor := &zstdchunked.Compressor{CompressionLevel: zstd.SpeedDefault, Metadata: annotations}
+	_, err = compressor.WriteTOCAndFooter(&buf, int64(buf.Len()), toc, nil)
+	require.NoError(t, err)
+
+	return buf.Bytes(), annotations
+}
+
+func TestReadTOC(t *testing.T) {
+	toc := &estargz.JTOC{
+		Version: 1,
+		Entries: []*estargz.TOCEntry{
+			{Name: "libc", Type: "reg", Size: 4},
+			{Name: "hosts", Type: "reg", Size: 19},
+			{Name: estargz.PrefetchLandmark, Type: "reg", Size: 1},
+			{Name: "sh", Type: "reg", Size: 5},
+		},
+	}
+	data := buildTestLayer(t, toc)
+	parsed, err := readTOC(bytes.NewReader(data), int64(len(data)), estargzDecompressors)
+	require.NoError(t, err)
+	require.Len(t, parsed.Entries, 4)
+	require.Equal(t, []string{"/libc", "/hosts"}, prefetchFilesFromTOC(parsed))
+
+	// The plain gzip layer is not in eStargz format.
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(bytes.Repeat([]byte("data"), 100))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	_, err = readTOC(bytes.NewReader(buf.Bytes()), int64(buf.Len()), estargzDecompressors)
+	require.Error(t, err)
+
+	_, err = readTOC(bytes.NewReader(nil), 0, estargzDecompressors)
+	require.Error(t, err)
+}
+
+func TestPrefetchFilesFromTOC(t *testing.T) {
+	toc := &estargz.JTOC{Entries: []*estargz.TOCEntry{
+		{Name: "bin/", Type: "dir"},
+		{Name: "bin/sh", Type: "reg"},
+		{Name: "bin/sh", Type: "chunk"},
+		{Name: "lib/libc", Type: "symlink"},
+		{Name: estargz.PrefetchLandmark, Type: "reg"},
+		{Name: "etc/hosts", Type: "reg"},
+	}}
+	require.Equal(t, []string{"/bin/sh"}, prefetchFilesFromTOC(toc))
+
+	toc.Entries = toc.Entries[:4]
+	require.Empty(t, prefetchFilesFromTOC(toc))
+}
+
+func TestReadZstdChunkedTOC(t *testing.T) {
+	toc := &estargz.JTOC{
+		Version: 1,
+		Entries: []*estargz.TOCEntry{
+			{Name: "usr/bin/podman", Type: "reg", Size: 7},
+			{Name: estargz.PrefetchLandmark, Type: "reg", Size: 1},
+			{Name: "etc/hosts", Type: "reg", Size: 19},
+		},
+	}
+	data, annotations := buildTestZstdChunkedLayer(t, toc)
+	require.NotEmpty(t, annotations[zstdchunked.ManifestChecksumAnnotation])
+	require.NotEmpty(t, annotations[zstdchunked.ManifestPositionAnnotation])
+
+	parsed, err := readTOC(bytes.NewReader(data), int64(len(data)), tocDecompressors(SourceFormatZstdChunked))
+	require.NoError(t, err)
+	require.Len(t, parsed.Entries, 3)
+	require.Equal(t, []string{"/usr/bin/podman"}, prefetchFilesFromTOC(parsed))
+
+	// The eStargz layer is not in zstd:chunked format, and vice versa.
+	estargzData := buildTestLayer(t, toc)
+	_, err = readTOC(bytes.NewReader(estargzData), int64(len(estargzData)), tocDecompressors(SourceFormatZstdChunked))
+	require.Error(t, err)
+	_, err = readTOC(bytes.NewReader(data), int64(len(data)), tocDecompressors(SourceFormatEStargz))
+	require.Error(t, err)
+
+	require.Nil(t, tocDecompressors(SourceFormatOCI))
+}
+
+func TestLayersWithoutZstdChunkedAnnotations(t *testing.T) {
+	annotated := ocispec.Descriptor{
+		Digest: digest.FromString("annotated"),
+		Annotations: map[string]string{
+			zstdchunked.ManifestChecksumAnnotation: digest.FromString("toc").String(),
+			zstdchunked.ManifestPositionAnnotation: "100:20:40:1",
+		},
+	}
+	partial := ocispec.Descriptor{
+		Digest: digest.FromString("partial"),
+		Annotations: map[string]string{
+			zstdchunked.ManifestChecksumAnnotation: digest.FromString("toc").String(),
+		},
+	}
+	plain := ocispec.Descriptor{Digest: digest.FromString("plain")}
+
+	require.Empty(t, layersWithoutZstdChunkedAnnotations([]ocispec.Descriptor{annotated}))
+	require.Equal(t, []digest.Digest{partial.Digest, plain.Digest},
+		layersWithoutZstdChunkedAnnotations([]ocispec.Descriptor{annotated, partial, plain}))
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+const (
+	FeatureBlobToc           = "blob-toc"
+	FeatureInlineChunkDigest = "inline-chunk-digest"
+	FeatureBatch             = "batch"
+)
+
+// The blob features consistent with `BlobFeatures` in
+// `storage/src/device.rs`.
+const (
+	blobFeatureBatch  = 0x00000080
+	blobFeatureHasToc = 0x20000000
+)
+
+// rafsFeature is the RAFS v6 feature of the image built by nydus-image.
+type rafsFeature struct {
+	// keyword is in the help of `nydus-image create` if the feature is
+	// supported by nydus-image.
+	keyword string
+	// args are the options of `nydus-image create` enabling the feature,
+	// they are passed by the builder wrapper unless the converter driver
+	// has passed the option.
+	args []string
+	// enabled reports whether the feature is enabled in bootstrap.
+	enabled func(bootstrap *rafs.Bootstrap) bool
+}
+
+var rafsFeatures = map[string]rafsFeature{
+	// The blobs have the table of content at the tail, to locate the
+	// bootstrap and chunk info in blob.
+	FeatureBlobToc: {
+		keyword: "blob-toc",
+		args:    []string{"--features", "blob-toc"},
+		enabled: func(bootstrap *rafs.Bootstrap) bool {
+			return allBlobs(bootstrap, blobFeatureHasToc)
+		},
+	},
+	// The chunk digests are inlined in the blob meta, so the chunks can
+	// be verified without the bootstrap. The converter driver always builds
+	// blobs with inlined meta, so no option is passed.
+	FeatureInlineChunkDigest: {
+		keyword: "blob-inline-meta",
+		enabled: func(bootstrap *rafs.Bootstrap) bool {
+			for _, feature := range bootstrap.Features {
+				if feature == "INLINED_CHUNK_DIGEST" {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	// The small chunks are merged into batch chunks to be compressed
+	// together. The converter driver passes `--batch-size` of the batch
+	// size option.
+	FeatureBatch: {
+		keyword: "--batch-size",
+		enabled: func(bootstrap *rafs.Bootstrap) bool {
+			return allBlobs(bootstrap, blobFeatureBatch)
+		},
+	},
+}
+
+func allBlobs(bootstrap *rafs.Bootstrap, feature uint32) bool {
+	for _, blob := range bootstrap.Blobs {
+		if blob.Features&feature == 0 {
+			return false
+		}
+	}
+	return len(bootstrap.Blobs) > 0
+}
+
+// FeatureNames returns the names of supported RAFS features.
+func FeatureNames() []string {
+	var names []string
+	for name := range rafsFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkFeatures checks the features required for the target image against
+// the options and the help of `nydus-image create`, so that the conversion
+// fails early if nydus-image is too old to build them.
+func checkFeatures(ctx context.Context, opt Opt) error {
+	for _, name := range opt.Features {
+		if _, ok := rafsFeatures[name]; !ok {
+			return fmt.Errorf("unknown RAFS feature '%s', possible values: %v", name, FeatureNames())
+		}
+		switch {
+		case opt.FsVersion != "6":
+			return fmt.Errorf("RAFS feature %s requires RAFS v6", name)
+		case name == FeatureBlobToc && opt.OCIRef:
+			return fmt.Errorf("RAFS feature %s is not supported with OCI ref", name)
+		case name == FeatureBatch && (opt.BatchSize == "" || opt.BatchSize == "0"):
+			return fmt.Errorf("RAFS feature %s requires batch size", name)
+		}
+	}
+	if len(opt.Features) == 0 {
+		return nil
+	}
+
+	builder := builderPath(opt)
+	help, err := exec.CommandContext(ctx, builder, "create", "--help").CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "get help of %s create", builder)
+	}
+	for _, name := range opt.Features {
+		if !strings.Contains(string(help), rafsFeatures[name].keyword) {
+			return fmt.Errorf("RAFS feature %s is not supported by %s, please upgrade it to the latest release", name, builder)
+		}
+	}
+	return nil
+}
+
+func builderPath(opt Opt) string {
+	if opt.NydusImagePath == "" {
+		return "nydus-image"
+	}
+	return opt.NydusImagePath
+}
+
+// wrapBuilder writes a script into workDir which runs `nydus-image create`
+// with the options enabling the required features, since the converter
+// driver can't pass extra options to the builder. The path of the script
+// is returned to be used as builder.
+func wrapBuilder(workDir string, opt Opt) (string, error) {
+	var script strings.Builder
+	script.WriteString(`#!/bin/sh
+has() {
+	opt=$1
+	shift
+	for arg in "$@"; do
+		[ "$arg" = "$opt" ] && return 0
+	done
+	return 1
+}
+if [ "$1" = "create" ]; then
+	shift
+`)
+	for _, name := range opt.Features {
+		if args := rafsFeatures[name].args; len(args) > 0 {
+			fmt.Fprintf(&script, "\thas %s \"$@\" || set -- %s \"$@\"\n", shellQuote(args[0]), shellQuoteAll(args))
+		}
+	}
+	fmt.Fprintf(&script, "\tset -- create \"$@\"\nfi\nexec %s \"$@\"\n", shellQuote(builderPath(opt)))
+
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return "", errors.Wrap(err, "create work directory")
+	}
+	path := filepath.Join(workDir, "nydus-image")
+	if err := os.WriteFile(path, []byte(script.String()), 0755); err != nil {
+		return "", errors.Wrap(err, "write builder wrapper")
+	}
+	return path, nil
+}
+
+func shellQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func shellQuoteAll(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// verifyFeatures checks the features required are enabled in the bootstrap
+// of each nydus manifest of target image.
+func verifyFeatures(ctx context.Context, pvd *provider.Provider, target, workDir string, features []string) error {
+	return walkBootstraps(ctx, pvd, target, workDir, func(manifest platformManifest, bootstrap *rafs.Bootstrap) error {
+		for _, name := range features {
+			if !rafsFeatures[name].enabled(bootstrap) {
+				return fmt.Errorf("RAFS feature %s is not enabled in %s", name, manifest.desc.Digest)
+			}
+		}
+		logrus.Infof("verified RAFS features %v of %s", features, manifest.desc.Digest)
+		return nil
+	})
+}
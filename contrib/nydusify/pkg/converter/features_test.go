@@ -0,0 +1,80 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func TestCheckFeatures(t *testing.T) {
+	ctx := context.Background()
+	builder := filepath.Join(t.TempDir(), "nydus-image")
+	require.NoError(t, os.WriteFile(builder, []byte("#!/bin/sh\necho '--blob-inline-meta --features <blob-toc>'\n"), 0755))
+	opt := Opt{NydusImagePath: builder, FsVersion: "6"}
+
+	require.NoError(t, checkFeatures(ctx, opt))
+	opt.Features = []string{FeatureBlobToc, FeatureInlineChunkDigest}
+	require.NoError(t, checkFeatures(ctx, opt))
+
+	opt.Features = []string{"amplify-io"}
+	require.ErrorContains(t, checkFeatures(ctx, opt), "unknown RAFS feature 'amplify-io'")
+
+	// The batch chunk isn't supported by the nydus-image.
+	opt.Features = []string{FeatureBatch}
+	require.ErrorContains(t, checkFeatures(ctx, opt), "requires batch size")
+	opt.BatchSize = "0x100000"
+	require.ErrorContains(t, checkFeatures(ctx, opt), "not supported by "+builder)
+
+	opt.Features = []string{FeatureBlobToc}
+	opt.OCIRef = true
+	require.ErrorContains(t, checkFeatures(ctx, opt), "not supported with OCI ref")
+	opt.OCIRef = false
+	opt.FsVersion = "5"
+	require.ErrorContains(t, checkFeatures(ctx, opt), "requires RAFS v6")
+}
+
+func TestWrapBuilder(t *testing.T) {
+	builder := filepath.Join(t.TempDir(), "nydus-image")
+	require.NoError(t, os.WriteFile(builder, []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+	opt := Opt{NydusImagePath: builder, Features: []string{FeatureBlobToc, FeatureInlineChunkDigest, FeatureBatch}}
+
+	wrapper, err := wrapBuilder(t.TempDir(), opt)
+	require.NoError(t, err)
+	run := func(args ...string) string {
+		output, err := exec.Command(wrapper, args...).CombinedOutput()
+		require.NoError(t, err)
+		return string(output)
+	}
+	require.Equal(t, "create --features blob-toc --batch-size 0x100000 /source\n", run("create", "--batch-size", "0x100000", "/source"))
+	// The option passed by the converter driver isn't duplicated.
+	require.Equal(t, "create --type tar-rafs --features blob-toc /source\n", run("create", "--type", "tar-rafs", "--features", "blob-toc", "/source"))
+	require.Equal(t, "merge --bootstrap a b\n", run("merge", "--bootstrap", "a", "b"))
+}
+
+func TestRafsFeatures(t *testing.T) {
+	bootstrap := &rafs.Bootstrap{
+		Features: []string{"HASH_BLAKE3", "INLINED_CHUNK_DIGEST"},
+		Blobs: []rafs.Blob{
+			{ID: "a", Features: blobFeatureHasToc | blobFeatureBatch},
+			{ID: "b", Features: blobFeatureHasToc},
+		},
+	}
+	require.True(t, rafsFeatures[FeatureBlobToc].enabled(bootstrap))
+	require.True(t, rafsFeatures[FeatureInlineChunkDigest].enabled(bootstrap))
+	require.False(t, rafsFeatures[FeatureBatch].enabled(bootstrap))
+
+	bootstrap = &rafs.Bootstrap{}
+	for _, name := range FeatureNames() {
+		require.False(t, rafsFeatures[name].enabled(bootstrap), name)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// MediaTypeFileDigests is the media type of the file digests artifact
+// attached to nydus image.
+const MediaTypeFileDigests = "application/vnd.nydus.file-digests.v1+json"
+
+// fileDigestsVersion is the version of file digests format.
+const fileDigestsVersion = 1
+
+// fileDigests is the content-addressed metadata of the regular files in a
+// nydus image, the runtimes with a local content-addressed cache look up
+// the files by digest to share the identical files across images.
+type fileDigests struct {
+	Version int `json:"version"`
+	// Digester and ChunkSize are of the chunks in bootstrap, the file
+	// digests are only comparable if both are the same.
+	Digester  string       `json:"digester"`
+	ChunkSize uint32       `json:"chunk_size"`
+	Files     []fileDigest `json:"files"`
+}
+
+type fileDigest struct {
+	Path   string        `json:"path"`
+	Size   uint64        `json:"size"`
+	Chunks uint64        `json:"chunks"`
+	Digest digest.Digest `json:"digest"`
+}
+
+// bootstrapFileDigests returns the digests of regular files not smaller
+// than minSize in bootstrap. The file digest is the sha256 of file size and
+// chunk digests in order, so the files of identical content have the same
+// digest without reading the blobs.
+func bootstrapFileDigests(bootstrap *rafs.Bootstrap, minSize int64) *fileDigests {
+	digests := &fileDigests{
+		Version:   fileDigestsVersion,
+		Digester:  bootstrap.Digester,
+		ChunkSize: bootstrap.ChunkSize,
+		Files:     []fileDigest{},
+	}
+	for _, file := range bootstrap.Files {
+		if !file.FileMode().IsRegular() || file.Size == 0 || file.Size < uint64(minSize) {
+			continue
+		}
+		// The chunks not recorded in the chunk table can't be digested.
+		if uint64(len(file.ChunkInfos)) != file.Chunks {
+			continue
+		}
+		digester := digest.SHA256.Digester()
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], file.Size)
+		digester.Hash().Write(size[:])
+		for _, chunk := range file.ChunkInfos {
+			digester.Hash().Write(chunk.Digest[:])
+		}
+		digests.Files = append(digests.Files, fileDigest{
+			Path:   file.Path,
+			Size:   file.Size,
+			Chunks: file.Chunks,
+			Digest: digester.Digest(),
+		})
+	}
+	return digests
+}
+
+// attachFileDigests generates the file digests from the bootstrap of each
+// nydus manifest of target image kept in content store, and attaches them
+// to the manifest as a referrer artifact.
+func attachFileDigests(ctx context.Context, pvd *provider.Provider, target, workDir string, minSize int64) error {
+	return walkBootstraps(ctx, pvd, target, workDir, func(manifest platformManifest, bootstrap *rafs.Bootstrap) error {
+		data, err := json.Marshal(bootstrapFileDigests(bootstrap, minSize))
+		if err != nil {
+			return errors.Wrap(err, "marshal file digests")
+		}
+		desc, err := writeArtifact(ctx, pvd.ContentStore(), MediaTypeFileDigests, data, manifest.desc)
+		if err != nil {
+			return errors.Wrap(err, "write file digests artifact")
+		}
+		if err := pvd.PushReferrer(ctx, target, *desc); err != nil {
+			return errors.Wrap(err, "push file digests artifact")
+		}
+		logrus.Infof("attached file digests %s to %s", desc.Digest, manifest.desc.Digest)
+		return nil
+	})
+}
+
+// walkBootstraps calls fn with the bootstrap of each nydus manifest of
+// target image kept in content store, the bootstraps are unpacked into
+// workDir, which is removed at last.
+func walkBootstraps(ctx context.Context, pvd *provider.Provider, target, workDir string, fn func(manifest platformManifest, bootstrap *rafs.Bootstrap) error) error {
+	store := pvd.ContentStore()
+	targetDesc, err := pvd.Image(ctx, target)
+	if err != nil {
+		return errors.Wrap(err, "get target image")
+	}
+	manifests, err := platformManifests(ctx, store, *targetDesc)
+	if err != nil {
+		return errors.Wrap(err, "read target manifests")
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return errors.Wrap(err, "create work directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	for _, manifest := range manifests {
+		var bootstrapLayer *ocispec.Descriptor
+		for idx, layer := range manifest.manifest.Layers {
+			if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" {
+				bootstrapLayer = &manifest.manifest.Layers[idx]
+			}
+		}
+		// Skip the source manifests kept by `--merge-platform`.
+		if bootstrapLayer == nil {
+			continue
+		}
+
+		bootstrapPath := filepath.Join(workDir, manifest.desc.Digest.Encoded())
+		if err := unpackBootstrap(ctx, store, *bootstrapLayer, bootstrapPath); err != nil {
+			return errors.Wrapf(err, "unpack bootstrap of %s", manifest.desc.Digest)
+		}
+		bootstrap, err := rafs.Load(bootstrapPath)
+		if err != nil {
+			return errors.Wrapf(err, "load bootstrap of %s", manifest.desc.Digest)
+		}
+		if err := fn(manifest, bootstrap); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unpackBootstrap(ctx context.Context, store content.Store, layer ocispec.Descriptor, path string) error {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+	return utils.UnpackFile(content.NewReader(ra), utils.BootstrapFileNameInLayer, path)
+}
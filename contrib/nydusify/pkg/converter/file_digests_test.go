@@ -0,0 +1,57 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func TestBootstrapFileDigests(t *testing.T) {
+	chunk := func(b byte) rafs.Chunk {
+		return rafs.Chunk{Digest: [32]byte{b}}
+	}
+	bootstrap := &rafs.Bootstrap{
+		Digester:  "blake3",
+		ChunkSize: 0x100000,
+		Files: []rafs.File{
+			{Path: "/", Mode: 0o40755},
+			{Path: "/a", Mode: 0o100644, Size: 0x180000, Chunks: 2, ChunkInfos: []rafs.Chunk{chunk(1), chunk(2)}},
+			{Path: "/b", Mode: 0o100755, Size: 0x180000, Chunks: 2, ChunkInfos: []rafs.Chunk{chunk(1), chunk(2)}},
+			{Path: "/c", Mode: 0o100644, Size: 0x180000, Chunks: 2, ChunkInfos: []rafs.Chunk{chunk(2), chunk(1)}},
+			{Path: "/empty", Mode: 0o100644},
+			{Path: "/small", Mode: 0o100644, Size: 16, Chunks: 1, ChunkInfos: []rafs.Chunk{chunk(3)}},
+			{Path: "/link", Mode: 0o120777, Size: 2, Target: "/a"},
+			// The chunks are not recorded in chunk table.
+			{Path: "/unknown", Mode: 0o100644, Size: 16, Chunks: 1},
+		},
+	}
+
+	digests := bootstrapFileDigests(bootstrap, 0)
+	require.Equal(t, fileDigestsVersion, digests.Version)
+	require.Equal(t, "blake3", digests.Digester)
+	require.Equal(t, uint32(0x100000), digests.ChunkSize)
+	require.Len(t, digests.Files, 4)
+	require.Equal(t, []string{"/a", "/b", "/c", "/small"}, []string{
+		digests.Files[0].Path, digests.Files[1].Path, digests.Files[2].Path, digests.Files[3].Path,
+	})
+	// The files of identical content have the same digest regardless of
+	// their metadata, the chunks in different order don't.
+	require.Equal(t, digests.Files[0].Digest, digests.Files[1].Digest)
+	require.NotEqual(t, digests.Files[0].Digest, digests.Files[2].Digest)
+	require.Equal(t, uint64(2), digests.Files[0].Chunks)
+	require.NoError(t, digests.Files[0].Digest.Validate())
+
+	// The files smaller than the minimum size are skipped.
+	digests = bootstrapFileDigests(bootstrap, 1024)
+	require.Len(t, digests.Files, 3)
+
+	digests = bootstrapFileDigests(&rafs.Bootstrap{}, 0)
+	require.NotNil(t, digests.Files)
+	require.Empty(t, digests.Files)
+}
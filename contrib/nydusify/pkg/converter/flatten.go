@@ -0,0 +1,343 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = whiteoutPrefix + ".wh..opq"
+
+	paxSchilyXattr = "SCHILY.xattr."
+	opaqueXattr    = "trusted.overlay.opaque"
+)
+
+// checkFlatten checks the options conflicting with flattening source image,
+// which is converted from the flattened local image.
+func checkFlatten(opt Opt) error {
+	switch {
+	case opt.OCIRef:
+		return errors.New("flattening is not supported with OCI ref, which references the source layers")
+	case opt.OCITailLayers > 0:
+		return errors.New("flattening is not supported with OCI tail layers")
+	case opt.Stream:
+		return errors.New("flattening is not supported in streaming conversion")
+	}
+	return nil
+}
+
+// layerFlattener merges the layer tars from the top to the bottom into one
+// tar, the entries hidden by the upper layers are skipped, so the layers are
+// read only once except for the hard links to hidden files.
+type layerFlattener struct {
+	tw *tar.Writer
+	// emitted records the paths written with the index of layer.
+	emitted map[string]int
+	// removed records the paths removed by the whiteouts of upper layers.
+	removed map[string]bool
+	// covered records the paths whose children in lower layers are hidden,
+	// by whiteout, opaque directory or non-directory entry.
+	covered map[string]bool
+}
+
+// flattenLayers writes the tar merged from count layers opened by open in
+// order, the layer of index zero is the lowest.
+func flattenLayers(w io.Writer, count int, open func(idx int) (io.ReadCloser, error)) error {
+	f := &layerFlattener{
+		tw:      tar.NewWriter(w),
+		emitted: map[string]int{},
+		removed: map[string]bool{},
+		covered: map[string]bool{},
+	}
+	for idx := count - 1; idx >= 0; idx-- {
+		if err := f.flatten(idx, open); err != nil {
+			return errors.Wrapf(err, "flatten layer %d", idx)
+		}
+	}
+	return f.tw.Close()
+}
+
+// hidden reports whether the entry of path in lower layers is hidden.
+func (f *layerFlattener) hidden(path string) bool {
+	if _, ok := f.emitted[path]; ok || f.removed[path] {
+		return true
+	}
+	for dir := filepath.Dir(path); dir != "/"; dir = filepath.Dir(dir) {
+		if f.covered[dir] {
+			return true
+		}
+	}
+	return f.covered["/"]
+}
+
+func (f *layerFlattener) write(hdr *tar.Header, reader io.Reader) error {
+	if err := f.tw.WriteHeader(hdr); err != nil {
+		return errors.Wrapf(err, "write header of %s", hdr.Name)
+	}
+	if hdr.Typeflag == tar.TypeReg && hdr.Size > 0 {
+		if _, err := io.Copy(f.tw, reader); err != nil {
+			return errors.Wrapf(err, "write %s", hdr.Name)
+		}
+	}
+	return nil
+}
+
+func (f *layerFlattener) flatten(idx int, open func(idx int) (io.ReadCloser, error)) error {
+	reader, err := open(idx)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// The whiteouts and opaque directories only hide the lower layers.
+	var removed, opaques []string
+	// relinks are the hard links to the files hidden in this layer, keyed
+	// by the link target.
+	relinks := map[string][]*tar.Header{}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read layer tar")
+		}
+
+		path := filepath.Clean("/" + hdr.Name)
+		dir, base := filepath.Split(path)
+		dir = filepath.Clean(dir)
+		switch {
+		case base == whiteoutOpaque:
+			opaques = append(opaques, dir)
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			removed = append(removed, filepath.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		case hdr.Typeflag == tar.TypeChar && hdr.Devmajor == 0 && hdr.Devminor == 0:
+			// The whiteout in overlayfs format.
+			removed = append(removed, path)
+			continue
+		}
+		// The root directory and the others written by the upper layers
+		// are kept.
+		if f.hidden(path) {
+			continue
+		}
+		f.emitted[path] = idx
+		if hdr.Typeflag != tar.TypeDir {
+			f.covered[path] = true
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			target := filepath.Clean("/" + hdr.Linkname)
+			if from, ok := f.emitted[target]; !ok || from != idx {
+				relinks[target] = append(relinks[target], hdr)
+				continue
+			}
+		}
+		if hdr.Typeflag == tar.TypeDir && hdr.PAXRecords[paxSchilyXattr+opaqueXattr] == "y" {
+			opaques = append(opaques, path)
+		}
+		if err := f.write(hdr, tr); err != nil {
+			return err
+		}
+	}
+
+	if len(relinks) > 0 {
+		if err := f.relink(idx, open, relinks); err != nil {
+			return err
+		}
+	}
+	for _, path := range removed {
+		f.removed[path] = true
+		f.covered[path] = true
+	}
+	for _, dir := range opaques {
+		f.covered[dir] = true
+	}
+	return nil
+}
+
+// relink reads the layer again to write the files hidden in this layer, the
+// first hard link to each file takes the file content, the others link to
+// it.
+func (f *layerFlattener) relink(idx int, open func(idx int) (io.ReadCloser, error), relinks map[string][]*tar.Header) error {
+	reader, err := open(idx)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tr := tar.NewReader(reader)
+	for len(relinks) > 0 {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "read layer tar")
+		}
+		path := filepath.Clean("/" + hdr.Name)
+		links, ok := relinks[path]
+		if !ok || hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		delete(relinks, path)
+
+		file := *hdr
+		file.Name = links[0].Name
+		if err := f.write(&file, tr); err != nil {
+			return err
+		}
+		for _, link := range links[1:] {
+			link.Linkname = file.Name
+			if err := f.write(link, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for target := range relinks {
+		return fmt.Errorf("target %s of hard link %s not found", target, relinks[target][0].Name)
+	}
+	return nil
+}
+
+// flattenImage writes the local image of source image, whose layers of each
+// manifest matched by platformMC are flattened into one layer.
+func flattenImage(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer) (string, error) {
+	return rewriteManifests(ctx, pvd, source, platformMC, func(manifest platformManifest) (*ocispec.Descriptor, error) {
+		if len(manifest.manifest.Layers) < 2 {
+			return nil, nil
+		}
+		logrus.Infof("flattening %d layers of %s", len(manifest.manifest.Layers), manifest.desc.Digest)
+		desc, err := flattenManifest(ctx, pvd.ContentStore(), manifest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "flatten manifest %s", manifest.desc.Digest)
+		}
+		return desc, nil
+	})
+}
+
+// flattenManifest writes the manifest whose layers are flattened into one
+// uncompressed layer, the history is replaced by the one of flattened layer.
+func flattenManifest(ctx context.Context, store content.Store, manifest platformManifest) (*ocispec.Descriptor, error) {
+	layers := manifest.manifest.Layers
+	writer, err := content.OpenWriter(ctx, store, content.WithRef("nydusify-flatten-"+manifest.desc.Digest.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "open layer writer")
+	}
+	defer writer.Close()
+	// The layer left by an interrupted conversion is written again.
+	if err := writer.Truncate(0); err != nil {
+		return nil, errors.Wrap(err, "truncate layer writer")
+	}
+
+	digester := digest.SHA256.Digester()
+	if err := flattenLayers(io.MultiWriter(writer, digester.Hash()), len(layers), func(idx int) (io.ReadCloser, error) {
+		return openLayer(ctx, store, layers[idx])
+	}); err != nil {
+		return nil, err
+	}
+	layerDigest := digester.Digest()
+	if err := writer.Commit(ctx, 0, layerDigest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return nil, errors.Wrap(err, "commit layer")
+	}
+	info, err := store.Info(ctx, layerDigest)
+	if err != nil {
+		return nil, errors.Wrap(err, "get layer info")
+	}
+
+	configData, err := content.ReadBlob(ctx, store, manifest.manifest.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config")
+	}
+	var config ocispec.Image
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return nil, errors.Wrap(err, "unmarshal config")
+	}
+	history := []ocispec.History{{
+		Created: config.Created,
+		Comment: fmt.Sprintf("flattened from %d layers", len(layers)),
+	}}
+	if configData, err = rewriteConfig(configData, []digest.Digest{layerDigest}, history); err != nil {
+		return nil, errors.Wrap(err, "rewrite config")
+	}
+
+	mediaType := ocispec.MediaTypeImageLayer
+	if manifest.desc.MediaType == images.MediaTypeDockerSchema2Manifest {
+		mediaType = images.MediaTypeDockerSchema2Layer
+	}
+	flattened := manifest.manifest
+	flattened.Config.Digest = digest.FromBytes(configData)
+	flattened.Config.Size = int64(len(configData))
+	flattened.Layers = []ocispec.Descriptor{{
+		MediaType: mediaType,
+		Digest:    layerDigest,
+		Size:      info.Size,
+	}}
+	manifestDesc, manifestData, err := utils.MarshalToDesc(flattened, manifest.desc.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal manifest")
+	}
+	if err := writeContent(ctx, store, flattened.Config, configData); err != nil {
+		return nil, err
+	}
+	if err := writeContent(ctx, store, *manifestDesc, manifestData); err != nil {
+		return nil, err
+	}
+
+	desc := manifest.desc
+	desc.Digest = manifestDesc.Digest
+	desc.Size = manifestDesc.Size
+	return &desc, nil
+}
+
+// openLayer returns the reader of uncompressed layer tar.
+func openLayer(ctx context.Context, store content.Store, layer ocispec.Descriptor) (io.ReadCloser, error) {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open layer %s", layer.Digest)
+	}
+	ds, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		ra.Close()
+		return nil, errors.Wrapf(err, "decompress layer %s", layer.Digest)
+	}
+	return &readCloser{Reader: ds, close: func() error {
+		ds.Close()
+		return ra.Close()
+	}}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (rc *readCloser) Close() error {
+	return rc.close()
+}
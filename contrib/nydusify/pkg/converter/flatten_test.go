@@ -0,0 +1,113 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	name     string
+	typeflag byte
+	data     string
+	linkname string
+}
+
+func writeTestTar(t *testing.T, entries []testEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Size:     int64(len(entry.data)),
+			Linkname: entry.linkname,
+			Mode:     0644,
+		}))
+		_, err := tw.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func readTestTar(t *testing.T, data []byte) []testEntry {
+	var entries []testEntry
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries = append(entries, testEntry{name: hdr.Name, typeflag: hdr.Typeflag, data: string(content), linkname: hdr.Linkname})
+	}
+	return entries
+}
+
+func TestFlattenLayers(t *testing.T) {
+	layers := [][]byte{
+		writeTestTar(t, []testEntry{
+			{name: "etc/", typeflag: tar.TypeDir},
+			{name: "etc/a", typeflag: tar.TypeReg, data: "a0"},
+			{name: "etc/b", typeflag: tar.TypeReg, data: "b0"},
+			{name: "etc/c", typeflag: tar.TypeLink, linkname: "etc/a"},
+			{name: "etc/d", typeflag: tar.TypeLink, linkname: "etc/a"},
+			{name: "bin/", typeflag: tar.TypeDir},
+			{name: "bin/sh", typeflag: tar.TypeReg, data: "sh"},
+			{name: "data/", typeflag: tar.TypeDir},
+			{name: "data/old", typeflag: tar.TypeReg, data: "old"},
+			{name: "opt/", typeflag: tar.TypeDir},
+			{name: "opt/app", typeflag: tar.TypeReg, data: "app"},
+		}),
+		writeTestTar(t, []testEntry{
+			{name: "etc/", typeflag: tar.TypeDir},
+			{name: "etc/a", typeflag: tar.TypeReg, data: "a1"},
+			{name: "etc/.wh.b", typeflag: tar.TypeReg},
+			{name: ".wh.bin", typeflag: tar.TypeReg},
+			{name: "data/", typeflag: tar.TypeDir},
+			{name: "data/.wh..wh..opq", typeflag: tar.TypeReg},
+			{name: "data/new", typeflag: tar.TypeReg, data: "new"},
+			{name: "opt", typeflag: tar.TypeSymlink, linkname: "usr/opt"},
+		}),
+	}
+
+	var buf bytes.Buffer
+	opened := map[int]int{}
+	require.NoError(t, flattenLayers(&buf, len(layers), func(idx int) (io.ReadCloser, error) {
+		opened[idx]++
+		return io.NopCloser(bytes.NewReader(layers[idx])), nil
+	}))
+	require.Equal(t, []testEntry{
+		{name: "etc/", typeflag: tar.TypeDir},
+		{name: "etc/a", typeflag: tar.TypeReg, data: "a1"},
+		{name: "data/", typeflag: tar.TypeDir},
+		{name: "data/new", typeflag: tar.TypeReg, data: "new"},
+		{name: "opt", typeflag: tar.TypeSymlink, linkname: "usr/opt"},
+		// The hard links to the file replaced in upper layer keep the
+		// content of lower layer.
+		{name: "etc/c", typeflag: tar.TypeReg, data: "a0"},
+		{name: "etc/d", typeflag: tar.TypeLink, linkname: "etc/c"},
+	}, readTestTar(t, buf.Bytes()))
+	// Only the layer with hard links to hidden files is read again.
+	require.Equal(t, map[int]int{0: 2, 1: 1}, opened)
+}
+
+func TestFlattenLayersLinkNotFound(t *testing.T) {
+	layer := writeTestTar(t, []testEntry{
+		{name: "a", typeflag: tar.TypeLink, linkname: "missing"},
+	})
+	err := flattenLayers(io.Discard, 1, func(int) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(layer)), nil
+	})
+	require.ErrorContains(t, err, "target /missing of hard link a not found")
+}
@@ -0,0 +1,455 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+// The stages of conversion where the hooks are called.
+const (
+	HookStagePreLayer  = "pre-layer"
+	HookStagePostLayer = "post-layer"
+	HookStagePrePush   = "pre-push"
+	HookStagePostPush  = "post-push"
+)
+
+// HookEvent describes the stage of conversion passed to the hooks.
+type HookEvent struct {
+	Stage  string `json:"stage"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	// Platform is the platform of the layer in pre-layer and post-layer
+	// stages, the layer shared by platforms is only passed once.
+	Platform string `json:"platform,omitempty"`
+	// Layer is the source layer in pre-layer stage, and the nydus layer
+	// (blob or bootstrap) in post-layer stage.
+	Layer *ocispec.Descriptor `json:"layer,omitempty"`
+	// Image is the target image in pre-push and post-push stages.
+	Image *ocispec.Descriptor `json:"image,omitempty"`
+}
+
+// Hooks run the user logic at the stages of conversion, e.g. virus scanning
+// the source layers, injecting annotations or audit logging. The nil hook
+// is skipped, and the error returned by hook fails the conversion.
+type Hooks struct {
+	// PreLayer is called with the uncompressed tar of each source layer
+	// while it's read by conversion, it may be called for layers
+	// concurrently.
+	PreLayer func(ctx context.Context, event HookEvent, layer io.Reader) error
+	// PostLayer is called with each nydus layer after conversion.
+	PostLayer func(ctx context.Context, event HookEvent) error
+	// PrePush is called before the target image is pushed, the returned
+	// annotations are set on the nydus manifests and index of target image.
+	PrePush func(ctx context.Context, event HookEvent) (map[string]string, error)
+	// PostPush is called after the target image is pushed.
+	PostPush func(ctx context.Context, event HookEvent) error
+}
+
+// ScriptHooks returns the hooks running the script with the stage as the
+// only argument, and the event in JSON as environment variable
+// `NYDUS_HOOK_EVENT`. In pre-layer stage, the uncompressed layer tar is
+// written to the stdin of script. In pre-push stage, the lines of
+// `key=value` printed by script are set as annotations of target image.
+// The script fails the conversion by exiting with non-zero code.
+func ScriptHooks(path string) *Hooks {
+	return &Hooks{
+		PreLayer: func(ctx context.Context, event HookEvent, layer io.Reader) error {
+			_, err := runHookScript(ctx, path, event, layer)
+			return err
+		},
+		PostLayer: func(ctx context.Context, event HookEvent) error {
+			_, err := runHookScript(ctx, path, event, nil)
+			return err
+		},
+		PrePush: func(ctx context.Context, event HookEvent) (map[string]string, error) {
+			output, err := runHookScript(ctx, path, event, nil)
+			if err != nil {
+				return nil, err
+			}
+			var values []string
+			for _, line := range strings.Split(string(output), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					values = append(values, line)
+				}
+			}
+			return ParseAnnotations(values)
+		},
+		PostPush: func(ctx context.Context, event HookEvent) error {
+			_, err := runHookScript(ctx, path, event, nil)
+			return err
+		},
+	}
+}
+
+func runHookScript(ctx context.Context, path string, event HookEvent, stdin io.Reader) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Debugf("running hook script %s %s", path, event.Stage)
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, event.Stage)
+	cmd.Env = append(os.Environ(), "NYDUS_HOOK_EVENT="+string(data))
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "run hook script %s in %s stage", path, event.Stage)
+	}
+	return stdout.Bytes(), nil
+}
+
+// layerEvents returns the events of each layer of image manifests matched by
+// platformMC, each layer is only included once.
+func layerEvents(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer, event HookEvent) ([]HookEvent, error) {
+	manifests, err := platformManifests(ctx, store, image)
+	if err != nil {
+		return nil, errors.Wrap(err, "read manifests")
+	}
+	var events []HookEvent
+	included := map[digest.Digest]bool{}
+	for _, manifest := range manifests {
+		if manifest.desc.Platform != nil && !platformMC.Match(*manifest.desc.Platform) {
+			continue
+		}
+		for _, layer := range manifest.manifest.Layers {
+			if included[layer.Digest] {
+				continue
+			}
+			included[layer.Digest] = true
+
+			event.Layer = &layer
+			event.Platform = ""
+			if manifest.desc.Platform != nil {
+				event.Platform = platforms.Format(*manifest.desc.Platform)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// runLayerHooks calls the hook with each layer of image manifests matched
+// by platformMC, each layer is only passed once. The uncompressed layer is
+// read only if withReader, the non-distributable layers not pulled are
+// skipped.
+func runLayerHooks(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer, withReader bool, event HookEvent, hook func(event HookEvent, layer io.Reader) error) error {
+	events, err := layerEvents(ctx, store, image, platformMC, event)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := runLayerHook(ctx, store, withReader, event, hook); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runLayerHook(ctx context.Context, store content.Store, withReader bool, event HookEvent, hook func(event HookEvent, layer io.Reader) error) error {
+	if !withReader {
+		return hook(event, nil)
+	}
+	if images.IsNonDistributable(event.Layer.MediaType) {
+		if _, err := store.Info(ctx, event.Layer.Digest); err != nil {
+			return nil
+		}
+	}
+	reader, err := openLayer(ctx, store, *event.Layer)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return hook(event, reader)
+}
+
+// errHookAbandoned is passed to the pre-layer hook reading the layer which
+// is not read through by conversion, the hook is called again later.
+var errHookAbandoned = errors.New("layer is not read through")
+
+// The states of source layers passed to pre-layer hook.
+const (
+	hookPending = iota
+	hookRunning
+	hookDone
+)
+
+// preLayerContent runs the pre-layer hook with each source layer while the
+// layer is read by conversion, so that the layer isn't fetched again for
+// the hook, e.g. in streaming conversion. The reads of layer are teed to
+// the hook if they're sequential from the start, which is how the layer is
+// decompressed by conversion. The layers not read through, e.g. the ones
+// hit in build cache, are passed to the hook by runPreLayerHook after
+// conversion.
+type preLayerContent struct {
+	content.Store
+	ctx        context.Context
+	pvd        *provider.Provider
+	source     string
+	platformMC platforms.MatchComparer
+	opt        Opt
+
+	mu     sync.Mutex
+	events []HookEvent
+	layers map[digest.Digest]HookEvent
+	states map[digest.Digest]int
+	err    error
+}
+
+func newPreLayerContent(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer, opt Opt) *preLayerContent {
+	// The source image is recorded by converter in the normalized name.
+	if named, err := reference.ParseDockerRef(source); err == nil {
+		source = named.String()
+	}
+	return &preLayerContent{
+		Store:      pvd.ContentStore(),
+		ctx:        ctx,
+		pvd:        pvd,
+		source:     source,
+		platformMC: platformMC,
+		opt:        opt,
+		states:     map[digest.Digest]int{},
+	}
+}
+
+// loadEvents loads the events of source layers once the source image is
+// pulled.
+func (c *preLayerContent) loadEvents(ctx context.Context) error {
+	if c.layers != nil {
+		return nil
+	}
+	image, err := c.pvd.Image(ctx, c.source)
+	if err != nil {
+		// The source image isn't pulled yet.
+		return nil
+	}
+	event := HookEvent{Stage: HookStagePreLayer, Source: c.opt.Source, Target: c.opt.Target}
+	events, err := layerEvents(ctx, c.Store, *image, c.platformMC, event)
+	if err != nil {
+		return errors.Wrap(err, "read source layers")
+	}
+	c.events = events
+	c.layers = map[digest.Digest]HookEvent{}
+	for _, event := range events {
+		c.layers[event.Layer.Digest] = event
+	}
+	return nil
+}
+
+func (c *preLayerContent) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (content.ReaderAt, error) {
+	ra, err := c.Store.ReaderAt(ctx, desc)
+	if err != nil || !images.IsLayerType(desc.MediaType) {
+		return ra, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.loadEvents(ctx); err != nil {
+		ra.Close()
+		return nil, err
+	}
+	event, ok := c.layers[desc.Digest]
+	if !ok || c.states[desc.Digest] != hookPending {
+		return ra, nil
+	}
+	c.states[desc.Digest] = hookRunning
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := c.runHook(event, pr)
+		// Stop teeing the layer not read through by hook, so that the
+		// conversion isn't blocked.
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return &preLayerReaderAt{ReaderAt: ra, content: c, digest: desc.Digest, pw: pw, done: done}, nil
+}
+
+func (c *preLayerContent) runHook(event HookEvent, layer io.Reader) error {
+	ds, err := compression.DecompressStream(layer)
+	if err != nil {
+		return errors.Wrapf(err, "decompress layer %s", event.Layer.Digest)
+	}
+	defer ds.Close()
+	if err := c.opt.Hooks.PreLayer(c.ctx, event, ds); err != nil {
+		return errors.Wrapf(err, "pre-layer hook of %s", event.Layer.Digest)
+	}
+	return nil
+}
+
+// finish records the result of hook, the layer is passed to the hook again
+// if abandoned.
+func (c *preLayerContent) finish(dgst digest.Digest, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if errors.Is(err, errHookAbandoned) {
+		c.states[dgst] = hookPending
+		return
+	}
+	c.states[dgst] = hookDone
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+}
+
+// preLayerReaderAt tees the sequential reads of layer to the pre-layer hook.
+type preLayerReaderAt struct {
+	content.ReaderAt
+	content *preLayerContent
+	digest  digest.Digest
+
+	mu     sync.Mutex
+	pw     *io.PipeWriter
+	done   chan error
+	offset int64
+}
+
+func (ra *preLayerReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := ra.ReaderAt.ReadAt(p, off)
+
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if ra.pw == nil {
+		return n, err
+	}
+	if off > ra.offset {
+		ra.stop(errHookAbandoned)
+		return n, err
+	}
+	if end := off + int64(n); end > ra.offset {
+		if _, werr := ra.pw.Write(p[ra.offset-off : n]); werr != nil {
+			// The hook has returned.
+			if herr := ra.stop(nil); herr != nil {
+				return n, herr
+			}
+			return n, err
+		}
+		ra.offset = end
+	}
+	if ra.offset >= ra.Size() {
+		if herr := ra.stop(nil); herr != nil {
+			return n, herr
+		}
+	}
+	return n, err
+}
+
+// stop closes the layer passed to hook with cause, and returns the result
+// of hook.
+func (ra *preLayerReaderAt) stop(cause error) error {
+	if cause != nil {
+		ra.pw.CloseWithError(cause)
+	} else {
+		ra.pw.Close()
+	}
+	ra.pw = nil
+	err := <-ra.done
+	if cause != nil {
+		err = cause
+	}
+	ra.content.finish(ra.digest, err)
+	return err
+}
+
+func (ra *preLayerReaderAt) Close() error {
+	ra.mu.Lock()
+	if ra.pw != nil {
+		ra.stop(errHookAbandoned)
+	}
+	ra.mu.Unlock()
+	return ra.ReaderAt.Close()
+}
+
+// runPreLayerHook checks the results of pre-layer hook called while the
+// source layers are read by conversion, and calls the hook with the layers
+// not read through.
+func runPreLayerHook(ctx context.Context, c *preLayerContent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if err := c.loadEvents(ctx); err != nil {
+		return err
+	}
+	for _, event := range c.events {
+		if c.states[event.Layer.Digest] == hookDone {
+			continue
+		}
+		if err := runLayerHook(ctx, c.Store, true, event, func(event HookEvent, layer io.Reader) error {
+			if err := c.opt.Hooks.PreLayer(ctx, event, layer); err != nil {
+				return errors.Wrapf(err, "pre-layer hook of %s", event.Layer.Digest)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		c.states[event.Layer.Digest] = hookDone
+	}
+	return nil
+}
+
+// runPostLayerHook calls the post-layer hook with the layers of nydus
+// manifests in target image.
+func runPostLayerHook(ctx context.Context, store content.Store, target ocispec.Descriptor, opt Opt) error {
+	event := HookEvent{Stage: HookStagePostLayer, Source: opt.Source, Target: opt.Target}
+	manifests, err := platformManifests(ctx, store, target)
+	if err != nil {
+		return errors.Wrap(err, "read target manifests")
+	}
+	for _, manifest := range manifests {
+		// Skip the source manifests kept by `--merge-platform`.
+		if !isNydusManifest(manifest.manifest) {
+			continue
+		}
+		if err := runLayerHooks(ctx, store, manifest.desc, platforms.All, false, event, func(event HookEvent, _ io.Reader) error {
+			if err := opt.Hooks.PostLayer(ctx, event); err != nil {
+				return errors.Wrapf(err, "post-layer hook of %s", event.Layer.Digest)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPrePushHook calls the pre-push hook with the target image, and returns
+// the annotations overridden by the ones returned by hook.
+func runPrePushHook(ctx context.Context, target ocispec.Descriptor, opt Opt) (map[string]string, error) {
+	event := HookEvent{Stage: HookStagePrePush, Source: opt.Source, Target: opt.Target, Image: &target}
+	hookAnnotations, err := opt.Hooks.PrePush(ctx, event)
+	if err != nil {
+		return nil, errors.Wrap(err, "pre-push hook")
+	}
+	if len(hookAnnotations) > 0 && (opt.WithReferrer || len(opt.EncryptRecipients) > 0) {
+		return nil, fmt.Errorf("annotations of pre-push hook are not supported with referrer or image encryption")
+	}
+	annotations, _ := mergeAnnotations(opt.Annotations, nil, hookAnnotations)
+	return annotations, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+func TestScriptHooks(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+echo "$NYDUS_HOOK_EVENT" > "$(dirname "$0")/$1.json"
+case "$1" in
+pre-layer) cat > "$(dirname "$0")/layer" ;;
+pre-push) echo "org.opencontainers.image.revision=abc"; echo ;;
+post-push) exit 1 ;;
+esac
+`), 0755))
+	hooks := ScriptHooks(script)
+
+	layer := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer")}
+	event := HookEvent{Stage: HookStagePreLayer, Source: "source", Target: "target", Platform: "linux/amd64", Layer: &layer}
+	require.NoError(t, hooks.PreLayer(ctx, event, bytes.NewReader([]byte("layer tar"))))
+	data, err := os.ReadFile(filepath.Join(dir, "layer"))
+	require.NoError(t, err)
+	require.Equal(t, "layer tar", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "pre-layer.json"))
+	require.NoError(t, err)
+	var got HookEvent
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, event, got)
+
+	event = HookEvent{Stage: HookStagePrePush, Source: "source", Target: "target"}
+	annotations, err := hooks.PrePush(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"org.opencontainers.image.revision": "abc"}, annotations)
+
+	event.Stage = HookStagePostPush
+	require.ErrorContains(t, hooks.PostPush(ctx, event), "run hook script")
+}
+
+// writeHookTestImage writes the image of platforms "linux/amd64" with layer
+// "a", and "linux/arm64" with layers "a" and "b".
+func writeHookTestImage(t *testing.T, store content.Store) (ocispec.Descriptor, []ocispec.Descriptor) {
+	ctx := context.Background()
+	var layers []ocispec.Descriptor
+	for _, name := range []string{"a", "b"} {
+		data := writeTestTar(t, []testEntry{{name: name, typeflag: tar.TypeReg, data: name}})
+		desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(data), Size: int64(len(data))}
+		require.NoError(t, content.WriteBlob(ctx, store, desc.Digest.String(), bytes.NewReader(data), desc))
+		layers = append(layers, desc)
+	}
+	var manifests []ocispec.Descriptor
+	for idx, platform := range []ocispec.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+	} {
+		desc := writeTestJSON(t, store, ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    writeTestJSON(t, store, ocispec.Image{Platform: platform}, ocispec.MediaTypeImageConfig),
+			Layers:    layers[:idx+1],
+		}, ocispec.MediaTypeImageManifest)
+		desc.Platform = &platform
+		manifests = append(manifests, desc)
+	}
+	image := writeTestJSON(t, store, ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: manifests}, ocispec.MediaTypeImageIndex)
+	return image, layers
+}
+
+func TestRunLayerHooks(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	image, layers := writeHookTestImage(t, store)
+
+	called := map[string]string{}
+	require.NoError(t, runLayerHooks(ctx, store, image, platforms.All, true, HookEvent{Stage: HookStagePreLayer}, func(event HookEvent, layer io.Reader) error {
+		require.Equal(t, HookStagePreLayer, event.Stage)
+		entries := readTestTar(t, readAll(t, layer))
+		called[event.Platform] = entries[0].data
+		return nil
+	}))
+	// The layer shared by platforms is only passed once.
+	require.Equal(t, map[string]string{"linux/amd64": "a", "linux/arm64": "b"}, called)
+
+	called = map[string]string{}
+	require.NoError(t, runLayerHooks(ctx, store, image, platforms.Only(ocispec.Platform{OS: "linux", Architecture: "arm64"}), false, HookEvent{}, func(event HookEvent, layer io.Reader) error {
+		require.Nil(t, layer)
+		called[event.Layer.Digest.String()] = event.Platform
+		return nil
+	}))
+	require.Equal(t, map[string]string{layers[0].Digest.String(): "linux/arm64", layers[1].Digest.String(): "linux/arm64"}, called)
+}
+
+func readAll(t *testing.T, reader io.Reader) []byte {
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return data
+}
+
+func TestPreLayerContent(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	pvd, err := provider.New(t.TempDir(), nil, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+	image, layers := writeHookTestImage(t, store)
+	source := pvd.AddLocalImage(image)
+
+	var mu sync.Mutex
+	called := map[string][]string{}
+	opt := Opt{Source: "source", Target: "target", Hooks: &Hooks{
+		PreLayer: func(_ context.Context, event HookEvent, layer io.Reader) error {
+			data, err := io.ReadAll(layer)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			called[event.Platform] = append(called[event.Platform], readTestTar(t, data)[0].data)
+			return nil
+		},
+	}}
+	c := newPreLayerContent(ctx, pvd, source, platforms.All, opt)
+
+	// The layer read sequentially is passed to hook while it's read.
+	ra, err := c.ReaderAt(ctx, layers[0])
+	require.NoError(t, err)
+	data := readAll(t, io.NewSectionReader(ra, 0, ra.Size()))
+	require.NoError(t, ra.Close())
+	require.Equal(t, map[string][]string{"linux/amd64": {"a"}}, called)
+
+	// The layer is passed to hook only once.
+	ra, err = c.ReaderAt(ctx, layers[0])
+	require.NoError(t, err)
+	require.Equal(t, data, readAll(t, io.NewSectionReader(ra, 0, ra.Size())))
+	require.NoError(t, ra.Close())
+
+	// The layer not read through is passed to hook after conversion.
+	ra, err = c.ReaderAt(ctx, layers[1])
+	require.NoError(t, err)
+	_, err = ra.ReadAt(make([]byte, 10), 10)
+	require.NoError(t, err)
+	require.NoError(t, ra.Close())
+	require.Equal(t, map[string][]string{"linux/amd64": {"a"}}, called)
+	require.NoError(t, runPreLayerHook(ctx, c))
+	require.Equal(t, map[string][]string{"linux/amd64": {"a"}, "linux/arm64": {"b"}}, called)
+
+	// The conversion fails once the hook fails.
+	opt.Hooks.PreLayer = func(context.Context, HookEvent, io.Reader) error {
+		return errors.New("virus found")
+	}
+	c = newPreLayerContent(ctx, pvd, source, platforms.All, opt)
+	ra, err = c.ReaderAt(ctx, layers[0])
+	require.NoError(t, err)
+	_, err = io.ReadAll(io.NewSectionReader(ra, 0, ra.Size()))
+	require.ErrorContains(t, err, "virus found")
+	require.NoError(t, ra.Close())
+	require.ErrorContains(t, runPreLayerHook(ctx, c), "pre-layer hook of "+layers[0].Digest.String())
+}
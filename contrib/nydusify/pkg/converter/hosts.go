@@ -5,7 +5,10 @@
 package converter
 
 import (
+	"github.com/distribution/reference"
 	"github.com/goharbor/acceleration-service/pkg/remote"
+
+	pkgPvd "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
 )
 
 func hosts(opt Opt) remote.HostFunc {
@@ -15,7 +18,39 @@ func hosts(opt Opt) remote.HostFunc {
 		opt.ChunkDictRef: opt.ChunkDictInsecure,
 		opt.CacheRef:     opt.CacheInsecure,
 	}
+	credFuncs := map[string]remote.CredentialFunc{}
+	if opt.SourceCredential != (pkgPvd.CredentialOption{}) {
+		credFuncs[opt.Source] = opt.SourceCredential.CredFunc()
+	}
+	if opt.TargetCredential != (pkgPvd.CredentialOption{}) {
+		credFuncs[opt.Target] = opt.TargetCredential.CredFunc()
+	}
+	targetRepo := repository(opt.Target)
+	cacheRepo := repository(opt.CacheRef)
 	return func(ref string) (remote.CredentialFunc, bool, error) {
+		// The other references in target repository, like the chunk dict
+		// found by `--chunk-dict-from-target`, are accessed as the target.
+		if _, ok := maps[ref]; !ok && targetRepo != "" && repository(ref) == targetRepo {
+			ref = opt.Target
+		}
+		// The entries of content-addressed build cache are tagged in the
+		// cache repository.
+		if _, ok := maps[ref]; !ok && cacheRepo != "" && repository(ref) == cacheRepo {
+			ref = opt.CacheRef
+		}
+		if credFunc, ok := credFuncs[ref]; ok {
+			return credFunc, maps[ref], nil
+		}
 		return remote.NewDockerConfigCredFunc(), maps[ref], nil
 	}
 }
+
+// repository returns the repository name of image reference, or empty if
+// the reference is invalid.
+func repository(ref string) string {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return ""
+	}
+	return named.Name()
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	pkgPvd "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+)
+
+func TestHosts(t *testing.T) {
+	hostFunc := hosts(Opt{
+		Source:           "docker.io/library/nginx:latest",
+		Target:           "localhost:5000/library/nginx:nydus",
+		TargetInsecure:   true,
+		TargetCredential: pkgPvd.CredentialOption{Username: "user", Password: "pass"},
+	})
+
+	credFunc, insecure, err := hostFunc("localhost:5000/library/nginx:nydus")
+	require.NoError(t, err)
+	require.True(t, insecure)
+	username, password, err := credFunc("localhost:5000")
+	require.NoError(t, err)
+	require.Equal(t, []string{"user", "pass"}, []string{username, password})
+
+	// The chunk dict in target repository is accessed as the target.
+	credFunc, insecure, err = hostFunc("localhost:5000/library/nginx@" + digest.FromString("dict").String())
+	require.NoError(t, err)
+	require.True(t, insecure)
+	username, _, err = credFunc("localhost:5000")
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+
+	_, insecure, err = hostFunc("docker.io/library/nginx:latest")
+	require.NoError(t, err)
+	require.False(t, insecure)
+	_, insecure, err = hostFunc("localhost:5000/library/busybox:nydus")
+	require.NoError(t, err)
+	require.False(t, insecure)
+}
+
+func TestHostsCacheRepository(t *testing.T) {
+	hostFunc := hosts(Opt{
+		Target:        "localhost:5000/library/nginx:nydus",
+		CacheRef:      "localhost:5001/nydus-cache",
+		CacheInsecure: true,
+	})
+
+	// The entries of content-addressed build cache are accessed as the cache.
+	_, insecure, err := hostFunc("localhost:5001/nydus-cache:sha256-" + digest.FromString("key").Encoded())
+	require.NoError(t, err)
+	require.True(t, insecure)
+	_, insecure, err = hostFunc("localhost:5001/other:latest")
+	require.NoError(t, err)
+	require.False(t, insecure)
+}
@@ -0,0 +1,234 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// bootstrapHistory is the history entry of the bootstrap layer, which is
+// the same as the one appended by the converter driver.
+var bootstrapHistory = ocispec.History{
+	CreatedBy: "Nydus Converter",
+	Comment:   "Nydus Bootstrap Layer",
+}
+
+// ParseAnnotations parses the annotations in the format of "key=value".
+func ParseAnnotations(values []string) (map[string]string, error) {
+	annotations := map[string]string{}
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid annotation '%s', should be in the format of key=value", value)
+		}
+		annotations[key] = val
+	}
+	return annotations, nil
+}
+
+// mergeAnnotations adds the inherited annotations missing in annotations,
+// then sets the overrides. It reports whether annotations are changed.
+func mergeAnnotations(annotations, inherited, overrides map[string]string) (map[string]string, bool) {
+	merged := make(map[string]string, len(annotations)+len(inherited)+len(overrides))
+	for key, value := range annotations {
+		merged[key] = value
+	}
+	changed := false
+	for key, value := range inherited {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+			changed = true
+		}
+	}
+	for key, value := range overrides {
+		if current, ok := merged[key]; !ok || current != value {
+			merged[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return annotations, false
+	}
+	return merged, true
+}
+
+// preserveConfig adds the labels of source config missing in target config,
+// and the history of source config if target config has none. The fields
+// unknown to OCI image spec are kept. It reports whether config is changed.
+func preserveConfig(targetData, sourceData []byte) ([]byte, bool, error) {
+	var target, source ocispec.Image
+	if err := json.Unmarshal(targetData, &target); err != nil {
+		return nil, false, errors.Wrap(err, "unmarshal target config")
+	}
+	if err := json.Unmarshal(sourceData, &source); err != nil {
+		return nil, false, errors.Wrap(err, "unmarshal source config")
+	}
+	labels, labelsChanged := mergeAnnotations(target.Config.Labels, source.Config.Labels, nil)
+	historyChanged := len(target.History) == 0 && len(source.History) > 0
+	if !labelsChanged && !historyChanged {
+		return targetData, false, nil
+	}
+
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(targetData, &config); err != nil {
+		return nil, false, errors.Wrap(err, "unmarshal target config")
+	}
+	var err error
+	if labelsChanged {
+		imageConfig := map[string]json.RawMessage{}
+		if data, ok := config["config"]; ok && string(data) != "null" {
+			if err := json.Unmarshal(data, &imageConfig); err != nil {
+				return nil, false, errors.Wrap(err, "unmarshal target image config")
+			}
+		}
+		if imageConfig["Labels"], err = json.Marshal(labels); err != nil {
+			return nil, false, err
+		}
+		if config["config"], err = json.Marshal(imageConfig); err != nil {
+			return nil, false, err
+		}
+	}
+	if historyChanged {
+		history := append(append([]ocispec.History{}, source.History...), bootstrapHistory)
+		if config["history"], err = json.Marshal(history); err != nil {
+			return nil, false, err
+		}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// preserveMetadata carries the annotations of source manifests and index,
+// the labels and history of source configs over to the nydus manifests of
+// target image, if they are dropped in conversion, and sets annotations on
+// them. The image rewritten is written into content store, target is
+// returned as is if nothing is changed.
+func preserveMetadata(ctx context.Context, store content.Store, source, target ocispec.Descriptor, annotations map[string]string) (*ocispec.Descriptor, error) {
+	sourceManifests, err := platformManifests(ctx, store, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "read source manifests")
+	}
+	targetManifests, err := platformManifests(ctx, store, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target manifests")
+	}
+
+	preserved := map[digest.Digest]ocispec.Descriptor{}
+	for _, manifest := range targetManifests {
+		// Skip the source manifests kept by `--merge-platform`.
+		if !isNydusManifest(manifest.manifest) {
+			continue
+		}
+		desc, err := preserveManifest(ctx, store, matchManifest(sourceManifests, manifest), manifest, annotations)
+		if err != nil {
+			return nil, errors.Wrapf(err, "preserve metadata of %s", manifest.desc.Digest)
+		}
+		if desc != nil {
+			preserved[manifest.desc.Digest] = *desc
+		}
+	}
+
+	targetIndex, err := readIndex(ctx, store, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target index")
+	}
+	if targetIndex == nil {
+		if desc, ok := preserved[target.Digest]; ok {
+			return &desc, nil
+		}
+		return &target, nil
+	}
+	sourceIndex, err := readIndex(ctx, store, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "read source index")
+	}
+	var inherited map[string]string
+	if sourceIndex != nil {
+		inherited = sourceIndex.Annotations
+	}
+	var changed bool
+	targetIndex.Annotations, changed = mergeAnnotations(targetIndex.Annotations, inherited, annotations)
+	if !changed && len(preserved) == 0 {
+		return &target, nil
+	}
+	for idx, desc := range targetIndex.Manifests {
+		if manifest, ok := preserved[desc.Digest]; ok {
+			targetIndex.Manifests[idx] = manifest
+		}
+	}
+	indexDesc, indexData, err := utils.MarshalToDesc(targetIndex, target.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal target index")
+	}
+	if err := writeContent(ctx, store, *indexDesc, indexData); err != nil {
+		return nil, err
+	}
+	return indexDesc, nil
+}
+
+// preserveManifest writes the target manifest with the metadata of source
+// manifest, it returns nil if nothing is changed.
+func preserveManifest(ctx context.Context, store content.Store, source *platformManifest, target platformManifest, annotations map[string]string) (*ocispec.Descriptor, error) {
+	manifest := target.manifest
+	var inherited map[string]string
+	if source != nil {
+		inherited = source.manifest.Annotations
+	}
+	var annotationsChanged, configChanged bool
+	manifest.Annotations, annotationsChanged = mergeAnnotations(manifest.Annotations, inherited, annotations)
+
+	var configData []byte
+	if source != nil {
+		targetData, err := content.ReadBlob(ctx, store, manifest.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "read target config")
+		}
+		sourceData, err := content.ReadBlob(ctx, store, source.manifest.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "read source config")
+		}
+		if configData, configChanged, err = preserveConfig(targetData, sourceData); err != nil {
+			return nil, err
+		}
+	}
+	if !annotationsChanged && !configChanged {
+		return nil, nil
+	}
+
+	if configChanged {
+		manifest.Config.Digest = digest.FromBytes(configData)
+		manifest.Config.Size = int64(len(configData))
+		if err := writeContent(ctx, store, manifest.Config, configData); err != nil {
+			return nil, err
+		}
+	}
+	manifestDesc, manifestData, err := utils.MarshalToDesc(manifest, target.desc.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal manifest")
+	}
+	if err := writeContent(ctx, store, *manifestDesc, manifestData); err != nil {
+		return nil, err
+	}
+	logrus.Infof("preserved metadata of source image in %s", manifestDesc.Digest)
+
+	desc := target.desc
+	desc.Digest = manifestDesc.Digest
+	desc.Size = manifestDesc.Size
+	return &desc, nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	annotations, err := ParseAnnotations([]string{"org.opencontainers.image.source=https://a.b/c", "empty=", "a=b=c"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"org.opencontainers.image.source": "https://a.b/c",
+		"empty":                           "",
+		"a":                               "b=c",
+	}, annotations)
+
+	_, err = ParseAnnotations([]string{"invalid"})
+	require.ErrorContains(t, err, "invalid annotation 'invalid'")
+	_, err = ParseAnnotations([]string{"=value"})
+	require.Error(t, err)
+}
+
+func TestMergeAnnotations(t *testing.T) {
+	target := map[string]string{"a": "target"}
+	merged, changed := mergeAnnotations(target, map[string]string{"a": "source"}, nil)
+	require.False(t, changed)
+	require.Equal(t, target, merged)
+
+	merged, changed = mergeAnnotations(target, map[string]string{"a": "source", "b": "source"}, map[string]string{"c": "override"})
+	require.True(t, changed)
+	require.Equal(t, map[string]string{"a": "target", "b": "source", "c": "override"}, merged)
+	// The annotations of target are not modified.
+	require.Equal(t, map[string]string{"a": "target"}, target)
+
+	merged, changed = mergeAnnotations(nil, nil, map[string]string{"a": "override"})
+	require.True(t, changed)
+	require.Equal(t, map[string]string{"a": "override"}, merged)
+	_, changed = mergeAnnotations(merged, nil, map[string]string{"a": "override"})
+	require.False(t, changed)
+}
+
+func TestPreserveConfig(t *testing.T) {
+	source := []byte(`{"config":{"Labels":{"a":"source","b":"source"}},"history":[{"created_by":"ADD rootfs"}],"rootfs":{"type":"layers","diff_ids":[]}}`)
+	target := []byte(`{"config":{"Cmd":["sh"],"Labels":{"a":"target"}},"rootfs":{"type":"layers","diff_ids":[]},"unknown":"kept"}`)
+
+	data, changed, err := preserveConfig(target, source)
+	require.NoError(t, err)
+	require.True(t, changed)
+	var config ocispec.Image
+	require.NoError(t, json.Unmarshal(data, &config))
+	require.Equal(t, map[string]string{"a": "target", "b": "source"}, config.Config.Labels)
+	require.Equal(t, []string{"sh"}, config.Config.Cmd)
+	require.Equal(t, []ocispec.History{{CreatedBy: "ADD rootfs"}, bootstrapHistory}, config.History)
+	require.Contains(t, string(data), `"unknown":"kept"`)
+
+	// The config carrying the metadata is not changed.
+	data, changed, err = preserveConfig(data, source)
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.NotNil(t, data)
+
+	// The config without image config.
+	data, changed, err = preserveConfig([]byte(`{"config":null}`), source)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NoError(t, json.Unmarshal(data, &config))
+	require.Equal(t, map[string]string{"a": "source", "b": "source"}, config.Config.Labels)
+}
+
+func TestPreserveMetadata(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	amd64 := ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	sourceConfig := ocispec.Image{Platform: amd64, Config: ocispec.ImageConfig{Labels: map[string]string{"vendor": "nydus"}}}
+	sourceManifestDesc := writeTestJSON(t, store, ocispec.Manifest{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Config:      writeTestJSON(t, store, sourceConfig, ocispec.MediaTypeImageConfig),
+		Annotations: map[string]string{"org.opencontainers.image.revision": "abc"},
+	}, ocispec.MediaTypeImageManifest)
+	sourceManifestDesc.Platform = &amd64
+	sourceDesc := writeTestJSON(t, store, ocispec.Index{
+		MediaType:   ocispec.MediaTypeImageIndex,
+		Manifests:   []ocispec.Descriptor{sourceManifestDesc},
+		Annotations: map[string]string{"org.opencontainers.image.source": "https://a.b/c"},
+	}, ocispec.MediaTypeImageIndex)
+
+	bootstrap := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Digest:      digest.FromString("bootstrap"),
+		Size:        9,
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}
+	targetManifestDesc := writeTestJSON(t, store, ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    writeTestJSON(t, store, ocispec.Image{Platform: amd64}, ocispec.MediaTypeImageConfig),
+		Layers:    []ocispec.Descriptor{bootstrap},
+	}, ocispec.MediaTypeImageManifest)
+	targetManifestDesc.Platform = &amd64
+	targetDesc := writeTestJSON(t, store, ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{targetManifestDesc},
+	}, ocispec.MediaTypeImageIndex)
+
+	desc, err := preserveMetadata(ctx, store, sourceDesc, targetDesc, map[string]string{"org.opencontainers.image.revision": "def"})
+	require.NoError(t, err)
+	require.NotEqual(t, targetDesc.Digest, desc.Digest)
+
+	index, err := readIndex(ctx, store, *desc)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"org.opencontainers.image.source":   "https://a.b/c",
+		"org.opencontainers.image.revision": "def",
+	}, index.Annotations)
+	manifests, err := platformManifests(ctx, store, *desc)
+	require.NoError(t, err)
+	require.Equal(t, &amd64, manifests[0].desc.Platform)
+	require.Equal(t, map[string]string{"org.opencontainers.image.revision": "def"}, manifests[0].manifest.Annotations)
+	configData, err := content.ReadBlob(ctx, store, manifests[0].manifest.Config)
+	require.NoError(t, err)
+	var config ocispec.Image
+	require.NoError(t, json.Unmarshal(configData, &config))
+	require.Equal(t, sourceConfig.Config.Labels, config.Config.Labels)
+
+	// The target image carrying the metadata is not changed.
+	preserved, err := preserveMetadata(ctx, store, sourceDesc, *desc, nil)
+	require.NoError(t, err)
+	require.Equal(t, desc.Digest, preserved.Digest)
+}
@@ -8,19 +8,20 @@ import (
 	"encoding/json"
 	"os"
 
-	"github.com/goharbor/acceleration-service/pkg/converter"
 	"github.com/pkg/errors"
 )
 
-func dumpMetric(metric *converter.Metric, path string) error {
+func dumpMetric(report *Report, path string) error {
 	file, err := os.Create(path)
 	if err != nil {
 		return errors.Wrap(err, "Create file for metric")
 	}
 	defer file.Close()
 
+	versioned := *report
+	versioned.Version = ReportVersion
 	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(metric); err != nil {
+	if err := encoder.Encode(&versioned); err != nil {
 		return errors.Wrap(err, "Encode JSON from metric")
 	}
 	return nil
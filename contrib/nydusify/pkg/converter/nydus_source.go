@@ -0,0 +1,180 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// The behaviors of converting a source image which is already a nydus
+// image, or an index containing nydus manifests.
+const (
+	// IfNydusSkip passes the source image through to target unchanged.
+	IfNydusSkip = "skip"
+	// IfNydusRebase converts the OCI manifests of source image again, the
+	// nydus blobs of source image are reused for the layers they are
+	// converted from, so that only the bootstraps are rebuilt.
+	IfNydusRebase = "rebase"
+	// IfNydusRebuild converts the OCI manifests of source image again, the
+	// nydus manifests of source image are dropped.
+	IfNydusRebuild = "rebuild"
+)
+
+// IfNydusModes are the possible values of Opt.IfNydus.
+var IfNydusModes = []string{IfNydusSkip, IfNydusRebase, IfNydusRebuild}
+
+// hasNydusManifest reports whether the manifests of source image matched by
+// platformMC contain a nydus manifest, the layers of remote source image
+// aren't pulled.
+func hasNydusManifest(ctx context.Context, pvd *provider.Provider, source string, local bool, platformMC platforms.MatchComparer) (bool, error) {
+	var manifests []ocispec.Manifest
+	if local {
+		desc, err := pvd.Image(ctx, source)
+		if err != nil {
+			return false, errors.Wrap(err, "get source image")
+		}
+		localManifests, err := platformManifests(ctx, pvd.ContentStore(), *desc)
+		if err != nil {
+			return false, errors.Wrap(err, "read source manifests")
+		}
+		for _, manifest := range localManifests {
+			if manifest.desc.Platform == nil || platformMC.Match(*manifest.desc.Platform) {
+				manifests = append(manifests, manifest.manifest)
+			}
+		}
+	} else {
+		var err error
+		if _, manifests, err = pvd.RemoteManifests(ctx, source, platformMC); err != nil {
+			return false, errors.Wrap(err, "get source manifests")
+		}
+	}
+	for _, manifest := range manifests {
+		if isNydusManifest(manifest) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// passThrough copies the source image to target unchanged, it does nothing
+// if they are the same image.
+func passThrough(ctx context.Context, pvd *provider.Provider, source, target string) error {
+	sourceNamed, sourceErr := reference.ParseDockerRef(source)
+	targetNamed, targetErr := reference.ParseDockerRef(target)
+	if sourceErr == nil && targetErr == nil && sourceNamed.String() == targetNamed.String() {
+		logrus.Infof("source image is already a nydus image, skip converting %s", source)
+		return nil
+	}
+
+	logrus.Infof("source image is already a nydus image, copy %s to %s", source, target)
+	if err := pvd.Pull(ctx, source); err != nil {
+		return errors.Wrap(err, "pull source image")
+	}
+	desc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "get source image")
+	}
+	if err := pvd.Push(ctx, *desc, target); err != nil {
+		return errors.Wrap(err, "push target image")
+	}
+	return nil
+}
+
+// stripNydusManifests returns the local image of the OCI manifests in the
+// source index, without the nydus manifests. If reuse is set, the nydus
+// blobs of the nydus manifests are recorded in the local build cache image
+// for the layers of OCI manifest of the same platform, whose ref is
+// returned as well.
+func stripNydusManifests(ctx context.Context, pvd *provider.Provider, source string, reuse bool, opt Opt) (string, string, error) {
+	store := pvd.ContentStore()
+	if err := pvd.Pull(ctx, source); err != nil {
+		return "", "", errors.Wrap(err, "pull source image")
+	}
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return "", "", errors.Wrap(err, "get source image")
+	}
+	index, err := readIndex(ctx, store, *sourceDesc)
+	if err != nil {
+		return "", "", errors.Wrap(err, "read source index")
+	}
+	manifests, err := platformManifests(ctx, store, *sourceDesc)
+	if err != nil {
+		return "", "", errors.Wrap(err, "read source manifests")
+	}
+
+	var ociManifests, nydusManifests []platformManifest
+	for _, manifest := range manifests {
+		if isNydusManifest(manifest.manifest) {
+			nydusManifests = append(nydusManifests, manifest)
+		} else {
+			ociManifests = append(ociManifests, manifest)
+		}
+	}
+	if index == nil || len(ociManifests) == 0 {
+		return "", "", fmt.Errorf("no OCI manifest in source image %s to convert from, revert it to OCI image first", source)
+	}
+
+	strippedIndex := *index
+	strippedIndex.Manifests = nil
+	for _, manifest := range ociManifests {
+		strippedIndex.Manifests = append(strippedIndex.Manifests, manifest.desc)
+	}
+	indexDesc, indexData, err := utils.MarshalToDesc(strippedIndex, sourceDesc.MediaType)
+	if err != nil {
+		return "", "", errors.Wrap(err, "marshal source index")
+	}
+	if err := content.WriteBlob(ctx, store, indexDesc.Digest.String(), bytes.NewReader(indexData), *indexDesc); err != nil {
+		return "", "", errors.Wrap(err, "write source index")
+	}
+	strippedSource := pvd.AddLocalImage(*indexDesc)
+	logrus.Infof("convert %d OCI manifests of source image, %d nydus manifests are dropped", len(ociManifests), len(nydusManifests))
+	if !reuse {
+		return strippedSource, "", nil
+	}
+
+	cacheManifest := buildCacheManifest(nil, nil, opt.FsVersion, opt.CacheVersion)
+	reused := map[digest.Digest]bool{}
+	for _, nydusManifest := range nydusManifests {
+		ociManifest := matchManifest(ociManifests, nydusManifest)
+		if ociManifest == nil {
+			continue
+		}
+		// The blobs can't be paired with the layers if the nydus image is
+		// converted with chunk dict, then the layers are converted again.
+		blobs := convertedLayers(ociManifest.manifest, nydusManifest.manifest, nil)
+		for _, layer := range ociManifest.manifest.Layers {
+			blob, ok := blobs[layer.Digest]
+			if !ok || reused[layer.Digest] {
+				continue
+			}
+			reused[layer.Digest] = true
+			appendCacheLayer(&cacheManifest, layer, blob)
+		}
+	}
+	if len(reused) == 0 {
+		logrus.Infof("no nydus blob of source image can be reused")
+		return strippedSource, "", nil
+	}
+	logrus.Infof("reuse %d nydus blobs of source image", len(reused))
+	cacheRef, err := writeBuildCacheImage(ctx, pvd, cacheManifest)
+	if err != nil {
+		return "", "", errors.Wrap(err, "write build cache image")
+	}
+	return strippedSource, cacheRef, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func writeTestJSON(t *testing.T, store content.Store, v interface{}, mediaType string) ocispec.Descriptor {
+	desc, data, err := utils.MarshalToDesc(v, mediaType)
+	require.NoError(t, err)
+	require.NoError(t, content.WriteBlob(context.Background(), store, desc.Digest.String(), bytes.NewReader(data), *desc))
+	return *desc
+}
+
+func TestStripNydusManifests(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	pvd, err := provider.New(t.TempDir(), nil, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+
+	layers := []ocispec.Descriptor{
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-1"), Size: 7},
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-2"), Size: 7},
+	}
+	blobs := []ocispec.Descriptor{
+		{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString("blob-1"), Size: 6},
+		{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString("blob-2"), Size: 6},
+	}
+	bootstrap := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Digest:      digest.FromString("bootstrap"),
+		Size:        9,
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}
+	amd64 := ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	ociDesc := writeTestJSON(t, store, ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Config: ocispec.DescriptorEmptyJSON, Layers: layers}, ocispec.MediaTypeImageManifest)
+	ociDesc.Platform = &amd64
+	nydusDesc := writeTestJSON(t, store, ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Config: ocispec.DescriptorEmptyJSON, Layers: append(append([]ocispec.Descriptor{}, blobs...), bootstrap)}, ocispec.MediaTypeImageManifest)
+	nydusDesc.Platform = &ocispec.Platform{OS: "linux", Architecture: "amd64", OSFeatures: []string{utils.ManifestOSFeatureNydus}}
+
+	indexDesc := writeTestJSON(t, store, ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{ociDesc, nydusDesc}}, ocispec.MediaTypeImageIndex)
+	source := pvd.AddLocalImage(indexDesc)
+	isNydus, err := hasNydusManifest(ctx, pvd, source, true, platforms.All)
+	require.NoError(t, err)
+	require.True(t, isNydus)
+
+	// The nydus manifest is dropped.
+	stripped, cacheRef, err := stripNydusManifests(ctx, pvd, source, false, Opt{FsVersion: "6"})
+	require.NoError(t, err)
+	require.Empty(t, cacheRef)
+	strippedDesc, err := pvd.Image(ctx, stripped)
+	require.NoError(t, err)
+	index, err := readIndex(ctx, store, *strippedDesc)
+	require.NoError(t, err)
+	require.Equal(t, []ocispec.Descriptor{ociDesc}, index.Manifests)
+	isNydus, err = hasNydusManifest(ctx, pvd, stripped, true, platforms.All)
+	require.NoError(t, err)
+	require.False(t, isNydus)
+
+	// The nydus blobs are reused for the layers by build cache.
+	_, cacheRef, err = stripNydusManifests(ctx, pvd, source, true, Opt{FsVersion: "6", CacheVersion: "v1"})
+	require.NoError(t, err)
+	cacheDesc, err := pvd.Image(ctx, cacheRef)
+	require.NoError(t, err)
+	manifests, err := platformManifests(ctx, store, *cacheDesc)
+	require.NoError(t, err)
+	cacheLayers := manifests[0].manifest.Layers
+	require.Len(t, cacheLayers, 4)
+	for idx := range layers {
+		require.Equal(t, layers[idx].Digest, cacheLayers[idx*2].Digest)
+		require.Equal(t, blobs[idx].Digest.String(), cacheLayers[idx*2].Annotations[utils.LayerAnnotationNydusTargetDigest])
+		require.Equal(t, blobs[idx].Digest, cacheLayers[idx*2+1].Digest)
+	}
+
+	// The nydus image without OCI manifest can't be converted again.
+	nydusSource := pvd.AddLocalImage(nydusDesc)
+	_, _, err = stripNydusManifests(ctx, pvd, nydusSource, false, Opt{})
+	require.ErrorContains(t, err, "no OCI manifest")
+}
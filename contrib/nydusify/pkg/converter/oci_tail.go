@@ -0,0 +1,489 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	snapConv "github.com/BraveY/snapshotter-converter/converter"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/nydus-snapshotter/pkg/label"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// ociTailManifest is a source manifest whose top layers are kept as OCI
+// layers in target image, only the lower layers are converted to nydus.
+type ociTailManifest struct {
+	// lower is the manifest of lower layers written into content store.
+	lower platformManifest
+	// layers, diffIDs and history are of the top layers kept.
+	layers  []ocispec.Descriptor
+	diffIDs []digest.Digest
+	history []ocispec.History
+}
+
+// checkOCITail checks the options conflicting with OCI tail layers, which
+// are appended to the nydus image converted by the converter driver.
+func checkOCITail(opt Opt) error {
+	switch {
+	case opt.FsVersion != "6":
+		return errors.New("OCI tail layers require RAFS v6")
+	case opt.OCIRef:
+		return errors.New("OCI tail layers are not supported with OCI ref, which keeps all layers")
+	case opt.MergePlatform:
+		return errors.New("OCI tail layers are not supported with merging platform")
+	case opt.BackendType != "":
+		return errors.New("OCI tail layers are not supported with storage backend")
+	case opt.WithReferrer:
+		return errors.New("OCI tail layers are not supported with referrer")
+	case len(opt.EncryptRecipients) > 0:
+		return errors.New("OCI tail layers are not supported with image encryption")
+	case opt.Stream:
+		return errors.New("OCI tail layers are not supported in streaming conversion")
+	}
+	return nil
+}
+
+// ociTailLayers returns the count of top layers kept as OCI layers, which
+// are at most count gzip layers not larger than maxSize (zero means no
+// limit). The lowest layer is always converted.
+func ociTailLayers(layers []ocispec.Descriptor, count int, maxSize int64) int {
+	kept := 0
+	for idx := len(layers) - 1; idx > 0 && kept < count; idx-- {
+		layer := layers[idx]
+		// Only the gzip layers can be referenced by OCI ref bootstrap.
+		if layer.MediaType != ocispec.MediaTypeImageLayerGzip && layer.MediaType != images.MediaTypeDockerSchema2LayerGzip {
+			break
+		}
+		if maxSize > 0 && layer.Size > maxSize {
+			break
+		}
+		kept++
+	}
+	return kept
+}
+
+// splitHistory splits the history at the entry creating the given count of
+// layers, the empty layer entries following it belong to the upper part.
+func splitHistory(history []ocispec.History, layers int) ([]ocispec.History, []ocispec.History) {
+	if layers == 0 {
+		return nil, history
+	}
+	for idx, entry := range history {
+		if entry.EmptyLayer {
+			continue
+		}
+		if layers--; layers == 0 {
+			return history[:idx+1], history[idx+1:]
+		}
+	}
+	return history, nil
+}
+
+// rewriteConfig replaces the rootfs and history of image config, the other
+// fields are kept as is.
+func rewriteConfig(data []byte, diffIDs []digest.Digest, history []ocispec.History) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	rootfs, err := json.Marshal(ocispec.RootFS{Type: "layers", DiffIDs: diffIDs})
+	if err != nil {
+		return nil, err
+	}
+	config["rootfs"] = rootfs
+	if len(history) > 0 {
+		if config["history"], err = json.Marshal(history); err != nil {
+			return nil, err
+		}
+	} else {
+		delete(config, "history")
+	}
+	return json.Marshal(config)
+}
+
+func writeContent(ctx context.Context, store content.Store, desc ocispec.Descriptor, data []byte) error {
+	if err := content.WriteBlob(ctx, store, desc.Digest.String(), bytes.NewReader(data), desc); err != nil {
+		return errors.Wrapf(err, "write blob %s", desc.Digest)
+	}
+	return nil
+}
+
+// rewriteManifests writes the local image of source image whose manifests
+// matched by platformMC are rewritten by fn, which returns the descriptor of
+// manifest written into content store, or nil to keep the manifest. The
+// source image is returned if no manifest is rewritten.
+func rewriteManifests(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer, fn func(manifest platformManifest) (*ocispec.Descriptor, error)) (string, error) {
+	store := pvd.ContentStore()
+	if err := pvd.Pull(ctx, source); err != nil {
+		return "", errors.Wrap(err, "pull source image")
+	}
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return "", errors.Wrap(err, "get source image")
+	}
+	index, err := readIndex(ctx, store, *sourceDesc)
+	if err != nil {
+		return "", errors.Wrap(err, "read source index")
+	}
+	manifests, err := platformManifests(ctx, store, *sourceDesc)
+	if err != nil {
+		return "", errors.Wrap(err, "read source manifests")
+	}
+
+	rewritten := map[digest.Digest]ocispec.Descriptor{}
+	var rewrittenDesc ocispec.Descriptor
+	for _, manifest := range manifests {
+		if manifest.desc.Platform != nil && !platformMC.Match(*manifest.desc.Platform) {
+			continue
+		}
+		desc, err := fn(manifest)
+		if err != nil {
+			return "", err
+		}
+		if desc != nil {
+			rewritten[manifest.desc.Digest] = *desc
+			rewrittenDesc = *desc
+		}
+	}
+	if len(rewritten) == 0 {
+		return source, nil
+	}
+	if index == nil {
+		return pvd.AddLocalImage(rewrittenDesc), nil
+	}
+
+	rewrittenIndex := *index
+	rewrittenIndex.Manifests = nil
+	for _, desc := range index.Manifests {
+		if manifest, ok := rewritten[desc.Digest]; ok {
+			desc = manifest
+		}
+		rewrittenIndex.Manifests = append(rewrittenIndex.Manifests, desc)
+	}
+	indexDesc, indexData, err := utils.MarshalToDesc(rewrittenIndex, sourceDesc.MediaType)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal source index")
+	}
+	if err := writeContent(ctx, store, *indexDesc, indexData); err != nil {
+		return "", err
+	}
+	return pvd.AddLocalImage(*indexDesc), nil
+}
+
+// splitOCITail writes the local image of source image without the top
+// layers kept as OCI layers, which is converted by the converter driver.
+// The manifests without the top layers to keep are not changed.
+func splitOCITail(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer, opt Opt) (string, []ociTailManifest, error) {
+	var tails []ociTailManifest
+	lower, err := rewriteManifests(ctx, pvd, source, platformMC, func(manifest platformManifest) (*ocispec.Descriptor, error) {
+		tail, err := splitManifest(ctx, pvd.ContentStore(), manifest, opt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "split manifest %s", manifest.desc.Digest)
+		}
+		if tail == nil {
+			return nil, nil
+		}
+		logrus.Infof("keep %d top layers of %s as OCI layers", len(tail.layers), manifest.desc.Digest)
+		tails = append(tails, *tail)
+		return &tail.lower.desc, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tails) == 0 {
+		logrus.Infof("no top layers of %s can be kept as OCI layers", source)
+	}
+	return lower, tails, nil
+}
+
+// splitManifest writes the manifest and config of the lower layers of
+// source manifest, it returns nil if no top layer is kept.
+func splitManifest(ctx context.Context, store content.Store, manifest platformManifest, opt Opt) (*ociTailManifest, error) {
+	layers := manifest.manifest.Layers
+	kept := ociTailLayers(layers, opt.OCITailLayers, opt.OCITailMaxSize)
+	if kept == 0 {
+		return nil, nil
+	}
+	configData, err := content.ReadBlob(ctx, store, manifest.manifest.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config")
+	}
+	var config ocispec.Image
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return nil, errors.Wrap(err, "unmarshal config")
+	}
+	diffIDs := config.RootFS.DiffIDs
+	if len(diffIDs) != len(layers) {
+		return nil, fmt.Errorf("%d diff ids in config mismatch with %d layers", len(diffIDs), len(layers))
+	}
+
+	lowers := len(layers) - kept
+	lowerHistory, upperHistory := splitHistory(config.History, lowers)
+	lowerConfigData, err := rewriteConfig(configData, diffIDs[:lowers], lowerHistory)
+	if err != nil {
+		return nil, errors.Wrap(err, "rewrite config")
+	}
+	lowerManifest := manifest.manifest
+	lowerManifest.Config.Digest = digest.FromBytes(lowerConfigData)
+	lowerManifest.Config.Size = int64(len(lowerConfigData))
+	lowerManifest.Layers = layers[:lowers]
+	manifestDesc, manifestData, err := utils.MarshalToDesc(lowerManifest, manifest.desc.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal manifest")
+	}
+	if err := writeContent(ctx, store, lowerManifest.Config, lowerConfigData); err != nil {
+		return nil, err
+	}
+	if err := writeContent(ctx, store, *manifestDesc, manifestData); err != nil {
+		return nil, err
+	}
+
+	lowerDesc := manifest.desc
+	lowerDesc.Digest = manifestDesc.Digest
+	lowerDesc.Size = manifestDesc.Size
+	return &ociTailManifest{
+		lower:   platformManifest{desc: lowerDesc, manifest: lowerManifest},
+		layers:  layers[lowers:],
+		diffIDs: diffIDs[lowers:],
+		history: upperHistory,
+	}, nil
+}
+
+// appendOCITail appends the top layers kept to the nydus manifests of lower
+// target image converted from the local image written by splitOCITail. The
+// top layers are referenced by the OCI ref bootstraps merged onto the lower
+// bootstrap, so they are lazily loaded from the OCI layers by nydusd. The
+// workDir is removed at last.
+func appendOCITail(ctx context.Context, pvd *provider.Provider, lowerTarget string, tails []ociTailManifest, workDir string, opt Opt) (*ocispec.Descriptor, error) {
+	store := pvd.ContentStore()
+	targetDesc, err := pvd.Image(ctx, lowerTarget)
+	if err != nil {
+		return nil, errors.Wrap(err, "get target image")
+	}
+	index, err := readIndex(ctx, store, *targetDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target index")
+	}
+	manifests, err := platformManifests(ctx, store, *targetDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target manifests")
+	}
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create work directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	lowers := make([]platformManifest, 0, len(tails))
+	lowerTails := map[digest.Digest]ociTailManifest{}
+	for _, tail := range tails {
+		lowers = append(lowers, tail.lower)
+		lowerTails[tail.lower.desc.Digest] = tail
+	}
+	// The OCI ref bootstraps of the top layers shared by the platforms are
+	// built only once.
+	refBootstraps := map[digest.Digest]string{}
+	appended := map[digest.Digest]ocispec.Descriptor{}
+	for _, manifest := range manifests {
+		if !isNydusManifest(manifest.manifest) {
+			continue
+		}
+		lower := matchManifest(lowers, manifest)
+		if lower == nil {
+			continue
+		}
+		desc, err := appendManifest(ctx, store, manifest, lowerTails[lower.desc.Digest], refBootstraps, workDir, opt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "append OCI layers to %s", manifest.desc.Digest)
+		}
+		appended[manifest.desc.Digest] = *desc
+	}
+
+	if index == nil {
+		if desc, ok := appended[targetDesc.Digest]; ok {
+			return &desc, nil
+		}
+		return targetDesc, nil
+	}
+	targetIndex := *index
+	targetIndex.Manifests = nil
+	for _, desc := range index.Manifests {
+		if manifest, ok := appended[desc.Digest]; ok {
+			desc = manifest
+		}
+		targetIndex.Manifests = append(targetIndex.Manifests, desc)
+	}
+	indexDesc, indexData, err := utils.MarshalToDesc(targetIndex, targetDesc.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal target index")
+	}
+	if err := writeContent(ctx, store, *indexDesc, indexData); err != nil {
+		return nil, err
+	}
+	return indexDesc, nil
+}
+
+// appendManifest writes the nydus manifest with the top layers appended,
+// the bootstrap layer is replaced by the merged one.
+func appendManifest(ctx context.Context, store content.Store, target platformManifest, tail ociTailManifest, refBootstraps map[digest.Digest]string, workDir string, opt Opt) (*ocispec.Descriptor, error) {
+	var blobs []ocispec.Descriptor
+	var lowerBootstrap *ocispec.Descriptor
+	for idx, layer := range target.manifest.Layers {
+		if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" {
+			lowerBootstrap = &target.manifest.Layers[idx]
+			continue
+		}
+		blobs = append(blobs, layer)
+	}
+
+	lowerPath := filepath.Join(workDir, target.desc.Digest.Encoded()+".lower")
+	if err := unpackBootstrap(ctx, store, *lowerBootstrap, lowerPath); err != nil {
+		return nil, errors.Wrap(err, "unpack lower bootstrap")
+	}
+	var refPaths, blobIDs []string
+	for _, layer := range tail.layers {
+		refPath, ok := refBootstraps[layer.Digest]
+		if !ok {
+			logrus.Infof("building OCI ref bootstrap of layer %s", layer.Digest)
+			var err error
+			if refPath, err = buildRefBootstrap(ctx, store, layer, workDir, opt); err != nil {
+				return nil, errors.Wrapf(err, "build OCI ref bootstrap of layer %s", layer.Digest)
+			}
+			refBootstraps[layer.Digest] = refPath
+		}
+		refPaths = append(refPaths, refPath)
+		// The blob IDs are the digests of OCI layers to read the chunks.
+		blobIDs = append(blobIDs, layer.Digest.Encoded())
+	}
+	bootstrapPath := filepath.Join(workDir, target.desc.Digest.Encoded()+".boot")
+	if err := build.NewBuilder(opt.NydusImagePath).Merge(build.MergeOption{
+		SourceBootstrapPaths: refPaths,
+		TargetBootstrapPath:  bootstrapPath,
+		ParentBootstrapPath:  lowerPath,
+		OriginalBlobIDs:      blobIDs,
+	}); err != nil {
+		return nil, errors.Wrap(err, "merge bootstraps")
+	}
+
+	bootstrap, bootstrapData, diffID, err := packBootstrapLayer(bootstrapPath)
+	if err != nil {
+		return nil, err
+	}
+	bootstrap.Annotations = copyAnnotations(lowerBootstrap.Annotations)
+	bootstrap.Annotations[utils.LayerAnnotationUncompressed] = diffID.String()
+
+	configData, err := content.ReadBlob(ctx, store, target.manifest.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "read config")
+	}
+	var config ocispec.Image
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return nil, errors.Wrap(err, "unmarshal config")
+	}
+	diffIDs := config.RootFS.DiffIDs
+	if len(diffIDs) != len(target.manifest.Layers) {
+		return nil, fmt.Errorf("%d diff ids in config mismatch with %d layers", len(diffIDs), len(target.manifest.Layers))
+	}
+	diffIDs = append(append(append([]digest.Digest{}, diffIDs[:len(diffIDs)-1]...), tail.diffIDs...), diffID)
+	history := append(append([]ocispec.History{}, config.History...), tail.history...)
+	if configData, err = rewriteConfig(configData, diffIDs, history); err != nil {
+		return nil, errors.Wrap(err, "rewrite config")
+	}
+
+	manifest := target.manifest
+	manifest.Config.Digest = digest.FromBytes(configData)
+	manifest.Config.Size = int64(len(configData))
+	manifest.Layers = blobs
+	for _, layer := range tail.layers {
+		layer.Annotations = copyAnnotations(layer.Annotations)
+		layer.Annotations[label.NydusRefLayer] = layer.Digest.String()
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+	manifest.Layers = append(manifest.Layers, *bootstrap)
+	manifestDesc, manifestData, err := utils.MarshalToDesc(manifest, target.desc.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal manifest")
+	}
+
+	if err := writeContent(ctx, store, *bootstrap, bootstrapData); err != nil {
+		return nil, err
+	}
+	if err := writeContent(ctx, store, manifest.Config, configData); err != nil {
+		return nil, err
+	}
+	if err := writeContent(ctx, store, *manifestDesc, manifestData); err != nil {
+		return nil, err
+	}
+
+	desc := target.desc
+	desc.Digest = manifestDesc.Digest
+	desc.Size = manifestDesc.Size
+	return &desc, nil
+}
+
+// buildRefBootstrap builds the OCI ref bootstrap of the gzip layer, which
+// references the chunks in the layer by the gzip context.
+func buildRefBootstrap(ctx context.Context, store content.Store, layer ocispec.Descriptor, workDir string, opt Opt) (string, error) {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return "", errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+
+	refPath := filepath.Join(workDir, layer.Digest.Encoded()+".ref")
+	refFile, err := os.Create(refPath)
+	if err != nil {
+		return "", errors.Wrap(err, "create OCI ref layer")
+	}
+	defer refFile.Close()
+	tw, err := snapConv.Pack(ctx, refFile, snapConv.PackOption{
+		WorkDir:     opt.WorkDir,
+		BuilderPath: opt.NydusImagePath,
+		FsVersion:   opt.FsVersion,
+		OCIRef:      true,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "pack layer")
+	}
+	if _, err := io.Copy(tw, content.NewReader(ra)); err != nil {
+		tw.Close()
+		return "", errors.Wrap(err, "write layer to builder")
+	}
+	if err := tw.Close(); err != nil {
+		return "", errors.Wrap(err, "pack layer")
+	}
+
+	refRa, err := local.OpenReader(refPath)
+	if err != nil {
+		return "", errors.Wrap(err, "open OCI ref layer")
+	}
+	defer refRa.Close()
+	bootstrapPath := filepath.Join(workDir, layer.Digest.Encoded()+".boot")
+	bootstrap, err := os.Create(bootstrapPath)
+	if err != nil {
+		return "", errors.Wrap(err, "create bootstrap")
+	}
+	defer bootstrap.Close()
+	if _, err := snapConv.UnpackEntry(refRa, snapConv.EntryBootstrap, bootstrap); err != nil {
+		return "", errors.Wrap(err, "unpack bootstrap")
+	}
+	return bootstrapPath, nil
+}
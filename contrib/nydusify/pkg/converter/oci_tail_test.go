@@ -0,0 +1,127 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+func TestOCITailLayers(t *testing.T) {
+	layers := []ocispec.Descriptor{
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Size: 100},
+		{MediaType: ocispec.MediaTypeImageLayerZstd, Size: 10},
+		{MediaType: images.MediaTypeDockerSchema2LayerGzip, Size: 50},
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Size: 10},
+	}
+	require.Equal(t, 0, ociTailLayers(layers, 0, 0))
+	require.Equal(t, 1, ociTailLayers(layers, 1, 0))
+	// The zstd layer can't be referenced by OCI ref.
+	require.Equal(t, 2, ociTailLayers(layers, 3, 0))
+	require.Equal(t, 1, ociTailLayers(layers, 3, 20))
+	require.Equal(t, 0, ociTailLayers(layers, 3, 5))
+	// The lowest layer is always converted.
+	require.Equal(t, 0, ociTailLayers(layers[:1], 1, 0))
+}
+
+func TestSplitHistory(t *testing.T) {
+	history := []ocispec.History{
+		{CreatedBy: "ADD rootfs"},
+		{CreatedBy: "ENV A=B", EmptyLayer: true},
+		{CreatedBy: "RUN make"},
+		{CreatedBy: "CMD sh", EmptyLayer: true},
+		{CreatedBy: "COPY app"},
+	}
+	lower, upper := splitHistory(history, 2)
+	require.Equal(t, history[:3], lower)
+	require.Equal(t, history[3:], upper)
+	lower, upper = splitHistory(history, 0)
+	require.Empty(t, lower)
+	require.Equal(t, history, upper)
+	lower, upper = splitHistory(history, 3)
+	require.Equal(t, history, lower)
+	require.Empty(t, upper)
+}
+
+func TestRewriteConfig(t *testing.T) {
+	data := []byte(`{"architecture":"amd64","os":"linux","config":{"Cmd":["sh"]},"container_config":{"Hostname":"x"},"rootfs":{"type":"layers","diff_ids":["sha256:a","sha256:b"]},"history":[{"created_by":"a"},{"created_by":"b"}]}`)
+	diffIDs := []digest.Digest{digest.FromString("a")}
+	rewritten, err := rewriteConfig(data, diffIDs, []ocispec.History{{CreatedBy: "a"}})
+	require.NoError(t, err)
+
+	var config ocispec.Image
+	require.NoError(t, json.Unmarshal(rewritten, &config))
+	require.Equal(t, diffIDs, config.RootFS.DiffIDs)
+	require.Equal(t, []ocispec.History{{CreatedBy: "a"}}, config.History)
+	require.Equal(t, []string{"sh"}, config.Config.Cmd)
+	// The fields unknown to OCI image spec are kept.
+	require.Contains(t, string(rewritten), `"container_config":{"Hostname":"x"}`)
+
+	rewritten, err = rewriteConfig(data, diffIDs, nil)
+	require.NoError(t, err)
+	require.NotContains(t, string(rewritten), `"history"`)
+}
+
+func TestSplitOCITail(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	pvd, err := provider.New(t.TempDir(), nil, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+
+	layers := []ocispec.Descriptor{
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-1"), Size: 1000},
+		{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer-2"), Size: 10},
+	}
+	diffIDs := []digest.Digest{digest.FromString("diff-1"), digest.FromString("diff-2")}
+	config := ocispec.Image{
+		Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"},
+		RootFS:   ocispec.RootFS{Type: "layers", DiffIDs: diffIDs},
+		History:  []ocispec.History{{CreatedBy: "ADD rootfs"}, {CreatedBy: "COPY app"}},
+	}
+	configDesc := writeTestJSON(t, store, config, ocispec.MediaTypeImageConfig)
+	manifestDesc := writeTestJSON(t, store, ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Config: configDesc, Layers: layers}, ocispec.MediaTypeImageManifest)
+	manifestDesc.Platform = &config.Platform
+	indexDesc := writeTestJSON(t, store, ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: []ocispec.Descriptor{manifestDesc}}, ocispec.MediaTypeImageIndex)
+	source := pvd.AddLocalImage(indexDesc)
+
+	// The top layer is larger than the max size.
+	lower, tails, err := splitOCITail(ctx, pvd, source, platforms.All, Opt{OCITailLayers: 1, OCITailMaxSize: 5})
+	require.NoError(t, err)
+	require.Equal(t, source, lower)
+	require.Empty(t, tails)
+
+	lower, tails, err = splitOCITail(ctx, pvd, source, platforms.All, Opt{OCITailLayers: 2})
+	require.NoError(t, err)
+	require.Len(t, tails, 1)
+	require.Equal(t, layers[1:], tails[0].layers)
+	require.Equal(t, diffIDs[1:], tails[0].diffIDs)
+	require.Equal(t, config.History[1:], tails[0].history)
+
+	lowerDesc, err := pvd.Image(ctx, lower)
+	require.NoError(t, err)
+	manifests, err := platformManifests(ctx, store, *lowerDesc)
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	require.Equal(t, &config.Platform, manifests[0].desc.Platform)
+	require.Equal(t, layers[:1], manifests[0].manifest.Layers)
+	configData, err := content.ReadBlob(ctx, store, manifests[0].manifest.Config)
+	require.NoError(t, err)
+	var lowerConfig ocispec.Image
+	require.NoError(t, json.Unmarshal(configData, &lowerConfig))
+	require.Equal(t, diffIDs[:1], lowerConfig.RootFS.DiffIDs)
+	require.Equal(t, config.History[:1], lowerConfig.History)
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// convertiblePlatforms matches the platforms of MatchComparer which can be
+// converted to nydus, nydus image is only supported on linux.
+type convertiblePlatforms struct {
+	platforms.MatchComparer
+}
+
+func (c convertiblePlatforms) Match(platform ocispec.Platform) bool {
+	return platform.OS == "linux" && c.MatchComparer.Match(platform)
+}
+
+// skipPlatforms doesn't match the platforms of skipped manifests.
+type skipPlatforms struct {
+	platforms.MatchComparer
+	skipped []ocispec.Descriptor
+}
+
+func (s skipPlatforms) Match(platform ocispec.Platform) bool {
+	for _, desc := range s.skipped {
+		if samePlatform(desc.Platform, &platform) {
+			return false
+		}
+	}
+	return s.MatchComparer.Match(platform)
+}
+
+// splitPlatforms returns the manifests in source index which are converted
+// by convertMC, and the ones matched by platformMC but can't be converted,
+// e.g. windows images, attestation manifests and the images having
+// non-distributable layers if not allowed, which are passed through to the
+// merged index as is. Both are in the order of source index.
+func splitPlatforms(ctx context.Context, pvd *provider.Provider, source string, platformMC, convertMC platforms.MatchComparer, allowNondistributable bool) ([]ocispec.Descriptor, []ocispec.Descriptor, error) {
+	store := pvd.ContentStore()
+	if err := pvd.Pull(ctx, source); err != nil {
+		return nil, nil, errors.Wrap(err, "pull source image")
+	}
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get source image")
+	}
+	index, err := readIndex(ctx, store, *sourceDesc)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "read source index")
+	}
+	descs := []ocispec.Descriptor{*sourceDesc}
+	if index != nil {
+		descs = index.Manifests
+	}
+
+	var converted, passthrough []ocispec.Descriptor
+	for _, desc := range descs {
+		if desc.Platform != nil && !convertMC.Match(*desc.Platform) {
+			if platformMC.Match(*desc.Platform) {
+				passthrough = append(passthrough, desc)
+			}
+			continue
+		}
+		if !allowNondistributable {
+			nondistributable, err := hasNondistributableLayers(ctx, store, desc)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "read manifest %s", desc.Digest)
+			}
+			// The manifest without platform can't be skipped in conversion.
+			if nondistributable && desc.Platform == nil {
+				return nil, nil, fmt.Errorf("manifest %s has non-distributable layers", desc.Digest)
+			}
+			if nondistributable {
+				logrus.Warnf("skipped converting manifest %s of platform %s, which has non-distributable layers", desc.Digest, platforms.Format(*desc.Platform))
+				passthrough = append(passthrough, desc)
+				continue
+			}
+		}
+		converted = append(converted, desc)
+	}
+	if len(converted) == 0 {
+		return nil, nil, errors.New("no platform of source image can be converted")
+	}
+	return converted, passthrough, nil
+}
+
+// hasNondistributableLayers reports whether the manifest has layers which
+// are not allowed to be redistributed, e.g. the foreign layers of windows
+// base images.
+func hasNondistributableLayers(ctx context.Context, store content.Store, desc ocispec.Descriptor) (bool, error) {
+	if !images.IsManifestType(desc.MediaType) {
+		return false, nil
+	}
+	data, err := content.ReadBlob(ctx, store, desc)
+	if err != nil {
+		return false, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return false, err
+	}
+	for _, layer := range manifest.Layers {
+		if images.IsNonDistributable(layer.MediaType) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// appendManifests writes the target index with the manifests appended, the
+// ones already in target index are skipped.
+func appendManifests(ctx context.Context, store content.Store, target ocispec.Descriptor, manifests []ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	index, err := readIndex(ctx, store, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target index")
+	}
+	if index == nil {
+		return nil, errors.New("target image is not an index")
+	}
+	existing := map[digest.Digest]bool{}
+	for _, desc := range index.Manifests {
+		existing[desc.Digest] = true
+	}
+	for _, desc := range manifests {
+		if !existing[desc.Digest] {
+			index.Manifests = append(index.Manifests, desc)
+		}
+	}
+
+	indexDesc, indexData, err := utils.MarshalToDesc(index, target.MediaType)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal target index")
+	}
+	if err := writeContent(ctx, store, *indexDesc, indexData); err != nil {
+		return nil, err
+	}
+	return indexDesc, nil
+}
+
+// platformNames formats the platforms of manifests, the manifest without
+// platform is skipped.
+func platformNames(manifests []ocispec.Descriptor) []string {
+	var names []string
+	for _, desc := range manifests {
+		if desc.Platform != nil {
+			names = append(names, platforms.Format(*desc.Platform))
+		}
+	}
+	return names
+}
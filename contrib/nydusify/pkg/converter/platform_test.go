@@ -0,0 +1,93 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+)
+
+func TestConvertiblePlatforms(t *testing.T) {
+	convertMC := convertiblePlatforms{platforms.All}
+	require.True(t, convertMC.Match(ocispec.Platform{OS: "linux", Architecture: "amd64"}))
+	require.False(t, convertMC.Match(ocispec.Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.5820"}))
+	require.False(t, convertMC.Match(ocispec.Platform{OS: "unknown", Architecture: "unknown"}))
+
+	convertMC = convertiblePlatforms{platforms.Only(ocispec.Platform{OS: "linux", Architecture: "arm64"})}
+	require.False(t, convertMC.Match(ocispec.Platform{OS: "linux", Architecture: "amd64"}))
+}
+
+func TestSplitPlatforms(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	pvd, err := provider.New(t.TempDir(), nil, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+
+	foreign := ocispec.Descriptor{
+		MediaType: images.MediaTypeDockerSchema2LayerForeignGzip,
+		Digest:    digest.FromString("foreign"),
+		Size:      7,
+		URLs:      []string{"https://a.b/foreign"},
+	}
+	var manifests []ocispec.Descriptor
+	for _, platform := range []ocispec.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+		{OS: "windows", Architecture: "amd64"},
+		{OS: "unknown", Architecture: "unknown"},
+	} {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    writeTestJSON(t, store, ocispec.Image{Platform: platform}, ocispec.MediaTypeImageConfig),
+		}
+		if platform.Architecture == "arm64" {
+			manifest.Layers = []ocispec.Descriptor{foreign}
+		}
+		desc := writeTestJSON(t, store, manifest, ocispec.MediaTypeImageManifest)
+		desc.Platform = &platform
+		manifests = append(manifests, desc)
+	}
+	indexDesc := writeTestJSON(t, store, ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: manifests}, ocispec.MediaTypeImageIndex)
+	source := pvd.AddLocalImage(indexDesc)
+
+	converted, passthrough, err := splitPlatforms(ctx, pvd, source, platforms.All, convertiblePlatforms{platforms.All}, false)
+	require.NoError(t, err)
+	require.Equal(t, manifests[:1], converted)
+	require.Equal(t, manifests[1:], passthrough)
+	require.Equal(t, []string{"linux/amd64"}, platformNames(converted))
+	require.Equal(t, []string{"linux/arm64", "windows/amd64", "unknown/unknown"}, platformNames(passthrough))
+
+	skipMC := skipPlatforms{MatchComparer: platforms.All, skipped: passthrough}
+	require.True(t, skipMC.Match(*manifests[0].Platform))
+	require.False(t, skipMC.Match(*manifests[1].Platform))
+
+	// The manifest having non-distributable layers is converted if allowed.
+	converted, passthrough, err = splitPlatforms(ctx, pvd, source, platforms.All, convertiblePlatforms{platforms.All}, true)
+	require.NoError(t, err)
+	require.Equal(t, manifests[:2], converted)
+	require.Equal(t, manifests[2:], passthrough)
+
+	// The platforms not matched are neither converted nor copied.
+	s390x := platforms.Only(ocispec.Platform{OS: "linux", Architecture: "s390x"})
+	_, _, err = splitPlatforms(ctx, pvd, source, s390x, convertiblePlatforms{s390x}, false)
+	require.ErrorContains(t, err, "no platform of source image can be converted")
+
+	targetDesc := writeTestJSON(t, store, ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: manifests[:3]}, ocispec.MediaTypeImageIndex)
+	desc, err := appendManifests(ctx, store, targetDesc, passthrough)
+	require.NoError(t, err)
+	index, err := readIndex(ctx, store, *desc)
+	require.NoError(t, err)
+	require.Equal(t, manifests, index.Manifests)
+}
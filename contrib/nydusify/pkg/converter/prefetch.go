@@ -0,0 +1,222 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/platforms"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPath is the PATH of container if it's not in the image config.
+const defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// runtimeFiles are commonly read by the programs on start.
+var runtimeFiles = []string{
+	"/etc/ld.so.cache",
+	"/etc/nsswitch.conf",
+	"/etc/passwd",
+	"/etc/group",
+	"/etc/hosts",
+	"/etc/localtime",
+	"/etc/ssl/certs/ca-certificates.crt",
+}
+
+// runtimeLibraries are the dynamic loaders and C libraries of glibc and
+// musl by architecture, both /lib and /usr/lib are listed for the images
+// with merged /usr.
+var runtimeLibraries = map[string][]string{
+	"amd64": {
+		"/lib64/ld-linux-x86-64.so.2",
+		"/lib/x86_64-linux-gnu/libc.so.6",
+		"/usr/lib/x86_64-linux-gnu/libc.so.6",
+		"/lib/ld-musl-x86_64.so.1",
+	},
+	"arm64": {
+		"/lib/ld-linux-aarch64.so.1",
+		"/lib/aarch64-linux-gnu/libc.so.6",
+		"/usr/lib/aarch64-linux-gnu/libc.so.6",
+		"/lib/ld-musl-aarch64.so.1",
+	},
+}
+
+// shells run the command string following "-c".
+var shells = map[string]bool{
+	"sh":   true,
+	"bash": true,
+	"dash": true,
+	"ash":  true,
+}
+
+// interpreters run the script of their first non-flag argument.
+var interpreters = []string{"python", "node", "ruby", "perl", "php", "java"}
+
+func isInterpreter(program string) bool {
+	for _, interpreter := range interpreters {
+		if strings.HasPrefix(program, interpreter) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAutoPrefetch generates the prefetch patterns from the image configs
+// of source image, unless the prefetch patterns are specified by user
+// explicitly.
+func applyAutoPrefetch(ctx context.Context, store content.Store, image ocispec.Descriptor, platformMC platforms.MatchComparer, opt *Opt) error {
+	if opt.PrefetchPatterns != "" && opt.PrefetchPatterns != "/" {
+		logrus.Infof("skip generating prefetch patterns, use the specified prefetch patterns")
+		return nil
+	}
+	manifests, err := matchedManifests(ctx, store, image, platformMC)
+	if err != nil {
+		return errors.Wrap(err, "get manifests of source image")
+	}
+
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, manifest := range manifests {
+		data, err := content.ReadBlob(ctx, store, manifest.Config)
+		if err != nil {
+			return errors.Wrap(err, "read image config")
+		}
+		var config ocispec.Image
+		if err := json.Unmarshal(data, &config); err != nil {
+			return errors.Wrap(err, "unmarshal image config")
+		}
+		for _, pattern := range autoPrefetchPatterns(config) {
+			if !seen[pattern] {
+				seen[pattern] = true
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	if len(patterns) == 0 {
+		logrus.Infof("no prefetch pattern generated from image config")
+		return nil
+	}
+	logrus.Infof("use %d prefetch patterns generated from image config", len(patterns))
+	opt.PrefetchPatterns = strings.Join(patterns, "\n")
+	return nil
+}
+
+// autoPrefetchPatterns guesses the files read on container start by the
+// entrypoint, cmd and env of image config, and the common runtime files.
+// The patterns missing in image are ignored by nydus-image, so all the
+// candidates of a program in PATH are listed.
+func autoPrefetchPatterns(image ocispec.Image) []string {
+	if image.OS == "windows" {
+		return nil
+	}
+	config := image.Config
+	if len(config.Entrypoint) == 0 && len(config.Cmd) == 0 {
+		return nil
+	}
+	env := map[string]string{}
+	for _, kv := range config.Env {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	searchPath, ok := env["PATH"]
+	if !ok {
+		searchPath = defaultPath
+	}
+	workDir := config.WorkingDir
+	if workDir == "" {
+		workDir = "/"
+	}
+
+	var patterns []string
+	seen := make(map[string]bool)
+	add := func(pattern string) {
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	// resolve returns the candidate paths of a program or script.
+	resolve := func(name string, lookPath bool) []string {
+		if path.IsAbs(name) {
+			return []string{path.Clean(name)}
+		}
+		if !lookPath || strings.Contains(name, "/") {
+			return []string{path.Join(workDir, name)}
+		}
+		var paths []string
+		for _, dir := range strings.Split(searchPath, ":") {
+			if path.IsAbs(dir) {
+				paths = append(paths, path.Join(dir, name))
+			}
+		}
+		return paths
+	}
+
+	args := append(append([]string{}, config.Entrypoint...), config.Cmd...)
+	for len(args) > 0 {
+		for _, program := range resolve(args[0], true) {
+			add(program)
+		}
+		name := path.Base(args[0])
+		args = args[1:]
+		if shells[name] {
+			if len(args) < 2 || args[0] != "-c" {
+				break
+			}
+			// The command string of shell is parsed roughly by words, the
+			// leading exec and environment variables are skipped.
+			var words []string
+			for _, word := range strings.Fields(args[1]) {
+				if len(words) == 0 && (word == "exec" || strings.Contains(word, "=")) {
+					continue
+				}
+				words = append(words, strings.Trim(word, `"'`))
+			}
+			args = words
+			continue
+		}
+		if strings.HasSuffix(name, ".sh") {
+			add("/bin/sh")
+			// The entrypoint script usually runs its arguments at last,
+			// like `exec "$@"`.
+			if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+				continue
+			}
+			break
+		}
+		if isInterpreter(name) {
+			for _, arg := range args {
+				if !strings.HasPrefix(arg, "-") {
+					for _, script := range resolve(arg, false) {
+						add(script)
+					}
+					break
+				}
+			}
+		}
+		break
+	}
+
+	for _, dir := range strings.Split(env["LD_LIBRARY_PATH"], ":") {
+		if path.IsAbs(dir) {
+			add(path.Clean(dir))
+		}
+	}
+	for _, library := range runtimeLibraries[image.Architecture] {
+		add(library)
+	}
+	for _, file := range runtimeFiles {
+		add(file)
+	}
+
+	return patterns
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoPrefetchPatterns(t *testing.T) {
+	image := func(arch string, config ocispec.ImageConfig) ocispec.Image {
+		return ocispec.Image{
+			Platform: ocispec.Platform{OS: "linux", Architecture: arch},
+			Config:   config,
+		}
+	}
+
+	// The program is looked up in PATH, and the script of entrypoint runs
+	// the program of cmd.
+	patterns := autoPrefetchPatterns(image("arm64", ocispec.ImageConfig{
+		Env:        []string{"PATH=/usr/sbin:/usr/bin", "LD_LIBRARY_PATH=/opt/lib:relative"},
+		Entrypoint: []string{"/docker-entrypoint.sh"},
+		Cmd:        []string{"nginx", "-g", "daemon off;"},
+	}))
+	require.Equal(t, append([]string{
+		"/docker-entrypoint.sh",
+		"/bin/sh",
+		"/usr/sbin/nginx",
+		"/usr/bin/nginx",
+		"/opt/lib",
+	}, append(runtimeLibraries["arm64"], runtimeFiles...)...), patterns)
+
+	// The command string of shell and the script of interpreter are
+	// resolved in working directory.
+	patterns = autoPrefetchPatterns(image("riscv64", ocispec.ImageConfig{
+		WorkingDir: "/app",
+		Cmd:        []string{"/bin/sh", "-c", "exec FOO=bar python3 -u 'main.py' --port 80"},
+	}))
+	require.Equal(t, append([]string{
+		"/bin/sh",
+		"/usr/local/sbin/python3",
+		"/usr/local/bin/python3",
+		"/usr/sbin/python3",
+		"/usr/bin/python3",
+		"/sbin/python3",
+		"/bin/python3",
+		"/app/main.py",
+	}, runtimeFiles...), patterns)
+
+	patterns = autoPrefetchPatterns(image("amd64", ocispec.ImageConfig{
+		Entrypoint: []string{"./bin/server"},
+		Cmd:        []string{"--config", "/etc/server.yaml"},
+	}))
+	require.Equal(t, "/bin/server", patterns[0])
+	require.Contains(t, patterns, "/lib64/ld-linux-x86-64.so.2")
+	require.NotContains(t, patterns, "/etc/server.yaml")
+
+	// Nothing is guessed without entrypoint and cmd.
+	require.Empty(t, autoPrefetchPatterns(image("amd64", ocispec.ImageConfig{Env: []string{"PATH=/bin"}})))
+	require.Empty(t, autoPrefetchPatterns(ocispec.Image{
+		Platform: ocispec.Platform{OS: "windows"},
+		Config:   ocispec.ImageConfig{Cmd: []string{"cmd.exe"}},
+	}))
+}
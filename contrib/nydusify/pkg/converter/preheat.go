@@ -0,0 +1,93 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/preheat"
+)
+
+// manifestURL returns the URL of the manifest of ref used by preheat.
+func manifestURL(ref string, plainHTTP bool) (string, string, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "parse reference %s", ref)
+	}
+	host := reference.Domain(named)
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	if plainHTTP {
+		scheme = "http"
+	}
+	var version string
+	if digested, ok := named.(reference.Digested); ok {
+		version = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		version = tagged.Tag()
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, reference.Path(named), version), host, nil
+}
+
+// preheatImage creates the preheat jobs of target image in Dragonfly for
+// each platform, and waits for them to finish if PreheatTimeout is set.
+func preheatImage(ctx context.Context, opt Opt) error {
+	url, host, err := manifestURL(opt.Target, opt.WithPlainHTTP)
+	if err != nil {
+		return err
+	}
+	credFunc, _, err := hosts(opt)(opt.Target)
+	if err != nil {
+		return errors.Wrap(err, "get target credential")
+	}
+	username, password, err := credFunc(host)
+	if err != nil {
+		return errors.Wrap(err, "get target credential")
+	}
+
+	platforms := []string{""}
+	if !opt.AllPlatforms && opt.Platforms != "" {
+		platforms = strings.Split(opt.Platforms, ",")
+	}
+
+	dragonfly := preheat.NewDragonfly(opt.PreheatDragonflyEndpoint, opt.PreheatDragonflyToken)
+	var ids []uint64
+	for _, platform := range platforms {
+		id, err := dragonfly.Preheat(ctx, preheat.Option{
+			URL:      url,
+			Username: username,
+			Password: password,
+			Platform: strings.TrimSpace(platform),
+			Scope:    opt.PreheatScope,
+		})
+		if err != nil {
+			return err
+		}
+		logrus.Infof("created preheat job %d of %s for platform %q", id, opt.Target, platform)
+		ids = append(ids, id)
+	}
+
+	if opt.PreheatTimeout <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, opt.PreheatTimeout)
+	defer cancel()
+	for _, id := range ids {
+		if err := dragonfly.Wait(ctx, id); err != nil {
+			return err
+		}
+	}
+	logrus.Infof("preheated target image %s", opt.Target)
+
+	return nil
+}
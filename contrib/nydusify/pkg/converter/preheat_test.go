@@ -0,0 +1,30 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestURL(t *testing.T) {
+	url, host, err := manifestURL("nginx:latest-nydus", false)
+	require.NoError(t, err)
+	require.Equal(t, "https://registry-1.docker.io/v2/library/nginx/manifests/latest-nydus", url)
+	require.Equal(t, "registry-1.docker.io", host)
+
+	url, host, err = manifestURL("localhost:5000/team/app", true)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:5000/v2/team/app/manifests/latest", url)
+	require.Equal(t, "localhost:5000", host)
+
+	url, _, err = manifestURL("registry.example.com/app@sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", false)
+	require.NoError(t, err)
+	require.Equal(t, "https://registry.example.com/v2/app/manifests/sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", url)
+
+	_, _, err = manifestURL("INVALID", false)
+	require.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	containerdclient "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/pkg/identifiers"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// ContainerdScheme is the prefix of an image in the content store of local
+// containerd, for example `containerd://k8s.io/docker.io/library/nginx:latest`.
+const ContainerdScheme = "containerd://"
+
+// ContainerdSource is an image in the content store of local containerd.
+type ContainerdSource struct {
+	Address   string
+	Namespace string
+	Image     string
+}
+
+// ParseContainerdSource parses the source like `containerd://<namespace>/<image>`,
+// the image is read from containerd listening on address.
+func ParseContainerdSource(source, address string) (*ContainerdSource, error) {
+	value := strings.TrimPrefix(source, ContainerdScheme)
+	namespace, image, ok := strings.Cut(value, "/")
+	if !strings.HasPrefix(source, ContainerdScheme) || !ok || namespace == "" || image == "" {
+		return nil, fmt.Errorf("invalid containerd source %s, should be %s<namespace>/<image>", source, ContainerdScheme)
+	}
+	if err := identifiers.Validate(namespace); err != nil {
+		return nil, errors.Wrapf(err, "invalid namespace of containerd source %s", source)
+	}
+	return &ContainerdSource{Address: address, Namespace: namespace, Image: image}, nil
+}
+
+// ImportContainerd copies the image of platforms matched by provider from
+// the content store of containerd, and returns the reference used to find
+// the image by `Pull` and `Image`.
+func (pvd *Provider) ImportContainerd(ctx context.Context, src *ContainerdSource) (string, error) {
+	client, err := containerdclient.New(src.Address)
+	if err != nil {
+		return "", errors.Wrapf(err, "connect to containerd %s", src.Address)
+	}
+	defer client.Close()
+	ctx = namespaces.WithNamespace(ctx, src.Namespace)
+
+	img, err := getContainerdImage(ctx, client.ImageService(), src.Image)
+	if err != nil {
+		return "", err
+	}
+
+	cs := client.ContentStore()
+	fetcher := remotes.FetcherFunc(func(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+		ra, err := cs.ReaderAt(ctx, desc)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				return nil, errors.Wrapf(err, "content %s of image %s not found in containerd, the image may be pulled for other platforms", desc.Digest, img.Name)
+			}
+			return nil, err
+		}
+		return &readCloser{Reader: content.NewReader(ra), close: ra.Close}, nil
+	})
+	handler := images.Handlers(
+		remotes.FetchHandler(pvd.store, fetcher),
+		images.FilterPlatforms(images.ChildrenHandler(cs), pvd.platformMC),
+	)
+	if err := images.Dispatch(ctx, handler, nil, img.Target); err != nil {
+		return "", errors.Wrapf(err, "copy image %s from containerd", img.Name)
+	}
+
+	ref := localImageRepo + ":" + img.Target.Digest.Encoded()
+	pvd.mutex.Lock()
+	pvd.images[ref] = &img.Target
+	pvd.localImages[ref] = true
+	pvd.mutex.Unlock()
+
+	return ref, nil
+}
+
+// getContainerdImage gets the image by name, or by the normalized name like
+// `docker.io/library/nginx:latest` for `nginx:latest`.
+func getContainerdImage(ctx context.Context, store images.Store, name string) (*images.Image, error) {
+	img, err := store.Get(ctx, name)
+	if errdefs.IsNotFound(err) {
+		if named, parseErr := reference.ParseDockerRef(name); parseErr == nil && named.String() != name {
+			img, err = store.Get(ctx, named.String())
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "get image %s from containerd", name)
+	}
+	return &img, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContainerdSource(t *testing.T) {
+	require.True(t, IsLocalSource("containerd://k8s.io/nginx:latest"))
+
+	src, err := ParseContainerdSource("containerd://k8s.io/docker.io/library/nginx:latest", "/run/containerd/containerd.sock")
+	require.NoError(t, err)
+	require.Equal(t, &ContainerdSource{
+		Address:   "/run/containerd/containerd.sock",
+		Namespace: "k8s.io",
+		Image:     "docker.io/library/nginx:latest",
+	}, src)
+
+	for _, source := range []string{
+		"containerd://nginx:latest",
+		"containerd:///nginx:latest",
+		"containerd://default/",
+		"containerd://-invalid/nginx:latest",
+		"docker.io/library/nginx:latest",
+	} {
+		_, err := ParseContainerdSource(source, "")
+		require.Error(t, err, source)
+	}
+}
+
+type fakeImageStore struct {
+	images.Store
+	images map[string]images.Image
+}
+
+func (s *fakeImageStore) Get(_ context.Context, name string) (images.Image, error) {
+	img, ok := s.images[name]
+	if !ok {
+		return images.Image{}, errdefs.ErrNotFound
+	}
+	return img, nil
+}
+
+func TestGetContainerdImage(t *testing.T) {
+	store := &fakeImageStore{images: map[string]images.Image{
+		"docker.io/library/nginx:latest": {Name: "docker.io/library/nginx:latest"},
+		"local-image":                    {Name: "local-image"},
+	}}
+
+	for _, name := range []string{"nginx:latest", "nginx", "docker.io/library/nginx:latest"} {
+		img, err := getContainerdImage(context.Background(), store, name)
+		require.NoError(t, err)
+		require.Equal(t, "docker.io/library/nginx:latest", img.Name)
+	}
+
+	img, err := getContainerdImage(context.Background(), store, "local-image")
+	require.NoError(t, err)
+	require.Equal(t, "local-image", img.Name)
+
+	_, err = getContainerdImage(context.Background(), store, "busybox:latest")
+	require.True(t, errdefs.IsNotFound(err))
+}
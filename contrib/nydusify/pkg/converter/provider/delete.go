@@ -0,0 +1,54 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// DeleteManifest deletes the manifest of dgst in the repository of ref by
+// the manifest delete API, the blobs are reclaimed by the garbage collection
+// of registry, see
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#deleting-manifests.
+func (pvd *Provider) DeleteManifest(ctx context.Context, ref string, dgst digest.Digest) error {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	hosts, err := pvd.registryHosts(ref)
+	if err != nil {
+		return err
+	}
+	repo := reference.Path(named)
+	scopedCtx := docker.WithScope(ctx, fmt.Sprintf("repository:%s:pull,push,delete", repo))
+
+	for _, host := range hosts {
+		if host.Capabilities&docker.HostCapabilityPush == 0 {
+			continue
+		}
+		url := fmt.Sprintf("%s://%s%s/%s/manifests/%s", host.Scheme, host.Host, host.Path, repo, dgst)
+		resp, err := doRequest(scopedCtx, host, http.MethodDelete, url, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+			return nil
+		case http.StatusMethodNotAllowed:
+			return fmt.Errorf("deleting manifest is not allowed by registry %s", host.Host)
+		default:
+			return fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+		}
+	}
+	return fmt.Errorf("no registry host to delete manifest of %s", ref)
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/containers/ocicrypt"
+	encconfig "github.com/containers/ocicrypt/config"
+	"github.com/distribution/reference"
+	"github.com/goharbor/acceleration-service/pkg/utils"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// encryptedSuffix is appended to the media type of encrypted layers, see
+// https://github.com/containers/ocicrypt/blob/main/docs/spec.md.
+const encryptedSuffix = "+encrypted"
+
+// SetEncryption encrypts the layers of the image pushed to ref with the
+// crypto config, the encryption metadata is written into the annotations
+// of layers. Other refs (e.g. build cache) are pushed as is.
+func (pvd *Provider) SetEncryption(ref string, cc *encconfig.CryptoConfig) error {
+	if cc == nil || cc.EncryptConfig == nil {
+		return errors.New("encrypt config is required")
+	}
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.encryptRef = named.String()
+	pvd.encryptConfig = cc.EncryptConfig
+	return nil
+}
+
+// encryptConfigFor returns the encrypt config if the image of ref should
+// be encrypted.
+func (pvd *Provider) encryptConfigFor(ref string) *encconfig.EncryptConfig {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.encryptConfig == nil {
+		return nil
+	}
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil || named.String() != pvd.encryptRef {
+		return nil
+	}
+	return pvd.encryptConfig
+}
+
+// encryptImage encrypts the layers of all manifests matching the platforms
+// in image, and returns the descriptor of the rewritten image.
+func encryptImage(ctx context.Context, store content.Store, desc ocispec.Descriptor, ref string, ec *encconfig.EncryptConfig, platformMC platforms.MatchComparer) (*ocispec.Descriptor, error) {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, images.MediaTypeDockerSchema2ManifestList:
+		var index ocispec.Index
+		if _, err := utils.ReadJSON(ctx, store, &index, desc); err != nil {
+			return nil, errors.Wrap(err, "read index")
+		}
+		for idx, manifest := range index.Manifests {
+			if manifest.Platform != nil && !platformMC.Match(*manifest.Platform) {
+				continue
+			}
+			encrypted, err := encryptImage(ctx, store, manifest, ref, ec, platformMC)
+			if err != nil {
+				return nil, err
+			}
+			index.Manifests[idx] = *encrypted
+		}
+		return utils.WriteJSON(ctx, store, index, desc, ref, nil)
+	case ocispec.MediaTypeImageManifest, images.MediaTypeDockerSchema2Manifest:
+		var manifest ocispec.Manifest
+		if _, err := utils.ReadJSON(ctx, store, &manifest, desc); err != nil {
+			return nil, errors.Wrap(err, "read manifest")
+		}
+		for idx, layer := range manifest.Layers {
+			encrypted, err := encryptLayer(ctx, store, layer, ec)
+			if err != nil {
+				return nil, errors.Wrapf(err, "encrypt layer %s", layer.Digest)
+			}
+			manifest.Layers[idx] = *encrypted
+		}
+		return utils.WriteJSON(ctx, store, manifest, desc, ref, nil)
+	default:
+		return nil, errors.Errorf("unsupported media type %s", desc.MediaType)
+	}
+}
+
+// encryptLayer writes the encrypted layer into content store, the layer
+// is skipped if it's already encrypted.
+func encryptLayer(ctx context.Context, store content.Store, desc ocispec.Descriptor, ec *encconfig.EncryptConfig) (*ocispec.Descriptor, error) {
+	if strings.HasSuffix(desc.MediaType, encryptedSuffix) {
+		return &desc, nil
+	}
+
+	ra, err := store.ReaderAt(ctx, desc)
+	if err != nil {
+		return nil, errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+
+	reader, finalizer, err := ocicrypt.EncryptLayer(ec, content.NewReader(ra), desc)
+	if err != nil {
+		return nil, errors.Wrap(err, "create layer encryptor")
+	}
+
+	cw, err := content.OpenWriter(ctx, store, content.WithRef("encrypt-"+desc.Digest.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "open content store writer")
+	}
+	defer cw.Close()
+
+	size, err := io.Copy(cw, reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy encrypted layer into content store")
+	}
+	encryptedDigest := cw.Digest()
+	if err := cw.Commit(ctx, size, encryptedDigest); err != nil {
+		if !errdefs.IsAlreadyExists(err) {
+			return nil, errors.Wrap(err, "commit encrypted layer")
+		}
+	}
+
+	encAnnotations, err := finalizer()
+	if err != nil {
+		return nil, errors.Wrap(err, "finalize layer encryption")
+	}
+
+	annotations := make(map[string]string, len(desc.Annotations)+len(encAnnotations))
+	for key, value := range desc.Annotations {
+		annotations[key] = value
+	}
+	for key, value := range encAnnotations {
+		annotations[key] = value
+	}
+
+	logrus.WithField("digest", desc.Digest).Debugf("encrypted layer to %s", encryptedDigest)
+
+	return &ocispec.Descriptor{
+		MediaType:   desc.MediaType + encryptedSuffix,
+		Digest:      encryptedDigest,
+		Size:        size,
+		Annotations: annotations,
+	}, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containers/ocicrypt"
+	encconfig "github.com/containers/ocicrypt/config"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCryptoConfig(t *testing.T) (encconfig.CryptoConfig, encconfig.CryptoConfig) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	ecc, err := encconfig.EncryptWithJwe([][]byte{pubPEM})
+	require.NoError(t, err)
+	dcc, err := encconfig.DecryptWithPrivKeys([][]byte{privPEM}, [][]byte{nil})
+	require.NoError(t, err)
+	return ecc, dcc
+}
+
+func TestEncryptConfigFor(t *testing.T) {
+	ecc, _ := newTestCryptoConfig(t)
+
+	pvd := &Provider{}
+	require.Nil(t, pvd.encryptConfigFor("busybox:latest-nydus"))
+
+	require.NoError(t, pvd.SetEncryption("busybox:latest-nydus", &ecc))
+	require.NotNil(t, pvd.encryptConfigFor("busybox:latest-nydus"))
+	require.NotNil(t, pvd.encryptConfigFor("docker.io/library/busybox:latest-nydus"))
+	require.Nil(t, pvd.encryptConfigFor("busybox:cache"))
+
+	require.Error(t, pvd.SetEncryption("Invalid:Ref", &ecc))
+	require.Error(t, pvd.SetEncryption("busybox:latest-nydus", &encconfig.CryptoConfig{}))
+}
+
+func TestEncryptLayer(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("nydus blob"), 1024)
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+		Annotations: map[string]string{
+			"containerd.io/snapshot/nydus-blob": "true",
+		},
+	}
+	require.NoError(t, content.WriteBlob(ctx, store, "layer", bytes.NewReader(data), desc))
+
+	ecc, dcc := newTestCryptoConfig(t)
+	encrypted, err := encryptLayer(ctx, store, desc, ecc.EncryptConfig)
+	require.NoError(t, err)
+	require.Equal(t, ocispec.MediaTypeImageLayerGzip+"+encrypted", encrypted.MediaType)
+	require.NotEqual(t, desc.Digest, encrypted.Digest)
+	require.Equal(t, "true", encrypted.Annotations["containerd.io/snapshot/nydus-blob"])
+	require.NotEmpty(t, encrypted.Annotations["org.opencontainers.image.enc.keys.jwe"])
+	require.NotEmpty(t, encrypted.Annotations["org.opencontainers.image.enc.pubopts"])
+
+	encryptedData, err := content.ReadBlob(ctx, store, *encrypted)
+	require.NoError(t, err)
+	require.Equal(t, encrypted.Size, int64(len(encryptedData)))
+	reader, _, err := ocicrypt.DecryptLayer(dcc.DecryptConfig, bytes.NewReader(encryptedData), *encrypted, false)
+	require.NoError(t, err)
+	decrypted, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, data, decrypted)
+
+	// The encrypted layer is not encrypted again.
+	again, err := encryptLayer(ctx, store, *encrypted, ecc.EncryptConfig)
+	require.NoError(t, err)
+	require.Equal(t, encrypted, again)
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// layoutMutex serializes the updates of `index.json` when multiple images
+// are written to the same OCI image layout concurrently.
+var layoutMutex sync.Mutex
+
+// SetOutputLayout writes the image pushed to ref into the OCI image layout
+// directory instead of the registry, other refs (e.g. build cache) are still
+// pushed to registry.
+func (pvd *Provider) SetOutputLayout(ref, dir string) error {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.layoutRef = named.String()
+	pvd.layoutDir = dir
+	return nil
+}
+
+// layoutDirFor returns the OCI image layout directory if the image of ref
+// should be written to local.
+func (pvd *Provider) layoutDirFor(ref string) string {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.layoutDir == "" {
+		return ""
+	}
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil || named.String() != pvd.layoutRef {
+		return ""
+	}
+	return pvd.layoutDir
+}
+
+// writeLayout writes the image and all its children from content store to
+// the OCI image layout, the image is tagged in `index.json` by the ref.
+func writeLayout(ctx context.Context, store content.Store, desc ocispec.Descriptor, ref, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "create OCI image layout directory")
+	}
+
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		// The non-distributable layers not pulled are referenced by URLs.
+		if images.IsNonDistributable(desc.MediaType) {
+			if _, err := store.Info(ctx, desc.Digest); errdefs.IsNotFound(err) {
+				return nil, nil
+			}
+		}
+		if err := writeLayoutBlob(ctx, store, desc, dir); err != nil {
+			return nil, err
+		}
+		return images.Children(ctx, store, desc)
+	})
+	if err := images.Walk(ctx, handler, desc); err != nil {
+		return errors.Wrap(err, "write blobs to OCI image layout")
+	}
+
+	layoutMutex.Lock()
+	defer layoutMutex.Unlock()
+
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), layout, 0644); err != nil {
+		return errors.Wrap(err, "write oci-layout file")
+	}
+
+	return updateLayoutIndex(dir, desc, ref)
+}
+
+func writeLayoutBlob(ctx context.Context, store content.Store, desc ocispec.Descriptor, dir string) error {
+	blobPath := filepath.Join(dir, ocispec.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded())
+	if info, err := os.Stat(blobPath); err == nil && info.Size() == desc.Size {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0755); err != nil {
+		return errors.Wrap(err, "create blob directory")
+	}
+
+	ra, err := store.ReaderAt(ctx, desc)
+	if err != nil {
+		return errors.Wrapf(err, "get reader of blob %s", desc.Digest)
+	}
+	defer ra.Close()
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(blobPath), ".tmp-")
+	if err != nil {
+		return errors.Wrap(err, "create temp blob file")
+	}
+	defer os.Remove(tmpFile.Name())
+
+	verifier := desc.Digest.Verifier()
+	_, err = io.Copy(io.MultiWriter(tmpFile, verifier), content.NewReader(ra))
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "write blob %s", desc.Digest)
+	}
+	if !verifier.Verified() {
+		return errors.Errorf("digest mismatch of blob %s", desc.Digest)
+	}
+
+	return os.Rename(tmpFile.Name(), blobPath)
+}
+
+// updateLayoutIndex adds the image into `index.json`, the image with the
+// same name is replaced.
+func updateLayoutIndex(dir string, desc ocispec.Descriptor, ref string) error {
+	indexPath := filepath.Join(dir, ocispec.ImageIndexFile)
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+	}
+	if data, err := os.ReadFile(indexPath); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return errors.Wrap(err, "unmarshal index.json")
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "read index.json")
+	}
+
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	desc.Annotations = map[string]string{
+		images.AnnotationImageName: named.String(),
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		desc.Annotations[ocispec.AnnotationRefName] = tagged.Tag()
+	}
+
+	manifests := []ocispec.Descriptor{}
+	for _, manifest := range index.Manifests {
+		if manifest.Annotations[images.AnnotationImageName] != named.String() {
+			manifests = append(manifests, manifest)
+		}
+	}
+	index.Manifests = append(manifests, desc)
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal index.json")
+	}
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return errors.Wrap(err, "write index.json")
+	}
+	return os.Rename(tmpPath, indexPath)
+}
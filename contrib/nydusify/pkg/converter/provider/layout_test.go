@@ -0,0 +1,72 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutDirFor(t *testing.T) {
+	pvd := &Provider{}
+	require.Empty(t, pvd.layoutDirFor("busybox:latest-nydus"))
+
+	require.NoError(t, pvd.SetOutputLayout("busybox:latest-nydus", "/tmp/out"))
+	require.Equal(t, "/tmp/out", pvd.layoutDirFor("busybox:latest-nydus"))
+	require.Equal(t, "/tmp/out", pvd.layoutDirFor("docker.io/library/busybox:latest-nydus"))
+	require.Empty(t, pvd.layoutDirFor("busybox:cache"))
+
+	require.Error(t, pvd.SetOutputLayout("Invalid:Ref", "/tmp/out"))
+}
+
+func TestUpdateLayoutIndex(t *testing.T) {
+	dir := t.TempDir()
+	readIndex := func() ocispec.Index {
+		data, err := os.ReadFile(filepath.Join(dir, ocispec.ImageIndexFile))
+		require.NoError(t, err)
+		var index ocispec.Index
+		require.NoError(t, json.Unmarshal(data, &index))
+		return index
+	}
+
+	desc1 := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("manifest1"),
+		Size:      100,
+	}
+	require.NoError(t, updateLayoutIndex(dir, desc1, "busybox:latest-nydus"))
+	index := readIndex()
+	require.Equal(t, ocispec.MediaTypeImageIndex, index.MediaType)
+	require.Len(t, index.Manifests, 1)
+	require.Equal(t, desc1.Digest, index.Manifests[0].Digest)
+	require.Equal(t, map[string]string{
+		images.AnnotationImageName: "docker.io/library/busybox:latest-nydus",
+		ocispec.AnnotationRefName:  "latest-nydus",
+	}, index.Manifests[0].Annotations)
+
+	desc2 := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromString("index2"),
+		Size:      200,
+	}
+	require.NoError(t, updateLayoutIndex(dir, desc2, "nginx:latest-nydus"))
+	require.Len(t, readIndex().Manifests, 2)
+
+	// The image with the same name is replaced.
+	desc3 := desc1
+	desc3.Digest = digest.FromString("manifest3")
+	require.NoError(t, updateLayoutIndex(dir, desc3, "busybox:latest-nydus"))
+	index = readIndex()
+	require.Len(t, index.Manifests, 2)
+	require.Equal(t, desc2.Digest, index.Manifests[0].Digest)
+	require.Equal(t, desc3.Digest, index.Manifests[1].Digest)
+}
@@ -0,0 +1,235 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// OCILayoutScheme is the prefix of an OCI image layout source, which is
+	// a directory or a tarball, for example `oci:/path/to/layout[:ref]`.
+	OCILayoutScheme = "oci:"
+	// DockerArchiveScheme is the prefix of a tarball exported by `docker save`
+	// or `podman save`, for example `docker-archive:/path/to/image.tar[:ref]`.
+	DockerArchiveScheme = "docker-archive:"
+
+	// localImageRepo is the repository name of the imported local images,
+	// which never be pulled from or pushed to a registry.
+	localImageRepo = "localhost/nydusify-local"
+)
+
+// LocalSource is an image source in local filesystem.
+type LocalSource struct {
+	Scheme string
+	Path   string
+	// Ref selects the image in the layout or archive if there are multiple
+	// images, it's matched with the image name or the tag annotation.
+	Ref string
+}
+
+// IsLocalSource checks if the source reference has a local scheme prefix,
+// including the containerd scheme.
+func IsLocalSource(source string) bool {
+	return strings.HasPrefix(source, OCILayoutScheme) || strings.HasPrefix(source, DockerArchiveScheme) ||
+		strings.HasPrefix(source, ContainerdScheme)
+}
+
+// ParseLocalSource parses the source like `oci:/path/to/layout[:ref]`, the
+// path may contain colons, so the longest existing path is chosen.
+func ParseLocalSource(source string) (*LocalSource, error) {
+	var scheme string
+	switch {
+	case strings.HasPrefix(source, OCILayoutScheme):
+		scheme = OCILayoutScheme
+	case strings.HasPrefix(source, DockerArchiveScheme):
+		scheme = DockerArchiveScheme
+	default:
+		return nil, fmt.Errorf("unsupported local source %s", source)
+	}
+
+	value := strings.TrimPrefix(source, scheme)
+	for idx := len(value); idx > 0; idx = strings.LastIndex(value[:idx], ":") {
+		if _, err := os.Stat(value[:idx]); err == nil {
+			ref := ""
+			if idx < len(value) {
+				ref = value[idx+1:]
+			}
+			return &LocalSource{Scheme: scheme, Path: value[:idx], Ref: ref}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("local source %s not found", source)
+}
+
+// ImportLocal imports the image from local source into content store, and
+// returns the reference used to find the image by `Pull` and `Image`.
+func (pvd *Provider) ImportLocal(ctx context.Context, src *LocalSource) (string, error) {
+	reader, err := openLocalSource(src)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	iopts := importOpts{
+		dgstRefT: func(dgst digest.Digest) string {
+			return "nydus" + "@" + dgst.String()
+		},
+		skipDgstRef:     func(name string) bool { return name != "" },
+		platformMatcher: pvd.platformMC,
+	}
+	imgs, err := load(ctx, reader, pvd.store, iopts)
+	if err != nil {
+		return "", errors.Wrapf(err, "load image from %s", src.Path)
+	}
+
+	var target *digest.Digest
+	var names []string
+	for idx := range imgs {
+		img := imgs[idx]
+		names = append(names, img.Name)
+		if src.Ref != "" && !matchImageName(img.Name, src.Ref) {
+			continue
+		}
+		if target != nil && *target != img.Target.Digest {
+			return "", fmt.Errorf("multiple images found in %s, specify one of %v", src.Path, names)
+		}
+		target = &img.Target.Digest
+
+		ref := localImageRepo + ":" + img.Target.Digest.Encoded()
+		pvd.mutex.Lock()
+		pvd.images[ref] = &img.Target
+		pvd.localImages[ref] = true
+		pvd.mutex.Unlock()
+	}
+	if target == nil {
+		return "", fmt.Errorf("image %s not found in %s, available images: %v", src.Ref, src.Path, names)
+	}
+
+	return localImageRepo + ":" + target.Encoded(), nil
+}
+
+// AddLocalImage registers the image written into content store, and
+// returns the reference used to find the image by `Pull` and `Image`.
+func (pvd *Provider) AddLocalImage(desc ocispec.Descriptor) string {
+	ref := localImageRepo + ":" + desc.Digest.Encoded()
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.images[ref] = &desc
+	pvd.localImages[ref] = true
+	return ref
+}
+
+// AddLocalTarget registers the reference of local image with name, the
+// image pushed to it is only kept in content store.
+func (pvd *Provider) AddLocalTarget(name string) string {
+	ref := localImageRepo + ":" + name
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.localImages[ref] = true
+	return ref
+}
+
+func matchImageName(name, ref string) bool {
+	if name == ref {
+		return true
+	}
+	named, err := reference.ParseDockerRef(ref)
+	return err == nil && named.String() == name
+}
+
+func openLocalSource(src *LocalSource) (io.ReadCloser, error) {
+	info, err := os.Stat(src.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat local source")
+	}
+
+	if info.IsDir() {
+		if src.Scheme != OCILayoutScheme {
+			return nil, fmt.Errorf("%s should be a tarball", src.Path)
+		}
+		if _, err := os.Stat(filepath.Join(src.Path, "index.json")); err != nil {
+			return nil, errors.Wrapf(err, "invalid OCI image layout %s", src.Path)
+		}
+		return tarDirectory(src.Path), nil
+	}
+
+	f, err := os.Open(src.Path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open local source")
+	}
+	ds, err := compression.DecompressStream(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "decompress local source")
+	}
+	return &readCloser{Reader: ds, close: func() error {
+		ds.Close()
+		return f.Close()
+	}}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (rc *readCloser) Close() error {
+	return rc.close()
+}
+
+// tarDirectory streams the regular files of directory as a tarball.
+func tarDirectory(dir string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			name, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     filepath.ToSlash(name),
+				Mode:     0644,
+				Size:     info.Size(),
+			}); err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalSource(t *testing.T) {
+	dir := t.TempDir()
+	layoutDir := filepath.Join(dir, "layout")
+	require.NoError(t, os.MkdirAll(layoutDir, 0755))
+	archivePath := filepath.Join(dir, "image:v1.tar")
+	require.NoError(t, os.WriteFile(archivePath, []byte{}, 0644))
+
+	require.True(t, IsLocalSource("oci:"+layoutDir))
+	require.True(t, IsLocalSource("docker-archive:"+archivePath))
+	require.False(t, IsLocalSource("docker.io/library/busybox:latest"))
+
+	src, err := ParseLocalSource("oci:" + layoutDir)
+	require.NoError(t, err)
+	require.Equal(t, &LocalSource{Scheme: OCILayoutScheme, Path: layoutDir}, src)
+
+	src, err = ParseLocalSource("oci:" + layoutDir + ":latest")
+	require.NoError(t, err)
+	require.Equal(t, &LocalSource{Scheme: OCILayoutScheme, Path: layoutDir, Ref: "latest"}, src)
+
+	src, err = ParseLocalSource("docker-archive:" + archivePath)
+	require.NoError(t, err)
+	require.Equal(t, &LocalSource{Scheme: DockerArchiveScheme, Path: archivePath}, src)
+
+	src, err = ParseLocalSource("docker-archive:" + archivePath + ":busybox:latest")
+	require.NoError(t, err)
+	require.Equal(t, &LocalSource{Scheme: DockerArchiveScheme, Path: archivePath, Ref: "busybox:latest"}, src)
+
+	_, err = ParseLocalSource("oci:" + filepath.Join(dir, "not-exist"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not found")
+
+	_, err = ParseLocalSource("docker.io/library/busybox:latest")
+	require.Error(t, err)
+}
+
+func TestMatchImageName(t *testing.T) {
+	require.True(t, matchImageName("latest", "latest"))
+	require.True(t, matchImageName("docker.io/library/busybox:latest", "busybox:latest"))
+	require.False(t, matchImageName("docker.io/library/busybox:latest", "busybox:v1"))
+}
+
+func TestTarDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte("{}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blobs", "sha256", "abc"), []byte("blob"), 0644))
+
+	reader := tarDirectory(dir)
+	defer reader.Close()
+
+	files := map[string]string{}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(data)
+	}
+	require.Equal(t, map[string]string{
+		"index.json":       "{}",
+		"blobs/sha256/abc": "blob",
+	}, files)
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// RemoteManifests resolves the image of ref in registry without pulling
+// it, and returns its descriptor and the manifests of the platforms matched
+// by platformMC, or all platforms if platformMC is nil.
+func (pvd *Provider) RemoteManifests(ctx context.Context, ref string, platformMC platforms.MatchComparer) (*ocispec.Descriptor, []ocispec.Manifest, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolver, err := pvd.Resolver(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	name, desc, err := resolver.Resolve(ctx, named.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	descs := []ocispec.Descriptor{desc}
+	if desc.MediaType == ocispec.MediaTypeImageIndex || desc.MediaType == images.MediaTypeDockerSchema2ManifestList {
+		var index ocispec.Index
+		if err := fetchJSON(ctx, fetcher, desc, &index); err != nil {
+			return nil, nil, err
+		}
+		descs = index.Manifests
+	}
+
+	var manifests []ocispec.Manifest
+	for _, desc := range descs {
+		if platformMC != nil && desc.Platform != nil && !platformMC.Match(*desc.Platform) {
+			continue
+		}
+		var manifest ocispec.Manifest
+		if err := fetchJSON(ctx, fetcher, desc, &manifest); err != nil {
+			return nil, nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return &desc, manifests, nil
+}
+
+func fetchJSON(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor, v interface{}) error {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return errors.Wrapf(err, "fetch %s", desc.Digest)
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
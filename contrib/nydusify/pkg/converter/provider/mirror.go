@@ -0,0 +1,191 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/distribution/reference"
+	"github.com/goharbor/acceleration-service/pkg/remote"
+	"github.com/pkg/errors"
+)
+
+// mirrorHeaderTimeout limits the time to wait for the response header from
+// a mirror, so that a hanging mirror is skipped instead of blocking the pull.
+const mirrorHeaderTimeout = 30 * time.Second
+
+// mirror is a registry host serving the images of another registry, like the
+// mirror host in containerd's hosts.toml.
+type mirror struct {
+	scheme string
+	host   string
+	path   string
+}
+
+func (m mirror) String() string {
+	return m.scheme + "://" + m.host + m.path
+}
+
+// parseMirror parses the mirror URL like `mirror.example.com`,
+// `http://mirror:5000` or `https://mirror.example.com/v2/proxy`, the scheme
+// defaults to https and the path defaults to `/v2` as in hosts.toml.
+func parseMirror(raw string) (*mirror, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse mirror %s", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %s of mirror %s", u.Scheme, raw)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("invalid mirror %s without host", raw)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(path, "/v2") {
+		path += "/v2"
+	}
+	return &mirror{scheme: u.Scheme, host: u.Host, path: path}, nil
+}
+
+// SetMirrors sets the mirrors to pull the images in the registry of ref, the
+// mirrors are tried in order before the registry itself, and the next one is
+// tried if the pull fails.
+func (pvd *Provider) SetMirrors(ref string, mirrors []string) error {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	parsed := make([]mirror, 0, len(mirrors))
+	for _, raw := range mirrors {
+		m, err := parseMirror(raw)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, *m)
+	}
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.mirrors == nil {
+		pvd.mirrors = make(map[string][]mirror)
+	}
+	pvd.mirrors[reference.Domain(named)] = parsed
+	return nil
+}
+
+// pullSource is a registry host to pull the image from.
+type pullSource struct {
+	name     string
+	resolver remotes.Resolver
+}
+
+// pullSources returns the mirrors of ref's registry followed by the registry
+// itself in the order to try.
+func (pvd *Provider) pullSources(ref string) ([]pullSource, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", ref)
+	}
+	pvd.mutex.Lock()
+	mirrors := pvd.mirrors[reference.Domain(named)]
+	pvd.mutex.Unlock()
+
+	sources := make([]pullSource, 0, len(mirrors)+1)
+	if len(mirrors) > 0 {
+		_, insecure, err := pvd.hosts(ref)
+		if err != nil {
+			return nil, err
+		}
+		client, err := pvd.newClient(ref, insecure)
+		if err != nil {
+			return nil, err
+		}
+		client = &http.Client{
+			Transport: &headerTimeoutTransport{base: client.Transport, timeout: mirrorHeaderTimeout},
+		}
+		for _, m := range mirrors {
+			sources = append(sources, pullSource{
+				name:     m.String(),
+				resolver: newMirrorResolver(client, m),
+			})
+		}
+	}
+
+	resolver, err := pvd.Resolver(ref)
+	if err != nil {
+		return nil, err
+	}
+	sources = append(sources, pullSource{name: reference.Domain(named), resolver: resolver})
+	return sources, nil
+}
+
+// newMirrorResolver creates the resolver to pull images from mirror only,
+// the credential of mirror is looked up by its host in docker config rather
+// than using the one of the origin registry.
+func newMirrorResolver(client *http.Client, m mirror) remotes.Resolver {
+	host := docker.RegistryHost{
+		Client: client,
+		Authorizer: docker.NewDockerAuthorizer(
+			docker.WithAuthClient(client),
+			docker.WithAuthCreds(remote.NewDockerConfigCredFunc()),
+		),
+		Host:         m.host,
+		Scheme:       m.scheme,
+		Path:         m.path,
+		Capabilities: docker.HostCapabilityPull | docker.HostCapabilityResolve,
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: func(string) ([]docker.RegistryHost, error) {
+			return []docker.RegistryHost{host}, nil
+		},
+	})
+}
+
+// headerTimeoutTransport fails the request if the response header isn't
+// received within timeout.
+type headerTimeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *headerTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.timeout, cancel)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() {
+		if err == nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, errors.Errorf("timeout waiting for response header from %s after %s", req.URL.Host, t.timeout)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelReadCloser cancels the request context once the body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
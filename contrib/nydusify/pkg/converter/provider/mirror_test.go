@@ -0,0 +1,67 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMirror(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"mirror.example.com":                   "https://mirror.example.com/v2",
+		"http://127.0.0.1:5000/":               "http://127.0.0.1:5000/v2",
+		"https://mirror.example.com/v2/proxy":  "https://mirror.example.com/v2/proxy/v2",
+		"https://mirror.example.com/proxy/v2/": "https://mirror.example.com/proxy/v2",
+	} {
+		m, err := parseMirror(raw)
+		require.NoError(t, err)
+		require.Equal(t, expected, m.String())
+	}
+
+	for _, raw := range []string{"ftp://mirror.example.com", "https:///v2"} {
+		_, err := parseMirror(raw)
+		require.Error(t, err)
+	}
+}
+
+func TestHeaderTimeoutTransport(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// The body isn't limited by the timeout.
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &http.Client{
+		Transport: &headerTimeoutTransport{base: http.DefaultTransport, timeout: 100 * time.Millisecond},
+	}
+
+	_, err := client.Get(server.URL + "/hang")
+	require.ErrorContains(t, err, "timeout waiting for response header")
+
+	resp, err := client.Get(server.URL + "/ok")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(data))
+}
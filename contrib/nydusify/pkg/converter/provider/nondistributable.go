@@ -0,0 +1,44 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/containerd/containerd/v2/core/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sirupsen/logrus"
+)
+
+// SetAllowNondistributable sets whether the non-distributable layers (e.g.
+// the foreign layers of windows base images) are pulled from their URLs and
+// pushed to registry. They are skipped by default, so the manifests keep
+// referencing them by URLs.
+func (pvd *Provider) SetAllowNondistributable(allow bool) {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.allowNondistributable = allow
+}
+
+// handlerWrapper returns the wrapper of pull and push handlers, which skips
+// the non-distributable layers unless they are allowed.
+func (pvd *Provider) handlerWrapper() func(images.Handler) images.Handler {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.allowNondistributable {
+		return nil
+	}
+	return skipNondistributable
+}
+
+func skipNondistributable(handler images.Handler) images.Handler {
+	return images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if images.IsNonDistributable(desc.MediaType) {
+			logrus.Debugf("skipped non-distributable layer %s", desc.Digest)
+			return nil, images.ErrSkipDesc
+		}
+		return handler.Handle(ctx, desc)
+	})
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerWrapper(t *testing.T) {
+	var handled []string
+	handler := images.HandlerFunc(func(_ context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		handled = append(handled, desc.MediaType)
+		return nil, nil
+	})
+
+	pvd := &Provider{}
+	wrapper := pvd.handlerWrapper()
+	require.NotNil(t, wrapper)
+	wrapped := wrapper(handler)
+	_, err := wrapped.Handle(context.Background(), ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip})
+	require.NoError(t, err)
+	_, err = wrapped.Handle(context.Background(), ocispec.Descriptor{MediaType: images.MediaTypeDockerSchema2LayerForeignGzip})
+	require.ErrorIs(t, err, images.ErrSkipDesc)
+	require.Equal(t, []string{ocispec.MediaTypeImageLayerGzip}, handled)
+
+	pvd.SetAllowNondistributable(true)
+	require.Nil(t, pvd.handlerWrapper())
+}
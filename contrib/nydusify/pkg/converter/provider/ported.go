@@ -123,6 +123,8 @@ func fetch(ctx context.Context, store content.Store, rCtx *client.RemoteContext,
 	if sc, ok := store.(*StreamContent); ok {
 		sc.SetDefaultRef(name)
 	}
+	// The layers skipped by stream layer store are read from ref later.
+	ctx = withSourceRef(ctx, ref)
 	if err := images.Dispatch(ctx, handler, limiter, desc); err != nil {
 		return images.Image{}, err
 	}
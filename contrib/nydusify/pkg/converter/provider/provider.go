@@ -6,9 +6,7 @@ package provider
 
 import (
 	"context"
-	"crypto/tls"
 	"io"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,11 +15,16 @@ import (
 
 	"github.com/containerd/containerd/v2/client"
 	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/images/archive"
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/containerd/v2/core/remotes/docker"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/platforms"
+	encconfig "github.com/containers/ocicrypt/config"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/progress"
+	pkgRemote "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/tracing"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 	"github.com/goharbor/acceleration-service/pkg/cache"
 	accelcontent "github.com/goharbor/acceleration-service/pkg/content"
@@ -30,22 +33,36 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
 )
 
 var LayerConcurrentLimit = 5
 
 type Provider struct {
-	mutex          sync.Mutex
-	usePlainHTTP   bool
-	images         map[string]*ocispec.Descriptor
-	store          content.Store
-	hosts          remote.HostFunc
-	platformMC     platforms.MatchComparer
-	cacheSize      int
-	cacheVersion   string
-	chunkSize      int64
-	pushRetryCount int
-	pushRetryDelay time.Duration
+	mutex                 sync.Mutex
+	usePlainHTTP          bool
+	images                map[string]*ocispec.Descriptor
+	localImages           map[string]bool
+	store                 content.Store
+	hosts                 remote.HostFunc
+	platformMC            platforms.MatchComparer
+	cacheSize             int
+	cacheVersion          string
+	chunkSize             int64
+	pushRetryCount        int
+	pushRetryDelay        time.Duration
+	layoutRef             string
+	layoutDir             string
+	encryptRef            string
+	encryptConfig         *encconfig.EncryptConfig
+	transports            map[string]pkgRemote.TransportOption
+	uploadChunkSize       int64
+	pullLimiter           *rate.Limiter
+	pushLimiter           *rate.Limiter
+	concurrency           int
+	mirrors               map[string][]mirror
+	allowNondistributable bool
 }
 
 // New creates a Provider with optional custom content.Store override.
@@ -69,6 +86,7 @@ func New(root string, hosts remote.HostFunc, cacheSize uint, cacheVersion string
 
 	return &Provider{
 		images:         make(map[string]*ocispec.Descriptor),
+		localImages:    make(map[string]bool),
 		store:          store,
 		hosts:          hosts,
 		cacheSize:      int(cacheSize),
@@ -80,50 +98,33 @@ func New(root string, hosts remote.HostFunc, cacheSize uint, cacheVersion string
 	}, nil
 }
 
-func newDefaultClient(skipTLSVerify bool) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          10,
-			IdleConnTimeout:       30 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 5 * time.Second,
-			DisableKeepAlives:     true,
-			TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: skipTLSVerify,
-			},
-		},
-	}
-}
-
-func newResolver(insecure, plainHTTP bool, credFunc remote.CredentialFunc, chunkSize int64) remotes.Resolver {
-	registryHosts := docker.ConfigureDefaultRegistries(
+func newRegistryHosts(client *http.Client, plainHTTP bool, credFunc remote.CredentialFunc, chunkSize int64) docker.RegistryHosts {
+	return docker.ConfigureDefaultRegistries(
 		docker.WithAuthorizer(
 			docker.NewDockerAuthorizer(
-				docker.WithAuthClient(newDefaultClient(insecure)),
+				docker.WithAuthClient(client),
 				docker.WithAuthCreds(credFunc),
 			),
 		),
-		docker.WithClient(newDefaultClient(insecure)),
+		docker.WithClient(client),
 		docker.WithPlainHTTP(func(_ string) (bool, error) {
 			return plainHTTP, nil
 		}),
 		docker.WithChunkSize(chunkSize),
 	)
+}
 
+func newResolver(client *http.Client, plainHTTP bool, credFunc remote.CredentialFunc, chunkSize int64) remotes.Resolver {
 	return docker.NewResolver(docker.ResolverOptions{
-		Hosts: registryHosts,
+		Hosts: newRegistryHosts(client, plainHTTP, credFunc, chunkSize),
 	})
 }
 
 func (pvd *Provider) UsePlainHTTP() {
 	pvd.usePlainHTTP = true
+	if sc, ok := pvd.store.(*StreamLayerContent); ok {
+		sc.UsePlainHTTP()
+	}
 }
 
 func (pvd *Provider) Resolver(ref string) (remotes.Resolver, error) {
@@ -131,21 +132,45 @@ func (pvd *Provider) Resolver(ref string) (remotes.Resolver, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newResolver(insecure, pvd.usePlainHTTP, credFunc, pvd.chunkSize), nil
+	client, err := pvd.newClient(ref, insecure)
+	if err != nil {
+		return nil, err
+	}
+	return newResolver(client, pvd.usePlainHTTP, credFunc, pvd.chunkSize), nil
 }
 
-func (pvd *Provider) Pull(ctx context.Context, ref string) error {
-	resolver, err := pvd.Resolver(ref)
+func (pvd *Provider) Pull(ctx context.Context, ref string) (retErr error) {
+	// The image imported by `ImportLocal` is already in content store.
+	pvd.mutex.Lock()
+	isLocal := pvd.localImages[ref]
+	pvd.mutex.Unlock()
+	if isLocal {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "provider.pull", attribute.String("ref", ref))
+	defer func() { tracing.End(span, retErr) }()
+
+	sources, err := pvd.pullSources(ref)
 	if err != nil {
 		return err
 	}
-	rc := &client.RemoteContext{
-		Resolver:               resolver,
-		PlatformMatcher:        pvd.platformMC,
-		MaxConcurrentDownloads: LayerConcurrentLimit,
+	var img images.Image
+	for idx, source := range sources {
+		rc := &client.RemoteContext{
+			Resolver:               source.resolver,
+			PlatformMatcher:        pvd.platformMC,
+			MaxConcurrentDownloads: pvd.layerConcurrency(),
+			HandlerWrapper:         pvd.handlerWrapper(),
+		}
+		img, err = fetch(progress.WithPhase(ctx, progress.PhasePull), pvd.store, rc, ref, 0)
+		if err == nil || ctx.Err() != nil || idx == len(sources)-1 {
+			break
+		}
+		// The fetched blobs are kept in content store, so the next
+		// source only pulls the remaining ones.
+		logrus.WithError(err).Warnf("failed to pull %s from %s, fail over to %s", ref, source.name, sources[idx+1].name)
 	}
-
-	img, err := fetch(ctx, pvd.store, rc, ref, 0)
 	if err != nil {
 		return err
 	}
@@ -157,6 +182,23 @@ func (pvd *Provider) Pull(ctx context.Context, ref string) error {
 	return nil
 }
 
+// SetConcurrency sets the count of layers pulled or pushed concurrently,
+// zero means LayerConcurrentLimit.
+func (pvd *Provider) SetConcurrency(concurrency int) {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.concurrency = concurrency
+}
+
+func (pvd *Provider) layerConcurrency() int {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.concurrency > 0 {
+		return pvd.concurrency
+	}
+	return LayerConcurrentLimit
+}
+
 // SetPushRetryConfig sets the retry configuration for push operations
 func (pvd *Provider) SetPushRetryConfig(count int, delay time.Duration) {
 	pvd.mutex.Lock()
@@ -165,26 +207,83 @@ func (pvd *Provider) SetPushRetryConfig(count int, delay time.Duration) {
 	pvd.pushRetryDelay = delay
 }
 
-func (pvd *Provider) Push(ctx context.Context, desc ocispec.Descriptor, ref string) error {
-	resolver, err := pvd.Resolver(ref)
-	if err != nil {
-		return err
+func (pvd *Provider) Push(ctx context.Context, desc ocispec.Descriptor, ref string) (retErr error) {
+	// The local image (e.g. the build cache seeded from content-addressed
+	// store) is only kept in content store.
+	pvd.mutex.Lock()
+	isLocal := pvd.localImages[ref]
+	pvd.mutex.Unlock()
+	if isLocal {
+		pvd.setImage(ref, desc)
+		return nil
 	}
-	rc := &client.RemoteContext{
-		Resolver:                    resolver,
-		PlatformMatcher:             pvd.platformMC,
-		MaxConcurrentUploadedLayers: LayerConcurrentLimit,
+
+	ctx, span := tracing.Start(ctx, "provider.push", attribute.String("ref", ref), attribute.String("digest", desc.Digest.String()))
+	defer func() { tracing.End(span, retErr) }()
+
+	if ec := pvd.encryptConfigFor(ref); ec != nil {
+		encrypted, err := encryptImage(ctx, pvd.store, desc, ref, ec, pvd.platformMC)
+		if err != nil {
+			return errors.Wrap(err, "encrypt image")
+		}
+		desc = *encrypted
 	}
 
-	err = utils.WithRetry(func() error {
-		return push(ctx, pvd.store, rc, desc, ref)
-	}, pvd.pushRetryCount, pvd.pushRetryDelay)
+	ctx = progress.WithPhase(ctx, progress.PhasePush)
+	if dir := pvd.layoutDirFor(ref); dir != "" {
+		if err := writeLayout(ctx, pvd.store, desc, ref, dir); err != nil {
+			return err
+		}
+		pvd.setImage(ref, desc)
+		return nil
+	}
 
-	if err != nil {
+	if err := pvd.pushWithRetry(ctx, desc, ref, ref, pvd.platformMC); err != nil {
 		logrus.WithError(err).Error("Push failed after all attempts")
+		return err
 	}
+	pvd.setImage(ref, desc)
 
-	return err
+	return nil
+}
+
+// pushWithRetry pushes the image desc to target ref through the resolver of
+// ref. The resolver caches the registry token, which may be expired during
+// a long push of large image, so a new resolver is created for each attempt
+// to request a new token with the credential got again. The blobs pushed by
+// the failed attempts are skipped.
+func (pvd *Provider) pushWithRetry(ctx context.Context, desc ocispec.Descriptor, ref, target string, platformMC platforms.MatchComparer) error {
+	if err := pvd.uploadBlobs(ctx, desc, ref, target, platformMC); err != nil {
+		return errors.Wrap(err, "upload blobs")
+	}
+
+	attempt := 0
+	return utils.WithRetryIf(func() error {
+		attempt++
+		if attempt > 1 {
+			logrus.Infof("refreshing registry token of %s to retry push", ref)
+		}
+		resolver, err := pvd.Resolver(ref)
+		if err != nil {
+			return err
+		}
+		rc := &client.RemoteContext{
+			Resolver:                    resolver,
+			PlatformMatcher:             platformMC,
+			MaxConcurrentUploadedLayers: pvd.layerConcurrency(),
+			HandlerWrapper:              pvd.handlerWrapper(),
+		}
+		return push(ctx, pvd.store, rc, desc, target)
+	}, pvd.pushRetryCount, pvd.pushRetryDelay, func(err error) bool {
+		return utils.RetryWithHTTP(err) || utils.IsUnauthorized(err)
+	})
+}
+
+// setImage records the image pushed to ref, so that it can be got by Image.
+func (pvd *Provider) setImage(ref string, desc ocispec.Descriptor) {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.images[ref] = &desc
 }
 
 func (pvd *Provider) Import(ctx context.Context, reader io.Reader) (string, error) {
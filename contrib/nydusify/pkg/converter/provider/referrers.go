@@ -0,0 +1,185 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	refdocker "github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/goharbor/acceleration-service/pkg/utils"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReferrersIndexSize limits the size of referrers index to read.
+const maxReferrersIndexSize = 4 << 20
+
+// Referrers lists the referrers of manifest dgst in the repository of ref by
+// the referrers API, see https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers.
+// It falls back to the referrers tag schema if the registry doesn't support
+// the referrers API.
+func (pvd *Provider) Referrers(ctx context.Context, ref string, dgst digest.Digest) ([]ocispec.Descriptor, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", ref)
+	}
+	hosts, err := pvd.registryHosts(ref)
+	if err != nil {
+		return nil, err
+	}
+	refspec, err := refdocker.Parse(named.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", ref)
+	}
+	scopedCtx, err := docker.ContextWithRepositoryScope(ctx, refspec, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		if host.Capabilities&docker.HostCapabilityPull == 0 {
+			continue
+		}
+		index, err := fetchReferrers(scopedCtx, host, reference.Path(named), dgst)
+		if err == nil {
+			return index.Manifests, nil
+		}
+		if !errdefs.IsNotImplemented(err) {
+			return nil, err
+		}
+		logrus.WithError(err).Debugf("fall back to referrers tag schema")
+		break
+	}
+
+	return pvd.referrersByTag(ctx, named, dgst)
+}
+
+// fetchReferrers returns errdefs.ErrNotImplemented if the registry doesn't
+// support the referrers API.
+func fetchReferrers(ctx context.Context, host docker.RegistryHost, repo string, dgst digest.Digest) (*ocispec.Index, error) {
+	url := fmt.Sprintf("%s://%s%s/%s/referrers/%s", host.Scheme, host.Host, host.Path, repo, dgst)
+
+	resp, err := doRequest(ctx, host, http.MethodGet, url, http.Header{"Accept": []string{ocispec.MediaTypeImageIndex}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusBadRequest, http.StatusMethodNotAllowed:
+		return nil, errors.Wrapf(errdefs.ErrNotImplemented, "referrers API returns status %s", resp.Status)
+	default:
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+
+	var index ocispec.Index
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxReferrersIndexSize)).Decode(&index); err != nil {
+		return nil, errors.Wrap(err, "decode referrers index")
+	}
+	return &index, nil
+}
+
+// referrersByTag reads the referrers index tagged by `<alg>-<ref>`, it
+// returns empty referrers if the tag doesn't exist.
+func (pvd *Provider) referrersByTag(ctx context.Context, named reference.Named, dgst digest.Digest) ([]ocispec.Descriptor, error) {
+	resolver, err := pvd.Resolver(named.String())
+	if err != nil {
+		return nil, err
+	}
+	tagRef := named.Name() + ":" + dgst.Algorithm().String() + "-" + dgst.Encoded()
+	_, desc, err := resolver.Resolve(ctx, tagRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "resolve referrers tag %s", tagRef)
+	}
+	fetcher, err := resolver.Fetcher(ctx, tagRef)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetch referrers index %s", tagRef)
+	}
+	defer rc.Close()
+
+	var index ocispec.Index
+	if err := json.NewDecoder(io.LimitReader(rc, maxReferrersIndexSize)).Decode(&index); err != nil {
+		return nil, errors.Wrap(err, "decode referrers index")
+	}
+	return index.Manifests, nil
+}
+
+// CopyReferrer copies the referrer artifact from the repository of source
+// to the repository of target, the subject of referrer is replaced by the
+// given subject, returns the descriptor of copied referrer.
+func (pvd *Provider) CopyReferrer(ctx context.Context, source string, referrer ocispec.Descriptor, target string, subject ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	sourceNamed, err := reference.ParseDockerRef(source)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", source)
+	}
+	resolver, err := pvd.Resolver(source)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, err := resolver.Fetcher(ctx, sourceNamed.Name())
+	if err != nil {
+		return nil, err
+	}
+	handler := images.Handlers(
+		remotes.FetchHandler(pvd.store, fetcher),
+		images.ChildrenHandler(pvd.store),
+	)
+	if err := images.Dispatch(ctx, handler, nil, referrer); err != nil {
+		return nil, errors.Wrap(err, "fetch referrer")
+	}
+
+	var manifest ocispec.Manifest
+	if _, err := utils.ReadJSON(ctx, pvd.store, &manifest, referrer); err != nil {
+		return nil, errors.Wrap(err, "read referrer manifest")
+	}
+	manifest.Subject = &ocispec.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      subject.Size,
+	}
+	desc, err := utils.WriteJSON(ctx, pvd.store, manifest, referrer, target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "write referrer manifest")
+	}
+
+	if err := pvd.PushReferrer(ctx, target, *desc); err != nil {
+		return nil, err
+	}
+
+	return desc, nil
+}
+
+// PushReferrer pushes the referrer artifact in content store to the
+// repository of target by digest, since a referrer is not tagged.
+func (pvd *Provider) PushReferrer(ctx context.Context, target string, desc ocispec.Descriptor) error {
+	named, err := reference.ParseDockerRef(target)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", target)
+	}
+	if err := pvd.pushWithRetry(ctx, desc, target, named.Name()+"@"+desc.Digest.String(), platforms.All); err != nil {
+		return errors.Wrap(err, "push referrer")
+	}
+	return nil
+}
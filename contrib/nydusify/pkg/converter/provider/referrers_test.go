@@ -0,0 +1,66 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchReferrers(t *testing.T) {
+	subject := digest.FromString("subject")
+	sbom := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/spdx+json",
+		Digest:       digest.FromString("sbom"),
+		Size:         100,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/busybox/referrers/" + subject.String():
+			require.Equal(t, ocispec.MediaTypeImageIndex, r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			require.NoError(t, json.NewEncoder(w).Encode(ocispec.Index{
+				MediaType: ocispec.MediaTypeImageIndex,
+				Manifests: []ocispec.Descriptor{sbom},
+			}))
+		case "/v2/library/legacy/referrers/" + subject.String():
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	host := docker.RegistryHost{
+		Client:       server.Client(),
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		Scheme:       "http",
+		Path:         "/v2",
+		Capabilities: docker.HostCapabilityPull | docker.HostCapabilityResolve,
+	}
+
+	index, err := fetchReferrers(context.Background(), host, "library/busybox", subject)
+	require.NoError(t, err)
+	require.Equal(t, []ocispec.Descriptor{sbom}, index.Manifests)
+
+	_, err = fetchReferrers(context.Background(), host, "library/legacy", subject)
+	require.True(t, errdefs.IsNotImplemented(err))
+
+	_, err = fetchReferrers(context.Background(), host, "library/broken", subject)
+	require.Error(t, err)
+	require.False(t, errdefs.IsNotImplemented(err))
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// registryHosts returns the registry hosts to access the image of ref.
+func (pvd *Provider) registryHosts(ref string) ([]docker.RegistryHost, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", ref)
+	}
+	credFunc, insecure, err := pvd.hosts(ref)
+	if err != nil {
+		return nil, err
+	}
+	client, err := pvd.newClient(ref, insecure)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := newRegistryHosts(client, pvd.usePlainHTTP, credFunc, pvd.chunkSize)(reference.Domain(named))
+	if err != nil {
+		return nil, errors.Wrap(err, "get registry hosts")
+	}
+	return hosts, nil
+}
+
+// doRequest sends a request to registry host with authorization, the request
+// is sent again once if it's unauthorized. The request body is created by
+// newBody for each attempt, it can be nil for the request without body.
+func doRequest(ctx context.Context, host docker.RegistryHost, method, url string, header http.Header, newBody func() io.Reader, size int64) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if newBody != nil {
+			body = newBody()
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, body)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.ContentLength = size
+		}
+		for key, values := range host.Header {
+			req.Header[key] = append(req.Header[key], values...)
+		}
+		for key, values := range header {
+			req.Header[key] = append([]string{}, values...)
+		}
+		if host.Authorizer != nil {
+			if err := host.Authorizer.Authorize(ctx, req); err != nil {
+				return nil, errors.Wrap(err, "authorize request")
+			}
+		}
+		resp, err := host.Client.Do(req)
+		if err != nil {
+			return nil, errors.Wrapf(err, "request %s", url)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || host.Authorizer == nil || attempt > 0 {
+			return resp, nil
+		}
+		err = host.Authorizer.AddResponses(ctx, []*http.Response{resp})
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "add auth responses")
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"sync"
 
 	ctrcontent "github.com/containerd/containerd/v2/core/content"
@@ -11,6 +12,8 @@ import (
 	"github.com/goharbor/acceleration-service/pkg/remote"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
 )
 
 // StreamContent is a content.Store adapter that:
@@ -24,6 +27,10 @@ type StreamContent struct {
 	hosts      remote.HostFunc
 	defaultRef string
 
+	// The blobs larger than chunkSize are read by concurrent range requests.
+	chunkSize   int64
+	concurrency int
+
 	mu     sync.RWMutex
 	labels map[digest.Digest]map[string]string
 	blobs  map[digest.Digest][]byte
@@ -41,6 +48,15 @@ func (s *StreamContent) SetDefaultRef(ref string) {
 	s.defaultRef = ref
 }
 
+// SetParallelRead enables reading the remote blobs larger than chunkSize by
+// concurrency range requests of chunkSize, the chunks are fetched ahead.
+func (s *StreamContent) SetParallelRead(chunkSize int64, concurrency int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunkSize = chunkSize
+	s.concurrency = concurrency
+}
+
 // Ingester implements heuristic routing for content ingestion:
 //   - If ref looks like containerd fetch key (manifest-*/index-*/layer-*/config-*/attestation-*),
 //     treat as remote fetch and skip ingestion (AlreadyExists).
@@ -70,12 +86,27 @@ func (s *StreamContent) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (
 		return &bytesReaderAt{r: bytes.NewReader(b)}, nil
 	}
 	ref := s.defaultRef
+	chunkSize, concurrency := s.chunkSize, s.concurrency
 	s.mu.RUnlock()
 
 	if ref == "" {
 		return nil, fmt.Errorf("stream content: defaultRef is empty: %w", errdefs.ErrNotFound)
 	}
 
+	if concurrency > 1 && chunkSize > 0 && desc.Size > chunkSize {
+		fetch := func(ctx context.Context, offset, size int64) (io.ReadCloser, error) {
+			ra, err := remote.Fetch(ctx, ref, desc, s.hosts, false)
+			if err != nil {
+				return nil, err
+			}
+			return &readCloser{
+				Reader: io.NewSectionReader(ra, offset, size),
+				close:  ra.Close,
+			}, nil
+		}
+		return utils.NewParallelReaderAt(ctx, fetch, desc.Size, chunkSize, concurrency), nil
+	}
+
 	return remote.Fetch(ctx, ref, desc, s.hosts, false)
 }
 
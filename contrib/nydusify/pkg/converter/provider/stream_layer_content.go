@@ -0,0 +1,165 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	ctrcontent "github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/errdefs"
+	"github.com/goharbor/acceleration-service/pkg/remote"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+type sourceRefKey struct{}
+
+// withSourceRef records the image reference being pulled in context, so
+// that the content store knows where to read the skipped layers later.
+func withSourceRef(ctx context.Context, ref string) context.Context {
+	return context.WithValue(ctx, sourceRefKey{}, ref)
+}
+
+func sourceRefFromContext(ctx context.Context) string {
+	ref, _ := ctx.Value(sourceRefKey{}).(string)
+	return ref
+}
+
+type streamedLayer struct {
+	size int64
+	refs []string
+}
+
+// StreamLayerContent is a content store used by streaming conversion, the
+// layers pulled from registry are not ingested into the local store, but
+// streamed from registry when they are read by the converter, while the
+// manifests, configs and the generated nydus blobs are stored in the local
+// base store as usual. It cuts the disk usage of work directory for large
+// images at the cost of reading layers from registry on demand.
+type StreamLayerContent struct {
+	ctrcontent.Store
+
+	hosts remote.HostFunc
+
+	mu        sync.RWMutex
+	plainHTTP bool
+	// layers records the skipped layers and the references which the
+	// layers can be read from.
+	layers map[digest.Digest]*streamedLayer
+	// labels of the skipped layers, which are not in the base store.
+	labels map[digest.Digest]map[string]string
+}
+
+func NewStreamLayerContent(base ctrcontent.Store, hosts remote.HostFunc) *StreamLayerContent {
+	return &StreamLayerContent{
+		Store:  base,
+		hosts:  hosts,
+		layers: make(map[digest.Digest]*streamedLayer),
+		labels: make(map[digest.Digest]map[string]string),
+	}
+}
+
+// UsePlainHTTP reads layers from registry by plain HTTP.
+func (s *StreamLayerContent) UsePlainHTTP() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.plainHTTP = true
+}
+
+// Writer skips the ingestion of layers fetched from registry, which are
+// identified by the containerd fetch key `layer-*`.
+func (s *StreamLayerContent) Writer(ctx context.Context, opts ...ctrcontent.WriterOpt) (ctrcontent.Writer, error) {
+	var wopts ctrcontent.WriterOpts
+	for _, opt := range opts {
+		opt(&wopts)
+	}
+
+	ref := sourceRefFromContext(ctx)
+	if ref != "" && strings.HasPrefix(wopts.Ref, "layer-") && wopts.Desc.Digest != "" {
+		if _, err := s.Store.Info(ctx, wopts.Desc.Digest); errdefs.IsNotFound(err) {
+			s.mu.Lock()
+			layer, ok := s.layers[wopts.Desc.Digest]
+			if !ok {
+				layer = &streamedLayer{size: wopts.Desc.Size}
+				s.layers[wopts.Desc.Digest] = layer
+			}
+			if !containsString(layer.refs, ref) {
+				layer.refs = append(layer.refs, ref)
+			}
+			s.mu.Unlock()
+			return nil, errdefs.ErrAlreadyExists
+		}
+	}
+
+	return s.Store.Writer(ctx, opts...)
+}
+
+func (s *StreamLayerContent) streamedLayer(dgst digest.Digest) (streamedLayer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	layer, ok := s.layers[dgst]
+	if !ok {
+		return streamedLayer{}, false
+	}
+	return streamedLayer{size: layer.size, refs: append([]string(nil), layer.refs...)}, true
+}
+
+func (s *StreamLayerContent) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (ctrcontent.ReaderAt, error) {
+	layer, ok := s.streamedLayer(desc.Digest)
+	if !ok {
+		return s.Store.ReaderAt(ctx, desc)
+	}
+
+	s.mu.RLock()
+	plainHTTP := s.plainHTTP
+	s.mu.RUnlock()
+
+	var err error
+	for _, ref := range layer.refs {
+		var ra ctrcontent.ReaderAt
+		if ra, err = remote.Fetch(ctx, ref, desc, s.hosts, plainHTTP); err == nil {
+			return ra, nil
+		}
+	}
+	return nil, errors.Wrapf(err, "stream layer %s from registry", desc.Digest)
+}
+
+func (s *StreamLayerContent) Info(ctx context.Context, dgst digest.Digest) (ctrcontent.Info, error) {
+	layer, ok := s.streamedLayer(dgst)
+	if !ok {
+		return s.Store.Info(ctx, dgst)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return ctrcontent.Info{Digest: dgst, Size: layer.size, Labels: copyMap(s.labels[dgst])}, nil
+}
+
+func (s *StreamLayerContent) Update(ctx context.Context, info ctrcontent.Info, fieldpaths ...string) (ctrcontent.Info, error) {
+	layer, ok := s.streamedLayer(info.Digest)
+	if !ok {
+		return s.Store.Update(ctx, info, fieldpaths...)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.labels[info.Digest] == nil {
+		s.labels[info.Digest] = make(map[string]string)
+	}
+	for k, v := range info.Labels {
+		s.labels[info.Digest][k] = v
+	}
+	return ctrcontent.Info{Digest: info.Digest, Size: layer.size, Labels: copyMap(s.labels[info.Digest])}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLayerContent(t *testing.T) {
+	base, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	store := NewStreamLayerContent(base, nil)
+	ctx := withSourceRef(context.Background(), "localhost:5000/busybox:latest")
+
+	layer := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromString("layer"),
+		Size:      5,
+	}
+	config := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageConfig,
+		Digest:    digest.FromString("config"),
+		Size:      6,
+	}
+
+	// The layer fetched from registry is not ingested.
+	_, err = store.Writer(ctx, content.WithRef(remotes.MakeRefKey(ctx, layer)), content.WithDescriptor(layer))
+	require.True(t, errdefs.IsAlreadyExists(err))
+	_, err = base.Info(ctx, layer.Digest)
+	require.True(t, errdefs.IsNotFound(err))
+
+	info, err := store.Info(ctx, layer.Digest)
+	require.NoError(t, err)
+	require.Equal(t, layer.Size, info.Size)
+
+	_, err = store.Update(ctx, content.Info{Digest: layer.Digest, Labels: map[string]string{"key": "value"}})
+	require.NoError(t, err)
+	info, err = store.Info(ctx, layer.Digest)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"key": "value"}, info.Labels)
+
+	// The other content is ingested into base store.
+	require.NoError(t, content.WriteBlob(ctx, store, remotes.MakeRefKey(ctx, config), strings.NewReader("config"), config))
+	_, err = base.Info(ctx, config.Digest)
+	require.NoError(t, err)
+	ra, err := store.ReaderAt(ctx, config)
+	require.NoError(t, err)
+	require.Equal(t, config.Size, ra.Size())
+	ra.Close()
+
+	// The layer without source reference is ingested as usual.
+	generated := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromString("nydus"),
+		Size:      5,
+	}
+	require.NoError(t, content.WriteBlob(context.Background(), store, remotes.MakeRefKey(ctx, generated), strings.NewReader("nydus"), generated))
+	_, err = base.Info(ctx, generated.Digest)
+	require.NoError(t, err)
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	refdocker "github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// maxTagListSize limits the size of a page of tag list to read.
+const maxTagListSize = 4 << 20
+
+// tagList is the response of tag list API.
+type tagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// Tags lists all the tags in repository by the tag list API, see
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-tags.
+func (pvd *Provider) Tags(ctx context.Context, repo string) ([]string, error) {
+	named, err := reference.ParseNormalizedNamed(repo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse repository %s", repo)
+	}
+	if !reference.IsNameOnly(named) {
+		return nil, fmt.Errorf("invalid repository %s with tag or digest", repo)
+	}
+	ref := reference.TagNameOnly(named).String()
+	hosts, err := pvd.registryHosts(ref)
+	if err != nil {
+		return nil, err
+	}
+	refspec, err := refdocker.Parse(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse reference %s", ref)
+	}
+	scopedCtx, err := docker.ContextWithRepositoryScope(ctx, refspec, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		if host.Capabilities&docker.HostCapabilityPull == 0 {
+			continue
+		}
+		return fetchTags(scopedCtx, host, reference.Path(named))
+	}
+	return nil, fmt.Errorf("no registry host to list tags of %s", repo)
+}
+
+// fetchTags fetches the tag list page by page following the `Link` header.
+func fetchTags(ctx context.Context, host docker.RegistryHost, repo string) ([]string, error) {
+	base := fmt.Sprintf("%s://%s", host.Scheme, host.Host)
+	next := fmt.Sprintf("%s%s/%s/tags/list", base, host.Path, repo)
+
+	tags := []string{}
+	for next != "" {
+		list, link, err := fetchTagList(ctx, host, next)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, list.Tags...)
+		if next, err = nextLink(base, link); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+func fetchTagList(ctx context.Context, host docker.RegistryHost, url string) (*tagList, string, error) {
+	resp, err := doRequest(ctx, host, http.MethodGet, url, http.Header{"Accept": []string{"application/json"}}, nil, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	var list tagList
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTagListSize)).Decode(&list); err != nil {
+		return nil, "", errors.Wrap(err, "decode tag list")
+	}
+	return &list, resp.Header.Get("Link"), nil
+}
+
+// nextLink parses the URL of next page from the `Link` header in the form
+// of `</v2/<name>/tags/list?n=<n>&last=<last>>; rel="next"`, it returns empty
+// if there is no next page.
+func nextLink(base, link string) (string, error) {
+	if link == "" {
+		return "", nil
+	}
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+	if start < 0 || end < start || !strings.Contains(link[end:], `rel="next"`) {
+		return "", fmt.Errorf("invalid link header %s", link)
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	nextURL, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", errors.Wrapf(err, "parse link header %s", link)
+	}
+	return baseURL.ResolveReference(nextURL).String(), nil
+}
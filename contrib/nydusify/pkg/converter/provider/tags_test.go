@@ -0,0 +1,63 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/library/busybox/tags/list":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/library/busybox/tags/list?n=2&last=1.36>; rel="next"`)
+				require.NoError(t, json.NewEncoder(w).Encode(tagList{Name: "library/busybox", Tags: []string{"1.35", "1.36"}}))
+				return
+			}
+			require.Equal(t, "1.36", r.URL.Query().Get("last"))
+			require.NoError(t, json.NewEncoder(w).Encode(tagList{Name: "library/busybox", Tags: []string{"latest"}}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := docker.RegistryHost{
+		Client:       server.Client(),
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		Scheme:       "http",
+		Path:         "/v2",
+		Capabilities: docker.HostCapabilityPull | docker.HostCapabilityResolve,
+	}
+
+	tags, err := fetchTags(context.Background(), host, "library/busybox")
+	require.NoError(t, err)
+	require.Equal(t, []string{"1.35", "1.36", "latest"}, tags)
+
+	_, err = fetchTags(context.Background(), host, "library/notfound")
+	require.Error(t, err)
+}
+
+func TestNextLink(t *testing.T) {
+	next, err := nextLink("https://registry.example.com", "")
+	require.NoError(t, err)
+	require.Empty(t, next)
+
+	next, err = nextLink("https://registry.example.com", `</v2/foo/tags/list?n=100&last=v1>; rel="next"`)
+	require.NoError(t, err)
+	require.Equal(t, "https://registry.example.com/v2/foo/tags/list?n=100&last=v1", next)
+
+	_, err = nextLink("https://registry.example.com", "invalid")
+	require.Error(t, err)
+}
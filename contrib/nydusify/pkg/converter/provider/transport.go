@@ -0,0 +1,59 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"net/http"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+
+	pkgRemote "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+)
+
+// SetTransport sets the CA bundle, client certificate and proxy to access
+// the registry of ref, it applies to all the images in the registry.
+func (pvd *Provider) SetTransport(ref string, opt pkgRemote.TransportOption) error {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", ref)
+	}
+	if err := opt.Validate(); err != nil {
+		return err
+	}
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	if pvd.transports == nil {
+		pvd.transports = make(map[string]pkgRemote.TransportOption)
+	}
+	pvd.transports[reference.Domain(named)] = opt
+	return nil
+}
+
+// SetRateLimit limits the total bandwidth in bytes per second of the pulls
+// and pushes from and to registries, zero means unlimited.
+func (pvd *Provider) SetRateLimit(pull, push int64) {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.pullLimiter = pkgRemote.NewRateLimiter(pull)
+	pvd.pushLimiter = pkgRemote.NewRateLimiter(push)
+}
+
+// newClient creates the HTTP client to access the registry of ref.
+func (pvd *Provider) newClient(ref string, insecure bool) (*http.Client, error) {
+	pvd.mutex.Lock()
+	var opt pkgRemote.TransportOption
+	if named, err := reference.ParseDockerRef(ref); err == nil {
+		opt = pvd.transports[reference.Domain(named)]
+	}
+	opt.PullLimiter = pvd.pullLimiter
+	opt.PushLimiter = pvd.pushLimiter
+	pvd.mutex.Unlock()
+	client, err := opt.WithInsecure(insecure).NewClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "create registry client")
+	}
+	return client, nil
+}
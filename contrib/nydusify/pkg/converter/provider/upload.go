@@ -0,0 +1,396 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	refdocker "github.com/containerd/containerd/v2/pkg/reference"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// maxUploadRetryDelay limits the exponential backoff between the retries of
+// resumable upload.
+const maxUploadRetryDelay = time.Minute
+
+// SetResumableUpload enables resumable upload for the blobs larger than
+// chunkSize, they are uploaded in chunks of chunkSize before pushing image.
+func (pvd *Provider) SetResumableUpload(chunkSize int64) {
+	pvd.mutex.Lock()
+	defer pvd.mutex.Unlock()
+	pvd.uploadChunkSize = chunkSize
+}
+
+// uploadBlobs uploads the large blobs of image desc to target by resumable
+// upload, so that they are skipped as existing blobs by the following push.
+// The blob is left to the push if the resumable upload fails.
+func (pvd *Provider) uploadBlobs(ctx context.Context, desc ocispec.Descriptor, ref, target string, platformMC platforms.MatchComparer) error {
+	pvd.mutex.Lock()
+	chunkSize := pvd.uploadChunkSize
+	pvd.mutex.Unlock()
+	if chunkSize <= 0 {
+		return nil
+	}
+
+	var blobs []ocispec.Descriptor
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if images.IsManifestType(desc.MediaType) || images.IsIndexType(desc.MediaType) {
+			return images.Children(ctx, pvd.store, desc)
+		}
+		if desc.Size > chunkSize {
+			blobs = append(blobs, desc)
+		}
+		return nil, nil
+	})
+	var walkHandler images.Handler = images.FilterPlatforms(handler, platformMC)
+	if wrapper := pvd.handlerWrapper(); wrapper != nil {
+		walkHandler = wrapper(walkHandler)
+	}
+	if err := images.Walk(ctx, walkHandler, desc); err != nil {
+		return errors.Wrap(err, "walk image")
+	}
+	if len(blobs) == 0 {
+		return nil
+	}
+
+	named, err := reference.ParseDockerRef(target)
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", target)
+	}
+	refspec, err := refdocker.Parse(named.String())
+	if err != nil {
+		return errors.Wrapf(err, "parse reference %s", target)
+	}
+	ctx, err = docker.ContextWithRepositoryScope(ctx, refspec, true)
+	if err != nil {
+		return err
+	}
+
+	uploader := &resumableUploader{
+		newHost: func() (docker.RegistryHost, error) {
+			return pvd.pushHost(ref)
+		},
+		repo:       reference.Path(named),
+		chunkSize:  chunkSize,
+		retryCount: pvd.pushRetryCount,
+		retryDelay: pvd.pushRetryDelay,
+	}
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(pvd.layerConcurrency())
+	for _, blob := range blobs {
+		eg.Go(func() error {
+			ra, err := pvd.store.ReaderAt(egCtx, blob)
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					return nil
+				}
+				return errors.Wrapf(err, "get reader of blob %s", blob.Digest)
+			}
+			defer ra.Close()
+			if err := uploader.Upload(egCtx, ra, blob); err != nil {
+				if egCtx.Err() != nil {
+					return err
+				}
+				logrus.WithError(err).Warnf("resumable upload of blob %s failed, push it in one request", blob.Digest)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+// pushHost returns the registry host to push the image of ref.
+func (pvd *Provider) pushHost(ref string) (docker.RegistryHost, error) {
+	hosts, err := pvd.registryHosts(ref)
+	if err != nil {
+		return docker.RegistryHost{}, err
+	}
+	for _, host := range hosts {
+		if host.Capabilities&docker.HostCapabilityPush != 0 {
+			return host, nil
+		}
+	}
+	return docker.RegistryHost{}, errors.Errorf("no registry host to push %s", ref)
+}
+
+// statusError is the unexpected response status of registry.
+type statusError struct {
+	method string
+	status string
+	code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %s of %s request", e.status, e.method)
+}
+
+func newStatusError(resp *http.Response) error {
+	err := &statusError{method: resp.Request.Method, status: resp.Status, code: resp.StatusCode}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return errors.Wrap(errdefs.ErrUnauthenticated, err.Error())
+	}
+	return err
+}
+
+// retryableUpload checks if the upload can be resumed after err.
+func retryableUpload(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.code {
+		case http.StatusRequestTimeout, http.StatusRequestedRangeNotSatisfiable, http.StatusTooManyRequests:
+			return true
+		}
+		return statusErr.code >= http.StatusInternalServerError
+	}
+	// Network errors and expired tokens.
+	return true
+}
+
+// resumableUploader uploads blobs in chunks by the chunked upload API of
+// registry, see https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-a-blob-in-chunks.
+// After a transient failure, the upload is resumed from the offset received
+// by registry, instead of restarting the blob from zero.
+type resumableUploader struct {
+	mutex      sync.Mutex
+	host       *docker.RegistryHost
+	newHost    func() (docker.RegistryHost, error)
+	repo       string
+	chunkSize  int64
+	retryCount int
+	retryDelay time.Duration
+}
+
+// getHost returns the registry host, the host is created again to request a
+// new registry token if refresh is true.
+func (u *resumableUploader) getHost(refresh bool) (docker.RegistryHost, error) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if u.host == nil || refresh {
+		host, err := u.newHost()
+		if err != nil {
+			return docker.RegistryHost{}, err
+		}
+		u.host = &host
+	}
+	return *u.host, nil
+}
+
+func (u *resumableUploader) url(host docker.RegistryHost, path string) string {
+	return fmt.Sprintf("%s://%s%s/%s/%s", host.Scheme, host.Host, host.Path, u.repo, path)
+}
+
+// Upload uploads the blob desc read from ra, it returns nil if the blob
+// already exists in registry.
+func (u *resumableUploader) Upload(ctx context.Context, ra io.ReaderAt, desc ocispec.Descriptor) error {
+	host, err := u.getHost(false)
+	if err != nil {
+		return err
+	}
+	exists, err := u.exists(ctx, host, desc.Digest)
+	if err != nil {
+		return errors.Wrap(err, "check blob existence")
+	}
+	if exists {
+		return nil
+	}
+	location, err := u.start(ctx, host)
+	if err != nil {
+		return errors.Wrap(err, "start upload")
+	}
+
+	var offset int64
+	failures := 0
+	for offset < desc.Size {
+		end := min(offset+u.chunkSize, desc.Size)
+		next, err := u.patch(ctx, host, location, io.NewSectionReader(ra, offset, end-offset), offset, end)
+		if err == nil {
+			location, offset, failures = next, end, 0
+			continue
+		}
+		if ctx.Err() != nil || !retryableUpload(err) || failures >= u.retryCount {
+			return errors.Wrapf(err, "upload blob %s at offset %d", desc.Digest, offset)
+		}
+
+		delay := min(u.retryDelay<<failures, maxUploadRetryDelay)
+		failures++
+		logrus.WithError(err).
+			WithField("attempt", failures).
+			WithField("total_attempts", u.retryCount).
+			Warnf("upload blob %s at offset %d failed, will resume after %s", desc.Digest, offset, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		if utils.IsUnauthorized(err) {
+			if host, err = u.getHost(true); err != nil {
+				return errors.Wrap(err, "refresh registry host")
+			}
+		}
+		// Resume from the offset received by registry.
+		next, received, err := u.status(ctx, host, location)
+		if errdefs.IsNotFound(err) {
+			logrus.Warnf("upload session of blob %s is expired, restart the upload", desc.Digest)
+		}
+		// The range `0-0` can't tell whether 0 or 1 byte is received.
+		if errdefs.IsNotFound(err) || (err == nil && received <= 1) {
+			next, err = u.start(ctx, host)
+			received = 0
+		}
+		if err != nil {
+			logrus.WithError(err).Warnf("get upload status of blob %s", desc.Digest)
+			continue
+		}
+		location, offset = next, received
+	}
+
+	return u.commit(ctx, host, location, desc.Digest)
+}
+
+func (u *resumableUploader) exists(ctx context.Context, host docker.RegistryHost, dgst digest.Digest) (bool, error) {
+	resp, err := doRequest(ctx, host, http.MethodHead, u.url(host, "blobs/"+dgst.String()), nil, nil, 0)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, newStatusError(resp)
+	}
+}
+
+// start starts an upload session and returns the upload location.
+func (u *resumableUploader) start(ctx context.Context, host docker.RegistryHost) (string, error) {
+	resp, err := doRequest(ctx, host, http.MethodPost, u.url(host, "blobs/uploads/"), nil, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return "", newStatusError(resp)
+	}
+	return location(resp)
+}
+
+// patch uploads the chunk [start, end) and returns the location to upload
+// the next chunk.
+func (u *resumableUploader) patch(ctx context.Context, host docker.RegistryHost, loc string, chunk *io.SectionReader, start, end int64) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-Range", fmt.Sprintf("%d-%d", start, end-1))
+	newBody := func() io.Reader {
+		return io.NewSectionReader(chunk, 0, chunk.Size())
+	}
+	resp, err := doRequest(ctx, host, http.MethodPatch, loc, header, newBody, end-start)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return "", newStatusError(resp)
+	}
+	return location(resp)
+}
+
+// status returns the location and the size received by registry of upload
+// session, it returns errdefs.ErrNotFound if the session is expired.
+func (u *resumableUploader) status(ctx context.Context, host docker.RegistryHost, loc string) (string, int64, error) {
+	resp, err := doRequest(ctx, host, http.MethodGet, loc, nil, nil, 0)
+	if err != nil {
+		return "", 0, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+	case http.StatusNotFound:
+		return "", 0, errors.Wrap(errdefs.ErrNotFound, "upload session")
+	default:
+		return "", 0, newStatusError(resp)
+	}
+	next, err := location(resp)
+	if err != nil {
+		return "", 0, err
+	}
+	received, err := parseRange(resp.Header.Get("Range"))
+	if err != nil {
+		return "", 0, err
+	}
+	return next, received, nil
+}
+
+// commit completes the upload session with the digest of blob.
+func (u *resumableUploader) commit(ctx context.Context, host docker.RegistryHost, loc string, dgst digest.Digest) error {
+	commitURL, err := url.Parse(loc)
+	if err != nil {
+		return errors.Wrapf(err, "parse upload location %s", loc)
+	}
+	query := commitURL.Query()
+	query.Set("digest", dgst.String())
+	commitURL.RawQuery = query.Encode()
+
+	resp, err := doRequest(ctx, host, http.MethodPut, commitURL.String(), nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Wrap(newStatusError(resp), "commit upload")
+	}
+	return nil
+}
+
+// location returns the absolute upload location of response.
+func location(resp *http.Response) (string, error) {
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return "", errors.Errorf("no upload location in response of %s request", resp.Request.Method)
+	}
+	next, err := resp.Request.URL.Parse(loc)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse upload location %s", loc)
+	}
+	return next.String(), nil
+}
+
+// parseRange returns the size received by registry from the `Range` header
+// in format `0-<end>`, the end is inclusive.
+func parseRange(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	_, end, ok := strings.Cut(strings.TrimPrefix(value, "bytes="), "-")
+	if !ok {
+		return 0, errors.Errorf("invalid range %s", value)
+	}
+	n, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid range %s", value)
+	}
+	return n + 1, nil
+}
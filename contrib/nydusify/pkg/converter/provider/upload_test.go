@@ -0,0 +1,173 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadRegistry is a registry supporting chunked upload, it fails the
+// PATCH requests in failPatches after receiving a half of the chunk, and
+// the upload session is expired by the failure if expireOnFailure is set.
+type uploadRegistry struct {
+	mutex           sync.Mutex
+	blobs           map[string][]byte
+	uploads         map[string]*bytes.Buffer
+	sessions        int
+	patches         int
+	failPatches     map[int]bool
+	expireOnFailure bool
+}
+
+func (r *uploadRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	const uploadPrefix = "/v2/library/busybox/blobs/uploads/"
+	switch {
+	case req.Method == http.MethodHead:
+		if _, ok := r.blobs[strings.TrimPrefix(req.URL.Path, "/v2/library/busybox/blobs/")]; ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case req.Method == http.MethodPost && req.URL.Path == uploadPrefix:
+		id := strconv.Itoa(r.sessions)
+		r.sessions++
+		r.uploads[id] = &bytes.Buffer{}
+		w.Header().Set("Location", uploadPrefix+id)
+		w.WriteHeader(http.StatusAccepted)
+	case strings.HasPrefix(req.URL.Path, uploadPrefix):
+		id := strings.TrimPrefix(req.URL.Path, uploadPrefix)
+		buf, ok := r.uploads[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Location", uploadPrefix+id)
+		switch req.Method {
+		case http.MethodGet:
+			w.Header().Set("Range", fmt.Sprintf("0-%d", max(buf.Len()-1, 0)))
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodPatch:
+			var start, end int
+			fmt.Sscanf(req.Header.Get("Content-Range"), "%d-%d", &start, &end)
+			if start != buf.Len() {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			data := make([]byte, end-start+1)
+			if _, err := io.ReadFull(req.Body, data); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.patches++
+			if r.failPatches[r.patches] {
+				buf.Write(data[:len(data)/2])
+				if r.expireOnFailure {
+					delete(r.uploads, id)
+				}
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			buf.Write(data)
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPut:
+			data := buf.Bytes()
+			if digest.FromBytes(data).String() != req.URL.Query().Get("digest") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.blobs[req.URL.Query().Get("digest")] = data
+			w.WriteHeader(http.StatusCreated)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestResumableUpload(t *testing.T) {
+	registry := &uploadRegistry{
+		blobs:       make(map[string][]byte),
+		uploads:     make(map[string]*bytes.Buffer),
+		failPatches: map[int]bool{2: true, 3: true},
+	}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	hostCreated := 0
+	uploader := &resumableUploader{
+		newHost: func() (docker.RegistryHost, error) {
+			hostCreated++
+			return docker.RegistryHost{
+				Client:       server.Client(),
+				Host:         strings.TrimPrefix(server.URL, "http://"),
+				Scheme:       "http",
+				Path:         "/v2",
+				Capabilities: docker.HostCapabilityPush,
+			}, nil
+		},
+		repo:       "library/busybox",
+		chunkSize:  10,
+		retryCount: 2,
+	}
+
+	data := []byte(strings.Repeat("0123456789", 4) + "abc")
+	desc := ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+	require.NoError(t, uploader.Upload(context.Background(), bytes.NewReader(data), desc))
+	require.Equal(t, data, registry.blobs[desc.Digest.String()])
+	// The second chunk is resumed twice from the received half, only the
+	// remaining parts are uploaded again.
+	require.Equal(t, 6, registry.patches)
+	require.Equal(t, 1, hostCreated)
+
+	// The existing blob is skipped.
+	require.NoError(t, uploader.Upload(context.Background(), bytes.NewReader(data), desc))
+	require.Equal(t, 6, registry.patches)
+
+	// The upload restarts if the session is expired.
+	data = []byte(strings.Repeat("abcdefghij", 3))
+	desc = ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+	registry.patches = 0
+	registry.failPatches = map[int]bool{2: true}
+	registry.expireOnFailure = true
+	uploader.retryCount = 1
+	require.NoError(t, uploader.Upload(context.Background(), bytes.NewReader(data), desc))
+	require.Equal(t, data, registry.blobs[desc.Digest.String()])
+	require.Equal(t, 5, registry.patches)
+
+	// Give up after the retries are exhausted.
+	data = []byte(strings.Repeat("klmnopqrst", 3))
+	desc = ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+	registry.patches = 0
+	registry.failPatches = map[int]bool{1: true, 2: true, 3: true}
+	registry.expireOnFailure = false
+	require.Error(t, uploader.Upload(context.Background(), bytes.NewReader(data), desc))
+	require.NotContains(t, registry.blobs, desc.Digest.String())
+}
+
+func TestParseRange(t *testing.T) {
+	for value, expected := range map[string]int64{"": 0, "0-0": 1, "0-1023": 1024, "bytes=0-99": 100} {
+		received, err := parseRange(value)
+		require.NoError(t, err)
+		require.Equal(t, expected, received)
+	}
+	_, err := parseRange("1024")
+	require.Error(t, err)
+}
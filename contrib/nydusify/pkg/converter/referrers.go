@@ -0,0 +1,147 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// referrerSubject maps a manifest of source image to the manifests of
+// target image, the referrers of source manifest are copied to the target
+// manifests.
+type referrerSubject struct {
+	source  digest.Digest
+	targets []ocispec.Descriptor
+}
+
+// copyReferrers copies the referrers (e.g. SBOM and provenance attestation)
+// of source image to target image, with the subject refreshed to the target
+// image or the target manifest of the same platform.
+func copyReferrers(ctx context.Context, pvd *provider.Provider, source, target string) error {
+	sourceDesc, err := resolveImage(ctx, pvd, source)
+	if err != nil {
+		return errors.Wrap(err, "resolve source image")
+	}
+	targetDesc, err := resolveImage(ctx, pvd, target)
+	if err != nil {
+		return errors.Wrap(err, "resolve target image")
+	}
+	sourceIndex, err := readIndex(ctx, pvd.ContentStore(), *sourceDesc)
+	if err != nil {
+		return errors.Wrap(err, "read source index")
+	}
+	targetIndex, err := readIndex(ctx, pvd.ContentStore(), *targetDesc)
+	if err != nil {
+		return errors.Wrap(err, "read target index")
+	}
+
+	copied := 0
+	for _, subject := range referrerSubjects(*sourceDesc, sourceIndex, *targetDesc, targetIndex) {
+		referrers, err := pvd.Referrers(ctx, source, subject.source)
+		if err != nil {
+			return errors.Wrapf(err, "list referrers of %s", subject.source)
+		}
+		for _, referrer := range referrers {
+			// Skip the nydus image associated to source image by `--with-referrer`.
+			if referrer.ArtifactType == utils.ArtifactTypeNydusImageManifest {
+				continue
+			}
+			for _, targetSubject := range subject.targets {
+				desc, err := pvd.CopyReferrer(ctx, source, referrer, target, targetSubject)
+				if err != nil {
+					return errors.Wrapf(err, "copy referrer %s", referrer.Digest)
+				}
+				logrus.WithField("artifactType", referrer.ArtifactType).
+					Infof("copied referrer %s of %s to %s of %s", referrer.Digest, subject.source, desc.Digest, targetSubject.Digest)
+				copied++
+			}
+		}
+	}
+	logrus.Infof("copied %d referrers to target image", copied)
+
+	return nil
+}
+
+func resolveImage(ctx context.Context, pvd *provider.Provider, ref string) (*ocispec.Descriptor, error) {
+	named, err := reference.ParseDockerRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := pvd.Resolver(ref)
+	if err != nil {
+		return nil, err
+	}
+	_, desc, err := resolver.Resolve(ctx, named.String())
+	if err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// readIndex returns nil if the image is not an index.
+func readIndex(ctx context.Context, store content.Store, desc ocispec.Descriptor) (*ocispec.Index, error) {
+	if desc.MediaType != ocispec.MediaTypeImageIndex && desc.MediaType != images.MediaTypeDockerSchema2ManifestList {
+		return nil, nil
+	}
+	data, err := content.ReadBlob(ctx, store, desc)
+	if err != nil {
+		return nil, err
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+// referrerSubjects maps source image to target image, and maps each source
+// manifest in index to the target manifests of the same platform, including
+// the source manifest itself kept in target index by `--merge-platform`.
+func referrerSubjects(sourceDesc ocispec.Descriptor, sourceIndex *ocispec.Index, targetDesc ocispec.Descriptor, targetIndex *ocispec.Index) []referrerSubject {
+	subjects := []referrerSubject{{
+		source:  sourceDesc.Digest,
+		targets: []ocispec.Descriptor{targetDesc},
+	}}
+	if sourceIndex == nil || targetIndex == nil {
+		return subjects
+	}
+
+	for _, sourceManifest := range sourceIndex.Manifests {
+		subject := referrerSubject{source: sourceManifest.Digest}
+		for _, targetManifest := range targetIndex.Manifests {
+			if targetManifest.Digest == sourceManifest.Digest || samePlatform(sourceManifest.Platform, targetManifest.Platform) {
+				subject.targets = append(subject.targets, targetManifest)
+			}
+		}
+		if len(subject.targets) > 0 {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	return subjects
+}
+
+// samePlatform ignores the OS features, which are used by nydus manifest.
+func samePlatform(a, b *ocispec.Platform) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	pa := platforms.Normalize(ocispec.Platform{OS: a.OS, Architecture: a.Architecture, Variant: a.Variant})
+	pb := platforms.Normalize(ocispec.Platform{OS: b.OS, Architecture: b.Architecture, Variant: b.Variant})
+	return pa.OS == pb.OS && pa.Architecture == pb.Architecture && pa.Variant == pb.Variant
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestReferrerSubjects(t *testing.T) {
+	amd64 := &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}
+	nydusAmd64 := &ocispec.Platform{OS: "linux", Architecture: "amd64", OSFeatures: []string{utils.ManifestOSFeatureNydus}}
+
+	sourceAmd64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("source-amd64"), Platform: amd64}
+	sourceArm64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("source-arm64"), Platform: arm64}
+	targetAmd64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("target-amd64"), Platform: nydusAmd64}
+	targetArm64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("target-arm64"), Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64"}}
+
+	sourceDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: digest.FromString("source")}
+	targetDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex, Digest: digest.FromString("target")}
+
+	// Single manifest image.
+	require.Equal(t, []referrerSubject{
+		{source: sourceDesc.Digest, targets: []ocispec.Descriptor{targetDesc}},
+	}, referrerSubjects(sourceDesc, nil, targetDesc, nil))
+
+	// Multi-platform image, the arm64 variant is normalized.
+	sourceIndex := &ocispec.Index{Manifests: []ocispec.Descriptor{sourceAmd64, sourceArm64}}
+	targetIndex := &ocispec.Index{Manifests: []ocispec.Descriptor{targetAmd64, targetArm64}}
+	require.Equal(t, []referrerSubject{
+		{source: sourceDesc.Digest, targets: []ocispec.Descriptor{targetDesc}},
+		{source: sourceAmd64.Digest, targets: []ocispec.Descriptor{targetAmd64}},
+		{source: sourceArm64.Digest, targets: []ocispec.Descriptor{targetArm64}},
+	}, referrerSubjects(sourceDesc, sourceIndex, targetDesc, targetIndex))
+
+	// The source manifest is kept in target index by `--merge-platform`.
+	targetIndex = &ocispec.Index{Manifests: []ocispec.Descriptor{sourceAmd64, targetAmd64}}
+	require.Equal(t, []referrerSubject{
+		{source: sourceDesc.Digest, targets: []ocispec.Descriptor{targetDesc}},
+		{source: sourceAmd64.Digest, targets: []ocispec.Descriptor{sourceAmd64, targetAmd64}},
+	}, referrerSubjects(sourceDesc, sourceIndex, targetDesc, targetIndex))
+}
+
+func TestSamePlatform(t *testing.T) {
+	require.False(t, samePlatform(nil, &ocispec.Platform{OS: "linux", Architecture: "amd64"}))
+	require.True(t, samePlatform(
+		&ocispec.Platform{OS: "linux", Architecture: "amd64"},
+		&ocispec.Platform{OS: "linux", Architecture: "amd64", OSFeatures: []string{utils.ManifestOSFeatureNydus}},
+	))
+	require.False(t, samePlatform(
+		&ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+		&ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v6"},
+	))
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// conversionDir returns the directory in work directory to keep the content
+// store of conversion, it's the same for the re-run of the same command, so
+// that an interrupted conversion can be resumed. The source layers pulled
+// are found in the content store, and the source layers converted are
+// labeled with the digests of nydus blobs built from them, which are reused
+// instead of being built again. It's keyed by all the options affecting the
+// target image, so the blobs built with different options are never reused.
+// The hooks can't be keyed, they must not change the target image between
+// the runs to resume.
+func conversionDir(opt Opt) (string, error) {
+	key, err := json.Marshal(struct {
+		Source       string
+		Target       string
+		AllPlatforms bool
+		Platforms    string
+		SourceFormat string
+		PrefetchAuto bool
+		Stream       bool
+		Config       map[string]string
+		// The chunk dict ref of the merged or selected chunk dicts is only
+		// known during conversion, so the chunk dicts are keyed instead.
+		ChunkDicts       []ChunkDict             `json:",omitempty"`
+		ChunkDictCatalog []ChunkDictCatalogEntry `json:",omitempty"`
+		CompressorPolicy string                  `json:",omitempty"`
+
+		SourceBackendType     string            `json:",omitempty"`
+		SourceBackendConfig   string            `json:",omitempty"`
+		ChunkDictFromTarget   bool              `json:",omitempty"`
+		ZstdChunkedInterop    bool              `json:",omitempty"`
+		IfNydus               string            `json:",omitempty"`
+		Features              []string          `json:",omitempty"`
+		FileDigests           bool              `json:",omitempty"`
+		FileDigestMinSize     int64             `json:",omitempty"`
+		OCITailLayers         int               `json:",omitempty"`
+		OCITailMaxSize        int64             `json:",omitempty"`
+		Flatten               bool              `json:",omitempty"`
+		Annotations           map[string]string `json:",omitempty"`
+		AllowNondistributable bool              `json:",omitempty"`
+		CopyReferrers         bool              `json:",omitempty"`
+		SBOMFormat            string            `json:",omitempty"`
+		EncryptRecipients     []string          `json:",omitempty"`
+		OutputLayout          string            `json:",omitempty"`
+	}{
+		Source:       opt.Source,
+		Target:       opt.Target,
+		AllPlatforms: opt.AllPlatforms,
+		Platforms:    opt.Platforms,
+		SourceFormat: opt.SourceFormat,
+		PrefetchAuto: opt.PrefetchAuto,
+		Stream:       opt.Stream,
+		Config:       getConfig(opt),
+
+		ChunkDicts:       opt.ChunkDicts,
+		ChunkDictCatalog: opt.ChunkDictCatalog,
+		CompressorPolicy: opt.CompressorPolicy.String(),
+
+		SourceBackendType:     opt.SourceBackendType,
+		SourceBackendConfig:   opt.SourceBackendConfig,
+		ChunkDictFromTarget:   opt.ChunkDictFromTarget,
+		ZstdChunkedInterop:    opt.ZstdChunkedInterop,
+		IfNydus:               opt.IfNydus,
+		Features:              opt.Features,
+		FileDigests:           opt.FileDigests,
+		FileDigestMinSize:     opt.FileDigestMinSize,
+		OCITailLayers:         opt.OCITailLayers,
+		OCITailMaxSize:        opt.OCITailMaxSize,
+		Flatten:               opt.Flatten,
+		Annotations:           opt.Annotations,
+		AllowNondistributable: opt.AllowNondistributable,
+		CopyReferrers:         opt.CopyReferrers,
+		SBOMFormat:            opt.SBOMFormat,
+		EncryptRecipients:     opt.EncryptRecipients,
+		OutputLayout:          opt.OutputLayout,
+	})
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(opt.WorkDir, "nydusify-"+digest.FromBytes(key).Encoded()[:16]), nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversionDir(t *testing.T) {
+	opt := Opt{WorkDir: "/tmp/work", Source: "localhost:5000/busybox", Target: "localhost:5000/busybox:nydus", FsVersion: "6"}
+	dir1, err := conversionDir(opt)
+	require.NoError(t, err)
+	dir2, err := conversionDir(opt)
+	require.NoError(t, err)
+	require.Equal(t, dir1, dir2)
+	require.True(t, strings.HasPrefix(dir1, "/tmp/work/nydusify-"))
+
+	opt.FsVersion = "5"
+	dir3, err := conversionDir(opt)
+	require.NoError(t, err)
+	require.NotEqual(t, dir1, dir3)
+
+	// The options affecting target image change the directory.
+	dirs := map[string]bool{dir1: true, dir3: true}
+	for _, change := range []func(opt *Opt){
+		func(opt *Opt) { opt.Features = []string{FeatureBlobToc} },
+		func(opt *Opt) { opt.FileDigests = true },
+		func(opt *Opt) { opt.OCITailLayers = 1 },
+		func(opt *Opt) { opt.EncryptRecipients = []string{"jwe:pubkey.pem"} },
+		func(opt *Opt) { opt.Flatten = true },
+		func(opt *Opt) { opt.Annotations = map[string]string{"key": "value"} },
+		func(opt *Opt) { opt.AllowNondistributable = true },
+		func(opt *Opt) { opt.IfNydus = IfNydusSkip },
+	} {
+		changed := opt
+		change(&changed)
+		dir, err := conversionDir(changed)
+		require.NoError(t, err)
+		require.False(t, dirs[dir])
+		dirs[dir] = true
+	}
+}
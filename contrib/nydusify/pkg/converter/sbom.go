@@ -0,0 +1,177 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/sbom"
+)
+
+// attachSBOM generates the SBOM from the layers of source image kept in
+// content store, and attaches it to the target manifest of the same
+// platform as a referrer artifact.
+func attachSBOM(ctx context.Context, pvd *provider.Provider, source, target, format string) error {
+	store := pvd.ContentStore()
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "get source image")
+	}
+	targetDesc, err := resolveImage(ctx, pvd, target)
+	if err != nil {
+		return errors.Wrap(err, "resolve target image")
+	}
+	sourceIndex, err := readIndex(ctx, store, *sourceDesc)
+	if err != nil {
+		return errors.Wrap(err, "read source index")
+	}
+	targetIndex, err := readIndex(ctx, store, *targetDesc)
+	if err != nil {
+		return errors.Wrap(err, "read target index")
+	}
+
+	manifests := map[digest.Digest]ocispec.Descriptor{sourceDesc.Digest: *sourceDesc}
+	if sourceIndex != nil {
+		for _, desc := range sourceIndex.Manifests {
+			manifests[desc.Digest] = desc
+		}
+	}
+
+	for _, subject := range referrerSubjects(*sourceDesc, sourceIndex, *targetDesc, targetIndex) {
+		// The SBOM describes the rootfs of a single platform.
+		manifestDesc := manifests[subject.source]
+		if sourceIndex != nil && manifestDesc.Digest == sourceDesc.Digest {
+			continue
+		}
+
+		rootfs, err := buildRootfs(ctx, store, manifestDesc)
+		if err != nil {
+			return errors.Wrapf(err, "build rootfs of %s", manifestDesc.Digest)
+		}
+		data, err := sbom.Generate(format, target, rootfs)
+		if err != nil {
+			return errors.Wrap(err, "generate SBOM")
+		}
+
+		for _, targetSubject := range subject.targets {
+			desc, err := writeSBOMArtifact(ctx, store, format, data, targetSubject)
+			if err != nil {
+				return errors.Wrap(err, "write SBOM artifact")
+			}
+			if err := pvd.PushReferrer(ctx, target, *desc); err != nil {
+				return errors.Wrap(err, "push SBOM artifact")
+			}
+			logrus.WithField("format", format).
+				Infof("attached SBOM %s to %s", desc.Digest, targetSubject.Digest)
+		}
+	}
+
+	return nil
+}
+
+// buildRootfs applies the layers of image manifest in order.
+func buildRootfs(ctx context.Context, store content.Store, manifestDesc ocispec.Descriptor) (*sbom.Rootfs, error) {
+	data, err := content.ReadBlob(ctx, store, manifestDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read manifest")
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "unmarshal manifest")
+	}
+
+	rootfs := sbom.NewRootfs()
+	for _, layer := range manifest.Layers {
+		if err := applyLayer(ctx, store, rootfs, layer); err != nil {
+			return nil, errors.Wrapf(err, "apply layer %s", layer.Digest)
+		}
+	}
+	return rootfs, nil
+}
+
+func applyLayer(ctx context.Context, store content.Store, rootfs *sbom.Rootfs, layer ocispec.Descriptor) error {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+
+	reader, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return rootfs.ApplyLayer(reader)
+}
+
+// writeSBOMArtifact writes the SBOM artifact manifest referring to subject
+// into content store.
+func writeSBOMArtifact(ctx context.Context, store content.Store, format string, data []byte, subject ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	mediaType, err := sbom.MediaType(format)
+	if err != nil {
+		return nil, err
+	}
+	return writeArtifact(ctx, store, mediaType, data, subject)
+}
+
+// writeArtifact writes the artifact manifest of data referring to subject
+// into content store, the config is the empty descriptor as recommended by
+// OCI image spec for artifacts.
+func writeArtifact(ctx context.Context, store content.Store, mediaType string, data []byte, subject ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	layer := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	config := ocispec.DescriptorEmptyJSON
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: mediaType,
+		Config:       config,
+		Layers:       []ocispec.Descriptor{layer},
+		Subject: &ocispec.Descriptor{
+			MediaType: subject.MediaType,
+			Digest:    subject.Digest,
+			Size:      subject.Size,
+		},
+	}
+	manifest.SchemaVersion = 2
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: mediaType,
+		Digest:       digest.FromBytes(manifestData),
+		Size:         int64(len(manifestData)),
+	}
+
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{config, config.Data},
+		{layer, data},
+		{desc, manifestData},
+	} {
+		if err := content.WriteBlob(ctx, store, blob.desc.Digest.String(), bytes.NewReader(blob.data), blob.desc); err != nil {
+			return nil, errors.Wrapf(err, "write blob %s", blob.desc.Digest)
+		}
+	}
+
+	return &desc, nil
+}
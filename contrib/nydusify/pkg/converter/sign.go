@@ -0,0 +1,52 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/signer"
+)
+
+func (opt *Opt) shouldSign() bool {
+	return opt.SignCosignKey != "" || opt.SignCosignKeyless
+}
+
+// signImage signs the pushed target image by cosign, the target image is
+// pinned by digest to avoid signing a tag overwritten by others.
+func signImage(ctx context.Context, pvd *provider.Provider, opt Opt) error {
+	named, err := reference.ParseDockerRef(opt.Target)
+	if err != nil {
+		return errors.Wrap(err, "parse target reference")
+	}
+	resolver, err := pvd.Resolver(named.String())
+	if err != nil {
+		return errors.Wrap(err, "get resolver")
+	}
+	_, desc, err := resolver.Resolve(ctx, named.String())
+	if err != nil {
+		return errors.Wrap(err, "resolve target image")
+	}
+	ref := named.Name() + "@" + desc.Digest.String()
+
+	logrus.Infof("signing target image %s", ref)
+	cosign := signer.NewCosign(opt.CosignPath)
+	if err := cosign.Sign(signer.SignOption{
+		Ref:       ref,
+		Key:       opt.SignCosignKey,
+		Insecure:  opt.TargetInsecure,
+		PlainHTTP: opt.WithPlainHTTP,
+	}); err != nil {
+		return errors.Wrap(err, "cosign sign")
+	}
+	logrus.Infof("signed target image %s", ref)
+
+	return nil
+}
@@ -0,0 +1,358 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/dustin/go-humanize"
+	"github.com/goharbor/acceleration-service/pkg/converter"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// ReportVersion is the format version of Report dumped by `--output-json`.
+// The version 1 has only the fields of conversion metric, whose values are
+// all integers, the version 2 keeps them and adds the nested fields.
+const ReportVersion = 2
+
+// Report is the result of image conversion dumped by `--output-json`, the
+// fields of conversion metric are kept at the top level.
+type Report struct {
+	// Version is set to ReportVersion when the report is dumped.
+	Version int `json:",omitempty"`
+	*converter.Metric
+	SizeAnalysis []ImageSize `json:",omitempty"`
+	// CopiedPlatforms can't be converted and are passed through to target
+	// index as is with `--merge-platform`.
+	ConvertedPlatforms []string `json:",omitempty"`
+	CopiedPlatforms    []string `json:",omitempty"`
+}
+
+// ImageSize is the size analysis of the nydus image of a platform against
+// the source image.
+type ImageSize struct {
+	Platform string `json:",omitempty"`
+	// SourceSize is the compressed size of source layers.
+	SourceSize int64
+	// SourceUncompressedSize is only analyzed with `--output-json`, since
+	// the source layers have to be decompressed again.
+	SourceUncompressedSize int64 `json:",omitempty"`
+	// TargetSize is the size of nydus blobs and bootstrap pushed for the
+	// image, excluding the blobs reused from chunk dict.
+	TargetSize          int64
+	TargetBlobSize      int64
+	TargetBootstrapSize int64
+	// ChunkDictBlobs are the blobs in chunk dict image referenced by the
+	// nydus image, the chunks deduplicated with the chunk dict are stored
+	// in them instead of the nydus blobs.
+	ChunkDictBlobs    int   `json:",omitempty"`
+	ChunkDictBlobSize int64 `json:",omitempty"`
+	// DedupRatio is the ratio of the blob data reused from chunk dict, which
+	// isn't pushed again, to all the blob data referenced by the image.
+	DedupRatio float64 `json:",omitempty"`
+	// SavedSize is SourceSize minus TargetSize, it's negative if the nydus
+	// image is larger.
+	SavedSize int64
+	Layers    []LayerSize
+}
+
+// LayerSize pairs a source layer with the nydus blob converted from it,
+// the digests of a side are empty if the layers can't be paired.
+type LayerSize struct {
+	SourceDigest           digest.Digest `json:",omitempty"`
+	SourceSize             int64         `json:",omitempty"`
+	SourceUncompressedSize int64         `json:",omitempty"`
+	TargetDigest           digest.Digest `json:",omitempty"`
+	TargetSize             int64         `json:",omitempty"`
+}
+
+type platformManifest struct {
+	desc     ocispec.Descriptor
+	manifest ocispec.Manifest
+}
+
+// analyzeSize analyzes the size of target image converted from source
+// image in the content store of provider.
+func analyzeSize(ctx context.Context, pvd *provider.Provider, source, target, chunkDictRef string, uncompressed bool) ([]ImageSize, error) {
+	store := pvd.ContentStore()
+	sourceDesc, err := pvd.Image(ctx, source)
+	if err != nil {
+		return nil, errors.Wrap(err, "get source image")
+	}
+	targetDesc, err := pvd.Image(ctx, target)
+	if err != nil {
+		return nil, errors.Wrap(err, "get target image")
+	}
+	dictBlobs, err := chunkDictBlobs(ctx, pvd, chunkDictRef)
+	if err != nil {
+		return nil, errors.Wrap(err, "get chunk dict blobs")
+	}
+
+	sourceManifests, err := platformManifests(ctx, store, *sourceDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read source manifests")
+	}
+	targetManifests, err := platformManifests(ctx, store, *targetDesc)
+	if err != nil {
+		return nil, errors.Wrap(err, "read target manifests")
+	}
+
+	var sizes []ImageSize
+	for _, targetManifest := range targetManifests {
+		// Skip the source manifests kept by `--merge-platform`.
+		if !isNydusManifest(targetManifest.manifest) {
+			continue
+		}
+		sourceManifest := matchManifest(sourceManifests, targetManifest)
+		if sourceManifest == nil {
+			continue
+		}
+		size, err := imageSize(ctx, store, *sourceManifest, targetManifest, dictBlobs, uncompressed)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, *size)
+	}
+
+	return sizes, nil
+}
+
+// platformManifests returns the manifests of image in content store, the
+// manifests of the platforms not pulled are skipped.
+func platformManifests(ctx context.Context, store content.Store, desc ocispec.Descriptor) ([]platformManifest, error) {
+	index, err := readIndex(ctx, store, desc)
+	if err != nil {
+		return nil, err
+	}
+	descs := []ocispec.Descriptor{desc}
+	if index != nil {
+		descs = index.Manifests
+	}
+
+	var manifests []platformManifest
+	for _, desc := range descs {
+		data, err := content.ReadBlob(ctx, store, desc)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "read manifest %s", desc.Digest)
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal manifest %s", desc.Digest)
+		}
+		manifests = append(manifests, platformManifest{desc: desc, manifest: manifest})
+	}
+	return manifests, nil
+}
+
+func isNydusManifest(manifest ocispec.Manifest) bool {
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func matchManifest(sources []platformManifest, target platformManifest) *platformManifest {
+	if len(sources) == 1 {
+		return &sources[0]
+	}
+	for idx := range sources {
+		if samePlatform(sources[idx].desc.Platform, target.desc.Platform) {
+			return &sources[idx]
+		}
+	}
+	return nil
+}
+
+func imageSize(ctx context.Context, store content.Store, source, target platformManifest, dictBlobs map[digest.Digest]bool, uncompressed bool) (*ImageSize, error) {
+	size := ImageSize{}
+	if target.desc.Platform != nil {
+		size.Platform = platforms.Format(*target.desc.Platform)
+	}
+
+	var blobs []ocispec.Descriptor
+	for _, layer := range target.manifest.Layers {
+		switch {
+		case layer.Annotations[utils.LayerAnnotationNydusBootstrap] == "true":
+			size.TargetBootstrapSize += layer.Size
+		case dictBlobs[layer.Digest]:
+			size.ChunkDictBlobs++
+			size.ChunkDictBlobSize += layer.Size
+		default:
+			size.TargetBlobSize += layer.Size
+			blobs = append(blobs, layer)
+		}
+	}
+	size.TargetSize = size.TargetBlobSize + size.TargetBootstrapSize
+	if size.ChunkDictBlobSize > 0 {
+		size.DedupRatio = float64(size.ChunkDictBlobSize) / float64(size.ChunkDictBlobSize+size.TargetBlobSize)
+	}
+
+	// The nydus blobs are in the order of the source layers converted from,
+	// but no blob is generated for an empty layer, so they are paired only
+	// if the numbers are the same.
+	paired := len(blobs) == len(source.manifest.Layers)
+	for idx, layer := range source.manifest.Layers {
+		layerSize := LayerSize{
+			SourceDigest: layer.Digest,
+			SourceSize:   layer.Size,
+		}
+		if uncompressed {
+			n, err := uncompressedSize(ctx, store, layer)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get uncompressed size of layer %s", layer.Digest)
+			}
+			layerSize.SourceUncompressedSize = n
+			size.SourceUncompressedSize += n
+		}
+		if paired {
+			layerSize.TargetDigest = blobs[idx].Digest
+			layerSize.TargetSize = blobs[idx].Size
+		}
+		size.SourceSize += layer.Size
+		size.Layers = append(size.Layers, layerSize)
+	}
+	if !paired {
+		for _, blob := range blobs {
+			size.Layers = append(size.Layers, LayerSize{
+				TargetDigest: blob.Digest,
+				TargetSize:   blob.Size,
+			})
+		}
+	}
+	size.SavedSize = size.SourceSize - size.TargetSize
+
+	return &size, nil
+}
+
+func uncompressedSize(ctx context.Context, store content.Store, layer ocispec.Descriptor) (int64, error) {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return 0, err
+	}
+	defer ra.Close()
+
+	reader, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	return io.Copy(io.Discard, reader)
+}
+
+// chunkDictBlobs returns the layers of chunk dict image, only the manifests
+// are fetched from registry if the image isn't in content store.
+func chunkDictBlobs(ctx context.Context, pvd *provider.Provider, ref string) (map[digest.Digest]bool, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	// The chunk dict merged by `mergeChunkDicts` is only in content store.
+	manifests, err := imageManifests(ctx, pvd, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := make(map[digest.Digest]bool)
+	for _, manifest := range manifests {
+		for _, layer := range manifest.Layers {
+			blobs[layer.Digest] = true
+		}
+	}
+	return blobs, nil
+}
+
+// imageManifests returns the manifests of image in content store, or in
+// registry if it's not pulled yet.
+func imageManifests(ctx context.Context, pvd *provider.Provider, ref string) ([]ocispec.Manifest, error) {
+	if desc, err := pvd.Image(ctx, ref); err == nil {
+		local, err := platformManifests(ctx, pvd.ContentStore(), *desc)
+		if err != nil {
+			return nil, err
+		}
+		var manifests []ocispec.Manifest
+		for _, manifest := range local {
+			manifests = append(manifests, manifest.manifest)
+		}
+		return manifests, nil
+	}
+	_, manifests, err := remoteManifests(ctx, pvd, ref)
+	return manifests, err
+}
+
+// remoteManifests resolves the image in registry, and returns its
+// descriptor and the manifests of all platforms.
+func remoteManifests(ctx context.Context, pvd *provider.Provider, ref string) (*ocispec.Descriptor, []ocispec.Manifest, error) {
+	return pvd.RemoteManifests(ctx, ref, nil)
+}
+
+func fetchJSON(ctx context.Context, fetcher remotes.Fetcher, desc ocispec.Descriptor, v interface{}) error {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return errors.Wrapf(err, "fetch %s", desc.Digest)
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
+
+// printSizeSummary prints the size analysis as a table.
+func printSizeSummary(writer io.Writer, sizes []ImageSize) {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PLATFORM\tSOURCE\tUNCOMPRESSED\tNYDUS BLOBS\tBOOTSTRAP\tCHUNK DICT BLOBS\tSAVED")
+	for _, size := range sizes {
+		platform := size.Platform
+		if platform == "" {
+			platform = "-"
+		}
+		uncompressed := "-"
+		if size.SourceUncompressedSize > 0 {
+			uncompressed = humanize.Bytes(uint64(size.SourceUncompressedSize))
+		}
+		chunkDict := "-"
+		if size.ChunkDictBlobs > 0 {
+			chunkDict = fmt.Sprintf("%s (%d)", humanize.Bytes(uint64(size.ChunkDictBlobSize)), size.ChunkDictBlobs)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			platform,
+			humanize.Bytes(uint64(size.SourceSize)),
+			uncompressed,
+			humanize.Bytes(uint64(size.TargetBlobSize)),
+			humanize.Bytes(uint64(size.TargetBootstrapSize)),
+			chunkDict,
+			formatSaved(size.SavedSize, size.SourceSize),
+		)
+	}
+	tw.Flush()
+}
+
+func formatSaved(saved, total int64) string {
+	sign := ""
+	if saved < 0 {
+		sign = "-"
+		saved = -saved
+	}
+	if total == 0 {
+		return sign + humanize.Bytes(uint64(saved))
+	}
+	return fmt.Sprintf("%s%s (%s%.1f%%)", sign, humanize.Bytes(uint64(saved)), sign, float64(saved)*100/float64(total))
+}
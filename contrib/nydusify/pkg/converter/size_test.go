@@ -0,0 +1,125 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goharbor/acceleration-service/pkg/converter"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestImageSize(t *testing.T) {
+	layer := func(name string, size int64) ocispec.Descriptor {
+		return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString(name), Size: size}
+	}
+	blob := func(name string, size int64) ocispec.Descriptor {
+		return ocispec.Descriptor{MediaType: utils.MediaTypeNydusBlob, Digest: digest.FromString(name), Size: size}
+	}
+	bootstrap := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageLayerGzip,
+		Digest:      digest.FromString("bootstrap"),
+		Size:        10,
+		Annotations: map[string]string{utils.LayerAnnotationNydusBootstrap: "true"},
+	}
+	amd64 := &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+
+	source := platformManifest{
+		desc:     ocispec.Descriptor{Platform: amd64},
+		manifest: ocispec.Manifest{Layers: []ocispec.Descriptor{layer("layer-1", 100), layer("layer-2", 200)}},
+	}
+	target := platformManifest{
+		desc: ocispec.Descriptor{Platform: amd64},
+		manifest: ocispec.Manifest{Layers: []ocispec.Descriptor{
+			blob("blob-1", 80), blob("dict-blob", 1000), blob("blob-2", 150), bootstrap,
+		}},
+	}
+	dictBlobs := map[digest.Digest]bool{digest.FromString("dict-blob"): true}
+
+	size, err := imageSize(context.Background(), nil, source, target, dictBlobs, false)
+	require.NoError(t, err)
+	require.Equal(t, &ImageSize{
+		Platform:            "linux/amd64",
+		SourceSize:          300,
+		TargetSize:          240,
+		TargetBlobSize:      230,
+		TargetBootstrapSize: 10,
+		ChunkDictBlobs:      1,
+		ChunkDictBlobSize:   1000,
+		DedupRatio:          float64(1000) / 1230,
+		SavedSize:           60,
+		Layers: []LayerSize{
+			{SourceDigest: digest.FromString("layer-1"), SourceSize: 100, TargetDigest: digest.FromString("blob-1"), TargetSize: 80},
+			{SourceDigest: digest.FromString("layer-2"), SourceSize: 200, TargetDigest: digest.FromString("blob-2"), TargetSize: 150},
+		},
+	}, size)
+
+	// The layers can't be paired without chunk dict blobs identified.
+	size, err = imageSize(context.Background(), nil, source, target, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, int64(1230), size.TargetBlobSize)
+	require.Equal(t, int64(-940), size.SavedSize)
+	require.Len(t, size.Layers, 5)
+	require.Empty(t, size.Layers[0].TargetDigest)
+	require.Empty(t, size.Layers[2].SourceDigest)
+
+	require.True(t, isNydusManifest(target.manifest))
+	require.False(t, isNydusManifest(source.manifest))
+}
+
+func TestMatchManifest(t *testing.T) {
+	amd64 := platformManifest{desc: ocispec.Descriptor{Digest: digest.FromString("amd64"), Platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"}}}
+	arm64 := platformManifest{desc: ocispec.Descriptor{Digest: digest.FromString("arm64"), Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64"}}}
+	target := platformManifest{desc: ocispec.Descriptor{Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64", OSFeatures: []string{utils.ManifestOSFeatureNydus}}}}
+
+	require.Equal(t, arm64.desc.Digest, matchManifest([]platformManifest{amd64, arm64}, target).desc.Digest)
+	require.Equal(t, amd64.desc.Digest, matchManifest([]platformManifest{amd64}, target).desc.Digest)
+	require.Nil(t, matchManifest([]platformManifest{amd64, amd64}, target))
+}
+
+func TestPrintSizeSummary(t *testing.T) {
+	buf := &bytes.Buffer{}
+	printSizeSummary(buf, []ImageSize{
+		{Platform: "linux/amd64", SourceSize: 1000000, SourceUncompressedSize: 3000000, TargetBlobSize: 700000, TargetBootstrapSize: 50000, SavedSize: 250000},
+		{SourceSize: 1000, TargetBlobSize: 1100, ChunkDictBlobs: 2, ChunkDictBlobSize: 5000, SavedSize: -100},
+	})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], "CHUNK DICT BLOBS")
+	require.Equal(t, []string{"linux/amd64", "1.0", "MB", "3.0", "MB", "700", "kB", "50", "kB", "-", "250", "kB", "(25.0%)"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"-", "1.0", "kB", "-", "1.1", "kB", "0", "B", "5.0", "kB", "(2)", "-100", "B", "(-10.0%)"}, strings.Fields(lines[2]))
+}
+
+func TestDumpMetric(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.json")
+	report := &Report{
+		Metric:       &converter.Metric{SourceImageSize: 100, TargetImageSize: 80, ConversionElapsed: time.Second},
+		SizeAnalysis: []ImageSize{{Platform: "linux/amd64", SourceSize: 100, TargetSize: 80}},
+	}
+	require.NoError(t, dumpMetric(report, path))
+	require.Zero(t, report.Version)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+	// The top-level fields of version 1 are kept.
+	require.JSONEq(t, "2", string(fields["Version"]))
+	require.JSONEq(t, "100", string(fields["SourceImageSize"]))
+	require.JSONEq(t, "80", string(fields["TargetImageSize"]))
+	require.JSONEq(t, "1000000000", string(fields["ConversionElapsed"]))
+	require.Contains(t, fields, "SizeAnalysis")
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"context"
+
+	"github.com/containerd/platforms"
+	"github.com/dustin/go-humanize"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// estimateSpace returns the space of work directory estimated to convert
+// the image of manifests: the source layers downloaded unless they are
+// streamed, and the nydus blobs built from them, whose size is estimated by
+// the compressed size of source layers. The layers shared by platforms are
+// counted once.
+func estimateSpace(manifests []ocispec.Manifest, stream bool) int64 {
+	var layers int64
+	seen := map[digest.Digest]bool{}
+	for _, manifest := range manifests {
+		for _, layer := range manifest.Layers {
+			if seen[layer.Digest] {
+				continue
+			}
+			seen[layer.Digest] = true
+			layers += layer.Size
+		}
+	}
+	if stream {
+		return layers
+	}
+	return layers * 2
+}
+
+// checkSpace fails fast if the work directory lacks the space estimated to
+// convert the source image, instead of running out of space in the middle
+// of conversion.
+func checkSpace(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer, opt Opt) error {
+	_, manifests, err := pvd.RemoteManifests(ctx, source, platformMC)
+	if err != nil {
+		// The estimation is best effort, the error of source image is
+		// reported by pulling.
+		logrus.WithError(err).Warn("failed to estimate the space required by conversion")
+		return nil
+	}
+	required := estimateSpace(manifests, opt.Stream)
+	logrus.Debugf("conversion needs about %s in work directory", humanize.IBytes(uint64(required)))
+	if err := utils.CheckSpace(opt.WorkDir, required, opt.WorkDirQuota); err != nil {
+		if !opt.Stream {
+			return errors.Wrapf(err, "insufficient space to convert image (about %s if streaming source layers)",
+				humanize.IBytes(uint64(estimateSpace(manifests, true))))
+		}
+		return errors.Wrap(err, "insufficient space to convert image")
+	}
+	return nil
+}
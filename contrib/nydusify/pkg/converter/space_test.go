@@ -0,0 +1,25 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package converter
+
+import (
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSpace(t *testing.T) {
+	base := ocispec.Descriptor{Digest: digest.FromString("base"), Size: 100}
+	manifests := []ocispec.Manifest{
+		{Layers: []ocispec.Descriptor{base, {Digest: digest.FromString("amd64"), Size: 10}}},
+		{Layers: []ocispec.Descriptor{base, {Digest: digest.FromString("arm64"), Size: 20}}},
+	}
+	// The layer shared by platforms is counted once.
+	require.Equal(t, int64(130), estimateSpace(manifests, true))
+	require.Equal(t, int64(260), estimateSpace(manifests, false))
+	require.Equal(t, int64(0), estimateSpace(nil, false))
+}
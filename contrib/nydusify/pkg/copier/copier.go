@@ -40,14 +40,24 @@ import (
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
 	nydusifyUtils "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
 )
 
 type Opt struct {
-	WorkDir        string
+	WorkDir string
+	// WorkDirQuota limits the usage of WorkDir in bytes, zero means
+	// unlimited.
+	WorkDirQuota int64
+	// KeepWorkDir is the policy to keep the temp directory in WorkDir
+	// after copy, which is removed by default.
+	KeepWorkDir    string
 	NydusImagePath string
 
 	Source string
 	Target string
+	// ExtraTargets are the additional target image references, the source
+	// image is pulled once and pushed to all the targets concurrently.
+	ExtraTargets []string
 
 	SourceInsecure bool
 	TargetInsecure bool
@@ -57,33 +67,184 @@ type Opt struct {
 
 	TargetBackendType   string
 	TargetBackendConfig string
+	// ExistenceCache caches the blobs known to exist in target backend, so
+	// that they aren't checked in backend again, nil means no cache.
+	ExistenceCache *backend.ExistenceCache
 
 	AllPlatforms bool
 	Platforms    string
 
+	// FilterPlatforms strips the manifests of unmatched platforms from image
+	// index, the index is kept even if only one platform is left.
+	FilterPlatforms string
+	// StripAttestations strips the attestation manifests from image index.
+	StripAttestations bool
+	// StripForeignLayers strips the manifests referring foreign layers (e.g.
+	// Windows base layers) from image index, which can't be mirrored.
+	StripForeignLayers bool
+	// PreserveDigest pushes the image index and manifests as is, so that the
+	// target image has the identical digest with the source image.
+	PreserveDigest bool
+
 	PushChunkSize int64
+
+	// MaxConcurrentBlobs is the count of blobs copied concurrently, zero
+	// means provider.LayerConcurrentLimit.
+	MaxConcurrentBlobs int
+	// The blobs larger than BlobChunkSize are read from source registry or
+	// backend by BlobConcurrency range requests concurrently.
+	BlobChunkSize   int64
+	BlobConcurrency int
 }
 
+const (
+	// The annotations of attestation manifest in image index built by BuildKit.
+	annotationReferenceType   = "vnd.docker.reference.type"
+	annotationReferenceDigest = "vnd.docker.reference.digest"
+	referenceTypeAttestation  = "attestation-manifest"
+)
+
 type output struct {
 	Blobs []string
 }
 
+// attestationMatcher matches the attestation manifests in addition to the
+// platforms, whose platform is "unknown/unknown" in image index.
+type attestationMatcher struct {
+	platforms.MatchComparer
+}
+
+func (m attestationMatcher) Match(platform ocispec.Platform) bool {
+	if platform.OS == "unknown" && platform.Architecture == "unknown" {
+		return true
+	}
+	return m.MatchComparer.Match(platform)
+}
+
+func isAttestation(desc ocispec.Descriptor) bool {
+	return desc.Annotations[annotationReferenceType] == referenceTypeAttestation
+}
+
+// blobConcurrency returns the count of blobs copied concurrently.
+func (opt Opt) blobConcurrency() int {
+	if opt.MaxConcurrentBlobs > 0 {
+		return opt.MaxConcurrentBlobs
+	}
+	return provider.LayerConcurrentLimit
+}
+
+// parallel checks if the blob in size is read by concurrent range requests.
+func (opt Opt) parallel(size int64) bool {
+	return opt.BlobConcurrency > 1 && opt.BlobChunkSize > 0 && size > opt.BlobChunkSize
+}
+
+// parallelReader reads the blob in backend by concurrent range requests.
+func parallelReader(ctx context.Context, rr remotes.RangeReadCloser, size int64, opt Opt) *nydusifyUtils.ParallelReaderAt {
+	return nydusifyUtils.NewParallelReaderAt(ctx, func(_ context.Context, offset, size int64) (io.ReadCloser, error) {
+		return rr.Reader(offset, size)
+	}, size, opt.BlobChunkSize, opt.BlobConcurrency)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// parallelRangeReader serves the range reads of chunked push from the
+// parallel reader.
+type parallelRangeReader struct {
+	*nydusifyUtils.ParallelReaderAt
+}
+
+func (r parallelRangeReader) Reader(offset, size int64) (io.ReadCloser, error) {
+	return io.NopCloser(io.NewSectionReader(r.ParallelReaderAt, offset, size)), nil
+}
+
+// validate checks the conflicts of options.
+func (opt Opt) validate() error {
+	if !opt.PreserveDigest {
+		return nil
+	}
+	if opt.SourceBackendType != "" || opt.TargetBackendType != "" {
+		return fmt.Errorf("preserve digest can't be used with source or target backend, which modifies the image manifests")
+	}
+	if opt.FilterPlatforms != "" || opt.StripAttestations || opt.StripForeignLayers {
+		return fmt.Errorf("preserve digest can't be used with the options modifying the image index")
+	}
+	if !opt.AllPlatforms {
+		return fmt.Errorf("preserve digest requires copying all platforms of the image index")
+	}
+	return nil
+}
+
+// filterManifests strips the manifests from image index by the options, the
+// attestation manifests are stripped with the manifests referred by them.
+func filterManifests(ctx context.Context, store content.Store, descs []ocispec.Descriptor, opt Opt) ([]ocispec.Descriptor, error) {
+	kept := map[digest.Digest]bool{}
+	for _, desc := range descs {
+		if isAttestation(desc) {
+			continue
+		}
+		if opt.StripForeignLayers {
+			manifest := ocispec.Manifest{}
+			if _, err := utils.ReadJSON(ctx, store, &manifest, desc); err != nil {
+				return nil, errors.Wrap(err, "read manifest")
+			}
+			foreign := false
+			for _, layer := range manifest.Layers {
+				if images.IsNonDistributable(layer.MediaType) {
+					foreign = true
+					break
+				}
+			}
+			if foreign {
+				logrus.WithField("platform", getPlatform(desc.Platform)).Infof("strip manifest %s with foreign layers", desc.Digest)
+				continue
+			}
+		}
+		kept[desc.Digest] = true
+	}
+
+	var filtered []ocispec.Descriptor
+	for _, desc := range descs {
+		if isAttestation(desc) {
+			if opt.StripAttestations || !kept[digest.Digest(desc.Annotations[annotationReferenceDigest])] {
+				continue
+			}
+		} else if !kept[desc.Digest] {
+			continue
+		}
+		filtered = append(filtered, desc)
+	}
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("no manifest is left after filtering")
+	}
+	return filtered, nil
+}
+
+// targets returns all the target image references.
+func (opt Opt) targets() []string {
+	return append([]string{opt.Target}, opt.ExtraTargets...)
+}
+
 func hosts(opt Opt) remote.HostFunc {
 	maps := map[string]bool{
 		opt.Source: opt.SourceInsecure,
-		opt.Target: opt.TargetInsecure,
+	}
+	for _, target := range opt.targets() {
+		maps[target] = opt.TargetInsecure
 	}
 	return func(ref string) (remote.CredentialFunc, bool, error) {
 		return remote.NewDockerConfigCredFunc(), maps[ref], nil
 	}
 }
 
-func getPusherInChunked(ctx context.Context, pvd *provider.Provider, desc ocispec.Descriptor, opt Opt) (remotes.PusherInChunked, error) {
-	resolver, err := pvd.Resolver(opt.Target)
+func getPusherInChunked(ctx context.Context, pvd *provider.Provider, desc ocispec.Descriptor, target string) (remotes.PusherInChunked, error) {
+	resolver, err := pvd.Resolver(target)
 	if err != nil {
 		return nil, errors.Wrap(err, "get resolver")
 	}
-	ref := opt.Target
+	ref := target
 	if !strings.Contains(ref, "@") {
 		ref = ref + "@" + desc.Digest.String()
 	}
@@ -96,27 +257,16 @@ func getPusherInChunked(ctx context.Context, pvd *provider.Provider, desc ocispe
 	return pusherInChunked, nil
 }
 
-func pushBlobFromBackend(
-	ctx context.Context, pvd *provider.Provider, backend backend.Backend, src ocispec.Descriptor, opt Opt,
-) ([]ocispec.Descriptor, *ocispec.Descriptor, error) {
-	if src.MediaType != ocispec.MediaTypeImageManifest && src.MediaType != images.MediaTypeDockerSchema2Manifest {
-		return nil, nil, fmt.Errorf("unsupported media type %s", src.MediaType)
-	}
-	manifest := ocispec.Manifest{}
-	if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &manifest, src); err != nil {
-		return nil, nil, errors.Wrap(err, "read manifest from store")
-	}
-	bootstrapDesc := parser.FindNydusBootstrapDesc(&manifest)
-	if bootstrapDesc == nil {
-		return nil, nil, nil
-	}
-	ra, err := pvd.ContentStore().ReaderAt(ctx, *bootstrapDesc)
+// bootstrapBlobIDs returns the deduplicated blob IDs referenced by the
+// bootstrap of Nydus image.
+func bootstrapBlobIDs(ctx context.Context, pvd *provider.Provider, bootstrapDesc ocispec.Descriptor, opt Opt) ([]string, error) {
+	ra, err := pvd.ContentStore().ReaderAt(ctx, bootstrapDesc)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "prepare reading bootstrap")
+		return nil, errors.Wrap(err, "prepare reading bootstrap")
 	}
 	bootstrapPath := filepath.Join(opt.WorkDir, "bootstrap.tgz")
 	if err := nydusifyUtils.UnpackFile(io.NewSectionReader(ra, 0, ra.Size()), nydusifyUtils.BootstrapFileNameInLayer, bootstrapPath); err != nil {
-		return nil, nil, errors.Wrap(err, "unpack bootstrap layer")
+		return nil, errors.Wrap(err, "unpack bootstrap layer")
 	}
 	outputPath := filepath.Join(opt.WorkDir, "output.json")
 	builder := tool.NewBuilder(opt.NydusImagePath)
@@ -124,15 +274,15 @@ func pushBlobFromBackend(
 		BootstrapPath:   bootstrapPath,
 		DebugOutputPath: outputPath,
 	}); err != nil {
-		return nil, nil, errors.Wrap(err, "check bootstrap")
+		return nil, errors.Wrap(err, "check bootstrap")
 	}
 	var out output
 	bytes, err := os.ReadFile(outputPath)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "read output file")
+		return nil, errors.Wrap(err, "read output file")
 	}
 	if err := json.Unmarshal(bytes, &out); err != nil {
-		return nil, nil, errors.Wrap(err, "unmarshal output json")
+		return nil, errors.Wrap(err, "unmarshal output json")
 	}
 
 	// Deduplicate the blobs for avoiding uploading repeatedly.
@@ -145,8 +295,31 @@ func pushBlobFromBackend(
 		blobIDs = append(blobIDs, blobID)
 		blobIDMap[blobID] = true
 	}
+	return blobIDs, nil
+}
 
-	sem := semaphore.NewWeighted(int64(provider.LayerConcurrentLimit))
+// pushBlobFromBackend pushes the blobs of Nydus image in backend to all the
+// targets, and appends them to the layers of manifest.
+func pushBlobFromBackend(
+	ctx context.Context, pvd *provider.Provider, backend backend.Backend, src ocispec.Descriptor, opt Opt,
+) ([]ocispec.Descriptor, *ocispec.Descriptor, error) {
+	if src.MediaType != ocispec.MediaTypeImageManifest && src.MediaType != images.MediaTypeDockerSchema2Manifest {
+		return nil, nil, fmt.Errorf("unsupported media type %s", src.MediaType)
+	}
+	manifest := ocispec.Manifest{}
+	if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &manifest, src); err != nil {
+		return nil, nil, errors.Wrap(err, "read manifest from store")
+	}
+	bootstrapDesc := parser.FindNydusBootstrapDesc(&manifest)
+	if bootstrapDesc == nil {
+		return nil, nil, nil
+	}
+	blobIDs, err := bootstrapBlobIDs(ctx, pvd, *bootstrapDesc, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sem := semaphore.NewWeighted(int64(opt.blobConcurrency()))
 	eg, ctx := errgroup.WithContext(ctx)
 	blobDescs := make([]ocispec.Descriptor, len(blobIDs))
 	for idx := range blobIDs {
@@ -173,59 +346,73 @@ func pushBlobFromBackend(
 					},
 				}
 
-				if err := nydusifyUtils.RetryWithAttempts(func() error {
-					pusher, err := getPusherInChunked(ctx, pvd, blobDescs[idx], opt)
-					if err != nil {
-						if errdefs.NeedsRetryWithHTTP(err) {
-							pvd.UsePlainHTTP()
-							pusher, err = getPusherInChunked(ctx, pvd, blobDescs[idx], opt)
-						}
+				for _, target := range opt.targets() {
+					if err := nydusifyUtils.RetryWithAttempts(func() error {
+						pusher, err := getPusherInChunked(ctx, pvd, blobDescs[idx], target)
 						if err != nil {
-							return errors.Wrapf(err, "get push writer: %s", blobDigest)
-						}
-					}
-
-					push := func() error {
-						if blobSize > opt.PushChunkSize {
-							rr, err := backend.RangeReader(blobID)
-							if err != nil {
-								return errors.Wrapf(err, "get push reader: %s", blobDigest)
-							}
-							if err := pusher.PushInChunked(ctx, blobDescs[idx], rr); err != nil {
-								return errors.Wrapf(err, "push blob in chunked: %s", blobDigest)
+							if errdefs.NeedsRetryWithHTTP(err) {
+								pvd.UsePlainHTTP()
+								pusher, err = getPusherInChunked(ctx, pvd, blobDescs[idx], target)
 							}
-						} else {
-							rc, err := backend.Reader(blobID)
-							if err != nil {
-								return errors.Wrap(err, "get blob reader")
-							}
-							defer rc.Close()
-							writer, err := pusher.Push(ctx, blobDescs[idx])
 							if err != nil {
 								return errors.Wrapf(err, "get push writer: %s", blobDigest)
 							}
-							if writer != nil {
-								defer writer.Close()
-								if err := content.Copy(ctx, writer, rc, blobSize, blobDigest); err != nil {
-									return errors.Wrapf(err, "push blob: %s", blobDigest)
+						}
+
+						push := func() error {
+							if blobSize > opt.PushChunkSize {
+								rr, err := backend.RangeReader(blobID)
+								if err != nil {
+									return errors.Wrapf(err, "get push reader: %s", blobDigest)
+								}
+								if opt.parallel(blobSize) {
+									pr := parallelReader(ctx, rr, blobSize, opt)
+									defer pr.Close()
+									rr = parallelRangeReader{pr}
+								}
+								if err := pusher.PushInChunked(ctx, blobDescs[idx], rr); err != nil {
+									return errors.Wrapf(err, "push blob in chunked: %s", blobDigest)
+								}
+							} else {
+								var rc io.ReadCloser
+								if opt.parallel(blobSize) {
+									rr, err := backend.RangeReader(blobID)
+									if err != nil {
+										return errors.Wrap(err, "get blob range reader")
+									}
+									pr := parallelReader(ctx, rr, blobSize, opt)
+									rc = &readCloser{io.NewSectionReader(pr, 0, blobSize), pr}
+								} else if rc, err = backend.Reader(blobID); err != nil {
+									return errors.Wrap(err, "get blob reader")
+								}
+								defer rc.Close()
+								writer, err := pusher.Push(ctx, blobDescs[idx])
+								if err != nil {
+									return errors.Wrapf(err, "get push writer: %s", blobDigest)
+								}
+								if writer != nil {
+									defer writer.Close()
+									if err := content.Copy(ctx, writer, rc, blobSize, blobDigest); err != nil {
+										return errors.Wrapf(err, "push blob: %s", blobDigest)
+									}
 								}
 							}
+							return nil
 						}
-						return nil
-					}
 
-					if err := push(); err != nil {
-						if containerdErrdefs.IsAlreadyExists(err) {
-							logrus.WithField("digest", blobDigest).WithField("size", blobSizeStr).Infof("pushed blob from backend (exists)")
-							return nil
+						if err := push(); err != nil {
+							if containerdErrdefs.IsAlreadyExists(err) {
+								logrus.WithField("digest", blobDigest).WithField("size", blobSizeStr).WithField("target", target).Infof("pushed blob from backend (exists)")
+								return nil
+							}
+							return errors.Wrapf(err, "copy blob content: %s", blobDigest)
 						}
-						return errors.Wrapf(err, "copy blob content: %s", blobDigest)
-					}
-					logrus.WithField("digest", blobDigest).WithField("size", blobSizeStr).Infof("pushed blob from backend")
+						logrus.WithField("digest", blobDigest).WithField("size", blobSizeStr).WithField("target", target).Infof("pushed blob from backend")
 
-					return nil
-				}, 3); err != nil {
-					return errors.Wrapf(err, "push blob: %s", blobDigest)
+						return nil
+					}, 3); err != nil {
+						return errors.Wrapf(err, "push blob to %s: %s", target, blobDigest)
+					}
 				}
 
 				return nil
@@ -299,12 +486,53 @@ func getLocalPath(ref string) (isLocalPath bool, absPath string, err error) {
 	return true, absPath, nil
 }
 
+// pushToTargets pushes the manifest or index to all the targets concurrently,
+// the blobs are read from the content store shared by all the pushes.
+func pushToTargets(ctx context.Context, pvd *provider.Provider, desc ocispec.Descriptor, targets []string, kind, platform string) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, target := range targets {
+		eg.Go(func() error {
+			logger := logrus.WithField("target", target)
+			if platform != "" {
+				logger = logger.WithField("platform", platform)
+			}
+			logger.Infof("pushing %s %s", kind, desc.Digest)
+			if err := pvd.Push(ctx, desc, target); err != nil {
+				if errdefs.NeedsRetryWithHTTP(err) {
+					pvd.UsePlainHTTP()
+					if err := pvd.Push(ctx, desc, target); err != nil {
+						return errors.Wrapf(err, "try to push %s to %s", kind, target)
+					}
+				} else {
+					return errors.Wrapf(err, "push %s to %s", kind, target)
+				}
+			}
+			logger.Infof("pushed %s %s", kind, desc.Digest)
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
 // Copy copies an image from the source to the target.
-func Copy(ctx context.Context, opt Opt) error {
+func Copy(ctx context.Context, opt Opt) (retErr error) {
 	// Containerd image fetch requires a namespace context.
 	ctx = namespaces.WithNamespace(ctx, "nydusify")
 
-	platformMC, err := platformutil.ParsePlatforms(opt.AllPlatforms, opt.Platforms)
+	if err := opt.validate(); err != nil {
+		return err
+	}
+
+	var platformMC platforms.MatchComparer
+	var err error
+	if opt.FilterPlatforms != "" {
+		platformMC, err = platformutil.ParsePlatforms(false, opt.FilterPlatforms)
+		if err == nil && !opt.StripAttestations {
+			platformMC = attestationMatcher{platformMC}
+		}
+	} else {
+		platformMC, err = platformutil.ParsePlatforms(opt.AllPlatforms, opt.Platforms)
+	}
 	if err != nil {
 		return err
 	}
@@ -316,7 +544,21 @@ func Copy(ctx context.Context, opt Opt) error {
 			return errors.Wrapf(err, "new backend")
 		}
 	}
+	var targetBkd backend.Backend
+	if opt.TargetBackendType != "" {
+		if opt.TargetBackendType == "registry" {
+			return fmt.Errorf("registry is not supported as target backend, omit the target backend to push blobs to target image")
+		}
+		targetBkd, err = backend.NewBackend(opt.TargetBackendType, []byte(opt.TargetBackendConfig), nil)
+		if err != nil {
+			return errors.Wrapf(err, "new target backend")
+		}
+		if opt.ExistenceCache != nil {
+			targetBkd = backend.WithExistenceCache(targetBkd, opt.TargetBackendType, []byte(opt.TargetBackendConfig), opt.ExistenceCache)
+		}
+	}
 
+	workDirCreated := false
 	if _, err := os.Stat(opt.WorkDir); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
@@ -324,15 +566,35 @@ func Copy(ctx context.Context, opt Opt) error {
 			}
 			// We should only clean up when the work directory not exists
 			// before, otherwise it may delete user data by mistake.
-			defer os.RemoveAll(opt.WorkDir)
+			workDirCreated = true
 		} else {
 			return errors.Wrap(err, "stat work directory")
 		}
 	}
-	tmpDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-")
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
 	if err != nil {
 		return errors.Wrap(err, "create temp directory")
 	}
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		unlock()
+		if workdir.Keep(opt.KeepWorkDir, workdir.KeepNever, retErr) {
+			logrus.Infof("temp directory is kept in %s", tmpDir)
+			return
+		}
+		os.RemoveAll(tmpDir)
+		if workDirCreated {
+			os.RemoveAll(opt.WorkDir)
+		}
+	}()
+	ctx, stopWatch := nydusifyUtils.WatchQuota(ctx, opt.WorkDir, opt.WorkDirQuota)
+	defer stopWatch()
+	defer func() {
+		retErr = nydusifyUtils.QuotaError(ctx, retErr)
+	}()
 
 	// Use stream-based content store: avoids local ingestion of pulled layer data, reads remotely on demand
 	baseStore, err := accelcontent.NewContent(hosts(opt), filepath.Join(tmpDir, "content"), tmpDir, "0MB")
@@ -340,12 +602,19 @@ func Copy(ctx context.Context, opt Opt) error {
 		return err
 	}
 	streamStore := provider.NewStreamContent(baseStore, hosts(opt))
+	streamStore.SetParallelRead(opt.BlobChunkSize, opt.BlobConcurrency)
+	var store content.Store = streamStore
+	if len(opt.ExtraTargets) > 0 {
+		// Pull the layers into local content store for multiple targets,
+		// so that they are read from source once and shared by all pushes.
+		store = baseStore
+	}
 
-	pvd, err := provider.New(tmpDir, hosts(opt), 200, "v1", platformMC, opt.PushChunkSize, streamStore)
+	pvd, err := provider.New(tmpDir, hosts(opt), 200, "v1", platformMC, opt.PushChunkSize, store)
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tmpDir)
+	pvd.SetConcurrency(opt.MaxConcurrentBlobs)
 
 	isLocalSource, inputPath, err := getLocalPath(opt.Source)
 	if err != nil {
@@ -378,6 +647,12 @@ func Copy(ctx context.Context, opt Opt) error {
 		}
 		source = sourceNamed.String()
 
+		if len(opt.ExtraTargets) > 0 || targetBkd != nil {
+			if err := checkSpace(ctx, pvd, source, platformMC, opt); err != nil {
+				return err
+			}
+		}
+
 		logrus.Infof("pulling source image %s", source)
 		if err := pvd.Pull(ctx, source); err != nil {
 			if errdefs.NeedsRetryWithHTTP(err) {
@@ -402,6 +677,12 @@ func Copy(ctx context.Context, opt Opt) error {
 		return errors.Wrap(err, "parse target path")
 	}
 	if isLocalTarget {
+		if len(opt.ExtraTargets) > 0 {
+			return fmt.Errorf("local target %s can't be used with multiple targets", opt.Target)
+		}
+		if targetBkd != nil {
+			return fmt.Errorf("local target %s can't be used with target backend", opt.Target)
+		}
 		logrus.Infof("exporting source image to %s", outputPath)
 		f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
@@ -415,17 +696,35 @@ func Copy(ctx context.Context, opt Opt) error {
 		return nil
 	}
 
+	var targets []string
+	for _, ref := range opt.targets() {
+		if isLocal, _, _ := getLocalPath(ref); isLocal {
+			return fmt.Errorf("local target %s can't be used with multiple targets", ref)
+		}
+		targetNamed, err := reference.ParseDockerRef(ref)
+		if err != nil {
+			return errors.Wrap(err, "parse target reference")
+		}
+		targets = append(targets, targetNamed.String())
+	}
+
+	if opt.PreserveDigest {
+		// The whole image is pushed without re-marshaling index and manifests.
+		if err := pushToTargets(ctx, pvd, *sourceImage, targets, "target image", ""); err != nil {
+			return errors.Wrap(err, "push target image")
+		}
+		return nil
+	}
+
 	sourceDescs, err := utils.GetManifests(ctx, pvd.ContentStore(), *sourceImage, platformMC)
 	if err != nil {
 		return errors.Wrap(err, "get image manifests")
 	}
-	targetDescs := make([]ocispec.Descriptor, len(sourceDescs))
-
-	targetNamed, err := reference.ParseDockerRef(opt.Target)
+	sourceDescs, err = filterManifests(ctx, pvd.ContentStore(), sourceDescs, opt)
 	if err != nil {
-		return errors.Wrap(err, "parse target reference")
+		return errors.Wrap(err, "filter image manifests")
 	}
-	target := targetNamed.String()
+	targetDescs := make([]ocispec.Descriptor, len(sourceDescs))
 
 	sem := semaphore.NewWeighted(1)
 	eg := errgroup.Group{}
@@ -437,7 +736,17 @@ func Copy(ctx context.Context, opt Opt) error {
 
 				sourceDesc := sourceDescs[idx]
 				targetDesc := &sourceDesc
-				if bkd != nil {
+				if targetBkd != nil {
+					_targetDesc, err := relocateBlobs(ctx, pvd, bkd, targetBkd, sourceDesc, opt)
+					if err != nil {
+						return errors.Wrap(err, "relocate blobs to target backend")
+					}
+					if _targetDesc == nil {
+						logrus.WithField("platform", getPlatform(sourceDesc.Platform)).Warnf("%s is not a nydus image", source)
+					} else {
+						targetDesc = _targetDesc
+					}
+				} else if bkd != nil {
 					descs, _targetDesc, err := pushBlobFromBackend(ctx, pvd, bkd, sourceDesc, opt)
 					if err != nil {
 						return errors.Wrap(err, "get resolver")
@@ -452,20 +761,7 @@ func Copy(ctx context.Context, opt Opt) error {
 				}
 				targetDescs[idx] = *targetDesc
 
-				logrus.WithField("platform", getPlatform(sourceDesc.Platform)).Infof("pushing target manifest %s", targetDesc.Digest)
-				if err := pvd.Push(ctx, *targetDesc, target); err != nil {
-					if errdefs.NeedsRetryWithHTTP(err) {
-						pvd.UsePlainHTTP()
-						if err := pvd.Push(ctx, *targetDesc, target); err != nil {
-							return errors.Wrap(err, "try to push image manifest")
-						}
-					} else {
-						return errors.Wrap(err, "push target image manifest")
-					}
-				}
-				logrus.WithField("platform", getPlatform(sourceDesc.Platform)).Infof("pushed target manifest %s", targetDesc.Digest)
-
-				return nil
+				return pushToTargets(ctx, pvd, *targetDesc, targets, "target manifest", getPlatform(sourceDesc.Platform))
 			})
 		}(idx)
 	}
@@ -473,7 +769,7 @@ func Copy(ctx context.Context, opt Opt) error {
 		return errors.Wrap(err, "push image manifests")
 	}
 
-	if len(targetDescs) > 1 && (sourceImage.MediaType == ocispec.MediaTypeImageIndex ||
+	if (len(targetDescs) > 1 || opt.FilterPlatforms != "") && (sourceImage.MediaType == ocispec.MediaTypeImageIndex ||
 		sourceImage.MediaType == images.MediaTypeDockerSchema2ManifestList) {
 		targetIndex := ocispec.Index{}
 		if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &targetIndex, *sourceImage); err != nil {
@@ -481,21 +777,13 @@ func Copy(ctx context.Context, opt Opt) error {
 		}
 		targetIndex.Manifests = targetDescs
 
-		targetImage, err := utils.WriteJSON(ctx, pvd.ContentStore(), targetIndex, *sourceImage, target, nil)
+		targetImage, err := utils.WriteJSON(ctx, pvd.ContentStore(), targetIndex, *sourceImage, targets[0], nil)
 		if err != nil {
 			return errors.Wrap(err, "write target manifest list")
 		}
-		if err := pvd.Push(ctx, *targetImage, target); err != nil {
-			if errdefs.NeedsRetryWithHTTP(err) {
-				pvd.UsePlainHTTP()
-				if err := pvd.Push(ctx, *targetImage, target); err != nil {
-					return errors.Wrap(err, "try to push image")
-				}
-			} else {
-				return errors.Wrap(err, "push target image")
-			}
+		if err := pushToTargets(ctx, pvd, *targetImage, targets, "target image", ""); err != nil {
+			return errors.Wrap(err, "push target image")
 		}
-		logrus.Infof("pushed image %s", target)
 	}
 
 	return nil
@@ -0,0 +1,172 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Opt
+		err  string
+	}{
+		{
+			name: "digest not preserved",
+			opt:  Opt{SourceBackendType: "oss", FilterPlatforms: "linux/amd64", StripAttestations: true},
+		},
+		{
+			name: "preserve digest",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true},
+		},
+		{
+			name: "source backend",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true, SourceBackendType: "oss"},
+			err:  "can't be used with source or target backend",
+		},
+		{
+			name: "target backend",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true, TargetBackendType: "s3"},
+			err:  "can't be used with source or target backend",
+		},
+		{
+			name: "filter platforms",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true, FilterPlatforms: "linux/amd64"},
+			err:  "can't be used with the options modifying the image index",
+		},
+		{
+			name: "strip attestations",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true, StripAttestations: true},
+			err:  "can't be used with the options modifying the image index",
+		},
+		{
+			name: "strip foreign layers",
+			opt:  Opt{PreserveDigest: true, AllPlatforms: true, StripForeignLayers: true},
+			err:  "can't be used with the options modifying the image index",
+		},
+		{
+			name: "some platforms",
+			opt:  Opt{PreserveDigest: true, Platforms: "linux/amd64"},
+			err:  "requires copying all platforms",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opt.validate()
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
+
+func writeManifest(t *testing.T, store content.Store, manifest ocispec.Manifest, platform ocispec.Platform, annotations map[string]string) ocispec.Descriptor {
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	desc := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Digest:      digest.FromBytes(data),
+		Size:        int64(len(data)),
+		Platform:    &platform,
+		Annotations: annotations,
+	}
+	require.NoError(t, content.WriteBlob(context.Background(), store, desc.Digest.String(), bytes.NewReader(data), desc))
+	return desc
+}
+
+func TestFilterManifests(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	layer := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayerGzip, Digest: digest.FromString("layer"), Size: 5}
+	foreign := ocispec.Descriptor{MediaType: images.MediaTypeDockerSchema2LayerForeignGzip, Digest: digest.FromString("foreign"), Size: 7}
+	linux := writeManifest(t, store, ocispec.Manifest{Layers: []ocispec.Descriptor{layer}}, ocispec.Platform{OS: "linux", Architecture: "amd64"}, nil)
+	windows := writeManifest(t, store, ocispec.Manifest{Layers: []ocispec.Descriptor{foreign, layer}}, ocispec.Platform{OS: "windows", Architecture: "amd64"}, nil)
+	attestation := func(subject ocispec.Descriptor) ocispec.Descriptor {
+		return writeManifest(t, store, ocispec.Manifest{Layers: []ocispec.Descriptor{layer}}, ocispec.Platform{OS: "unknown", Architecture: "unknown"}, map[string]string{
+			annotationReferenceType:   referenceTypeAttestation,
+			annotationReferenceDigest: subject.Digest.String(),
+		})
+	}
+	linuxAttestation := attestation(linux)
+	windowsAttestation := attestation(windows)
+	descs := []ocispec.Descriptor{linux, windows, linuxAttestation, windowsAttestation}
+
+	tests := []struct {
+		name     string
+		descs    []ocispec.Descriptor
+		opt      Opt
+		expected []ocispec.Descriptor
+		err      string
+	}{
+		{
+			name:     "keep all",
+			descs:    descs,
+			expected: descs,
+		},
+		{
+			name:     "strip attestations",
+			descs:    descs,
+			opt:      Opt{StripAttestations: true},
+			expected: []ocispec.Descriptor{linux, windows},
+		},
+		{
+			name:     "strip foreign layers with their attestations",
+			descs:    descs,
+			opt:      Opt{StripForeignLayers: true},
+			expected: []ocispec.Descriptor{linux, linuxAttestation},
+		},
+		{
+			name:     "strip both",
+			descs:    descs,
+			opt:      Opt{StripAttestations: true, StripForeignLayers: true},
+			expected: []ocispec.Descriptor{linux},
+		},
+		{
+			name:     "attestation without subject",
+			descs:    []ocispec.Descriptor{linux, windowsAttestation},
+			expected: []ocispec.Descriptor{linux},
+		},
+		{
+			name:  "nothing left",
+			descs: []ocispec.Descriptor{windows, windowsAttestation},
+			opt:   Opt{StripForeignLayers: true},
+			err:   "no manifest is left after filtering",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered, err := filterManifests(ctx, store, test.descs, test.opt)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, filtered)
+		})
+	}
+}
+
+func TestAttestationMatcher(t *testing.T) {
+	matcher := attestationMatcher{platforms.Only(ocispec.Platform{OS: "linux", Architecture: "amd64"})}
+	require.True(t, matcher.Match(ocispec.Platform{OS: "linux", Architecture: "amd64"}))
+	require.True(t, matcher.Match(ocispec.Platform{OS: "unknown", Architecture: "unknown"}))
+	require.False(t, matcher.Match(ocispec.Platform{OS: "linux", Architecture: "arm64"}))
+}
@@ -0,0 +1,305 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/dustin/go-humanize"
+	"github.com/goharbor/acceleration-service/pkg/utils"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	nydusifyUtils "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// blobReader opens the blob in source backend, or the blob layer of source
+// image if source backend isn't specified.
+func blobReader(ctx context.Context, pvd *provider.Provider, sourceBackend backend.Backend, layers map[digest.Digest]ocispec.Descriptor, blobID string, opt Opt) (io.ReadCloser, int64, error) {
+	if sourceBackend != nil {
+		size, err := sourceBackend.Size(blobID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "get blob size")
+		}
+		if opt.parallel(size) {
+			rr, err := sourceBackend.RangeReader(blobID)
+			if err != nil {
+				return nil, 0, errors.Wrap(err, "get blob range reader")
+			}
+			pr := parallelReader(ctx, rr, size, opt)
+			return &readCloser{io.NewSectionReader(pr, 0, size), pr}, size, nil
+		}
+		rc, err := sourceBackend.Reader(blobID)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "get blob reader")
+		}
+		return rc, size, nil
+	}
+
+	desc, ok := layers[digest.NewDigestFromEncoded(digest.SHA256, blobID)]
+	if !ok {
+		return nil, 0, fmt.Errorf("blob %s isn't found in the layers of source image", blobID)
+	}
+	ra, err := pvd.ContentStore().ReaderAt(ctx, desc)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "get blob layer reader")
+	}
+	return &readCloser{io.NewSectionReader(ra, 0, desc.Size), ra}, desc.Size, nil
+}
+
+// fetchAttempts is the count of attempts to read a blob mismatching its
+// digest or size.
+const fetchAttempts = 3
+
+// fetchBlobOnce reads the blob into a temporary file in work directory, and
+// verifies the blob by its size and digest, as the blob ID is the sha256
+// digest of blob. The file is returned to be removed even on error.
+func fetchBlobOnce(ctx context.Context, pvd *provider.Provider, sourceBackend backend.Backend, layers map[digest.Digest]ocispec.Descriptor, blobID string, opt Opt) (string, int64, error) {
+	rc, size, err := blobReader(ctx, pvd, sourceBackend, layers, blobID, opt)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "open blob")
+	}
+	defer rc.Close()
+
+	file, err := os.CreateTemp(opt.WorkDir, "blob-")
+	if err != nil {
+		return "", 0, errors.Wrap(err, "create temporary blob file")
+	}
+	defer file.Close()
+	digester := digest.SHA256.Digester()
+	n, err := io.Copy(io.MultiWriter(file, digester.Hash()), rc)
+	if err != nil {
+		return file.Name(), 0, errors.Wrap(err, "read blob")
+	}
+	if n != size {
+		return file.Name(), 0, errors.Wrapf(backend.ErrBlobMismatch, "read %d bytes, expected %d bytes", n, size)
+	}
+	if actual := digester.Digest(); actual.Encoded() != blobID {
+		return file.Name(), 0, errors.Wrapf(backend.ErrBlobMismatch, "read digest %s, expected sha256:%s", actual, blobID)
+	}
+	return file.Name(), size, nil
+}
+
+// fetchBlob reads the blob into a temporary file through which the blob is
+// uploaded to target backend, as required by the backends uploading in
+// multipart. The blob is read again if it mismatches, and the file is
+// removed by the caller after the backend is finalized.
+func fetchBlob(ctx context.Context, pvd *provider.Provider, sourceBackend backend.Backend, layers map[digest.Digest]ocispec.Descriptor, blobID string, opt Opt) (string, int64, error) {
+	for attempt := 1; ; attempt++ {
+		path, size, err := fetchBlobOnce(ctx, pvd, sourceBackend, layers, blobID, opt)
+		if err == nil {
+			return path, size, nil
+		}
+		if path != "" {
+			os.Remove(path)
+		}
+		if !errors.Is(err, backend.ErrBlobMismatch) || attempt >= fetchAttempts {
+			return "", 0, err
+		}
+		logrus.WithError(err).WithField("blob", blobID).Warnf("read blob again (attempt %d/%d)", attempt+1, fetchAttempts)
+	}
+}
+
+// uploadAttempts is the count of attempts to upload a blob mismatching its
+// size in target backend.
+const uploadAttempts = 3
+
+// verifyUploaded checks the size of blobs uploaded to target backend, and
+// returns the indexes of the blobs mismatching. The content is verified by
+// the backends supporting checksums while uploading, like the CRC64 of OSS
+// and the CRC32 of S3.
+func verifyUploaded(targetBackend backend.Backend, blobIDs []string, sizes []int64) ([]int, error) {
+	var mismatched []int
+	var mismatchErr error
+	for idx, blobID := range blobIDs {
+		if sizes[idx] == 0 {
+			continue
+		}
+		size, err := targetBackend.Size(blobID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get size of blob %s in target backend", blobID)
+		}
+		if size != sizes[idx] {
+			mismatched = append(mismatched, idx)
+			if mismatchErr == nil {
+				mismatchErr = errors.Wrapf(backend.ErrBlobMismatch, "blob %s has %d bytes in target backend, expected %d bytes", blobID, size, sizes[idx])
+			}
+		}
+	}
+	return mismatched, mismatchErr
+}
+
+// finalizeUploaded finalizes the uploading of blobs to target backend and
+// verifies the blobs uploaded. The blobs mismatching are uploaded again from
+// the temporary files.
+func finalizeUploaded(ctx context.Context, targetBackend backend.Backend, blobIDs, blobPaths []string, sizes []int64) error {
+	for attempt := 1; ; attempt++ {
+		if err := targetBackend.Finalize(false); err != nil {
+			return errors.Wrap(err, "finalize uploading blobs to target backend")
+		}
+		mismatched, err := verifyUploaded(targetBackend, blobIDs, sizes)
+		if err == nil {
+			return nil
+		}
+		if len(mismatched) == 0 || attempt >= uploadAttempts {
+			return errors.Wrap(err, "verify blobs in target backend")
+		}
+		logrus.WithError(err).Warnf("upload %d blobs again (attempt %d/%d)", len(mismatched), attempt+1, uploadAttempts)
+		for _, idx := range mismatched {
+			if _, err := targetBackend.Upload(ctx, blobIDs[idx], blobPaths[idx], sizes[idx], true); err != nil {
+				if err := targetBackend.Finalize(true); err != nil {
+					logrus.WithError(err).Warn("cancel uploading blobs to target backend")
+				}
+				return errors.Wrapf(err, "upload blob %s", blobIDs[idx])
+			}
+		}
+	}
+}
+
+// uploadBlob uploads the blob to target backend unless it exists there. The
+// size of blob uploaded is returned, zero if the blob exists, along with the
+// temporary file of blob to be removed after the backend is finalized.
+func uploadBlob(
+	ctx context.Context, pvd *provider.Provider, sourceBackend, targetBackend backend.Backend, layers map[digest.Digest]ocispec.Descriptor, blobID string, opt Opt,
+) (string, int64, error) {
+	exist, err := targetBackend.Check(blobID)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "check blob %s in target backend", blobID)
+	}
+	if exist {
+		logrus.WithField("blob", blobID).Infof("skip relocating blob (exists)")
+		return "", 0, nil
+	}
+
+	logrus.WithField("blob", blobID).Infof("relocating blob")
+	path, size, err := fetchBlob(ctx, pvd, sourceBackend, layers, blobID, opt)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "read blob %s", blobID)
+	}
+	if _, err := targetBackend.Upload(ctx, blobID, path, size, false); err != nil {
+		return path, 0, errors.Wrapf(err, "upload blob %s", blobID)
+	}
+	logrus.WithField("blob", blobID).WithField("size", humanize.Bytes(uint64(size))).Infof("relocated blob")
+	return path, size, nil
+}
+
+// relocateBlobs copies the blobs of Nydus image from the source backend, or
+// the blob layers of image if source backend isn't specified, to the target
+// backend. The blob layers are removed from the manifest then, which will be
+// read from the target backend by nydusd. The blob IDs in the blob table of
+// bootstrap are the digests of blobs, so that the bootstrap is kept as is.
+func relocateBlobs(
+	ctx context.Context, pvd *provider.Provider, sourceBackend, targetBackend backend.Backend, src ocispec.Descriptor, opt Opt,
+) (*ocispec.Descriptor, error) {
+	if src.MediaType != ocispec.MediaTypeImageManifest && src.MediaType != images.MediaTypeDockerSchema2Manifest {
+		return nil, fmt.Errorf("unsupported media type %s", src.MediaType)
+	}
+	manifest := ocispec.Manifest{}
+	if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &manifest, src); err != nil {
+		return nil, errors.Wrap(err, "read manifest from store")
+	}
+	bootstrapDesc := parser.FindNydusBootstrapDesc(&manifest)
+	if bootstrapDesc == nil {
+		return nil, nil
+	}
+	blobIDs, err := bootstrapBlobIDs(ctx, pvd, *bootstrapDesc, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := map[digest.Digest]ocispec.Descriptor{}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == nydusifyUtils.MediaTypeNydusBlob {
+			layers[layer.Digest] = layer
+		}
+	}
+
+	blobPaths := make([]string, len(blobIDs))
+	// sizes are the sizes of blobs uploaded, zero if the blob exists.
+	sizes := make([]int64, len(blobIDs))
+	defer func() {
+		for _, path := range blobPaths {
+			if path != "" {
+				os.Remove(path)
+			}
+		}
+	}()
+
+	sem := semaphore.NewWeighted(int64(opt.blobConcurrency()))
+	eg, egCtx := errgroup.WithContext(ctx)
+	for idx := range blobIDs {
+		eg.Go(func() error {
+			if err := sem.Acquire(egCtx, 1); err != nil {
+				return err
+			}
+			defer sem.Release(1)
+
+			path, size, err := uploadBlob(egCtx, pvd, sourceBackend, targetBackend, layers, blobIDs[idx], opt)
+			blobPaths[idx] = path
+			if err != nil {
+				return err
+			}
+			sizes[idx] = size
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		if err := targetBackend.Finalize(true); err != nil {
+			logrus.WithError(err).Warn("cancel uploading blobs to target backend")
+		}
+		return nil, errors.Wrap(err, "relocate blobs")
+	}
+	if err := finalizeUploaded(ctx, targetBackend, blobIDs, blobPaths, sizes); err != nil {
+		return nil, err
+	}
+
+	if sourceBackend != nil {
+		// The blobs are not in the manifest of image in source backend.
+		return &src, nil
+	}
+
+	config := ocispec.Image{}
+	if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &config, manifest.Config); err != nil {
+		return nil, errors.Wrap(err, "read config json")
+	}
+	var kept []ocispec.Descriptor
+	var diffIDs []digest.Digest
+	for idx, layer := range manifest.Layers {
+		if layer.MediaType == nydusifyUtils.MediaTypeNydusBlob {
+			continue
+		}
+		kept = append(kept, layer)
+		if len(config.RootFS.DiffIDs) == len(manifest.Layers) {
+			diffIDs = append(diffIDs, config.RootFS.DiffIDs[idx])
+		}
+	}
+	if len(config.RootFS.DiffIDs) == len(manifest.Layers) {
+		config.RootFS.DiffIDs = diffIDs
+	}
+	manifest.Layers = kept
+
+	configDesc, err := utils.WriteJSON(ctx, pvd.ContentStore(), config, manifest.Config, opt.Target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "write config json")
+	}
+	manifest.Config = *configDesc
+
+	target, err := utils.WriteJSON(ctx, pvd.ContentStore(), &manifest, src, opt.Target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "write manifest json")
+	}
+	return target, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/platforms"
+	"github.com/goharbor/acceleration-service/pkg/utils"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	nydusifyUtils "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// flakyBackend returns the corrupted blob for the first reads.
+type flakyBackend struct {
+	backend.Backend
+	blob      []byte
+	corrupted int
+	reads     int
+}
+
+func (b *flakyBackend) Size(string) (int64, error) {
+	return int64(len(b.blob)), nil
+}
+
+func (b *flakyBackend) Reader(string) (io.ReadCloser, error) {
+	b.reads++
+	blob := bytes.Clone(b.blob)
+	if b.reads <= b.corrupted {
+		blob[0] ^= 0xff
+	}
+	return io.NopCloser(bytes.NewReader(blob)), nil
+}
+
+func TestFetchBlob(t *testing.T) {
+	blob := []byte("nydus blob")
+	blobID := digest.FromBytes(blob).Encoded()
+	opt := Opt{WorkDir: t.TempDir()}
+
+	// The blob is read again if it's corrupted.
+	bkd := &flakyBackend{blob: blob, corrupted: fetchAttempts - 1}
+	path, size, err := fetchBlob(context.Background(), nil, bkd, nil, blobID, opt)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blob)), size)
+	require.Equal(t, fetchAttempts, bkd.reads)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, blob, data)
+	require.NoError(t, os.Remove(path))
+
+	bkd = &flakyBackend{blob: blob, corrupted: fetchAttempts}
+	_, _, err = fetchBlob(context.Background(), nil, bkd, nil, blobID, opt)
+	require.ErrorIs(t, err, backend.ErrBlobMismatch)
+	require.ErrorContains(t, err, "expected sha256:"+blobID)
+	require.Equal(t, fetchAttempts, bkd.reads)
+
+	// The temporary files of corrupted blobs are removed.
+	entries, err := os.ReadDir(opt.WorkDir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	mismatched, err := verifyUploaded(&flakyBackend{blob: blob}, []string{blobID, "exists"}, []int64{size, 0})
+	require.NoError(t, err)
+	require.Empty(t, mismatched)
+	mismatched, err = verifyUploaded(&flakyBackend{blob: blob[1:]}, []string{"exists", blobID}, []int64{0, size})
+	require.ErrorIs(t, err, backend.ErrBlobMismatch)
+	require.Equal(t, []int{1}, mismatched)
+}
+
+// memoryBackend is the target backend keeping the blobs uploaded in memory.
+type memoryBackend struct {
+	backend.Backend
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	uploadErr error
+	// truncated is the count of the first uploads losing the last byte.
+	truncated int
+	uploads   int
+	finalized []bool
+}
+
+func (b *memoryBackend) Check(blobID string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.blobs[blobID]
+	return ok, nil
+}
+
+func (b *memoryBackend) Upload(_ context.Context, blobID, blobPath string, _ int64, _ bool) (*ocispec.Descriptor, error) {
+	if b.uploadErr != nil {
+		return nil, b.uploadErr
+	}
+	data, err := os.ReadFile(blobPath)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads++
+	if b.uploads <= b.truncated {
+		data = data[:len(data)-1]
+	}
+	b.blobs[blobID] = data
+	return nil, nil
+}
+
+func (b *memoryBackend) Size(blobID string) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.blobs[blobID]
+	if !ok {
+		return 0, fmt.Errorf("blob %s not found", blobID)
+	}
+	return int64(len(data)), nil
+}
+
+func (b *memoryBackend) Finalize(cancel bool) error {
+	b.finalized = append(b.finalized, cancel)
+	return nil
+}
+
+func TestUploadBlob(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("nydus blob")
+	blobID := digest.FromBytes(blob).Encoded()
+	opt := Opt{WorkDir: t.TempDir()}
+	source := &flakyBackend{blob: blob}
+
+	// The blob existing in target backend is skipped.
+	target := &memoryBackend{blobs: map[string][]byte{blobID: blob}}
+	path, size, err := uploadBlob(ctx, nil, source, target, nil, blobID, opt)
+	require.NoError(t, err)
+	require.Empty(t, path)
+	require.Zero(t, size)
+	require.Zero(t, source.reads)
+
+	target = &memoryBackend{blobs: map[string][]byte{}}
+	path, size, err = uploadBlob(ctx, nil, source, target, nil, blobID, opt)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(blob)), size)
+	require.Equal(t, blob, target.blobs[blobID])
+	require.NoError(t, os.Remove(path))
+
+	// The temporary file is returned to be removed on uploading error.
+	target = &memoryBackend{blobs: map[string][]byte{}, uploadErr: errors.New("upload failed")}
+	path, _, err = uploadBlob(ctx, nil, source, target, nil, blobID, opt)
+	require.ErrorContains(t, err, "upload failed")
+	require.FileExists(t, path)
+	require.NoError(t, os.Remove(path))
+}
+
+// writeBlob writes the data into content store as the layer in media type.
+func writeBlob(t *testing.T, store content.Store, mediaType string, data []byte, annotations map[string]string) ocispec.Descriptor {
+	desc := ocispec.Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest.FromBytes(data),
+		Size:        int64(len(data)),
+		Annotations: annotations,
+	}
+	require.NoError(t, content.WriteBlob(context.Background(), store, desc.Digest.String(), bytes.NewReader(data), desc))
+	return desc
+}
+
+// bootstrapLayer packs the bootstrap file into the gzip tarball of layer.
+func bootstrapLayer(t *testing.T) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	bootstrap := []byte("bootstrap")
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: nydusifyUtils.BootstrapFileNameInLayer, Mode: 0644, Size: int64(len(bootstrap))}))
+	_, err := tw.Write(bootstrap)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+// fakeBuilder writes the script checking bootstrap like nydus-image, which
+// outputs the blob IDs in blob table.
+func fakeBuilder(t *testing.T, blobIDs []string) string {
+	output, err := json.Marshal(output{Blobs: blobIDs})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "nydus-image")
+	script := fmt.Sprintf("#!/bin/sh\necho '%s' > \"$5\"\n", output)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestRelocateBlobs(t *testing.T) {
+	ctx := context.Background()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+	pvd, err := provider.New(t.TempDir(), nil, 0, "v1", platforms.All, 0, store)
+	require.NoError(t, err)
+
+	blob := []byte("nydus blob")
+	blobID := digest.FromBytes(blob).Encoded()
+	blobDesc := writeBlob(t, store, nydusifyUtils.MediaTypeNydusBlob, blob, nil)
+	bootstrapDesc := writeBlob(t, store, ocispec.MediaTypeImageLayerGzip, bootstrapLayer(t), map[string]string{
+		nydusifyUtils.LayerAnnotationNydusBootstrap: "true",
+	})
+	config := ocispec.Image{RootFS: ocispec.RootFS{Type: "layers", DiffIDs: []digest.Digest{blobDesc.Digest, digest.FromString("bootstrap")}}}
+	configData, err := json.Marshal(config)
+	require.NoError(t, err)
+	configDesc := writeBlob(t, store, ocispec.MediaTypeImageConfig, configData, nil)
+	src := writeManifest(t, store, ocispec.Manifest{
+		Config: configDesc,
+		Layers: []ocispec.Descriptor{blobDesc, bootstrapDesc},
+	}, ocispec.Platform{OS: "linux", Architecture: "amd64"}, nil)
+	src.Platform = nil
+
+	// The blob appearing repeatedly in blob table is uploaded once.
+	opt := Opt{WorkDir: t.TempDir(), NydusImagePath: fakeBuilder(t, []string{blobID, blobID})}
+	target := &memoryBackend{blobs: map[string][]byte{}}
+	desc, err := relocateBlobs(ctx, pvd, nil, target, src, opt)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{blobID: blob}, target.blobs)
+	require.Equal(t, []bool{false}, target.finalized)
+
+	// The blob layers are removed from the manifest and config.
+	var manifest ocispec.Manifest
+	_, err = utils.ReadJSON(ctx, store, &manifest, *desc)
+	require.NoError(t, err)
+	require.Equal(t, []ocispec.Descriptor{bootstrapDesc}, manifest.Layers)
+	_, err = utils.ReadJSON(ctx, store, &config, manifest.Config)
+	require.NoError(t, err)
+	require.Equal(t, []digest.Digest{digest.FromString("bootstrap")}, config.RootFS.DiffIDs)
+
+	// The manifest is kept as is if the blobs are read from source backend.
+	source := &flakyBackend{blob: blob}
+	target = &memoryBackend{blobs: map[string][]byte{}}
+	desc, err = relocateBlobs(ctx, pvd, source, target, src, opt)
+	require.NoError(t, err)
+	require.Equal(t, src, *desc)
+	require.Equal(t, map[string][]byte{blobID: blob}, target.blobs)
+
+	// The blob mismatching its size in target backend is uploaded again.
+	target = &memoryBackend{blobs: map[string][]byte{}, truncated: uploadAttempts - 1}
+	_, err = relocateBlobs(ctx, pvd, source, target, src, opt)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{blobID: blob}, target.blobs)
+	require.Equal(t, uploadAttempts, target.uploads)
+	require.Equal(t, []bool{false, false, false}, target.finalized)
+
+	target = &memoryBackend{blobs: map[string][]byte{}, truncated: uploadAttempts}
+	_, err = relocateBlobs(ctx, pvd, source, target, src, opt)
+	require.ErrorIs(t, err, backend.ErrBlobMismatch)
+	require.Equal(t, uploadAttempts, target.uploads)
+
+	// No blob is uploaded once the context is canceled, and the uploading is
+	// canceled in target backend.
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	target = &memoryBackend{blobs: map[string][]byte{}}
+	_, err = relocateBlobs(canceled, pvd, nil, target, src, opt)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, target.blobs)
+	require.Equal(t, []bool{true}, target.finalized)
+
+	// The temporary files of blobs are removed.
+	entries, err := os.ReadDir(opt.WorkDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NotContains(t, entry.Name(), "blob-")
+	}
+}
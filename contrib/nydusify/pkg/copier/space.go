@@ -0,0 +1,53 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"context"
+
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	nydusifyUtils "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// checkSpace fails fast if the work directory lacks the space estimated to
+// copy the source image. The layers are only stored in work directory if
+// they are pulled once for multiple targets, or relocated to target backend
+// through temporary files, otherwise they are streamed from source.
+func checkSpace(ctx context.Context, pvd *provider.Provider, source string, platformMC platforms.MatchComparer, opt Opt) error {
+	_, manifests, err := pvd.RemoteManifests(ctx, source, platformMC)
+	if err != nil {
+		// The estimation is best effort, the error of source image is
+		// reported by pulling.
+		logrus.WithError(err).Warn("failed to estimate the space required by copy")
+		return nil
+	}
+	var layers int64
+	seen := map[digest.Digest]bool{}
+	for _, manifest := range manifests {
+		for _, layer := range manifest.Layers {
+			if !seen[layer.Digest] {
+				seen[layer.Digest] = true
+				layers += layer.Size
+			}
+		}
+	}
+
+	var required int64
+	if len(opt.ExtraTargets) > 0 {
+		required += layers
+	}
+	if opt.TargetBackendType != "" {
+		required += layers
+	}
+	if err := nydusifyUtils.CheckSpace(opt.WorkDir, required, opt.WorkDirQuota); err != nil {
+		return errors.Wrap(err, "insufficient space to copy image")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	containerdErrdefs "github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/goharbor/acceleration-service/pkg/errdefs"
+	"github.com/goharbor/acceleration-service/pkg/remote"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
+)
+
+// SyncOpt defines the options to sync the tags of source repository to the
+// target repositories, the Source, Target and ExtraTargets of Opt are
+// repositories without tag.
+type SyncOpt struct {
+	Opt
+	// IncludeTags and ExcludeTags are the regular expressions to filter the
+	// tags, the tag is synced if it matches IncludeTags but not ExcludeTags.
+	IncludeTags string
+	ExcludeTags string
+}
+
+// filterTags returns the sorted tags matched by the include and exclude
+// expressions, the empty expression is ignored.
+func filterTags(tags []string, include, exclude string) ([]string, error) {
+	var includeRe, excludeRe *regexp.Regexp
+	var err error
+	if include != "" {
+		if includeRe, err = regexp.Compile(include); err != nil {
+			return nil, errors.Wrap(err, "invalid include tags expression")
+		}
+	}
+	if exclude != "" {
+		if excludeRe, err = regexp.Compile(exclude); err != nil {
+			return nil, errors.Wrap(err, "invalid exclude tags expression")
+		}
+	}
+
+	filtered := []string{}
+	for _, tag := range tags {
+		if includeRe != nil && !includeRe.MatchString(tag) {
+			continue
+		}
+		if excludeRe != nil && excludeRe.MatchString(tag) {
+			continue
+		}
+		filtered = append(filtered, tag)
+	}
+	sort.Strings(filtered)
+	return filtered, nil
+}
+
+// repoHosts returns the registry hosts of the repositories in references.
+func repoHosts(opt Opt) remote.HostFunc {
+	maps := map[string]bool{}
+	if named, err := reference.ParseNormalizedNamed(opt.Source); err == nil {
+		maps[named.Name()] = opt.SourceInsecure
+	}
+	for _, target := range opt.targets() {
+		if named, err := reference.ParseNormalizedNamed(target); err == nil {
+			maps[named.Name()] = opt.TargetInsecure
+		}
+	}
+	return func(ref string) (remote.CredentialFunc, bool, error) {
+		insecure := false
+		if named, err := reference.ParseNormalizedNamed(ref); err == nil {
+			insecure = maps[named.Name()]
+		}
+		return remote.NewDockerConfigCredFunc(), insecure, nil
+	}
+}
+
+// resolve returns the digest of image reference, or empty if it doesn't
+// exist.
+func resolve(ctx context.Context, pvd *provider.Provider, ref string) (digest.Digest, error) {
+	resolver, err := pvd.Resolver(ref)
+	if err != nil {
+		return "", err
+	}
+	_, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		if containerdErrdefs.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return desc.Digest, nil
+}
+
+// upToDate checks if the tag of all the target repositories has the same
+// digest with the source repository.
+func upToDate(ctx context.Context, pvd *provider.Provider, opt Opt, tag string) (bool, error) {
+	sourceDigest, err := resolve(ctx, pvd, opt.Source+":"+tag)
+	if err != nil {
+		return false, errors.Wrap(err, "resolve source image")
+	}
+	for _, target := range opt.targets() {
+		targetDigest, err := resolve(ctx, pvd, target+":"+tag)
+		if err != nil {
+			return false, errors.Wrap(err, "resolve target image")
+		}
+		if targetDigest != sourceDigest {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Sync copies the matched tags of source repository to the target
+// repositories, the tags with the same digest in source and targets are
+// skipped. The failure of a tag doesn't stop syncing the others.
+func Sync(ctx context.Context, opt SyncOpt) error {
+	var names []string
+	for _, repo := range append([]string{opt.Source}, opt.targets()...) {
+		named, err := reference.ParseNormalizedNamed(repo)
+		if err != nil {
+			return errors.Wrapf(err, "parse repository %s", repo)
+		}
+		if !reference.IsNameOnly(named) {
+			return fmt.Errorf("invalid repository %s with tag or digest", repo)
+		}
+		names = append(names, named.Name())
+	}
+	opt.Source, opt.Target, opt.ExtraTargets = names[0], names[1], names[2:]
+
+	if _, err := os.Stat(opt.WorkDir); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrap(err, "stat work directory")
+		}
+		if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
+			return errors.Wrap(err, "prepare work directory")
+		}
+		defer os.RemoveAll(opt.WorkDir)
+	}
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix+"sync-")
+	if err != nil {
+		return errors.Wrap(err, "create temp directory")
+	}
+	defer os.RemoveAll(tmpDir)
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pvd, err := provider.New(tmpDir, repoHosts(opt.Opt), 200, "v1", platforms.All, opt.PushChunkSize, nil)
+	if err != nil {
+		return err
+	}
+
+	tags, err := pvd.Tags(ctx, opt.Source)
+	if err != nil && errdefs.NeedsRetryWithHTTP(err) {
+		pvd.UsePlainHTTP()
+		tags, err = pvd.Tags(ctx, opt.Source)
+	}
+	if err != nil {
+		return errors.Wrap(err, "list tags of source repository")
+	}
+	if tags, err = filterTags(tags, opt.IncludeTags, opt.ExcludeTags); err != nil {
+		return err
+	}
+	logrus.Infof("syncing %d tags of %s", len(tags), opt.Source)
+
+	var failed []string
+	for _, tag := range tags {
+		logger := logrus.WithField("tag", tag)
+		if ok, err := upToDate(ctx, pvd, opt.Opt, tag); err != nil {
+			logger.WithError(err).Warn("failed to check image digest")
+		} else if ok {
+			logger.Info("skip up-to-date image")
+			continue
+		}
+
+		copyOpt := opt.Opt
+		copyOpt.Source = opt.Source + ":" + tag
+		copyOpt.Target = opt.Target + ":" + tag
+		copyOpt.ExtraTargets = nil
+		for _, target := range opt.ExtraTargets {
+			copyOpt.ExtraTargets = append(copyOpt.ExtraTargets, target+":"+tag)
+		}
+		if err := Copy(ctx, copyOpt); err != nil {
+			logger.WithError(err).Error("failed to sync image")
+			failed = append(failed, tag)
+			continue
+		}
+		logger.Info("synced image")
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to sync %d of %d tags: %v", len(failed), len(tags), failed)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package copier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterTags(t *testing.T) {
+	tags := []string{"latest", "v1.1", "v1.0", "v1.1-nydus", "v2.0-rc1"}
+
+	filtered, err := filterTags(tags, "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"latest", "v1.0", "v1.1", "v1.1-nydus", "v2.0-rc1"}, filtered)
+
+	filtered, err = filterTags(tags, `^v\d+\.\d+`, `-(nydus|rc\d+)$`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1.0", "v1.1"}, filtered)
+
+	filtered, err = filterTags(tags, "^v3", "")
+	require.NoError(t, err)
+	require.Empty(t, filtered)
+
+	_, err = filterTags(tags, "(", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,297 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package doctor checks the environment of nydusify, including the nydus
+// binaries, the kernel support to mount Nydus images and the connectivity
+// of registries, and suggests how to fix the problems found.
+package doctor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+const releaseURL = "https://github.com/dragonflyoss/nydus/releases"
+
+// The kernel interfaces checked, which are overridden by tests.
+var (
+	fuseDevice      = "/dev/fuse"
+	procFilesystems = "/proc/filesystems"
+	cachefilesDev   = "/dev/cachefiles"
+)
+
+// feature is the feature of nydus binary required by some nydusify
+// commands, which is supported if the help of subcommand has keyword.
+type feature struct {
+	name       string
+	subcommand []string
+	keyword    string
+	usedBy     string
+}
+
+var builderFeatures = []feature{
+	{name: "batch chunks", subcommand: []string{"create"}, keyword: "--batch-size", usedBy: "convert --batch-size"},
+	{name: "chunk dict generation", keyword: "chunkdict", usedBy: "chunkdict generate"},
+	{name: "blob compaction", keyword: "compact", usedBy: "compact"},
+	{name: "prefetch optimization", keyword: "optimize", usedBy: "optimize"},
+}
+
+var nydusdFeatures = []feature{
+	{name: "NBD device", keyword: "nbd", usedBy: "mount --nbd-device"},
+}
+
+// Opt defines the options of environment checks.
+type Opt struct {
+	NydusImagePath string
+	NydusdPath     string
+	// Registries are the registry hosts like "docker.io" or
+	// "localhost:5000" to check the connectivity.
+	Registries []string
+	Transport  remote.TransportOption
+	// Timeout is the timeout to access each registry, default to 10s.
+	Timeout time.Duration
+}
+
+// Check is the result of a check.
+type Check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Suggestion is the action to fix the failed or warned check.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Report is the result of environment checks.
+type Report struct {
+	Checks []Check `json:"checks"`
+}
+
+func (report *Report) add(name, status, message, suggestion string) {
+	report.Checks = append(report.Checks, Check{Name: name, Status: status, Message: message, Suggestion: suggestion})
+}
+
+// Failed reports whether any of the checks failed.
+func (report *Report) Failed() bool {
+	for _, check := range report.Checks {
+		if check.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+// Run checks the environment.
+func Run(ctx context.Context, opt Opt) *Report {
+	if opt.Timeout == 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	report := &Report{}
+	checkBinary(ctx, report, "nydus-image", opt.NydusImagePath, builderFeatures)
+	checkBinary(ctx, report, "nydusd", opt.NydusdPath, nydusdFeatures)
+	checkFUSE(report)
+	checkEROFS(report)
+	for _, host := range opt.Registries {
+		checkRegistry(ctx, report, host, opt)
+	}
+	return report
+}
+
+func run(ctx context.Context, path string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "run %s %s: %s", path, strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// parseVersion returns the version in the output of `--version` like
+// "Version: v2.3.0", or the first line if not found.
+func parseVersion(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	first := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first == "" {
+			first = line
+		}
+		if version, ok := strings.CutPrefix(line, "Version:"); ok {
+			return strings.TrimSpace(version)
+		}
+	}
+	return first
+}
+
+func checkBinary(ctx context.Context, report *Report, name, path string, features []feature) {
+	if path == "" {
+		path = name
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		report.add(name, StatusFail, fmt.Sprintf("%s is not found", path),
+			fmt.Sprintf("install %s from %s, or specify its path by --%s", name, releaseURL, name))
+		return
+	}
+	output, err := run(ctx, resolved, "--version")
+	if err != nil {
+		report.add(name, StatusFail, err.Error(),
+			fmt.Sprintf("reinstall %s from %s", name, releaseURL))
+		return
+	}
+	report.add(name, StatusOK, fmt.Sprintf("%s %s", resolved, parseVersion(output)), "")
+
+	helps := map[string]string{}
+	for _, feature := range features {
+		key := strings.Join(feature.subcommand, " ")
+		help, ok := helps[key]
+		if !ok {
+			help, err = run(ctx, resolved, append(append([]string{}, feature.subcommand...), "--help")...)
+			if err != nil {
+				help = ""
+			}
+			helps[key] = help
+		}
+		checkName := fmt.Sprintf("%s: %s", name, feature.name)
+		if strings.Contains(help, feature.keyword) {
+			report.add(checkName, StatusOK, "supported", "")
+		} else {
+			report.add(checkName, StatusWarn, fmt.Sprintf("not supported, required by nydusify %s", feature.usedBy),
+				fmt.Sprintf("upgrade %s to the latest release from %s", name, releaseURL))
+		}
+	}
+}
+
+func checkFUSE(report *Report) {
+	if _, err := os.Stat(fuseDevice); err != nil {
+		report.add("FUSE", StatusWarn, fmt.Sprintf("%s is not available, nydusify check and mount can't mount images by FUSE", fuseDevice),
+			"run `modprobe fuse`, or run the container with `--device /dev/fuse --cap-add SYS_ADMIN`")
+		return
+	}
+	report.add("FUSE", StatusOK, fmt.Sprintf("%s is available", fuseDevice), "")
+}
+
+func checkEROFS(report *Report) {
+	data, err := os.ReadFile(procFilesystems)
+	if err != nil {
+		report.add("EROFS", StatusWarn, fmt.Sprintf("read %s: %s", procFilesystems, err), "")
+		return
+	}
+	erofs := false
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[len(fields)-1] == "erofs" {
+			erofs = true
+		}
+	}
+	if !erofs {
+		report.add("EROFS", StatusWarn, "kernel EROFS isn't loaded, RAFS v6 images can't be mounted by kernel",
+			"run `modprobe erofs`, or upgrade to Linux 5.16+ with CONFIG_EROFS_FS enabled")
+		return
+	}
+	report.add("EROFS", StatusOK, "kernel EROFS is supported", "")
+
+	if _, err := os.Stat(cachefilesDev); err != nil {
+		report.add("EROFS over fscache", StatusWarn, fmt.Sprintf("%s is not available, images can't be mounted in fscache mode", cachefilesDev),
+			"run `modprobe cachefiles`, or upgrade to Linux 5.19+ with CONFIG_CACHEFILES_ONDEMAND enabled")
+		return
+	}
+	report.add("EROFS over fscache", StatusOK, fmt.Sprintf("%s is available", cachefilesDev), "")
+}
+
+// checkRegistry pings the registry API of host, the registry is reachable
+// if it responds, even if it requires authentication.
+func checkRegistry(ctx context.Context, report *Report, host string, opt Opt) {
+	name := "registry " + host
+	client, err := opt.Transport.NewClient()
+	if err != nil {
+		report.add(name, StatusFail, err.Error(), "fix the CA certificate, client certificate or proxy of registry")
+		return
+	}
+	client.Timeout = opt.Timeout
+
+	ping := func(scheme string) (int, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/v2/", scheme, host), nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	scheme := "https"
+	status, err := ping(scheme)
+	if err != nil && opt.Transport.Insecure {
+		scheme = "http"
+		status, err = ping(scheme)
+	}
+	if err != nil {
+		suggestion := "check the network, DNS and proxy settings"
+		if strings.Contains(err.Error(), "certificate") {
+			suggestion = "specify the CA certificate of registry, or skip the verification for insecure registry"
+		} else if strings.Contains(err.Error(), "HTTP response to HTTPS client") {
+			suggestion = "access the registry by plain HTTP for insecure registry"
+		}
+		report.add(name, StatusFail, err.Error(), suggestion)
+		return
+	}
+	switch status {
+	case http.StatusOK, http.StatusUnauthorized:
+		report.add(name, StatusOK, fmt.Sprintf("reachable by %s", scheme), "")
+	default:
+		report.add(name, StatusWarn, fmt.Sprintf("unexpected status %d of %s://%s/v2/", status, scheme, host),
+			"check if it's an OCI distribution registry")
+	}
+}
+
+// PrintReport writes the checks and the suggestions of report.
+func PrintReport(writer io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tMESSAGE")
+	for _, check := range report.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, check.Status, check.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	suggested := false
+	for _, check := range report.Checks {
+		if check.Suggestion == "" {
+			continue
+		}
+		if !suggested {
+			fmt.Fprintln(writer, "\nSuggestions:")
+			suggested = true
+		}
+		fmt.Fprintf(writer, "  - %s: %s\n", check.Name, check.Suggestion)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+)
+
+func writeScript(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func findCheck(t *testing.T, report *Report, name string) Check {
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("check %s not found", name)
+	return Check{}
+}
+
+func TestParseVersion(t *testing.T) {
+	require.Equal(t, "v2.3.0", parseVersion("\nVersion: \tv2.3.0\nGit Commit: \tabc\n"))
+	require.Equal(t, "nydusd 2.3.0", parseVersion("nydusd 2.3.0\n"))
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	builder := writeScript(t, dir, "nydus-image", `
+case "$1" in
+--version) echo "Version: 	v2.3.0" ;;
+create) echo "--batch-size <batch-size>" ;;
+*) echo "Commands: create, chunkdict, optimize" ;;
+esac
+`)
+	nydusd := writeScript(t, dir, "nydusd", "exit 1\n")
+
+	fuseDevice = filepath.Join(dir, "fuse")
+	procFilesystems = filepath.Join(dir, "filesystems")
+	cachefilesDev = filepath.Join(dir, "cachefiles")
+	require.NoError(t, os.WriteFile(procFilesystems, []byte("nodev\tproc\n\terofs\n"), 0644))
+	require.NoError(t, os.WriteFile(cachefilesDev, nil, 0644))
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v2/", r.URL.Path)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer registry.Close()
+	host := strings.TrimPrefix(registry.URL, "http://")
+
+	report := Run(context.Background(), Opt{
+		NydusImagePath: builder,
+		NydusdPath:     nydusd,
+		Registries:     []string{host},
+		Transport:      remote.TransportOption{Insecure: true},
+	})
+
+	require.Equal(t, StatusOK, findCheck(t, report, "nydus-image").Status)
+	require.Contains(t, findCheck(t, report, "nydus-image").Message, "v2.3.0")
+	require.Equal(t, StatusOK, findCheck(t, report, "nydus-image: batch chunks").Status)
+	require.Equal(t, StatusOK, findCheck(t, report, "nydus-image: chunk dict generation").Status)
+	compact := findCheck(t, report, "nydus-image: blob compaction")
+	require.Equal(t, StatusWarn, compact.Status)
+	require.Contains(t, compact.Message, "required by nydusify compact")
+	require.Equal(t, StatusFail, findCheck(t, report, "nydusd").Status)
+	require.Equal(t, StatusWarn, findCheck(t, report, "FUSE").Status)
+	require.Equal(t, StatusOK, findCheck(t, report, "EROFS").Status)
+	require.Equal(t, StatusOK, findCheck(t, report, "EROFS over fscache").Status)
+	require.Equal(t, StatusOK, findCheck(t, report, "registry "+host).Status)
+	require.True(t, report.Failed())
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintReport(&buf, report))
+	require.Contains(t, buf.String(), "Suggestions:")
+	require.Contains(t, buf.String(), "FUSE: run `modprobe fuse`")
+
+	// The registry isn't reachable by HTTPS if it's not insecure.
+	report = &Report{}
+	checkRegistry(context.Background(), report, host, Opt{})
+	require.Equal(t, StatusFail, report.Checks[0].Status)
+	require.Contains(t, report.Checks[0].Suggestion, "plain HTTP")
+
+	report = &Report{}
+	checkBinary(context.Background(), report, "nydus-image", filepath.Join(dir, "not-exist"), builderFeatures)
+	require.Equal(t, StatusFail, report.Checks[0].Status)
+	require.Contains(t, report.Checks[0].Suggestion, "--nydus-image")
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package gc collects the blobs in object storage backend which are not
+// referenced by any of the given Nydus images.
+package gc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// Opt defines the options of blob garbage collection.
+type Opt struct {
+	WorkDir string
+	// Images are the Nydus image references whose blobs are kept, all the
+	// platforms of image index are included.
+	Images   []string
+	Insecure bool
+	Backend  backend.Pruner
+	// GracePeriod protects the blobs modified recently from deletion, which
+	// may be uploaded by an ongoing conversion before the image is pushed.
+	GracePeriod time.Duration
+	DryRun      bool
+}
+
+// Blob is the orphaned blob in backend.
+type Blob struct {
+	ID           string    `json:"id"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	// Deleted is false if the blob is in grace period or dry-run mode.
+	Deleted bool `json:"deleted"`
+}
+
+// Report is the result of blob garbage collection.
+type Report struct {
+	Images     int    `json:"images"`
+	Referenced int    `json:"referenced"`
+	Total      int    `json:"total"`
+	Orphans    []Blob `json:"orphans"`
+	Deleted    int    `json:"deleted"`
+	FreedSize  int64  `json:"freed_size"`
+}
+
+func pull(ctx context.Context, remote *remote.Remote, desc ocispec.Descriptor, res interface{}) error {
+	reader, err := remote.Pull(ctx, desc, true)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(res)
+}
+
+// manifests returns all the manifests of image reference.
+func manifests(ctx context.Context, remote *remote.Remote) ([]ocispec.Manifest, error) {
+	desc, err := remote.Resolve(ctx)
+	if err != nil && utils.RetryWithHTTP(err) {
+		remote.MaybeWithHTTP(err)
+		desc, err = remote.Resolve(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve image")
+	}
+
+	descs := []ocispec.Descriptor{*desc}
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, images.MediaTypeDockerSchema2ManifestList:
+		var index ocispec.Index
+		if err := pull(ctx, remote, *desc, &index); err != nil {
+			return nil, errors.Wrap(err, "pull image index")
+		}
+		descs = index.Manifests
+	}
+
+	var manifests []ocispec.Manifest
+	for _, desc := range descs {
+		var manifest ocispec.Manifest
+		if err := pull(ctx, remote, desc, &manifest); err != nil {
+			return nil, errors.Wrapf(err, "pull image manifest %s", desc.Digest)
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// bootstrapBlobs returns the blob IDs in the blob table of bootstrap.
+func bootstrapBlobs(ctx context.Context, remote *remote.Remote, bootstrapDesc ocispec.Descriptor, workDir string) ([]string, error) {
+	reader, err := remote.Pull(ctx, bootstrapDesc, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "pull Nydus bootstrap layer")
+	}
+	defer reader.Close()
+
+	bootstrapPath := filepath.Join(workDir, bootstrapDesc.Digest.Encoded())
+	defer os.Remove(bootstrapPath)
+	if err := utils.UnpackFile(reader, utils.BootstrapFileNameInLayer, bootstrapPath); err != nil {
+		return nil, errors.Wrap(err, "unpack Nydus bootstrap layer")
+	}
+	bootstrap, err := rafs.Load(bootstrapPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse Nydus bootstrap")
+	}
+
+	var blobs []string
+	for _, blob := range bootstrap.Blobs {
+		blobs = append(blobs, blob.ID)
+	}
+	return blobs, nil
+}
+
+// referencedBlobs returns the blob IDs referenced by the Nydus manifests of
+// image reference, including the blob layers, the blobs annotated in
+// bootstrap layer and the blob table of bootstrap.
+func referencedBlobs(ctx context.Context, opt Opt, ref, workDir string) ([]string, error) {
+	remote, err := provider.DefaultRemote(ref, opt.Insecure)
+	if err != nil {
+		return nil, errors.Wrap(err, "create image provider")
+	}
+	manifests, err := manifests(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []string
+	found := false
+	for idx := range manifests {
+		bootstrapDesc := parser.FindNydusBootstrapDesc(&manifests[idx])
+		if bootstrapDesc == nil {
+			continue
+		}
+		found = true
+		for _, layer := range manifests[idx].Layers {
+			if layer.MediaType == utils.MediaTypeNydusBlob {
+				blobs = append(blobs, layer.Digest.Encoded())
+			}
+		}
+		if annotation := bootstrapDesc.Annotations[utils.LayerAnnotationNydusReferenceBlobIDs]; annotation != "" {
+			var ids []string
+			if err := json.Unmarshal([]byte(annotation), &ids); err != nil {
+				return nil, errors.Wrap(err, "unmarshal referenced blob ids of bootstrap layer")
+			}
+			blobs = append(blobs, ids...)
+		}
+		ids, err := bootstrapBlobs(ctx, remote, *bootstrapDesc, workDir)
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, ids...)
+	}
+	if !found {
+		return nil, errors.New("no Nydus manifest found")
+	}
+
+	return blobs, nil
+}
+
+// GC deletes the blobs in backend which are not referenced by the images
+// and not modified in grace period, the deletion is skipped in dry-run mode.
+func GC(ctx context.Context, opt Opt) (*Report, error) {
+	if len(opt.Images) == 0 {
+		return nil, errors.New("at least one image is required to collect garbage blobs")
+	}
+	if err := os.MkdirAll(opt.WorkDir, 0750); err != nil {
+		return nil, errors.Wrap(err, "create working directory")
+	}
+	workDir, err := os.MkdirTemp(opt.WorkDir, "gc-")
+	if err != nil {
+		return nil, errors.Wrap(err, "create temporary directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	// Any failure of collecting the referenced blobs aborts the GC, so
+	// that the blobs in use are never deleted.
+	referenced := map[string]bool{}
+	for _, ref := range opt.Images {
+		logrus.Infof("Collecting blobs referenced by %s", ref)
+		blobs, err := referencedBlobs(ctx, opt, ref, workDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "collect blobs of image %s", ref)
+		}
+		for _, blob := range blobs {
+			referenced[blob] = true
+		}
+	}
+
+	objects, err := opt.Backend.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "list blobs in backend")
+	}
+
+	return collect(ctx, opt, referenced, objects, time.Now())
+}
+
+func collect(ctx context.Context, opt Opt, referenced map[string]bool, objects []backend.BlobObject, now time.Time) (*Report, error) {
+	report := &Report{
+		Images:     len(opt.Images),
+		Referenced: len(referenced),
+		Total:      len(objects),
+		Orphans:    []Blob{},
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ID < objects[j].ID
+	})
+	for _, object := range objects {
+		if referenced[object.ID] {
+			continue
+		}
+		blob := Blob{
+			ID:           object.ID,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		}
+		if now.Sub(object.LastModified) >= opt.GracePeriod && !opt.DryRun {
+			logrus.Infof("Deleting blob %s", object.ID)
+			if err := opt.Backend.Delete(ctx, object.ID); err != nil {
+				return nil, errors.Wrapf(err, "delete blob %s", object.ID)
+			}
+			blob.Deleted = true
+			report.Deleted++
+			report.FreedSize += object.Size
+		}
+		report.Orphans = append(report.Orphans, blob)
+	}
+	return report, nil
+}
+
+// PrintReport writes the orphaned blobs and the summary of report.
+func PrintReport(writer io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BLOB ID\tSIZE\tLAST MODIFIED\tDELETED")
+	for _, blob := range report.Orphans {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%t\n", blob.ID, blob.Size, blob.LastModified.Format(time.RFC3339), blob.Deleted)
+	}
+	fmt.Fprintf(
+		tw, "\n%d blobs referenced by %d images, %d of %d blobs in backend are orphaned, %d deleted (%d bytes freed)\n",
+		report.Referenced, report.Images, len(report.Orphans), report.Total, report.Deleted, report.FreedSize,
+	)
+	return tw.Flush()
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package gc
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+)
+
+type fakePruner struct {
+	deleted []string
+}
+
+func (pruner *fakePruner) List(_ context.Context) ([]backend.BlobObject, error) {
+	return nil, nil
+}
+
+func (pruner *fakePruner) Delete(_ context.Context, blobID string) error {
+	pruner.deleted = append(pruner.deleted, blobID)
+	return nil
+}
+
+func TestCollect(t *testing.T) {
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	blobID := func(c string) string {
+		return strings.Repeat(c, 64)
+	}
+	objects := []backend.BlobObject{
+		{ID: blobID("c"), Size: 30, LastModified: now.Add(-time.Hour)},
+		{ID: blobID("a"), Size: 10, LastModified: now.Add(-48 * time.Hour)},
+		{ID: blobID("b"), Size: 20, LastModified: now.Add(-48 * time.Hour)},
+	}
+	referenced := map[string]bool{blobID("a"): true}
+
+	pruner := &fakePruner{}
+	opt := Opt{Images: []string{"foo:nydus"}, Backend: pruner, GracePeriod: 24 * time.Hour}
+	report, err := collect(context.Background(), opt, referenced, objects, now)
+	require.NoError(t, err)
+	require.Equal(t, []string{blobID("b")}, pruner.deleted)
+	require.Equal(t, 3, report.Total)
+	require.Equal(t, 1, report.Referenced)
+	require.Equal(t, 1, report.Deleted)
+	require.Equal(t, int64(20), report.FreedSize)
+	require.Len(t, report.Orphans, 2)
+	require.True(t, report.Orphans[0].Deleted)
+	require.False(t, report.Orphans[1].Deleted)
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintReport(&buf, report))
+	require.Contains(t, buf.String(), "1 blobs referenced by 1 images, 2 of 3 blobs in backend are orphaned, 1 deleted (20 bytes freed)")
+
+	pruner = &fakePruner{}
+	opt.Backend = pruner
+	opt.DryRun = true
+	report, err = collect(context.Background(), opt, referenced, objects, now)
+	require.NoError(t, err)
+	require.Empty(t, pruner.deleted)
+	require.Zero(t, report.Deleted)
+	require.Len(t, report.Orphans, 2)
+}
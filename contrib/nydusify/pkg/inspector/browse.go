@@ -0,0 +1,131 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"text/tabwriter"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// List returns the file of path, or the files in it if it's a directory,
+// the symlinks in path are followed like `ls`.
+func (image *Image) List(filePath string) ([]rafs.File, error) {
+	file, err := image.Bootstrap.Resolve(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !file.IsDir() {
+		// Show the symlink itself rather than its target.
+		if link, err := image.Bootstrap.Lookup(filePath); err == nil {
+			file = link
+		}
+		return []rafs.File{*file}, nil
+	}
+	return image.Bootstrap.Children(file), nil
+}
+
+// blobDesc returns the descriptor of blob layer in image manifest.
+func (image *Image) blobDesc(blob rafs.Blob) ocispec.Descriptor {
+	dgst := digest.NewDigestFromEncoded(digest.SHA256, blob.ID)
+	for _, layer := range image.layers {
+		if layer.Digest == dgst {
+			return layer
+		}
+	}
+	return ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    dgst,
+		Size:      int64(blob.CompressedSize),
+	}
+}
+
+// Cat writes the content of regular file to writer, only the chunks of the
+// file are fetched from registry by range requests.
+func (image *Image) Cat(ctx context.Context, filePath string, writer io.Writer) error {
+	file, err := image.Bootstrap.Resolve(filePath)
+	if err != nil {
+		return err
+	}
+	if file.IsDir() {
+		return fmt.Errorf("%s: is a directory", file.Path)
+	}
+	if file.FileMode().Type() != 0 {
+		return fmt.Errorf("%s: not a regular file", file.Path)
+	}
+	if uint64(len(file.ChunkInfos)) != file.Chunks {
+		return fmt.Errorf("%s: chunks are not found in chunk table of bootstrap", file.Path)
+	}
+
+	readers := map[uint32]io.ReadSeekCloser{}
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+
+	remaining := file.Size
+	for _, chunk := range file.ChunkInfos {
+		reader, ok := readers[chunk.BlobIndex]
+		if !ok {
+			blob := image.Bootstrap.Blobs[chunk.BlobIndex]
+			rc, err := image.remote.Pull(ctx, image.blobDesc(blob), true)
+			if err != nil {
+				return errors.Wrapf(err, "pull blob %s", blob.ID)
+			}
+			if reader, ok = rc.(io.ReadSeekCloser); !ok {
+				rc.Close()
+				return fmt.Errorf("range request is not supported to read blob %s", blob.ID)
+			}
+			readers[chunk.BlobIndex] = reader
+		}
+
+		if _, err := reader.Seek(int64(chunk.CompressedOffset), io.SeekStart); err != nil {
+			return errors.Wrapf(err, "seek chunk %d", chunk.Index)
+		}
+		data := make([]byte, chunk.CompressedSize)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return errors.Wrapf(err, "read chunk %d", chunk.Index)
+		}
+		decoded, err := image.Bootstrap.DecodeChunk(chunk, data)
+		if err != nil {
+			return errors.Wrapf(err, "%s", file.Path)
+		}
+		if uint64(len(decoded)) > remaining {
+			decoded = decoded[:remaining]
+		}
+		if _, err := writer.Write(decoded); err != nil {
+			return err
+		}
+		remaining -= uint64(len(decoded))
+	}
+	if remaining != 0 {
+		return fmt.Errorf("%s: %d bytes are missing in chunks", file.Path, remaining)
+	}
+
+	return nil
+}
+
+// PrintList writes the files in the form of `ls -l`.
+func PrintList(writer io.Writer, files []rafs.File) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 1, ' ', 0)
+	for _, file := range files {
+		name := path.Base(file.Path)
+		if file.Target != "" {
+			name = fmt.Sprintf("%s -> %s", name, file.Target)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", file.FileMode(), file.Nlink, file.UID, file.GID, file.Size, name)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,186 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// ChunkStat is the statistics of chunks shared with the base image.
+type ChunkStat struct {
+	Chunks       int     `json:"chunks"`
+	SharedChunks int     `json:"shared_chunks"`
+	Size         uint64  `json:"size"`
+	SharedSize   uint64  `json:"shared_size"`
+	SharedRatio  float64 `json:"shared_ratio"`
+}
+
+func (stat *ChunkStat) add(chunk rafs.Chunk, shared bool) {
+	stat.Chunks++
+	stat.Size += uint64(chunk.UncompressedSize)
+	if shared {
+		stat.SharedChunks++
+		stat.SharedSize += uint64(chunk.UncompressedSize)
+	}
+	if stat.Size > 0 {
+		stat.SharedRatio = float64(stat.SharedSize) / float64(stat.Size)
+	}
+}
+
+// LayerDiff is the commonality of the blob layer of new image with the base
+// image.
+type LayerDiff struct {
+	BlobID string `json:"blob_id"`
+	// Reused means the blob is also referred by base image.
+	Reused bool `json:"reused"`
+	ChunkStat
+}
+
+// DiffReport is the difference of new image from the base image.
+type DiffReport struct {
+	Base    string   `json:"base"`
+	Target  string   `json:"target"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+	// Chunks counts the deduplicated chunks referred by new image files,
+	// the chunks with the same digest in base image are shared.
+	Chunks ChunkStat   `json:"chunks"`
+	Layers []LayerDiff `json:"layers"`
+}
+
+// fileChanged checks if the file is changed in metadata or content, the
+// size of directory is ignored since it changes with the entries.
+func fileChanged(base, target *rafs.File) bool {
+	if base.Mode != target.Mode || base.UID != target.UID || base.GID != target.GID || base.Target != target.Target {
+		return true
+	}
+	if target.IsDir() {
+		return false
+	}
+	if base.Size != target.Size || len(base.ChunkInfos) != len(target.ChunkInfos) {
+		return true
+	}
+	for idx := range base.ChunkInfos {
+		if base.ChunkInfos[idx].Digest != target.ChunkInfos[idx].Digest {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares the files and chunks of target image with the base image.
+func Diff(base, target *Image) *DiffReport {
+	report := &DiffReport{
+		Base:    base.Reference,
+		Target:  target.Reference,
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+		Layers:  []LayerDiff{},
+	}
+
+	baseFiles := map[string]*rafs.File{}
+	baseChunks := map[[32]byte]bool{}
+	baseBlobs := map[string]bool{}
+	for idx := range base.Bootstrap.Files {
+		file := &base.Bootstrap.Files[idx]
+		baseFiles[file.Path] = file
+		for _, chunk := range file.ChunkInfos {
+			baseChunks[chunk.Digest] = true
+		}
+	}
+	for _, blob := range base.Bootstrap.Blobs {
+		baseBlobs[blob.ID] = true
+	}
+
+	for _, blob := range target.Bootstrap.Blobs {
+		report.Layers = append(report.Layers, LayerDiff{
+			BlobID: blob.ID,
+			Reused: baseBlobs[blob.ID],
+		})
+	}
+
+	visited := map[[32]byte]bool{}
+	for idx := range target.Bootstrap.Files {
+		file := &target.Bootstrap.Files[idx]
+		if baseFile, ok := baseFiles[file.Path]; !ok {
+			report.Added = append(report.Added, file.Path)
+		} else {
+			if fileChanged(baseFile, file) {
+				report.Changed = append(report.Changed, file.Path)
+			}
+			delete(baseFiles, file.Path)
+		}
+
+		for _, chunk := range file.ChunkInfos {
+			if visited[chunk.Digest] {
+				continue
+			}
+			visited[chunk.Digest] = true
+			shared := baseChunks[chunk.Digest]
+			report.Chunks.add(chunk, shared)
+			report.Layers[chunk.BlobIndex].add(chunk, shared)
+		}
+	}
+
+	// The files of base image are sorted by path.
+	for _, file := range base.Bootstrap.Files {
+		if _, ok := baseFiles[file.Path]; ok {
+			report.Removed = append(report.Removed, file.Path)
+		}
+	}
+
+	return report
+}
+
+// PrintDiff writes the diff report to writer in output format.
+func PrintDiff(writer io.Writer, output string, report *DiffReport) error {
+	switch output {
+	case OutputJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshal diff report")
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	case OutputTable, "":
+	default:
+		return fmt.Errorf("unsupported output format %s", output)
+	}
+
+	for _, path := range report.Added {
+		fmt.Fprintf(writer, "A %s\n", path)
+	}
+	for _, path := range report.Removed {
+		fmt.Fprintf(writer, "D %s\n", path)
+	}
+	for _, path := range report.Changed {
+		fmt.Fprintf(writer, "M %s\n", path)
+	}
+	fmt.Fprintf(writer, "\n%d added, %d removed, %d changed\n", len(report.Added), len(report.Removed), len(report.Changed))
+	fmt.Fprintf(
+		writer, "%d of %d chunks (%d of %d bytes, %.2f%%) are shared with %s\n\n",
+		report.Chunks.SharedChunks, report.Chunks.Chunks, report.Chunks.SharedSize, report.Chunks.Size,
+		report.Chunks.SharedRatio*100, report.Base,
+	)
+
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BLOB ID\tREUSED\tCHUNKS\tSHARED CHUNKS\tSIZE\tSHARED SIZE\tSHARED RATIO")
+	for _, layer := range report.Layers {
+		fmt.Fprintf(
+			tw, "%s\t%t\t%d\t%d\t%d\t%d\t%.2f%%\n", layer.BlobID, layer.Reused,
+			layer.Chunks, layer.SharedChunks, layer.Size, layer.SharedSize, layer.SharedRatio*100,
+		)
+	}
+	return tw.Flush()
+}
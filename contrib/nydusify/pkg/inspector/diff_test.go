@@ -0,0 +1,75 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/builder"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func buildImage(t *testing.T, reference string, files map[string]string) *Image {
+	rootfs := t.TempDir()
+	output := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(rootfs, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(rootfs, name), []byte(content), 0644))
+	}
+	bootstrapPath := filepath.Join(output, "image.boot")
+	_, err := builder.Build(builder.Option{
+		RootfsPath:    rootfs,
+		BootstrapPath: bootstrapPath,
+		BlobPath:      filepath.Join(output, "image.blob"),
+		ChunkSize:     0x1000,
+	})
+	require.NoError(t, err)
+	bootstrap, err := rafs.Load(bootstrapPath)
+	require.NoError(t, err)
+	return &Image{Reference: reference, Bootstrap: bootstrap}
+}
+
+func TestDiff(t *testing.T) {
+	shared := string(bytes.Repeat([]byte("a"), 0x1000))
+	base := buildImage(t, "foo:v1", map[string]string{
+		"bin/app":     shared + "v1",
+		"etc/config":  "config",
+		"etc/removed": "removed",
+	})
+	target := buildImage(t, "foo:v2", map[string]string{
+		"bin/app":    shared + "v2",
+		"etc/config": "config",
+		"etc/added":  "added",
+	})
+
+	report := Diff(base, target)
+	require.Equal(t, []string{"/etc/added"}, report.Added)
+	require.Equal(t, []string{"/etc/removed"}, report.Removed)
+	require.Equal(t, []string{"/bin/app"}, report.Changed)
+	require.Equal(t, 4, report.Chunks.Chunks)
+	require.Equal(t, 2, report.Chunks.SharedChunks)
+	require.Equal(t, uint64(0x1000+2+6+5), report.Chunks.Size)
+	require.Equal(t, uint64(0x1000+6), report.Chunks.SharedSize)
+	require.Len(t, report.Layers, 1)
+	require.False(t, report.Layers[0].Reused)
+	require.Equal(t, report.Chunks, report.Layers[0].ChunkStat)
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintDiff(&buf, OutputTable, report))
+	require.Contains(t, buf.String(), "A /etc/added\nD /etc/removed\nM /bin/app\n\n1 added, 1 removed, 1 changed\n")
+	require.Contains(t, buf.String(), "2 of 4 chunks (4102 of 4109 bytes, 99.83%) are shared with foo:v1\n")
+
+	report = Diff(base, base)
+	require.Empty(t, report.Added)
+	require.Empty(t, report.Removed)
+	require.Empty(t, report.Changed)
+	require.True(t, report.Layers[0].Reused)
+	require.Equal(t, 1.0, report.Chunks.SharedRatio)
+}
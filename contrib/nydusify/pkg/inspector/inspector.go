@@ -0,0 +1,205 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inspector prints the metadata and browses the files of Nydus
+// image by parsing the bootstrap, without mounting the image by nydusd.
+package inspector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// Opt defines Nydus image inspector options.
+type Opt struct {
+	WorkDir        string
+	Target         string
+	TargetInsecure bool
+	ExpectedArch   string
+}
+
+// Result is the metadata of Nydus image.
+type Result struct {
+	Reference  string      `json:"reference"`
+	Digest     string      `json:"digest"`
+	Version    string      `json:"version"`
+	BlockSize  uint32      `json:"block_size"`
+	ChunkSize  uint32      `json:"chunk_size"`
+	Compressor string      `json:"compressor"`
+	Digester   string      `json:"digester"`
+	Flags      uint64      `json:"flags"`
+	Features   []string    `json:"features"`
+	Inodes     uint64      `json:"inodes"`
+	Files      int         `json:"files"`
+	Blobs      []rafs.Blob `json:"blobs"`
+	Prefetch   []string    `json:"prefetch"`
+}
+
+// NewResult summarizes the bootstrap of image reference.
+func NewResult(reference, digest string, bootstrap *rafs.Bootstrap) *Result {
+	return &Result{
+		Reference:  reference,
+		Digest:     digest,
+		Version:    bootstrap.Version,
+		BlockSize:  bootstrap.BlockSize,
+		ChunkSize:  bootstrap.ChunkSize,
+		Compressor: bootstrap.Compressor,
+		Digester:   bootstrap.Digester,
+		Flags:      bootstrap.Flags,
+		Features:   bootstrap.Features,
+		Inodes:     bootstrap.Inodes,
+		Files:      len(bootstrap.Files),
+		Blobs:      bootstrap.Blobs,
+		Prefetch:   bootstrap.Prefetch,
+	}
+}
+
+// Image is the Nydus image opened by its bootstrap.
+type Image struct {
+	Reference string
+	Digest    string
+	Bootstrap *rafs.Bootstrap
+
+	remote *remote.Remote
+	layers []ocispec.Descriptor
+}
+
+// Open pulls the bootstrap of target image and parses its metadata.
+func Open(ctx context.Context, opt Opt) (*Image, error) {
+	targetRemote, err := provider.DefaultRemote(opt.Target, opt.TargetInsecure)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image provider")
+	}
+	targetParser, err := parser.New(targetRemote, opt.ExpectedArch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image reference parser")
+	}
+	parsed, err := targetParser.Parse(ctx)
+	if err != nil && utils.RetryWithHTTP(err) {
+		targetParser.Remote.MaybeWithHTTP(err)
+		parsed, err = targetParser.Parse(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse image reference")
+	}
+	if parsed.NydusImage == nil {
+		return nil, errors.New("not a Nydus image")
+	}
+
+	if err := os.MkdirAll(opt.WorkDir, 0750); err != nil {
+		return nil, errors.Wrap(err, "can't create working directory")
+	}
+	workDir, err := os.MkdirTemp(opt.WorkDir, "inspect-")
+	if err != nil {
+		return nil, errors.Wrap(err, "can't create temporary directory")
+	}
+	defer os.RemoveAll(workDir)
+
+	logrus.Infof("Pulling Nydus bootstrap of %s", opt.Target)
+	reader, err := targetParser.PullNydusBootstrap(ctx, parsed.NydusImage)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pull Nydus bootstrap layer")
+	}
+	defer reader.Close()
+	bootstrapPath := filepath.Join(workDir, "nydus_bootstrap")
+	if err := utils.UnpackFile(reader, utils.BootstrapFileNameInLayer, bootstrapPath); err != nil {
+		return nil, errors.Wrap(err, "failed to unpack Nydus bootstrap layer")
+	}
+
+	bootstrap, err := rafs.Load(bootstrapPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse Nydus bootstrap")
+	}
+
+	return &Image{
+		Reference: opt.Target,
+		Digest:    parsed.NydusImage.Desc.Digest.String(),
+		Bootstrap: bootstrap,
+		remote:    targetParser.Remote,
+		layers:    parsed.NydusImage.Manifest.Layers,
+	}, nil
+}
+
+// Inspect pulls the bootstrap of target image and summarizes its metadata.
+func Inspect(ctx context.Context, opt Opt) (*Result, error) {
+	image, err := Open(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return NewResult(image.Reference, image.Digest, image.Bootstrap), nil
+}
+
+// Print writes the result to writer in output format.
+func Print(writer io.Writer, output string, result *Result) error {
+	switch output {
+	case OutputJSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshal result")
+		}
+		_, err = fmt.Fprintln(writer, string(data))
+		return err
+	case OutputTable, "":
+		return printTable(writer, result)
+	default:
+		return fmt.Errorf("unsupported output format %s", output)
+	}
+}
+
+func printTable(writer io.Writer, result *Result) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Reference:\t%s\n", result.Reference)
+	fmt.Fprintf(tw, "Digest:\t%s\n", result.Digest)
+	fmt.Fprintf(tw, "RAFS Version:\t%s\n", result.Version)
+	fmt.Fprintf(tw, "Block Size:\t%d\n", result.BlockSize)
+	fmt.Fprintf(tw, "Chunk Size:\t0x%x\n", result.ChunkSize)
+	fmt.Fprintf(tw, "Compressor:\t%s\n", result.Compressor)
+	fmt.Fprintf(tw, "Digester:\t%s\n", result.Digester)
+	fmt.Fprintf(tw, "Features:\t%s\n", strings.Join(result.Features, ","))
+	fmt.Fprintf(tw, "Inodes:\t%d\n", result.Inodes)
+	fmt.Fprintf(tw, "Files:\t%d\n", result.Files)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "\nBlobs:\n")
+	tw = tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tBLOB ID\tCHUNKS\tCOMPRESSED SIZE\tUNCOMPRESSED SIZE")
+	for idx, blob := range result.Blobs {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\n", idx, blob.ID, blob.ChunkCount, blob.CompressedSize, blob.UncompressedSize)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "\nPrefetch Table:\n")
+	if len(result.Prefetch) == 0 {
+		fmt.Fprintln(writer, "  (empty)")
+	}
+	for _, path := range result.Prefetch {
+		fmt.Fprintf(writer, "  %s\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func testResult() *Result {
+	return NewResult("localhost:5000/foo:nydus", "sha256:aaaa", &rafs.Bootstrap{
+		Version:    "v6",
+		BlockSize:  4096,
+		ChunkSize:  0x100000,
+		Compressor: "zstd",
+		Digester:   "blake3",
+		Flags:      0x01000094,
+		Features:   []string{"HASH_BLAKE3", "EXPLICIT_UID_GID", "COMPRESSION_ZSTD", "ENCRYPTION_NONE"},
+		Inodes:     3,
+		Blobs: []rafs.Blob{
+			{ID: "blob1", ChunkCount: 2, CompressedSize: 100, UncompressedSize: 200},
+		},
+		Prefetch: []string{"/bin"},
+		Files:    []rafs.File{{Path: "/"}, {Path: "/bin"}, {Path: "/bin/sh"}},
+	})
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Print(&buf, OutputTable, testResult()))
+	require.Contains(t, buf.String(), "RAFS Version:  v6")
+	require.Contains(t, buf.String(), "Chunk Size:    0x100000")
+	require.Contains(t, buf.String(), "Features:      HASH_BLAKE3,EXPLICIT_UID_GID,COMPRESSION_ZSTD,ENCRYPTION_NONE")
+	require.Contains(t, buf.String(), "0      blob1    2       100              200")
+	require.Contains(t, buf.String(), "Prefetch Table:\n  /bin\n")
+
+	buf.Reset()
+	require.NoError(t, Print(&buf, OutputJSON, testResult()))
+	var result Result
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	require.Equal(t, *testResult(), result)
+	require.Equal(t, 3, result.Files)
+
+	require.EqualError(t, Print(&buf, "yaml", testResult()), "unsupported output format yaml")
+}
+
+func TestPrintList(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PrintList(&buf, []rafs.File{
+		{Path: "/bin", Mode: 0040755, Nlink: 2, Size: 4096},
+		{Path: "/sh", Mode: 0120777, Nlink: 1, Size: 7, Target: "bin/bash"},
+		{Path: "/etc/passwd", Mode: 0100644, Nlink: 1, UID: 1000, GID: 100, Size: 12345},
+	}))
+	require.Equal(t, ""+
+		"drwxr-xr-x 2 0    0   4096  bin\n"+
+		"Lrwxrwxrwx 1 0    0   7     sh -> bin/bash\n"+
+		"-rw-r--r-- 1 1000 100 12345 passwd\n", buf.String())
+}
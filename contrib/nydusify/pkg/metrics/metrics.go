@@ -21,6 +21,7 @@ const (
 	convertSuccessCountKey = "convert_success_count_key"
 	convertFailureCountKey = "convert_failure_count_key"
 	storeCacheDurationKey  = "store_cache_duration"
+	existenceCacheKey      = "backend_existence_cache_total"
 	namespace              = "nydusify"
 	subsystem              = "convert"
 )
@@ -65,6 +66,17 @@ var (
 		},
 		[]string{"source_reference"},
 	)
+
+	// The existence cache is used by copy and gc rather than convert, so the
+	// metric isn't in the subsystem of convert.
+	existenceCache = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      existenceCacheKey,
+			Help:      "The lookups of blob existence cache. Broken down by backend types and results (hit or miss).",
+		},
+		[]string{"backend", "result"},
+	)
 )
 
 var register sync.Once
@@ -79,7 +91,7 @@ func sinceInSeconds(start time.Time) float64 {
 func Register(exp Exporter) {
 	register.Do(func() {
 		Registry = prometheus.NewRegistry()
-		Registry.MustRegister(convertDuration, convertSuccessCount, convertFailureCount, storeCacheDuration)
+		Registry.MustRegister(convertDuration, convertSuccessCount, convertFailureCount, storeCacheDuration, existenceCache)
 		exporter = exp
 	})
 }
@@ -106,3 +118,11 @@ func ConversionFailureCount(ref string, reason string) {
 func StoreCacheDuration(ref string, start time.Time) {
 	storeCacheDuration.WithLabelValues(ref).Add(sinceInSeconds(start))
 }
+
+func BackendExistenceCache(backendType string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	existenceCache.WithLabelValues(backendType, result).Inc()
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	"github.com/pkg/errors"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ClientConfig is the config to access Kubernetes API server.
+type ClientConfig struct {
+	// Server is the URL of API server, like `http://127.0.0.1:8001` served
+	// by `kubectl proxy`, the in-cluster config of service account is used
+	// if empty.
+	Server    string
+	TokenFile string
+	CAFile    string
+}
+
+// Client is a minimal Kubernetes API client to manage NydusConversions and
+// their jobs.
+type Client struct {
+	server    string
+	tokenFile string
+	client    *http.Client
+}
+
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.Server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("not running in Kubernetes cluster, API server should be specified")
+		}
+		cfg.Server = "https://" + net.JoinHostPort(host, port)
+		if cfg.TokenFile == "" {
+			cfg.TokenFile = serviceAccountDir + "/token"
+		}
+		if cfg.CAFile == "" {
+			cfg.CAFile = serviceAccountDir + "/ca.crt"
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &Client{
+		server:    strings.TrimSuffix(cfg.Server, "/"),
+		tokenFile: cfg.TokenFile,
+		client:    &http.Client{Transport: transport},
+	}, nil
+}
+
+func conversionsPath(namespace string) string {
+	if namespace == "" {
+		return "/apis/" + Group + "/" + Version + "/" + Resource
+	}
+	return "/apis/" + Group + "/" + Version + "/namespaces/" + namespace + "/" + Resource
+}
+
+func jobsPath(namespace string) string {
+	return "/apis/batch/v1/namespaces/" + namespace + "/jobs"
+}
+
+// do sends the request with the JSON body, and decodes the JSON response
+// into result. It returns errdefs.ErrNotFound and errdefs.ErrConflict for
+// the 404 and 409 responses.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body, result interface{}) error {
+	resp, err := c.request(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		return nil
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(result), "decode response of %s", path)
+}
+
+func (c *Client) request(ctx context.Context, method, path, contentType string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal request")
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.tokenFile != "" {
+		// The token of service account is rotated, so read it every time.
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "read token file")
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", method, path)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	err = fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(message))
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, errors.Wrap(errdefs.ErrNotFound, err.Error())
+	case http.StatusConflict:
+		return nil, errors.Wrap(errdefs.ErrConflict, err.Error())
+	}
+	return nil, err
+}
+
+// ListConversions lists the NydusConversions in namespace, or in all
+// namespaces if namespace is empty.
+func (c *Client) ListConversions(ctx context.Context, namespace string) (*NydusConversionList, error) {
+	var list NydusConversionList
+	if err := c.do(ctx, http.MethodGet, conversionsPath(namespace), "", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// WatchConversions watches the changes of NydusConversions since the
+// resourceVersion, and calls handle for each changed one until the watch
+// is closed by server or the context is canceled.
+func (c *Client) WatchConversions(ctx context.Context, namespace, resourceVersion string, handle func(eventType string, conv *NydusConversion)) error {
+	query := url.Values{"watch": []string{"true"}, "resourceVersion": []string{resourceVersion}}
+	resp, err := c.request(ctx, http.MethodGet, conversionsPath(namespace)+"?"+query.Encode(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var event struct {
+			Type   string          `json:"type"`
+			Object json.RawMessage `json:"object"`
+		}
+		if err := decoder.Decode(&event); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "decode watch event")
+		}
+		if event.Type == "ERROR" {
+			// The resource version is too old, the caller should list again.
+			return fmt.Errorf("watch error: %s", event.Object)
+		}
+		var conv NydusConversion
+		if err := json.Unmarshal(event.Object, &conv); err != nil {
+			return errors.Wrap(err, "decode watched object")
+		}
+		handle(event.Type, &conv)
+	}
+}
+
+// UpdateConversionStatus updates the status subresource of NydusConversion,
+// and returns the updated one. It returns errdefs.ErrConflict if the
+// conversion is changed meanwhile.
+func (c *Client) UpdateConversionStatus(ctx context.Context, conv *NydusConversion) (*NydusConversion, error) {
+	path := conversionsPath(conv.Metadata.Namespace) + "/" + conv.Metadata.Name + "/status"
+	var updated NydusConversion
+	if err := c.do(ctx, http.MethodPut, path, "application/json", conv, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (c *Client) GetJob(ctx context.Context, namespace, name string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, jobsPath(namespace)+"/"+name, "", nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (c *Client) CreateJob(ctx context.Context, job *Job) error {
+	return c.do(ctx, http.MethodPost, jobsPath(job.Metadata.Namespace), "application/json", job, nil)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0600))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /apis/nydus.dragonflyoss.io/v1alpha1/namespaces/default/nydusconversions":
+			if r.URL.Query().Get("watch") == "true" {
+				if r.URL.Query().Get("resourceVersion") != "10" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				io.WriteString(w, `{"type":"ADDED","object":{"metadata":{"name":"busybox"}}}`+"\n")
+				io.WriteString(w, `{"type":"DELETED","object":{"metadata":{"name":"nginx"}}}`+"\n")
+				return
+			}
+			io.WriteString(w, `{"metadata":{"resourceVersion":"10"},"items":[{"metadata":{"name":"nginx","generation":1},"spec":{"source":"nginx"}}]}`)
+		case "PUT /apis/nydus.dragonflyoss.io/v1alpha1/namespaces/default/nydusconversions/nginx/status":
+			var conv NydusConversion
+			if err := json.NewDecoder(r.Body).Decode(&conv); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			conv.Metadata.ResourceVersion = "11"
+			json.NewEncoder(w).Encode(conv)
+		case "POST /apis/batch/v1/namespaces/default/jobs":
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Server: server.URL, TokenFile: tokenFile})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	list, err := client.ListConversions(ctx, "default")
+	require.NoError(t, err)
+	require.Equal(t, "10", list.Metadata.ResourceVersion)
+	require.Len(t, list.Items, 1)
+	require.Equal(t, "nginx", list.Items[0].Spec.Source)
+
+	var events []string
+	require.NoError(t, client.WatchConversions(ctx, "default", "10", func(eventType string, conv *NydusConversion) {
+		events = append(events, eventType+" "+conv.Metadata.Name)
+	}))
+	require.Equal(t, []string{"ADDED busybox", "DELETED nginx"}, events)
+
+	conv := list.Items[0]
+	conv.Metadata.Namespace = "default"
+	conv.Status.Phase = PhaseRunning
+	updated, err := client.UpdateConversionStatus(ctx, &conv)
+	require.NoError(t, err)
+	require.Equal(t, "11", updated.Metadata.ResourceVersion)
+	require.Equal(t, PhaseRunning, updated.Status.Phase)
+
+	_, err = client.GetJob(ctx, "default", "nginx-1")
+	require.True(t, errdefs.IsNotFound(err))
+	err = client.CreateJob(ctx, &Job{Metadata: ObjectMeta{Name: "nginx-1", Namespace: "default"}})
+	require.True(t, errdefs.IsConflict(err))
+}
@@ -0,0 +1,300 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package operator implements a Kubernetes controller, which watches the
+// NydusConversion custom resources and runs the conversions as jobs.
+package operator
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+type Opt struct {
+	// Namespace is the namespace to watch, all namespaces are watched if
+	// empty.
+	Namespace string
+	// Image is the default nydusify image running the conversions.
+	Image string
+	// JobServiceAccount is the service account of conversion jobs.
+	JobServiceAccount string
+	// BackoffLimit is the default number of retries of conversion jobs.
+	BackoffLimit int32
+	// Resync is the interval to list all the NydusConversions and check the
+	// status of their jobs.
+	Resync time.Duration
+	// Workers is the number of NydusConversions reconciled at the same time.
+	Workers int
+	// MetricsAddress is the address to serve prometheus metrics on
+	// `/metrics`, metrics are not served if empty.
+	MetricsAddress string
+}
+
+type kubeClient interface {
+	ListConversions(ctx context.Context, namespace string) (*NydusConversionList, error)
+	WatchConversions(ctx context.Context, namespace, resourceVersion string, handle func(eventType string, conv *NydusConversion)) error
+	UpdateConversionStatus(ctx context.Context, conv *NydusConversion) (*NydusConversion, error)
+	GetJob(ctx context.Context, namespace, name string) (*Job, error)
+	CreateJob(ctx context.Context, job *Job) error
+}
+
+type Controller struct {
+	opt     Opt
+	client  kubeClient
+	metrics *metrics
+	queue   *workQueue
+	now     func() time.Time
+
+	mutex sync.Mutex
+	// conversions caches the latest NydusConversions by key `namespace/name`.
+	conversions map[string]*NydusConversion
+}
+
+func New(client *Client, opt Opt) (*Controller, error) {
+	if opt.Image == "" {
+		return nil, errors.New("nydusify image is required")
+	}
+	if opt.Resync <= 0 {
+		opt.Resync = 30 * time.Second
+	}
+	if opt.Workers <= 0 {
+		opt.Workers = 1
+	}
+	return &Controller{
+		opt:         opt,
+		client:      client,
+		metrics:     newMetrics(),
+		queue:       newWorkQueue(),
+		now:         time.Now,
+		conversions: map[string]*NydusConversion{},
+	}, nil
+}
+
+func conversionKey(conv *NydusConversion) string {
+	return conv.Metadata.Namespace + "/" + conv.Metadata.Name
+}
+
+// Run reconciles the NydusConversions until the context is canceled.
+func (c *Controller) Run(ctx context.Context) error {
+	if c.opt.MetricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(c.metrics.registry, promhttp.HandlerOpts{}))
+		server := &http.Server{Addr: c.opt.MetricsAddress, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.WithError(err).Error("serve metrics")
+			}
+		}()
+		defer server.Close()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.opt.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx)
+		}()
+	}
+	defer wg.Wait()
+	defer c.queue.shutdown()
+
+	logrus.Infof("watching %s in namespace %q", Resource, c.opt.Namespace)
+	for {
+		if err := c.sync(ctx); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warnf("sync %s", Resource)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// sync lists all the NydusConversions to reconcile, then watches the
+// changes until the resync interval elapses.
+func (c *Controller) sync(ctx context.Context) error {
+	list, err := c.client.ListConversions(ctx, c.opt.Namespace)
+	if err != nil {
+		return err
+	}
+
+	conversions := map[string]*NydusConversion{}
+	phases := map[string]int{PhasePending: 0, PhaseRunning: 0, PhaseSucceeded: 0, PhaseFailed: 0}
+	for idx := range list.Items {
+		conv := &list.Items[idx]
+		conversions[conversionKey(conv)] = conv
+		phase := conv.Status.Phase
+		if phase == "" {
+			phase = PhasePending
+		}
+		phases[phase]++
+	}
+	for phase, count := range phases {
+		c.metrics.conversions.WithLabelValues(phase).Set(float64(count))
+	}
+	c.mutex.Lock()
+	c.conversions = conversions
+	c.mutex.Unlock()
+	for key := range conversions {
+		c.queue.add(key)
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, c.opt.Resync)
+	defer cancel()
+	err = c.client.WatchConversions(watchCtx, c.opt.Namespace, list.Metadata.ResourceVersion, func(eventType string, conv *NydusConversion) {
+		key := conversionKey(conv)
+		c.mutex.Lock()
+		if eventType == "DELETED" {
+			delete(c.conversions, key)
+		} else {
+			c.conversions[key] = conv
+		}
+		c.mutex.Unlock()
+		c.queue.add(key)
+	})
+	if err != nil && watchCtx.Err() == nil {
+		return errors.Wrap(err, "watch")
+	}
+	return nil
+}
+
+func (c *Controller) work(ctx context.Context) {
+	for {
+		key, ok := c.queue.get()
+		if !ok {
+			return
+		}
+		if err := c.reconcile(ctx, key); err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Warnf("reconcile %s %s", Kind, key)
+		}
+		c.queue.done(key)
+	}
+}
+
+// reconcile creates the job for the generation of NydusConversion, and
+// updates the status of NydusConversion by the job status.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	cached := c.conversions[key]
+	c.mutex.Unlock()
+	if cached == nil || cached.Metadata.DeletionTimestamp != nil {
+		return nil
+	}
+	conv := *cached
+	generation := conv.Metadata.Generation
+	status := &conv.Status
+	if status.ObservedGeneration == generation && (status.Phase == PhaseSucceeded || status.Phase == PhaseFailed) {
+		return nil
+	}
+	oldStatus := cached.Status
+	status.Conditions = append([]Condition{}, cached.Status.Conditions...)
+	now := c.now().UTC().Truncate(time.Second)
+
+	if status.ObservedGeneration != generation {
+		// The spec is changed, convert again by a new job.
+		status.ObservedGeneration = generation
+		status.Phase = PhasePending
+		status.JobName = ""
+		status.StartTime = nil
+		status.CompletionTime = nil
+		status.Conditions = nil
+	}
+
+	if err := validateSpec(&conv.Spec); err != nil {
+		status.Phase = PhaseFailed
+		status.CompletionTime = &now
+		status.setCondition(ConditionComplete, "False", "InvalidSpec", err.Error(), now)
+		return c.updateStatus(ctx, &conv, oldStatus)
+	}
+
+	name := jobName(&conv)
+	job, err := c.client.GetJob(ctx, conv.Metadata.Namespace, name)
+	if errdefs.IsNotFound(err) {
+		job = newJob(&conv, c.opt)
+		if err := c.client.CreateJob(ctx, job); err != nil && !errdefs.IsConflict(err) {
+			return errors.Wrapf(err, "create job %s", name)
+		}
+		logrus.Infof("created job %s for %s %s", name, Kind, key)
+	} else if err != nil {
+		return errors.Wrapf(err, "get job %s", name)
+	}
+
+	if status.StartTime == nil {
+		status.StartTime = &now
+	}
+	status.JobName = name
+	status.setCondition(ConditionJobCreated, "True", "JobCreated", "", now)
+
+	switch {
+	case job.Status.Succeeded > 0:
+		status.Phase = PhaseSucceeded
+		status.CompletionTime = &now
+		status.setCondition(ConditionComplete, "True", "JobSucceeded", "", now)
+	case jobFailed(job) != nil:
+		cond := jobFailed(job)
+		status.Phase = PhaseFailed
+		status.CompletionTime = &now
+		status.setCondition(ConditionComplete, "False", cond.Reason, cond.Message, now)
+	default:
+		status.Phase = PhaseRunning
+		status.setCondition(ConditionComplete, "Unknown", "JobRunning", "", now)
+	}
+
+	return c.updateStatus(ctx, &conv, oldStatus)
+}
+
+func jobFailed(job *Job) *JobCondition {
+	for idx := range job.Status.Conditions {
+		cond := &job.Status.Conditions[idx]
+		if cond.Type == "Failed" && cond.Status == "True" {
+			return cond
+		}
+	}
+	return nil
+}
+
+// updateStatus updates the status if it's changed from oldStatus, and
+// records the metrics of finished conversion.
+func (c *Controller) updateStatus(ctx context.Context, conv *NydusConversion, oldStatus NydusConversionStatus) error {
+	status := conv.Status
+	if reflect.DeepEqual(status, oldStatus) {
+		return nil
+	}
+	updated, err := c.client.UpdateConversionStatus(ctx, conv)
+	if err != nil {
+		if errdefs.IsConflict(err) {
+			// The conversion is changed, it will be reconciled again by
+			// the watched change.
+			return nil
+		}
+		return errors.Wrap(err, "update status")
+	}
+	// Cache the updated one in case the reconciliation is triggered again
+	// before the change is watched.
+	key := conversionKey(conv)
+	c.mutex.Lock()
+	if _, ok := c.conversions[key]; ok {
+		c.conversions[key] = updated
+	}
+	c.mutex.Unlock()
+
+	if status.Phase != oldStatus.Phase && (status.Phase == PhaseSucceeded || status.Phase == PhaseFailed) {
+		logrus.Infof("%s %s/%s %s", Kind, conv.Metadata.Namespace, conv.Metadata.Name, status.Phase)
+		c.metrics.completions.WithLabelValues(status.Phase).Inc()
+		if status.StartTime != nil && status.CompletionTime != nil {
+			c.metrics.duration.Observe(status.CompletionTime.Sub(*status.StartTime).Seconds())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	mutex    sync.Mutex
+	jobs     map[string]*Job
+	statuses []NydusConversionStatus
+}
+
+func (f *fakeClient) ListConversions(context.Context, string) (*NydusConversionList, error) {
+	return &NydusConversionList{}, nil
+}
+
+func (f *fakeClient) WatchConversions(context.Context, string, string, func(string, *NydusConversion)) error {
+	return nil
+}
+
+func (f *fakeClient) UpdateConversionStatus(_ context.Context, conv *NydusConversion) (*NydusConversion, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.statuses = append(f.statuses, conv.Status)
+	updated := *conv
+	return &updated, nil
+}
+
+func (f *fakeClient) GetJob(_ context.Context, namespace, name string) (*Job, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	job, ok := f.jobs[namespace+"/"+name]
+	if !ok {
+		return nil, errdefs.ErrNotFound
+	}
+	return job, nil
+}
+
+func (f *fakeClient) CreateJob(_ context.Context, job *Job) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.jobs[job.Metadata.Namespace+"/"+job.Metadata.Name] = job
+	return nil
+}
+
+func newTestController(client kubeClient, conv *NydusConversion) *Controller {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Controller{
+		opt:         Opt{Image: "nydusify:latest", BackoffLimit: 2},
+		client:      client,
+		metrics:     newMetrics(),
+		queue:       newWorkQueue(),
+		now:         func() time.Time { now = now.Add(time.Minute); return now },
+		conversions: map[string]*NydusConversion{conversionKey(conv): conv},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	client := &fakeClient{jobs: map[string]*Job{}}
+	conv := &NydusConversion{
+		Metadata: ObjectMeta{Name: "nginx", Namespace: "default", UID: "uid", Generation: 1},
+		Spec:     NydusConversionSpec{Source: "nginx:latest", Target: "nginx:latest-nydus"},
+	}
+	c := newTestController(client, conv)
+	key := conversionKey(conv)
+
+	// The job is created for the new conversion.
+	require.NoError(t, c.reconcile(context.Background(), key))
+	job := client.jobs["default/nginx-1"]
+	require.NotNil(t, job)
+	require.Equal(t, "uid", job.Metadata.OwnerReferences[0].UID)
+	require.Equal(t, int32(2), *job.Spec.BackoffLimit)
+	status := c.conversions[key].Status
+	require.Equal(t, PhaseRunning, status.Phase)
+	require.Equal(t, "nginx-1", status.JobName)
+	require.Equal(t, int64(1), status.ObservedGeneration)
+	require.Len(t, status.Conditions, 2)
+
+	// Nothing is changed while the job is running.
+	require.NoError(t, c.reconcile(context.Background(), key))
+	require.Len(t, client.statuses, 1)
+
+	job.Status.Succeeded = 1
+	require.NoError(t, c.reconcile(context.Background(), key))
+	status = c.conversions[key].Status
+	require.Equal(t, PhaseSucceeded, status.Phase)
+	require.NotNil(t, status.CompletionTime)
+	require.Equal(t, "True", status.Conditions[1].Status)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.completions.WithLabelValues(PhaseSucceeded)))
+
+	// The finished conversion is not reconciled again.
+	require.NoError(t, c.reconcile(context.Background(), key))
+	require.Len(t, client.statuses, 2)
+
+	// A new job is created once the spec is changed.
+	updated := *c.conversions[key]
+	updated.Metadata.Generation = 2
+	updated.Spec.Source = "nginx:1.27"
+	c.conversions[key] = &updated
+	require.NoError(t, c.reconcile(context.Background(), key))
+	require.Contains(t, client.jobs, "default/nginx-2")
+	require.Equal(t, PhaseRunning, c.conversions[key].Status.Phase)
+
+	client.jobs["default/nginx-2"].Status.Conditions = []JobCondition{{
+		Type: "Failed", Status: "True", Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit",
+	}}
+	require.NoError(t, c.reconcile(context.Background(), key))
+	status = c.conversions[key].Status
+	require.Equal(t, PhaseFailed, status.Phase)
+	require.Equal(t, "BackoffLimitExceeded", status.Conditions[1].Reason)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.completions.WithLabelValues(PhaseFailed)))
+}
+
+func TestReconcileInvalidSpec(t *testing.T) {
+	client := &fakeClient{jobs: map[string]*Job{}}
+	conv := &NydusConversion{
+		Metadata: ObjectMeta{Name: "invalid", Namespace: "default", Generation: 1},
+		Spec:     NydusConversionSpec{Source: "nginx:latest"},
+	}
+	c := newTestController(client, conv)
+
+	require.NoError(t, c.reconcile(context.Background(), conversionKey(conv)))
+	require.Empty(t, client.jobs)
+	status := c.conversions[conversionKey(conv)].Status
+	require.Equal(t, PhaseFailed, status.Phase)
+	require.Equal(t, "InvalidSpec", status.Conditions[0].Reason)
+}
+
+func TestNewJob(t *testing.T) {
+	backoffLimit := int32(0)
+	conv := &NydusConversion{
+		Metadata: ObjectMeta{Name: "nginx", Namespace: "team", UID: "uid", Generation: 3},
+		Spec: NydusConversionSpec{
+			Source:         "nginx:latest",
+			Target:         "nginx:latest-nydus",
+			Backend:        &Backend{Type: "oss", ConfigSecret: "oss-config"},
+			RegistrySecret: "registry",
+			Image:          "nydusify:v2",
+			Policy: Policy{
+				Platforms:    "linux/amd64,linux/arm64",
+				FsVersion:    "5",
+				BackoffLimit: &backoffLimit,
+				ExtraArgs:    []string{"--oci"},
+			},
+		},
+	}
+
+	job := newJob(conv, Opt{Image: "nydusify:latest", BackoffLimit: 2, JobServiceAccount: "nydusify"})
+	require.Equal(t, "nginx-3", job.Metadata.Name)
+	require.Equal(t, "team", job.Metadata.Namespace)
+	require.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	pod := job.Spec.Template.Spec
+	require.Equal(t, "nydusify", pod.ServiceAccountName)
+	require.Equal(t, "Never", pod.RestartPolicy)
+	container := pod.Containers[0]
+	require.Equal(t, "nydusify:v2", container.Image)
+	require.Equal(t, []string{
+		"convert", "--source", "nginx:latest", "--target", "nginx:latest-nydus", "--work-dir", workDir,
+		"--backend-type", "oss", "--backend-config-file", backendConfigDir + "/config.json",
+		"--platform", "linux/amd64,linux/arm64", "--fs-version", "5", "--oci",
+	}, container.Args)
+	require.Equal(t, []EnvVar{{Name: "DOCKER_CONFIG", Value: dockerConfigDir}}, container.Env)
+	require.Len(t, container.VolumeMounts, 3)
+	require.Len(t, pod.Volumes, 3)
+	require.Equal(t, "registry", pod.Volumes[1].Secret.SecretName)
+	require.Equal(t, "oss-config", pod.Volumes[2].Secret.SecretName)
+
+	conv.Metadata.Name = string(make([]byte, 60))
+	require.Len(t, jobName(conv), maxJobNameLength)
+}
+
+func TestWorkQueue(t *testing.T) {
+	q := newWorkQueue()
+	q.add("a")
+	q.add("b")
+	q.add("a")
+
+	key, ok := q.get()
+	require.True(t, ok)
+	require.Equal(t, "a", key)
+	// The key being processed is queued again after done.
+	q.add("a")
+	key, _ = q.get()
+	require.Equal(t, "b", key)
+	q.done("b")
+	q.done("a")
+	key, _ = q.get()
+	require.Equal(t, "a", key)
+
+	q.shutdown()
+	_, ok = q.get()
+	require.False(t, ok)
+}
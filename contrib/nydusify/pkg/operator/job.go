@@ -0,0 +1,132 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import (
+	"fmt"
+	"strconv"
+)
+
+const (
+	dockerConfigDir  = "/etc/nydusify/docker"
+	backendConfigDir = "/etc/nydusify/backend"
+	workDir          = "/var/lib/nydusify"
+
+	// maxJobNameLength leaves room for the pod name suffix added by the job
+	// controller within the 63 characters limit of label value.
+	maxJobNameLength = 52
+)
+
+// jobName returns the job name of the generation of conversion, so that a
+// new job is created once the spec is changed.
+func jobName(conv *NydusConversion) string {
+	suffix := "-" + strconv.FormatInt(conv.Metadata.Generation, 10)
+	name := conv.Metadata.Name
+	if len(name)+len(suffix) > maxJobNameLength {
+		name = name[:maxJobNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// convertArgs returns the arguments of `nydusify convert` for conversion.
+func convertArgs(spec *NydusConversionSpec) []string {
+	args := []string{"convert", "--source", spec.Source, "--target", spec.Target, "--work-dir", workDir}
+	if spec.Backend != nil {
+		args = append(args,
+			"--backend-type", spec.Backend.Type,
+			"--backend-config-file", backendConfigDir+"/config.json",
+		)
+	}
+	if spec.Policy.Platforms != "" {
+		args = append(args, "--platform", spec.Policy.Platforms)
+	}
+	if spec.Policy.FsVersion != "" {
+		args = append(args, "--fs-version", spec.Policy.FsVersion)
+	}
+	if spec.Policy.Compressor != "" {
+		args = append(args, "--compressor", spec.Policy.Compressor)
+	}
+	return append(args, spec.Policy.ExtraArgs...)
+}
+
+// newJob creates the job running `nydusify convert` for conversion, the job
+// is owned by the conversion to be deleted along with it.
+func newJob(conv *NydusConversion, opt Opt) *Job {
+	image := opt.Image
+	if conv.Spec.Image != "" {
+		image = conv.Spec.Image
+	}
+	container := Container{
+		Name:         "nydusify",
+		Image:        image,
+		Command:      []string{"nydusify"},
+		Args:         convertArgs(&conv.Spec),
+		VolumeMounts: []VolumeMount{{Name: "work", MountPath: workDir}},
+	}
+	volumes := []Volume{{Name: "work", EmptyDir: &struct{}{}}}
+
+	if conv.Spec.RegistrySecret != "" {
+		container.Env = append(container.Env, EnvVar{Name: "DOCKER_CONFIG", Value: dockerConfigDir})
+		container.VolumeMounts = append(container.VolumeMounts, VolumeMount{Name: "registry", MountPath: dockerConfigDir, ReadOnly: true})
+		volumes = append(volumes, Volume{Name: "registry", Secret: &SecretVolume{
+			SecretName: conv.Spec.RegistrySecret,
+			Items:      []KeyToPath{{Key: ".dockerconfigjson", Path: "config.json"}},
+		}})
+	}
+	if conv.Spec.Backend != nil {
+		container.VolumeMounts = append(container.VolumeMounts, VolumeMount{Name: "backend", MountPath: backendConfigDir, ReadOnly: true})
+		volumes = append(volumes, Volume{Name: "backend", Secret: &SecretVolume{
+			SecretName: conv.Spec.Backend.ConfigSecret,
+			Items:      []KeyToPath{{Key: "config.json", Path: "config.json"}},
+		}})
+	}
+
+	labels := map[string]string{conversionLabel: conv.Metadata.UID}
+	backoffLimit := conv.Spec.Policy.BackoffLimit
+	if backoffLimit == nil {
+		backoffLimit = &opt.BackoffLimit
+	}
+	return &Job{
+		APIVersion: "batch/v1",
+		Kind:       "Job",
+		Metadata: ObjectMeta{
+			Name:      jobName(conv),
+			Namespace: conv.Metadata.Namespace,
+			Labels:    labels,
+			OwnerReferences: []OwnerReference{{
+				APIVersion:         Group + "/" + Version,
+				Kind:               Kind,
+				Name:               conv.Metadata.Name,
+				UID:                conv.Metadata.UID,
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		Spec: JobSpec{
+			BackoffLimit:          backoffLimit,
+			ActiveDeadlineSeconds: conv.Spec.Policy.ActiveDeadlineSeconds,
+			Template: PodTemplate{
+				Metadata: ObjectMeta{Labels: labels},
+				Spec: PodSpec{
+					RestartPolicy:      "Never",
+					ServiceAccountName: opt.JobServiceAccount,
+					Containers:         []Container{container},
+					Volumes:            volumes,
+				},
+			},
+		},
+	}
+}
+
+// validateSpec checks the required fields of spec.
+func validateSpec(spec *NydusConversionSpec) error {
+	if spec.Source == "" || spec.Target == "" {
+		return fmt.Errorf("source and target are required")
+	}
+	if spec.Backend != nil && (spec.Backend.Type == "" || spec.Backend.ConfigSecret == "") {
+		return fmt.Errorf("backend type and config secret are required")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	registry *prometheus.Registry
+	// conversions is the number of NydusConversions by phase.
+	conversions *prometheus.GaugeVec
+	// completions counts the finished conversions by phase.
+	completions *prometheus.CounterVec
+	// duration is the duration of the finished conversions.
+	duration prometheus.Histogram
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		conversions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "nydusify",
+			Subsystem: "operator",
+			Name:      "conversions",
+			Help:      "The number of NydusConversions. Broken down by phase.",
+		}, []string{"phase"}),
+		completions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "nydusify",
+			Subsystem: "operator",
+			Name:      "completions_total",
+			Help:      "The total number of finished NydusConversions. Broken down by phase.",
+		}, []string{"phase"}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "nydusify",
+			Subsystem: "operator",
+			Name:      "conversion_duration_seconds",
+			Help:      "The duration of finished NydusConversions from job creation to completion.",
+			Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+		}),
+	}
+	m.registry.MustRegister(m.conversions, m.completions, m.duration)
+	return m
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import "sync"
+
+// workQueue is a FIFO queue of keys, a key is queued only once until it's
+// got, and is never processed by multiple workers at the same time.
+type workQueue struct {
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	keys   []string
+	queued map[string]bool
+	// processing records the keys being processed, a key added during
+	// processing is queued again once it's done.
+	processing map[string]bool
+	closed     bool
+}
+
+func newWorkQueue() *workQueue {
+	q := &workQueue{
+		queued:     map[string]bool{},
+		processing: map[string]bool{},
+	}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+func (q *workQueue) add(key string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.closed || q.queued[key] {
+		return
+	}
+	q.queued[key] = true
+	if q.processing[key] {
+		return
+	}
+	q.keys = append(q.keys, key)
+	q.cond.Signal()
+}
+
+// get waits for a key, it returns false if the queue is shut down.
+func (q *workQueue) get() (string, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.keys) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed {
+		return "", false
+	}
+	key := q.keys[0]
+	q.keys = q.keys[1:]
+	delete(q.queued, key)
+	q.processing[key] = true
+	return key, true
+}
+
+func (q *workQueue) done(key string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.processing, key)
+	if q.queued[key] {
+		q.keys = append(q.keys, key)
+		q.cond.Signal()
+	}
+}
+
+func (q *workQueue) shutdown() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package operator
+
+import "time"
+
+const (
+	// Group and Version are the API group and version of NydusConversion.
+	Group   = "nydus.dragonflyoss.io"
+	Version = "v1alpha1"
+	// Kind and Resource are the kind and plural resource name of NydusConversion.
+	Kind     = "NydusConversion"
+	Resource = "nydusconversions"
+
+	// conversionLabel labels the jobs created for a NydusConversion with its
+	// UID, since the name may exceed the length limit of label value.
+	conversionLabel = Group + "/conversion-uid"
+)
+
+// Phases of NydusConversion.
+const (
+	PhasePending   = "Pending"
+	PhaseRunning   = "Running"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+)
+
+// Condition types of NydusConversion.
+const (
+	// ConditionJobCreated is true once the conversion job is created.
+	ConditionJobCreated = "JobCreated"
+	// ConditionComplete is true if the conversion job succeeded, and false
+	// with the reason if the job failed.
+	ConditionComplete = "Complete"
+)
+
+type ObjectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	UID               string            `json:"uid,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	Generation        int64             `json:"generation,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp,omitempty"`
+}
+
+type OwnerReference struct {
+	APIVersion         string `json:"apiVersion"`
+	Kind               string `json:"kind"`
+	Name               string `json:"name"`
+	UID                string `json:"uid"`
+	Controller         bool   `json:"controller,omitempty"`
+	BlockOwnerDeletion bool   `json:"blockOwnerDeletion,omitempty"`
+}
+
+// NydusConversion declares the conversion of a source image to a Nydus image.
+type NydusConversion struct {
+	APIVersion string                `json:"apiVersion,omitempty"`
+	Kind       string                `json:"kind,omitempty"`
+	Metadata   ObjectMeta            `json:"metadata"`
+	Spec       NydusConversionSpec   `json:"spec"`
+	Status     NydusConversionStatus `json:"status,omitempty"`
+}
+
+type NydusConversionList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion,omitempty"`
+	} `json:"metadata"`
+	Items []NydusConversion `json:"items"`
+}
+
+type NydusConversionSpec struct {
+	// Source is the source image reference.
+	Source string `json:"source"`
+	// Target is the Nydus image reference.
+	Target string `json:"target"`
+	// Backend stores the Nydus blobs in storage backend instead of registry.
+	Backend *Backend `json:"backend,omitempty"`
+	// Policy controls how the image is converted and retried.
+	Policy Policy `json:"policy,omitempty"`
+	// RegistrySecret is the name of the `kubernetes.io/dockerconfigjson`
+	// secret to access the source and target registries.
+	RegistrySecret string `json:"registrySecret,omitempty"`
+	// Image is the nydusify image running the conversion, it overrides
+	// the default image of operator.
+	Image string `json:"image,omitempty"`
+}
+
+type Backend struct {
+	// Type is the storage backend type, like `oss` and `s3`.
+	Type string `json:"type"`
+	// ConfigSecret is the name of secret containing the backend config
+	// JSON in key `config.json`.
+	ConfigSecret string `json:"configSecret"`
+}
+
+type Policy struct {
+	Platforms  string `json:"platforms,omitempty"`
+	FsVersion  string `json:"fsVersion,omitempty"`
+	Compressor string `json:"compressor,omitempty"`
+	// BackoffLimit is the number of retries of the conversion job.
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds limits the duration of the conversion job.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// ExtraArgs are appended to the arguments of `nydusify convert`.
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+}
+
+type NydusConversionStatus struct {
+	Phase              string      `json:"phase,omitempty"`
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	JobName            string      `json:"jobName,omitempty"`
+	StartTime          *time.Time  `json:"startTime,omitempty"`
+	CompletionTime     *time.Time  `json:"completionTime,omitempty"`
+	Conditions         []Condition `json:"conditions,omitempty"`
+}
+
+type Condition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// setCondition sets the condition of type, the transition time is updated
+// only if the status is changed.
+func (status *NydusConversionStatus) setCondition(condType, condStatus, reason, message string, now time.Time) {
+	for idx := range status.Conditions {
+		cond := &status.Conditions[idx]
+		if cond.Type != condType {
+			continue
+		}
+		if cond.Status != condStatus {
+			cond.LastTransitionTime = now
+		}
+		cond.Status = condStatus
+		cond.Reason = reason
+		cond.Message = message
+		return
+	}
+	status.Conditions = append(status.Conditions, Condition{
+		Type:               condType,
+		Status:             condStatus,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// Job is the subset of Kubernetes batch/v1 Job used by operator.
+type Job struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Spec       JobSpec    `json:"spec"`
+	Status     JobStatus  `json:"status,omitempty"`
+}
+
+type JobSpec struct {
+	BackoffLimit          *int32      `json:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds *int64      `json:"activeDeadlineSeconds,omitempty"`
+	Template              PodTemplate `json:"template"`
+}
+
+type PodTemplate struct {
+	Metadata ObjectMeta `json:"metadata,omitempty"`
+	Spec     PodSpec    `json:"spec"`
+}
+
+type PodSpec struct {
+	RestartPolicy      string      `json:"restartPolicy"`
+	ServiceAccountName string      `json:"serviceAccountName,omitempty"`
+	Containers         []Container `json:"containers"`
+	Volumes            []Volume    `json:"volumes,omitempty"`
+}
+
+type Container struct {
+	Name         string        `json:"name"`
+	Image        string        `json:"image"`
+	Command      []string      `json:"command,omitempty"`
+	Args         []string      `json:"args,omitempty"`
+	Env          []EnvVar      `json:"env,omitempty"`
+	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+type Volume struct {
+	Name     string        `json:"name"`
+	Secret   *SecretVolume `json:"secret,omitempty"`
+	EmptyDir *struct{}     `json:"emptyDir,omitempty"`
+}
+
+type SecretVolume struct {
+	SecretName string      `json:"secretName"`
+	Items      []KeyToPath `json:"items,omitempty"`
+}
+
+type KeyToPath struct {
+	Key  string `json:"key"`
+	Path string `json:"path"`
+}
+
+type JobStatus struct {
+	Active     int32          `json:"active,omitempty"`
+	Succeeded  int32          `json:"succeeded,omitempty"`
+	Failed     int32          `json:"failed,omitempty"`
+	Conditions []JobCondition `json:"conditions,omitempty"`
+}
+
+type JobCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
 )
 
 const (
@@ -54,6 +55,17 @@ type Opt struct {
 
 	OptimizePolicy    string
 	PrefetchFilesPath string
+	// AccessTracePaths are the access pattern files exported by nydusd, the
+	// prefetch files are generated from them ordered by first access time,
+	// it conflicts with PrefetchFilesPath.
+	AccessTracePaths []string
+	// HotBlobMaxSize limits the size of prefetch blob if not zero, only the
+	// most frequently accessed files (or the files listed earlier in
+	// PrefetchFilesPath) within the size are prefetched.
+	HotBlobMaxSize uint64
+	// Verify checks the optimized image is content-identical to the source
+	// image before pushing.
+	Verify bool
 
 	AllPlatforms bool
 	Platforms    string
@@ -233,6 +245,9 @@ func fetchBlobs(ctx context.Context, opt Opt, buildDir string) error {
 // Optimize coverts and push a new optimized nydus image
 func Optimize(ctx context.Context, opt Opt) error {
 	ctx = namespaces.WithNamespace(ctx, "nydusify")
+	if len(opt.AccessTracePaths) > 0 && opt.PrefetchFilesPath != "" {
+		return fmt.Errorf("access trace conflicts with prefetch files")
+	}
 
 	sourceRemote, err := provider.DefaultRemote(opt.Source, opt.SourceInsecure)
 	if err != nil {
@@ -261,28 +276,38 @@ func Optimize(ctx context.Context, opt Opt) error {
 			return errors.Wrap(err, "stat work directory")
 		}
 	}
-	buildDir, err := os.MkdirTemp(opt.WorkDir, "nydusify-")
+	buildDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
 	if err != nil {
 		return errors.Wrap(err, "create temp directory")
 	}
 	defer os.RemoveAll(buildDir)
+	unlock, err := workdir.Lock(buildDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	if err := fetchBlobs(ctx, opt, buildDir); err != nil {
 		return errors.Wrap(err, "prepare nydus blobs")
 	}
 
 	originalBootstrap := filepath.Join(buildDir, "nydus_bootstrap")
-	bootstrapDesc := parser.FindNydusBootstrapDesc(&sourceNydusImage.Manifest)
-	if bootstrapDesc == nil {
-		return fmt.Errorf("not found Nydus bootstrap layer in manifest")
-	}
-	bootstrapReader, err := sourceParser.Remote.Pull(ctx, *bootstrapDesc, true)
+	bootstrapDesc, err := fetchBootstrap(ctx, sourceParser, sourceNydusImage, originalBootstrap)
 	if err != nil {
-		return errors.Wrap(err, "pull Nydus originalBootstrap layer")
+		return err
 	}
-	defer bootstrapReader.Close()
-	if err := utils.UnpackFile(bootstrapReader, utils.BootstrapFileNameInLayer, originalBootstrap); err != nil {
-		return errors.Wrap(err, "unpack Nydus originalBootstrap layer")
+
+	if len(opt.AccessTracePaths) > 0 {
+		opt.PrefetchFilesPath = filepath.Join(buildDir, EntryPrefetchFiles)
+		if err := generatePrefetchFiles(originalBootstrap, opt.AccessTracePaths, opt.PrefetchFilesPath, opt.HotBlobMaxSize); err != nil {
+			return errors.Wrap(err, "generate prefetch files from access trace")
+		}
+	} else if opt.HotBlobMaxSize > 0 {
+		prefetchFilesPath := filepath.Join(buildDir, EntryPrefetchFiles)
+		if err := limitPrefetchFiles(originalBootstrap, opt.PrefetchFilesPath, prefetchFilesPath, opt.HotBlobMaxSize); err != nil {
+			return errors.Wrap(err, "limit prefetch files in hot blob size")
+		}
+		opt.PrefetchFilesPath = prefetchFilesPath
 	}
 
 	compressAlgo := bootstrapDesc.Digest.Algorithm().String()
@@ -305,6 +330,13 @@ func Optimize(ctx context.Context, opt Opt) error {
 	}
 	logrus.Infof("builded new prefetch blob and bootstrap, elapsed: %s", time.Since(start))
 
+	if opt.Verify {
+		if err := verifyOptimized(originalBootstrap, newBootstrapPath, blobDir, prefetchBlobID); err != nil {
+			return errors.Wrap(err, "verify optimized image")
+		}
+		logrus.Infof("verified optimized image is content-identical to source image")
+	}
+
 	buildInfo := BuildInfo{
 		SourceImage:      *sourceParsed.NydusImage,
 		BuildDir:         buildDir,
@@ -319,6 +351,24 @@ func Optimize(ctx context.Context, opt Opt) error {
 	return nil
 }
 
+// fetchBootstrap pulls the bootstrap layer of Nydus image and unpacks the
+// bootstrap to path.
+func fetchBootstrap(ctx context.Context, sourceParser *parser.Parser, nydusImage *parser.Image, path string) (*ocispec.Descriptor, error) {
+	bootstrapDesc := parser.FindNydusBootstrapDesc(&nydusImage.Manifest)
+	if bootstrapDesc == nil {
+		return nil, fmt.Errorf("not found Nydus bootstrap layer in manifest")
+	}
+	bootstrapReader, err := sourceParser.Remote.Pull(ctx, *bootstrapDesc, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "pull Nydus originalBootstrap layer")
+	}
+	defer bootstrapReader.Close()
+	if err := utils.UnpackFile(bootstrapReader, utils.BootstrapFileNameInLayer, path); err != nil {
+		return nil, errors.Wrap(err, "unpack Nydus originalBootstrap layer")
+	}
+	return bootstrapDesc, nil
+}
+
 // push blob
 func pushBlob(ctx context.Context, opt Opt, buildInfo BuildInfo) (*ocispec.Descriptor, error) {
 	blobDir := buildInfo.BlobDir
@@ -0,0 +1,349 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/committer"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
+)
+
+// RecordOpt is the option to record the accessed files of a running
+// container (or a nydusd instance) as prefetch files.
+type RecordOpt struct {
+	WorkDir string
+
+	ContainerdAddress string
+	Namespace         string
+	ContainerID       string
+
+	// APISock is the API socket of nydusd and FsID is the mount id of RAFS
+	// instance in nydusd, they are detected from the container if not
+	// specified. FsID can be empty if nydusd has only one instance.
+	APISock string
+	FsID    string
+
+	// BootstrapPath is the bootstrap of Nydus image to map the accessed
+	// inodes to paths, it is pulled from Source (default to the image of
+	// container) if not specified.
+	BootstrapPath  string
+	Source         string
+	SourceInsecure bool
+
+	// Duration is the warm-up window of workload, the accesses are exported
+	// once it elapses or the context is canceled.
+	Duration   time.Duration
+	OutputPath string
+}
+
+// nydusdProcess is a running nydusd found in procfs.
+type nydusdProcess struct {
+	Pid        int
+	APISock    string
+	Mountpoint string
+}
+
+// parseNydusdArgs returns the API socket and the mountpoint in the command
+// line arguments of nydusd.
+func parseNydusdArgs(args []string) (string, string, bool) {
+	if len(args) == 0 || !strings.HasPrefix(filepath.Base(args[0]), "nydusd") {
+		return "", "", false
+	}
+	var apiSock, mountpoint string
+	for i := 1; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "--apisock", "-A":
+			apiSock = value
+		case "--mountpoint", "-M":
+			mountpoint = value
+		default:
+			continue
+		}
+		if !hasValue {
+			i++
+		}
+	}
+	return apiSock, mountpoint, apiSock != "" && mountpoint != ""
+}
+
+// listNydusd returns the nydusd processes serving API socket in procRoot.
+func listNydusd(procRoot string) ([]nydusdProcess, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, errors.Wrap(err, "read procfs")
+	}
+	processes := []nydusdProcess{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// The process may exit during iteration.
+		cmdline, err := os.ReadFile(filepath.Join(procRoot, entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		apiSock, mountpoint, ok := parseNydusdArgs(strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00"))
+		if !ok {
+			continue
+		}
+		processes = append(processes, nydusdProcess{
+			Pid:        pid,
+			APISock:    apiSock,
+			Mountpoint: mountpoint,
+		})
+	}
+	return processes, nil
+}
+
+// matchInstance returns the id of RAFS instance mounted by nydusd which is
+// used as a lower directory of container rootfs.
+func matchInstance(mountpoint string, ids []string, lowerDirs []string) (string, bool) {
+	for _, id := range ids {
+		instanceDir := filepath.Join(mountpoint, id)
+		for _, lowerDir := range lowerDirs {
+			lowerDir = filepath.Clean(lowerDir)
+			if lowerDir == instanceDir || strings.HasPrefix(lowerDir, instanceDir+"/") {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+// nydusdClient requests the HTTP API of nydusd over unix socket.
+type nydusdClient struct {
+	sock   string
+	client *http.Client
+}
+
+func newNydusdClient(sock string) *nydusdClient {
+	transport := &http.Transport{
+		MaxIdleConns:          10,
+		IdleConnTimeout:       10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{
+				Timeout:   5 * time.Second,
+				KeepAlive: 5 * time.Second,
+			}
+			return dialer.DialContext(ctx, "unix", sock)
+		},
+	}
+
+	return &nydusdClient{
+		sock: sock,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+	}
+}
+
+func (c *nydusdClient) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "request nydusd API %s", c.sock)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "read response of %s", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s with status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrapf(err, "invalid response of %s", path)
+	}
+	return nil
+}
+
+// instances returns the mount ids of filesystem instances in nydusd.
+func (c *nydusdClient) instances(ctx context.Context) ([]string, error) {
+	info := struct {
+		BackendCollection map[string]json.RawMessage `json:"backend_collection"`
+	}{}
+	if err := c.get(ctx, "/api/v1/daemon", &info); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(info.BackendCollection))
+	for id := range info.BackendCollection {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// accessPatterns exports the access patterns of filesystem instance, which
+// requires `access_pattern` enabled in nydusd config.
+func (c *nydusdClient) accessPatterns(ctx context.Context, fsID string) ([]AccessRecord, error) {
+	path := "/api/v1/metrics/pattern"
+	if fsID != "" {
+		path += "?id=" + url.QueryEscape(fsID)
+	}
+	records := []AccessRecord{}
+	if err := c.get(ctx, path, &records); err != nil {
+		return nil, errors.Wrap(err, "export access patterns, please ensure `access_pattern` is enabled in nydusd config")
+	}
+	return records, nil
+}
+
+// findInstance returns the API socket of nydusd and the mount id of RAFS
+// instance serving the lower directories of container rootfs.
+func findInstance(ctx context.Context, procRoot string, lowerDirs []string) (string, string, error) {
+	processes, err := listNydusd(procRoot)
+	if err != nil {
+		return "", "", err
+	}
+	for _, process := range processes {
+		ids, err := newNydusdClient(process.APISock).instances(ctx)
+		if err != nil {
+			logrus.WithError(err).Warnf("skip nydusd %d", process.Pid)
+			continue
+		}
+		if id, ok := matchInstance(process.Mountpoint, ids, lowerDirs); ok {
+			logrus.Infof("found nydusd %d with API socket %s, instance %s", process.Pid, process.APISock, id)
+			return process.APISock, id, nil
+		}
+	}
+	return "", "", fmt.Errorf("not found nydusd serving the container rootfs, is it a Nydus container in FUSE mode?")
+}
+
+// pullBootstrap pulls the bootstrap of Nydus image to path.
+func pullBootstrap(ctx context.Context, source string, insecure bool, path string) error {
+	sourceRemote, err := provider.DefaultRemote(source, insecure)
+	if err != nil {
+		return errors.Wrap(err, "Init source image parser")
+	}
+	sourceParser, err := parser.New(sourceRemote, runtime.GOARCH)
+	if err != nil {
+		return errors.Wrap(err, "failed to create parser")
+	}
+	sourceParsed, err := sourceParser.Parse(ctx)
+	if err != nil {
+		return errors.Wrap(err, "parse source image")
+	}
+	if sourceParsed.NydusImage == nil {
+		return fmt.Errorf("image %s is not a Nydus image", source)
+	}
+	_, err = fetchBootstrap(ctx, sourceParser, sourceParsed.NydusImage, path)
+	return err
+}
+
+// Record records the files accessed by the workload of container during the
+// warm-up window through the API of nydusd, and writes them as the prefetch
+// files ordered by first access, which can be consumed by Optimize.
+func Record(ctx context.Context, opt RecordOpt) error {
+	if opt.OutputPath == "" {
+		return fmt.Errorf("output path is required")
+	}
+	if opt.ContainerID == "" && opt.APISock == "" {
+		return fmt.Errorf("either container or nydusd API socket is required")
+	}
+
+	apiSock, fsID, source := opt.APISock, opt.FsID, opt.Source
+	if opt.ContainerID != "" {
+		ctx := namespaces.WithNamespace(ctx, opt.Namespace)
+		manager, err := committer.NewManager(opt.ContainerdAddress)
+		if err != nil {
+			return errors.Wrap(err, "create container manager")
+		}
+		containerID, err := manager.Resolve(ctx, opt.ContainerID)
+		if err != nil {
+			return errors.Wrap(err, "resolve container")
+		}
+		inspect, err := manager.Inspect(ctx, containerID)
+		if err != nil {
+			return errors.Wrap(err, "inspect container")
+		}
+		if apiSock == "" {
+			apiSock, fsID, err = findInstance(ctx, "/proc", strings.Split(inspect.LowerDirs, ":"))
+			if err != nil {
+				return err
+			}
+		}
+		if source == "" {
+			source = inspect.Image
+		}
+	}
+
+	bootstrapPath := opt.BootstrapPath
+	if bootstrapPath == "" {
+		if source == "" {
+			return fmt.Errorf("either source image or bootstrap is required")
+		}
+		if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
+			return errors.Wrap(err, "prepare work directory")
+		}
+		recordDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
+		if err != nil {
+			return errors.Wrap(err, "create temp directory")
+		}
+		defer os.RemoveAll(recordDir)
+		unlock, err := workdir.Lock(recordDir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		bootstrapPath = filepath.Join(recordDir, EntryBootstrap)
+		if err := pullBootstrap(ctx, source, opt.SourceInsecure, bootstrapPath); err != nil {
+			return errors.Wrapf(err, "pull bootstrap of %s", source)
+		}
+	}
+
+	client := newNydusdClient(apiSock)
+	// Check the access pattern is available before waiting.
+	if _, err := client.accessPatterns(ctx, fsID); err != nil {
+		return err
+	}
+
+	logrus.Infof("recording file accesses for %s, interrupt to stop early", opt.Duration)
+	timer := time.NewTimer(opt.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		logrus.Infof("recording is stopped")
+	}
+
+	records, err := client.accessPatterns(context.WithoutCancel(ctx), fsID)
+	if err != nil {
+		return err
+	}
+	if err := writePrefetchFiles(bootstrapPath, [][]AccessRecord{records}, opt.OutputPath, 0); err != nil {
+		return err
+	}
+	logrus.Infof("prefetch files are written to %s", opt.OutputPath)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNydusdArgs(t *testing.T) {
+	apiSock, mountpoint, ok := parseNydusdArgs([]string{"/usr/bin/nydusd", "fuse", "--config", "/etc/nydus/config.json", "--apisock", "/run/nydus/api.sock", "--mountpoint=/var/lib/containerd-nydus/mnt"})
+	require.True(t, ok)
+	require.Equal(t, "/run/nydus/api.sock", apiSock)
+	require.Equal(t, "/var/lib/containerd-nydus/mnt", mountpoint)
+
+	apiSock, mountpoint, ok = parseNydusdArgs([]string{"nydusd", "-A", "/run/api.sock", "-M", "/mnt"})
+	require.True(t, ok)
+	require.Equal(t, "/run/api.sock", apiSock)
+	require.Equal(t, "/mnt", mountpoint)
+
+	_, _, ok = parseNydusdArgs([]string{"nydusd", "--mountpoint", "/mnt"})
+	require.False(t, ok)
+	_, _, ok = parseNydusdArgs([]string{"containerd", "--apisock", "/run/api.sock", "--mountpoint", "/mnt"})
+	require.False(t, ok)
+	_, _, ok = parseNydusdArgs(nil)
+	require.False(t, ok)
+}
+
+func TestListNydusd(t *testing.T) {
+	procRoot := t.TempDir()
+	cmdlines := map[string][]string{
+		"100":  {"/usr/bin/nydusd", "--apisock", "/run/api.sock", "--mountpoint", "/mnt"},
+		"200":  {"/usr/bin/containerd"},
+		"self": {"/usr/bin/nydusd", "--apisock", "/run/self.sock", "--mountpoint", "/mnt"},
+	}
+	for pid, args := range cmdlines {
+		require.NoError(t, os.MkdirAll(filepath.Join(procRoot, pid), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(procRoot, pid, "cmdline"), []byte(strings.Join(args, "\x00")+"\x00"), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, "300"), 0755))
+
+	processes, err := listNydusd(procRoot)
+	require.NoError(t, err)
+	require.Equal(t, []nydusdProcess{{Pid: 100, APISock: "/run/api.sock", Mountpoint: "/mnt"}}, processes)
+}
+
+func TestMatchInstance(t *testing.T) {
+	lowerDirs := []string{"/var/lib/containerd/snapshots/2/fs", "/var/lib/containerd-nydus/mnt/10/"}
+
+	id, ok := matchInstance("/var/lib/containerd-nydus/mnt", []string{"/9", "/10"}, lowerDirs)
+	require.True(t, ok)
+	require.Equal(t, "/10", id)
+
+	id, ok = matchInstance("/var/lib/containerd-nydus/snapshots/10/mnt", []string{"/"}, []string{"/var/lib/containerd-nydus/snapshots/10/mnt"})
+	require.True(t, ok)
+	require.Equal(t, "/", id)
+
+	_, ok = matchInstance("/var/lib/containerd-nydus/mnt", []string{"/1"}, lowerDirs)
+	require.False(t, ok)
+}
+
+func TestNydusdClient(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/daemon", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"state":"RUNNING","backend_collection":{"/10":{"backend_type":"rafs"}}}`))
+	})
+	mux.HandleFunc("/api/v1/metrics/pattern", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "/10" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"Unknown","message":"no counter"}`))
+			return
+		}
+		w.Write([]byte(`[{"ino":44,"nr_read":2,"first_access_time_secs":1700000000,"first_access_time_nanos":12}]`))
+	})
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	client := newNydusdClient(sock)
+	ids, err := client.instances(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"/10"}, ids)
+
+	records, err := client.accessPatterns(context.Background(), "/10")
+	require.NoError(t, err)
+	require.Equal(t, []AccessRecord{{Ino: 44, NrRead: 2, FirstAccessTimeSecs: 1700000000, FirstAccessTimeNanos: 12}}, records)
+
+	_, err = client.accessPatterns(context.Background(), "/1")
+	require.ErrorContains(t, err, "access_pattern")
+}
@@ -0,0 +1,417 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// AccessRecord is the access pattern of a file exported by nydusd API
+// `/api/v1/metrics/pattern`, which is recorded if `access_pattern` is
+// enabled in nydusd config. The inode number of RAFS v6 is the nid of inode
+// in bootstrap, Path can be specified instead of Ino.
+type AccessRecord struct {
+	Ino                  uint64 `json:"ino"`
+	Path                 string `json:"path,omitempty"`
+	NrRead               uint64 `json:"nr_read"`
+	FirstAccessTimeSecs  uint64 `json:"first_access_time_secs"`
+	FirstAccessTimeNanos uint32 `json:"first_access_time_nanos"`
+}
+
+func (record AccessRecord) firstAccessTime() uint64 {
+	return record.FirstAccessTimeSecs*1e9 + uint64(record.FirstAccessTimeNanos)
+}
+
+// LoadAccessTrace reads the access patterns from trace file.
+func LoadAccessTrace(path string) ([]AccessRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read access trace %s", path)
+	}
+	records := []AccessRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, errors.Wrapf(err, "invalid access trace %s", path)
+	}
+	return records, nil
+}
+
+// stargzRecord is an entry of the file accesses recorded by the optimizer
+// of stargz-snapshotter, one JSON object per line.
+type stargzRecord struct {
+	Name string `json:"name"`
+}
+
+// LoadPrefetchTraces reads the paths of files accessed in the trace files,
+// in the order of traces and accesses, to be used as the prefetch patterns
+// of conversion. The format of trace is detected by its content:
+//
+//   - the access patterns of nydusd in JSON array, only the records with
+//     path are used as the inode numbers can't be resolved without the
+//     bootstrap;
+//   - the records of stargz-snapshotter in JSON lines;
+//   - the CSV of fanotify tracers with the path in the first column, like
+//     the optimizer of nydus-snapshotter, which also accepts plain path
+//     lists.
+func LoadPrefetchTraces(paths []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	for _, tracePath := range paths {
+		traceFiles, err := loadPrefetchTrace(tracePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(traceFiles) == 0 {
+			return nil, errors.Errorf("no file path found in trace %s", tracePath)
+		}
+		for _, file := range traceFiles {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+func loadPrefetchTrace(tracePath string) ([]string, error) {
+	data, err := os.ReadFile(tracePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read trace %s", tracePath)
+	}
+	var names []string
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		names, err = nydusdTraceFiles(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		names, err = stargzTraceFiles(trimmed)
+	default:
+		names, err = csvTraceFiles(trimmed)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid trace %s", tracePath)
+	}
+
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			files = append(files, path.Clean("/"+name))
+		}
+	}
+	return files, nil
+}
+
+func nydusdTraceFiles(data []byte) ([]string, error) {
+	records := []AccessRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	unresolved := 0
+	accessed := make([]AccessRecord, 0, len(records))
+	for _, record := range records {
+		if record.NrRead == 0 {
+			continue
+		}
+		if record.Path == "" {
+			unresolved++
+			continue
+		}
+		accessed = append(accessed, record)
+	}
+	if unresolved > 0 {
+		logrus.Warnf("skip %d access records without path, the inode numbers can only be resolved by the nydus bootstrap", unresolved)
+	}
+	sort.SliceStable(accessed, func(i, j int) bool {
+		return accessed[i].firstAccessTime() < accessed[j].firstAccessTime()
+	})
+	names := make([]string, 0, len(accessed))
+	for _, record := range accessed {
+		names = append(names, record.Path)
+	}
+	return names, nil
+}
+
+func stargzTraceFiles(data []byte) ([]string, error) {
+	var names []string
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var record stargzRecord
+		if err := decoder.Decode(&record); err == io.EOF {
+			return names, nil
+		} else if err != nil {
+			return nil, err
+		}
+		names = append(names, record.Name)
+	}
+}
+
+func csvTraceFiles(data []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for idx, record := range records {
+		// Skip the header like "path,size,elapsed".
+		if idx == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "path") {
+			continue
+		}
+		names = append(names, record[0])
+	}
+	return names, nil
+}
+
+// accessedFile is a regular file accessed in the traces.
+type accessedFile struct {
+	path string
+	// offset is the first access time relative to the start of trace.
+	offset uint64
+	// reads is the total read count across traces.
+	reads uint64
+}
+
+// accessedFiles returns the regular files accessed in the traces ordered by
+// their first access time, so that the chunks of files are placed in the
+// prefetch blob in access order. The access time is relative to the first
+// access of each trace, and the earliest one wins if the file is accessed
+// in multiple traces.
+func accessedFiles(traces [][]AccessRecord, files []rafs.File) []accessedFile {
+	nids := map[uint64]string{}
+	regulars := map[string]bool{}
+	for _, file := range files {
+		// Only the regular files with data are prefetched.
+		if file.Chunks > 0 {
+			if _, ok := nids[file.Nid]; !ok {
+				nids[file.Nid] = file.Path
+			}
+			regulars[file.Path] = true
+		}
+	}
+
+	accessed := map[string]*accessedFile{}
+	unknown := 0
+	for _, records := range traces {
+		start := uint64(math.MaxUint64)
+		for _, record := range records {
+			if record.NrRead > 0 && record.firstAccessTime() > 0 && record.firstAccessTime() < start {
+				start = record.firstAccessTime()
+			}
+		}
+		for _, record := range records {
+			if record.NrRead == 0 || record.firstAccessTime() == 0 {
+				continue
+			}
+			path := record.Path
+			if path == "" {
+				path = nids[record.Ino]
+			} else if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+			if !regulars[path] {
+				unknown++
+				continue
+			}
+			offset := record.firstAccessTime() - start
+			if file, ok := accessed[path]; !ok {
+				accessed[path] = &accessedFile{path: path, offset: offset, reads: record.NrRead}
+			} else {
+				file.reads += record.NrRead
+				if offset < file.offset {
+					file.offset = offset
+				}
+			}
+		}
+	}
+	if unknown > 0 {
+		logrus.Warnf("skip %d access records of unknown or non-regular files", unknown)
+	}
+
+	result := make([]accessedFile, 0, len(accessed))
+	for _, file := range accessed {
+		result = append(result, *file)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].offset != result[j].offset {
+			return result[i].offset < result[j].offset
+		}
+		return result[i].path < result[j].path
+	})
+	return result
+}
+
+// prefetchFilesFromTraces returns the paths of regular files accessed in the
+// traces ordered by their first access time.
+func prefetchFilesFromTraces(traces [][]AccessRecord, files []rafs.File) []string {
+	return filePaths(accessedFiles(traces, files))
+}
+
+func filePaths(files []accessedFile) []string {
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.path)
+	}
+	return paths
+}
+
+// dataFiles returns the file of path, or the files under path if it's a
+// directory, files must be sorted by path.
+func dataFiles(files []rafs.File, path string) []rafs.File {
+	idx := sort.Search(len(files), func(i int) bool { return files[i].Path >= path })
+	if idx == len(files) || files[idx].Path != path {
+		return nil
+	}
+	if !files[idx].IsDir() {
+		return files[idx : idx+1]
+	}
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	end := idx + 1
+	for end < len(files) && strings.HasPrefix(files[end].Path, prefix) {
+		end++
+	}
+	return files[idx+1 : end]
+}
+
+// limitHotFiles selects the most frequently read files (the earlier one in
+// accessed wins for the same read count) whose data fits in maxSize, and
+// returns them in the original order of accessed. The size of file is the
+// compressed size of its chunks not selected yet, or the file size if the
+// chunks are not recorded in bootstrap.
+func limitHotFiles(accessed []accessedFile, files []rafs.File, maxSize uint64) []accessedFile {
+	ranked := make([]int, len(accessed))
+	for idx := range ranked {
+		ranked[idx] = idx
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return accessed[ranked[i]].reads > accessed[ranked[j]].reads
+	})
+
+	selected := make([]bool, len(accessed))
+	chunks := map[[32]byte]bool{}
+	total := uint64(0)
+	for _, idx := range ranked {
+		targets := dataFiles(files, accessed[idx].path)
+		if len(targets) == 0 {
+			continue
+		}
+		size := uint64(0)
+		newChunks := map[[32]byte]bool{}
+		for _, file := range targets {
+			if file.Chunks == 0 {
+				continue
+			}
+			if len(file.ChunkInfos) == 0 {
+				size += file.Size
+				continue
+			}
+			for _, chunk := range file.ChunkInfos {
+				if !chunks[chunk.Digest] && !newChunks[chunk.Digest] {
+					size += uint64(chunk.CompressedSize)
+					newChunks[chunk.Digest] = true
+				}
+			}
+		}
+		// Skip the file exceeding the budget, the colder but smaller
+		// files may still fit.
+		if total+size > maxSize {
+			continue
+		}
+		total += size
+		for digest := range newChunks {
+			chunks[digest] = true
+		}
+		selected[idx] = true
+	}
+
+	result := []accessedFile{}
+	for idx, file := range accessed {
+		if selected[idx] {
+			result = append(result, file)
+		}
+	}
+	logrus.Infof("selected %d of %d prefetch files within hot blob size %d, total %d", len(result), len(accessed), maxSize, total)
+	return result
+}
+
+// generatePrefetchFiles writes the prefetch files ordered by the access
+// traces, which is consumed by `nydus-image optimize --prefetch-files`.
+func generatePrefetchFiles(bootstrapPath string, tracePaths []string, outputPath string, maxSize uint64) error {
+	traces := [][]AccessRecord{}
+	for _, path := range tracePaths {
+		records, err := LoadAccessTrace(path)
+		if err != nil {
+			return err
+		}
+		traces = append(traces, records)
+	}
+	return writePrefetchFiles(bootstrapPath, traces, outputPath, maxSize)
+}
+
+// writePrefetchFiles maps the access records to the files in bootstrap and
+// writes them to outputPath ordered by first access, only the hottest files
+// within maxSize are written if maxSize is not zero.
+func writePrefetchFiles(bootstrapPath string, traces [][]AccessRecord, outputPath string, maxSize uint64) error {
+	bootstrap, err := rafs.Load(bootstrapPath)
+	if err != nil {
+		return errors.Wrap(err, "load bootstrap, access trace requires RAFS v6")
+	}
+
+	files := accessedFiles(traces, bootstrap.Files)
+	if maxSize > 0 {
+		files = limitHotFiles(files, bootstrap.Files, maxSize)
+	}
+	if len(files) == 0 {
+		return errors.New("no file of image is accessed in access traces")
+	}
+	logrus.Infof("generated %d prefetch files ordered by first access", len(files))
+
+	return os.WriteFile(outputPath, []byte(strings.Join(filePaths(files), "\n")+"\n"), 0644)
+}
+
+// limitPrefetchFiles writes the files in prefetchFilesPath within maxSize
+// to outputPath, the files listed earlier are hotter.
+func limitPrefetchFiles(bootstrapPath, prefetchFilesPath, outputPath string, maxSize uint64) error {
+	bootstrap, err := rafs.Load(bootstrapPath)
+	if err != nil {
+		return errors.Wrap(err, "load bootstrap, hot blob size requires RAFS v6")
+	}
+	data, err := os.ReadFile(prefetchFilesPath)
+	if err != nil {
+		return errors.Wrapf(err, "read prefetch files %s", prefetchFilesPath)
+	}
+
+	files := []accessedFile{}
+	for _, line := range strings.Split(string(data), "\n") {
+		path := strings.TrimSpace(line)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		files = append(files, accessedFile{path: path})
+	}
+	files = limitHotFiles(files, bootstrap.Files, maxSize)
+	if len(files) == 0 {
+		return errors.New("no prefetch file fits in hot blob size")
+	}
+
+	return os.WriteFile(outputPath, []byte(strings.Join(filePaths(files), "\n")+"\n"), 0644)
+}
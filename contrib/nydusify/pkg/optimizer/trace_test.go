@@ -0,0 +1,122 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func TestPrefetchFilesFromTraces(t *testing.T) {
+	files := []rafs.File{
+		{Path: "/", Nid: 36, Mode: 0040755},
+		{Path: "/bin", Nid: 40, Mode: 0040755},
+		{Path: "/bin/app", Nid: 44, Mode: 0100755, Size: 10, Chunks: 1},
+		{Path: "/etc/config", Nid: 48, Mode: 0100644, Size: 10, Chunks: 1},
+		{Path: "/lib/libc.so", Nid: 52, Mode: 0100755, Size: 10, Chunks: 1},
+		{Path: "/lib/libc.so.6", Nid: 52, Mode: 0100755, Size: 10, Chunks: 1},
+		{Path: "/data", Nid: 56, Mode: 0100644, Size: 10, Chunks: 1},
+	}
+
+	traces := [][]AccessRecord{
+		{
+			{Ino: 52, NrRead: 3, FirstAccessTimeSecs: 100, FirstAccessTimeNanos: 500},
+			{Ino: 44, NrRead: 1, FirstAccessTimeSecs: 100},
+			{Ino: 40, NrRead: 1, FirstAccessTimeSecs: 100, FirstAccessTimeNanos: 100},
+			{Ino: 56, NrRead: 0},
+			{Ino: 99, NrRead: 1, FirstAccessTimeSecs: 101},
+		},
+		{
+			{Path: "etc/config", NrRead: 1, FirstAccessTimeSecs: 2000, FirstAccessTimeNanos: 200},
+			{Path: "/bin/app", NrRead: 1, FirstAccessTimeSecs: 2000},
+		},
+	}
+	require.Equal(t, []string{"/bin/app", "/etc/config", "/lib/libc.so"}, prefetchFilesFromTraces(traces, files))
+	require.Empty(t, prefetchFilesFromTraces(nil, files))
+}
+
+func TestLoadAccessTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pattern.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"ino":44,"nr_read":2,"first_access_time_secs":1700000000,"first_access_time_nanos":12}]`), 0644))
+	records, err := LoadAccessTrace(path)
+	require.NoError(t, err)
+	require.Equal(t, []AccessRecord{{Ino: 44, NrRead: 2, FirstAccessTimeSecs: 1700000000, FirstAccessTimeNanos: 12}}, records)
+
+	require.NoError(t, os.WriteFile(path, []byte("invalid"), 0644))
+	_, err = LoadAccessTrace(path)
+	require.Error(t, err)
+}
+
+func TestLimitHotFiles(t *testing.T) {
+	shared := rafs.Chunk{Digest: [32]byte{1}, CompressedSize: 40}
+	files := []rafs.File{
+		{Path: "/", Nid: 36, Mode: 0040755},
+		{Path: "/bin", Nid: 40, Mode: 0040755},
+		{Path: "/bin/app", Nid: 44, Mode: 0100755, Size: 100, Chunks: 2, ChunkInfos: []rafs.Chunk{shared, {Digest: [32]byte{2}, CompressedSize: 30}}},
+		{Path: "/bin/tool", Nid: 48, Mode: 0100755, Size: 50, Chunks: 1, ChunkInfos: []rafs.Chunk{shared}},
+		{Path: "/data", Nid: 52, Mode: 0100644, Size: 200, Chunks: 1},
+		{Path: "/etc", Nid: 56, Mode: 0040755},
+		{Path: "/etc/config", Nid: 60, Mode: 0100644, Size: 10, Chunks: 1},
+	}
+
+	traces := [][]AccessRecord{{
+		{Ino: 52, NrRead: 1, FirstAccessTimeSecs: 1},
+		{Ino: 48, NrRead: 5, FirstAccessTimeSecs: 2},
+		{Ino: 44, NrRead: 3, FirstAccessTimeSecs: 3},
+		{Ino: 60, NrRead: 1, FirstAccessTimeSecs: 4},
+	}}
+	accessed := accessedFiles(traces, files)
+	require.Equal(t, []string{"/data", "/bin/tool", "/bin/app", "/etc/config"}, filePaths(accessed))
+
+	// The shared chunk of /bin/tool is counted once, /data is too large.
+	require.Equal(t, []string{"/bin/tool", "/bin/app", "/etc/config"}, filePaths(limitHotFiles(accessed, files, 80)))
+	require.Equal(t, []string{"/bin/tool"}, filePaths(limitHotFiles(accessed, files, 45)))
+	require.Empty(t, limitHotFiles(accessed, files, 5))
+
+	// The files listed earlier are hotter, the directory includes its files.
+	listed := []accessedFile{{path: "/etc"}, {path: "/bin"}, {path: "/not-found"}, {path: "/data"}}
+	require.Equal(t, []string{"/etc", "/bin"}, filePaths(limitHotFiles(listed, files, 100)))
+	require.Equal(t, []string{"/etc"}, filePaths(limitHotFiles(listed, files, 60)))
+}
+
+func TestLoadPrefetchTraces(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	nydusd := write("pattern.json", `[
+		{"ino":44,"path":"/lib/libc.so.6","nr_read":2,"first_access_time_secs":101},
+		{"ino":48,"path":"bin/app","nr_read":1,"first_access_time_secs":100},
+		{"ino":52,"nr_read":1,"first_access_time_secs":100},
+		{"ino":56,"path":"/data","nr_read":0}
+	]`)
+	stargz := write("record.json", "{\"name\":\"etc/config\"}\n{\"name\":\"./bin/app\",\"layerIndex\":1}\n")
+	fanotify := write("trace.csv", "path,size,elapsed\n/usr/bin/python3,4096,10\n\"/app/main.py\",100,20\n")
+	list := write("list.txt", "/etc/hosts\n\n/etc/passwd\n")
+
+	files, err := LoadPrefetchTraces([]string{nydusd, stargz, fanotify, list})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/bin/app", "/lib/libc.so.6",
+		"/etc/config",
+		"/usr/bin/python3", "/app/main.py",
+		"/etc/hosts", "/etc/passwd",
+	}, files)
+
+	_, err = LoadPrefetchTraces([]string{write("inodes.json", `[{"ino":44,"nr_read":1}]`)})
+	require.ErrorContains(t, err, "no file path found")
+	_, err = LoadPrefetchTraces([]string{write("invalid.json", `{"name":`)})
+	require.ErrorContains(t, err, "invalid trace")
+	_, err = LoadPrefetchTraces([]string{filepath.Join(dir, "missing")})
+	require.Error(t, err)
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/inspector"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+// maxReportedPaths limits the paths in the error of verification.
+const maxReportedPaths = 10
+
+// chunkLocation is the compressed range of chunk in blob.
+type chunkLocation struct {
+	blobID string
+	offset uint64
+	size   uint32
+}
+
+func reportPaths(paths []string) string {
+	if len(paths) > maxReportedPaths {
+		return strings.Join(paths[:maxReportedPaths], ", ") + fmt.Sprintf(" and %d more", len(paths)-maxReportedPaths)
+	}
+	return strings.Join(paths, ", ")
+}
+
+// verifyBootstraps checks the optimized bootstrap has the same files and
+// chunk digests as the original one, and the chunks are either located in
+// the same range of original blobs or in the range of prefetch blob.
+func verifyBootstraps(original, optimized *rafs.Bootstrap, prefetchBlobID string, prefetchBlobSize uint64) error {
+	report := inspector.Diff(&inspector.Image{Bootstrap: original}, &inspector.Image{Bootstrap: optimized})
+	if len(report.Added) > 0 {
+		return fmt.Errorf("files are added: %s", reportPaths(report.Added))
+	}
+	if len(report.Removed) > 0 {
+		return fmt.Errorf("files are removed: %s", reportPaths(report.Removed))
+	}
+	if len(report.Changed) > 0 {
+		return fmt.Errorf("files are changed: %s", reportPaths(report.Changed))
+	}
+
+	locations := map[chunkLocation]bool{}
+	for _, file := range original.Files {
+		for _, chunk := range file.ChunkInfos {
+			if chunk.BlobIndex < uint32(len(original.Blobs)) {
+				locations[chunkLocation{
+					blobID: original.Blobs[chunk.BlobIndex].ID,
+					offset: chunk.CompressedOffset,
+					size:   chunk.CompressedSize,
+				}] = true
+			}
+		}
+	}
+
+	for _, file := range optimized.Files {
+		for _, chunk := range file.ChunkInfos {
+			if chunk.BlobIndex >= uint32(len(optimized.Blobs)) {
+				return fmt.Errorf("chunk %d of file %s has invalid blob index %d", chunk.Index, file.Path, chunk.BlobIndex)
+			}
+			location := chunkLocation{
+				blobID: optimized.Blobs[chunk.BlobIndex].ID,
+				offset: chunk.CompressedOffset,
+				size:   chunk.CompressedSize,
+			}
+			if location.blobID == prefetchBlobID {
+				if location.offset+uint64(location.size) > prefetchBlobSize {
+					return fmt.Errorf("chunk %d of file %s exceeds prefetch blob size %d", chunk.Index, file.Path, prefetchBlobSize)
+				}
+			} else if !locations[location] {
+				return fmt.Errorf("chunk %d of file %s is not found in blob %s of source image", chunk.Index, file.Path, location.blobID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifyOptimized checks the optimized image is content-identical to the
+// source image by comparing the metadata and chunks in bootstraps, and the
+// prefetch blob in blobDir.
+func verifyOptimized(originalBootstrapPath, optimizedBootstrapPath, blobDir, prefetchBlobID string) error {
+	original, err := rafs.Load(originalBootstrapPath)
+	if err != nil {
+		return errors.Wrap(err, "load source bootstrap, verification requires RAFS v6")
+	}
+	optimized, err := rafs.Load(optimizedBootstrapPath)
+	if err != nil {
+		return errors.Wrap(err, "load optimized bootstrap")
+	}
+	info, err := os.Stat(filepath.Join(blobDir, prefetchBlobID))
+	if err != nil {
+		return errors.Wrap(err, "stat prefetch blob")
+	}
+	return verifyBootstraps(original, optimized, prefetchBlobID, uint64(info.Size()))
+}
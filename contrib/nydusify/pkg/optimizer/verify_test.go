@@ -0,0 +1,56 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func TestVerifyBootstraps(t *testing.T) {
+	chunk := rafs.Chunk{Digest: [32]byte{1}, BlobIndex: 0, CompressedOffset: 100, CompressedSize: 50, UncompressedSize: 80}
+	original := &rafs.Bootstrap{
+		Blobs: []rafs.Blob{{ID: "blob"}},
+		Files: []rafs.File{
+			{Path: "/", Mode: 0040755},
+			{Path: "/app", Mode: 0100755, Size: 80, Chunks: 1, ChunkInfos: []rafs.Chunk{chunk}},
+			{Path: "/lib", Mode: 0100755, Size: 80, Chunks: 1, ChunkInfos: []rafs.Chunk{chunk}},
+		},
+	}
+	prefetchChunk := chunk
+	prefetchChunk.BlobIndex = 1
+	prefetchChunk.CompressedOffset = 0
+	optimized := func() *rafs.Bootstrap {
+		return &rafs.Bootstrap{
+			Blobs: []rafs.Blob{{ID: "blob"}, {ID: "prefetch"}},
+			Files: []rafs.File{
+				{Path: "/", Mode: 0040755},
+				{Path: "/app", Mode: 0100755, Size: 80, Chunks: 1, ChunkInfos: []rafs.Chunk{prefetchChunk}},
+				{Path: "/lib", Mode: 0100755, Size: 80, Chunks: 1, ChunkInfos: []rafs.Chunk{chunk}},
+			},
+		}
+	}
+	require.NoError(t, verifyBootstraps(original, optimized(), "prefetch", 50))
+	require.ErrorContains(t, verifyBootstraps(original, optimized(), "prefetch", 40), "exceeds prefetch blob size")
+
+	target := optimized()
+	target.Files = target.Files[:2]
+	require.ErrorContains(t, verifyBootstraps(original, target, "prefetch", 50), "files are removed: /lib")
+
+	target = optimized()
+	target.Files[2].Mode = 0100644
+	require.ErrorContains(t, verifyBootstraps(original, target, "prefetch", 50), "files are changed: /lib")
+
+	target = optimized()
+	target.Files[2].ChunkInfos = []rafs.Chunk{{Digest: [32]byte{1}, BlobIndex: 0, CompressedOffset: 200, CompressedSize: 50}}
+	require.ErrorContains(t, verifyBootstraps(original, target, "prefetch", 50), "not found in blob blob")
+
+	target = optimized()
+	target.Files[2].ChunkInfos = []rafs.Chunk{{Digest: [32]byte{1}, BlobIndex: 2}}
+	require.ErrorContains(t, verifyBootstraps(original, target, "prefetch", 50), "invalid blob index")
+}
@@ -40,6 +40,15 @@ func (a Artifact) blobFilePath(imageName string, isDigest bool) string {
 	return filepath.Join(a.OutputDir, imageName+".blob")
 }
 
+// statePath is the file recording the state of source directory at the last
+// build, which is used by incremental build.
+func (a Artifact) statePath(imageName string) string {
+	if suffix := filepath.Ext(imageName); suffix != "" {
+		return filepath.Join(a.OutputDir, strings.TrimSuffix(imageName, suffix)+".state.json")
+	}
+	return filepath.Join(a.OutputDir, imageName+".state.json")
+}
+
 func (a Artifact) outputJSONPath() string {
 	return filepath.Join(a.OutputDir, "output.json")
 }
@@ -99,3 +99,77 @@ func (cfg *S3BackendConfig) rawBlobBackendCfg() []byte {
 func (cfg *S3BackendConfig) backendType() string {
 	return "s3"
 }
+
+type AzblobBackendConfig struct {
+	Endpoint                string `json:"endpoint,omitempty"`
+	AccountName             string `json:"account_name,omitempty"`
+	AccountKey              string `json:"account_key,omitempty"`
+	SASToken                string `json:"sas_token,omitempty"`
+	ManagedIdentity         bool   `json:"managed_identity,omitempty"`
+	ManagedIdentityClientID string `json:"managed_identity_client_id,omitempty"`
+	ContainerName           string `json:"container_name"`
+	MetaPrefix              string `json:"meta_prefix"`
+	BlobPrefix              string `json:"blob_prefix"`
+}
+
+func (cfg *AzblobBackendConfig) rawBackendCfg(objectPrefix string) []byte {
+	azblobConfig := backend.AzblobConfig{
+		AccountName:             cfg.AccountName,
+		AccountKey:              cfg.AccountKey,
+		SASToken:                cfg.SASToken,
+		ManagedIdentity:         cfg.ManagedIdentity,
+		ManagedIdentityClientID: cfg.ManagedIdentityClientID,
+		Endpoint:                cfg.Endpoint,
+		ContainerName:           cfg.ContainerName,
+		ObjectPrefix:            objectPrefix,
+	}
+	b, _ := json.Marshal(azblobConfig)
+	return b
+}
+
+func (cfg *AzblobBackendConfig) rawMetaBackendCfg() []byte {
+	return cfg.rawBackendCfg(cfg.MetaPrefix)
+}
+
+func (cfg *AzblobBackendConfig) rawBlobBackendCfg() []byte {
+	return cfg.rawBackendCfg(cfg.BlobPrefix)
+}
+
+func (cfg *AzblobBackendConfig) backendType() string {
+	return "azblob"
+}
+
+type GCSBackendConfig struct {
+	Endpoint        string `json:"endpoint,omitempty"`
+	BucketName      string `json:"bucket_name"`
+	CredentialsFile string `json:"credentials_file,omitempty"`
+	CredentialsJSON string `json:"credentials_json,omitempty"`
+	Anonymous       bool   `json:"anonymous,omitempty"`
+	MetaPrefix      string `json:"meta_prefix"`
+	BlobPrefix      string `json:"blob_prefix"`
+}
+
+func (cfg *GCSBackendConfig) rawBackendCfg(objectPrefix string) []byte {
+	gcsConfig := backend.GCSConfig{
+		Endpoint:        cfg.Endpoint,
+		BucketName:      cfg.BucketName,
+		ObjectPrefix:    objectPrefix,
+		CredentialsFile: cfg.CredentialsFile,
+		CredentialsJSON: cfg.CredentialsJSON,
+		Anonymous:       cfg.Anonymous,
+	}
+	b, _ := json.Marshal(gcsConfig)
+	return b
+}
+
+func (cfg *GCSBackendConfig) rawMetaBackendCfg() []byte {
+	return cfg.rawBackendCfg(cfg.MetaPrefix)
+}
+
+func (cfg *GCSBackendConfig) rawBlobBackendCfg() []byte {
+	return cfg.rawBackendCfg(cfg.BlobPrefix)
+}
+
+func (cfg *GCSBackendConfig) backendType() string {
+	return "gcs"
+}
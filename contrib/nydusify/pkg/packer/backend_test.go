@@ -28,3 +28,33 @@ func TestS3BackendConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "s3", s3BackendConfig.backendType())
 }
+
+func TestAzblobBackendConfig(t *testing.T) {
+	azblobBackendConfig := &AzblobBackendConfig{
+		AccountName:   "test",
+		AccountKey:    "dGVzdEtleQ==",
+		ContainerName: "blobs",
+		MetaPrefix:    "meta",
+		BlobPrefix:    "blob",
+	}
+	_, err := backend.NewBackend("azblob", azblobBackendConfig.rawMetaBackendCfg(), nil)
+	require.NoError(t, err)
+	_, err = backend.NewBackend("azblob", azblobBackendConfig.rawBlobBackendCfg(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "azblob", azblobBackendConfig.backendType())
+}
+
+func TestGCSBackendConfig(t *testing.T) {
+	gcsBackendConfig := &GCSBackendConfig{
+		Endpoint:   "http://localhost:4443",
+		BucketName: "test",
+		Anonymous:  true,
+		MetaPrefix: "meta",
+		BlobPrefix: "blob",
+	}
+	_, err := backend.NewBackend("gcs", gcsBackendConfig.rawMetaBackendCfg(), nil)
+	require.NoError(t, err)
+	_, err = backend.NewBackend("gcs", gcsBackendConfig.rawBlobBackendCfg(), nil)
+	require.NoError(t, err)
+	require.Equal(t, "gcs", gcsBackendConfig.backendType())
+}
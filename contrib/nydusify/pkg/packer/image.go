@@ -0,0 +1,205 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/containerd/platforms"
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// blobLayer returns the layer descriptor of Nydus blob in output directory.
+func (p *Packer) blobLayer(blobID string) (ocispec.Descriptor, error) {
+	info, err := os.Stat(p.blobFilePath(blobID, true))
+	if err != nil {
+		return ocispec.Descriptor{}, errors.Wrapf(err, "failed to find blob %s in output directory", blobID)
+	}
+	blobDigest := digest.NewDigestFromEncoded(digest.SHA256, blobID)
+	return ocispec.Descriptor{
+		MediaType: utils.MediaTypeNydusBlob,
+		Digest:    blobDigest,
+		Size:      info.Size(),
+		Annotations: map[string]string{
+			utils.LayerAnnotationUncompressed: blobDigest.String(),
+			utils.LayerAnnotationNydusBlob:    "true",
+		},
+	}, nil
+}
+
+// bootstrapLayer packs the bootstrap into a tar.gz layer in output directory,
+// and returns the layer descriptor and the diff id of layer.
+func (p *Packer) bootstrapLayer(bootstrapPath, fsVersion string) (string, ocispec.Descriptor, digest.Digest, error) {
+	diffID, _, err := utils.PackTargzInfo(bootstrapPath, utils.BootstrapFileNameInLayer, false)
+	if err != nil {
+		return "", ocispec.Descriptor{}, "", errors.Wrap(err, "failed to calculate diff id of bootstrap layer")
+	}
+
+	reader, err := utils.PackTargz(bootstrapPath, utils.BootstrapFileNameInLayer, true)
+	if err != nil {
+		return "", ocispec.Descriptor{}, "", errors.Wrap(err, "failed to pack bootstrap layer")
+	}
+	defer reader.Close()
+	layerPath := bootstrapPath + ".tar.gz"
+	layer, err := os.Create(layerPath)
+	if err != nil {
+		return "", ocispec.Descriptor{}, "", errors.Wrap(err, "failed to create bootstrap layer")
+	}
+	defer layer.Close()
+	digester := digest.SHA256.Digester()
+	size, err := io.Copy(io.MultiWriter(layer, digester.Hash()), reader)
+	if err != nil {
+		return "", ocispec.Descriptor{}, "", errors.Wrap(err, "failed to write bootstrap layer")
+	}
+
+	return layerPath, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digester.Digest(),
+		Size:      size,
+		Annotations: map[string]string{
+			utils.LayerAnnotationNydusFsVersion: fsVersion,
+			utils.LayerAnnotationNydusBootstrap: "true",
+		},
+	}, diffID, nil
+}
+
+// pushContent pushes the content to registry, it's retried with plain HTTP
+// if the registry doesn't support HTTPS.
+func pushContent(ctx context.Context, remoter *remote.Remote, desc ocispec.Descriptor, byDigest bool, open func() (io.ReadCloser, error)) error {
+	push := func() error {
+		reader, err := open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return remoter.Push(ctx, desc, byDigest, reader)
+	}
+	err := push()
+	if err != nil && utils.RetryWithHTTP(err) {
+		remoter.MaybeWithHTTP(err)
+		err = push()
+	}
+	return err
+}
+
+func openFile(path string) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}
+}
+
+func openBytes(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
+
+// pushImage wraps the bootstrap and blobs into a Nydus image with generated
+// image config, and pushes it to registry. The blobs are not included in the
+// image if they are pushed to storage backend.
+func (p *Packer) pushImage(ctx context.Context, req PackRequest, bootstrapPath string, blobs []string) (string, error) {
+	platform := platforms.DefaultSpec()
+	if req.Platform != "" {
+		var err error
+		if platform, err = platforms.Parse(req.Platform); err != nil {
+			return "", errors.Wrapf(err, "invalid platform %s", req.Platform)
+		}
+	}
+
+	remoter, err := provider.DefaultRemote(req.OutputImage, req.OutputImageInsecure)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create remote")
+	}
+
+	layers := []ocispec.Descriptor{}
+	diffIDs := []digest.Digest{}
+	if !req.PushToRemote {
+		pushed := map[string]bool{}
+		for _, blobID := range blobs {
+			if pushed[blobID] {
+				continue
+			}
+			pushed[blobID] = true
+			desc, err := p.blobLayer(blobID)
+			if err != nil {
+				return "", err
+			}
+			p.logger.Infof("push blob layer %s", desc.Digest)
+			if err := pushContent(ctx, remoter, desc, true, openFile(p.blobFilePath(blobID, true))); err != nil {
+				return "", errors.Wrapf(err, "failed to push blob layer %s", desc.Digest)
+			}
+			layers = append(layers, desc)
+			diffIDs = append(diffIDs, desc.Digest)
+		}
+	}
+
+	layerPath, bootstrapDesc, bootstrapDiffID, err := p.bootstrapLayer(bootstrapPath, req.FsVersion)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(layerPath)
+	p.logger.Infof("push bootstrap layer %s", bootstrapDesc.Digest)
+	if err := pushContent(ctx, remoter, bootstrapDesc, true, openFile(layerPath)); err != nil {
+		return "", errors.Wrap(err, "failed to push bootstrap layer")
+	}
+	layers = append(layers, bootstrapDesc)
+	diffIDs = append(diffIDs, bootstrapDiffID)
+
+	created := time.Now().UTC()
+	config := ocispec.Image{
+		Created:  &created,
+		Platform: platform,
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal image config")
+	}
+	configDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageConfig,
+		Digest:    digest.FromBytes(configBytes),
+		Size:      int64(len(configBytes)),
+	}
+	if err := pushContent(ctx, remoter, configDesc, true, openBytes(configBytes)); err != nil {
+		return "", errors.Wrap(err, "failed to push image config")
+	}
+
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layers,
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal image manifest")
+	}
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+	if err := pushContent(ctx, remoter, manifestDesc, false, openBytes(manifestBytes)); err != nil {
+		return "", errors.Wrap(err, "failed to push image manifest")
+	}
+	p.logger.Infof("pushed Nydus image %s@%s", req.OutputImage, manifestDesc.Digest)
+
+	return req.OutputImage + "@" + manifestDesc.Digest.String(), nil
+}
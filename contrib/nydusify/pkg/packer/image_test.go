@@ -0,0 +1,44 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+func TestImageLayers(t *testing.T) {
+	tmpDir := t.TempDir()
+	p := &Packer{Artifact: Artifact{OutputDir: tmpDir}}
+
+	blobID := digest.FromString("blob").Encoded()
+	_, err := p.blobLayer(blobID)
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, blobID), []byte("blob"), 0644))
+	desc, err := p.blobLayer(blobID)
+	require.NoError(t, err)
+	require.Equal(t, utils.MediaTypeNydusBlob, desc.MediaType)
+	require.Equal(t, "sha256:"+blobID, desc.Digest.String())
+	require.Equal(t, int64(4), desc.Size)
+	require.Equal(t, "true", desc.Annotations[utils.LayerAnnotationNydusBlob])
+
+	bootstrapPath := filepath.Join(tmpDir, "app.meta")
+	require.NoError(t, os.WriteFile(bootstrapPath, []byte("bootstrap"), 0644))
+	layerPath, desc, diffID, err := p.bootstrapLayer(bootstrapPath, "6")
+	require.NoError(t, err)
+	layer, err := os.ReadFile(layerPath)
+	require.NoError(t, err)
+	require.Equal(t, digest.FromBytes(layer), desc.Digest)
+	require.Equal(t, int64(len(layer)), desc.Size)
+	require.Equal(t, "6", desc.Annotations[utils.LayerAnnotationNydusFsVersion])
+	require.Equal(t, "true", desc.Annotations[utils.LayerAnnotationNydusBootstrap])
+	require.NotEqual(t, desc.Digest, diffID)
+}
@@ -0,0 +1,425 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const whiteoutPrefix = ".wh."
+
+// fileState is the metadata of a file in source directory, which is recorded
+// to find out the changed files in the next build.
+type fileState struct {
+	Mode    uint32 `json:"mode"`
+	UID     uint32 `json:"uid"`
+	GID     uint32 `json:"gid"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Rdev    uint64 `json:"rdev,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+func (s fileState) isDir() bool {
+	return s.Mode&syscall.S_IFMT == syscall.S_IFDIR
+}
+
+func (s fileState) isReg() bool {
+	return s.Mode&syscall.S_IFMT == syscall.S_IFREG
+}
+
+// unchanged checks if the file is unchanged since the previous state, the
+// regular file only touched is unchanged if its content digest is the same.
+func (s fileState) unchanged(prev fileState) bool {
+	if s.Mode != prev.Mode || s.UID != prev.UID || s.GID != prev.GID ||
+		s.Size != prev.Size || s.Rdev != prev.Rdev || s.Link != prev.Link {
+		return false
+	}
+	if s.isReg() && s.Digest != "" {
+		return s.Digest == prev.Digest
+	}
+	return s.ModTime == prev.ModTime
+}
+
+// buildState is the state of source directory at the last build.
+type buildState struct {
+	SourceDir string               `json:"source_dir"`
+	Files     map[string]fileState `json:"files"`
+}
+
+func loadBuildState(path string) (*buildState, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	state := buildState{}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, errors.Wrapf(err, "invalid build state %s", path)
+	}
+	return &state, nil
+}
+
+func (state *buildState) save(path string) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// scanSourceDir collects the state of files in source directory, the content
+// digest of regular file is reused from the previous state if the metadata
+// isn't changed.
+func scanSourceDir(root string, prev map[string]fileState) (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := filepath.WalkDir(root, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return errors.Errorf("unsupported file stat of %s", path)
+		}
+		state := fileState{
+			Mode:    stat.Mode,
+			UID:     stat.Uid,
+			GID:     stat.Gid,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UnixNano(),
+			Rdev:    uint64(stat.Rdev),
+		}
+		if state.isDir() {
+			state.Size = 0
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if state.Link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		if state.isReg() {
+			if old, ok := prev[rel]; ok && old.Mode == state.Mode && old.Size == state.Size && old.ModTime == state.ModTime {
+				state.Digest = old.Digest
+			} else if state.Digest, err = fileDigest(path); err != nil {
+				return errors.Wrapf(err, "calculate digest of %s", path)
+			}
+		}
+		files[rel] = state
+		return nil
+	})
+	return files, err
+}
+
+// diffStates returns the files added or changed, and the top most files
+// deleted since the previous state.
+func diffStates(prev, current map[string]fileState) ([]string, []string) {
+	changed := []string{}
+	for path, state := range current {
+		if old, ok := prev[path]; !ok || !state.unchanged(old) {
+			changed = append(changed, path)
+		}
+	}
+	deleted := []string{}
+	for path := range prev {
+		if _, ok := current[path]; ok {
+			continue
+		}
+		if parent, ok := current[filepath.Dir(path)]; !ok || !parent.isDir() {
+			// The parent directory is deleted or replaced too.
+			continue
+		}
+		deleted = append(deleted, path)
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return changed, deleted
+}
+
+// stager links the changed files of source directory into the staging
+// directory, which is built as an upper layer on the parent bootstrap.
+type stager struct {
+	root    string
+	dir     string
+	files   map[string]fileState
+	created map[string]bool
+}
+
+func (s *stager) setAttrs(target string, state fileState) error {
+	if err := os.Lchown(target, int(state.UID), int(state.GID)); err != nil && !os.IsPermission(err) {
+		return err
+	}
+	if state.Link != "" {
+		return nil
+	}
+	if err := os.Chmod(target, os.FileMode(state.Mode&0777)|modeBits(state.Mode)); err != nil {
+		return err
+	}
+	mtime := time.Unix(0, state.ModTime)
+	return os.Chtimes(target, mtime, mtime)
+}
+
+func modeBits(mode uint32) os.FileMode {
+	var bits os.FileMode
+	if mode&syscall.S_ISUID != 0 {
+		bits |= os.ModeSetuid
+	}
+	if mode&syscall.S_ISGID != 0 {
+		bits |= os.ModeSetgid
+	}
+	if mode&syscall.S_ISVTX != 0 {
+		bits |= os.ModeSticky
+	}
+	return bits
+}
+
+// mkdir creates the directory and its parents in staging directory.
+func (s *stager) mkdir(rel string) error {
+	if s.created[rel] {
+		return nil
+	}
+	if rel != "." {
+		if err := s.mkdir(filepath.Dir(rel)); err != nil {
+			return err
+		}
+		if err := os.Mkdir(filepath.Join(s.dir, rel), 0755); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+	s.created[rel] = true
+	return nil
+}
+
+// copy creates the file in staging directory, it's hard linked if possible,
+// otherwise copied without the extended attributes.
+func (s *stager) copy(rel string, state fileState) error {
+	source := filepath.Join(s.root, rel)
+	target := filepath.Join(s.dir, rel)
+	if err := os.Link(source, target); err == nil {
+		return nil
+	}
+
+	switch {
+	case state.Link != "":
+		if err := os.Symlink(state.Link, target); err != nil {
+			return err
+		}
+	case state.isReg():
+		if err := copyRegularFile(source, target); err != nil {
+			return err
+		}
+	default:
+		if err := syscall.Mknod(target, state.Mode, int(state.Rdev)); err != nil {
+			return err
+		}
+	}
+	return s.setAttrs(target, state)
+}
+
+func copyRegularFile(source, target string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// stage links the changed files and creates the OCI whiteouts of deleted
+// files in staging directory.
+func (s *stager) stage(changed, deleted []string) error {
+	for _, rel := range changed {
+		state := s.files[rel]
+		if state.isDir() {
+			if err := s.mkdir(rel); err != nil {
+				return errors.Wrapf(err, "create directory %s", rel)
+			}
+			continue
+		}
+		if err := s.mkdir(filepath.Dir(rel)); err != nil {
+			return errors.Wrapf(err, "create directory %s", filepath.Dir(rel))
+		}
+		if err := s.copy(rel, state); err != nil {
+			return errors.Wrapf(err, "stage file %s", rel)
+		}
+	}
+
+	for _, rel := range deleted {
+		parent := filepath.Dir(rel)
+		if err := s.mkdir(parent); err != nil {
+			return errors.Wrapf(err, "create directory %s", parent)
+		}
+		whiteout := filepath.Join(s.dir, parent, whiteoutPrefix+filepath.Base(rel))
+		if err := os.WriteFile(whiteout, nil, 0644); err != nil {
+			return errors.Wrapf(err, "create whiteout of %s", rel)
+		}
+	}
+
+	// Set the attributes of directories after their children are created,
+	// the children (longer paths) first.
+	dirs := make([]string, 0, len(s.created))
+	for rel := range s.created {
+		dirs = append(dirs, rel)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, rel := range dirs {
+		if err := s.setAttrs(filepath.Join(s.dir, rel), s.files[rel]); err != nil {
+			return errors.Wrapf(err, "set attributes of directory %s", rel)
+		}
+	}
+
+	return nil
+}
+
+// incrementalBuild is the incremental build of a source directory, only the
+// files changed since the last build are built into a new blob with the last
+// built bootstrap as parent.
+type incrementalBuild struct {
+	statePath     string
+	bootstrapPath string
+	parentPath    string
+	stagingDir    string
+	state         *buildState
+	// unchanged is true if nothing is changed since the last build.
+	unchanged bool
+}
+
+// prepareIncremental finds out the changes of source directory since the
+// last build, and points the request to the staged changes and the parent
+// bootstrap. The source directory is built as a whole if it isn't built
+// before.
+func (p *Packer) prepareIncremental(req *PackRequest) (*incrementalBuild, error) {
+	sourceDir, err := filepath.Abs(req.SourceDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "get absolute path of source directory")
+	}
+	inc := &incrementalBuild{
+		statePath:     p.statePath(req.ImageName),
+		bootstrapPath: p.bootstrapPath(req.ImageName),
+		parentPath:    p.bootstrapPath(req.ImageName) + ".parent",
+		stagingDir:    p.bootstrapPath(req.ImageName) + ".staging",
+	}
+
+	prev, err := loadBuildState(inc.statePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "load build state")
+	}
+	if prev != nil && prev.SourceDir != sourceDir {
+		p.logger.Warnf("the last build is from source directory %q, rebuild all files", prev.SourceDir)
+		prev = nil
+	}
+	if _, err := os.Stat(inc.bootstrapPath); prev != nil && err != nil {
+		p.logger.Warnf("the last built bootstrap %s isn't found, rebuild all files", inc.bootstrapPath)
+		prev = nil
+	}
+	var prevFiles map[string]fileState
+	if prev != nil {
+		prevFiles = prev.Files
+	}
+
+	files, err := scanSourceDir(sourceDir, prevFiles)
+	if err != nil {
+		return nil, errors.Wrapf(err, "scan source directory %s", sourceDir)
+	}
+	inc.state = &buildState{SourceDir: sourceDir, Files: files}
+	if prev == nil {
+		p.logger.Infof("build all %d files of source directory", len(files))
+		return inc, nil
+	}
+
+	changed, deleted := diffStates(prev.Files, files)
+	if len(changed) == 0 && len(deleted) == 0 {
+		inc.unchanged = true
+		return inc, nil
+	}
+	p.logger.Infof("build %d changed and %d deleted files since the last build", len(changed), len(deleted))
+
+	if err := os.RemoveAll(inc.stagingDir); err != nil {
+		return nil, errors.Wrap(err, "clean staging directory")
+	}
+	if err := os.MkdirAll(inc.stagingDir, 0755); err != nil {
+		return nil, errors.Wrap(err, "create staging directory")
+	}
+	s := &stager{root: sourceDir, dir: inc.stagingDir, files: files, created: map[string]bool{}}
+	if err := s.stage(changed, deleted); err != nil {
+		os.RemoveAll(inc.stagingDir)
+		return nil, errors.Wrap(err, "stage changed files")
+	}
+
+	// Keep the last built bootstrap as parent, which is restored if the build
+	// fails.
+	if err := os.Rename(inc.bootstrapPath, inc.parentPath); err != nil {
+		os.RemoveAll(inc.stagingDir)
+		return nil, errors.Wrap(err, "keep parent bootstrap")
+	}
+	req.SourceDir = inc.stagingDir
+	req.Parent = inc.parentPath
+
+	return inc, nil
+}
+
+// finish saves the state of source directory if the build succeeds, or
+// restores the last built bootstrap otherwise.
+func (inc *incrementalBuild) finish(buildErr error) error {
+	defer os.RemoveAll(inc.stagingDir)
+	if _, err := os.Stat(inc.parentPath); err == nil {
+		if buildErr != nil {
+			return os.Rename(inc.parentPath, inc.bootstrapPath)
+		}
+		if err := os.Remove(inc.parentPath); err != nil {
+			return err
+		}
+	}
+	if buildErr != nil {
+		return nil
+	}
+	return inc.state.save(inc.statePath)
+}
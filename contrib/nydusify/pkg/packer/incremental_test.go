@@ -0,0 +1,89 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementalChanges(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir/sub"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "keep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "dir/sub/file"), []byte("file"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "keep/modified"), []byte("old"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "keep/touched"), []byte("touched"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "keep/removed"), []byte("removed"), 0644))
+	require.NoError(t, os.Symlink("modified", filepath.Join(root, "keep/link")))
+
+	prev, err := scanSourceDir(root, nil)
+	require.NoError(t, err)
+	require.Len(t, prev, 9)
+	require.NotEmpty(t, prev["keep/touched"].Digest)
+	require.Equal(t, "modified", prev["keep/link"].Link)
+
+	changed, deleted := diffStates(prev, prev)
+	require.Empty(t, changed)
+	require.Empty(t, deleted)
+
+	mtime := time.Now().Add(time.Hour)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "keep/modified"), []byte("new"), 0644))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "keep/modified"), mtime, mtime))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "keep/touched"), mtime, mtime))
+	require.NoError(t, os.Remove(filepath.Join(root, "keep/removed")))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "keep"), mtime, mtime))
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "dir")))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "new"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new/file"), []byte("new"), 0600))
+	require.NoError(t, os.Chtimes(root, mtime, mtime))
+
+	current, err := scanSourceDir(root, prev)
+	require.NoError(t, err)
+	changed, deleted = diffStates(prev, current)
+	require.Equal(t, []string{".", "keep", "keep/modified", "new", "new/file"}, changed)
+	require.Equal(t, []string{"dir", "keep/removed"}, deleted)
+
+	staging := t.TempDir()
+	s := &stager{root: root, dir: staging, files: current, created: map[string]bool{}}
+	require.NoError(t, s.stage(changed, deleted))
+
+	content, err := os.ReadFile(filepath.Join(staging, "keep/modified"))
+	require.NoError(t, err)
+	require.Equal(t, "new", string(content))
+	info, err := os.Stat(filepath.Join(staging, "new/file"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.FileExists(t, filepath.Join(staging, ".wh.dir"))
+	require.FileExists(t, filepath.Join(staging, "keep/.wh.removed"))
+	require.NoFileExists(t, filepath.Join(staging, "keep/touched"))
+	require.NoFileExists(t, filepath.Join(staging, "keep/link"))
+	info, err = os.Stat(filepath.Join(staging, "keep"))
+	require.NoError(t, err)
+	require.Equal(t, mtime.Unix(), info.ModTime().Unix())
+}
+
+func TestBuildState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.state.json")
+	state, err := loadBuildState(path)
+	require.NoError(t, err)
+	require.Nil(t, state)
+
+	state = &buildState{
+		SourceDir: "/path/to/source",
+		Files:     map[string]fileState{"file": {Mode: 0100644, Size: 4, Digest: "abc"}},
+	}
+	require.NoError(t, state.save(path))
+	loaded, err := loadBuildState(path)
+	require.NoError(t, err)
+	require.Equal(t, state, loaded)
+
+	require.Equal(t, "/out/app.state.json", Artifact{OutputDir: "/out"}.statePath("app.meta"))
+	require.Equal(t, "/out/app.state.json", Artifact{OutputDir: "/out"}.statePath("app"))
+}
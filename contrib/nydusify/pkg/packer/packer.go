@@ -3,12 +3,14 @@ package packer
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/builder"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/compactor"
 
@@ -32,6 +34,9 @@ type Opt struct {
 	NydusImagePath string
 	OutputDir      string
 	BackendConfig  BackendConfig
+	// NativeBuilder builds image with the built-in RAFS v6 builder instead
+	// of nydus-image binary.
+	NativeBuilder bool
 }
 
 type Builder interface {
@@ -44,6 +49,8 @@ type Packer struct {
 	BackendConfig  BackendConfig
 	pusher         *Pusher
 	builder        Builder
+	nativeBuilder  bool
+	stdin          io.Reader
 	Artifact
 }
 
@@ -59,15 +66,34 @@ type PackRequest struct {
 	ChunkSize    string
 	PushToRemote bool
 
+	// SourceTar is the (gzip compressed) tar file to build from instead of
+	// SourceDir, or StdinSource to read the tar stream from stdin.
+	SourceTar string
+
 	ChunkDict         string
 	Parent            string
 	TryCompact        bool
 	CompactConfigPath string
+
+	// Incremental builds only the files changed since the last build of
+	// the image name into a new blob, with the last built bootstrap as
+	// parent. The state of source directory is kept in output directory.
+	Incremental bool
+
+	// OutputImage is the reference of Nydus image wrapping the bootstrap
+	// and blobs to be pushed to registry, the blobs are not pushed if they
+	// are pushed to storage backend. Platform is the platform in image
+	// config, default to the current platform.
+	OutputImage         string
+	OutputImageInsecure bool
+	Platform            string
 }
 
 type PackResult struct {
 	Meta string
 	Blob string
+	// Image is the reference of pushed Nydus image with manifest digest.
+	Image string
 }
 
 func New(opt Opt) (*Packer, error) {
@@ -84,11 +110,17 @@ func New(opt Opt) (*Packer, error) {
 		BackendConfig:  opt.BackendConfig,
 		logger:         logger,
 		nydusImagePath: opt.NydusImagePath,
+		nativeBuilder:  opt.NativeBuilder,
+		stdin:          os.Stdin,
 	}
-	if err = p.ensureNydusImagePath(); err != nil {
-		return nil, err
+	if p.nativeBuilder {
+		p.builder = builder.New()
+	} else {
+		if err = p.ensureNydusImagePath(); err != nil {
+			return nil, err
+		}
+		p.builder = build.NewBuilder(p.nydusImagePath)
 	}
-	p.builder = build.NewBuilder(p.nydusImagePath)
 	if p.BackendConfig != nil {
 		p.pusher, err = NewPusher(NewPusherOpt{
 			Artifact:      artifact,
@@ -227,8 +259,52 @@ func (p *Packer) tryCompactParent(req *PackRequest) error {
 	return nil
 }
 
-func (p *Packer) Pack(_ context.Context, req PackRequest) (PackResult, error) {
-	p.logger.Infof("start to build image from source directory %q", req.SourceDir)
+func (p *Packer) Pack(ctx context.Context, req PackRequest) (_ PackResult, retErr error) {
+	if req.SourceTar != "" {
+		p.logger.Infof("start to build image from source tar %q", req.SourceTar)
+	} else {
+		p.logger.Infof("start to build image from source directory %q", req.SourceDir)
+	}
+	if p.nativeBuilder && (req.Parent != "" || req.ChunkDict != "" || req.TryCompact || req.Incremental || req.SourceTar != "") {
+		return PackResult{}, errors.New("parent bootstrap, chunk-dict, compact, incremental build and source tar are not supported by native builder")
+	}
+	if req.Incremental && req.Parent != "" {
+		return PackResult{}, errors.New("parent bootstrap can't be specified for incremental build")
+	}
+	if req.SourceTar != "" && (req.SourceDir != "" || req.Incremental) {
+		return PackResult{}, errors.New("source tar conflicts with source directory and incremental build")
+	}
+	var sourceType string
+	if req.SourceTar != "" {
+		sourcePath, _sourceType, cleanup, err := p.prepareTarSource(req)
+		if err != nil {
+			return PackResult{}, err
+		}
+		defer cleanup()
+		req.SourceDir = sourcePath
+		sourceType = _sourceType
+	}
+	if !req.Incremental {
+		// The state is stale once the image is rebuilt as a whole.
+		os.Remove(p.statePath(req.ImageName))
+	} else {
+		inc, err := p.prepareIncremental(&req)
+		if err != nil {
+			return PackResult{}, errors.Wrap(err, "failed to prepare incremental build")
+		}
+		if inc.unchanged {
+			p.logger.Infof("no changes in source directory %q since the last build", req.SourceDir)
+			return p.packUnchanged(ctx, req)
+		}
+		defer func() {
+			if err := inc.finish(retErr); err != nil {
+				p.logger.WithError(err).Errorf("failed to finish incremental build")
+				if retErr == nil {
+					retErr = errors.Wrap(err, "failed to save build state")
+				}
+			}
+		}()
+	}
 	if err := p.tryCompactParent(&req); err != nil {
 		return PackResult{}, err
 	}
@@ -249,12 +325,13 @@ func (p *Packer) Pack(_ context.Context, req PackRequest) (PackResult, error) {
 		BlobPath:            blobPath,
 		OutputJSONPath:      p.outputJSONPath(),
 		RootfsPath:          req.SourceDir,
+		SourceType:          sourceType,
 		WhiteoutSpec:        "oci",
 		Compressor:          req.Compressor,
 		ChunkSize:           req.ChunkSize,
 		FsVersion:           req.FsVersion,
 	}); err != nil {
-		return PackResult{}, errors.Wrapf(err, "failed to build image from directory %s", req.SourceDir)
+		return PackResult{}, errors.Wrapf(err, "failed to build image from %s", req.SourceDir)
 	}
 	newBlobHash, err := p.getNewBlobsHash(append(parentBlobs, chunkDictBlobs...))
 	if err != nil {
@@ -263,7 +340,7 @@ func (p *Packer) Pack(_ context.Context, req PackRequest) (PackResult, error) {
 	if newBlobHash == "" {
 		blobPath = ""
 	} else {
-		if req.Parent != "" || req.PushToRemote {
+		if req.Parent != "" || req.PushToRemote || req.Incremental || req.OutputImage != "" {
 			p.logger.Infof("rename blob file into sha256 csum")
 			newBlobName := p.blobFilePath(newBlobHash, true)
 			if err = os.Rename(blobPath, newBlobName); err != nil {
@@ -272,21 +349,62 @@ func (p *Packer) Pack(_ context.Context, req PackRequest) (PackResult, error) {
 			blobPath = newBlobName
 		}
 	}
-	if !req.PushToRemote {
-		// if we don't need to push meta and blob to remote, just return the local build artifact
-		return PackResult{
-			Meta: bootstrapPath,
-			Blob: blobPath,
-		}, nil
+	// the local build artifact is returned if we don't need to push meta and blob to remote
+	result := PackResult{
+		Meta: bootstrapPath,
+		Blob: blobPath,
+	}
+	if req.PushToRemote {
+		if result, err = p.push(req.ImageName, newBlobHash, parentBlobs); err != nil {
+			return PackResult{}, err
+		}
+	}
+	if req.OutputImage != "" {
+		blobs := append(append([]string{}, parentBlobs...), chunkDictBlobs...)
+		if newBlobHash != "" {
+			blobs = append(blobs, newBlobHash)
+		}
+		if result.Image, err = p.pushImage(ctx, req, bootstrapPath, blobs); err != nil {
+			return PackResult{}, err
+		}
 	}
 
+	return result, nil
+}
+
+// packUnchanged returns the last build result as nothing is changed, which
+// is pushed again if required.
+func (p *Packer) packUnchanged(ctx context.Context, req PackRequest) (PackResult, error) {
+	bootstrapPath := p.bootstrapPath(req.ImageName)
+	if !req.PushToRemote && req.OutputImage == "" {
+		return PackResult{Meta: bootstrapPath}, nil
+	}
+	blobs, err := p.getBlobsFromBootstrap(bootstrapPath)
+	if err != nil {
+		return PackResult{}, errors.Wrap(err, "failed to get blobs from bootstrap")
+	}
+	result := PackResult{Meta: bootstrapPath}
+	if req.PushToRemote {
+		if result, err = p.push(req.ImageName, "", blobs); err != nil {
+			return PackResult{}, err
+		}
+	}
+	if req.OutputImage != "" {
+		if result.Image, err = p.pushImage(ctx, req, bootstrapPath, blobs); err != nil {
+			return PackResult{}, err
+		}
+	}
+	return result, nil
+}
+
+func (p *Packer) push(imageName, blob string, parentBlobs []string) (PackResult, error) {
 	// if pusher is empty, that means backend config is not provided
 	if p.pusher == nil {
 		return PackResult{}, errors.New("can not push image to remote due to lack of backend configuration")
 	}
 	pushResult, err := p.pusher.Push(PushRequest{
-		Meta:        req.ImageName,
-		Blob:        newBlobHash,
+		Meta:        imageName,
+		Blob:        blob,
 		ParentBlobs: parentBlobs,
 	})
 	if err != nil {
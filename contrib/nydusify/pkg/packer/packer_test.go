@@ -47,6 +47,13 @@ func TestNew(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "failed to find nydus-image binary")
 
+	_, err = New(Opt{
+		LogLevel:      logrus.InfoLevel,
+		OutputDir:     tmpDir,
+		NativeBuilder: true,
+	})
+	require.NoError(t, err)
+
 	_, err = New(Opt{
 		LogLevel:       logrus.InfoLevel,
 		OutputDir:      "nil",
@@ -207,6 +214,37 @@ func TestPack(t *testing.T) {
 	}, res)
 }
 
+func TestPackNative(t *testing.T) {
+	tmpDir, tearDown := setUpTmpDir(t)
+	defer tearDown()
+	p, err := New(Opt{
+		LogLevel:      logrus.InfoLevel,
+		OutputDir:     tmpDir,
+		NativeBuilder: true,
+	})
+	require.NoError(t, err)
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "file"), []byte("nydus"), 0644))
+	res, err := p.Pack(context.Background(), PackRequest{
+		SourceDir: sourceDir,
+		ImageName: "native.meta",
+		FsVersion: "6",
+		ChunkSize: "0x100000",
+	})
+	require.NoError(t, err)
+	require.FileExists(t, res.Meta)
+	require.FileExists(t, res.Blob)
+
+	_, err = p.Pack(context.Background(), PackRequest{
+		SourceDir: sourceDir,
+		ImageName: "native.meta",
+		Parent:    res.Meta,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not supported by native builder")
+}
+
 func TestPusher_getBlobHash(t *testing.T) {
 	artifact, err := NewArtifact("testdata")
 	require.NoError(t, err)
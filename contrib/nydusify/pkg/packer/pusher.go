@@ -142,6 +142,18 @@ func ParseBackendConfig(backendType, backendConfigFile string) (BackendConfig, e
 			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigFile)
 		}
 		return &cfg, nil
+	case "azblob":
+		var cfg AzblobBackendConfig
+		if err = json.NewDecoder(cfgFile).Decode(&cfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigFile)
+		}
+		return &cfg, nil
+	case "gcs":
+		var cfg GCSBackendConfig
+		if err = json.NewDecoder(cfgFile).Decode(&cfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigFile)
+		}
+		return &cfg, nil
 	default:
 		return nil, fmt.Errorf("unsupported backend type %s", backendType)
 	}
@@ -162,6 +174,18 @@ func ParseBackendConfigString(backendType, backendConfigContent string) (Backend
 			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigContent)
 		}
 		return &cfg, nil
+	case "azblob":
+		var cfg AzblobBackendConfig
+		if err := json.Unmarshal([]byte(backendConfigContent), &cfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigContent)
+		}
+		return &cfg, nil
+	case "gcs":
+		var cfg GCSBackendConfig
+		if err := json.Unmarshal([]byte(backendConfigContent), &cfg); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode backend-config %s", backendConfigContent)
+		}
+		return &cfg, nil
 	default:
 		return nil, fmt.Errorf("unsupported backend type %s", backendType)
 	}
@@ -0,0 +1,76 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// StdinSource is the source tar path to read the tar stream from stdin.
+	StdinSource = "-"
+
+	sourceTypeTar   = "tar-rafs"
+	sourceTypeTarGz = "targz-rafs"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// tarSourceType detects the source type of tar file for nydus-image, the
+// tar file can be gzip compressed.
+func tarSourceType(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(gzipMagic))
+	if _, err := io.ReadFull(file, magic); err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		return sourceTypeTarGz, nil
+	}
+	return sourceTypeTar, nil
+}
+
+// prepareTarSource returns the source tar file and its type, the tar stream
+// from stdin is saved into a temporary file in output directory, which is
+// removed by the returned cleanup function.
+func (p *Packer) prepareTarSource(req PackRequest) (string, string, func(), error) {
+	sourcePath := req.SourceTar
+	cleanup := func() {}
+
+	if sourcePath == StdinSource {
+		file, err := os.CreateTemp(p.OutputDir, "source-*.tar")
+		if err != nil {
+			return "", "", nil, errors.Wrap(err, "failed to create temporary source tar")
+		}
+		defer file.Close()
+		cleanup = func() {
+			os.Remove(file.Name())
+		}
+		size, err := io.Copy(file, p.stdin)
+		if err != nil {
+			cleanup()
+			return "", "", nil, errors.Wrap(err, "failed to read source tar from stdin")
+		}
+		p.logger.Infof("read source tar from stdin, size %d", size)
+		sourcePath = file.Name()
+	}
+
+	sourceType, err := tarSourceType(sourcePath)
+	if err != nil {
+		cleanup()
+		return "", "", nil, errors.Wrapf(err, "failed to read source tar %s", req.SourceTar)
+	}
+
+	return sourcePath, sourceType, cleanup, nil
+}
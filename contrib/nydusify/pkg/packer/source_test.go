@@ -0,0 +1,67 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package packer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarSourceType(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tarPath := filepath.Join(tmpDir, "source.tar")
+	require.NoError(t, os.WriteFile(tarPath, []byte("plain tar"), 0644))
+	sourceType, err := tarSourceType(tarPath)
+	require.NoError(t, err)
+	require.Equal(t, sourceTypeTar, sourceType)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err = gw.Write([]byte("gzip tar"))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	tarGzPath := filepath.Join(tmpDir, "source.tar.gz")
+	require.NoError(t, os.WriteFile(tarGzPath, buf.Bytes(), 0644))
+	sourceType, err = tarSourceType(tarGzPath)
+	require.NoError(t, err)
+	require.Equal(t, sourceTypeTarGz, sourceType)
+
+	_, err = tarSourceType(filepath.Join(tmpDir, "not-found.tar"))
+	require.Error(t, err)
+}
+
+func TestPrepareTarSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	p := &Packer{
+		Artifact: Artifact{OutputDir: tmpDir},
+		logger:   logrus.New(),
+		stdin:    bytes.NewReader([]byte("tar stream")),
+	}
+
+	sourcePath, sourceType, cleanup, err := p.prepareTarSource(PackRequest{SourceTar: StdinSource})
+	require.NoError(t, err)
+	require.Equal(t, sourceTypeTar, sourceType)
+	require.Equal(t, tmpDir, filepath.Dir(sourcePath))
+	content, err := os.ReadFile(sourcePath)
+	require.NoError(t, err)
+	require.Equal(t, "tar stream", string(content))
+	cleanup()
+	require.NoFileExists(t, sourcePath)
+
+	tarPath := filepath.Join(tmpDir, "source.tar")
+	require.NoError(t, os.WriteFile(tarPath, []byte("plain tar"), 0644))
+	sourcePath, _, cleanup, err = p.prepareTarSource(PackRequest{SourceTar: tarPath})
+	require.NoError(t, err)
+	require.Equal(t, tarPath, sourcePath)
+	cleanup()
+	require.FileExists(t, tarPath)
+}
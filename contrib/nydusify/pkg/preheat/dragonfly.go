@@ -0,0 +1,161 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package preheat warms the image blobs in the P2P network of Dragonfly by
+// the preheat job API of Dragonfly manager, see
+// https://d7y.io/docs/next/advanced-guides/open-api/preheat/.
+package preheat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ScopeSingleSeedPeer preheats the image in one seed peer.
+	ScopeSingleSeedPeer = "single_seed_peer"
+	// ScopeAllSeedPeers preheats the image in all seed peers.
+	ScopeAllSeedPeers = "all_seed_peers"
+	// ScopeAllPeers preheats the image in all peers.
+	ScopeAllPeers = "all_peers"
+)
+
+// Scopes are the possible scopes of preheat.
+var Scopes = []string{ScopeSingleSeedPeer, ScopeAllSeedPeers, ScopeAllPeers}
+
+// Job states of Dragonfly manager.
+const (
+	stateSuccess = "SUCCESS"
+	stateFailure = "FAILURE"
+)
+
+type Option struct {
+	// URL is the manifest URL of image, like
+	// `https://registry.example.com/v2/library/nginx/manifests/latest`.
+	URL      string
+	Username string
+	Password string
+	// Platform selects the manifest in image index, like `linux/amd64`.
+	Platform string
+	Scope    string
+}
+
+type Dragonfly struct {
+	endpoint     string
+	token        string
+	client       *http.Client
+	pollInterval time.Duration
+}
+
+// NewDragonfly creates the preheat client of Dragonfly manager at endpoint,
+// like `http://dragonfly-manager:8080`, token is the personal access token
+// of Dragonfly manager.
+func NewDragonfly(endpoint, token string) *Dragonfly {
+	return &Dragonfly{
+		endpoint:     strings.TrimSuffix(endpoint, "/"),
+		token:        token,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		pollInterval: 5 * time.Second,
+	}
+}
+
+type jobRequest struct {
+	Type string  `json:"type"`
+	Args jobArgs `json:"args"`
+}
+
+type jobArgs struct {
+	Type     string `json:"type"`
+	URL      string `json:"url"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Platform string `json:"platform,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+type job struct {
+	ID     uint64          `json:"id"`
+	State  string          `json:"state"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+func (d *Dragonfly) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "marshal request")
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.endpoint+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if d.token != "" {
+		req.Header.Set("Authorization", "Bearer "+d.token)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(message))
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(result), "decode response of %s", path)
+}
+
+// Preheat creates the preheat job of image, and returns the job ID.
+func (d *Dragonfly) Preheat(ctx context.Context, opt Option) (uint64, error) {
+	var created job
+	if err := d.do(ctx, http.MethodPost, "/oapi/v1/jobs", jobRequest{
+		Type: "preheat",
+		Args: jobArgs{
+			Type:     "image",
+			URL:      opt.URL,
+			Username: opt.Username,
+			Password: opt.Password,
+			Platform: opt.Platform,
+			Scope:    opt.Scope,
+		},
+	}, &created); err != nil {
+		return 0, errors.Wrap(err, "create preheat job")
+	}
+	return created.ID, nil
+}
+
+// Wait waits for the preheat job to finish until the context is done.
+func (d *Dragonfly) Wait(ctx context.Context, id uint64) error {
+	for {
+		var current job
+		if err := d.do(ctx, http.MethodGet, "/oapi/v1/jobs/"+strconv.FormatUint(id, 10), nil, &current); err != nil {
+			return errors.Wrapf(err, "get preheat job %d", id)
+		}
+		switch current.State {
+		case stateSuccess:
+			return nil
+		case stateFailure:
+			return fmt.Errorf("preheat job %d failed: %s", id, current.Result)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "wait for preheat job %d in state %s", id, current.State)
+		case <-time.After(d.pollInterval):
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package preheat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDragonflyPreheat(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []jobRequest
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /oapi/v1/jobs":
+			var req jobRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			requests = append(requests, req)
+			json.NewEncoder(w).Encode(job{ID: uint64(len(requests)), State: "PENDING"})
+		case "GET /oapi/v1/jobs/1":
+			polls++
+			state := "PENDING"
+			if polls > 1 {
+				state = stateSuccess
+			}
+			json.NewEncoder(w).Encode(job{ID: 1, State: state})
+		case "GET /oapi/v1/jobs/2":
+			json.NewEncoder(w).Encode(job{ID: 2, State: stateFailure, Result: json.RawMessage(`{"message":"manifest unknown"}`)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dragonfly := NewDragonfly(server.URL+"/", "token")
+	dragonfly.pollInterval = 10 * time.Millisecond
+	ctx := context.Background()
+
+	id, err := dragonfly.Preheat(ctx, Option{
+		URL:      "https://registry.example.com/v2/library/nginx/manifests/latest-nydus",
+		Username: "user",
+		Password: "pass",
+		Platform: "linux/arm64",
+		Scope:    ScopeAllSeedPeers,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+	require.Equal(t, jobRequest{Type: "preheat", Args: jobArgs{
+		Type:     "image",
+		URL:      "https://registry.example.com/v2/library/nginx/manifests/latest-nydus",
+		Username: "user",
+		Password: "pass",
+		Platform: "linux/arm64",
+		Scope:    ScopeAllSeedPeers,
+	}}, requests[0])
+	require.NoError(t, dragonfly.Wait(ctx, id))
+	require.Equal(t, 2, polls)
+
+	require.ErrorContains(t, dragonfly.Wait(ctx, 2), "manifest unknown")
+
+	_, err = NewDragonfly(server.URL, "invalid").Preheat(ctx, Option{})
+	require.ErrorContains(t, err, "401")
+}
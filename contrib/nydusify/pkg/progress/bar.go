@@ -0,0 +1,165 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	barWidth       = 30
+	redrawInterval = 100 * time.Millisecond
+)
+
+// barReporter draws a progress bar for each running layer, the lines of
+// completed layers are kept above the bars. It's also an io.Writer, so
+// that the log lines can be printed without breaking the bars.
+type barReporter struct {
+	mutex sync.Mutex
+	out   io.Writer
+	// items are the running layers in the order of the first event.
+	items []*Event
+	index map[string]*Event
+	// lines is the number of bar lines drawn last time.
+	lines int
+	dirty bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newBarReporter(out io.Writer) *barReporter {
+	r := &barReporter{
+		out:   out,
+		index: make(map[string]*Event),
+		stop:  make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.loop()
+	return r
+}
+
+func (r *barReporter) loop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.mutex.Lock()
+			if r.dirty {
+				r.redraw()
+			}
+			r.mutex.Unlock()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *barReporter) Report(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := string(event.Phase) + "/" + event.ID
+	if item, ok := r.index[key]; ok {
+		*item = event
+	} else {
+		item := event
+		r.index[key] = &item
+		r.items = append(r.items, &item)
+	}
+	r.dirty = true
+}
+
+// Write prints the log lines above the bars.
+func (r *barReporter) Write(p []byte) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.clear()
+	n, err := r.out.Write(p)
+	r.draw()
+	return n, err
+}
+
+func (r *barReporter) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.redraw()
+	return nil
+}
+
+func (r *barReporter) redraw() {
+	r.clear()
+	r.draw()
+	r.dirty = false
+}
+
+func (r *barReporter) clear() {
+	for i := 0; i < r.lines; i++ {
+		fmt.Fprint(r.out, "\x1b[1A\x1b[2K")
+	}
+	r.lines = 0
+}
+
+// draw prints the completed layers once, and the bars of running layers.
+func (r *barReporter) draw() {
+	running := r.items[:0]
+	for _, item := range r.items {
+		if item.Done {
+			fmt.Fprintln(r.out, formatLine(*item))
+			delete(r.index, string(item.Phase)+"/"+item.ID)
+			continue
+		}
+		running = append(running, item)
+	}
+	r.items = running
+
+	for _, item := range r.items {
+		fmt.Fprintln(r.out, formatLine(*item))
+	}
+	r.lines = len(r.items)
+}
+
+func formatLine(event Event) string {
+	id := event.ID
+	if dgst, err := digest.Parse(id); err == nil {
+		id = dgst.Encoded()[:12]
+	}
+	size := humanize.Bytes(uint64(event.Current))
+	if event.Total > 0 {
+		size += "/" + humanize.Bytes(uint64(event.Total))
+	}
+	if event.Done {
+		return fmt.Sprintf("%-5s %s done %s", event.Phase, id, size)
+	}
+	return fmt.Sprintf("%-5s %s %s %s", event.Phase, id, formatBar(event.Current, event.Total), size)
+}
+
+func formatBar(current, total int64) string {
+	if total <= 0 {
+		return "[" + strings.Repeat("-", barWidth) + "]"
+	}
+	filled := int(current * barWidth / total)
+	if filled > barWidth {
+		filled = barWidth
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	return "[" + bar + "]"
+}
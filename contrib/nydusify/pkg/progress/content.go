@@ -0,0 +1,168 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/errdefs"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// The ingestion key of layer fetched from registry, see remotes.MakeRefKey.
+	pullRefPrefix = "layer-"
+	// The ingestion key of nydus blob converted from a layer, which is in
+	// format `convert-nydus-from-<digest>`.
+	buildRefPrefix = "convert-"
+)
+
+// Content tracks the layers pulled, built and pushed through the content
+// store, and reports the progress to reporter.
+type Content struct {
+	content.Store
+	reporter Reporter
+}
+
+func NewContent(store content.Store, reporter Reporter) *Content {
+	return &Content{
+		Store:    store,
+		reporter: reporter,
+	}
+}
+
+// writerItem returns the phase and the ID of layer written by ref.
+func writerItem(phase Phase, ref string) (Phase, string, bool) {
+	switch {
+	case phase == PhasePull && strings.HasPrefix(ref, pullRefPrefix):
+		return PhasePull, strings.TrimPrefix(ref, pullRefPrefix), true
+	case phase == "" && strings.HasPrefix(ref, buildRefPrefix):
+		return PhaseBuild, ref[strings.LastIndex(ref, "-")+1:], true
+	default:
+		return "", "", false
+	}
+}
+
+func (c *Content) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	var wopts content.WriterOpts
+	for _, opt := range opts {
+		if err := opt(&wopts); err != nil {
+			return nil, err
+		}
+	}
+
+	writer, err := c.Store.Writer(ctx, opts...)
+	phase, id, ok := writerItem(phaseFromContext(ctx), wopts.Ref)
+	if !ok {
+		return writer, err
+	}
+	if err != nil {
+		// The layer pulled before is reused.
+		if errdefs.IsAlreadyExists(err) {
+			c.reporter.Report(Event{
+				Time:    time.Now(),
+				Phase:   phase,
+				ID:      id,
+				Current: wopts.Desc.Size,
+				Total:   wopts.Desc.Size,
+				Done:    true,
+			})
+		}
+		return nil, err
+	}
+
+	return &progressWriter{
+		Writer:   writer,
+		reporter: c.reporter,
+		phase:    phase,
+		id:       id,
+		total:    wopts.Desc.Size,
+	}, nil
+}
+
+func (c *Content) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (content.ReaderAt, error) {
+	ra, err := c.Store.ReaderAt(ctx, desc)
+	if err != nil || phaseFromContext(ctx) != PhasePush || !images.IsLayerType(desc.MediaType) {
+		return ra, err
+	}
+	return &progressReaderAt{
+		ReaderAt: ra,
+		reporter: c.reporter,
+		id:       desc.Digest.String(),
+	}, nil
+}
+
+type progressWriter struct {
+	content.Writer
+	reporter Reporter
+	phase    Phase
+	id       string
+	total    int64
+	current  int64
+}
+
+func (w *progressWriter) report(done bool) {
+	w.reporter.Report(Event{
+		Time:    time.Now(),
+		Phase:   w.phase,
+		ID:      w.id,
+		Current: w.current,
+		Total:   w.total,
+		Done:    done,
+	})
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.current += int64(n)
+	w.report(false)
+	return n, err
+}
+
+func (w *progressWriter) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	err := w.Writer.Commit(ctx, size, expected, opts...)
+	if err == nil || errdefs.IsAlreadyExists(err) {
+		w.report(true)
+	}
+	return err
+}
+
+type progressReaderAt struct {
+	content.ReaderAt
+	reporter Reporter
+	id       string
+	current  atomic.Int64
+}
+
+func (ra *progressReaderAt) report(done bool) {
+	ra.reporter.Report(Event{
+		Time:    time.Now(),
+		Phase:   PhasePush,
+		ID:      ra.id,
+		Current: min(ra.current.Load(), ra.Size()),
+		Total:   ra.Size(),
+		Done:    done,
+	})
+}
+
+func (ra *progressReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := ra.ReaderAt.ReadAt(p, off)
+	ra.current.Add(int64(n))
+	ra.report(false)
+	return n, err
+}
+
+func (ra *progressReaderAt) Close() error {
+	if ra.current.Load() >= ra.Size() {
+		ra.report(true)
+	}
+	return ra.ReaderAt.Close()
+}
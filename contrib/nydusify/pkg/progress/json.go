@@ -0,0 +1,47 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// jsonInterval limits the rate of events of the same layer, the first and
+// the last events are always written.
+const jsonInterval = 500 * time.Millisecond
+
+type jsonReporter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	last    map[string]time.Time
+}
+
+func newJSONReporter(writer io.Writer) *jsonReporter {
+	return &jsonReporter{
+		encoder: json.NewEncoder(writer),
+		last:    make(map[string]time.Time),
+	}
+}
+
+func (r *jsonReporter) Report(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := string(event.Phase) + "/" + event.ID
+	last, ok := r.last[key]
+	if ok && !event.Done && event.Time.Sub(last) < jsonInterval {
+		return
+	}
+	r.last[key] = event.Time
+	// Errors are ignored since the progress is best effort.
+	_ = r.encoder.Encode(event)
+}
+
+func (r *jsonReporter) Close() error {
+	return nil
+}
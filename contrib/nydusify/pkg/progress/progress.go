@@ -0,0 +1,102 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package progress reports the per-layer progress of image conversion, as
+// interactive progress bars or machine-readable JSON events.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Phase is the phase of a layer in conversion.
+type Phase string
+
+const (
+	PhasePull  Phase = "pull"
+	PhaseBuild Phase = "build"
+	PhasePush  Phase = "push"
+)
+
+const (
+	ModeAuto = "auto"
+	ModeBar  = "bar"
+	ModeJSON = "json"
+	ModeNone = "none"
+)
+
+// Modes are the possible values of `--progress`.
+var Modes = []string{ModeAuto, ModeBar, ModeJSON, ModeNone}
+
+// Event is the progress of a layer in a phase.
+type Event struct {
+	Time  time.Time `json:"time"`
+	Phase Phase     `json:"phase"`
+	// ID is the digest of layer, for the build phase, it's the digest of
+	// the source layer being converted.
+	ID      string `json:"id"`
+	Current int64  `json:"current"`
+	// Total is 0 if the size is unknown, e.g. the size of a nydus blob
+	// being built.
+	Total int64 `json:"total,omitempty"`
+	Done  bool  `json:"done"`
+}
+
+// Reporter receives the progress events, it must be safe for concurrent
+// use.
+type Reporter interface {
+	Report(event Event)
+	// Close flushes the pending events.
+	Close() error
+}
+
+// New creates the reporter of mode, the progress bars are drawn on stderr
+// and the JSON events are written to stdout line by line. It returns nil if
+// the progress is disabled.
+func New(mode string, stdout, stderr io.Writer) (Reporter, error) {
+	switch mode {
+	case ModeJSON:
+		return newJSONReporter(stdout), nil
+	case ModeBar:
+		return newBarReporter(stderr), nil
+	case ModeAuto:
+		if isTerminal(stderr) {
+			return newBarReporter(stderr), nil
+		}
+		return nil, nil
+	case ModeNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported progress mode %s", mode)
+	}
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+type phaseKey struct{}
+
+// WithPhase marks the content store operations with ctx in phase, the
+// layers written in pull phase and read in push phase are tracked.
+func WithPhase(ctx context.Context, phase Phase) context.Context {
+	return context.WithValue(ctx, phaseKey{}, phase)
+}
+
+func phaseFromContext(ctx context.Context) Phase {
+	phase, _ := ctx.Value(phaseKey{}).(Phase)
+	return phase
+}
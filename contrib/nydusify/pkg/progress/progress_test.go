@@ -0,0 +1,113 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	reporter, err := New(ModeNone, &bytes.Buffer{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Nil(t, reporter)
+
+	// The bytes.Buffer is not a terminal.
+	reporter, err = New(ModeAuto, &bytes.Buffer{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Nil(t, reporter)
+
+	reporter, err = New(ModeJSON, &bytes.Buffer{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.IsType(t, &jsonReporter{}, reporter)
+
+	_, err = New("unknown", &bytes.Buffer{}, &bytes.Buffer{})
+	require.Error(t, err)
+}
+
+func TestWriterItem(t *testing.T) {
+	layer := digest.FromString("layer").String()
+
+	phase, id, ok := writerItem(PhasePull, "layer-"+layer)
+	require.True(t, ok)
+	require.Equal(t, PhasePull, phase)
+	require.Equal(t, layer, id)
+
+	phase, id, ok = writerItem("", "convert-nydus-from-"+layer)
+	require.True(t, ok)
+	require.Equal(t, PhaseBuild, phase)
+	require.Equal(t, layer, id)
+
+	_, _, ok = writerItem(PhasePull, "manifest-"+layer)
+	require.False(t, ok)
+	_, _, ok = writerItem("", "layer-"+layer)
+	require.False(t, ok)
+
+	require.Equal(t, PhasePush, phaseFromContext(WithPhase(context.Background(), PhasePush)))
+}
+
+func TestJSONReporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := newJSONReporter(buf)
+	layer := digest.FromString("layer").String()
+
+	now := time.Now()
+	reporter.Report(Event{Time: now, Phase: PhasePull, ID: layer, Current: 10, Total: 100})
+	// Throttled.
+	reporter.Report(Event{Time: now.Add(time.Millisecond), Phase: PhasePull, ID: layer, Current: 20, Total: 100})
+	reporter.Report(Event{Time: now.Add(time.Second), Phase: PhasePull, ID: layer, Current: 50, Total: 100})
+	reporter.Report(Event{Time: now.Add(time.Second + time.Millisecond), Phase: PhasePull, ID: layer, Current: 100, Total: 100, Done: true})
+	require.NoError(t, reporter.Close())
+
+	var events []Event
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 3)
+	require.Equal(t, int64(10), events[0].Current)
+	require.Equal(t, int64(50), events[1].Current)
+	require.True(t, events[2].Done)
+	require.Equal(t, PhasePull, events[2].Phase)
+}
+
+func TestBarReporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reporter := newBarReporter(buf)
+	layer := digest.FromString("layer").String()
+
+	reporter.Report(Event{Phase: PhasePull, ID: layer, Current: 50, Total: 100})
+	reporter.Report(Event{Phase: PhaseBuild, ID: layer, Current: 1024})
+	_, err := reporter.Write([]byte("log line\n"))
+	require.NoError(t, err)
+	reporter.Report(Event{Phase: PhasePull, ID: layer, Current: 100, Total: 100, Done: true})
+	require.NoError(t, reporter.Close())
+
+	output := buf.String()
+	require.Contains(t, output, "log line\n")
+	require.Contains(t, output, "pull  "+digest.FromString("layer").Encoded()[:12]+" [===============>              ] 50 B/100 B")
+	require.Contains(t, output, "pull  "+digest.FromString("layer").Encoded()[:12]+" done 100 B/100 B")
+	// The completed layer is printed once.
+	require.Equal(t, 1, strings.Count(output, " done "))
+	require.Len(t, reporter.items, 1)
+	require.Equal(t, PhaseBuild, reporter.items[0].Phase)
+}
+
+func TestFormatBar(t *testing.T) {
+	require.Equal(t, "["+strings.Repeat("-", barWidth)+"]", formatBar(10, 0))
+	require.Equal(t, "[>"+strings.Repeat(" ", barWidth-1)+"]", formatBar(0, 100))
+	require.Equal(t, "["+strings.Repeat("=", barWidth)+"]", formatBar(100, 100))
+	require.Equal(t, "["+strings.Repeat("=", barWidth)+"]", formatBar(200, 100))
+}
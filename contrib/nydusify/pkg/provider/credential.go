@@ -0,0 +1,90 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"os"
+	"strings"
+
+	dockerconfig "github.com/docker/cli/cli/config"
+	"github.com/pkg/errors"
+)
+
+// CredentialOption configures the credential to access registry, the docker
+// auth config file `$DOCKER_CONFIG/config.json` is used if it's empty.
+type CredentialOption struct {
+	Username string
+	Password string
+	// IdentityToken is the OAuth2 refresh token exchanged for registry token,
+	// like the one saved by `docker login` with a token.
+	IdentityToken string
+	// Helper is the docker credential helper to get credential, which is the
+	// suffix of binary `docker-credential-<helper>` in `$PATH`, for example
+	// `ecr-login`, `gcloud` and `pass`.
+	Helper string
+}
+
+// Validate checks that only one kind of credential is specified.
+func (opt CredentialOption) Validate() error {
+	kinds := 0
+	if opt.Username != "" || opt.Password != "" {
+		if opt.Username == "" || opt.Password == "" {
+			return errors.New("username and password must be specified together")
+		}
+		kinds++
+	}
+	if opt.IdentityToken != "" {
+		kinds++
+	}
+	if opt.Helper != "" {
+		if strings.ContainsAny(opt.Helper, `/\`) {
+			return errors.Errorf("invalid credential helper %s, should be the suffix of docker-credential-<helper>", opt.Helper)
+		}
+		kinds++
+	}
+	if kinds > 1 {
+		return errors.New("username/password, identity token and credential helper are mutually exclusive")
+	}
+	return nil
+}
+
+// CredFunc returns the function to get the credential of registry host. The
+// returned username is empty for an identity token.
+func (opt CredentialOption) CredFunc() func(host string) (string, string, error) {
+	return func(host string) (string, string, error) {
+		switch {
+		case opt.Username != "":
+			return opt.Username, opt.Password, nil
+		case opt.IdentityToken != "":
+			return "", opt.IdentityToken, nil
+		}
+
+		// The host of docker hub image will be converted to `registry-1.docker.io` in:
+		// github.com/containerd/containerd/remotes/docker/registry.go
+		// But we need use the key `https://index.docker.io/v1/` to find auth from docker config.
+		if host == "registry-1.docker.io" {
+			host = "https://index.docker.io/v1/"
+		}
+
+		config := dockerconfig.LoadDefaultConfigFile(os.Stderr)
+		if opt.Helper != "" {
+			// The helper configured by `credHelpers` takes precedence over
+			// `credsStore` in docker config.
+			config.CredentialHelpers = map[string]string{host: opt.Helper}
+		}
+		authConfig, err := config.GetAuthConfig(host)
+		if err != nil {
+			if opt.Helper != "" {
+				return "", "", errors.Wrapf(err, "get credential of %s from docker-credential-%s", host, opt.Helper)
+			}
+			return "", "", err
+		}
+
+		if authConfig.Username == "" && authConfig.IdentityToken != "" {
+			return "", authConfig.IdentityToken, nil
+		}
+		return authConfig.Username, authConfig.Password, nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialOption(t *testing.T) {
+	require.NoError(t, CredentialOption{}.Validate())
+	require.NoError(t, CredentialOption{Username: "user", Password: "pass"}.Validate())
+	require.NoError(t, CredentialOption{Helper: "ecr-login"}.Validate())
+	require.Error(t, CredentialOption{Username: "user"}.Validate())
+	require.Error(t, CredentialOption{Helper: "../ecr-login"}.Validate())
+	require.Error(t, CredentialOption{IdentityToken: "token", Helper: "gcloud"}.Validate())
+
+	username, password, err := CredentialOption{Username: "user", Password: "pass"}.CredFunc()("registry.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", password)
+
+	username, password, err = CredentialOption{IdentityToken: "token"}.CredFunc()("registry.example.com")
+	require.NoError(t, err)
+	require.Empty(t, username)
+	require.Equal(t, "token", password)
+}
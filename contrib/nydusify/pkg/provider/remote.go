@@ -5,60 +5,37 @@
 package provider
 
 import (
-	"crypto/tls"
 	"encoding/base64"
-	"net"
-	"net/http"
-	"os"
 	"strings"
-	"time"
 
 	"github.com/containerd/containerd/v2/core/remotes"
 	"github.com/containerd/containerd/v2/core/remotes/docker"
-	dockerconfig "github.com/docker/cli/cli/config"
 	"github.com/pkg/errors"
 
 	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/remote"
 )
 
-func newDefaultClient(skipTLSVerify bool) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          10,
-			IdleConnTimeout:       30 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 5 * time.Second,
-			DisableKeepAlives:     true,
-			TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: skipTLSVerify,
-			},
-		},
-	}
-}
-
 // withCredentialFunc accepts host url parameter and returns with
 // username, password and error.
 type withCredentialFunc = func(string) (string, string, error)
 
 // withRemote creates a remote instance, it uses the implementation of containerd
 // docker remote to access image from remote registry.
-func withRemote(ref string, insecure bool, credFunc withCredentialFunc) (*remote.Remote, error) {
+func withRemote(ref string, transport remote.TransportOption, credFunc withCredentialFunc) (*remote.Remote, error) {
+	client, err := transport.NewClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "create registry client")
+	}
+
 	resolverFunc := func(retryWithHTTP bool) remotes.Resolver {
 		registryHosts := docker.ConfigureDefaultRegistries(
 			docker.WithAuthorizer(
 				docker.NewDockerAuthorizer(
-					docker.WithAuthClient(newDefaultClient(insecure)),
+					docker.WithAuthClient(client),
 					docker.WithAuthCreds(credFunc),
 				),
 			),
-			docker.WithClient(newDefaultClient(insecure)),
+			docker.WithClient(client),
 			docker.WithPlainHTTP(func(_ string) (bool, error) {
 				return retryWithHTTP, nil
 			}),
@@ -76,28 +53,23 @@ func withRemote(ref string, insecure bool, credFunc withCredentialFunc) (*remote
 // file `$DOCKER_CONFIG/config.json` to communicate with remote registry, `$DOCKER_CONFIG`
 // defaults to `~/.docker`.
 func DefaultRemote(ref string, insecure bool) (*remote.Remote, error) {
-	return withRemote(ref, insecure, func(host string) (string, string, error) {
-		// The host of docker hub image will be converted to `registry-1.docker.io` in:
-		// github.com/containerd/containerd/remotes/docker/registry.go
-		// But we need use the key `https://index.docker.io/v1/` to find auth from docker config.
-		if host == "registry-1.docker.io" {
-			host = "https://index.docker.io/v1/"
-		}
-
-		config := dockerconfig.LoadDefaultConfigFile(os.Stderr)
-		authConfig, err := config.GetAuthConfig(host)
-		if err != nil {
-			return "", "", err
-		}
+	return NewRemote(ref, remote.TransportOption{Insecure: insecure}, CredentialOption{})
+}
 
-		return authConfig.Username, authConfig.Password, nil
-	})
+// NewRemote creates a remote instance, it accesses the registry with the
+// CA bundle, client certificate and proxy of transport option, and the
+// credential of credential option.
+func NewRemote(ref string, transport remote.TransportOption, credential CredentialOption) (*remote.Remote, error) {
+	if err := credential.Validate(); err != nil {
+		return nil, err
+	}
+	return withRemote(ref, transport, credential.CredFunc())
 }
 
 // DefaultRemoteWithAuth creates a remote instance, it parses base64 encoded auth string
 // to communicate with remote registry.
 func DefaultRemoteWithAuth(ref string, insecure bool, auth string) (*remote.Remote, error) {
-	return withRemote(ref, insecure, func(_ string) (string, string, error) {
+	return withRemote(ref, remote.TransportOption{Insecure: insecure}, func(_ string) (string, string, error) {
 		// Leave auth empty if no authorization be required
 		if strings.TrimSpace(auth) == "" {
 			return "", "", nil
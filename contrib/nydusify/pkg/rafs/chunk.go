@@ -0,0 +1,170 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rafs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// maxSymlinks is the limit of symlinks followed to resolve a path, which is
+// consistent with MAXSYMLINKS of Linux.
+const maxSymlinks = 40
+
+// Chunk locates the data chunk of regular file in blob.
+type Chunk struct {
+	Digest           [32]byte
+	BlobIndex        uint32
+	Index            uint32
+	Flags            uint32
+	FileOffset       uint64
+	CompressedOffset uint64
+	CompressedSize   uint32
+	UncompressedSize uint32
+}
+
+// FileMode returns the mode of file in os.FileMode form.
+func (file File) FileMode() os.FileMode {
+	mode := os.FileMode(file.Mode & 0777)
+	switch file.Mode & modeTypeMask {
+	case modeDir:
+		mode |= os.ModeDir
+	case modeSymlink:
+		mode |= os.ModeSymlink
+	case modeChrdev:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case modeBlkdev:
+		mode |= os.ModeDevice
+	case modeFifo:
+		mode |= os.ModeNamedPipe
+	case modeSock:
+		mode |= os.ModeSocket
+	}
+	if file.Mode&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if file.Mode&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if file.Mode&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
+
+// Lookup returns the file of path without following symlinks.
+func (bootstrap *Bootstrap) Lookup(filePath string) (*File, error) {
+	filePath = path.Join("/", filePath)
+	idx := sort.Search(len(bootstrap.Files), func(i int) bool {
+		return bootstrap.Files[i].Path >= filePath
+	})
+	if idx >= len(bootstrap.Files) || bootstrap.Files[idx].Path != filePath {
+		return nil, fmt.Errorf("%s: no such file or directory", filePath)
+	}
+	return &bootstrap.Files[idx], nil
+}
+
+// Resolve returns the file of path, the symlinks in path are followed.
+func (bootstrap *Bootstrap) Resolve(filePath string) (*File, error) {
+	links := 0
+	resolved := "/"
+	pending := strings.Split(path.Join("/", filePath), "/")
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if name == "" {
+			continue
+		}
+		file, err := bootstrap.Lookup(path.Join(resolved, name))
+		if err != nil {
+			return nil, err
+		}
+		if file.Mode&modeTypeMask != modeSymlink {
+			if len(pending) > 0 && !file.IsDir() {
+				return nil, fmt.Errorf("%s: not a directory", file.Path)
+			}
+			resolved = file.Path
+			continue
+		}
+		if links++; links > maxSymlinks {
+			return nil, fmt.Errorf("%s: too many levels of symbolic links", filePath)
+		}
+		if path.IsAbs(file.Target) {
+			resolved = "/"
+		}
+		pending = append(strings.Split(file.Target, "/"), pending...)
+	}
+	return bootstrap.Lookup(resolved)
+}
+
+// Children returns the files in the directory, sorted by name.
+func (bootstrap *Bootstrap) Children(dir *File) []File {
+	var children []File
+	for _, file := range bootstrap.Files {
+		if file.Path != "/" && path.Dir(file.Path) == dir.Path {
+			children = append(children, file)
+		}
+	}
+	return children
+}
+
+// DecodeChunk decompresses the chunk data read from the compressed range of
+// blob.
+func (bootstrap *Bootstrap) DecodeChunk(chunk Chunk, data []byte) ([]byte, error) {
+	if chunk.BlobIndex >= uint32(len(bootstrap.Blobs)) {
+		return nil, fmt.Errorf("invalid blob index %d", chunk.BlobIndex)
+	}
+	blob := bootstrap.Blobs[chunk.BlobIndex]
+	if blob.Features&(blobFeatureZran|blobFeatureTarfs|blobFeatureBatch|blobFeatureEncrypted) != 0 {
+		return nil, fmt.Errorf("unsupported features 0x%x of blob %s", blob.Features, blob.ID)
+	}
+	if chunk.Flags&(chunkFlagEncrypted|chunkFlagBatch) != 0 {
+		return nil, fmt.Errorf("unsupported flags 0x%x of chunk %d", chunk.Flags, chunk.Index)
+	}
+	if uint64(len(data)) != uint64(chunk.CompressedSize) {
+		return nil, fmt.Errorf("chunk %d has %d bytes, expected %d", chunk.Index, len(data), chunk.CompressedSize)
+	}
+	if chunk.Flags&ChunkFlagCompressed == 0 {
+		if chunk.CompressedSize != chunk.UncompressedSize {
+			return nil, fmt.Errorf("uncompressed chunk %d has compressed size %d, but uncompressed size %d", chunk.Index, chunk.CompressedSize, chunk.UncompressedSize)
+		}
+		return data, nil
+	}
+
+	var reader io.Reader
+	switch blob.Compressor {
+	case "zstd":
+		decoder, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	case "gzip":
+		decoder, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		reader = decoder
+	default:
+		return nil, fmt.Errorf("unsupported compressor %s of blob %s", blob.Compressor, blob.ID)
+	}
+
+	decompressed := make([]byte, chunk.UncompressedSize)
+	if _, err := io.ReadFull(reader, decompressed); err != nil {
+		return nil, errors.Wrapf(err, "decompress chunk %d", chunk.Index)
+	}
+	return decompressed, nil
+}
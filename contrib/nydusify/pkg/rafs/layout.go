@@ -0,0 +1,286 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rafs
+
+// The on-disk structures of RAFS v6 filesystem, they must be kept in sync
+// with `rafs/src/metadata/layout/v6.rs`. All fields are encoded in
+// little-endian. The ones exported are shared with the native builder.
+
+const (
+	SuperOffset       = 1024
+	SuperBlockSize    = 128
+	ExtSuperBlockSize = 256
+	DevTableOffset    = SuperOffset + SuperBlockSize + ExtSuperBlockSize
+
+	minMetaSize          = 4096
+	DeviceSlotSize       = 128
+	InodeSlotSize        = 32
+	compactInodeSize     = 32
+	ExtendedInodeSize    = 64
+	DirentSize           = 12
+	ChunkAddrSize        = 8
+	BlobEntrySize        = 256
+	ChunkInfoSize        = 80
+	prefetchEntrySize    = 4
+	XattrIbodyHeaderSize = 12
+	XattrEntrySize       = 4
+
+	blobIDSize = 64
+
+	minChunkSize = 0x1000
+	maxChunkSize = 0x1000000
+
+	rafsV5SuperMagic = 0x52414653
+
+	ErofsSuperMagic                 = 0xE0F5E1E2
+	ErofsFeatureCompatRafsV6        = 0x40000000
+	ErofsFeatureIncompatChunkedFile = 0x00000004
+	ErofsFeatureIncompatDeviceTable = 0x00000008
+
+	ErofsInodeLayoutExtended = 1
+	erofsInodeFormatMask     = 0xf
+	ErofsInodeFlatPlain      = 0
+	ErofsInodeFlatInline     = 2
+	ErofsInodeChunkBased     = 4
+	ErofsChunkFormatIndexes  = 0x0020
+	erofsChunkFormatBlkBits  = 0x001f
+
+	FlagCompressionNone = 0x00000001
+	FlagCompressionLZ4  = 0x00000002
+	FlagHashBlake3      = 0x00000004
+	FlagHashSHA256      = 0x00000008
+	FlagExplicitUIDGID  = 0x00000010
+	FlagHasXattr        = 0x00000020
+	FlagCompressionGzip = 0x00000040
+	FlagCompressionZstd = 0x00000080
+	FlagInlinedDigest   = 0x00000100
+	FlagTarfsMode       = 0x00000200
+	FlagEncryptionNone  = 0x01000000
+	FlagEncryptionXTS   = 0x02000000
+
+	blobFeatureZran      = 0x00000008
+	blobFeatureTarfs     = 0x00000040
+	blobFeatureBatch     = 0x00000080
+	blobFeatureEncrypted = 0x00000100
+
+	ChunkFlagCompressed = 0x00000001
+	chunkFlagEncrypted  = 0x00000004
+	chunkFlagBatch      = 0x00000008
+)
+
+// The compression algorithms of blob, which are consistent with
+// `Algorithm` in `utils/src/compress/mod.rs`.
+const (
+	CompressorNone     = 0
+	CompressorLZ4Block = 1
+	CompressorGzip     = 2
+	CompressorZstd     = 3
+)
+
+var compressorNames = map[uint32]string{
+	CompressorNone:     "none",
+	CompressorLZ4Block: "lz4_block",
+	CompressorGzip:     "gzip",
+	CompressorZstd:     "zstd",
+}
+
+// flagNames are the names of RAFS superblock flags, which are consistent
+// with `RafsSuperFlags` in `rafs/src/metadata/mod.rs`.
+var flagNames = []struct {
+	flag uint64
+	name string
+}{
+	{FlagCompressionNone, "COMPRESSION_NONE"},
+	{FlagCompressionLZ4, "COMPRESSION_LZ4"},
+	{FlagHashBlake3, "HASH_BLAKE3"},
+	{FlagHashSHA256, "HASH_SHA256"},
+	{FlagExplicitUIDGID, "EXPLICIT_UID_GID"},
+	{FlagHasXattr, "HAS_XATTR"},
+	{FlagCompressionGzip, "COMPRESSION_GZIP"},
+	{FlagCompressionZstd, "COMPRESSION_ZSTD"},
+	{FlagInlinedDigest, "INLINED_CHUNK_DIGEST"},
+	{FlagTarfsMode, "TARTFS_MODE"},
+	{FlagEncryptionNone, "ENCRYPTION_NONE"},
+	{FlagEncryptionXTS, "ENCRYPTION_ASE_128_XTS"},
+}
+
+// The file types in directory entry.
+const (
+	FileTypeUnknown = 0
+	FileTypeRegular = 1
+	FileTypeDir     = 2
+	FileTypeChrdev  = 3
+	FileTypeBlkdev  = 4
+	FileTypeFifo    = 5
+	FileTypeSock    = 6
+	FileTypeSymlink = 7
+)
+
+// The file types in inode mode.
+const (
+	modeTypeMask = 0xf000
+	modeSock     = 0xc000
+	modeSymlink  = 0xa000
+	modeRegular  = 0x8000
+	modeBlkdev   = 0x6000
+	modeDir      = 0x4000
+	modeChrdev   = 0x2000
+	modeFifo     = 0x1000
+)
+
+var modeFileTypes = map[uint32]uint8{
+	modeRegular: FileTypeRegular,
+	modeDir:     FileTypeDir,
+	modeChrdev:  FileTypeChrdev,
+	modeBlkdev:  FileTypeBlkdev,
+	modeFifo:    FileTypeFifo,
+	modeSock:    FileTypeSock,
+	modeSymlink: FileTypeSymlink,
+}
+
+// SuperBlock is the EROFS superblock at SuperOffset.
+type SuperBlock struct {
+	Magic           uint32
+	Checksum        uint32
+	FeatureCompat   uint32
+	BlkSzBits       uint8
+	ExtSlots        uint8
+	RootNid         uint16
+	Inos            uint64
+	BuildTime       uint64
+	BuildTimeNsec   uint32
+	Blocks          uint32
+	MetaBlkAddr     uint32
+	XattrBlkAddr    uint32
+	UUID            [16]byte
+	VolumeName      [16]byte
+	FeatureIncompat uint32
+	U               uint16
+	ExtraDevices    uint16
+	DevtSlotOff     uint16
+	Reserved        [38]byte
+}
+
+// ExtSuperBlock is the RAFS extended superblock following SuperBlock.
+type ExtSuperBlock struct {
+	Flags               uint64
+	BlobTableOffset     uint64
+	BlobTableSize       uint32
+	ChunkSize           uint32
+	ChunkTableOffset    uint64
+	ChunkTableSize      uint64
+	PrefetchTableOffset uint64
+	PrefetchTableSize   uint32
+	Padding             uint32
+	Reserved            [200]byte
+}
+
+type compactInode struct {
+	Format      uint16
+	XattrICount uint16
+	Mode        uint16
+	Nlink       uint16
+	Size        uint32
+	Reserved    uint32
+	U           uint32
+	Ino         uint32
+	UID         uint16
+	GID         uint16
+	Reserved2   [4]byte
+}
+
+// ExtendedInode is the EROFS extended inode, the builder only writes
+// this layout.
+type ExtendedInode struct {
+	Format      uint16
+	XattrICount uint16
+	Mode        uint16
+	Reserved    uint16
+	Size        uint64
+	U           uint32
+	Ino         uint32
+	UID         uint32
+	GID         uint32
+	Mtime       uint64
+	MtimeNsec   uint32
+	Nlink       uint32
+	Reserved2   [16]byte
+}
+
+// Dirent is the entry of directory, the names follow the entries.
+type Dirent struct {
+	Nid      uint64
+	NameOff  uint16
+	FileType uint8
+	Reserved uint8
+}
+
+// ChunkAddr is the chunk index of chunk-based file.
+type ChunkAddr struct {
+	BlobAddrLo uint16
+	BlobAddrHi uint16
+	BlkAddr    uint32
+}
+
+// blobIndex returns the index in blob table, the device id 0 is the
+// bootstrap, so it's invalid.
+func (addr ChunkAddr) blobIndex() (uint32, bool) {
+	index := uint32(addr.BlobAddrHi & 0xff)
+	if index == 0 {
+		return 0, false
+	}
+	return index - 1, true
+}
+
+func (addr ChunkAddr) ciIndex() uint32 {
+	return uint32(addr.BlobAddrHi>>8)<<16 | uint32(addr.BlobAddrLo)
+}
+
+// ChunkInfo is the entry of chunk information table in bootstrap, which
+// shares the format of RAFS v5 chunk.
+type ChunkInfo struct {
+	BlockID            [32]byte
+	BlobIndex          uint32
+	Flags              uint32
+	CompressedSize     uint32
+	UncompressedSize   uint32
+	CompressedOffset   uint64
+	UncompressedOffset uint64
+	FileOffset         uint64
+	Index              uint32
+	Crc32              uint32
+}
+
+// DeviceSlot is the entry of device table, one for each blob.
+type DeviceSlot struct {
+	BlobID        [64]byte
+	Blocks        uint32
+	MappedBlkAddr uint32
+	Reserved      [56]byte
+}
+
+// BlobEntry is the entry of RAFS blob table.
+type BlobEntry struct {
+	BlobID             [64]byte
+	BlobIndex          uint32
+	ChunkSize          uint32
+	ChunkCount         uint32
+	CompressionAlgo    uint32
+	DigestAlgo         uint32
+	Features           uint32
+	CompressedSize     uint64
+	UncompressedSize   uint64
+	BlobTocSize        uint32
+	CiCompressor       uint32
+	CiOffset           uint64
+	CiCompressedSize   uint64
+	CiUncompressedSize uint64
+	BlobTocDigest      [32]byte
+	BlobMetaDigest     [32]byte
+	BlobMetaSize       uint64
+	CipherIV           [8]byte
+	CipherAlgo         uint32
+	Reserved           [36]byte
+}
@@ -0,0 +1,697 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package rafs parses and verifies the metadata of RAFS v6 bootstrap in
+// Go, including the superblock, blob table and inode tree, so that the
+// bootstrap can be checked without nydusd and FUSE.
+package rafs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Blob is the entry of blob table in bootstrap.
+type Blob struct {
+	ID               string `json:"id"`
+	ChunkCount       uint32 `json:"chunk_count"`
+	CompressedSize   uint64 `json:"compressed_size"`
+	UncompressedSize uint64 `json:"uncompressed_size"`
+	Compressor       string `json:"compressor"`
+	Features         uint32 `json:"features"`
+}
+
+// File is the file in the inode tree of bootstrap.
+type File struct {
+	Path  string `json:"path"`
+	Nid   uint64 `json:"nid"`
+	Mode  uint32 `json:"mode"`
+	UID   uint32 `json:"uid"`
+	GID   uint32 `json:"gid"`
+	Nlink uint32 `json:"nlink"`
+	Size  uint64 `json:"size"`
+	// Chunks is the number of chunks of regular file.
+	Chunks uint64 `json:"chunks,omitempty"`
+	// ChunkInfos locates the chunks of regular file in blobs, it's empty
+	// if the chunks are not recorded in the chunk table of bootstrap.
+	ChunkInfos []Chunk `json:"-"`
+	// Target is the target of symlink.
+	Target string `json:"target,omitempty"`
+}
+
+// IsDir returns true if the file is a directory.
+func (file File) IsDir() bool {
+	return file.Mode&modeTypeMask == modeDir
+}
+
+// Bootstrap is the metadata parsed from RAFS v6 bootstrap.
+type Bootstrap struct {
+	Version    string   `json:"version"`
+	BlockSize  uint32   `json:"block_size"`
+	ChunkSize  uint32   `json:"chunk_size"`
+	Compressor string   `json:"compressor"`
+	Digester   string   `json:"digester"`
+	Flags      uint64   `json:"flags"`
+	Features   []string `json:"features"`
+	Inodes     uint64   `json:"inodes"`
+	Blobs      []Blob   `json:"blobs"`
+	// Prefetch is the paths of the inodes in prefetch table.
+	Prefetch []string `json:"prefetch"`
+	// Files are sorted by path, the root directory is "/".
+	Files []File `json:"files"`
+}
+
+// compressor returns the name of compression algorithm in flags.
+func compressor(flags uint64) string {
+	switch {
+	case flags&FlagCompressionNone != 0:
+		return "none"
+	case flags&FlagCompressionLZ4 != 0:
+		return "lz4_block"
+	case flags&FlagCompressionGzip != 0:
+		return "gzip"
+	case flags&FlagCompressionZstd != 0:
+		return "zstd"
+	}
+	return "unknown"
+}
+
+// digester returns the name of digest algorithm in flags.
+func digester(flags uint64) string {
+	switch {
+	case flags&FlagHashBlake3 != 0:
+		return "blake3"
+	case flags&FlagHashSHA256 != 0:
+		return "sha256"
+	}
+	return "unknown"
+}
+
+// features returns the names of flags.
+func features(flags uint64) []string {
+	var names []string
+	for _, flag := range flagNames {
+		if flags&flag.flag != 0 {
+			names = append(names, flag.name)
+		}
+	}
+	return names
+}
+
+// Load reads and verifies the RAFS v6 bootstrap file.
+func Load(bootstrapPath string) (*Bootstrap, error) {
+	data, err := os.ReadFile(bootstrapPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read bootstrap")
+	}
+	return Parse(data)
+}
+
+// Parse verifies the RAFS v6 bootstrap data: the superblock, device table,
+// blob table, chunk and prefetch table, and walks the inode tree from root
+// to verify the inodes, directory entries and chunk addresses.
+func Parse(data []byte) (*Bootstrap, error) {
+	p := &parser{
+		data:    data,
+		visited: map[uint64]bool{},
+		nids:    map[uint64]string{},
+		chunks:  map[chunkKey]ChunkInfo{},
+	}
+	if err := p.parseSuperBlock(); err != nil {
+		return nil, errors.Wrap(err, "invalid superblock")
+	}
+	if err := p.parseBlobTable(); err != nil {
+		return nil, errors.Wrap(err, "invalid blob table")
+	}
+	if err := p.parseDeviceTable(); err != nil {
+		return nil, errors.Wrap(err, "invalid device table")
+	}
+	if err := p.parseChunkTable(); err != nil {
+		return nil, errors.Wrap(err, "invalid chunk table")
+	}
+	if err := p.parseTree(); err != nil {
+		return nil, errors.Wrap(err, "invalid inode tree")
+	}
+	if err := p.parsePrefetchTable(); err != nil {
+		return nil, errors.Wrap(err, "invalid prefetch table")
+	}
+
+	sort.Slice(p.files, func(i, j int) bool {
+		return p.files[i].Path < p.files[j].Path
+	})
+
+	return &Bootstrap{
+		Version:    "v6",
+		BlockSize:  uint32(p.blockSize),
+		ChunkSize:  p.ext.ChunkSize,
+		Compressor: compressor(p.ext.Flags),
+		Digester:   digester(p.ext.Flags),
+		Flags:      p.ext.Flags,
+		Features:   features(p.ext.Flags),
+		Inodes:     p.sb.Inos,
+		Blobs:      p.blobs,
+		Prefetch:   p.prefetch,
+		Files:      p.files,
+	}, nil
+}
+
+type parser struct {
+	data      []byte
+	sb        SuperBlock
+	ext       ExtSuperBlock
+	blockSize uint64
+	blobs     []Blob
+	files     []File
+	prefetch  []string
+	// visited records the directories walked to detect loops.
+	visited map[uint64]bool
+	// nids records the first path of the inodes referred by directory
+	// entries.
+	nids map[uint64]string
+	// chunks is the chunk table indexed by blob and chunk index.
+	chunks map[chunkKey]ChunkInfo
+}
+
+type chunkKey struct {
+	blobIndex uint32
+	index     uint32
+}
+
+func (p *parser) slice(offset, size uint64) ([]byte, error) {
+	if offset > uint64(len(p.data)) || size > uint64(len(p.data))-offset {
+		return nil, fmt.Errorf("range [%d, %d) is out of bootstrap size %d", offset, offset+size, len(p.data))
+	}
+	return p.data[offset : offset+size], nil
+}
+
+func (p *parser) read(offset uint64, v interface{}) error {
+	buf, err := p.slice(offset, uint64(binary.Size(v)))
+	if err != nil {
+		return err
+	}
+	return binary.Read(bytes.NewReader(buf), binary.LittleEndian, v)
+}
+
+func countFlags(flags uint64, mask uint64) int {
+	return bits.OnesCount64(flags & mask)
+}
+
+func isValidChunkSize(size uint32) bool {
+	return size >= minChunkSize && size <= maxChunkSize && size&(size-1) == 0
+}
+
+func (p *parser) parseSuperBlock() error {
+	if len(p.data) >= 4 && binary.LittleEndian.Uint32(p.data) == rafsV5SuperMagic {
+		return errors.New("RAFS v5 is not supported")
+	}
+	if err := p.read(SuperOffset, &p.sb); err != nil {
+		return err
+	}
+	if p.sb.Magic != ErofsSuperMagic {
+		return fmt.Errorf("invalid magic 0x%x", p.sb.Magic)
+	}
+	if p.sb.Checksum != 0 {
+		return fmt.Errorf("unsupported checksum 0x%x", p.sb.Checksum)
+	}
+	if p.sb.FeatureCompat&ErofsFeatureCompatRafsV6 == 0 {
+		return fmt.Errorf("no RAFS v6 compatible feature in 0x%x", p.sb.FeatureCompat)
+	}
+	if p.sb.BlkSzBits != 12 && p.sb.BlkSzBits != 9 {
+		return fmt.Errorf("invalid block size bits %d", p.sb.BlkSzBits)
+	}
+	p.blockSize = 1 << p.sb.BlkSzBits
+	if uint64(len(p.data)) < minMetaSize || uint64(len(p.data))%p.blockSize != 0 {
+		return fmt.Errorf("bootstrap size %d is not aligned to block size %d", len(p.data), p.blockSize)
+	}
+	if p.sb.ExtSlots != 0 {
+		return fmt.Errorf("unsupported extended slots %d", p.sb.ExtSlots)
+	}
+	if p.sb.Inos == 0 {
+		return errors.New("no inode")
+	}
+	if p.sb.XattrBlkAddr != 0 {
+		return fmt.Errorf("unsupported shared xattr block address %d", p.sb.XattrBlkAddr)
+	}
+	if p.sb.FeatureIncompat != ErofsFeatureIncompatChunkedFile|ErofsFeatureIncompatDeviceTable {
+		return fmt.Errorf("unsupported incompatible features 0x%x", p.sb.FeatureIncompat)
+	}
+	if uint64(p.sb.DevtSlotOff)*DeviceSlotSize != DevTableOffset {
+		return fmt.Errorf("invalid device table slot offset %d", p.sb.DevtSlotOff)
+	}
+
+	if err := p.read(SuperOffset+SuperBlockSize, &p.ext); err != nil {
+		return err
+	}
+	compressors := uint64(FlagCompressionNone | FlagCompressionLZ4 | FlagCompressionGzip | FlagCompressionZstd)
+	if countFlags(p.ext.Flags, compressors) != 1 {
+		return fmt.Errorf("invalid compression flags 0x%x", p.ext.Flags)
+	}
+	if countFlags(p.ext.Flags, FlagHashBlake3|FlagHashSHA256) != 1 {
+		return fmt.Errorf("invalid hash flags 0x%x", p.ext.Flags)
+	}
+	if !isValidChunkSize(p.ext.ChunkSize) {
+		return fmt.Errorf("invalid chunk size 0x%x", p.ext.ChunkSize)
+	}
+	if p.ext.ChunkTableSize%ChunkInfoSize != 0 {
+		return fmt.Errorf("chunk table size %d is not aligned to %d", p.ext.ChunkTableSize, ChunkInfoSize)
+	}
+	if _, err := p.slice(p.ext.ChunkTableOffset, p.ext.ChunkTableSize); err != nil {
+		return errors.Wrap(err, "chunk table")
+	}
+
+	return nil
+}
+
+func (p *parser) parseBlobTable() error {
+	offset, size := p.ext.BlobTableOffset, uint64(p.ext.BlobTableSize)
+	if size == 0 {
+		return nil
+	}
+	if offset%minMetaSize != 0 || offset < DevTableOffset+uint64(p.sb.ExtraDevices)*DeviceSlotSize {
+		return fmt.Errorf("invalid offset %d", offset)
+	}
+	if size%BlobEntrySize != 0 {
+		return fmt.Errorf("size %d is not aligned to %d", size, BlobEntrySize)
+	}
+	if _, err := p.slice(offset, size); err != nil {
+		return err
+	}
+
+	for idx := uint64(0); idx < size/BlobEntrySize; idx++ {
+		var entry BlobEntry
+		if err := p.read(offset+idx*BlobEntrySize, &entry); err != nil {
+			return err
+		}
+		blobID := string(entry.BlobID[:])
+		if !isBlobID(blobID) {
+			return fmt.Errorf("invalid blob id %q at index %d", strings.TrimRight(blobID, "\x00"), idx)
+		}
+		if uint64(entry.BlobIndex) != idx {
+			return fmt.Errorf("blob %s has index %d at index %d", blobID, entry.BlobIndex, idx)
+		}
+		if entry.ChunkSize != p.ext.ChunkSize {
+			return fmt.Errorf("blob %s has chunk size 0x%x, but 0x%x in superblock", blobID, entry.ChunkSize, p.ext.ChunkSize)
+		}
+		compressor, ok := compressorNames[entry.CompressionAlgo]
+		if !ok {
+			return fmt.Errorf("blob %s has invalid compression algorithm %d", blobID, entry.CompressionAlgo)
+		}
+		p.blobs = append(p.blobs, Blob{
+			ID:               blobID,
+			ChunkCount:       entry.ChunkCount,
+			CompressedSize:   entry.CompressedSize,
+			UncompressedSize: entry.UncompressedSize,
+			Compressor:       compressor,
+			Features:         entry.Features,
+		})
+	}
+
+	return nil
+}
+
+// isBlobID checks if the id is the hex of sha256 digest.
+func isBlobID(id string) bool {
+	if len(id) != blobIDSize {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) parseDeviceTable() error {
+	blobs := map[string]bool{}
+	for _, blob := range p.blobs {
+		blobs[blob.ID] = true
+	}
+	for idx := uint64(0); idx < uint64(p.sb.ExtraDevices); idx++ {
+		var slot DeviceSlot
+		if err := p.read(DevTableOffset+idx*DeviceSlotSize, &slot); err != nil {
+			return err
+		}
+		blobID := string(slot.BlobID[:])
+		if !isBlobID(blobID) {
+			return fmt.Errorf("invalid blob id %q at index %d", strings.TrimRight(blobID, "\x00"), idx)
+		}
+		if slot.Blocks == 0 {
+			return fmt.Errorf("blob %s has no block", blobID)
+		}
+		if !blobs[blobID] {
+			return fmt.Errorf("blob %s is not in blob table", blobID)
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseChunkTable() error {
+	for offset := uint64(0); offset < p.ext.ChunkTableSize; offset += ChunkInfoSize {
+		var info ChunkInfo
+		if err := p.read(p.ext.ChunkTableOffset+offset, &info); err != nil {
+			return err
+		}
+		if info.BlobIndex >= uint32(len(p.blobs)) {
+			return fmt.Errorf("chunk %d refers to invalid blob index %d of %d blobs", offset/ChunkInfoSize, info.BlobIndex, len(p.blobs))
+		}
+		p.chunks[chunkKey{blobIndex: info.BlobIndex, index: info.Index}] = info
+	}
+	return nil
+}
+
+func (p *parser) parsePrefetchTable() error {
+	offset, size := p.ext.PrefetchTableOffset, uint64(p.ext.PrefetchTableSize)
+	if size%prefetchEntrySize != 0 {
+		return fmt.Errorf("size %d is not aligned to %d", size, prefetchEntrySize)
+	}
+	table, err := p.slice(offset, size)
+	if err != nil {
+		return err
+	}
+	for idx := 0; idx < len(table); idx += prefetchEntrySize {
+		nid := uint64(binary.LittleEndian.Uint32(table[idx:]))
+		filePath, ok := p.nids[nid]
+		if !ok {
+			return fmt.Errorf("inode %d is not in inode tree", nid)
+		}
+		p.prefetch = append(p.prefetch, filePath)
+	}
+	return nil
+}
+
+// inode is the compact or extended inode of RAFS v6.
+type inode struct {
+	nid         uint64
+	offset      uint64
+	format      uint16
+	xattrICount uint16
+	mode        uint32
+	nlink       uint32
+	size        uint64
+	u           uint32
+	uid         uint32
+	gid         uint32
+}
+
+func (ino *inode) layout() uint16 {
+	return ino.format >> 1
+}
+
+// metaSize returns the size of inode and inline xattrs.
+func (ino *inode) metaSize() uint64 {
+	size := uint64(compactInodeSize)
+	if ino.format&ErofsInodeLayoutExtended != 0 {
+		size = ExtendedInodeSize
+	}
+	if ino.xattrICount > 0 {
+		size += XattrIbodyHeaderSize + uint64(ino.xattrICount-1)*XattrEntrySize
+	}
+	return size
+}
+
+func (p *parser) inode(nid uint64) (*inode, error) {
+	offset := uint64(p.sb.MetaBlkAddr)*p.blockSize + nid*InodeSlotSize
+	buf, err := p.slice(offset, 2)
+	if err != nil {
+		return nil, errors.Wrapf(err, "inode %d", nid)
+	}
+	format := binary.LittleEndian.Uint16(buf)
+	if format&^erofsInodeFormatMask != 0 {
+		return nil, fmt.Errorf("inode %d has unsupported format 0x%x", nid, format)
+	}
+
+	ino := &inode{nid: nid, offset: offset}
+	if format&ErofsInodeLayoutExtended != 0 {
+		var disk ExtendedInode
+		if err := p.read(offset, &disk); err != nil {
+			return nil, errors.Wrapf(err, "inode %d", nid)
+		}
+		ino.format, ino.xattrICount, ino.mode, ino.nlink = disk.Format, disk.XattrICount, uint32(disk.Mode), disk.Nlink
+		ino.size, ino.u, ino.uid, ino.gid = disk.Size, disk.U, disk.UID, disk.GID
+	} else {
+		var disk compactInode
+		if err := p.read(offset, &disk); err != nil {
+			return nil, errors.Wrapf(err, "inode %d", nid)
+		}
+		ino.format, ino.xattrICount, ino.mode, ino.nlink = disk.Format, disk.XattrICount, uint32(disk.Mode), uint32(disk.Nlink)
+		ino.size, ino.u, ino.uid, ino.gid = uint64(disk.Size), disk.U, uint32(disk.UID), uint32(disk.GID)
+	}
+
+	switch ino.layout() {
+	case ErofsInodeFlatPlain, ErofsInodeFlatInline, ErofsInodeChunkBased:
+	default:
+		return nil, fmt.Errorf("inode %d has unsupported data layout %d", nid, ino.layout())
+	}
+	if _, ok := modeFileTypes[ino.mode&modeTypeMask]; !ok {
+		return nil, fmt.Errorf("inode %d has invalid mode 0%o", nid, ino.mode)
+	}
+	if _, err := p.slice(offset, ino.metaSize()); err != nil {
+		return nil, errors.Wrapf(err, "inode %d", nid)
+	}
+
+	return ino, nil
+}
+
+// inodeData reads the data of directory or symlink, which is stored in
+// bootstrap by flat plain or flat inline layout.
+func (p *parser) inodeData(ino *inode) ([]byte, error) {
+	offset := uint64(ino.u) * p.blockSize
+	switch ino.layout() {
+	case ErofsInodeFlatPlain:
+		return p.slice(offset, ino.size)
+	case ErofsInodeFlatInline:
+		full := ino.size / p.blockSize * p.blockSize
+		head, err := p.slice(offset, full)
+		if err != nil {
+			return nil, err
+		}
+		tail, err := p.slice(ino.offset+ino.metaSize(), ino.size-full)
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]byte{}, head...), tail...), nil
+	}
+	return nil, fmt.Errorf("unexpected data layout %d", ino.layout())
+}
+
+type entry struct {
+	name string
+	Dirent
+}
+
+// readDir reads the directory entries, which are sorted by name in each
+// block, and across the blocks.
+func (p *parser) readDir(ino *inode) ([]entry, error) {
+	data, err := p.inodeData(ino)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for blk := uint64(0); blk < uint64(len(data)); blk += p.blockSize {
+		block := data[blk:min(blk+p.blockSize, uint64(len(data)))]
+		if len(block) < DirentSize {
+			return nil, fmt.Errorf("block %d is too small", blk/p.blockSize)
+		}
+		first := binary.LittleEndian.Uint16(block[8:])
+		if first == 0 || first%DirentSize != 0 || int(first) > len(block) {
+			return nil, fmt.Errorf("block %d has invalid name offset %d", blk/p.blockSize, first)
+		}
+		count := int(first) / DirentSize
+		for i := 0; i < count; i++ {
+			var d Dirent
+			if err := binary.Read(bytes.NewReader(block[i*DirentSize:]), binary.LittleEndian, &d); err != nil {
+				return nil, err
+			}
+			end := len(block)
+			if i+1 < count {
+				end = int(binary.LittleEndian.Uint16(block[(i+1)*DirentSize+8:]))
+			}
+			if int(d.NameOff) >= end || end > len(block) {
+				return nil, fmt.Errorf("block %d has invalid name range [%d, %d)", blk/p.blockSize, d.NameOff, end)
+			}
+			name := string(block[d.NameOff:end])
+			if i+1 == count {
+				// The name of last entry is padded by zero.
+				name = strings.TrimRight(name, "\x00")
+			}
+			if name == "" || strings.ContainsAny(name, "/\x00") {
+				return nil, fmt.Errorf("invalid name %q", name)
+			}
+			if len(entries) > 0 && entries[len(entries)-1].name >= name {
+				return nil, fmt.Errorf("entry %q is not sorted after %q", name, entries[len(entries)-1].name)
+			}
+			entries = append(entries, entry{name: name, Dirent: d})
+		}
+	}
+
+	return entries, nil
+}
+
+// checkChunks validates the chunk addresses of regular file, and returns
+// the number of chunks and their information in chunk table.
+func (p *parser) checkChunks(ino *inode) (uint64, []Chunk, error) {
+	if ino.layout() != ErofsInodeChunkBased {
+		return 0, nil, nil
+	}
+	if ino.u&ErofsChunkFormatIndexes == 0 {
+		return 0, nil, fmt.Errorf("unsupported chunk format 0x%x", ino.u)
+	}
+	chunkSize := p.blockSize << (ino.u & erofsChunkFormatBlkBits)
+	if chunkSize != uint64(p.ext.ChunkSize) {
+		return 0, nil, fmt.Errorf("chunk size 0x%x mismatches 0x%x in superblock", chunkSize, p.ext.ChunkSize)
+	}
+
+	count := (ino.size + chunkSize - 1) / chunkSize
+	offset := (ino.offset + ino.metaSize() + ChunkAddrSize - 1) / ChunkAddrSize * ChunkAddrSize
+	if _, err := p.slice(offset, count*ChunkAddrSize); err != nil {
+		return 0, nil, err
+	}
+	chunks := make([]Chunk, 0, count)
+	for idx := uint64(0); idx < count; idx++ {
+		var addr ChunkAddr
+		if err := p.read(offset+idx*ChunkAddrSize, &addr); err != nil {
+			return 0, nil, err
+		}
+		blobIndex, ok := addr.blobIndex()
+		if !ok || blobIndex >= uint32(len(p.blobs)) {
+			return 0, nil, fmt.Errorf("chunk %d refers to invalid blob index %d of %d blobs", idx, addr.BlobAddrHi&0xff, len(p.blobs))
+		}
+		// The chunk of tarfs is not recorded in the compression
+		// information table of blob.
+		if p.ext.Flags&FlagTarfsMode == 0 && addr.ciIndex() >= p.blobs[blobIndex].ChunkCount {
+			return 0, nil, fmt.Errorf("chunk %d refers to chunk index %d of %d chunks in blob %s", idx, addr.ciIndex(), p.blobs[blobIndex].ChunkCount, p.blobs[blobIndex].ID)
+		}
+		if info, ok := p.chunks[chunkKey{blobIndex: blobIndex, index: addr.ciIndex()}]; ok && chunks != nil {
+			chunks = append(chunks, Chunk{
+				Digest:           info.BlockID,
+				BlobIndex:        blobIndex,
+				Index:            info.Index,
+				Flags:            info.Flags,
+				FileOffset:       idx * chunkSize,
+				CompressedOffset: info.CompressedOffset,
+				CompressedSize:   info.CompressedSize,
+				UncompressedSize: info.UncompressedSize,
+			})
+		} else {
+			chunks = nil
+		}
+	}
+
+	return count, chunks, nil
+}
+
+func (p *parser) parseTree() error {
+	root, err := p.inode(uint64(p.sb.RootNid))
+	if err != nil {
+		return err
+	}
+	if root.mode&modeTypeMask != modeDir {
+		return fmt.Errorf("root inode %d is not a directory", root.nid)
+	}
+	p.nids[root.nid] = "/"
+	return p.walk("/", root, root.nid)
+}
+
+func (p *parser) addFile(filePath string, ino *inode) error {
+	file := File{
+		Path:  filePath,
+		Nid:   ino.nid,
+		Mode:  ino.mode,
+		UID:   ino.uid,
+		GID:   ino.gid,
+		Nlink: ino.nlink,
+		Size:  ino.size,
+	}
+	switch ino.mode & modeTypeMask {
+	case modeRegular:
+		count, chunks, err := p.checkChunks(ino)
+		if err != nil {
+			return errors.Wrapf(err, "file %s", filePath)
+		}
+		file.Chunks, file.ChunkInfos = count, chunks
+	case modeSymlink:
+		if ino.layout() == ErofsInodeChunkBased {
+			return fmt.Errorf("symlink %s has chunk based layout", filePath)
+		}
+		target, err := p.inodeData(ino)
+		if err != nil {
+			return errors.Wrapf(err, "symlink %s", filePath)
+		}
+		file.Target = string(target)
+	}
+	p.files = append(p.files, file)
+	return nil
+}
+
+// walk validates the directory and its descendants recursively.
+func (p *parser) walk(dirPath string, dir *inode, parentNid uint64) error {
+	if dir.layout() == ErofsInodeChunkBased {
+		return fmt.Errorf("directory %s has chunk based layout", dirPath)
+	}
+	if p.visited[dir.nid] {
+		return fmt.Errorf("directory %s (inode %d) is linked more than once", dirPath, dir.nid)
+	}
+	p.visited[dir.nid] = true
+	if err := p.addFile(dirPath, dir); err != nil {
+		return err
+	}
+
+	entries, err := p.readDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "directory %s", dirPath)
+	}
+	var hasDot, hasDotDot bool
+	for _, entry := range entries {
+		switch entry.name {
+		case ".":
+			if entry.Nid != dir.nid {
+				return fmt.Errorf("directory %s: entry '.' refers to inode %d, expected %d", dirPath, entry.Nid, dir.nid)
+			}
+			hasDot = true
+			continue
+		case "..":
+			if entry.Nid != parentNid {
+				return fmt.Errorf("directory %s: entry '..' refers to inode %d, expected %d", dirPath, entry.Nid, parentNid)
+			}
+			hasDotDot = true
+			continue
+		}
+
+		childPath := path.Join(dirPath, entry.name)
+		child, err := p.inode(entry.Nid)
+		if err != nil {
+			return errors.Wrapf(err, "file %s", childPath)
+		}
+		if fileType := modeFileTypes[child.mode&modeTypeMask]; fileType != entry.FileType {
+			return fmt.Errorf("file %s has type %d in directory entry, but mode 0%o in inode", childPath, entry.FileType, child.mode)
+		}
+		if _, ok := p.nids[child.nid]; !ok {
+			p.nids[child.nid] = childPath
+		}
+		if entry.FileType == FileTypeDir {
+			if err := p.walk(childPath, child, dir.nid); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.addFile(childPath, child); err != nil {
+			return err
+		}
+	}
+	if !hasDot || !hasDotDot {
+		return fmt.Errorf("directory %s has no entry '.' or '..'", dirPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rafs_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/builder"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/rafs"
+)
+
+func buildBootstrap(t *testing.T) ([]byte, string) {
+	rootfs := t.TempDir()
+	output := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(rootfs, "dir/sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "big"), bytes.Repeat([]byte("0123456789abcdef"), 0x2800), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "dir/small"), []byte("hello nydus"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(rootfs, "empty"), nil, 0644))
+	require.NoError(t, os.Symlink("../big", filepath.Join(rootfs, "dir/symlink")))
+
+	bootstrapPath := filepath.Join(output, "image.boot")
+	blobPath := filepath.Join(output, "image.blob")
+	_, err := builder.Build(builder.Option{
+		RootfsPath:    rootfs,
+		BootstrapPath: bootstrapPath,
+		BlobPath:      blobPath,
+		ChunkSize:     0x10000,
+	})
+	require.NoError(t, err)
+	data, err := os.ReadFile(bootstrapPath)
+	require.NoError(t, err)
+	return data, blobPath
+}
+
+func findFile(t *testing.T, bootstrap *rafs.Bootstrap, path string) rafs.File {
+	for _, file := range bootstrap.Files {
+		if file.Path == path {
+			return file
+		}
+	}
+	t.Fatalf("file %s not found", path)
+	return rafs.File{}
+}
+
+func inodeOffset(data []byte, nid uint64) uint64 {
+	metaBlkAddr := binary.LittleEndian.Uint32(data[rafs.SuperOffset+40:])
+	return uint64(metaBlkAddr)*4096 + nid*rafs.InodeSlotSize
+}
+
+func TestParse(t *testing.T) {
+	data, _ := buildBootstrap(t)
+	bootstrap, err := rafs.Parse(data)
+	require.NoError(t, err)
+	require.Equal(t, uint32(4096), bootstrap.BlockSize)
+	require.Equal(t, uint32(0x10000), bootstrap.ChunkSize)
+	require.Equal(t, "v6", bootstrap.Version)
+	require.Equal(t, "zstd", bootstrap.Compressor)
+	require.Equal(t, "blake3", bootstrap.Digester)
+	require.Contains(t, bootstrap.Features, "HASH_BLAKE3")
+	require.Empty(t, bootstrap.Prefetch)
+	require.Len(t, bootstrap.Blobs, 1)
+
+	var paths []string
+	for _, file := range bootstrap.Files {
+		paths = append(paths, file.Path)
+	}
+	require.Equal(t, []string{"/", "/big", "/dir", "/dir/small", "/dir/sub", "/dir/symlink", "/empty"}, paths)
+	require.True(t, findFile(t, bootstrap, "/dir").IsDir())
+	require.Equal(t, uint64(3), findFile(t, bootstrap, "/big").Chunks)
+	require.Equal(t, uint64(len("hello nydus")), findFile(t, bootstrap, "/dir/small").Size)
+	require.Equal(t, "../big", findFile(t, bootstrap, "/dir/symlink").Target)
+	require.Zero(t, findFile(t, bootstrap, "/empty").Chunks)
+
+	t.Run("invalid magic", func(t *testing.T) {
+		corrupted := append([]byte{}, data...)
+		corrupted[rafs.SuperOffset] = 0
+		_, err := rafs.Parse(corrupted)
+		require.ErrorContains(t, err, "invalid superblock: invalid magic")
+	})
+
+	t.Run("rafs v5", func(t *testing.T) {
+		corrupted := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(corrupted, 0x52414653) // RAFS v5 magic
+		_, err := rafs.Parse(corrupted)
+		require.ErrorContains(t, err, "RAFS v5 is not supported")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := rafs.Parse(data[:len(data)-4096])
+		require.Error(t, err)
+	})
+
+	t.Run("mismatched file type", func(t *testing.T) {
+		corrupted := append([]byte{}, data...)
+		offset := inodeOffset(corrupted, findFile(t, bootstrap, "/empty").Nid)
+		binary.LittleEndian.PutUint16(corrupted[offset+4:], syscall.S_IFIFO|0644)
+		_, err := rafs.Parse(corrupted)
+		require.ErrorContains(t, err, "file /empty has type 1 in directory entry, but mode 010644 in inode")
+	})
+
+	t.Run("invalid blob index", func(t *testing.T) {
+		corrupted := append([]byte{}, data...)
+		offset := inodeOffset(corrupted, findFile(t, bootstrap, "/big").Nid) + rafs.ExtendedInodeSize
+		corrupted[offset+2] = 2
+		_, err := rafs.Parse(corrupted)
+		require.ErrorContains(t, err, "file /big: chunk 0 refers to invalid blob index 2 of 1 blobs")
+	})
+}
+
+func TestReadFile(t *testing.T) {
+	data, blobPath := buildBootstrap(t)
+	bootstrap, err := rafs.Parse(data)
+	require.NoError(t, err)
+	blob, err := os.ReadFile(blobPath)
+	require.NoError(t, err)
+
+	readFile := func(file *rafs.File) []byte {
+		require.Len(t, file.ChunkInfos, int(file.Chunks))
+		var content []byte
+		for _, chunk := range file.ChunkInfos {
+			require.Equal(t, uint64(len(content)), chunk.FileOffset)
+			decoded, err := bootstrap.DecodeChunk(chunk, blob[chunk.CompressedOffset:chunk.CompressedOffset+uint64(chunk.CompressedSize)])
+			require.NoError(t, err)
+			content = append(content, decoded...)
+		}
+		return content
+	}
+
+	file, err := bootstrap.Resolve("/dir/small")
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello nydus"), readFile(file))
+
+	big, err := bootstrap.Resolve("dir/symlink")
+	require.NoError(t, err)
+	require.Equal(t, "/big", big.Path)
+	require.Equal(t, bytes.Repeat([]byte("0123456789abcdef"), 0x2800), readFile(big))
+
+	file, err = bootstrap.Lookup("/dir/symlink")
+	require.NoError(t, err)
+	require.Equal(t, "Lrwxrwxrwx", file.FileMode().String())
+
+	dir, err := bootstrap.Resolve("/dir/sub/..")
+	require.NoError(t, err)
+	var names []string
+	for _, child := range bootstrap.Children(dir) {
+		names = append(names, child.Path)
+	}
+	require.Equal(t, []string{"/dir/small", "/dir/sub", "/dir/symlink"}, names)
+
+	_, err = bootstrap.Resolve("/empty/foo")
+	require.EqualError(t, err, "/empty: not a directory")
+	_, err = bootstrap.Resolve("/missing")
+	require.EqualError(t, err, "/missing: no such file or directory")
+
+	_, err = bootstrap.DecodeChunk(big.ChunkInfos[0], nil)
+	require.ErrorContains(t, err, "has 0 bytes")
+}
@@ -0,0 +1,68 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRateBurst limits the bytes read at once through a rate limiter.
+const maxRateBurst = 1 << 20
+
+// NewRateLimiter creates the limiter of bandwidth in bytes per second, it
+// returns nil if bytesPerSecond is not positive. The limiter can be shared
+// by the clients to limit their total bandwidth.
+func NewRateLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxRateBurst)))
+}
+
+// rateLimitTransport limits the bandwidth of request bodies by push limiter
+// and response bodies by pull limiter.
+type rateLimitTransport struct {
+	base http.RoundTripper
+	pull *rate.Limiter
+	push *rate.Limiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.push != nil && req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &rateLimitReader{ReadCloser: req.Body, ctx: req.Context(), limiter: t.push}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if t.pull != nil && resp.Body != nil {
+		resp.Body = &rateLimitReader{ReadCloser: resp.Body, ctx: req.Context(), limiter: t.pull}
+	}
+	return resp, nil
+}
+
+type rateLimitReader struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitReader) Read(p []byte) (int, error) {
+	if len(p) > r.limiter.Burst() {
+		p = p[:r.limiter.Burst()]
+	}
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	require.Nil(t, NewRateLimiter(0))
+	require.Equal(t, maxRateBurst, NewRateLimiter(100<<20).Burst())
+
+	data := bytes.Repeat([]byte("a"), 200<<10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			io.Copy(io.Discard, r.Body)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	// 100KB is allowed by the initial burst, the remaining 100KB takes 1s.
+	client, err := TransportOption{PullLimiter: NewRateLimiter(100 << 10)}.NewClient()
+	require.NoError(t, err)
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, data, body)
+	require.Greater(t, time.Since(start), 800*time.Millisecond)
+
+	client, err = TransportOption{PushLimiter: NewRateLimiter(100 << 10)}.NewClient()
+	require.NoError(t, err)
+	start = time.Now()
+	req, err := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader(data))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Greater(t, time.Since(start), 800*time.Millisecond)
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// TransportOption configures the HTTP client to access registry.
+type TransportOption struct {
+	// Insecure skips the verification of registry certificate.
+	Insecure bool
+	// CACert is the path of PEM encoded CA bundle to verify the registry
+	// certificate, it's appended to the system CA pool.
+	CACert string
+	// ClientCert and ClientKey are the paths of PEM encoded client
+	// certificate and key for mutual TLS.
+	ClientCert string
+	ClientKey  string
+	// Proxy is the URL of HTTP/HTTPS proxy, the proxy is read from the
+	// environment variables `HTTP_PROXY`, `HTTPS_PROXY` and `NO_PROXY` if
+	// it's empty.
+	Proxy string
+	// PullLimiter and PushLimiter limit the bandwidth of response and
+	// request bodies, see NewRateLimiter.
+	PullLimiter *rate.Limiter
+	PushLimiter *rate.Limiter
+}
+
+// WithInsecure returns a copy of option skipping the verification of
+// registry certificate if insecure is true.
+func (opt TransportOption) WithInsecure(insecure bool) TransportOption {
+	opt.Insecure = opt.Insecure || insecure
+	return opt
+}
+
+// Validate checks the option without reading the certificates.
+func (opt TransportOption) Validate() error {
+	if (opt.ClientCert == "") != (opt.ClientKey == "") {
+		return errors.New("client certificate and key must be specified together")
+	}
+	if opt.Proxy != "" {
+		if _, err := parseProxy(opt.Proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseProxy(proxy string) (*url.URL, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse proxy %s", proxy)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q, should be one of http, https and socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, errors.Errorf("invalid proxy %s", proxy)
+	}
+	return proxyURL, nil
+}
+
+// TLSConfig loads the CA bundle and client certificate of option.
+func (opt TransportOption) TLSConfig() (*tls.Config, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: opt.Insecure,
+	}
+	if opt.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(opt.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "read CA certificate")
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("no valid certificate found in %s", opt.CACert)
+		}
+		config.RootCAs = pool
+	}
+	if opt.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(opt.ClientCert, opt.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewClient creates the HTTP client to access registry with option.
+func (opt TransportOption) NewClient() (*http.Client, error) {
+	tlsConfig, err := opt.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	proxy := http.ProxyFromEnvironment
+	if opt.Proxy != "" {
+		proxyURL, err := parseProxy(opt.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 5 * time.Second,
+		DisableKeepAlives:     true,
+		TLSNextProto:          make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+		TLSClientConfig:       tlsConfig,
+	}
+	if opt.PullLimiter != nil || opt.PushLimiter != nil {
+		transport = &rateLimitTransport{
+			base: transport,
+			pull: opt.PullLimiter,
+			push: opt.PushLimiter,
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+	}, nil
+}
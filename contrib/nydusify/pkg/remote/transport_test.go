@@ -0,0 +1,91 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package remote
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, path, typ string, data []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: data}), 0600))
+}
+
+func TestTransportOptionMutualTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	writePEM(t, caCert, "CERTIFICATE", server.Certificate().Raw)
+
+	// The self-signed certificate of test server is unknown to system CAs.
+	client, err := TransportOption{}.NewClient()
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	client, err = TransportOption{Insecure: true}.NewClient()
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Reuse the server certificate as client certificate.
+	serverCert := server.TLS.Certificates[0]
+	clientCert := filepath.Join(dir, "client.pem")
+	clientKey := filepath.Join(dir, "client-key.pem")
+	writePEM(t, clientCert, "CERTIFICATE", serverCert.Certificate[0])
+	key, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	require.NoError(t, err)
+	writePEM(t, clientKey, "PRIVATE KEY", key)
+
+	client, err = TransportOption{CACert: caCert, ClientCert: clientCert, ClientKey: clientKey}.NewClient()
+	require.NoError(t, err)
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = TransportOption{CACert: clientKey}.NewClient()
+	require.ErrorContains(t, err, "no valid certificate")
+	_, err = TransportOption{ClientCert: clientCert}.NewClient()
+	require.ErrorContains(t, err, "must be specified together")
+}
+
+func TestTransportOptionProxy(t *testing.T) {
+	require.NoError(t, TransportOption{Proxy: "http://proxy.example.com:3128"}.Validate())
+	require.Error(t, TransportOption{Proxy: "ftp://proxy.example.com"}.Validate())
+	require.Error(t, TransportOption{Proxy: "proxy.example.com:3128"}.Validate())
+
+	client, err := TransportOption{Proxy: "socks5://127.0.0.1:1080"}.NewClient()
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, "https://registry.example.com/v2/", nil)
+	require.NoError(t, err)
+	proxyURL, err := client.Transport.(*http.Transport).Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, &url.URL{Scheme: "socks5", Host: "127.0.0.1:1080"}, proxyURL)
+
+	require.True(t, TransportOption{}.WithInsecure(true).Insecure)
+	require.True(t, TransportOption{Insecure: true}.WithInsecure(false).Insecure)
+}
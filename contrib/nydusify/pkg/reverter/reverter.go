@@ -0,0 +1,556 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package reverter converts a nydus image back into an OCI image with
+// gzip or eStargz layers, for the runtimes without nydus support.
+package reverter
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	snapConv "github.com/BraveY/snapshotter-converter/converter"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/distribution/reference"
+	"github.com/goharbor/acceleration-service/pkg/errdefs"
+	"github.com/goharbor/acceleration-service/pkg/platformutil"
+	"github.com/goharbor/acceleration-service/pkg/remote"
+	"github.com/goharbor/acceleration-service/pkg/utils"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/backend"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/build"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/converter/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	nydusifyUtils "github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/workdir"
+)
+
+const (
+	TargetFormatOCI     = "oci"
+	TargetFormatEStargz = "estargz"
+)
+
+type Opt struct {
+	WorkDir        string
+	NydusImagePath string
+
+	Source string
+	Target string
+
+	SourceInsecure bool
+	TargetInsecure bool
+	WithPlainHTTP  bool
+
+	// SourceBackendType and SourceBackendConfig specify the storage backend
+	// of the blobs of source image, whose manifest has the bootstrap layer
+	// only.
+	SourceBackendType   string
+	SourceBackendConfig string
+
+	AllPlatforms bool
+	Platforms    string
+
+	// TargetFormat is the format of target image layers, "oci" for gzip
+	// layers or "estargz" for eStargz layers.
+	TargetFormat string
+}
+
+type reverter struct {
+	opt     Opt
+	pvd     *provider.Provider
+	backend backend.Backend
+	builder *build.Builder
+	workDir string
+}
+
+func hosts(opt Opt) remote.HostFunc {
+	maps := map[string]bool{
+		opt.Source: opt.SourceInsecure,
+		opt.Target: opt.TargetInsecure,
+	}
+	return func(ref string) (remote.CredentialFunc, bool, error) {
+		return remote.NewDockerConfigCredFunc(), maps[ref], nil
+	}
+}
+
+// Revert converts the nydus image of source into an OCI image, and pushes
+// it to target. Each nydus blob layer is unpacked into an OCI layer by
+// `nydus-image unpack`, the nydus bootstrap layer is dropped.
+func Revert(ctx context.Context, opt Opt) error {
+	ctx = namespaces.WithNamespace(ctx, "nydusify")
+
+	switch opt.TargetFormat {
+	case "":
+		opt.TargetFormat = TargetFormatOCI
+	case TargetFormatOCI, TargetFormatEStargz:
+	default:
+		return fmt.Errorf("unsupported target format %s", opt.TargetFormat)
+	}
+
+	platformMC, err := platformutil.ParsePlatforms(opt.AllPlatforms, opt.Platforms)
+	if err != nil {
+		return err
+	}
+
+	var bkd backend.Backend
+	if opt.SourceBackendType != "" {
+		bkd, err = backend.NewBackend(opt.SourceBackendType, []byte(opt.SourceBackendConfig), nil)
+		if err != nil {
+			return errors.Wrap(err, "new source backend")
+		}
+	}
+
+	if _, err := os.Stat(opt.WorkDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(opt.WorkDir, 0755); err != nil {
+				return errors.Wrap(err, "prepare work directory")
+			}
+			// We should only clean up when the work directory not exists
+			// before, otherwise it may delete user data by mistake.
+			defer os.RemoveAll(opt.WorkDir)
+		} else {
+			return errors.Wrap(err, "stat work directory")
+		}
+	}
+	tmpDir, err := os.MkdirTemp(opt.WorkDir, workdir.Prefix)
+	if err != nil {
+		return errors.Wrap(err, "create temp directory")
+	}
+	defer os.RemoveAll(tmpDir)
+	unlock, err := workdir.Lock(tmpDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pvd, err := provider.New(tmpDir, hosts(opt), 200, "v1", platformMC, 0, nil)
+	if err != nil {
+		return err
+	}
+	if opt.WithPlainHTTP {
+		pvd.UsePlainHTTP()
+	}
+
+	sourceNamed, err := reference.ParseDockerRef(opt.Source)
+	if err != nil {
+		return errors.Wrap(err, "parse source reference")
+	}
+	source := sourceNamed.String()
+	targetNamed, err := reference.ParseDockerRef(opt.Target)
+	if err != nil {
+		return errors.Wrap(err, "parse target reference")
+	}
+	target := targetNamed.String()
+
+	logrus.Infof("pulling source image %s", source)
+	if err := pvd.Pull(ctx, source); err != nil {
+		if errdefs.NeedsRetryWithHTTP(err) {
+			pvd.UsePlainHTTP()
+			if err := pvd.Pull(ctx, source); err != nil {
+				return errors.Wrap(err, "try to pull image")
+			}
+		} else {
+			return errors.Wrap(err, "pull source image")
+		}
+	}
+	logrus.Infof("pulled source image %s", source)
+
+	sourceImage, err := pvd.Image(ctx, source)
+	if err != nil {
+		return errors.Wrap(err, "find image from store")
+	}
+	sourceDescs, err := utils.GetManifests(ctx, pvd.ContentStore(), *sourceImage, platformMC)
+	if err != nil {
+		return errors.Wrap(err, "get image manifests")
+	}
+
+	r := &reverter{
+		opt:     opt,
+		pvd:     pvd,
+		backend: bkd,
+		builder: build.NewBuilder(opt.NydusImagePath),
+		workDir: tmpDir,
+	}
+
+	var targetDescs []ocispec.Descriptor
+	for _, sourceDesc := range sourceDescs {
+		targetDesc, err := r.revertManifest(ctx, sourceDesc, target)
+		if err != nil {
+			return errors.Wrapf(err, "revert manifest %s", sourceDesc.Digest)
+		}
+		if targetDesc == nil {
+			logrus.Infof("skip manifest %s which is not a nydus image", sourceDesc.Digest)
+			continue
+		}
+		targetDescs = append(targetDescs, *targetDesc)
+	}
+	if len(targetDescs) == 0 {
+		return fmt.Errorf("no nydus image found in %s", source)
+	}
+
+	targetImage := &targetDescs[0]
+	if sourceImage.MediaType == ocispec.MediaTypeImageIndex ||
+		sourceImage.MediaType == images.MediaTypeDockerSchema2ManifestList {
+		targetIndex := ocispec.Index{}
+		if _, err := utils.ReadJSON(ctx, pvd.ContentStore(), &targetIndex, *sourceImage); err != nil {
+			return errors.Wrap(err, "read source manifest list")
+		}
+		targetIndex.Manifests = targetDescs
+		if targetImage, err = utils.WriteJSON(ctx, pvd.ContentStore(), targetIndex, *sourceImage, target, nil); err != nil {
+			return errors.Wrap(err, "write target manifest list")
+		}
+	}
+
+	logrus.Infof("pushing target image %s", target)
+	if err := pvd.Push(ctx, *targetImage, target); err != nil {
+		if errdefs.NeedsRetryWithHTTP(err) {
+			pvd.UsePlainHTTP()
+			if err := pvd.Push(ctx, *targetImage, target); err != nil {
+				return errors.Wrap(err, "try to push image")
+			}
+		} else {
+			return errors.Wrap(err, "push target image")
+		}
+	}
+	logrus.Infof("pushed target image %s", target)
+
+	return nil
+}
+
+// revertManifest returns nil if the manifest is not a nydus image.
+func (r *reverter) revertManifest(ctx context.Context, desc ocispec.Descriptor, target string) (*ocispec.Descriptor, error) {
+	store := r.pvd.ContentStore()
+	manifest := ocispec.Manifest{}
+	if _, err := utils.ReadJSON(ctx, store, &manifest, desc); err != nil {
+		return nil, errors.Wrap(err, "read manifest")
+	}
+	bootstrapDesc := parser.FindNydusBootstrapDesc(&manifest)
+	if bootstrapDesc == nil {
+		return nil, nil
+	}
+
+	layerMediaType := ocispec.MediaTypeImageLayerGzip
+	if manifest.MediaType == images.MediaTypeDockerSchema2Manifest || desc.MediaType == images.MediaTypeDockerSchema2Manifest {
+		layerMediaType = images.MediaTypeDockerSchema2LayerGzip
+	}
+
+	var layers []ocispec.Descriptor
+	var diffIDs []digest.Digest
+	blobLayers := manifest.Layers[:len(manifest.Layers)-1]
+	if len(blobLayers) == 0 {
+		// The blobs are in storage backend rather than the layers of image,
+		// so the image is reverted into one layer by the bootstrap.
+		logrus.WithField("digest", bootstrapDesc.Digest).Infof("reverting bootstrap with blobs in backend")
+		targetLayer, diffID, err := r.revertBootstrap(ctx, *bootstrapDesc, layerMediaType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "revert bootstrap %s", bootstrapDesc.Digest)
+		}
+		logrus.WithField("digest", bootstrapDesc.Digest).Infof("reverted bootstrap to %s", targetLayer.Digest)
+		layers = append(layers, *targetLayer)
+		diffIDs = append(diffIDs, diffID)
+	}
+	for _, layer := range blobLayers {
+		if layer.Annotations[nydusifyUtils.LayerAnnotationNydusBlob] != "true" {
+			return nil, fmt.Errorf("unsupported layer %s, only nydus blob layers can be reverted", layer.Digest)
+		}
+		logrus.WithField("digest", layer.Digest).Infof("reverting layer")
+		targetLayer, diffID, err := r.revertLayer(ctx, layer, layerMediaType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "revert layer %s", layer.Digest)
+		}
+		logrus.WithField("digest", layer.Digest).Infof("reverted layer to %s", targetLayer.Digest)
+		layers = append(layers, *targetLayer)
+		diffIDs = append(diffIDs, diffID)
+	}
+
+	config := ocispec.Image{}
+	if _, err := utils.ReadJSON(ctx, store, &config, manifest.Config); err != nil {
+		return nil, errors.Wrap(err, "read image config")
+	}
+	config.RootFS.DiffIDs = diffIDs
+	// The history must match the layers, drop it if the layers are changed
+	// by nydus conversion.
+	if countLayerHistory(config.History) != len(diffIDs) {
+		config.History = nil
+	}
+	configDesc, err := utils.WriteJSON(ctx, store, config, manifest.Config, target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "write image config")
+	}
+
+	manifest.Config = *configDesc
+	manifest.Layers = layers
+	manifest.Subject = nil
+	if manifest.ArtifactType == nydusifyUtils.ArtifactTypeNydusImageManifest {
+		manifest.ArtifactType = ""
+	}
+	targetDesc, err := utils.WriteJSON(ctx, store, manifest, desc, target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "write manifest")
+	}
+	targetDesc.ArtifactType = ""
+	if targetDesc.Platform != nil {
+		platform := *targetDesc.Platform
+		platform.OSFeatures = nil
+		for _, feature := range targetDesc.Platform.OSFeatures {
+			if feature != nydusifyUtils.ManifestOSFeatureNydus {
+				platform.OSFeatures = append(platform.OSFeatures, feature)
+			}
+		}
+		targetDesc.Platform = &platform
+	}
+
+	return targetDesc, nil
+}
+
+func countLayerHistory(history []ocispec.History) int {
+	count := 0
+	for _, h := range history {
+		if !h.EmptyLayer {
+			count++
+		}
+	}
+	return count
+}
+
+// bootstrapBlobIDs returns the blob IDs referenced by the bootstrap.
+func (r *reverter) bootstrapBlobIDs(bootstrapPath, dir string) ([]string, error) {
+	outputPath := filepath.Join(dir, "output.json")
+	if err := tool.NewBuilder(r.opt.NydusImagePath).Check(tool.BuilderOption{
+		BootstrapPath:   bootstrapPath,
+		DebugOutputPath: outputPath,
+	}); err != nil {
+		return nil, errors.Wrap(err, "check bootstrap")
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read output file")
+	}
+	var out struct {
+		Blobs []string `json:"blobs"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, errors.Wrap(err, "unmarshal output json")
+	}
+	var blobIDs []string
+	seen := map[string]bool{}
+	for _, blobID := range out.Blobs {
+		if !seen[blobID] {
+			seen[blobID] = true
+			blobIDs = append(blobIDs, blobID)
+		}
+	}
+	return blobIDs, nil
+}
+
+// revertBootstrap unpacks the bootstrap of the image whose blobs are in
+// source backend into an OCI tar, then compresses it into the content
+// store, returns the layer descriptor and diff id.
+func (r *reverter) revertBootstrap(ctx context.Context, bootstrap ocispec.Descriptor, mediaType string) (*ocispec.Descriptor, digest.Digest, error) {
+	if r.backend == nil {
+		return nil, "", fmt.Errorf("no blob layer in image, the source backend of blobs is required")
+	}
+	layerDir, err := os.MkdirTemp(r.workDir, "layer-")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "create layer directory")
+	}
+	defer os.RemoveAll(layerDir)
+
+	ra, err := r.pvd.ContentStore().ReaderAt(ctx, bootstrap)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "open bootstrap layer")
+	}
+	defer ra.Close()
+	bootstrapPath := filepath.Join(layerDir, "image.boot")
+	if err := nydusifyUtils.UnpackFile(io.NewSectionReader(ra, 0, ra.Size()), nydusifyUtils.BootstrapFileNameInLayer, bootstrapPath); err != nil {
+		return nil, "", errors.Wrap(err, "unpack bootstrap layer")
+	}
+
+	blobIDs, err := r.bootstrapBlobIDs(bootstrapPath, layerDir)
+	if err != nil {
+		return nil, "", err
+	}
+	blobDir := filepath.Join(layerDir, "blobs")
+	if err := os.Mkdir(blobDir, 0755); err != nil {
+		return nil, "", errors.Wrap(err, "create blob directory")
+	}
+	logrus.Infof("fetching %d blobs from source backend", len(blobIDs))
+	if err := backend.FetchBlobs(ctx, r.backend, blobIDs, blobDir); err != nil {
+		return nil, "", errors.Wrap(err, "fetch blobs from source backend")
+	}
+
+	tarPath := filepath.Join(layerDir, "layer.tar")
+	if err := r.builder.Unpack(build.UnpackOption{
+		BootstrapPath: bootstrapPath,
+		BlobDir:       blobDir,
+		OutputPath:    tarPath,
+	}); err != nil {
+		return nil, "", errors.Wrap(err, "unpack nydus image to tar")
+	}
+	return r.compressLayer(ctx, tarPath, layerDir, mediaType)
+}
+
+// revertLayer unpacks the nydus blob layer into an OCI tar, then compresses
+// it into the content store, returns the layer descriptor and diff id.
+func (r *reverter) revertLayer(ctx context.Context, layer ocispec.Descriptor, mediaType string) (*ocispec.Descriptor, digest.Digest, error) {
+	layerDir, err := os.MkdirTemp(r.workDir, "layer-")
+	if err != nil {
+		return nil, "", errors.Wrap(err, "create layer directory")
+	}
+	defer os.RemoveAll(layerDir)
+
+	ra, err := r.pvd.ContentStore().ReaderAt(ctx, layer)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "open layer")
+	}
+	defer ra.Close()
+
+	bootstrapPath := filepath.Join(layerDir, "image.boot")
+	if err := unpackEntry(ra, snapConv.EntryBootstrap, bootstrapPath); err != nil {
+		return nil, "", errors.Wrap(err, "unpack layer bootstrap")
+	}
+	blobPath := filepath.Join(layerDir, "image.blob")
+	if err := unpackEntry(ra, snapConv.EntryBlob, blobPath); err != nil {
+		return nil, "", errors.Wrap(err, "unpack layer blob")
+	}
+
+	tarPath := filepath.Join(layerDir, "layer.tar")
+	if err := r.builder.Unpack(build.UnpackOption{
+		BootstrapPath: bootstrapPath,
+		BlobPath:      blobPath,
+		OutputPath:    tarPath,
+	}); err != nil {
+		return nil, "", errors.Wrap(err, "unpack nydus layer to tar")
+	}
+	return r.compressLayer(ctx, tarPath, layerDir, mediaType)
+}
+
+// compressLayer compresses the OCI tar in the target format into the
+// content store, returns the layer descriptor and diff id.
+func (r *reverter) compressLayer(ctx context.Context, tarPath, layerDir, mediaType string) (*ocispec.Descriptor, digest.Digest, error) {
+	compressedPath := filepath.Join(layerDir, "layer.tar.gz")
+	var desc *ocispec.Descriptor
+	var diffID digest.Digest
+	var err error
+	if r.opt.TargetFormat == TargetFormatEStargz {
+		desc, diffID, err = compressEStargz(tarPath, compressedPath)
+	} else {
+		desc, diffID, err = compressGzip(tarPath, compressedPath)
+	}
+	if err != nil {
+		return nil, "", errors.Wrap(err, "compress layer")
+	}
+	desc.MediaType = mediaType
+
+	file, err := os.Open(compressedPath)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "open compressed layer")
+	}
+	defer file.Close()
+	if err := content.WriteBlob(ctx, r.pvd.ContentStore(), desc.Digest.String(), file, *desc); err != nil {
+		return nil, "", errors.Wrap(err, "write layer into content store")
+	}
+
+	return desc, diffID, nil
+}
+
+func unpackEntry(ra content.ReaderAt, entry, target string) error {
+	file, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = snapConv.UnpackEntry(ra, entry, file)
+	return err
+}
+
+func compressGzip(src, dst string) (*ocispec.Descriptor, digest.Digest, error) {
+	tarFile, err := os.Open(src)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tarFile.Close()
+	file, err := os.Create(dst)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	digester := digest.Canonical.Digester()
+	counter := &countWriter{}
+	gw := gzip.NewWriter(io.MultiWriter(file, digester.Hash(), counter))
+	diffIDDigester := digest.Canonical.Digester()
+	if _, err := io.Copy(io.MultiWriter(gw, diffIDDigester.Hash()), tarFile); err != nil {
+		return nil, "", err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return &ocispec.Descriptor{
+		Digest: digester.Digest(),
+		Size:   counter.size,
+	}, diffIDDigester.Digest(), nil
+}
+
+func compressEStargz(src, dst string) (*ocispec.Descriptor, digest.Digest, error) {
+	tarFile, err := os.Open(src)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tarFile.Close()
+	info, err := tarFile.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := os.Create(dst)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	blob, err := estargz.Build(io.NewSectionReader(tarFile, 0, info.Size()))
+	if err != nil {
+		return nil, "", errors.Wrap(err, "build eStargz")
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(io.MultiWriter(file, digester.Hash()), blob)
+	if err != nil {
+		blob.Close()
+		return nil, "", err
+	}
+	if err := blob.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return &ocispec.Descriptor{
+		Digest: digester.Digest(),
+		Size:   size,
+		Annotations: map[string]string{
+			estargz.TOCJSONDigestAnnotation: blob.TOCDigest().String(),
+		},
+	}, blob.DiffID(), nil
+}
+
+type countWriter struct {
+	size int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.size += int64(len(p))
+	return len(p), nil
+}
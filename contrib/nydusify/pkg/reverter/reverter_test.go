@@ -0,0 +1,53 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reverter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressGzip(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("nydus layer"), 1024)
+	src := filepath.Join(dir, "layer.tar")
+	require.NoError(t, os.WriteFile(src, data, 0644))
+
+	dst := filepath.Join(dir, "layer.tar.gz")
+	desc, diffID, err := compressGzip(src, dst)
+	require.NoError(t, err)
+	require.Equal(t, digest.FromBytes(data), diffID)
+
+	compressed, err := os.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, digest.FromBytes(compressed), desc.Digest)
+	require.Equal(t, int64(len(compressed)), desc.Size)
+
+	gr, err := gzip.NewReader(bytes.NewReader(compressed))
+	require.NoError(t, err)
+	uncompressed, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	require.Equal(t, data, uncompressed)
+
+	_, _, err = compressGzip(filepath.Join(dir, "not-exist"), dst)
+	require.Error(t, err)
+}
+
+func TestCountLayerHistory(t *testing.T) {
+	require.Equal(t, 0, countLayerHistory(nil))
+	require.Equal(t, 2, countLayerHistory([]ocispec.History{
+		{CreatedBy: "ADD rootfs.tar /"},
+		{CreatedBy: "ENV PATH=/bin", EmptyLayer: true},
+		{CreatedBy: "RUN apk add curl"},
+	}))
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	PackageTypeDeb = "deb"
+	PackageTypeApk = "apk"
+)
+
+// Package is an OS package installed in rootfs.
+type Package struct {
+	Type         string
+	Name         string
+	Version      string
+	Architecture string
+	// Distro is the ID in os-release, e.g. "debian" or "alpine".
+	Distro string
+}
+
+// PURL returns the package URL, see https://github.com/package-url/purl-spec.
+func (pkg Package) PURL() string {
+	purl := fmt.Sprintf("pkg:%s/%s/%s@%s", pkg.Type, pkg.Distro, url.PathEscape(pkg.Name), url.PathEscape(pkg.Version))
+	if pkg.Architecture != "" {
+		purl += "?arch=" + url.QueryEscape(pkg.Architecture)
+	}
+	return purl
+}
+
+// OSRelease returns the ID and VERSION_ID in os-release of rootfs.
+func (rootfs *Rootfs) OSRelease() (string, string) {
+	var id, versionID string
+	scanner := bufio.NewScanner(bytes.NewReader(rootfs.metadataFile("/etc/os-release", "/usr/lib/os-release")))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch key {
+		case "ID":
+			id = value
+		case "VERSION_ID":
+			versionID = value
+		}
+	}
+	return id, versionID
+}
+
+// Packages returns the OS packages recorded in the dpkg or apk database
+// of rootfs, sorted by name.
+func (rootfs *Rootfs) Packages() []Package {
+	distro, _ := rootfs.OSRelease()
+
+	var packages []Package
+	if data := rootfs.metadataFile("/var/lib/dpkg/status"); data != nil {
+		packages = append(packages, parseDpkgStatus(data, distro)...)
+	}
+	for name, data := range rootfs.metadata {
+		if strings.HasPrefix(name, dpkgStatusDir) {
+			packages = append(packages, parseDpkgStatus(data, distro)...)
+		}
+	}
+	if data := rootfs.metadataFile("/lib/apk/db/installed", "/usr/lib/apk/db/installed"); data != nil {
+		packages = append(packages, parseApkInstalled(data, distro)...)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].Version < packages[j].Version
+	})
+	return packages
+}
+
+// paragraphs splits the package database into the paragraphs separated
+// by blank lines, each paragraph is a map of fields.
+func paragraphs(data []byte, sep string) []map[string]string {
+	var result []map[string]string
+	fields := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				result = append(result, fields)
+				fields = map[string]string{}
+			}
+			continue
+		}
+		// Skip the continuation lines of multi-line fields.
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, sep); ok {
+			fields[key] = strings.TrimSpace(value)
+		}
+	}
+	if len(fields) > 0 {
+		result = append(result, fields)
+	}
+	return result
+}
+
+func parseDpkgStatus(data []byte, distro string) []Package {
+	if distro == "" {
+		distro = "debian"
+	}
+	var packages []Package
+	for _, fields := range paragraphs(data, ":") {
+		if fields["Package"] == "" {
+			continue
+		}
+		// The status field is absent in the files of distroless images.
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		packages = append(packages, Package{
+			Type:         PackageTypeDeb,
+			Name:         fields["Package"],
+			Version:      fields["Version"],
+			Architecture: fields["Architecture"],
+			Distro:       distro,
+		})
+	}
+	return packages
+}
+
+func parseApkInstalled(data []byte, distro string) []Package {
+	if distro == "" {
+		distro = "alpine"
+	}
+	var packages []Package
+	for _, fields := range paragraphs(data, ":") {
+		if fields["P"] == "" {
+			continue
+		}
+		packages = append(packages, Package{
+			Type:         PackageTypeApk,
+			Name:         fields["P"],
+			Version:      fields["V"],
+			Architecture: fields["A"],
+			Distro:       distro,
+		})
+	}
+	return packages
+}
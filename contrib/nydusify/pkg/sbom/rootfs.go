@@ -0,0 +1,176 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// dpkgStatusDir is used by distroless images to record the installed
+// packages, one file per package.
+const dpkgStatusDir = "/var/lib/dpkg/status.d/"
+
+// The package databases and OS release files are kept in memory to detect
+// the OS packages installed in rootfs.
+var metadataFiles = map[string]bool{
+	"/etc/os-release":           true,
+	"/usr/lib/os-release":       true,
+	"/var/lib/dpkg/status":      true,
+	"/lib/apk/db/installed":     true,
+	"/usr/lib/apk/db/installed": true,
+}
+
+func isMetadataFile(name string) bool {
+	return metadataFiles[name] || strings.HasPrefix(name, dpkgStatusDir)
+}
+
+// File is a regular file in rootfs.
+type File struct {
+	Path   string
+	Size   int64
+	SHA1   string
+	SHA256 string
+}
+
+// Rootfs is the filesystem merged from image layers.
+type Rootfs struct {
+	files    map[string]*File
+	metadata map[string][]byte
+}
+
+func NewRootfs() *Rootfs {
+	return &Rootfs{
+		files:    make(map[string]*File),
+		metadata: make(map[string][]byte),
+	}
+}
+
+// ApplyLayer applies the uncompressed layer tar on top of rootfs, the
+// whiteout files of overlayfs spec are handled.
+func (rootfs *Rootfs) ApplyLayer(reader io.Reader) error {
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "read layer tar")
+		}
+
+		name := path.Clean("/" + hdr.Name)
+		dir, base := path.Split(name)
+		if base == whiteoutOpaque {
+			rootfs.removeChildren(path.Clean(dir))
+			continue
+		}
+		if strings.HasPrefix(base, whiteoutPrefix) {
+			rootfs.remove(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		}
+
+		// The entry in upper layer overrides the lower one, and a non-directory
+		// entry hides all files under the same path in lower layers.
+		rootfs.remove(name)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			file, err := rootfs.addFile(name, tr)
+			if err != nil {
+				return errors.Wrapf(err, "read file %s", name)
+			}
+			file.Size = hdr.Size
+		case tar.TypeLink:
+			target := path.Clean("/" + hdr.Linkname)
+			if file, ok := rootfs.files[target]; ok {
+				link := *file
+				link.Path = name
+				rootfs.files[name] = &link
+			}
+			if data, ok := rootfs.metadata[target]; ok {
+				rootfs.metadata[name] = data
+			}
+		}
+	}
+}
+
+func (rootfs *Rootfs) addFile(name string, reader io.Reader) (*File, error) {
+	sha1Hash := sha1.New()
+	sha256Hash := sha256.New()
+	writers := []io.Writer{sha1Hash, sha256Hash}
+	var metadata bytes.Buffer
+	if isMetadataFile(name) {
+		writers = append(writers, &metadata)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), reader); err != nil {
+		return nil, err
+	}
+	if isMetadataFile(name) {
+		rootfs.metadata[name] = metadata.Bytes()
+	}
+
+	file := &File{
+		Path:   name,
+		SHA1:   hex.EncodeToString(sha1Hash.Sum(nil)),
+		SHA256: hex.EncodeToString(sha256Hash.Sum(nil)),
+	}
+	rootfs.files[name] = file
+	return file, nil
+}
+
+func (rootfs *Rootfs) remove(name string) {
+	delete(rootfs.files, name)
+	delete(rootfs.metadata, name)
+	rootfs.removeChildren(name)
+}
+
+func (rootfs *Rootfs) removeChildren(dir string) {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for name := range rootfs.files {
+		if strings.HasPrefix(name, prefix) {
+			delete(rootfs.files, name)
+		}
+	}
+	for name := range rootfs.metadata {
+		if strings.HasPrefix(name, prefix) {
+			delete(rootfs.metadata, name)
+		}
+	}
+}
+
+// Files returns the regular files sorted by path.
+func (rootfs *Rootfs) Files() []File {
+	files := make([]File, 0, len(rootfs.files))
+	for _, file := range rootfs.files {
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
+
+func (rootfs *Rootfs) metadataFile(names ...string) []byte {
+	for _, name := range names {
+		if data, ok := rootfs.metadata[name]; ok {
+			return data
+		}
+	}
+	return nil
+}
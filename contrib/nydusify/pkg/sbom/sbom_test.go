@@ -0,0 +1,164 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package sbom
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+type tarEntry struct {
+	name     string
+	data     string
+	typeflag byte
+	linkname string
+}
+
+func buildLayer(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{
+			Name:     entry.name,
+			Typeflag: typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+		}
+		if typeflag == tar.TypeReg {
+			hdr.Size = int64(len(entry.data))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		if typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte(entry.data))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	return buf
+}
+
+func filePaths(rootfs *Rootfs) []string {
+	paths := []string{}
+	for _, file := range rootfs.Files() {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func TestApplyLayer(t *testing.T) {
+	rootfs := NewRootfs()
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t,
+		tarEntry{name: "bin/", typeflag: tar.TypeDir},
+		tarEntry{name: "bin/sh", data: "sh"},
+		tarEntry{name: "etc/passwd", data: "root"},
+		tarEntry{name: "opt/app/a", data: "a"},
+		tarEntry{name: "opt/app/b", data: "b"},
+		tarEntry{name: "tmp/file", data: "tmp"},
+	)))
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t,
+		tarEntry{name: "bin/.wh.sh"},
+		tarEntry{name: "bin/bash", data: "bash"},
+		tarEntry{name: "bin/sh", typeflag: tar.TypeLink, linkname: "bin/bash"},
+		tarEntry{name: "etc/passwd", data: "root:x:0:0"},
+		tarEntry{name: "opt/app/.wh..wh..opq"},
+		tarEntry{name: "opt/app/c", data: "c"},
+		tarEntry{name: "tmp", typeflag: tar.TypeSymlink, linkname: "/var/tmp"},
+	)))
+
+	require.Equal(t, []string{"/bin/bash", "/bin/sh", "/etc/passwd", "/opt/app/c"}, filePaths(rootfs))
+	files := rootfs.Files()
+	require.Equal(t, files[0].SHA256, files[1].SHA256)
+	require.Equal(t, digest.FromString("root:x:0:0").Encoded(), files[2].SHA256)
+	require.Equal(t, int64(len("root:x:0:0")), files[2].Size)
+}
+
+func TestPackages(t *testing.T) {
+	rootfs := NewRootfs()
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t,
+		tarEntry{name: "etc/os-release", data: "NAME=\"Debian GNU/Linux\"\nID=debian\nVERSION_ID=\"12\"\n"},
+		tarEntry{name: "var/lib/dpkg/status", data: `Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.36-9+deb12u4
+Description: GNU C Library
+ Contains the standard libraries.
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+
+Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2+b2
+`},
+		tarEntry{name: "var/lib/dpkg/status.d/tzdata", data: "Package: tzdata\nVersion: 2024a-0+deb12u1\nArchitecture: all\n"},
+	)))
+
+	packages := rootfs.Packages()
+	require.Equal(t, []Package{
+		{Type: PackageTypeDeb, Name: "bash", Version: "5.2.15-2+b2", Architecture: "amd64", Distro: "debian"},
+		{Type: PackageTypeDeb, Name: "libc6", Version: "2.36-9+deb12u4", Architecture: "amd64", Distro: "debian"},
+		{Type: PackageTypeDeb, Name: "tzdata", Version: "2024a-0+deb12u1", Architecture: "all", Distro: "debian"},
+	}, packages)
+	require.Equal(t, "pkg:deb/debian/libc6@2.36-9+deb12u4?arch=amd64", packages[1].PURL())
+
+	rootfs = NewRootfs()
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t,
+		tarEntry{name: "etc/os-release", data: "ID=alpine\nVERSION_ID=3.19.1\n"},
+		tarEntry{name: "lib/apk/db/installed", data: "C:Q1abc=\nP:musl\nV:1.2.4_git20230717-r4\nA:x86_64\n\nC:Q1def=\nP:busybox\nV:1.36.1-r15\nA:x86_64\n"},
+	)))
+	id, versionID := rootfs.OSRelease()
+	require.Equal(t, "alpine", id)
+	require.Equal(t, "3.19.1", versionID)
+	require.Equal(t, []Package{
+		{Type: PackageTypeApk, Name: "busybox", Version: "1.36.1-r15", Architecture: "x86_64", Distro: "alpine"},
+		{Type: PackageTypeApk, Name: "musl", Version: "1.2.4_git20230717-r4", Architecture: "x86_64", Distro: "alpine"},
+	}, rootfs.Packages())
+
+	// The package database removed by upper layer.
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t, tarEntry{name: "lib/apk/.wh.db"})))
+	require.Empty(t, rootfs.Packages())
+}
+
+func TestGenerate(t *testing.T) {
+	rootfs := NewRootfs()
+	require.NoError(t, rootfs.ApplyLayer(buildLayer(t,
+		tarEntry{name: "etc/os-release", data: "ID=alpine\n"},
+		tarEntry{name: "lib/apk/db/installed", data: "P:musl\nV:1.2.4-r4\nA:x86_64\n"},
+	)))
+
+	data, err := Generate(FormatSPDX, "example.com/app:latest-nydus", rootfs)
+	require.NoError(t, err)
+	var doc spdxDocument
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	require.Len(t, doc.Packages, 2)
+	require.Equal(t, "pkg:apk/alpine/musl@1.2.4-r4?arch=x86_64", doc.Packages[1].ExternalRefs[0].ReferenceLocator)
+	require.Len(t, doc.Files, 2)
+	require.Len(t, doc.Relationships, 4)
+
+	data, err = Generate(FormatCycloneDX, "example.com/app:latest-nydus", rootfs)
+	require.NoError(t, err)
+	var bom cycloneDXBOM
+	require.NoError(t, json.Unmarshal(data, &bom))
+	require.Equal(t, "CycloneDX", bom.BOMFormat)
+	require.Equal(t, "container", bom.Metadata.Component.Type)
+	require.Len(t, bom.Components, 3)
+	require.Equal(t, "library", bom.Components[0].Type)
+	require.Equal(t, "file", bom.Components[1].Type)
+
+	_, err = Generate("unknown", "example.com/app", rootfs)
+	require.Error(t, err)
+}
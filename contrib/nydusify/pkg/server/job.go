@@ -0,0 +1,154 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxJobLogs is the maximum number of log lines kept for a job, the
+// earlier lines are dropped.
+const maxJobLogs = 1000
+
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCanceled  JobStatus = "canceled"
+)
+
+// JobRequest is the request body to submit a conversion job.
+type JobRequest struct {
+	Source         string `json:"source"`
+	Target         string `json:"target"`
+	SourceInsecure bool   `json:"source_insecure,omitempty"`
+	TargetInsecure bool   `json:"target_insecure,omitempty"`
+	PlainHTTP      bool   `json:"plain_http,omitempty"`
+
+	Platforms     string `json:"platforms,omitempty"`
+	AllPlatforms  bool   `json:"all_platforms,omitempty"`
+	MergePlatform bool   `json:"merge_platform,omitempty"`
+
+	BackendType      string `json:"backend_type,omitempty"`
+	BackendConfig    string `json:"backend_config,omitempty"`
+	BackendForcePush bool   `json:"backend_force_push,omitempty"`
+
+	CacheRef          string `json:"cache_ref,omitempty"`
+	ChunkDict         string `json:"chunk_dict,omitempty"`
+	OCI               bool   `json:"oci,omitempty"`
+	OCIRef            bool   `json:"oci_ref,omitempty"`
+	WithReferrer      bool   `json:"with_referrer,omitempty"`
+	FsVersion         string `json:"fs_version,omitempty"`
+	Compressor        string `json:"compressor,omitempty"`
+	ChunkSize         string `json:"chunk_size,omitempty"`
+	PrefetchPatterns  string `json:"prefetch_patterns,omitempty"`
+	CacheInsecure     bool   `json:"cache_insecure,omitempty"`
+	ChunkDictInsecure bool   `json:"chunk_dict_insecure,omitempty"`
+}
+
+// Job is a conversion job submitted to the server.
+type Job struct {
+	ID         string     `json:"id"`
+	Request    JobRequest `json:"request"`
+	Status     JobStatus  `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	mutex   sync.Mutex
+	cancel  context.CancelFunc
+	logs    []string
+	dropped int
+	updated chan struct{}
+}
+
+func newJob(id string, req JobRequest, cancel context.CancelFunc) *Job {
+	return &Job{
+		ID:        id,
+		Request:   req,
+		Status:    JobPending,
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+		updated:   make(chan struct{}),
+	}
+}
+
+// notify wakes up the log followers, caller must hold the job lock.
+func (job *Job) notify() {
+	close(job.updated)
+	job.updated = make(chan struct{})
+}
+
+func (job *Job) log(format string, args ...interface{}) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	line := fmt.Sprintf("%s %s", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	job.logs = append(job.logs, line)
+	if n := len(job.logs) - maxJobLogs; n > 0 {
+		job.logs = append(job.logs[:0], job.logs[n:]...)
+		job.dropped += n
+	}
+	job.notify()
+}
+
+func (job *Job) setStatus(status JobStatus, err error) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	now := time.Now()
+	switch status {
+	case JobRunning:
+		job.StartedAt = &now
+	case JobSucceeded, JobFailed, JobCanceled:
+		job.FinishedAt = &now
+	}
+	job.Status = status
+	if err != nil {
+		job.Error = err.Error()
+	}
+	job.notify()
+}
+
+func (job *Job) finished() bool {
+	return job.Status == JobSucceeded || job.Status == JobFailed || job.Status == JobCanceled
+}
+
+// snapshot returns a copy of job for JSON encoding.
+func (job *Job) snapshot() *Job {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	return &Job{
+		ID:         job.ID,
+		Request:    job.Request,
+		Status:     job.Status,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// logsFrom returns the log lines from the offset, the offset of next line,
+// whether the job has finished, and a channel closed on the next update of
+// job. The lines already dropped are skipped.
+func (job *Job) logsFrom(offset int) ([]string, int, bool, <-chan struct{}) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	start := offset - job.dropped
+	if start < 0 {
+		start = 0
+	}
+	var lines []string
+	if start < len(job.logs) {
+		lines = append(lines, job.logs[start:]...)
+	}
+	return lines, job.dropped + len(job.logs), job.finished(), job.updated
+}
@@ -0,0 +1,347 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package server runs nydusify as a long-running conversion service,
+// which exposes a REST API to submit conversion jobs, query job status,
+// stream job logs and cancel jobs.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+)
+
+type Opt struct {
+	Address string
+	// Workers is the maximum number of jobs running at the same time.
+	Workers        uint
+	WorkDir        string
+	NydusImagePath string
+	// JobRetention is how long the finished jobs are kept, default 24h.
+	JobRetention time.Duration
+	// MaxFinishedJobs is the maximum number of finished jobs kept, the
+	// earliest finished ones are removed first, default 1000.
+	MaxFinishedJobs uint
+}
+
+type Server struct {
+	opt Opt
+
+	mutex sync.RWMutex
+	jobs  map[string]*Job
+
+	sem     *semaphore.Weighted
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	convert func(ctx context.Context, opts api.ConvertOptions) error
+}
+
+func New(opt Opt) *Server {
+	if opt.Workers == 0 {
+		opt.Workers = 1
+	}
+	if opt.JobRetention == 0 {
+		opt.JobRetention = 24 * time.Hour
+	}
+	if opt.MaxFinishedJobs == 0 {
+		opt.MaxFinishedJobs = 1000
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		opt:     opt,
+		jobs:    map[string]*Job{},
+		sem:     semaphore.NewWeighted(int64(opt.Workers)),
+		ctx:     ctx,
+		cancel:  cancel,
+		convert: api.Convert,
+	}
+}
+
+// Handler returns the HTTP handler of REST API:
+//
+//	POST /api/v1/jobs                submit a conversion job
+//	GET  /api/v1/jobs                list all jobs
+//	GET  /api/v1/jobs/{id}           query job status
+//	GET  /api/v1/jobs/{id}/logs      get job logs, streamed with `?follow=true`
+//	POST /api/v1/jobs/{id}/cancel    cancel a pending or running job
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/jobs", s.submitJob)
+	mux.HandleFunc("GET /api/v1/jobs", s.listJobs)
+	mux.HandleFunc("GET /api/v1/jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /api/v1/jobs/{id}/logs", s.getJobLogs)
+	mux.HandleFunc("POST /api/v1/jobs/{id}/cancel", s.cancelJob)
+	return mux
+}
+
+// Run serves the API until the context is canceled, then all the jobs
+// are canceled and waited to exit.
+func (s *Server) Run(ctx context.Context) error {
+	// The work directory is shared by all jobs, it must be prepared before
+	// any job starts, otherwise the first finished job would remove the
+	// directory still used by the others.
+	if _, err := os.Stat(s.opt.WorkDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if err := os.MkdirAll(s.opt.WorkDir, 0755); err != nil {
+				return errors.Wrap(err, "prepare work directory")
+			}
+			defer os.RemoveAll(s.opt.WorkDir)
+		} else {
+			return errors.Wrap(err, "stat work directory")
+		}
+	}
+
+	httpServer := &http.Server{
+		Addr:    s.opt.Address,
+		Handler: s.Handler(),
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logrus.Infof("conversion service is listening on %s", s.opt.Address)
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		s.shutdown()
+		return errors.Wrap(err, "serve API")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("shutdown API server")
+	}
+	s.shutdown()
+
+	return nil
+}
+
+func (s *Server) shutdown() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Server) submitJob(w http.ResponseWriter, r *http.Request) {
+	var req JobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "decode job request"))
+		return
+	}
+	if req.Source == "" || req.Target == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("both source and target are required"))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	job := newJob(uuid.NewString(), req, cancel)
+
+	s.mutex.Lock()
+	s.pruneJobs()
+	s.jobs[job.ID] = job
+	s.mutex.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		s.runJob(ctx, job)
+	}()
+
+	writeJSON(w, http.StatusAccepted, job.snapshot())
+}
+
+// pruneJobs removes the jobs finished before the retention, and the
+// earliest finished jobs beyond the maximum number, caller must hold the
+// server lock.
+func (s *Server) pruneJobs() {
+	expired := time.Now().Add(-s.opt.JobRetention)
+	var finished []*Job
+	for id, job := range s.jobs {
+		snapshot := job.snapshot()
+		switch {
+		case snapshot.FinishedAt == nil:
+		case snapshot.FinishedAt.Before(expired):
+			delete(s.jobs, id)
+		default:
+			finished = append(finished, snapshot)
+		}
+	}
+	if len(finished) <= int(s.opt.MaxFinishedJobs) {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, job := range finished[:len(finished)-int(s.opt.MaxFinishedJobs)] {
+		delete(s.jobs, job.ID)
+	}
+}
+
+func (s *Server) runJob(ctx context.Context, job *Job) {
+	job.log("job submitted, convert %s to %s", job.Request.Source, job.Request.Target)
+
+	if err := s.sem.Acquire(ctx, 1); err != nil {
+		job.log("job canceled before start")
+		job.setStatus(JobCanceled, err)
+		return
+	}
+	defer s.sem.Release(1)
+
+	job.setStatus(JobRunning, nil)
+	err := s.convert(ctx, s.convertOptions(job))
+	switch {
+	case err == nil:
+		job.setStatus(JobSucceeded, nil)
+	case ctx.Err() != nil:
+		job.log("job canceled")
+		job.setStatus(JobCanceled, err)
+	default:
+		job.log("job failed: %s", err)
+		job.setStatus(JobFailed, err)
+	}
+}
+
+func (s *Server) convertOptions(job *Job) api.ConvertOptions {
+	req := job.Request
+	return api.ConvertOptions{
+		Source:         req.Source,
+		Target:         req.Target,
+		SourceInsecure: req.SourceInsecure,
+		TargetInsecure: req.TargetInsecure,
+		PlainHTTP:      req.PlainHTTP,
+
+		WorkDir:        s.opt.WorkDir,
+		NydusImagePath: s.opt.NydusImagePath,
+
+		Platforms:     req.Platforms,
+		AllPlatforms:  req.AllPlatforms,
+		MergePlatform: req.MergePlatform,
+
+		BackendType:      req.BackendType,
+		BackendConfig:    req.BackendConfig,
+		BackendForcePush: req.BackendForcePush,
+
+		CacheRef:          req.CacheRef,
+		CacheInsecure:     req.CacheInsecure,
+		ChunkDict:         req.ChunkDict,
+		ChunkDictInsecure: req.ChunkDictInsecure,
+
+		Docker2OCI:       req.OCI,
+		OCIRef:           req.OCIRef,
+		WithReferrer:     req.WithReferrer,
+		FsVersion:        req.FsVersion,
+		Compressor:       req.Compressor,
+		ChunkSize:        req.ChunkSize,
+		PrefetchPatterns: req.PrefetchPatterns,
+
+		Progress: func(event api.Event) {
+			if event.Err != nil {
+				job.log("%s %s: %s", event.Message, event.Stage, event.Err)
+			} else {
+				job.log("%s %s", event.Message, event.Stage)
+			}
+		},
+	}
+}
+
+func (s *Server) lookupJob(w http.ResponseWriter, r *http.Request) *Job {
+	id := r.PathValue("id")
+	s.mutex.RLock()
+	job, ok := s.jobs[id]
+	s.mutex.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+		return nil
+	}
+	return job
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, _ *http.Request) {
+	s.mutex.RLock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.snapshot())
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	if job := s.lookupJob(w, r); job != nil {
+		writeJSON(w, http.StatusOK, job.snapshot())
+	}
+}
+
+func (s *Server) cancelJob(w http.ResponseWriter, r *http.Request) {
+	job := s.lookupJob(w, r)
+	if job == nil {
+		return
+	}
+	job.cancel()
+	writeJSON(w, http.StatusOK, job.snapshot())
+}
+
+func (s *Server) getJobLogs(w http.ResponseWriter, r *http.Request) {
+	job := s.lookupJob(w, r)
+	if job == nil {
+		return
+	}
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := w.(http.Flusher)
+	offset := 0
+	for {
+		lines, next, finished, updated := job.logsFrom(offset)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		offset = next
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !follow || finished {
+			return
+		}
+		select {
+		case <-updated:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logrus.WithError(err).Warn("encode response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
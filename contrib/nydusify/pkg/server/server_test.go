@@ -0,0 +1,163 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+)
+
+func submit(t *testing.T, url string, req JobRequest) *Job {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/api/v1/jobs", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var job Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	return &job
+}
+
+func getJob(t *testing.T, url, id string) *Job {
+	resp, err := http.Get(url + "/api/v1/jobs/" + id)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var job Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	return &job
+}
+
+func waitJob(t *testing.T, url, id string, status JobStatus) *Job {
+	var job *Job
+	require.Eventually(t, func() bool {
+		job = getJob(t, url, id)
+		return job.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestServer(t *testing.T) {
+	server := New(Opt{Workers: 1, WorkDir: "/tmp/nydusify", NydusImagePath: "/path/to/nydus-image"})
+	server.convert = func(ctx context.Context, opts api.ConvertOptions) error {
+		require.Equal(t, "/tmp/nydusify", opts.WorkDir)
+		require.Equal(t, "/path/to/nydus-image", opts.NydusImagePath)
+		opts.Progress(api.Event{Stage: api.StageStarted, Message: "convert " + opts.Source})
+		switch opts.Source {
+		case "localhost:5000/block:latest":
+			<-ctx.Done()
+			return ctx.Err()
+		case "localhost:5000/fail:latest":
+			return fmt.Errorf("mock error")
+		}
+		return nil
+	}
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	defer server.shutdown()
+
+	job := submit(t, ts.URL, JobRequest{Source: "localhost:5000/busybox:latest", Target: "localhost:5000/busybox:nydus"})
+	job = waitJob(t, ts.URL, job.ID, JobSucceeded)
+	require.NotNil(t, job.StartedAt)
+	require.NotNil(t, job.FinishedAt)
+
+	resp, err := http.Get(ts.URL + "/api/v1/jobs/" + job.ID + "/logs?follow=true")
+	require.NoError(t, err)
+	logs, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Contains(t, string(logs), "convert localhost:5000/busybox:latest started")
+
+	job = submit(t, ts.URL, JobRequest{Source: "localhost:5000/fail:latest", Target: "localhost:5000/fail:nydus"})
+	job = waitJob(t, ts.URL, job.ID, JobFailed)
+	require.Equal(t, "mock error", job.Error)
+
+	// The blocked job occupies the only worker, so the next job is pending.
+	blocked := submit(t, ts.URL, JobRequest{Source: "localhost:5000/block:latest", Target: "localhost:5000/block:nydus"})
+	waitJob(t, ts.URL, blocked.ID, JobRunning)
+	pending := submit(t, ts.URL, JobRequest{Source: "localhost:5000/busybox:latest", Target: "localhost:5000/busybox:nydus"})
+	require.Equal(t, JobPending, getJob(t, ts.URL, pending.ID).Status)
+
+	resp, err = http.Post(ts.URL+"/api/v1/jobs/"+blocked.ID+"/cancel", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	waitJob(t, ts.URL, blocked.ID, JobCanceled)
+	waitJob(t, ts.URL, pending.ID, JobSucceeded)
+
+	resp, err = http.Get(ts.URL + "/api/v1/jobs")
+	require.NoError(t, err)
+	var jobs []Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jobs))
+	resp.Body.Close()
+	require.Len(t, jobs, 4)
+	require.Equal(t, "localhost:5000/busybox:latest", jobs[0].Request.Source)
+
+	resp, err = http.Get(ts.URL + "/api/v1/jobs/not-exist")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(ts.URL+"/api/v1/jobs", "application/json", strings.NewReader(`{"source": "localhost:5000/busybox:latest"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestPruneJobs(t *testing.T) {
+	server := New(Opt{JobRetention: time.Hour, MaxFinishedJobs: 2})
+	now := time.Now()
+	for idx, finishedAt := range []time.Duration{0, -2 * time.Hour, -3 * time.Minute, -2 * time.Minute, -time.Minute} {
+		job := newJob(fmt.Sprintf("job-%d", idx), JobRequest{}, func() {})
+		if finishedAt != 0 {
+			job.Status = JobSucceeded
+			at := now.Add(finishedAt)
+			job.FinishedAt = &at
+		}
+		server.jobs[job.ID] = job
+	}
+	server.pruneJobs()
+
+	// The running job is kept, the expired job and the earliest finished
+	// job beyond the maximum number are removed.
+	var ids []string
+	for id := range server.jobs {
+		ids = append(ids, id)
+	}
+	require.ElementsMatch(t, []string{"job-0", "job-3", "job-4"}, ids)
+}
+
+func TestJobLogs(t *testing.T) {
+	job := newJob("job", JobRequest{}, func() {})
+	for idx := 0; idx < maxJobLogs+10; idx++ {
+		job.log("line %d", idx)
+	}
+	lines, next, finished, _ := job.logsFrom(0)
+	require.Len(t, lines, maxJobLogs)
+	require.Contains(t, lines[0], "line 10")
+	require.Equal(t, maxJobLogs+10, next)
+	require.False(t, finished)
+
+	job.log("last line")
+	lines, next, _, _ = job.logsFrom(next)
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], "last line")
+	require.Equal(t, maxJobLogs+11, next)
+}
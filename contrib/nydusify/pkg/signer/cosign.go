@@ -0,0 +1,121 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signer signs and verifies images by calling the cosign CLI.
+package signer
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+type SignOption struct {
+	// Ref is the image reference to sign, it should be pinned by digest.
+	Ref string
+	// Key is the private key path or KMS URI, the image is signed in
+	// keyless mode if it's empty.
+	Key string
+	// Insecure skips verifying server certs for HTTPS registry.
+	Insecure bool
+	// PlainHTTP allows to access the registry by plain http.
+	PlainHTTP bool
+}
+
+type VerifyOption struct {
+	Ref string
+	// Key is the public key path or KMS URI, the signature is verified
+	// against the certificate identity if it's empty.
+	Key                   string
+	CertificateIdentity   string
+	CertificateOIDCIssuer string
+	Insecure              bool
+	PlainHTTP             bool
+}
+
+type Cosign struct {
+	binaryPath string
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+func NewCosign(binaryPath string) *Cosign {
+	return &Cosign{
+		binaryPath: binaryPath,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+	}
+}
+
+func registryArgs(insecure, plainHTTP bool) []string {
+	var args []string
+	if insecure {
+		args = append(args, "--allow-insecure-registry")
+	}
+	if plainHTTP {
+		args = append(args, "--allow-http-registry")
+	}
+	return args
+}
+
+func signArgs(option SignOption) []string {
+	args := []string{"sign", "--yes"}
+	if option.Key != "" {
+		args = append(args, "--key", option.Key)
+	}
+	args = append(args, registryArgs(option.Insecure, option.PlainHTTP)...)
+	return append(args, option.Ref)
+}
+
+func verifyArgs(option VerifyOption) ([]string, error) {
+	args := []string{"verify"}
+	if option.Key != "" {
+		args = append(args, "--key", option.Key)
+	} else {
+		if option.CertificateIdentity == "" || option.CertificateOIDCIssuer == "" {
+			return nil, errors.New("certificate identity and OIDC issuer are required to verify keyless signature")
+		}
+		args = append(args,
+			"--certificate-identity", option.CertificateIdentity,
+			"--certificate-oidc-issuer", option.CertificateOIDCIssuer,
+		)
+	}
+	args = append(args, registryArgs(option.Insecure, option.PlainHTTP)...)
+	return append(args, option.Ref), nil
+}
+
+func (cosign *Cosign) run(args []string) error {
+	logrus.Debugf("\tCommand: %s %s", cosign.binaryPath, strings.Join(args, " "))
+
+	cmd := exec.Command(cosign.binaryPath, args...)
+	cmd.Stdout = cosign.stdout
+	cmd.Stderr = cosign.stderr
+
+	if err := cmd.Run(); err != nil {
+		logrus.WithError(err).Errorf("fail to run %v %+v", cosign.binaryPath, args)
+		return err
+	}
+
+	return nil
+}
+
+// Sign calls `cosign sign` to sign the image and push the signature to
+// the registry, the private key password is read from the environment
+// variable `COSIGN_PASSWORD`.
+func (cosign *Cosign) Sign(option SignOption) error {
+	return cosign.run(signArgs(option))
+}
+
+// Verify calls `cosign verify` to verify the signature of the image.
+func (cosign *Cosign) Verify(option VerifyOption) error {
+	args, err := verifyArgs(option)
+	if err != nil {
+		return err
+	}
+	return cosign.run(args)
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRef = "localhost:5000/busybox@sha256:6e2b3a6c5f2c5c6b2e4b7d5f0c8f2a7e3d2b5a6c7d8e9f0a1b2c3d4e5f6a7b8c"
+
+func TestSignArgs(t *testing.T) {
+	require.Equal(t, []string{"sign", "--yes", "--key", "cosign.key", testRef}, signArgs(SignOption{
+		Ref: testRef,
+		Key: "cosign.key",
+	}))
+	require.Equal(t, []string{"sign", "--yes", "--allow-insecure-registry", "--allow-http-registry", testRef}, signArgs(SignOption{
+		Ref:       testRef,
+		Insecure:  true,
+		PlainHTTP: true,
+	}))
+}
+
+func TestVerifyArgs(t *testing.T) {
+	args, err := verifyArgs(VerifyOption{Ref: testRef, Key: "cosign.pub"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"verify", "--key", "cosign.pub", testRef}, args)
+
+	args, err = verifyArgs(VerifyOption{
+		Ref:                   testRef,
+		CertificateIdentity:   "ci@example.com",
+		CertificateOIDCIssuer: "https://token.actions.githubusercontent.com",
+		PlainHTTP:             true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"verify",
+		"--certificate-identity", "ci@example.com",
+		"--certificate-oidc-issuer", "https://token.actions.githubusercontent.com",
+		"--allow-http-registry",
+		testRef,
+	}, args)
+
+	_, err = verifyArgs(VerifyOption{Ref: testRef, CertificateIdentity: "ci@example.com"})
+	require.Error(t, err)
+}
+
+func TestCosignRun(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "cosign")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+
+	var stdout bytes.Buffer
+	cosign := NewCosign(binary)
+	cosign.stdout = &stdout
+	require.NoError(t, cosign.Sign(SignOption{Ref: testRef, Key: "cosign.key"}))
+	require.Equal(t, "sign --yes --key cosign.key "+testRef+"\n", stdout.String())
+
+	require.Error(t, cosign.Verify(VerifyOption{Ref: testRef}))
+	require.Error(t, NewCosign(filepath.Join(t.TempDir(), "not-exist")).Sign(SignOption{Ref: testRef}))
+}
@@ -20,8 +20,11 @@ const (
 	LayerAnnotationNydusFsVersion     = "containerd.io/snapshot/nydus-fs-version"
 	LayerAnnotationNydusSourceChainID = "containerd.io/snapshot/nydus-source-chainid"
 	LayerAnnotationNydusArtifactType  = "containerd.io/snapshot/nydus-artifact-type"
+	LayerAnnotationNydusSourceDigest  = "containerd.io/snapshot/nydus-source-digest"
+	LayerAnnotationNydusTargetDigest  = "containerd.io/snapshot/nydus-target-digest"
 
 	LayerAnnotationNydusReferenceBlobIDs = "containerd.io/snapshot/nydus-reference-blob-ids"
+	LayerAnnotationNydusIPFSCID          = "containerd.io/snapshot/nydus-ipfs-cid"
 
 	LayerAnnotationUncompressed = "containerd.io/uncompressed"
 
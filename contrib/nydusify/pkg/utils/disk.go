@@ -0,0 +1,132 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// ErrQuotaExceeded is returned if the usage of work directory exceeds the
+// quota specified by user.
+var ErrQuotaExceeded = errors.New("work directory quota exceeded")
+
+// quotaCheckInterval is the interval to check the usage of work directory.
+const quotaCheckInterval = time.Second
+
+// DiskFree returns the bytes available to unprivileged users in the
+// filesystem of path.
+func DiskFree(path string) (int64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, errors.Wrapf(err, "statfs %s", path)
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// DirSize returns the total size of the regular files under dir, the files
+// removed during walking are ignored.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// CheckSpace checks if the required bytes can be written into dir, within
+// the free space of its filesystem and the quota of dir, zero quota means
+// unlimited. The error of insufficient quota is ErrQuotaExceeded.
+func CheckSpace(dir string, required, quota int64) error {
+	free, err := DiskFree(dir)
+	if err != nil {
+		return err
+	}
+	if required > free {
+		return errors.Errorf("need about %s in work directory %s, but only %s is available",
+			humanize.IBytes(uint64(required)), dir, humanize.IBytes(uint64(free)))
+	}
+	if quota <= 0 {
+		return nil
+	}
+	used, err := DirSize(dir)
+	if err != nil {
+		return errors.Wrapf(err, "get usage of %s", dir)
+	}
+	if used+required > quota {
+		return errors.Wrapf(ErrQuotaExceeded, "need about %s in work directory %s with %s used, exceeding the quota %s",
+			humanize.IBytes(uint64(required)), dir, humanize.IBytes(uint64(used)), humanize.IBytes(uint64(quota)))
+	}
+	return nil
+}
+
+// WatchQuota returns the context canceled with ErrQuotaExceeded once the
+// usage of dir exceeds quota, the cause can be got by context.Cause. The
+// returned function stops watching, the context isn't watched if quota is
+// zero.
+func WatchQuota(ctx context.Context, dir string, quota int64) (context.Context, context.CancelFunc) {
+	return watchQuota(ctx, dir, quota, quotaCheckInterval)
+}
+
+func watchQuota(ctx context.Context, dir string, quota int64, interval time.Duration) (context.Context, context.CancelFunc) {
+	if quota <= 0 {
+		return context.WithCancel(ctx)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				used, err := DirSize(dir)
+				if err == nil && used > quota {
+					cancel(errors.Wrapf(ErrQuotaExceeded, "work directory %s uses %s, exceeding the quota %s",
+						dir, humanize.IBytes(uint64(used)), humanize.IBytes(uint64(quota))))
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// QuotaError returns the error of exceeding quota if ctx returned by
+// WatchQuota is canceled by it, otherwise err is returned.
+func QuotaError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); errors.Is(cause, ErrQuotaExceeded) {
+		return cause
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSpace(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "content"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "content", "blob"), make([]byte, 3000), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bootstrap"), make([]byte, 1000), 0644))
+
+	size, err := DirSize(dir)
+	require.NoError(t, err)
+	require.Equal(t, int64(4000), size)
+
+	free, err := DiskFree(dir)
+	require.NoError(t, err)
+	require.Greater(t, free, int64(0))
+
+	require.NoError(t, CheckSpace(dir, 1000, 0))
+	require.NoError(t, CheckSpace(dir, 1000, 5000))
+	err = CheckSpace(dir, 1001, 5000)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.Contains(t, err.Error(), "exceeding the quota")
+
+	err = CheckSpace(dir, free+1<<40, 0)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrQuotaExceeded)
+}
+
+func TestWatchQuota(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, stop := watchQuota(context.Background(), dir, 1000, 10*time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blob"), make([]byte, 2000), 0644))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("quota exceeding isn't detected")
+	}
+	stop()
+	err := QuotaError(ctx, errors.New("context canceled"))
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	require.Contains(t, err.Error(), dir)
+
+	// The error isn't replaced if the quota isn't exceeded.
+	ctx, stop = watchQuota(context.Background(), dir, 1<<30, 10*time.Millisecond)
+	stop()
+	require.EqualError(t, QuotaError(ctx, errors.New("pull failed")), "pull failed")
+	require.NoError(t, QuotaError(ctx, nil))
+
+	ctx, stop = WatchQuota(context.Background(), dir, 0)
+	defer stop()
+	require.NoError(t, ctx.Err())
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RangeFetcher fetches the range [offset, offset+size) of a blob.
+type RangeFetcher func(ctx context.Context, offset, size int64) (io.ReadCloser, error)
+
+type readChunk struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// ParallelReaderAt reads a blob by the concurrent range requests in chunk
+// size, the chunks following the read offset are fetched ahead, so that the
+// sequential reading of a large blob is not bound to a single stream. At most
+// concurrency chunks are buffered in memory.
+type ParallelReaderAt struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	fetch       RangeFetcher
+	size        int64
+	chunkSize   int64
+	concurrency int
+
+	mutex  sync.Mutex
+	chunks map[int64]*readChunk
+}
+
+// NewParallelReaderAt creates a reader of the blob in size fetched by fetch.
+func NewParallelReaderAt(ctx context.Context, fetch RangeFetcher, size, chunkSize int64, concurrency int) *ParallelReaderAt {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &ParallelReaderAt{
+		ctx:         ctx,
+		cancel:      cancel,
+		fetch:       fetch,
+		size:        size,
+		chunkSize:   chunkSize,
+		concurrency: concurrency,
+		chunks:      map[int64]*readChunk{},
+	}
+}
+
+func (r *ParallelReaderAt) fetchChunk(idx int64, chunk *readChunk) {
+	defer close(chunk.done)
+
+	offset := idx * r.chunkSize
+	size := r.chunkSize
+	if offset+size > r.size {
+		size = r.size - offset
+	}
+	rc, err := r.fetch(r.ctx, offset, size)
+	if err != nil {
+		chunk.err = errors.Wrapf(err, "fetch range %d-%d", offset, offset+size-1)
+		return
+	}
+	defer rc.Close()
+	chunk.data = make([]byte, size)
+	if _, err := io.ReadFull(rc, chunk.data); err != nil {
+		chunk.err = errors.Wrapf(err, "read range %d-%d", offset, offset+size-1)
+	}
+}
+
+// chunk returns the chunk idx, and schedules fetching the following chunks
+// ahead, the chunks before idx are released.
+func (r *ParallelReaderAt) chunk(idx int64) *readChunk {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i := range r.chunks {
+		if i < idx {
+			delete(r.chunks, i)
+		}
+	}
+	for i := idx; i < idx+int64(r.concurrency) && i*r.chunkSize < r.size; i++ {
+		if _, ok := r.chunks[i]; !ok {
+			chunk := &readChunk{done: make(chan struct{})}
+			r.chunks[i] = chunk
+			go r.fetchChunk(i, chunk)
+		}
+	}
+	return r.chunks[idx]
+}
+
+func (r *ParallelReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	n := 0
+	for n < len(p) && off < r.size {
+		idx := off / r.chunkSize
+		chunk := r.chunk(idx)
+		select {
+		case <-chunk.done:
+		case <-r.ctx.Done():
+			return n, r.ctx.Err()
+		}
+		if chunk.err != nil {
+			return n, chunk.err
+		}
+		copied := copy(p[n:], chunk.data[off-idx*r.chunkSize:])
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *ParallelReaderAt) Size() int64 {
+	return r.size
+}
+
+// Close cancels the fetching chunks.
+func (r *ParallelReaderAt) Close() error {
+	r.cancel()
+	return nil
+}
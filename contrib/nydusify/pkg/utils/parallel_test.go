@@ -0,0 +1,51 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelReaderAt(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	var requests atomic.Int32
+	fetch := func(_ context.Context, offset, size int64) (io.ReadCloser, error) {
+		requests.Add(1)
+		return io.NopCloser(bytes.NewReader(data[offset : offset+size])), nil
+	}
+
+	reader := NewParallelReaderAt(context.Background(), fetch, int64(len(data)), 1024, 4)
+	defer reader.Close()
+	require.Equal(t, int64(len(data)), reader.Size())
+
+	read, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+	require.Equal(t, int32(10), requests.Load())
+
+	buf := make([]byte, 100)
+	n, err := reader.ReadAt(buf, 5000)
+	require.NoError(t, err)
+	require.Equal(t, 100, n)
+	require.Equal(t, data[5000:5100], buf)
+
+	n, err = reader.ReadAt(buf, int64(len(data))-10)
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 10, n)
+
+	failed := NewParallelReaderAt(context.Background(), func(_ context.Context, _, _ int64) (io.ReadCloser, error) {
+		return nil, errors.New("unavailable")
+	}, 100, 10, 2)
+	defer failed.Close()
+	_, err = failed.ReadAt(buf, 0)
+	require.ErrorContains(t, err, "fetch range 0-9: unavailable")
+}
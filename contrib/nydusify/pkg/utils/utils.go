@@ -18,7 +18,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteserrors "github.com/containerd/containerd/v2/core/remotes/errors"
 	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	containerdErrdefs "github.com/containerd/errdefs"
 	"github.com/goharbor/acceleration-service/pkg/errdefs"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -62,6 +65,12 @@ func GetNydusFsVersionOrDefault(annotations map[string]string, defaultVersion Fs
 // If retryCount is 0, it will use the default value of 3.
 // If retryDelay is 0, it will use the default value of 5 seconds.
 func WithRetry(f func() error, retryCount int, retryDelay time.Duration) error {
+	return WithRetryIf(f, retryCount, retryDelay, RetryWithHTTP)
+}
+
+// WithRetryIf is WithRetry retrying the function only on the errors checked
+// by retryable.
+func WithRetryIf(f func() error, retryCount int, retryDelay time.Duration, retryable func(error) bool) error {
 	const (
 		defaultRetryCount = 3
 		defaultRetryDelay = 5 * time.Second
@@ -77,7 +86,7 @@ func WithRetry(f func() error, retryCount int, retryDelay time.Duration) error {
 	var lastErr error
 	for i := 0; i < retryCount; i++ {
 		if lastErr != nil {
-			if !RetryWithHTTP(lastErr) {
+			if !retryable(lastErr) {
 				return lastErr
 			}
 			logrus.WithError(lastErr).
@@ -139,6 +148,15 @@ func RetryWithHTTP(err error) bool {
 		errdefs.NeedsRetryWithHTTP(err)
 }
 
+// IsUnauthorized checks if the error is caused by the registry rejecting the
+// token or credential, which may be expired during a long-running operation.
+func IsUnauthorized(err error) bool {
+	var statusErr remoteserrors.ErrUnexpectedStatus
+	return containerdErrdefs.IsUnauthorized(err) ||
+		errors.Is(err, docker.ErrInvalidAuthorization) ||
+		(errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusUnauthorized)
+}
+
 func MarshalToDesc(data interface{}, mediaType string) (*ocispec.Descriptor, []byte, error) {
 	bytes, err := json.Marshal(data)
 	if err != nil {
@@ -18,6 +18,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteserrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	containerdErrdefs "github.com/containerd/errdefs"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
@@ -255,6 +258,39 @@ func TestRetryWithHTTP(t *testing.T) {
 	require.False(t, RetryWithHTTP(nil))
 }
 
+func TestIsUnauthorized(t *testing.T) {
+	require.True(t, IsUnauthorized(errors.Wrap(containerdErrdefs.ErrUnauthenticated, "push blob")))
+	require.True(t, IsUnauthorized(fmt.Errorf("server message: invalid_token: %w", docker.ErrInvalidAuthorization)))
+	require.True(t, IsUnauthorized(errors.Wrap(remoteserrors.ErrUnexpectedStatus{Status: "401 Unauthorized", StatusCode: http.StatusUnauthorized}, "push manifest")))
+	require.False(t, IsUnauthorized(remoteserrors.ErrUnexpectedStatus{Status: "403 Forbidden", StatusCode: http.StatusForbidden}))
+	// The message of error isn't matched.
+	require.False(t, IsUnauthorized(fmt.Errorf("read config: 401 Unauthorized")))
+	require.False(t, IsUnauthorized(nil))
+}
+
+func TestWithRetryIf(t *testing.T) {
+	unauthorized := errors.Wrap(containerdErrdefs.ErrUnauthenticated, "push blob")
+	attempts := 0
+	err := WithRetryIf(func() error {
+		attempts++
+		if attempts < 3 {
+			return unauthorized
+		}
+		return nil
+	}, 3, time.Millisecond, IsUnauthorized)
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	// The unauthorized error isn't retried by WithRetry.
+	attempts = 0
+	err = WithRetry(func() error {
+		attempts++
+		return unauthorized
+	}, 3, time.Millisecond)
+	require.ErrorIs(t, err, containerdErrdefs.ErrUnauthenticated)
+	require.Equal(t, 1, attempts)
+}
+
 func TestGetNydusFsVersionOrDefault(t *testing.T) {
 	testAnnotations := make(map[string]string)
 	fsVersion := GetNydusFsVersionOrDefault(testAnnotations, V5)
@@ -0,0 +1,197 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package viewer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"syscall"
+
+	modelspec "github.com/CloudNativeAI/model-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/checker/tool"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/parser"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/provider"
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+// Image is the Nydus image to be mounted by MultiViewer.
+type Image struct {
+	Target        string
+	BackendType   string
+	BackendConfig string
+}
+
+// MultiViewer mounts multiple Nydus images under the subdirectories of
+// mount path by a shared nydusd daemon, the images share the blob cache,
+// so that the blobs deduplicated between images are cached only once.
+type MultiViewer struct {
+	Opt
+	Images []Image
+}
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// imageName returns the name of subdirectory to mount the image, which is
+// the reference with the special characters replaced, e.g.
+// "docker.io_library_nginx_latest".
+func imageName(target string) string {
+	return invalidNameChars.ReplaceAllString(target, "_")
+}
+
+// NewMulti creates MultiViewer instance, only FUSE mode is supported.
+func NewMulti(opt Opt, images []Image) (*MultiViewer, error) {
+	if len(images) == 0 {
+		return nil, errors.New("missing target image reference")
+	}
+	if opt.Writable || (opt.Mode != "" && opt.Mode != ModeFuse) {
+		return nil, errors.New("only read-only FUSE mode is supported to mount multiple images")
+	}
+	names := map[string]string{}
+	for _, image := range images {
+		name := imageName(image.Target)
+		if exist, ok := names[name]; ok {
+			return nil, errors.Errorf("images %s and %s are mounted to the same directory %s", exist, image.Target, name)
+		}
+		names[name] = image.Target
+	}
+	return &MultiViewer{
+		Opt:    opt,
+		Images: images,
+	}, nil
+}
+
+// prepare pulls the bootstrap and creates nydusd config of the image to
+// the subdirectory of working directory.
+func (viewer *MultiViewer) prepare(ctx context.Context, image Image) (*FsViewer, error) {
+	name := imageName(image.Target)
+	opt := viewer.Opt
+	opt.Target = image.Target
+	opt.BackendType = image.BackendType
+	opt.BackendConfig = image.BackendConfig
+	opt.WorkDir = filepath.Join(viewer.WorkDir, "images", name)
+
+	targetRemote, err := provider.DefaultRemote(image.Target, opt.TargetInsecure)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image provider")
+	}
+	targetParser, err := parser.New(targetRemote, opt.ExpectedArch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create image reference parser")
+	}
+	targetParsed, err := targetParser.Parse(ctx)
+	if err != nil && utils.RetryWithHTTP(err) {
+		targetParser.Remote.MaybeWithHTTP(err)
+		targetParsed, err = targetParser.Parse(ctx)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse image reference")
+	}
+	if targetParsed.NydusImage == nil {
+		return nil, errors.New("not a Nydus image")
+	}
+	if targetParsed.NydusImage.Manifest.ArtifactType == modelspec.ArtifactTypeModelManifest {
+		return nil, errors.New("model artifact is not supported to mount with other images")
+	}
+
+	fsViewer := &FsViewer{
+		Opt:    opt,
+		Parser: targetParser,
+		NydusdConfig: tool.NydusdConfig{
+			NydusdPath:     opt.NydusdPath,
+			EnablePrefetch: opt.Prefetch,
+			BackendType:    opt.BackendType,
+			BackendConfig:  opt.BackendConfig,
+			BootstrapPath:  filepath.Join(opt.WorkDir, "nydus_bootstrap"),
+			ConfigPath:     filepath.Join(opt.WorkDir, "nydusd_config.json"),
+			// The blob cache is shared by all the images.
+			BlobCacheDir: filepath.Join(viewer.WorkDir, "fs/nydus_blobs"),
+			Mode:         "direct",
+		},
+	}
+	if err := fsViewer.PullBootstrap(ctx, targetParsed); err != nil {
+		return nil, errors.Wrap(err, "failed to pull Nydus image bootstrap")
+	}
+
+	nydusManifest := parser.FindNydusBootstrapDesc(&targetParsed.NydusImage.Manifest)
+	if nydusManifest != nil && utils.GetNydusFsVersionOrDefault(nydusManifest.Annotations, utils.V5) == utils.V5 {
+		fsViewer.NydusdConfig.DigestValidate = true
+	}
+	// Write the config of nydusd to mount the image.
+	if _, err := tool.NewNydusd(fsViewer.NydusdConfig); err != nil {
+		return nil, errors.Wrap(err, "can't create Nydusd config")
+	}
+
+	return fsViewer, nil
+}
+
+// View mounts all the images under the mount path, and blocks until
+// SIGINT/SIGTERM is received.
+func (viewer *MultiViewer) View(ctx context.Context) error {
+	if err := os.RemoveAll(viewer.WorkDir); err != nil {
+		return errors.Wrap(err, "failed to clean up working directory")
+	}
+
+	var fsViewers []*FsViewer
+	for _, image := range viewer.Images {
+		logrus.Infof("Preparing Nydus image %s", image.Target)
+		fsViewer, err := viewer.prepare(ctx, image)
+		if err != nil {
+			return errors.Wrapf(err, "prepare image %s", image.Target)
+		}
+		fsViewers = append(fsViewers, fsViewer)
+	}
+
+	shared := &FsViewer{
+		Opt: viewer.Opt,
+		NydusdConfig: tool.NydusdConfig{
+			NydusdPath:    viewer.NydusdPath,
+			ConfigPath:    filepath.Join(viewer.WorkDir, "fs/nydusd_config.json"),
+			BlobCacheDir:  filepath.Join(viewer.WorkDir, "fs/nydus_blobs"),
+			MountPath:     viewer.MountPath,
+			APISockPath:   filepath.Join(viewer.WorkDir, "fs/nydus_api.sock"),
+			Mode:          "direct",
+			Shared:        true,
+			BackendType:   viewer.BackendType,
+			BackendConfig: viewer.BackendConfig,
+		},
+	}
+	if err := os.MkdirAll(filepath.Join(viewer.WorkDir, "fs"), 0750); err != nil {
+		return errors.Wrap(err, "can't create working directory")
+	}
+	if err := shared.MountImage(); err != nil {
+		return err
+	}
+
+	for _, fsViewer := range fsViewers {
+		mountpoint := fmt.Sprintf("/%s", imageName(fsViewer.Target))
+		logrus.Infof("Mounting Nydus image %s to %s", fsViewer.Target, filepath.Join(viewer.MountPath, mountpoint))
+		if err := shared.nydusd.MountRafs(mountpoint, fsViewer.NydusdConfig.BootstrapPath, fsViewer.NydusdConfig.ConfigPath); err != nil {
+			if umountErr := shared.nydusd.Umount(false); umountErr != nil {
+				logrus.WithError(umountErr).Warn("failed to umount Nydus images")
+			}
+			return errors.Wrapf(err, "mount image %s", fsViewer.Target)
+		}
+	}
+
+	// Block current goroutine in order to umount the file system and clean up workdir
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	logrus.Infof("Please send signal SIGINT/SIGTERM to umount the file system")
+	sig := <-sigs
+	logrus.Infof("Received Signal: %s", sig)
+
+	if err := os.RemoveAll(viewer.WorkDir); err != nil {
+		return errors.Wrap(err, "failed to clean up working directory")
+	}
+
+	return nil
+}
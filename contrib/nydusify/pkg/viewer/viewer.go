@@ -3,12 +3,14 @@ package viewer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
 	"syscall"
 
+	"github.com/containerd/containerd/v2/core/mount"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
@@ -27,6 +29,16 @@ func prettyDump(obj interface{}, name string) error {
 	return os.WriteFile(name, bytes, 0644)
 }
 
+const (
+	// ModeFuse mounts the image by nydusd through FUSE.
+	ModeFuse = "fuse"
+	// ModeBlock exports the image as an EROFS block device by nydusd
+	// through NBD, and mounts the block device.
+	ModeBlock = "block"
+
+	DefaultNBDDevice = "/dev/nbd0"
+)
+
 // Opt defines fsViewer options, Target is the Nydus image reference
 type Opt struct {
 	WorkDir        string
@@ -40,6 +52,12 @@ type Opt struct {
 	ExpectedArch  string
 	FsVersion     string
 	Prefetch      bool
+	// Writable stacks an overlayfs upper directory on the mounted image to
+	// allow modifications, which are discarded on umount.
+	Writable bool
+	// Mode is ModeFuse by default, the NBDDevice is used for ModeBlock.
+	Mode      string
+	NBDDevice string
 }
 
 // fsViewer provides complete view of file system in nydus image
@@ -47,6 +65,7 @@ type FsViewer struct {
 	Opt
 	Parser       *parser.Parser
 	NydusdConfig tool.NydusdConfig
+	nydusd       *tool.Nydusd
 }
 
 // New creates fsViewer instance, Target is the Nydus image reference
@@ -54,6 +73,17 @@ func New(opt Opt) (*FsViewer, error) {
 	if opt.Target == "" {
 		return nil, errors.Errorf("missing target image reference, please add option '--target reference'")
 	}
+	switch opt.Mode {
+	case "":
+		opt.Mode = ModeFuse
+	case ModeFuse:
+	case ModeBlock:
+		if opt.NBDDevice == "" {
+			opt.NBDDevice = DefaultNBDDevice
+		}
+	default:
+		return nil, errors.Errorf("unsupported mount mode %s, possible values: '%s', '%s'", opt.Mode, ModeFuse, ModeBlock)
+	}
 	targetRemote, err := provider.DefaultRemote(opt.Target, opt.TargetInsecure)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create image provider")
@@ -148,10 +178,64 @@ func (fsViewer *FsViewer) MountImage() error {
 	if err := nydusd.Mount(); err != nil {
 		return errors.Wrap(err, "failed to mount Nydus image")
 	}
+	fsViewer.nydusd = nydusd
+
+	return nil
+}
+
+// overlayMounts returns the overlay mount stacking the writable upper
+// directory on the read-only Nydus image.
+func overlayMounts(lowerDir, upperDir, workDir string) []mount.Mount {
+	return []mount.Mount{
+		{
+			Type:   "overlay",
+			Source: "overlay",
+			Options: []string{
+				fmt.Sprintf("lowerdir=%s", lowerDir),
+				fmt.Sprintf("upperdir=%s", upperDir),
+				fmt.Sprintf("workdir=%s", workDir),
+			},
+		},
+	}
+}
+
+// MountOverlay mounts the overlayfs on the mount path, the Nydus image
+// mounted by nydusd is the lower directory.
+func (fsViewer *FsViewer) MountOverlay() error {
+	upperDir := filepath.Join(fsViewer.WorkDir, "fs/upper")
+	workDir := filepath.Join(fsViewer.WorkDir, "fs/work")
+	for _, dir := range []string{upperDir, workDir, fsViewer.MountPath} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return errors.Wrapf(err, "can't create directory %s", dir)
+		}
+	}
+
+	logrus.Infof("Mounting writable overlay of Nydus image to %s", fsViewer.MountPath)
+	if err := mount.All(overlayMounts(fsViewer.NydusdConfig.MountPath, upperDir, workDir), fsViewer.MountPath); err != nil {
+		return errors.Wrap(err, "failed to mount overlay")
+	}
 
 	return nil
 }
 
+// umount umounts the overlay and Nydus image for writable mount, so that
+// the upper directory can be cleaned up with working directory, and stops
+// nydusd serving the block device. The FUSE mount is umounted by nydusd
+// itself on signal.
+func (fsViewer *FsViewer) umount() error {
+	if fsViewer.Writable {
+		if err := mount.Unmount(fsViewer.MountPath, 0); err != nil {
+			return errors.Wrap(err, "failed to umount overlay")
+		}
+	}
+	if fsViewer.nydusd != nil && (fsViewer.Writable || fsViewer.Mode == ModeBlock) {
+		if err := fsViewer.nydusd.Umount(false); err != nil {
+			return errors.Wrap(err, "failed to umount Nydus image")
+		}
+	}
+	return nil
+}
+
 // View provides the structure of the file system in target nydus image
 // It includes two steps, pull the boostrap of the image, and mount the
 // image under specified path.
@@ -187,6 +271,13 @@ func (fsViewer *FsViewer) view(ctx context.Context) error {
 		APISockPath:    filepath.Join(fsViewer.Opt.WorkDir, "fs/nydus_api.sock"),
 		Mode:           "direct",
 	}
+	if fsViewer.Opt.Writable {
+		// The image is mounted by nydusd as the lower directory of overlay.
+		nydusdConfig.MountPath = filepath.Join(fsViewer.Opt.WorkDir, "fs/lower")
+	}
+	if fsViewer.Opt.Mode == ModeBlock {
+		nydusdConfig.NBDDevice = fsViewer.Opt.NBDDevice
+	}
 	if isModelArtifact {
 		nydusdConfig.ExternalBackendConfigPath = filepath.Join(fsViewer.Opt.WorkDir, "fs/nydusd_backend.json")
 	}
@@ -205,6 +296,9 @@ func (fsViewer *FsViewer) view(ctx context.Context) error {
 	nydusManifest := parser.FindNydusBootstrapDesc(&targetParsed.NydusImage.Manifest)
 	if nydusManifest != nil {
 		v := utils.GetNydusFsVersionOrDefault(nydusManifest.Annotations, utils.V5)
+		if v == utils.V5 && fsViewer.Mode == ModeBlock {
+			return errors.New("block mode requires RAFS v6 image")
+		}
 		if v == utils.V5 {
 			// Digest validate is not currently supported for v6,
 			// but v5 supports it. In order to make the check more sufficient,
@@ -217,6 +311,14 @@ func (fsViewer *FsViewer) view(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if fsViewer.Writable {
+		if err := fsViewer.MountOverlay(); err != nil {
+			if umountErr := fsViewer.nydusd.Umount(false); umountErr != nil {
+				logrus.WithError(umountErr).Warn("failed to umount Nydus image")
+			}
+			return err
+		}
+	}
 
 	// Block current goroutine in order to umount the file system and clean up workdir
 	sigs := make(chan os.Signal, 1)
@@ -231,6 +333,9 @@ func (fsViewer *FsViewer) view(ctx context.Context) error {
 
 	logrus.Infof("Please send signal SIGINT/SIGTERM to umount the file system")
 	<-done
+	if err := fsViewer.umount(); err != nil {
+		return err
+	}
 	if err := os.RemoveAll(fsViewer.WorkDir); err != nil {
 		return errors.Wrap(err, "failed to clean up working directory")
 	}
@@ -153,3 +153,38 @@ func TestHandleExternalBackendConfig(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestOverlayMounts(t *testing.T) {
+	mounts := overlayMounts("/work/fs/lower", "/work/fs/upper", "/work/fs/work")
+	require.Len(t, mounts, 1)
+	require.Equal(t, "overlay", mounts[0].Type)
+	require.Equal(t, []string{
+		"lowerdir=/work/fs/lower",
+		"upperdir=/work/fs/upper",
+		"workdir=/work/fs/work",
+	}, mounts[0].Options)
+}
+
+func TestUmountReadOnly(t *testing.T) {
+	fsViewer := FsViewer{Opt: Opt{MountPath: "/not-mounted"}}
+	require.NoError(t, fsViewer.umount())
+}
+
+func TestNewFsViewerMode(t *testing.T) {
+	_, err := New(Opt{Target: "test", Mode: "virtiofs"})
+	require.ErrorContains(t, err, "unsupported mount mode virtiofs")
+}
+
+func TestNewMulti(t *testing.T) {
+	images := []Image{{Target: "docker.io/library/nginx:latest"}, {Target: "docker.io/library/nginx@sha256:abc"}}
+	multiViewer, err := NewMulti(Opt{}, images)
+	require.NoError(t, err)
+	require.Len(t, multiViewer.Images, 2)
+	require.Equal(t, "docker.io_library_nginx_latest", imageName(images[0].Target))
+	require.Equal(t, "docker.io_library_nginx_sha256_abc", imageName(images[1].Target))
+
+	_, err = NewMulti(Opt{}, []Image{{Target: "nginx:latest"}, {Target: "nginx/latest"}})
+	require.ErrorContains(t, err, "are mounted to the same directory nginx_latest")
+	_, err = NewMulti(Opt{Mode: ModeBlock}, images)
+	require.ErrorContains(t, err, "only read-only FUSE mode is supported")
+}
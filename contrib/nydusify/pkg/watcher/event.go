@@ -0,0 +1,142 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// PushEvent is an image pushed to registry.
+type PushEvent struct {
+	// Namespace is the repository path without image name, for example
+	// "library" for "docker.io/library/busybox".
+	Namespace string
+	// Reference is the full image reference with tag.
+	Reference string
+	Tag       string
+}
+
+// See https://goharbor.io/docs/main/working-with-projects/project-configuration/configure-webhooks/
+type harborPayload struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Resources []struct {
+			Tag         string `json:"tag"`
+			ResourceURL string `json:"resource_url"`
+		} `json:"resources"`
+		Repository struct {
+			Namespace string `json:"namespace"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+// See https://distribution.github.io/distribution/about/notifications/
+type registryPayload struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			MediaType  string `json:"mediaType"`
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+var manifestMediaTypes = map[string]bool{
+	"application/vnd.oci.image.manifest.v1+json":                true,
+	"application/vnd.oci.image.index.v1+json":                   true,
+	"application/vnd.docker.distribution.manifest.v2+json":      true,
+	"application/vnd.docker.distribution.manifest.list.v2+json": true,
+}
+
+// ParseEvents parses the image push events from the webhook payload of
+// Harbor or Docker Registry v2 notifications, other events are ignored.
+func ParseEvents(data []byte) ([]PushEvent, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, errors.Wrap(err, "unmarshal webhook payload")
+	}
+
+	if _, ok := probe["events"]; ok {
+		return parseRegistryEvents(data)
+	}
+	if _, ok := probe["event_data"]; ok {
+		return parseHarborEvents(data)
+	}
+
+	return nil, fmt.Errorf("unknown webhook payload")
+}
+
+func parseHarborEvents(data []byte) ([]PushEvent, error) {
+	var payload harborPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.Wrap(err, "unmarshal harbor payload")
+	}
+	if payload.Type != "PUSH_ARTIFACT" {
+		return nil, nil
+	}
+
+	var events []PushEvent
+	for _, resource := range payload.EventData.Resources {
+		if resource.Tag == "" {
+			continue
+		}
+		events = append(events, PushEvent{
+			Namespace: payload.EventData.Repository.Namespace,
+			Reference: resource.ResourceURL,
+			Tag:       resource.Tag,
+		})
+	}
+	return events, nil
+}
+
+func parseRegistryEvents(data []byte) ([]PushEvent, error) {
+	var payload registryPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, errors.Wrap(err, "unmarshal registry payload")
+	}
+
+	var events []PushEvent
+	for _, event := range payload.Events {
+		target := event.Target
+		if event.Action != "push" || target.Tag == "" || !manifestMediaTypes[target.MediaType] {
+			continue
+		}
+		namespace := path.Dir(target.Repository)
+		if namespace == "." {
+			namespace = ""
+		}
+		events = append(events, PushEvent{
+			Namespace: namespace,
+			Reference: fmt.Sprintf("%s/%s:%s", event.Request.Host, target.Repository, target.Tag),
+			Tag:       target.Tag,
+		})
+	}
+	return events, nil
+}
+
+// targetReference generates the target reference by adding a suffix to
+// the tag of source reference.
+func targetReference(source, suffix string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(source)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image reference %s", source)
+	}
+	if _, ok := named.(reference.Tagged); !ok {
+		return "", fmt.Errorf("image reference %s has no tag", source)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return "", fmt.Errorf("image reference %s is pinned by digest", source)
+	}
+	return named.String() + suffix, nil
+}
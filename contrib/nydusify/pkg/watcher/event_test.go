@@ -0,0 +1,118 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHarborEvents(t *testing.T) {
+	events, err := ParseEvents([]byte(`
+	{
+		"type": "PUSH_ARTIFACT",
+		"occur_at": 1680501893,
+		"operator": "admin",
+		"event_data": {
+			"resources": [
+				{
+					"digest": "sha256:954b378c375d852eb3c63ab88978f640b4348b01c1b3456a024a81536dafbbf4",
+					"tag": "latest",
+					"resource_url": "harbor.example.com/library/busybox:latest"
+				}
+			],
+			"repository": {
+				"name": "busybox",
+				"namespace": "library",
+				"repo_full_name": "library/busybox",
+				"repo_type": "public"
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []PushEvent{{
+		Namespace: "library",
+		Reference: "harbor.example.com/library/busybox:latest",
+		Tag:       "latest",
+	}}, events)
+
+	events, err = ParseEvents([]byte(`{"type": "DELETE_ARTIFACT", "event_data": {}}`))
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestParseRegistryEvents(t *testing.T) {
+	events, err := ParseEvents([]byte(`
+	{
+		"events": [
+			{
+				"action": "push",
+				"target": {
+					"mediaType": "application/vnd.oci.image.manifest.v1+json",
+					"repository": "team/app/web",
+					"tag": "v1"
+				},
+				"request": {"host": "registry.example.com:5000"}
+			},
+			{
+				"action": "push",
+				"target": {
+					"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+					"repository": "team/app/web"
+				},
+				"request": {"host": "registry.example.com:5000"}
+			},
+			{
+				"action": "pull",
+				"target": {
+					"mediaType": "application/vnd.oci.image.manifest.v1+json",
+					"repository": "busybox",
+					"tag": "latest"
+				},
+				"request": {"host": "registry.example.com:5000"}
+			},
+			{
+				"action": "push",
+				"target": {
+					"mediaType": "application/vnd.docker.distribution.manifest.list.v2+json",
+					"repository": "busybox",
+					"tag": "latest"
+				},
+				"request": {"host": "registry.example.com:5000"}
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []PushEvent{{
+		Namespace: "team/app",
+		Reference: "registry.example.com:5000/team/app/web:v1",
+		Tag:       "v1",
+	}, {
+		Namespace: "",
+		Reference: "registry.example.com:5000/busybox:latest",
+		Tag:       "latest",
+	}}, events)
+
+	_, err = ParseEvents([]byte(`{"foo": "bar"}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown webhook payload")
+
+	_, err = ParseEvents([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestTargetReference(t *testing.T) {
+	target, err := targetReference("registry.example.com:5000/busybox:latest", "-nydus")
+	require.NoError(t, err)
+	require.Equal(t, "registry.example.com:5000/busybox:latest-nydus", target)
+
+	target, err = targetReference("busybox:latest", "-nydus")
+	require.NoError(t, err)
+	require.Equal(t, "docker.io/library/busybox:latest-nydus", target)
+
+	_, err = targetReference("busybox", "-nydus")
+	require.Error(t, err)
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package watcher receives the webhook notifications of image push from
+// Harbor or Docker Registry v2, and converts the pushed images to Nydus
+// images automatically.
+package watcher
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/semaphore"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+)
+
+// maxPayloadSize limits the size of webhook payload.
+const maxPayloadSize = 4 * 1024 * 1024
+
+type Opt struct {
+	Address string
+	// Path is the URL path to receive webhook notifications.
+	Path string
+	// Secret is compared with the `Authorization` header of webhook
+	// request if specified.
+	Secret string
+	// TargetSuffix is appended to the tag of source image to generate the
+	// target image, the pushed images with this suffix are ignored.
+	TargetSuffix string
+	// Namespaces are the glob patterns of repository namespaces to be
+	// converted, all namespaces are converted if empty.
+	Namespaces []string
+	// Workers is the maximum number of conversions running at the same time.
+	Workers uint
+
+	// ConvertOptions is the template of conversion options, the source
+	// and target are filled by the webhook event.
+	ConvertOptions api.ConvertOptions
+}
+
+type Watcher struct {
+	opt Opt
+
+	mutex sync.Mutex
+	// converting records the target images under conversion, the value
+	// is set if the source image is pushed again during conversion, then
+	// the image will be converted again after the current conversion.
+	converting map[string]bool
+
+	sem     *semaphore.Weighted
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	convert func(ctx context.Context, opts api.ConvertOptions) error
+}
+
+func New(opt Opt) (*Watcher, error) {
+	if opt.TargetSuffix == "" {
+		return nil, fmt.Errorf("target suffix is required to avoid converting the converted images again")
+	}
+	for _, pattern := range opt.Namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid namespace pattern %s", pattern)
+		}
+	}
+	if opt.Path == "" {
+		opt.Path = "/webhook"
+	} else if !strings.HasPrefix(opt.Path, "/") {
+		return nil, fmt.Errorf("webhook path %s should start with '/'", opt.Path)
+	}
+	if opt.Workers == 0 {
+		opt.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		opt:        opt,
+		converting: map[string]bool{},
+		sem:        semaphore.NewWeighted(int64(opt.Workers)),
+		ctx:        ctx,
+		cancel:     cancel,
+		convert:    api.Convert,
+	}, nil
+}
+
+// Handler returns the HTTP handler receiving webhook notifications.
+func (w *Watcher) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+w.opt.Path, w.handleWebhook)
+	return mux
+}
+
+// Run receives webhook notifications until the context is canceled, then
+// all the conversions are canceled and waited to exit.
+func (w *Watcher) Run(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:    w.opt.Address,
+		Handler: w.Handler(),
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logrus.Infof("watching webhook notifications on %s%s", w.opt.Address, w.opt.Path)
+		errChan <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errChan:
+		w.shutdown()
+		return errors.Wrap(err, "serve webhook")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("shutdown webhook server")
+	}
+	w.shutdown()
+
+	return nil
+}
+
+func (w *Watcher) shutdown() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *Watcher) handleWebhook(rw http.ResponseWriter, r *http.Request) {
+	if w.opt.Secret != "" &&
+		subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(w.opt.Secret)) != 1 {
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(rw, errors.Wrap(err, "read payload").Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := ParseEvents(data)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range events {
+		w.handleEvent(event)
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// match checks if the pushed image should be converted.
+func (w *Watcher) match(event PushEvent) bool {
+	if strings.HasSuffix(event.Tag, w.opt.TargetSuffix) {
+		return false
+	}
+	if len(w.opt.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range w.opt.Namespaces {
+		if matched, _ := path.Match(pattern, event.Namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) handleEvent(event PushEvent) {
+	if !w.match(event) {
+		logrus.Debugf("skip pushed image %s", event.Reference)
+		return
+	}
+	target, err := targetReference(event.Reference, w.opt.TargetSuffix)
+	if err != nil {
+		logrus.WithError(err).Warnf("skip pushed image %s", event.Reference)
+		return
+	}
+
+	w.mutex.Lock()
+	if _, ok := w.converting[target]; ok {
+		w.converting[target] = true
+		w.mutex.Unlock()
+		logrus.Infof("pushed image %s is under conversion, will convert it again later", event.Reference)
+		return
+	}
+	w.converting[target] = false
+	w.mutex.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for {
+			w.run(event.Reference, target)
+
+			w.mutex.Lock()
+			again := w.converting[target] && w.ctx.Err() == nil
+			if again {
+				w.converting[target] = false
+			} else {
+				delete(w.converting, target)
+			}
+			w.mutex.Unlock()
+			if !again {
+				return
+			}
+		}
+	}()
+}
+
+func (w *Watcher) run(source, target string) {
+	if err := w.sem.Acquire(w.ctx, 1); err != nil {
+		return
+	}
+	defer w.sem.Release(1)
+
+	opts := w.opt.ConvertOptions
+	opts.Source = source
+	opts.Target = target
+
+	logrus.Infof("converting pushed image %s to %s", source, target)
+	if err := w.convert(w.ctx, opts); err != nil {
+		logrus.WithError(err).Errorf("convert pushed image %s", source)
+		return
+	}
+	logrus.Infof("converted pushed image %s to %s", source, target)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package watcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/api"
+)
+
+func harborPush(namespace, resourceURL, tag string) string {
+	return `{"type": "PUSH_ARTIFACT", "event_data": {"resources": [{"tag": "` + tag +
+		`", "resource_url": "` + resourceURL + `"}], "repository": {"namespace": "` + namespace + `"}}}`
+}
+
+func TestWatcher(t *testing.T) {
+	watcher, err := New(Opt{
+		Secret:       "token",
+		TargetSuffix: "-nydus",
+		Namespaces:   []string{"library", "team/*"},
+		Workers:      2,
+		ConvertOptions: api.ConvertOptions{
+			FsVersion: "5",
+		},
+	})
+	require.NoError(t, err)
+
+	var mutex sync.Mutex
+	converted := map[string]api.ConvertOptions{}
+	watcher.convert = func(_ context.Context, opts api.ConvertOptions) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		converted[opts.Source] = opts
+		return nil
+	}
+
+	server := httptest.NewServer(watcher.Handler())
+	defer server.Close()
+
+	post := func(secret, payload string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/webhook", strings.NewReader(payload))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", secret)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, post("wrong", harborPush("library", "harbor.example.com/library/busybox:latest", "latest")))
+	require.Equal(t, http.StatusBadRequest, post("token", "not json"))
+
+	// Matched namespace.
+	require.Equal(t, http.StatusOK, post("token", harborPush("library", "harbor.example.com/library/busybox:latest", "latest")))
+	require.Equal(t, http.StatusOK, post("token", harborPush("team/app", "harbor.example.com/team/app/web:v1", "v1")))
+	// Converted image is ignored.
+	require.Equal(t, http.StatusOK, post("token", harborPush("library", "harbor.example.com/library/busybox:latest-nydus", "latest-nydus")))
+	// Unmatched namespace.
+	require.Equal(t, http.StatusOK, post("token", harborPush("other", "harbor.example.com/other/busybox:latest", "latest")))
+
+	watcher.shutdown()
+
+	require.Len(t, converted, 2)
+	opts := converted["harbor.example.com/library/busybox:latest"]
+	require.Equal(t, "harbor.example.com/library/busybox:latest-nydus", opts.Target)
+	require.Equal(t, "5", opts.FsVersion)
+	require.Equal(t, "harbor.example.com/team/app/web:v1-nydus", converted["harbor.example.com/team/app/web:v1"].Target)
+}
+
+func TestWatcherPushedDuringConversion(t *testing.T) {
+	watcher, err := New(Opt{TargetSuffix: "-nydus"})
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	count := 0
+	watcher.convert = func(_ context.Context, _ api.ConvertOptions) error {
+		mutex.Lock()
+		count++
+		first := count == 1
+		mutex.Unlock()
+		if first {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+
+	event := PushEvent{Reference: "registry.example.com/busybox:latest", Tag: "latest"}
+	watcher.handleEvent(event)
+	<-started
+	// Pushed twice during conversion, only converted once again.
+	watcher.handleEvent(event)
+	watcher.handleEvent(event)
+	close(release)
+
+	require.Eventually(t, func() bool {
+		watcher.mutex.Lock()
+		defer watcher.mutex.Unlock()
+		return len(watcher.converting) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	watcher.shutdown()
+	require.Equal(t, 2, count)
+}
+
+func TestNewWatcher(t *testing.T) {
+	_, err := New(Opt{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "target suffix is required")
+
+	_, err = New(Opt{TargetSuffix: "-nydus", Namespaces: []string{"["}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid namespace pattern")
+}
@@ -0,0 +1,231 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package workdir manages the lifecycle of the temporary directories created
+// by nydusify in work directory: whether they are kept after the command,
+// and the collection of stale ones left behind by crashed runs.
+package workdir
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/dragonflyoss/nydus/contrib/nydusify/pkg/utils"
+)
+
+const (
+	// KeepNever removes the temporary directory after the command.
+	KeepNever = "never"
+	// KeepOnFailure keeps the temporary directory if the command fails,
+	// for debugging or resuming the command.
+	KeepOnFailure = "on-failure"
+	// KeepAlways keeps the temporary directory after the command.
+	KeepAlways = "always"
+)
+
+// Prefix is the name prefix of the temporary directories created by
+// nydusify in work directory.
+const Prefix = "nydusify-"
+
+// lockName is the file locked by the process using temporary directory.
+const lockName = ".lock"
+
+// ValidatePolicy checks the policy to keep temporary directory, empty
+// policy means the default of command.
+func ValidatePolicy(policy string) error {
+	switch policy {
+	case "", KeepNever, KeepOnFailure, KeepAlways:
+		return nil
+	}
+	return errors.Errorf("invalid work directory keep policy %q, possible values: %s, %s, %s",
+		policy, KeepNever, KeepOnFailure, KeepAlways)
+}
+
+// Keep reports whether the temporary directory should be kept after the
+// command returned err, by policy or defaultPolicy if policy is empty.
+func Keep(policy, defaultPolicy string, err error) bool {
+	if policy == "" {
+		policy = defaultPolicy
+	}
+	switch policy {
+	case KeepAlways:
+		return true
+	case KeepOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// Lock marks the temporary directory dir in use until the returned function
+// is called, so that it isn't collected by Clean however long the command
+// runs. The lock is shared by the processes using the same directory, and
+// released by the kernel if the process crashes.
+func Lock(dir string) (func(), error) {
+	file, err := os.OpenFile(filepath.Join(dir, lockName), os.O_RDONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "create lock file")
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_SH); err != nil {
+		file.Close()
+		return nil, errors.Wrapf(err, "lock %s", dir)
+	}
+	return func() {
+		file.Close()
+	}, nil
+}
+
+// inUse checks if dir is locked by a running process.
+func inUse(dir string) (bool, error) {
+	file, err := os.Open(filepath.Join(dir, lockName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// lastModified returns the latest modification time of the files in dir.
+func lastModified(dir string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// Opt defines the options of cleaning work directory.
+type Opt struct {
+	WorkDir string
+	// TTL protects the temporary directories modified recently from
+	// deletion.
+	TTL    time.Duration
+	DryRun bool
+}
+
+// Dir is the stale temporary directory in work directory.
+type Dir struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	// Deleted is false in dry-run mode.
+	Deleted bool `json:"deleted"`
+}
+
+// Report is the result of cleaning work directory.
+type Report struct {
+	Total     int   `json:"total"`
+	InUse     int   `json:"in_use"`
+	Stale     []Dir `json:"stale"`
+	Deleted   int   `json:"deleted"`
+	FreedSize int64 `json:"freed_size"`
+}
+
+// Clean removes the temporary directories in work directory which aren't
+// in use and not modified within TTL.
+func Clean(opt Opt) (*Report, error) {
+	return clean(opt, time.Now())
+}
+
+func clean(opt Opt, now time.Time) (*Report, error) {
+	entries, err := os.ReadDir(opt.WorkDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read work directory")
+	}
+
+	report := &Report{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), Prefix) {
+			continue
+		}
+		report.Total++
+		dir := filepath.Join(opt.WorkDir, entry.Name())
+
+		used, err := inUse(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "check lock of %s", dir)
+		}
+		if used {
+			report.InUse++
+			continue
+		}
+		modified, err := lastModified(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get modification time of %s", dir)
+		}
+		if now.Sub(modified) < opt.TTL {
+			continue
+		}
+		size, err := utils.DirSize(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get size of %s", dir)
+		}
+
+		stale := Dir{Path: dir, Size: size, LastModified: modified}
+		if !opt.DryRun {
+			if err := os.RemoveAll(dir); err != nil {
+				return nil, errors.Wrapf(err, "remove %s", dir)
+			}
+			logrus.Infof("removed stale work directory %s", dir)
+			stale.Deleted = true
+			report.Deleted++
+			report.FreedSize += size
+		}
+		report.Stale = append(report.Stale, stale)
+	}
+	sort.Slice(report.Stale, func(i, j int) bool {
+		return report.Stale[i].LastModified.Before(report.Stale[j].LastModified)
+	})
+
+	return report, nil
+}
+
+// PrintReport writes the stale directories and the summary of report.
+func PrintReport(writer io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tSIZE\tLAST MODIFIED\tDELETED")
+	for _, dir := range report.Stale {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%t\n", dir.Path, dir.Size, dir.LastModified.Format(time.RFC3339), dir.Deleted)
+	}
+	fmt.Fprintf(
+		tw, "\n%d of %d directories are stale, %d in use, %d deleted (%d bytes freed)\n",
+		len(report.Stale), report.Total, report.InUse, report.Deleted, report.FreedSize,
+	)
+	return tw.Flush()
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Nydus Developers. All rights reserved.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package workdir
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeep(t *testing.T) {
+	failure := errors.New("push failed")
+	require.True(t, Keep("", KeepOnFailure, failure))
+	require.False(t, Keep("", KeepOnFailure, nil))
+	require.False(t, Keep("", KeepNever, failure))
+	require.False(t, Keep(KeepNever, KeepOnFailure, failure))
+	require.True(t, Keep(KeepAlways, KeepNever, nil))
+
+	require.NoError(t, ValidatePolicy(""))
+	require.NoError(t, ValidatePolicy(KeepOnFailure))
+	require.Error(t, ValidatePolicy("sometimes"))
+}
+
+func TestClean(t *testing.T) {
+	workDir := t.TempDir()
+	now := time.Now()
+	mkdir := func(name string, modified time.Time) string {
+		dir := filepath.Join(workDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "content"), 0755))
+		file := filepath.Join(dir, "content", "blob")
+		require.NoError(t, os.WriteFile(file, make([]byte, 10), 0644))
+		for _, path := range []string{file, filepath.Join(dir, "content"), dir} {
+			require.NoError(t, os.Chtimes(path, modified, modified))
+		}
+		return dir
+	}
+	stale := mkdir("nydusify-stale", now.Add(-48*time.Hour))
+	fresh := mkdir("nydusify-fresh", now.Add(-time.Hour))
+	locked := mkdir("nydusify-locked", now.Add(-48*time.Hour))
+	other := mkdir("user-data", now.Add(-48*time.Hour))
+
+	unlock, err := Lock(locked)
+	require.NoError(t, err)
+	defer unlock()
+	for _, path := range []string{filepath.Join(locked, lockName), locked} {
+		require.NoError(t, os.Chtimes(path, now.Add(-48*time.Hour), now.Add(-48*time.Hour)))
+	}
+
+	report, err := clean(Opt{WorkDir: workDir, TTL: 24 * time.Hour, DryRun: true}, now)
+	require.NoError(t, err)
+	require.Equal(t, 3, report.Total)
+	require.Equal(t, 1, report.InUse)
+	require.Len(t, report.Stale, 1)
+	require.Equal(t, stale, report.Stale[0].Path)
+	require.False(t, report.Stale[0].Deleted)
+	require.DirExists(t, stale)
+
+	report, err = clean(Opt{WorkDir: workDir, TTL: 24 * time.Hour}, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Deleted)
+	require.Equal(t, int64(10), report.FreedSize)
+	require.NoDirExists(t, stale)
+	require.DirExists(t, fresh)
+	require.DirExists(t, locked)
+	require.DirExists(t, other)
+
+	var buf bytes.Buffer
+	require.NoError(t, PrintReport(&buf, report))
+	require.Contains(t, buf.String(), "1 of 3 directories are stale, 1 in use, 1 deleted (10 bytes freed)")
+
+	// The directory is collected once the command using it exits.
+	unlock()
+	report, err = clean(Opt{WorkDir: workDir, TTL: 24 * time.Hour}, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Deleted)
+	require.NoDirExists(t, locked)
+}
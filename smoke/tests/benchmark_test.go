@@ -120,7 +120,13 @@ func (b *BenchmarkTestSuite) prepareImage(t *testing.T, ctx *tool.Context, image
 		t.Fatalf("can't read convert metric file")
 		return 0, 0
 	}
-	var convertMetric map[string]int64
+	// The metric of format version 2 keeps the top-level fields of version
+	// 1, besides the nested size analysis.
+	var convertMetric struct {
+		SourceImageSize   int64
+		TargetImageSize   int64
+		ConversionElapsed int64
+	}
 	err = json.Unmarshal(metricData, &convertMetric)
 	if err != nil {
 		t.Fatalf("can't parsing convert metric file")
@@ -128,10 +134,10 @@ func (b *BenchmarkTestSuite) prepareImage(t *testing.T, ctx *tool.Context, image
 	}
 	if b.snapshotter == "nydus" {
 		b.testImage = target
-		return convertMetric["TargetImageSize"], convertMetric["ConversionElapsed"]
+		return convertMetric.TargetImageSize, convertMetric.ConversionElapsed
 	}
 	b.testImage = source
-	return convertMetric["SourceImageSize"], 0
+	return convertMetric.SourceImageSize, 0
 }
 
 func (b *BenchmarkTestSuite) dumpMetric() {